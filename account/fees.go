@@ -0,0 +1,113 @@
+package account
+
+import (
+	"cex-price-diff-notifications/shared"
+	"context"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+// FeeFetcher retrieves the fee rates of one exchange account on one market type.
+type FeeFetcher func(ctx context.Context) (shared.FeeRates, error)
+
+// feeSource is how the fee rates of one exchange are fetched.
+type feeSource struct {
+	perp FeeFetcher
+	spot FeeFetcher
+}
+
+// Fees caches the fee rates of the accounts' tiers per exchange, so spreads are evaluated with the fees
+// actually paid rather than the configured defaults.
+type Fees struct {
+	mu      sync.RWMutex
+	perp    map[string]shared.FeeRates
+	spot    map[string]shared.FeeRates
+	sources map[string]feeSource
+}
+
+// NewFees creates an empty fee rates cache.
+func NewFees() *Fees {
+	return &Fees{
+		perp:    make(map[string]shared.FeeRates),
+		spot:    make(map[string]shared.FeeRates),
+		sources: make(map[string]feeSource),
+	}
+}
+
+// Register sets the fee rates sources of an exchange (e.g., "Binance").
+func (f *Fees) Register(exchange string, perp, spot FeeFetcher) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sources[exchange] = feeSource{perp: perp, spot: spot}
+}
+
+// TakerFeesBps returns the perpetual taker fees of configured, in basis points per exchange, with the fetched
+// ones in their place.
+func (f *Fees) TakerFeesBps(configured map[string]float64) map[string]float64 {
+	return f.merge(configured, f.perp)
+}
+
+// SpotTakerFeesBps returns the spot taker fees of configured, in basis points per exchange, with the fetched
+// ones in their place.
+func (f *Fees) SpotTakerFeesBps(configured map[string]float64) map[string]float64 {
+	return f.merge(configured, f.spot)
+}
+
+func (f *Fees) merge(configured map[string]float64, fetched map[string]shared.FeeRates) map[string]float64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	fees := maps.Clone(configured)
+	if fees == nil {
+		fees = make(map[string]float64, len(fetched))
+	}
+	for exchange, rates := range fetched {
+		fees[exchange] = rates.TakerBps
+	}
+	return fees
+}
+
+// Refresh re-fetches the fee rates of all registered exchanges. Rates that fail to refresh keep their
+// previous value, or the configured one if never fetched.
+func (f *Fees) Refresh(ctx context.Context) {
+	f.mu.RLock()
+	sources := maps.Clone(f.sources)
+	f.mu.RUnlock()
+
+	for exchange, src := range sources {
+		f.refresh(ctx, exchange, "perp", src.perp, f.perp)
+		f.refresh(ctx, exchange, "spot", src.spot, f.spot)
+	}
+}
+
+func (f *Fees) refresh(ctx context.Context, exchange, market string, fetch FeeFetcher, rates map[string]shared.FeeRates) {
+	if fetch == nil {
+		return
+	}
+	r, err := fetch(ctx)
+	if err != nil {
+		slog.Error("Failed to refresh account fee rates, keeping the previous ones", "exchange", exchange, "market", market, "error", err)
+		return
+	}
+	f.mu.Lock()
+	rates[exchange] = r
+	f.mu.Unlock()
+	slog.Info("Account fee rates refreshed", "exchange", exchange, "market", market, "maker_bps", r.MakerBps, "taker_bps", r.TakerBps)
+}
+
+// Run refreshes the fee rates immediately and then every interval. It blocks until ctx is cancelled and should
+// be run in a goroutine.
+func (f *Fees) Run(ctx context.Context, interval time.Duration) {
+	f.Refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.Refresh(ctx)
+		}
+	}
+}
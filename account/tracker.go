@@ -0,0 +1,138 @@
+// Package account polls the balances and open positions of the exchange futures accounts through their
+// private endpoints, so opportunities can be checked against the margin actually available, and the fee
+// tiers of the accounts, so they are priced with the fees actually paid.
+package account
+
+import (
+	"cex-price-diff-notifications/metrics"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BalanceFetcher retrieves the balances of one exchange's futures account, keyed by asset.
+type BalanceFetcher func(ctx context.Context) (map[string]shared.Balance, error)
+
+// PositionFetcher retrieves the open positions of one exchange's futures account.
+type PositionFetcher func(ctx context.Context) ([]shared.Position, error)
+
+// source is how the account of one exchange is fetched.
+type source struct {
+	balances  BalanceFetcher
+	positions PositionFetcher
+}
+
+// Snapshot is the state of every tracked account.
+type Snapshot struct {
+	Balances  map[string]map[string]shared.Balance `json:"balances"`  // exchange -> asset -> balance
+	Positions map[string][]shared.Position         `json:"positions"` // exchange -> open positions
+	UpdatedAt map[string]time.Time                 `json:"updated_at"`
+}
+
+// Tracker caches account balances and positions per exchange.
+type Tracker struct {
+	mu        sync.RWMutex
+	balances  map[string]map[string]shared.Balance
+	positions map[string][]shared.Position
+	updatedAt map[string]time.Time
+	sources   map[string]source
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		balances:  make(map[string]map[string]shared.Balance),
+		positions: make(map[string][]shared.Position),
+		updatedAt: make(map[string]time.Time),
+		sources:   make(map[string]source),
+	}
+}
+
+// Register sets the account sources of an exchange (e.g., "Binance").
+func (t *Tracker) Register(exchange string, balances BalanceFetcher, positions PositionFetcher) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sources[exchange] = source{balances: balances, positions: positions}
+}
+
+// Available returns the margin available in an asset on an exchange. It reports false until the exchange's
+// balances were fetched; an asset without balance is then available at 0.
+func (t *Tracker) Available(exchange, asset string) (float64, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	balances, ok := t.balances[exchange]
+	if !ok {
+		return 0, false
+	}
+	return balances[asset].Available, true
+}
+
+// Snapshot returns a copy of the tracked accounts.
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s := Snapshot{
+		Balances:  make(map[string]map[string]shared.Balance, len(t.balances)),
+		Positions: make(map[string][]shared.Position, len(t.positions)),
+		UpdatedAt: maps.Clone(t.updatedAt),
+	}
+	for exchange, balances := range t.balances {
+		s.Balances[exchange] = maps.Clone(balances)
+	}
+	for exchange, positions := range t.positions {
+		s.Positions[exchange] = slices.Clone(positions)
+	}
+	return s
+}
+
+// Refresh re-fetches the accounts of all registered exchanges. Exchanges that fail keep their previous state.
+func (t *Tracker) Refresh(ctx context.Context) {
+	t.mu.RLock()
+	sources := maps.Clone(t.sources)
+	t.mu.RUnlock()
+
+	for exchange, src := range sources {
+		balances, err := src.balances(ctx)
+		if err != nil {
+			slog.Error("Failed to refresh account balances", "exchange", exchange, "error", err)
+			continue
+		}
+		positions, err := src.positions(ctx)
+		if err != nil {
+			slog.Error("Failed to refresh account positions", "exchange", exchange, "error", err)
+			continue
+		}
+		t.mu.Lock()
+		t.balances[exchange] = balances
+		t.positions[exchange] = positions
+		t.updatedAt[exchange] = time.Now()
+		t.mu.Unlock()
+
+		metrics.AvailableBalance.DeletePartialMatch(map[string]string{"exchange": exchange})
+		for asset, b := range balances {
+			metrics.AvailableBalance.WithLabelValues(exchange, asset).Set(b.Available)
+		}
+		metrics.OpenPositions.WithLabelValues(exchange).Set(float64(len(positions)))
+		slog.Debug("Account refreshed", "exchange", exchange, "assets", len(balances), "positions", len(positions))
+	}
+}
+
+// Run refreshes the accounts immediately and then every interval. It blocks until ctx is cancelled and should
+// be run in a goroutine.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	t.Refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Refresh(ctx)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package adapters
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cex-price-diff-notifications/shared"
+)
+
+const (
+	binanceBalancePath      = "/fapi/v2/balance"
+	binancePositionRiskPath = "/fapi/v2/positionRisk"
+	mexcAssetsPath          = "/api/v1/private/account/assets"
+	mexcOpenPositionsPath   = "/api/v1/private/position/open_positions"
+)
+
+// GetBalances fetches the margin balances of the Binance futures account, keyed by asset. It requires API
+// credentials.
+func (a *BinanceAdapter) GetBalances(ctx context.Context) (map[string]shared.Balance, error) {
+	body, err := signedGet(ctx, "Binance", binanceFuturesURL, binanceBalancePath, binanceAPIKeyHeader, a.credentials.get(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var dtos []BinanceBalanceDto
+	if err := json.Unmarshal(body, &dtos); err != nil {
+		return nil, decodeError("Binance", decodeError("Mexc", fmt.Errorf("failed to unmarshal Binance balances: %w", err)))
+	}
+
+	balances := make(map[string]shared.Balance, len(dtos))
+	for _, d := range dtos {
+		total, _ := strconv.ParseFloat(d.Balance, 64)
+		available, _ := strconv.ParseFloat(d.AvailableBalance, 64)
+		if total == 0 && available == 0 {
+			continue
+		}
+		balances[d.Asset] = shared.Balance{Asset: d.Asset, Total: total, Available: available}
+	}
+	return balances, nil
+}
+
+// GetPositions fetches the open positions of the Binance futures account. It requires API credentials.
+func (a *BinanceAdapter) GetPositions(ctx context.Context) ([]shared.Position, error) {
+	body, err := signedGet(ctx, "Binance", binanceFuturesURL, binancePositionRiskPath, binanceAPIKeyHeader, a.credentials.get(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var dtos []BinancePositionDto
+	if err := json.Unmarshal(body, &dtos); err != nil {
+		return nil, decodeError("Binance", decodeError("Mexc", fmt.Errorf("failed to unmarshal Binance positions: %w", err)))
+	}
+
+	var positions []shared.Position
+	for _, d := range dtos {
+		quantity, _ := strconv.ParseFloat(d.PositionAmt, 64)
+		if quantity == 0 {
+			continue // Every symbol is listed, open or not
+		}
+		unifiedSymbol, err := UnwrapBinanceSymbol(d.Symbol)
+		if err != nil {
+			continue
+		}
+		entryPrice, _ := strconv.ParseFloat(d.EntryPrice, 64)
+		pnl, _ := strconv.ParseFloat(d.UnRealizedProfit, 64)
+		leverage, _ := strconv.Atoi(d.Leverage)
+		positions = append(positions, shared.Position{
+			UnifiedSymbol: unifiedSymbol,
+			Symbol:        d.Symbol,
+			Quantity:      quantity,
+			EntryPrice:    entryPrice,
+			UnrealizedPnL: pnl,
+			Leverage:      leverage,
+		})
+	}
+	return positions, nil
+}
+
+// GetBalances fetches the margin balances of the Mexc futures account, keyed by asset. It requires API
+// credentials.
+func (a *MexcAdapter) GetBalances(ctx context.Context) (map[string]shared.Balance, error) {
+	body, err := mexcSignedGet(ctx, mexcAssetsPath, a.credentials.get())
+	if err != nil {
+		return nil, err
+	}
+	var response MexcAssetsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, decodeError("Mexc", decodeError("Mexc", fmt.Errorf("failed to unmarshal Mexc assets: %w", err)))
+	}
+	if !response.Success {
+		return nil, mexcError(response.Code, fmt.Errorf("Mexc assets API returned success: false, code: %d", response.Code))
+	}
+
+	balances := make(map[string]shared.Balance, len(response.Data))
+	for _, d := range response.Data {
+		if d.Equity == 0 && d.AvailableBalance == 0 {
+			continue
+		}
+		balances[d.Currency] = shared.Balance{Asset: d.Currency, Total: d.Equity, Available: d.AvailableBalance}
+	}
+	return balances, nil
+}
+
+// GetPositions fetches the open positions of the Mexc futures account. It requires API credentials. Mexc
+// doesn't report unrealized PnL, and its contract quantities are converted with the contract sizes of the
+// last contract specs fetch, made first if there was none.
+func (a *MexcAdapter) GetPositions(ctx context.Context) ([]shared.Position, error) {
+	body, err := mexcSignedGet(ctx, mexcOpenPositionsPath, a.credentials.get())
+	if err != nil {
+		return nil, err
+	}
+	var response MexcPositionsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, decodeError("Mexc", decodeError("Mexc", fmt.Errorf("failed to unmarshal Mexc positions: %w", err)))
+	}
+	if !response.Success {
+		return nil, mexcError(response.Code, fmt.Errorf("Mexc positions API returned success: false, code: %d", response.Code))
+	}
+
+	a.mu.RLock()
+	known := a.contractSizes != nil
+	a.mu.RUnlock()
+	if !known && len(response.Data) > 0 {
+		if _, err := a.GetContractSpecs(ctx); err != nil {
+			return nil, err
+		}
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	positions := make([]shared.Position, 0, len(response.Data))
+	for _, d := range response.Data {
+		unifiedSymbol, err := UnwrapMexcSymbol(d.Symbol)
+		if err != nil {
+			continue
+		}
+		contractSize, ok := a.contractSizes[unifiedSymbol]
+		if !ok || contractSize <= 0 {
+			contractSize = 1
+		}
+		quantity := d.HoldVol * contractSize
+		if d.PositionType == 2 {
+			quantity = -quantity
+		}
+		positions = append(positions, shared.Position{
+			UnifiedSymbol: unifiedSymbol,
+			Symbol:        d.Symbol,
+			Quantity:      quantity,
+			EntryPrice:    d.OpenAvgPrice,
+			Leverage:      d.Leverage,
+		})
+	}
+	return positions, nil
+}
+
+// mexcSignedGet performs a GET request against a signed Mexc futures endpoint without parameters: the
+// signature is an HMAC-SHA256 of the API key and the request time, sent in headers with them.
+func mexcSignedGet(ctx context.Context, path string, creds credentials) ([]byte, error) {
+	if creds.apiKey == "" || creds.apiSecret == "" {
+		return nil, newError(CodeAuthFailed, "Mexc", fmt.Errorf("Mexc %s: %w", path, ErrMissingCredentials))
+	}
+	requestTime := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(creds.apiSecret))
+	mac.Write([]byte(creds.apiKey + requestTime))
+
+	header := http.Header{}
+	header.Set("ApiKey", creds.apiKey)
+	header.Set("Request-Time", requestTime)
+	header.Set("Signature", hex.EncodeToString(mac.Sum(nil)))
+	resp, err := httpGetWithHeader(ctx, "Mexc", mexcFuturesURL+path, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request to Mexc %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to read Mexc %s response body: %w", path, err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("Mexc", resp, body, fmt.Errorf("Mexc %s returned non-OK status: %d, body: %s", path, resp.StatusCode, string(body)))
+	}
+	return body, nil
+}
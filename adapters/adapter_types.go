@@ -1,11 +1,24 @@
 package adapters
 
+import "encoding/json"
+
 // BinanceBookTickerDto represents a single ticker response from Binance.
 // We only define the fields we need. The json unmarshaller will ignore the rest.
 type BinanceBookTickerDto struct {
 	Symbol   string `json:"symbol"`
 	BidPrice string `json:"bidPrice"`
+	BidQty   string `json:"bidQty"`
 	AskPrice string `json:"askPrice"`
+	AskQty   string `json:"askQty"`
+	Time     int64  `json:"time"` // Last update time in milliseconds
+}
+
+// Binance24hrTickerDto represents a single 24hr rolling window statistics response from Binance.
+type Binance24hrTickerDto struct {
+	Symbol           string `json:"symbol"`
+	QuoteVolume      string `json:"quoteVolume"`      // USDⓈ-M only
+	BaseVolume       string `json:"baseVolume"`       // COIN-M only, in base units
+	WeightedAvgPrice string `json:"weightedAvgPrice"` // Converts BaseVolume to a quote volume
 }
 
 // BinancePremiumIndexDto represents a single premium index response from Binance.
@@ -29,9 +42,45 @@ type BinanceFundingRateDto struct {
 	FundingIntervalHours int    `json:"fundingIntervalHours"`
 }
 
+// BinanceFundingRateHistoryDto represents a single settled funding rate from Binance's history endpoint.
+type BinanceFundingRateHistoryDto struct {
+	Symbol      string `json:"symbol"`
+	FundingRate string `json:"fundingRate"`
+	FundingTime int64  `json:"fundingTime"`
+}
+
+// BinanceExchangeInfoResponse represents the response from Binance's exchange info endpoint.
+type BinanceExchangeInfoResponse struct {
+	Symbols []BinanceSymbolInfoDto `json:"symbols"`
+}
+
+// BinanceSymbolInfoDto represents a single symbol's trading rules from Binance.
+type BinanceSymbolInfoDto struct {
+	Symbol         string                   `json:"symbol"`
+	ContractType   string                   `json:"contractType"`
+	Status         string                   `json:"status"`         // USDⓈ-M only
+	ContractStatus string                   `json:"contractStatus"` // COIN-M only
+	ContractSize   float64                  `json:"contractSize"`   // COIN-M only: USD per contract
+	Filters        []BinanceSymbolFilterDto `json:"filters"`
+}
+
+// BinanceSymbolFilterDto represents a single trading filter; only the fields of the filters we use are defined.
+type BinanceSymbolFilterDto struct {
+	FilterType string `json:"filterType"`
+	TickSize   string `json:"tickSize"` // PRICE_FILTER
+	StepSize   string `json:"stepSize"` // LOT_SIZE
+	MinQty     string `json:"minQty"`   // LOT_SIZE
+	Notional   string `json:"notional"` // MIN_NOTIONAL
+}
+
 // MexcContractDetailDto represents a single contract detail from Mexc.
 type MexcContractDetailDto struct {
-	Symbol string `json:"symbol"`
+	Symbol       string  `json:"symbol"`
+	ContractSize float64 `json:"contractSize"` // Base units per contract
+	PriceUnit    float64 `json:"priceUnit"`    // Tick size
+	VolUnit      float64 `json:"volUnit"`      // Quantity step in contracts
+	MinVol       float64 `json:"minVol"`       // Minimum order quantity in contracts
+	MaxLeverage  int     `json:"maxLeverage"`
 }
 
 // MexcContractDetailResponse represents the full response from Mexc's contract detail endpoint.
@@ -49,6 +98,13 @@ type MexcFundingRateDto struct {
 	CollectCycle   int     `json:"collectCycle"`
 }
 
+// MexcCachedFundingRate is a Mexc funding rate as persisted in the funding cache, along with when its interval
+// and next settle time were fetched from the per-symbol endpoint.
+type MexcCachedFundingRate struct {
+	MexcFundingRateDto
+	MetaFetchedAt int64 `json:"metaFetchedAt,omitempty"` // Milliseconds since epoch, 0 in entries cached without it
+}
+
 // MexcFundingRateResponse represents the full response from Mexc's funding rate endpoint.
 type MexcFundingRateResponse struct {
 	Success bool               `json:"success"`
@@ -56,13 +112,52 @@ type MexcFundingRateResponse struct {
 	Data    MexcFundingRateDto `json:"data"`
 }
 
+// MexcStreamRequest is a request sent on the Mexc futures websocket, e.g., a subscription or a ping.
+type MexcStreamRequest struct {
+	Method string            `json:"method"`          // e.g., "sub.funding.rate" or "ping"
+	Param  map[string]string `json:"param,omitempty"` // e.g., {"symbol": "ETH_USDT"}
+}
+
+// MexcStreamMessage represents a message pushed on the Mexc futures websocket. Data depends on the channel.
+type MexcStreamMessage struct {
+	Channel string          `json:"channel"` // e.g., "push.funding.rate", "rs.sub.funding.rate", "rs.error" or "pong"
+	Symbol  string          `json:"symbol,omitempty"`
+	Data    json.RawMessage `json:"data"`
+	Ts      int64           `json:"ts"` // Milliseconds since epoch
+}
+
+// MexcStreamFundingDto represents the data of a push.funding.rate message.
+type MexcStreamFundingDto struct {
+	Symbol         string  `json:"symbol"`
+	Rate           float64 `json:"rate"`
+	NextSettleTime int64   `json:"nextSettleTime"` // Milliseconds since epoch
+}
+
+// MexcFundingRateHistoryDto represents a single settled funding rate from Mexc's history endpoint.
+type MexcFundingRateHistoryDto struct {
+	Symbol      string  `json:"symbol"`
+	FundingRate float64 `json:"fundingRate"`
+	SettleTime  int64   `json:"settleTime"`
+}
+
+// MexcFundingRateHistoryResponse represents the full response from Mexc's funding rate history endpoint.
+type MexcFundingRateHistoryResponse struct {
+	Success bool `json:"success"`
+	Code    int  `json:"code"`
+	Data    struct {
+		ResultList []MexcFundingRateHistoryDto `json:"resultList"`
+	} `json:"data"`
+}
+
 // MexcTickerDto represents a single ticker response from Mexc.
 // We only define the fields we need.
 type MexcTickerDto struct {
-	Symbol   string  `json:"symbol"`
-	Bid1     float64 `json:"bid1"`
-	Ask1     float64 `json:"ask1"`
-	Amount24 float64 `json:"amount24"` // This is 'volume24' in the docs, but 'amount24' is volume in USD
+	Symbol      string  `json:"symbol"`
+	Bid1        float64 `json:"bid1"`
+	Ask1        float64 `json:"ask1"`
+	Amount24    float64 `json:"amount24"`    // This is 'volume24' in the docs, but 'amount24' is volume in USD
+	Timestamp   int64   `json:"timestamp"`   // Snapshot time in milliseconds
+	FundingRate float64 `json:"fundingRate"` // Current funding rate, used for bulk funding updates
 }
 
 // MexcTickersResponse represents the full response structure from Mexc's ticker endpoint.
@@ -71,3 +166,224 @@ type MexcTickersResponse struct {
 	Code    int             `json:"code"`
 	Data    []MexcTickerDto `json:"data"`
 }
+
+// SpotBookTickerDto represents a single spot book ticker. Binance and Mexc share this format on their v3 spot APIs.
+type SpotBookTickerDto struct {
+	Symbol   string `json:"symbol"`
+	BidPrice string `json:"bidPrice"`
+	AskPrice string `json:"askPrice"`
+}
+
+// SpotExchangeInfoResponse represents the spot exchange info response. Binance and Mexc share this format.
+type SpotExchangeInfoResponse struct {
+	Symbols []SpotSymbolInfoDto `json:"symbols"`
+}
+
+// SpotSymbolInfoDto represents a single spot market's base and quote assets.
+type SpotSymbolInfoDto struct {
+	Symbol     string `json:"symbol"`
+	Status     string `json:"status"` // "TRADING" on Binance, "1" or "ENABLED" on Mexc
+	BaseAsset  string `json:"baseAsset"`
+	QuoteAsset string `json:"quoteAsset"`
+}
+
+// WalletCoinDto represents a coin's deposit and withdrawal configuration. Binance (/sapi/v1/capital/config/getall)
+// and Mexc (/api/v3/capital/config/getall) share this format.
+type WalletCoinDto struct {
+	Coin        string             `json:"coin"`
+	NetworkList []WalletNetworkDto `json:"networkList"`
+}
+
+// WalletNetworkDto represents a single network a coin can be deposited or withdrawn on.
+type WalletNetworkDto struct {
+	Network        string `json:"network"`
+	NetWork        string `json:"netWork"` // Mexc only: short network code; "network" holds the display name there
+	DepositEnable  bool   `json:"depositEnable"`
+	WithdrawEnable bool   `json:"withdrawEnable"`
+	WithdrawFee    string `json:"withdrawFee"` // Fee in coin units
+}
+
+// WithdrawalDto represents a withdrawal of a Binance-compatible capital withdraw history.
+type WithdrawalDto struct {
+	Coin           string          `json:"coin"`
+	Network        string          `json:"network"`
+	TxID           string          `json:"txId"`
+	TransactionFee string          `json:"transactionFee"` // Fee in coin units
+	ApplyTime      json.RawMessage `json:"applyTime"`      // Binance: "2006-01-02 15:04:05" in UTC; Mexc: milliseconds
+}
+
+// DepositDto represents a deposit of a Binance-compatible capital deposit history.
+type DepositDto struct {
+	Coin         string `json:"coin"`
+	Network      string `json:"network"`
+	TxID         string `json:"txId"`
+	InsertTime   int64  `json:"insertTime"`   // When the deposit was detected, in milliseconds
+	CompleteTime int64  `json:"completeTime"` // When the deposit was credited, in milliseconds (Binance only)
+}
+
+// BinanceBalanceDto represents one asset of the Binance futures account (/fapi/v2/balance).
+type BinanceBalanceDto struct {
+	Asset            string `json:"asset"`
+	Balance          string `json:"balance"`
+	AvailableBalance string `json:"availableBalance"`
+}
+
+// BinancePositionDto represents one position of the Binance futures account (/fapi/v2/positionRisk).
+type BinancePositionDto struct {
+	Symbol           string `json:"symbol"`
+	PositionAmt      string `json:"positionAmt"` // Base units, negative for shorts
+	EntryPrice       string `json:"entryPrice"`
+	UnRealizedProfit string `json:"unRealizedProfit"`
+	Leverage         string `json:"leverage"`
+}
+
+// MexcAssetsResponse represents the response from Mexc's futures account assets endpoint.
+type MexcAssetsResponse struct {
+	Success bool           `json:"success"`
+	Code    int            `json:"code"`
+	Data    []MexcAssetDto `json:"data"`
+}
+
+// MexcAssetDto represents one asset of the Mexc futures account.
+type MexcAssetDto struct {
+	Currency         string  `json:"currency"`
+	Equity           float64 `json:"equity"`
+	AvailableBalance float64 `json:"availableBalance"`
+}
+
+// MexcPositionsResponse represents the response from Mexc's open positions endpoint.
+type MexcPositionsResponse struct {
+	Success bool              `json:"success"`
+	Code    int               `json:"code"`
+	Data    []MexcPositionDto `json:"data"`
+}
+
+// MexcPositionDto represents one open position of the Mexc futures account.
+type MexcPositionDto struct {
+	Symbol       string  `json:"symbol"`
+	PositionType int     `json:"positionType"` // 1 long, 2 short
+	HoldVol      float64 `json:"holdVol"`      // In contracts
+	OpenAvgPrice float64 `json:"openAvgPrice"`
+	Leverage     int     `json:"leverage"`
+}
+
+// BinanceCommissionRateDto represents the fee rates of the Binance futures account on a symbol
+// (/fapi/v1/commissionRate), as fractions.
+type BinanceCommissionRateDto struct {
+	Symbol              string `json:"symbol"`
+	MakerCommissionRate string `json:"makerCommissionRate"`
+	TakerCommissionRate string `json:"takerCommissionRate"`
+}
+
+// BinanceSpotCommissionDto represents the fee rates of the Binance spot account on a symbol
+// (/api/v3/account/commission), as fractions. The taxes are charged on top of the standard commission.
+type BinanceSpotCommissionDto struct {
+	Symbol             string                    `json:"symbol"`
+	StandardCommission BinanceCommissionRatesDto `json:"standardCommission"`
+	TaxCommission      BinanceCommissionRatesDto `json:"taxCommission"`
+}
+
+// BinanceCommissionRatesDto represents a set of Binance spot fee rates.
+type BinanceCommissionRatesDto struct {
+	Maker string `json:"maker"`
+	Taker string `json:"taker"`
+}
+
+// MexcTieredFeeRateResponse represents the response from Mexc's futures fee tier endpoint.
+type MexcTieredFeeRateResponse struct {
+	Success bool `json:"success"`
+	Code    int  `json:"code"`
+	Data    struct {
+		Level    int     `json:"level"`
+		MakerFee float64 `json:"makerFee"` // Fraction
+		TakerFee float64 `json:"takerFee"` // Fraction
+	} `json:"data"`
+}
+
+// MexcTradeFeeResponse represents the response from Mexc's spot trade fee endpoint.
+type MexcTradeFeeResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data struct {
+		MakerCommission float64 `json:"makerCommission"` // Fraction
+		TakerCommission float64 `json:"takerCommission"` // Fraction
+	} `json:"data"`
+}
+
+// GmxTokensResponse represents the response from GMX's tokens endpoint.
+type GmxTokensResponse struct {
+	Tokens []GmxTokenDto `json:"tokens"`
+}
+
+// GmxTokenDto represents a single token GMX prices, with the decimals its prices are scaled by.
+type GmxTokenDto struct {
+	Symbol   string `json:"symbol"`
+	Address  string `json:"address"`
+	Decimals int    `json:"decimals"`
+}
+
+// GmxPriceTickerDto represents a single oracle price from GMX. Prices are integers scaled by 10^(30 - token
+// decimals); longs open at the max price and shorts at the min price.
+type GmxPriceTickerDto struct {
+	TokenAddress string `json:"tokenAddress"`
+	TokenSymbol  string `json:"tokenSymbol"`
+	MinPrice     string `json:"minPrice"`
+	MaxPrice     string `json:"maxPrice"`
+	UpdatedAt    int64  `json:"updatedAt"` // Update time in milliseconds
+}
+
+// GmxMarketsInfoResponse represents the response from GMX's markets info endpoint.
+type GmxMarketsInfoResponse struct {
+	Markets []GmxMarketInfoDto `json:"markets"`
+}
+
+// GmxMarketInfoDto represents a single GMX perp market. Open interests are in USD and rates are annualized,
+// both integers scaled by 10^30. Net rates are received by each side: funding minus borrowing fees.
+type GmxMarketInfoDto struct {
+	Name              string `json:"name"` // e.g., "BTC/USD [BTC-USDC]"
+	MarketToken       string `json:"marketToken"`
+	IndexToken        string `json:"indexToken"`
+	IsListed          bool   `json:"isListed"`
+	OpenInterestLong  string `json:"openInterestLong"`
+	OpenInterestShort string `json:"openInterestShort"`
+	NetRateLong       string `json:"netRateLong"`
+	NetRateShort      string `json:"netRateShort"`
+}
+
+// BinanceSystemStatusDto represents the response from Binance's system status endpoint.
+type BinanceSystemStatusDto struct {
+	Status int    `json:"status"` // 0 normal, 1 system maintenance
+	Msg    string `json:"msg"`
+}
+
+// BinanceDepthDto represents the order book of a Binance futures symbol (/fapi/v1/depth). Levels are
+// [price, quantity] pairs of strings.
+type BinanceDepthDto struct {
+	LastUpdateID    int64       `json:"lastUpdateId"`
+	TransactionTime int64       `json:"T"` // Milliseconds since epoch
+	Bids            [][2]string `json:"bids"`
+	Asks            [][2]string `json:"asks"`
+}
+
+// MexcDepthResponse represents the order book of a Mexc contract (/api/v1/contract/depth/{symbol}). Levels are
+// [price, contracts, order count] triples.
+type MexcDepthResponse struct {
+	Success bool `json:"success"`
+	Code    int  `json:"code"`
+	Data    struct {
+		Bids      [][]float64 `json:"bids"`
+		Asks      [][]float64 `json:"asks"`
+		Version   int64       `json:"version"`
+		Timestamp int64       `json:"timestamp"` // Milliseconds since epoch
+	} `json:"data"`
+}
+
+// BinanceMarkPriceDto represents a symbol's update of the Binance futures mark price stream (!markPrice@arr).
+// Only the funding fields are decoded; the keys differ by case only from the prices left out.
+type BinanceMarkPriceDto struct {
+	EventType       string `json:"e"` // "markPriceUpdate"
+	EventTime       int64  `json:"E"` // Milliseconds since epoch
+	Symbol          string `json:"s"`
+	FundingRate     string `json:"r"`
+	NextFundingTime int64  `json:"T"` // Milliseconds since epoch
+}
@@ -1,71 +1,217 @@
 package adapters
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"cex-price-diff-notifications/logging"
 	"cex-price-diff-notifications/shared"
+	"cex-price-diff-notifications/shared/retry"
+	"cex-price-diff-notifications/storage"
 )
 
+// Paths of the market data endpoints, after the market's prefix.
 const (
-	binanceFuturesURL       = "https://fapi.binance.com"
-	binanceBookTickerPath   = "/fapi/v1/ticker/bookTicker"
-	binancePremiumIndexPath = "/fapi/v1/premiumIndex"
-	binanceFundingInfoPath  = "/fapi/v1/fundingInfo"
+	binanceBookTickerPath   = "/v1/ticker/bookTicker"
+	binancePremiumIndexPath = "/v1/premiumIndex"
+	binanceFundingInfoPath  = "/v1/fundingInfo"
+	binanceFundingRatePath  = "/v1/fundingRate"
+	binanceExchangeInfoPath = "/v1/exchangeInfo"
+	binance24hrTickerPath   = "/v1/ticker/24hr"
 )
 
-// BinanceAdapter holds state and logic for interacting with the Binance API.
+// BinanceAdapter holds state and logic for interacting with the futures API of a Binance market.
 type BinanceAdapter struct {
-	FundingRates map[string]BinanceFundingRateDto
-	mu           sync.RWMutex
+	market             BinanceMarket
+	fundingRates       map[string]BinanceFundingRateDto
+	mu                 sync.RWMutex
+	fundingCache       *storage.FundingCache[BinanceFundingRateDto] // Nil keeps funding rates in memory only
+	fundingIntervals   map[string]int                               // Funding interval in hours keyed by Binance symbol, for those not settling every 8 hours
+	fundingIntervalsAt time.Time                                    // Time of the last funding interval fetch
+	fundingStreamAt    atomic.Int64                                 // Time of the last mark price stream message, in milliseconds since epoch
+	fundingAt          atomic.Int64                                 // Time of the last funding rate update, polled or streamed, in milliseconds since epoch
+	streamURL          string                                       // Base URL of the futures market streams
+	volumes            map[string]float64                           // 24h quote volume keyed by Binance symbol
+	spotMarkets        spotMarkets
+	credentials        apiKeys // Only needed for private endpoints such as wallet status
 }
 
-// NewBinanceAdapter creates a new instance of the BinanceAdapter.
+// NewBinanceAdapter creates a new instance of the BinanceAdapter for the USDⓈ-M futures.
 func NewBinanceAdapter() *BinanceAdapter {
+	return NewBinanceMarketAdapter(BinanceUSDM)
+}
+
+// NewBinanceMarketAdapter creates a new instance of the BinanceAdapter for a futures market, e.g., BinanceCoinM.
+func NewBinanceMarketAdapter(market BinanceMarket) *BinanceAdapter {
 	return &BinanceAdapter{
-		FundingRates: make(map[string]BinanceFundingRateDto),
+		market:       market,
+		fundingRates: make(map[string]BinanceFundingRateDto),
+		streamURL:    market.streamURL,
+		volumes:      make(map[string]float64),
 	}
 }
 
-// GetTickers fetches the latest book tickers from Binance.
-func (a *BinanceAdapter) GetTickers() ([]BinanceBookTickerDto, time.Duration, error) {
+// Market returns the futures market of the adapter.
+func (a *BinanceAdapter) Market() BinanceMarket {
+	return a.market
+}
+
+// SetCredentials sets the API key pair used for signed endpoints. It can be called while fetching.
+func (a *BinanceAdapter) SetCredentials(apiKey, apiSecret string) {
+	a.credentials.set(apiKey, apiSecret)
+}
+
+// SetFundingCache sets the cache funding rates are persisted to and loaded from.
+func (a *BinanceAdapter) SetFundingCache(cache *storage.FundingCache[BinanceFundingRateDto]) {
+	a.fundingCache = cache
+}
+
+// LoadFundingRates loads Binance funding rates from the funding cache, if set, so the first cycle after
+// a restart already has them.
+func (a *BinanceAdapter) LoadFundingRates() {
+	if a.fundingCache == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	rates, err := a.fundingCache.Load(ctx)
+	if err != nil {
+		slog.Error("Failed to load Binance funding rates from Redis", "market", a.market.Name, "error", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for unifiedSymbol, dto := range rates {
+		a.fundingRates[unifiedSymbol] = dto
+	}
+	slog.Info("Loaded Binance funding rates from Redis.", "market", a.market.Name, "loaded_count", len(rates))
+}
+
+// GetTickers fetches the latest book tickers from Binance, decoding them into buf's storage: pass the tickers
+// of the previous call, once done with them, to avoid reallocating the multi-megabyte payload every cycle.
+func (a *BinanceAdapter) GetTickers(ctx context.Context, buf []BinanceBookTickerDto) ([]BinanceBookTickerDto, time.Duration, error) {
 	start := time.Now()
 
-	resp, err := http.Get(binanceFuturesURL + binanceBookTickerPath)
+	name := a.market.Name
+	resp, err := httpGet(ctx, name, a.market.url(binanceBookTickerPath))
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to make HTTP request to Binance tickers: %w", err)
+		return nil, 0, fmt.Errorf("failed to make HTTP request to %s tickers: %w", name, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("Binance tickers API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, 0, statusError(name, resp, bodyBytes, fmt.Errorf("%s tickers API returned non-OK status: %d, body: %s", name, resp.StatusCode, string(bodyBytes)))
+	}
+
+	tickers := reuse(buf)
+	if err := decodeJSON(resp.Body, &tickers); err != nil {
+		return nil, 0, decodeError(name, fmt.Errorf("failed to decode %s tickers: %w", name, err))
+	}
+
+	duration := time.Since(start)
+	return tickers, duration, nil
+}
+
+// Tickers converts book tickers to unified tickers with their 24h quote volume, skipping unsupported markets.
+func (a *BinanceAdapter) Tickers(dtos []BinanceBookTickerDto) []shared.TickerBidAsk {
+	tickers := make([]shared.TickerBidAsk, 0, len(dtos))
+	for _, dto := range dtos {
+		t, err := dto.ToTickerBidAsk(a.market)
+		if err != nil {
+			// Unsupported quotes on USDⓈ-M and delivery contracts on COIN-M are expected
+			if CodeOf(err) != CodeSymbolUnsupported {
+				logging.Warn("Failed to convert Binance DTO", "market", a.market.Name, "symbol", dto.Symbol, "error", err)
+			}
+			continue
+		}
+		if volume, ok := a.QuoteVolume(dto.Symbol); ok {
+			t.VolumeUSD = volume
+		}
+		tickers = append(tickers, t)
+	}
+	return tickers
+}
+
+// UpdateVolumes fetches and stores the 24h quote volume of all symbols from Binance.
+func (a *BinanceAdapter) UpdateVolumes(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+
+	name := a.market.Name
+	resp, err := httpGet(ctx, name, a.market.url(binance24hrTickerPath))
+	if err != nil {
+		return 0, fmt.Errorf("failed to make HTTP request to %s 24hr tickers: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return 0, statusError(name, resp, bodyBytes, fmt.Errorf("%s 24hr tickers API returned non-OK status: %d, body: %s", name, resp.StatusCode, string(bodyBytes)))
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to read Binance tickers response body: %w", err)
+		return 0, newError(CodeExchangeDown, name, fmt.Errorf("failed to read %s 24hr tickers response body: %w", name, err))
 	}
 
-	var tickers []BinanceBookTickerDto
-	if err := json.Unmarshal(body, &tickers); err != nil {
-		return nil, 0, fmt.Errorf("failed to unmarshal Binance tickers: %w", err)
+	var stats []Binance24hrTickerDto
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return 0, decodeError(name, fmt.Errorf("failed to unmarshal %s 24hr tickers: %w", name, err))
 	}
 
-	duration := time.Since(start)
-	return tickers, duration, nil
+	volumes := make(map[string]float64, len(stats))
+	for _, s := range stats {
+		if v, ok := s.quoteVolume(); ok {
+			volumes[s.Symbol] = v
+		}
+	}
+
+	a.mu.Lock()
+	a.volumes = volumes
+	a.mu.Unlock()
+
+	return time.Since(start), nil
+}
+
+// QuoteVolume returns the last fetched 24h quote volume of a Binance symbol of the market.
+func (a *BinanceAdapter) QuoteVolume(binanceSymbol string) (float64, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	v, ok := a.volumes[binanceSymbol]
+	return v, ok
+}
+
+// GetSpotTickers fetches the latest spot book tickers from Binance as unified spot tickers (e.g., "ETH/BTC:SPOT").
+// It is not safe for concurrent use.
+func (a *BinanceAdapter) GetSpotTickers(ctx context.Context) ([]shared.TickerBidAsk, time.Duration, error) {
+	return getSpotTickers(ctx, "Binance", binanceSpotURL, &a.spotMarkets)
+}
+
+// GetWalletStatus fetches the deposit/withdrawal status and fees of every asset on Binance, keyed by asset.
+// It requires API credentials.
+func (a *BinanceAdapter) GetWalletStatus(ctx context.Context) (map[string][]shared.AssetNetwork, error) {
+	return getWalletStatus(ctx, "Binance", binanceSpotURL, binanceWalletConfigPath, binanceAPIKeyHeader, a.credentials.get())
+}
+
+// GetTransferHistory fetches the recent withdrawals and deposits of the Binance account, to learn how long
+// transfers take. It requires API credentials.
+func (a *BinanceAdapter) GetTransferHistory(ctx context.Context) (withdrawals, deposits []shared.Transfer, err error) {
+	return getTransferHistory(ctx, "Binance", binanceSpotURL, binanceWithdrawalsPath, binanceDepositsPath, binanceAPIKeyHeader, a.credentials.get())
 }
 
-// UpdateFundingRates fetches and stores the latest funding rates from Binance in parallel.
-func (a *BinanceAdapter) UpdateFundingRates() (time.Duration, error) {
+// UpdateFundingRates fetches and stores the latest funding rates of the market from Binance in parallel.
+func (a *BinanceAdapter) UpdateFundingRates(ctx context.Context) (time.Duration, error) {
 	start := time.Now()
+	name := a.market.Name
 	var wg sync.WaitGroup
 	var errPremium, errInfo error
 	var premiumIndexes []BinancePremiumIndexDto
@@ -76,55 +222,34 @@ func (a *BinanceAdapter) UpdateFundingRates() (time.Duration, error) {
 	// Fetch Premium Index in a goroutine
 	go func() {
 		defer wg.Done()
-		resp, err := http.Get(binanceFuturesURL + binancePremiumIndexPath)
+		resp, err := httpGet(ctx, name, a.market.url(binancePremiumIndexPath))
 		if err != nil {
-			errPremium = fmt.Errorf("failed to make HTTP request to Binance premium index: %w", err)
+			errPremium = fmt.Errorf("failed to make HTTP request to %s premium index: %w", name, err)
 			return
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
 			bodyBytes, _ := io.ReadAll(resp.Body)
-			errPremium = fmt.Errorf("Binance premium index API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+			errPremium = statusError(name, resp, bodyBytes, fmt.Errorf("%s premium index API returned non-OK status: %d, body: %s", name, resp.StatusCode, string(bodyBytes)))
 			return
 		}
 
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			errPremium = fmt.Errorf("failed to read Binance premium index response body: %w", err)
+			errPremium = newError(CodeExchangeDown, name, fmt.Errorf("failed to read %s premium index response body: %w", name, err))
 			return
 		}
 
 		if err := json.Unmarshal(body, &premiumIndexes); err != nil {
-			errPremium = fmt.Errorf("failed to unmarshal Binance premium indexes: %w", err)
+			errPremium = decodeError(name, fmt.Errorf("failed to unmarshal %s premium indexes: %w", name, err))
 		}
 	}()
 
 	// Fetch Funding Info in a goroutine
 	go func() {
 		defer wg.Done()
-		resp, err := http.Get(binanceFuturesURL + binanceFundingInfoPath)
-		if err != nil {
-			errInfo = fmt.Errorf("failed to make HTTP request to Binance funding info: %w", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			errInfo = fmt.Errorf("Binance funding info API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes))
-			return
-		}
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			errInfo = fmt.Errorf("failed to read Binance funding info response body: %w", err)
-			return
-		}
-
-		if err := json.Unmarshal(body, &fundingInfos); err != nil {
-			errInfo = fmt.Errorf("failed to unmarshal Binance funding infos: %w", err)
-		}
+		fundingInfos, errInfo = a.getFundingInfos(ctx)
 	}()
 
 	wg.Wait()
@@ -136,79 +261,293 @@ func (a *BinanceAdapter) UpdateFundingRates() (time.Duration, error) {
 		return 0, errInfo
 	}
 
-	// Create a map for quick lookup of funding intervals
-	fundingInfoMap := make(map[string]BinanceFundingInfoDto)
-	for _, info := range fundingInfos {
-		fundingInfoMap[info.Symbol] = info
-	}
-
 	a.mu.Lock()
-	defer a.mu.Unlock()
-
+	a.setFundingIntervals(fundingInfos)
 	loggedCount := 0
 	for _, premiumIndex := range premiumIndexes {
-		unifiedSymbol, err := UnwrapBinanceSymbol(premiumIndex.Symbol)
+		unifiedSymbol, err := a.market.Unwrap(premiumIndex.Symbol)
 		if err != nil {
 			continue
 		}
 
 		combinedRate := BinanceFundingRateDto{
-			Symbol:          premiumIndex.Symbol,
-			LastFundingRate: premiumIndex.LastFundingRate,
-			NextFundingTime: premiumIndex.NextFundingTime,
-		}
-
-		if info, ok := fundingInfoMap[premiumIndex.Symbol]; ok {
-			combinedRate.FundingIntervalHours = info.FundingIntervalHours
-		} else {
-			combinedRate.FundingIntervalHours = 8 // Default to 8 hours
+			Symbol:               premiumIndex.Symbol,
+			LastFundingRate:      premiumIndex.LastFundingRate,
+			NextFundingTime:      premiumIndex.NextFundingTime,
+			FundingIntervalHours: a.fundingInterval(premiumIndex.Symbol),
 		}
-		a.FundingRates[unifiedSymbol] = combinedRate
+		a.fundingRates[unifiedSymbol] = combinedRate
 
 		if loggedCount < 2 {
-			slog.Info("Combined Binance funding rate", "data", combinedRate)
+			slog.Info("Combined Binance funding rate", "market", name, "data", combinedRate)
 			loggedCount++
 		}
 	}
+	a.mu.Unlock()
+	a.fundingAt.Store(time.Now().UnixMilli())
 
+	a.saveFundingRates(ctx)
 	return time.Since(start), nil
 }
 
-// ToTickerBidAsk converts a BinanceBookTickerDto to a shared.TickerBidAsk.
-func (b BinanceBookTickerDto) ToTickerBidAsk() (shared.TickerBidAsk, error) {
-	unifiedSymbol, err := UnwrapBinanceSymbol(b.Symbol)
+// getFundingInfos fetches the funding intervals of the symbols of the market that don't settle every 8 hours.
+func (a *BinanceAdapter) getFundingInfos(ctx context.Context) ([]BinanceFundingInfoDto, error) {
+	name := a.market.Name
+	resp, err := httpGet(ctx, name, a.market.url(binanceFundingInfoPath))
 	if err != nil {
-		return shared.TickerBidAsk{}, fmt.Errorf("failed to unwrap Binance symbol %s: %w", b.Symbol, err)
+		return nil, fmt.Errorf("failed to make HTTP request to %s funding info: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, statusError(name, resp, bodyBytes, fmt.Errorf("%s funding info API returned non-OK status: %d, body: %s", name, resp.StatusCode, string(bodyBytes)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(CodeExchangeDown, name, fmt.Errorf("failed to read %s funding info response body: %w", name, err))
+	}
+
+	var fundingInfos []BinanceFundingInfoDto
+	if err := json.Unmarshal(body, &fundingInfos); err != nil {
+		return nil, decodeError(name, fmt.Errorf("failed to unmarshal %s funding infos: %w", name, err))
+	}
+	return fundingInfos, nil
+}
+
+// setFundingIntervals replaces the known funding intervals, keyed by Binance symbol. a.mu must be held.
+func (a *BinanceAdapter) setFundingIntervals(infos []BinanceFundingInfoDto) {
+	a.fundingIntervals = make(map[string]int, len(infos))
+	for _, info := range infos {
+		a.fundingIntervals[info.Symbol] = info.FundingIntervalHours
+	}
+	a.fundingIntervalsAt = time.Now()
+}
+
+// fundingInterval returns the funding interval of a Binance symbol in hours, 8 unless listed otherwise.
+// a.mu must be held.
+func (a *BinanceAdapter) fundingInterval(binanceSymbol string) int {
+	if hours, ok := a.fundingIntervals[binanceSymbol]; ok && hours > 0 {
+		return hours
+	}
+	return 8
+}
+
+// saveFundingRates persists the funding rates to the funding cache, if set, so they are available right after
+// a restart.
+func (a *BinanceAdapter) saveFundingRates(ctx context.Context) {
+	if a.fundingCache == nil {
+		return
+	}
+	a.mu.RLock()
+	snapshot := make(map[string]BinanceFundingRateDto, len(a.fundingRates))
+	for unifiedSymbol, dto := range a.fundingRates {
+		snapshot[unifiedSymbol] = dto
+	}
+	a.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	err := retry.Do(ctx, cacheRetry, func(ctx context.Context) error { return a.fundingCache.Save(ctx, snapshot) })
+	if err != nil {
+		slog.Error("Failed to save Binance funding rates to Redis", "market", a.market.Name, "error", err)
+	}
+}
+
+// GetFundingRatesSnapshot returns a copy of the latest funding rates, keyed by unified symbol, that later
+// updates don't change. Rates that don't parse are left out.
+func (a *BinanceAdapter) GetFundingRatesSnapshot() map[string]shared.FundingRateInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	rates := make(map[string]shared.FundingRateInfo, len(a.fundingRates))
+	for unifiedSymbol, dto := range a.fundingRates {
+		info, err := dto.ToFundingRateInfo()
+		if err != nil {
+			logging.Warn("Failed to parse Binance funding rate", "market", a.market.Name, "symbol", unifiedSymbol, "rate_str", dto.LastFundingRate, "error", err)
+			continue
+		}
+		rates[unifiedSymbol] = info
+	}
+	return rates
+}
+
+// GetFundingHistory fetches the most recent settled funding rates for a unified symbol of the market from Binance.
+func (a *BinanceAdapter) GetFundingHistory(ctx context.Context, unifiedSymbol string, limit int) ([]shared.FundingRatePoint, error) {
+	name := a.market.Name
+	binanceSymbol, err := a.market.Wrap(unifiedSymbol)
+	if err != nil {
+		return nil, newError(CodeSymbolUnsupported, name, err)
+	}
+
+	url := fmt.Sprintf("%s?symbol=%s&limit=%d", a.market.url(binanceFundingRatePath), binanceSymbol, limit)
+	resp, err := httpGet(ctx, name, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request to %s funding history: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, statusError(name, resp, bodyBytes, fmt.Errorf("%s funding history API returned non-OK status: %d, body: %s", name, resp.StatusCode, string(bodyBytes)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(CodeExchangeDown, name, fmt.Errorf("failed to read %s funding history response body: %w", name, err))
+	}
+
+	var history []BinanceFundingRateHistoryDto
+	if err := json.Unmarshal(body, &history); err != nil {
+		return nil, decodeError(name, fmt.Errorf("failed to unmarshal %s funding history: %w", name, err))
+	}
+
+	points := make([]shared.FundingRatePoint, 0, len(history))
+	for _, h := range history {
+		r, err := strconv.ParseFloat(h.FundingRate, 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, shared.FundingRatePoint{Rate: r, SettleTime: h.FundingTime})
+	}
+	return points, nil
+}
+
+// GetContractSpecs fetches the trading rules of all perpetual contracts of the market from Binance, keyed by
+// unified symbol. COIN-M quantities are contracts of ContractSize USD rather than base units.
+func (a *BinanceAdapter) GetContractSpecs(ctx context.Context) (map[string]shared.ContractSpec, error) {
+	name := a.market.Name
+	resp, err := httpGet(ctx, name, a.market.url(binanceExchangeInfoPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request to %s exchange info: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, statusError(name, resp, bodyBytes, fmt.Errorf("%s exchange info API returned non-OK status: %d, body: %s", name, resp.StatusCode, string(bodyBytes)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(CodeExchangeDown, name, fmt.Errorf("failed to read %s exchange info response body: %w", name, err))
+	}
+
+	var info BinanceExchangeInfoResponse
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, decodeError(name, fmt.Errorf("failed to unmarshal %s exchange info: %w", name, err))
+	}
+
+	specs := make(map[string]shared.ContractSpec)
+	for _, s := range info.Symbols {
+		// COIN-M reports the status as contractStatus
+		if s.ContractType != "PERPETUAL" || cmp.Or(s.Status, s.ContractStatus) != "TRADING" {
+			continue
+		}
+		unifiedSymbol, err := a.market.Unwrap(s.Symbol)
+		if err != nil {
+			continue
+		}
+
+		spec := shared.ContractSpec{ContractSize: 1}
+		if a.market.inverse {
+			spec.ContractSize = s.ContractSize
+		}
+		for _, f := range s.Filters {
+			switch f.FilterType {
+			case "PRICE_FILTER":
+				spec.TickSize, _ = strconv.ParseFloat(f.TickSize, 64)
+			case "LOT_SIZE":
+				spec.StepSize, _ = strconv.ParseFloat(f.StepSize, 64)
+				spec.MinQty, _ = strconv.ParseFloat(f.MinQty, 64)
+			case "MIN_NOTIONAL":
+				spec.MinNotional, _ = strconv.ParseFloat(f.Notional, 64)
+			}
+		}
+		specs[unifiedSymbol] = spec
+	}
+	return specs, nil
+}
+
+// ToTickerBidAsk converts a BinanceBookTickerDto of a market to a shared.TickerBidAsk.
+func (b BinanceBookTickerDto) ToTickerBidAsk(market BinanceMarket) (shared.TickerBidAsk, error) {
+	unifiedSymbol, err := market.Unwrap(b.Symbol)
+	if err != nil {
+		return shared.TickerBidAsk{}, newError(CodeSymbolUnsupported, market.Name, fmt.Errorf("failed to unwrap %s symbol %s: %w", market.Name, b.Symbol, err))
 	}
 
 	bid, err := strconv.ParseFloat(b.BidPrice, 64)
 	if err != nil {
-		return shared.TickerBidAsk{}, fmt.Errorf("failed to parse Binance bid price %s: %w", b.BidPrice, err)
+		return shared.TickerBidAsk{}, newError(CodeParseError, market.Name, fmt.Errorf("failed to parse %s bid price %s: %w", market.Name, b.BidPrice, err))
 	}
 
 	ask, err := strconv.ParseFloat(b.AskPrice, 64)
 	if err != nil {
-		return shared.TickerBidAsk{}, fmt.Errorf("failed to parse Binance ask price %s: %w", b.AskPrice, err)
+		return shared.TickerBidAsk{}, newError(CodeParseError, market.Name, fmt.Errorf("failed to parse %s ask price %s: %w", market.Name, b.AskPrice, err))
 	}
 
-	// As per instruction, hardcode volume for Binance
+	// The book ticker carries no volume; the adapter overrides this with UpdateVolumes data when available
 	volumeUSD := 1_000_000.0
 
+	// Sizes are informational, so parse failures just leave them unknown, as are COIN-M's, counted in contracts
+	var bidQty, askQty float64
+	if !market.inverse {
+		bidQty, _ = strconv.ParseFloat(b.BidQty, 64)
+		askQty, _ = strconv.ParseFloat(b.AskQty, 64)
+	}
+
+	timestamp := time.Now()
+	if b.Time > 0 {
+		timestamp = time.UnixMilli(b.Time)
+	}
+
 	return shared.TickerBidAsk{
 			Symbol:        b.Symbol,
 			UnifiedSymbol: unifiedSymbol,
 			Bid:           bid,
 			Ask:           ask,
 			VolumeUSD:     volumeUSD,
+			BidQty:        bidQty,
+			AskQty:        askQty,
+			Timestamp:     timestamp,
 		},
 		nil
 }
 
-// UnwrapBinanceSymbol converts a Binance symbol (e.g., "BTCUSDT") to our unified format (e.g., "BTC/USDT:PERP").
-func UnwrapBinanceSymbol(binanceSymbol string) (string, error) {
-	if !strings.HasSuffix(binanceSymbol, "USDT") {
-		return "", shared.ErrUnsupportedQuoteCurrency
+// quoteVolume returns the 24h quote volume of the statistics: reported on USDⓈ-M, and derived from the base
+// volume on COIN-M.
+func (b Binance24hrTickerDto) quoteVolume() (float64, bool) {
+	if b.QuoteVolume != "" {
+		v, err := strconv.ParseFloat(b.QuoteVolume, 64)
+		return v, err == nil
 	}
-	base := strings.TrimSuffix(binanceSymbol, "USDT")
-	return base + "/USDT:PERP", nil
+	base, err := strconv.ParseFloat(b.BaseVolume, 64)
+	if err != nil {
+		return 0, false
+	}
+	price, err := strconv.ParseFloat(b.WeightedAvgPrice, 64)
+	return base * price, err == nil
+}
+
+// ToFundingRateInfo converts a BinanceFundingRateDto to a shared.FundingRateInfo.
+func (b BinanceFundingRateDto) ToFundingRateInfo() (shared.FundingRateInfo, error) {
+	rate, err := strconv.ParseFloat(b.LastFundingRate, 64)
+	if err != nil {
+		return shared.FundingRateInfo{}, newError(CodeParseError, "Binance", fmt.Errorf("failed to parse Binance funding rate %s: %w", b.LastFundingRate, err))
+	}
+	return shared.FundingRateInfo{
+		Rate:           rate,
+		Interval:       b.FundingIntervalHours,
+		NextSettleTime: b.NextFundingTime,
+	}, nil
+}
+
+// UnwrapBinanceSymbol converts a Binance USDⓈ-M symbol (e.g., "BTCUSDT", "BTCUSDC") to our unified format (e.g., "BTC/USDT:PERP").
+func UnwrapBinanceSymbol(binanceSymbol string) (string, error) {
+	return BinanceUSDM.Unwrap(binanceSymbol)
+}
+
+// WrapBinanceSymbol converts a unified symbol (e.g., "BTC/USDT:PERP") to the Binance USDⓈ-M format (e.g., "BTCUSDT").
+func WrapBinanceSymbol(unifiedSymbol string) (string, error) {
+	return BinanceUSDM.Wrap(unifiedSymbol)
 }
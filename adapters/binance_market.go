@@ -0,0 +1,96 @@
+package adapters
+
+import (
+	"fmt"
+	"strings"
+
+	"cex-price-diff-notifications/shared"
+)
+
+const (
+	binanceFuturesURL     = "https://fapi.binance.com"
+	binanceCoinFuturesURL = "https://dapi.binance.com"
+
+	binanceCoinFuturesStreamURL = "wss://dstream.binance.com"
+
+	BinanceFundingCachePrefix      = "binance:funding_rate:"
+	BinanceCoinMFundingCachePrefix = "binance_coinm:funding_rate:"
+)
+
+// BinanceMarket is one of the Binance futures contract families. They share the shape of their API, so one
+// BinanceAdapter implementation serves both, but not its host, path prefix, symbols or streams.
+type BinanceMarket struct {
+	Name               string // Exchange name in errors, logs and metrics
+	FundingCachePrefix string // Redis key prefix of the cached funding rates
+	baseURL            string // REST API host
+	apiPrefix          string // Path prefix of the market data endpoints, e.g., "/fapi"
+	streamURL          string // Market streams host
+	markPriceStream    bool   // Whether the mark prices of every symbol are pushed on one stream
+	inverse            bool   // Contracts are quoted in USD and margined in the base asset
+}
+
+// Binance futures contract families.
+var (
+	// BinanceUSDM is the USDⓈ-M family: linear perpetuals quoted and margined in stablecoins, e.g., BTCUSDT
+	// for "BTC/USDT:PERP". Quantities are base units.
+	BinanceUSDM = BinanceMarket{
+		Name:               "Binance",
+		FundingCachePrefix: BinanceFundingCachePrefix,
+		baseURL:            binanceFuturesURL,
+		apiPrefix:          "/fapi",
+		streamURL:          binanceFuturesStreamURL,
+		markPriceStream:    true,
+	}
+
+	// BinanceCoinM is the COIN-M family: inverse perpetuals quoted in USD and margined in the base asset, e.g.,
+	// BTCUSD_PERP for "BTC/USD:INVERSE". Quantities are contracts worth a fixed USD amount, its ContractSize.
+	BinanceCoinM = BinanceMarket{
+		Name:               "BinanceCoinM",
+		FundingCachePrefix: BinanceCoinMFundingCachePrefix,
+		baseURL:            binanceCoinFuturesURL,
+		apiPrefix:          "/dapi",
+		streamURL:          binanceCoinFuturesStreamURL,
+		inverse:            true,
+	}
+)
+
+// binanceCoinMSuffix ends the symbols of COIN-M perpetuals; its delivery contracts end with their date instead.
+const binanceCoinMSuffix = "USD_PERP"
+
+// url returns the URL of a market data endpoint, e.g., "/v1/ticker/bookTicker".
+func (m BinanceMarket) url(path string) string {
+	return m.baseURL + m.apiPrefix + path
+}
+
+// Unwrap converts a symbol of the market (e.g., "BTCUSDT" or "BTCUSD_PERP") to our unified format (e.g.,
+// "BTC/USDT:PERP" or "BTC/USD:INVERSE").
+func (m BinanceMarket) Unwrap(binanceSymbol string) (string, error) {
+	if !m.inverse {
+		symbol, err := shared.BinanceSymbols.Parse(binanceSymbol, shared.MarketPerp)
+		if err != nil {
+			return "", err
+		}
+		return symbol.String(), nil
+	}
+	base, found := strings.CutSuffix(binanceSymbol, binanceCoinMSuffix)
+	if !found || base == "" {
+		return "", fmt.Errorf("%s is not a %s perpetual", binanceSymbol, m.Name)
+	}
+	return shared.NewSymbol(base, "USD", shared.MarketInverse).String(), nil
+}
+
+// Wrap converts a unified symbol (e.g., "BTC/USDT:PERP" or "BTC/USD:INVERSE") to the format of the market (e.g.,
+// "BTCUSDT" or "BTCUSD_PERP").
+func (m BinanceMarket) Wrap(unifiedSymbol string) (string, error) {
+	symbol, err := shared.ParseSymbol(unifiedSymbol)
+	if err != nil {
+		return "", err
+	}
+	if !m.inverse {
+		return shared.BinanceSymbols.Format(symbol), nil
+	}
+	if symbol.Quote() != "USD" || symbol.Market() != shared.MarketInverse {
+		return "", fmt.Errorf("%s has no %s perpetual", unifiedSymbol, m.Name)
+	}
+	return symbol.Base() + binanceCoinMSuffix, nil
+}
@@ -0,0 +1,150 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"cex-price-diff-notifications/shared/retry"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	binanceFuturesStreamURL    = "wss://fstream.binance.com"
+	binanceMarkPriceStreamPath = "/ws/!markPrice@arr@1s"
+	binanceFundingInfoRefresh  = time.Hour        // Funding intervals, missing from the stream, rarely change
+	binanceStreamReadTimeout   = 30 * time.Second // The stream pushes every second; silence this long means it's dead
+	binanceStreamStaleAfter    = 10 * time.Second // After this long without a message, the stream's rates are stale
+)
+
+// streamReconnect paces the reconnections to a dropped stream.
+var streamReconnect = retry.Policy{InitialDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}
+
+// SetStreamURL sets the base URL of the futures market streams, e.g., to stream from a testnet or simulator.
+// It must be called before StreamFundingRates.
+func (a *BinanceAdapter) SetStreamURL(url string) {
+	a.streamURL = url
+}
+
+// StreamFundingRates keeps the funding rates updated from the mark price stream, which pushes the funding rate
+// and next funding time of every symbol each second, until ctx is cancelled. A dropped stream, e.g., at
+// Binance's daily disconnect, is reconnected with backoff. Markets without such a stream, as COIN-M, return
+// right away, leaving the funding rates to UpdateFundingRates. It should be run in a goroutine.
+func (a *BinanceAdapter) StreamFundingRates(ctx context.Context) {
+	if !a.market.markPriceStream {
+		slog.Info("Binance market has no mark price stream of every symbol, polling its funding rates", "market", a.market.Name)
+		return
+	}
+	for attempt := 1; ctx.Err() == nil; attempt++ {
+		received, err := a.streamFundingRates(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			attempt = 1
+		}
+		delay := streamReconnect.Delay(attempt)
+		slog.Warn("Binance mark price stream disconnected, reconnecting", "market", a.market.Name, "code", CodeOf(err), "error", err, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// FundingStreamLive reports whether the mark price stream delivered an update recently, so the funding rates
+// don't need polling.
+func (a *BinanceAdapter) FundingStreamLive(now time.Time) bool {
+	at := a.fundingStreamAt.Load()
+	return at > 0 && now.Sub(time.UnixMilli(at)) < binanceStreamStaleAfter
+}
+
+// FundingUpdatedAt returns the time of the last funding rate update, polled or streamed, or the zero time before
+// the first one; rates loaded from the cache don't count.
+func (a *BinanceAdapter) FundingUpdatedAt() time.Time {
+	at := a.fundingAt.Load()
+	if at == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(at)
+}
+
+// streamFundingRates reads the mark price stream until it fails, reporting whether any update was received.
+func (a *BinanceAdapter) streamFundingRates(ctx context.Context) (bool, error) {
+	a.mu.RLock()
+	known := a.fundingIntervals != nil
+	a.mu.RUnlock()
+	if !known {
+		if err := a.refreshFundingIntervals(ctx); err != nil {
+			slog.Error("Failed to fetch Binance funding intervals, assuming 8 hours until the next refresh", "market", a.market.Name, "code", CodeOf(err), "error", err)
+		}
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, a.streamURL+binanceMarkPriceStreamPath, nil)
+	if err != nil {
+		return false, newError(CodeExchangeDown, a.market.Name, fmt.Errorf("failed to connect to the %s mark price stream: %w", a.market.Name, err))
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	slog.Info("Connected to the Binance mark price stream", "market", a.market.Name)
+
+	var received bool
+	for {
+		conn.SetReadDeadline(time.Now().Add(binanceStreamReadTimeout))
+		var updates []BinanceMarkPriceDto
+		if err := conn.ReadJSON(&updates); err != nil {
+			return received, newError(CodeExchangeDown, a.market.Name, fmt.Errorf("failed to read the %s mark price stream: %w", a.market.Name, err))
+		}
+		received = true
+		a.applyMarkPrices(updates)
+
+		a.mu.RLock()
+		due := time.Since(a.fundingIntervalsAt) >= binanceFundingInfoRefresh
+		a.mu.RUnlock()
+		if due {
+			if err := a.refreshFundingIntervals(ctx); err != nil {
+				slog.Error("Failed to refresh Binance funding intervals", "market", a.market.Name, "code", CodeOf(err), "error", err)
+			}
+			// The cache is saved as often as the intervals are refreshed, rather than every second
+			a.saveFundingRates(ctx)
+		}
+	}
+}
+
+// refreshFundingIntervals fetches the funding intervals, which the stream doesn't carry.
+func (a *BinanceAdapter) refreshFundingIntervals(ctx context.Context) error {
+	infos, err := a.getFundingInfos(ctx)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		// Retried at the next refresh; the intervals known so far still apply
+		a.fundingIntervalsAt = time.Now()
+		return err
+	}
+	a.setFundingIntervals(infos)
+	return nil
+}
+
+// applyMarkPrices stores the funding rates of a mark price stream message.
+func (a *BinanceAdapter) applyMarkPrices(updates []BinanceMarkPriceDto) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, u := range updates {
+		unifiedSymbol, err := a.market.Unwrap(u.Symbol)
+		if err != nil || u.NextFundingTime <= 0 {
+			continue
+		}
+		a.fundingRates[unifiedSymbol] = BinanceFundingRateDto{
+			Symbol:               u.Symbol,
+			LastFundingRate:      u.FundingRate,
+			NextFundingTime:      u.NextFundingTime,
+			FundingIntervalHours: a.fundingInterval(u.Symbol),
+		}
+	}
+	now := time.Now().UnixMilli()
+	a.fundingStreamAt.Store(now)
+	a.fundingAt.Store(now)
+}
@@ -0,0 +1,113 @@
+package adapters
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// PayloadObserver is told the size of each exchange response body once it is closed: the bytes received and
+// the bytes after decompression, equal for uncompressed responses.
+type PayloadObserver func(exchange string, wire, decoded int64)
+
+// payloadObserver is the PayloadObserver of every exchange request, nil until set.
+var payloadObserver atomic.Pointer[PayloadObserver]
+
+// SetPayloadObserver sets the observer of the exchange response sizes, e.g., to export them as metrics.
+func SetPayloadObserver(observe PayloadObserver) {
+	payloadObserver.Store(&observe)
+}
+
+// gzipTransport requests gzip-compressed responses and decompresses them, counting the bytes on the wire and
+// after decompression. Go's transport only decompresses transparently when it sets Accept-Encoding itself,
+// which hides the compressed size.
+type gzipTransport struct {
+	next http.RoundTripper
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") != "" {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &payloadBody{wire: &countingReader{r: resp.Body}, closer: resp.Body, exchange: exchangeOfHost(req.URL.Hostname())}
+	body.decoded = body.wire
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		body.gzipped = true
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	resp.Body = body
+	return resp, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// payloadBody is a response body, decompressed if gzipped, that reports its sizes when closed.
+type payloadBody struct {
+	wire     *countingReader
+	decoded  io.Reader // wire, or the gzip reader over it
+	closer   io.Closer
+	exchange string
+	gzipped  bool
+	read     int64
+	closed   bool
+}
+
+func (b *payloadBody) Read(p []byte) (int, error) {
+	if b.gzipped {
+		// The gzip header is read on the first Read rather than when the response arrives
+		zr, err := gzip.NewReader(b.wire)
+		if err != nil {
+			return 0, err
+		}
+		b.decoded, b.gzipped = zr, false
+	}
+	n, err := b.decoded.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (b *payloadBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		if observe := payloadObserver.Load(); observe != nil && b.wire.n > 0 {
+			(*observe)(b.exchange, b.wire.n, b.read)
+		}
+	}
+	return b.closer.Close()
+}
+
+// exchangeOfHost returns the exchange an API host belongs to, or the host if unknown.
+func exchangeOfHost(host string) string {
+	switch {
+	case strings.HasSuffix(host, "binance.com"):
+		return "Binance"
+	case strings.HasSuffix(host, "mexc.com"):
+		return "Mexc"
+	case strings.HasSuffix(host, "gmxinfra.io"):
+		return "GMX"
+	default:
+		return host
+	}
+}
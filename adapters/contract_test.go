@@ -0,0 +1,30 @@
+package adapters_test
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/testsupport"
+	"net/http"
+	"testing"
+)
+
+// TestAdapterContracts checks the exchange adapters against fake exchange servers, without the live APIs.
+func TestAdapterContracts(t *testing.T) {
+	binance := testsupport.NewBinance()
+	defer binance.Close()
+	mexc := testsupport.NewMexc()
+	defer mexc.Close()
+	adapters.SetTransport(testsupport.Transport(binance, mexc))
+	t.Cleanup(func() { adapters.SetTransport(http.DefaultTransport) })
+
+	contracts := []testsupport.Contract{
+		testsupport.BinanceContract(adapters.NewBinanceAdapter(), binance),
+		testsupport.MexcContract(adapters.NewMexcAdapter(), mexc),
+	}
+	for _, c := range contracts {
+		t.Run(c.Server.Name, func(t *testing.T) {
+			if err := testsupport.CheckAdapter(t.Context(), c); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cex-price-diff-notifications/shared"
+)
+
+const (
+	binanceDepthPath = "/v1/depth" // After the market's prefix
+	mexcDepthPath    = "/api/v1/contract/depth/"
+)
+
+// binanceDepthLimits are the depths Binance accepts, in levels per side.
+var binanceDepthLimits = []int{5, 10, 20, 50, 100, 500, 1000}
+
+// GetOrderBook fetches the top limit levels of each side of the order book of a unified symbol of the market
+// from Binance. COIN-M quantities are contracts.
+func (a *BinanceAdapter) GetOrderBook(ctx context.Context, unifiedSymbol string, limit int) (shared.OrderBook, error) {
+	name := a.market.Name
+	binanceSymbol, err := a.market.Wrap(unifiedSymbol)
+	if err != nil {
+		return shared.OrderBook{}, newError(CodeSymbolUnsupported, name, err)
+	}
+	// Other depths are rejected; the closest one above is asked for and the extra levels dropped
+	depth := binanceDepthLimits[len(binanceDepthLimits)-1]
+	for _, l := range binanceDepthLimits {
+		if l >= limit {
+			depth = l
+			break
+		}
+	}
+
+	url := fmt.Sprintf("%s?symbol=%s&limit=%d", a.market.url(binanceDepthPath), binanceSymbol, depth)
+	resp, err := httpGet(ctx, name, url)
+	if err != nil {
+		return shared.OrderBook{}, fmt.Errorf("failed to make HTTP request to %s order book: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return shared.OrderBook{}, statusError(name, resp, bodyBytes, fmt.Errorf("%s order book API returned non-OK status: %d, body: %s", name, resp.StatusCode, string(bodyBytes)))
+	}
+
+	var dto BinanceDepthDto
+	if err := decodeJSON(resp.Body, &dto); err != nil {
+		return shared.OrderBook{}, decodeError(name, fmt.Errorf("failed to decode %s order book: %w", name, err))
+	}
+	bids, err := binanceLevels(dto.Bids, limit)
+	if err != nil {
+		return shared.OrderBook{}, err
+	}
+	asks, err := binanceLevels(dto.Asks, limit)
+	if err != nil {
+		return shared.OrderBook{}, err
+	}
+	timestamp := time.Now()
+	if dto.TransactionTime > 0 {
+		timestamp = time.UnixMilli(dto.TransactionTime)
+	}
+	return shared.OrderBook{Exchange: name, UnifiedSymbol: unifiedSymbol, Bids: bids, Asks: asks, Timestamp: timestamp}, nil
+}
+
+// binanceLevels parses up to limit levels of a side of a Binance order book.
+func binanceLevels(raw [][2]string, limit int) ([]shared.PriceLevel, error) {
+	levels := make([]shared.PriceLevel, 0, min(len(raw), limit))
+	for _, l := range raw[:min(len(raw), limit)] {
+		price, err := strconv.ParseFloat(l[0], 64)
+		if err != nil {
+			return nil, newError(CodeParseError, "Binance", fmt.Errorf("failed to parse Binance order book price %q: %w", l[0], err))
+		}
+		qty, err := strconv.ParseFloat(l[1], 64)
+		if err != nil {
+			return nil, newError(CodeParseError, "Binance", fmt.Errorf("failed to parse Binance order book quantity %q: %w", l[1], err))
+		}
+		levels = append(levels, shared.PriceLevel{Price: price, Qty: qty})
+	}
+	return levels, nil
+}
+
+// GetOrderBook fetches the top limit levels of each side of the order book of a unified symbol from Mexc.
+// Contract quantities are converted to base units using the contract size.
+func (a *MexcAdapter) GetOrderBook(ctx context.Context, unifiedSymbol string, limit int) (shared.OrderBook, error) {
+	mexcSymbol, err := WrapMexcSymbol(unifiedSymbol)
+	if err != nil {
+		return shared.OrderBook{}, newError(CodeSymbolUnsupported, "Mexc", err)
+	}
+
+	url := fmt.Sprintf("%s%s%s?limit=%d", mexcFuturesURL, mexcDepthPath, mexcSymbol, limit)
+	resp, err := httpGet(ctx, "Mexc", url)
+	if err != nil {
+		return shared.OrderBook{}, fmt.Errorf("failed to make HTTP request to Mexc order book: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return shared.OrderBook{}, statusError("Mexc", resp, bodyBytes, fmt.Errorf("Mexc order book API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return shared.OrderBook{}, newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to read Mexc order book response body: %w", err))
+	}
+	var response MexcDepthResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return shared.OrderBook{}, decodeError("Mexc", fmt.Errorf("failed to unmarshal Mexc order book: %w", err))
+	}
+	if !response.Success {
+		return shared.OrderBook{}, mexcError(response.Code, fmt.Errorf("Mexc order book API returned success: false, code: %d", response.Code))
+	}
+
+	a.mu.RLock()
+	known := a.contractSizes != nil
+	a.mu.RUnlock()
+	if !known {
+		if _, err := a.GetContractSpecs(ctx); err != nil {
+			return shared.OrderBook{}, err
+		}
+	}
+	a.mu.RLock()
+	contractSize, ok := a.contractSizes[unifiedSymbol]
+	a.mu.RUnlock()
+	if !ok || contractSize <= 0 {
+		contractSize = 1
+	}
+
+	timestamp := time.Now()
+	if response.Data.Timestamp > 0 {
+		timestamp = time.UnixMilli(response.Data.Timestamp)
+	}
+	return shared.OrderBook{
+		Exchange:      "Mexc",
+		UnifiedSymbol: unifiedSymbol,
+		Bids:          mexcLevels(response.Data.Bids, limit, contractSize),
+		Asks:          mexcLevels(response.Data.Asks, limit, contractSize),
+		Timestamp:     timestamp,
+	}, nil
+}
+
+// mexcLevels converts up to limit levels of a side of a Mexc order book, skipping malformed ones.
+func mexcLevels(raw [][]float64, limit int, contractSize float64) []shared.PriceLevel {
+	levels := make([]shared.PriceLevel, 0, min(len(raw), limit))
+	for _, l := range raw {
+		if len(levels) == limit {
+			break
+		}
+		if len(l) < 2 {
+			continue
+		}
+		levels = append(levels, shared.PriceLevel{Price: l[0], Qty: l[1] * contractSize})
+	}
+	return levels
+}
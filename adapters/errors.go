@@ -0,0 +1,176 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorCode classifies an adapter failure, so callers can react to it: back off, retry, alert or skip.
+type ErrorCode string
+
+// Adapter error codes.
+const (
+	CodeRateLimited       ErrorCode = "rate_limited"       // The exchange throttled the requests; back off before the next one
+	CodeExchangeDown      ErrorCode = "exchange_down"      // The exchange is unreachable or failing (network error, 5xx); retry later
+	CodeAuthFailed        ErrorCode = "auth_failed"        // Credentials are missing, invalid or not allowed; retrying won't help
+	CodeParseError        ErrorCode = "parse_error"        // The response doesn't have the expected format
+	CodeSymbolUnsupported ErrorCode = "symbol_unsupported" // The exchange doesn't list the symbol, or its quote currency isn't supported
+	CodeRejected          ErrorCode = "rejected"           // The exchange refused the request for another reason
+)
+
+// Error is a failure of an adapter method, classified by its code. Every adapter method returns one (possibly
+// wrapped) on failure, except when ctx is cancelled.
+type Error struct {
+	Code       ErrorCode
+	Exchange   string
+	Status     int           // HTTP status or exchange error code, 0 if none
+	RetryAfter time.Duration // Delay the exchange asked for before the next request, 0 if none
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return string(e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is matches the error of the same code among ErrRateLimited, ErrExchangeDown, ErrAuthFailed, ErrParse,
+// ErrSymbolUnsupported and ErrRejected.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Err == nil && t.Code == e.Code
+}
+
+// Sentinels matching the adapter errors of a code with errors.Is.
+var (
+	ErrRateLimited       = &Error{Code: CodeRateLimited}
+	ErrExchangeDown      = &Error{Code: CodeExchangeDown}
+	ErrAuthFailed        = &Error{Code: CodeAuthFailed}
+	ErrParse             = &Error{Code: CodeParseError}
+	ErrSymbolUnsupported = &Error{Code: CodeSymbolUnsupported}
+	ErrRejected          = &Error{Code: CodeRejected}
+)
+
+// CodeOf returns the code of an adapter error, or "" if err isn't one (e.g., a cancellation).
+func CodeOf(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// RetryAfter returns the delay an exchange asked for before the next request, or 0 if err doesn't carry one.
+func RetryAfter(err error) time.Duration {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.RetryAfter
+	}
+	return 0
+}
+
+// newError classifies err as a failure of an exchange.
+func newError(code ErrorCode, exchange string, err error) error {
+	return &Error{Code: code, Exchange: exchange, Err: err}
+}
+
+// requestError classifies a failed request: cancellations are returned as is, failures already classified
+// keep their code, and the rest are network failures.
+func requestError(ctx context.Context, exchange string, err error) error {
+	if ctx.Err() != nil || CodeOf(err) != "" {
+		return err
+	}
+	return newError(CodeExchangeDown, exchange, err)
+}
+
+// decodeError classifies a failure to decode a response: malformed or truncated payloads are parse errors,
+// while failures to read the body are network failures.
+func decodeError(exchange string, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return newError(CodeParseError, exchange, err)
+	}
+	return newError(CodeExchangeDown, exchange, err)
+}
+
+// statusError classifies a response with a non-OK status, err describing it. Binance explains 4xx statuses
+// with an error code in the body, which refines the classification.
+func statusError(exchange string, resp *http.Response, body []byte, err error) error {
+	e := &Error{Code: statusCode(resp.StatusCode), Exchange: exchange, Status: resp.StatusCode, Err: err}
+	if e.Code == CodeRateLimited {
+		e.RetryAfter = retryAfter(resp.Header)
+	}
+	var apiErr struct {
+		Code int `json:"code"`
+	}
+	if e.Code == CodeRejected && exchange == "Binance" && json.Unmarshal(body, &apiErr) == nil {
+		e.Code = binanceCode(apiErr.Code)
+	}
+	return e
+}
+
+// statusCode classifies an HTTP status other than 200.
+func statusCode(status int) ErrorCode {
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusTeapot: // Binance answers 418 to clients that ignored 429s
+		return CodeRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return CodeAuthFailed
+	case status >= 500:
+		return CodeExchangeDown
+	}
+	return CodeRejected
+}
+
+// binanceCode classifies a Binance error code.
+func binanceCode(code int) ErrorCode {
+	switch code {
+	case -1003, -1015: // Too many requests, too many orders
+		return CodeRateLimited
+	case -1001, -1007, -1016: // Disconnected, timeout, service shutting down
+		return CodeExchangeDown
+	case -1002, -1022, -2014, -2015: // Unauthorized, invalid signature, bad API key format, rejected API key
+		return CodeAuthFailed
+	case -1121, -1122, -4141: // Invalid symbol, invalid symbol status, symbol delisted
+		return CodeSymbolUnsupported
+	}
+	return CodeRejected
+}
+
+// mexcError classifies a Mexc response with success: false, err describing it. Rate limited responses are
+// also told to the rate limit observer, as they come with a 200 status.
+func mexcError(code int, err error) error {
+	e := &Error{Code: CodeRejected, Exchange: "Mexc", Status: code, Err: err}
+	switch code {
+	case 510: // Excessive frequency of requests
+		e.Code = CodeRateLimited
+		if observe := rateLimitObserver.Load(); observe != nil {
+			(*observe)("Mexc", 1)
+		}
+	case 500, 501: // Internal error, system busy
+		e.Code = CodeExchangeDown
+	case 401, 402, 403, 406, 602: // Unauthorized, key expired, forbidden, IP not whitelisted, invalid signature
+		e.Code = CodeAuthFailed
+	case 1001, 1002: // Contract doesn't exist, contract not activated
+		e.Code = CodeSymbolUnsupported
+	}
+	return e
+}
+
+// retryAfter parses a Retry-After header in seconds, or returns 0.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
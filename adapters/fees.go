@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"cex-price-diff-notifications/shared"
+)
+
+const (
+	binanceCommissionRatePath = "/fapi/v1/commissionRate"
+	binanceSpotCommissionPath = "/api/v3/account/commission"
+	mexcTieredFeeRatePath     = "/api/v1/private/account/tiered_fee_rate"
+	mexcTradeFeePath          = "/api/v3/tradeFee"
+	feeReferenceBase          = "BTC" // Fees are read on the BTC market of the default quote, standing for the account's tier
+	bpsPerFraction            = 10_000
+)
+
+// feeReferenceSymbol returns the market the account fees are read on.
+func feeReferenceSymbol(market shared.MarketType) shared.Symbol {
+	return shared.NewSymbol(feeReferenceBase, shared.DefaultQuoteCurrency(), market)
+}
+
+// GetFeeRates fetches the fee rates of the Binance futures account's tier. It requires API credentials.
+func (a *BinanceAdapter) GetFeeRates(ctx context.Context) (shared.FeeRates, error) {
+	params := url.Values{"symbol": {shared.BinanceSymbols.Format(feeReferenceSymbol(shared.MarketPerp))}}
+	body, err := signedGet(ctx, "Binance", binanceFuturesURL, binanceCommissionRatePath, binanceAPIKeyHeader, a.credentials.get(), params)
+	if err != nil {
+		return shared.FeeRates{}, err
+	}
+	var dto BinanceCommissionRateDto
+	if err := json.Unmarshal(body, &dto); err != nil {
+		return shared.FeeRates{}, decodeError("Binance", fmt.Errorf("failed to unmarshal Binance commission rate: %w", err))
+	}
+	maker, _ := strconv.ParseFloat(dto.MakerCommissionRate, 64)
+	taker, err := strconv.ParseFloat(dto.TakerCommissionRate, 64)
+	if err != nil {
+		return shared.FeeRates{}, newError(CodeParseError, "Binance", fmt.Errorf("failed to parse Binance taker commission rate %q: %w", dto.TakerCommissionRate, err))
+	}
+	return shared.FeeRates{MakerBps: maker * bpsPerFraction, TakerBps: taker * bpsPerFraction}, nil
+}
+
+// GetSpotFeeRates fetches the fee rates of the Binance spot account's tier, taxes included. It requires API
+// credentials.
+func (a *BinanceAdapter) GetSpotFeeRates(ctx context.Context) (shared.FeeRates, error) {
+	params := url.Values{"symbol": {shared.BinanceSymbols.Format(feeReferenceSymbol(shared.MarketSpot))}}
+	body, err := signedGet(ctx, "Binance", binanceSpotURL, binanceSpotCommissionPath, binanceAPIKeyHeader, a.credentials.get(), params)
+	if err != nil {
+		return shared.FeeRates{}, err
+	}
+	var dto BinanceSpotCommissionDto
+	if err := json.Unmarshal(body, &dto); err != nil {
+		return shared.FeeRates{}, decodeError("Binance", fmt.Errorf("failed to unmarshal Binance spot commission: %w", err))
+	}
+	taker, err := strconv.ParseFloat(dto.StandardCommission.Taker, 64)
+	if err != nil {
+		return shared.FeeRates{}, newError(CodeParseError, "Binance", fmt.Errorf("failed to parse Binance spot taker commission %q: %w", dto.StandardCommission.Taker, err))
+	}
+	maker, _ := strconv.ParseFloat(dto.StandardCommission.Maker, 64)
+	makerTax, _ := strconv.ParseFloat(dto.TaxCommission.Maker, 64)
+	takerTax, _ := strconv.ParseFloat(dto.TaxCommission.Taker, 64)
+	return shared.FeeRates{MakerBps: (maker + makerTax) * bpsPerFraction, TakerBps: (taker + takerTax) * bpsPerFraction}, nil
+}
+
+// GetFeeRates fetches the fee rates of the Mexc futures account's tier. It requires API credentials.
+func (a *MexcAdapter) GetFeeRates(ctx context.Context) (shared.FeeRates, error) {
+	body, err := mexcSignedGet(ctx, mexcTieredFeeRatePath, a.credentials.get())
+	if err != nil {
+		return shared.FeeRates{}, err
+	}
+	var response MexcTieredFeeRateResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return shared.FeeRates{}, decodeError("Mexc", fmt.Errorf("failed to unmarshal Mexc fee tier: %w", err))
+	}
+	if !response.Success {
+		return shared.FeeRates{}, mexcError(response.Code, fmt.Errorf("Mexc fee tier API returned success: false, code: %d", response.Code))
+	}
+	return shared.FeeRates{MakerBps: response.Data.MakerFee * bpsPerFraction, TakerBps: response.Data.TakerFee * bpsPerFraction}, nil
+}
+
+// GetSpotFeeRates fetches the fee rates of the Mexc spot account. It requires API credentials.
+func (a *MexcAdapter) GetSpotFeeRates(ctx context.Context) (shared.FeeRates, error) {
+	params := url.Values{"symbol": {shared.BinanceSymbols.Format(feeReferenceSymbol(shared.MarketSpot))}} // Mexc spot symbols have no separator
+	body, err := signedGet(ctx, "Mexc", mexcSpotURL, mexcTradeFeePath, mexcAPIKeyHeader, a.credentials.get(), params)
+	if err != nil {
+		return shared.FeeRates{}, err
+	}
+	var response MexcTradeFeeResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return shared.FeeRates{}, decodeError("Mexc", fmt.Errorf("failed to unmarshal Mexc trade fee: %w", err))
+	}
+	if response.Code != 0 && response.Code != 200 {
+		return shared.FeeRates{}, mexcError(response.Code, fmt.Errorf("Mexc trade fee API returned code %d: %s", response.Code, response.Msg))
+	}
+	return shared.FeeRates{MakerBps: response.Data.MakerCommission * bpsPerFraction, TakerBps: response.Data.TakerCommission * bpsPerFraction}, nil
+}
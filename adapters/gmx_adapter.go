@@ -0,0 +1,250 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cex-price-diff-notifications/logging"
+	"cex-price-diff-notifications/shared"
+)
+
+const (
+	gmxURL             = "https://arbitrum-api.gmxinfra.io"
+	gmxTickersPath     = "/prices/tickers"
+	gmxTokensPath      = "/tokens"
+	gmxMarketsInfoPath = "/markets/info"
+	gmxPingPath        = "/ping"
+
+	gmxQuote         = "USDC" // GMX markets are quoted in USD, settled in USDC
+	gmxScale         = 30     // Decimals of GMX prices, open interests and rates
+	gmxHoursPerYear  = 365 * 24
+	gmxFundingPeriod = 1 // Hours; GMX accrues funding and borrowing fees continuously, reported here hourly
+)
+
+// gmxAliases maps the symbols of wrapped index tokens to the base of their market.
+var gmxAliases = map[string]string{"WETH": "ETH", "WBTC": "BTC", "WBTC.b": "BTC"}
+
+// GmxAdapter reads perp prices and funding from GMX v2 on Arbitrum, so CEX↔DEX dislocations show up in the
+// same spread feed. It is read-only: GMX positions are opened on-chain, not through an API.
+type GmxAdapter struct {
+	mu           sync.RWMutex
+	tokens       map[string]GmxTokenDto            // Keyed by lowercased address, fetched once
+	fundingRates map[string]shared.FundingRateInfo // Keyed by unified symbol, from the last tickers fetch
+	fundingAt    atomic.Int64                      // Time of the last tickers fetch, in milliseconds since epoch
+}
+
+// NewGmxAdapter creates a new instance of the GmxAdapter.
+func NewGmxAdapter() *GmxAdapter {
+	slog.Info("Initializing GMX adapter...")
+	return &GmxAdapter{fundingRates: make(map[string]shared.FundingRateInfo)}
+}
+
+// GetTickers fetches the latest oracle prices from GMX as unified tickers, one per base asset: GMX lists
+// several markets on the same index token, of which the one with the largest open interest is kept, its open
+// interest standing in for the volume. The funding rates of those markets are refreshed along.
+func (a *GmxAdapter) GetTickers(ctx context.Context) ([]shared.TickerBidAsk, time.Duration, error) {
+	start := time.Now()
+
+	tokens, err := a.getTokens(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var prices []GmxPriceTickerDto
+	if err := gmxGet(ctx, gmxTickersPath, "tickers", &prices); err != nil {
+		return nil, 0, err
+	}
+	var info GmxMarketsInfoResponse
+	if err := gmxGet(ctx, gmxMarketsInfoPath, "markets info", &info); err != nil {
+		return nil, 0, err
+	}
+
+	byAddress := make(map[string]GmxPriceTickerDto, len(prices))
+	for _, p := range prices {
+		byAddress[strings.ToLower(p.TokenAddress)] = p
+	}
+	markets := make(map[string]GmxMarketInfoDto) // Largest listed market per base
+	for _, m := range info.Markets {
+		token, ok := tokens[strings.ToLower(m.IndexToken)]
+		if !m.IsListed || !ok {
+			continue
+		}
+		base := gmxBase(token.Symbol)
+		if best, ok := markets[base]; !ok || gmxOpenInterest(m) > gmxOpenInterest(best) {
+			markets[base] = m
+		}
+	}
+
+	tickers := make([]shared.TickerBidAsk, 0, len(markets))
+	fundingRates := make(map[string]shared.FundingRateInfo, len(markets))
+	nextSettle := time.Now().Truncate(time.Hour).Add(time.Hour).UnixMilli()
+	for base, m := range markets {
+		symbol := base + "-" + gmxQuote
+		_, quote, err := shared.MatchQuoteCurrency(symbol, "-")
+		if err != nil {
+			continue // USDC isn't a configured quote
+		}
+		price, ok := byAddress[strings.ToLower(m.IndexToken)]
+		if !ok {
+			continue
+		}
+		t, err := price.toTickerBidAsk(symbol, shared.PerpSymbol(base, quote).String(), tokens[strings.ToLower(m.IndexToken)].Decimals)
+		if err != nil {
+			logging.Warn("Failed to convert GMX DTO", "market", m.Name, "error", err)
+			continue
+		}
+		t.VolumeUSD = gmxOpenInterest(m)
+		tickers = append(tickers, t)
+
+		rateShort := gmxHourlyRate(m.NetRateShort)
+		fundingRates[t.UnifiedSymbol] = shared.FundingRateInfo{
+			Rate:           -gmxHourlyRate(m.NetRateLong),
+			RateShort:      &rateShort,
+			Interval:       gmxFundingPeriod,
+			NextSettleTime: nextSettle,
+		}
+	}
+
+	a.mu.Lock()
+	a.fundingRates = fundingRates
+	a.mu.Unlock()
+	a.fundingAt.Store(time.Now().UnixMilli())
+
+	return tickers, time.Since(start), nil
+}
+
+// GetFundingRatesSnapshot returns a copy of the latest funding rates, keyed by unified symbol, that later
+// updates don't change. Rate is what longs pay and RateShort what shorts receive each hour, net of the
+// borrowing fees both sides pay.
+func (a *GmxAdapter) GetFundingRatesSnapshot() map[string]shared.FundingRateInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	rates := make(map[string]shared.FundingRateInfo, len(a.fundingRates))
+	for unifiedSymbol, info := range a.fundingRates {
+		rates[unifiedSymbol] = info
+	}
+	return rates
+}
+
+// FundingUpdatedAt returns the time of the last funding rate update, which come with the tickers, or the zero
+// time before the first one.
+func (a *GmxAdapter) FundingUpdatedAt() time.Time {
+	at := a.fundingAt.Load()
+	if at == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(at)
+}
+
+// Ping measures the round-trip latency to the GMX API.
+func (a *GmxAdapter) Ping(ctx context.Context) (time.Duration, error) {
+	return ping(ctx, "GMX", gmxURL+gmxPingPath)
+}
+
+// getTokens returns the tokens GMX prices keyed by lowercased address, fetching them on first use.
+func (a *GmxAdapter) getTokens(ctx context.Context) (map[string]GmxTokenDto, error) {
+	a.mu.RLock()
+	tokens := a.tokens
+	a.mu.RUnlock()
+	if tokens != nil {
+		return tokens, nil
+	}
+
+	var resp GmxTokensResponse
+	if err := gmxGet(ctx, gmxTokensPath, "tokens", &resp); err != nil {
+		return nil, err
+	}
+	tokens = make(map[string]GmxTokenDto, len(resp.Tokens))
+	for _, t := range resp.Tokens {
+		tokens[strings.ToLower(t.Address)] = t
+	}
+
+	a.mu.Lock()
+	a.tokens = tokens
+	a.mu.Unlock()
+	return tokens, nil
+}
+
+// gmxGet fetches a GMX endpoint and decodes it into v; what names the endpoint in errors.
+func gmxGet(ctx context.Context, path, what string, v any) error {
+	resp, err := httpGet(ctx, "GMX", gmxURL+path)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request to GMX %s: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return statusError("GMX", resp, bodyBytes, fmt.Errorf("GMX %s API returned non-OK status: %d, body: %s", what, resp.StatusCode, string(bodyBytes)))
+	}
+	if err := decodeJSON(resp.Body, v); err != nil {
+		return decodeError("GMX", fmt.Errorf("failed to decode GMX %s: %w", what, err))
+	}
+	return nil
+}
+
+// toTickerBidAsk converts an oracle price to a unified ticker: shorts open at the min price and longs at the
+// max price, which act as the bid and ask.
+func (dto GmxPriceTickerDto) toTickerBidAsk(symbol, unifiedSymbol string, decimals int) (shared.TickerBidAsk, error) {
+	bid, errBid := gmxParse(dto.MinPrice, gmxScale-decimals)
+	ask, errAsk := gmxParse(dto.MaxPrice, gmxScale-decimals)
+	if err := errors.Join(errBid, errAsk); err != nil {
+		return shared.TickerBidAsk{}, newError(CodeParseError, "GMX", fmt.Errorf("failed to parse GMX price of %s: %w", dto.TokenSymbol, err))
+	}
+	if bid <= 0 || ask <= 0 {
+		return shared.TickerBidAsk{}, newError(CodeParseError, "GMX", fmt.Errorf("invalid GMX price of %s: min %s, max %s", dto.TokenSymbol, dto.MinPrice, dto.MaxPrice))
+	}
+	timestamp := time.Now()
+	if dto.UpdatedAt > 0 {
+		timestamp = time.UnixMilli(dto.UpdatedAt)
+	}
+	return shared.TickerBidAsk{
+		Symbol:        symbol,
+		UnifiedSymbol: unifiedSymbol,
+		Bid:           bid,
+		Ask:           ask,
+		Timestamp:     timestamp,
+	}, nil
+}
+
+// gmxBase returns the base asset of an index token symbol, unwrapping wrapped tokens.
+func gmxBase(tokenSymbol string) string {
+	if base, ok := gmxAliases[tokenSymbol]; ok {
+		return base
+	}
+	return tokenSymbol
+}
+
+// gmxOpenInterest returns the open interest of a market in USD, both sides included.
+func gmxOpenInterest(m GmxMarketInfoDto) float64 {
+	long, _ := gmxParse(m.OpenInterestLong, gmxScale)
+	short, _ := gmxParse(m.OpenInterestShort, gmxScale)
+	return long + short
+}
+
+// gmxHourlyRate converts an annualized GMX rate to an hourly fraction.
+func gmxHourlyRate(annual string) float64 {
+	rate, _ := gmxParse(annual, gmxScale)
+	return rate / gmxHoursPerYear
+}
+
+// gmxParse parses a GMX integer scaled by 10^decimals. Empty values are 0.
+func gmxParse(value string, decimals int) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return v / math.Pow10(decimals), nil
+}
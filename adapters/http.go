@@ -0,0 +1,126 @@
+package adapters
+
+import (
+	"cex-price-diff-notifications/shared/retry"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// httpClient sends the exchange requests, asking for gzip-compressed responses.
+var httpClient = &http.Client{Transport: &gzipTransport{next: http.DefaultTransport}}
+
+// SetTransport replaces the transport of the exchange requests, e.g., to inject failures. Responses are still
+// requested compressed. It must be called before the adapters are used.
+func SetTransport(transport http.RoundTripper) {
+	httpClient = &http.Client{Transport: &gzipTransport{next: transport}}
+}
+
+// RateLimitObserver is told the rate limit pressure of each exchange response: the fraction of the exchange's
+// request weight limit used, from the weight headers, or 1 when the request was rate limited.
+type RateLimitObserver func(exchange string, usage float64)
+
+// rateLimitObserver is the RateLimitObserver of every exchange request, nil until set.
+var rateLimitObserver atomic.Pointer[RateLimitObserver]
+
+// SetRateLimitObserver sets the observer of the exchanges' rate limit pressure, e.g., to adapt the polling.
+func SetRateLimitObserver(observe RateLimitObserver) {
+	rateLimitObserver.Store(&observe)
+}
+
+// weightLimits are the request weights allowed per minute by the APIs reporting them in the
+// X-MBX-USED-WEIGHT-1M header, keyed by host.
+var weightLimits = map[string]float64{
+	"fapi.binance.com": 2400,
+	"dapi.binance.com": 2400,
+	"api.binance.com":  6000,
+}
+
+// observeRateLimit tells the rate limit observer, if any, the pressure reported by a response.
+func observeRateLimit(exchange string, req *http.Request, resp *http.Response) {
+	observe := rateLimitObserver.Load()
+	if observe == nil {
+		return
+	}
+	if statusCode(resp.StatusCode) == CodeRateLimited {
+		(*observe)(exchange, 1)
+		return
+	}
+	limit, ok := weightLimits[req.URL.Hostname()]
+	if !ok {
+		return
+	}
+	if used, err := strconv.ParseFloat(resp.Header.Get("X-MBX-USED-WEIGHT-1M"), 64); err == nil {
+		(*observe)(exchange, used/limit)
+	}
+}
+
+// requestRetry retries exchange requests that failed on the network or with a 429 or 5xx status.
+var requestRetry = retry.Policy{MaxAttempts: 3, InitialDelay: 250 * time.Millisecond, MaxDelay: 2 * time.Second, Jitter: 0.2}
+
+// cacheRetry retries funding cache writes, so a Redis hiccup doesn't lose an update.
+var cacheRetry = retry.Policy{MaxAttempts: 3, InitialDelay: 500 * time.Millisecond, Jitter: 0.2}
+
+// decodeJSON decodes a JSON response body as it streams in, rather than reading it whole first, then drains
+// what follows so the connection can be reused.
+func decodeJSON(body io.Reader, v any) error {
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, body)
+	return nil
+}
+
+// reuse returns buf emptied, for decoding a JSON array into its storage. The elements are zeroed first, as
+// decoding doesn't reset fields missing from the JSON.
+func reuse[T any](buf []T) []T {
+	clear(buf[:cap(buf)])
+	return buf[:0]
+}
+
+// httpGet performs a GET request to an exchange that is aborted when ctx is cancelled, e.g., on shutdown.
+func httpGet(ctx context.Context, exchange, url string) (*http.Response, error) {
+	return httpGetWithHeader(ctx, exchange, url, nil)
+}
+
+// httpGetWithHeader performs a GET request to an exchange with extra headers, retrying transient failures per
+// requestRetry. Responses with other statuses are returned for the caller to handle. Failures are returned as
+// an *Error.
+func httpGetWithHeader(ctx context.Context, exchange, url string, header http.Header) (*http.Response, error) {
+	var resp *http.Response
+	err := retry.DoNotify(ctx, requestRetry, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		r, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		observeRateLimit(exchange, req, r)
+		if r.StatusCode == http.StatusTooManyRequests || r.StatusCode >= 500 {
+			body, _ := io.ReadAll(r.Body)
+			r.Body.Close()
+			return statusError(exchange, r, body, fmt.Errorf("status %d, body: %s", r.StatusCode, string(body)))
+		}
+		resp = r
+		return nil
+	}, func(err error, attempt int, delay time.Duration) {
+		endpoint, _, _ := strings.Cut(url, "?") // The query may carry a signature
+		slog.Warn("Exchange request failed, retrying", "url", endpoint, "attempt", attempt, "retry_in", delay, "error", err)
+	})
+	if err != nil {
+		return nil, requestError(ctx, exchange, err)
+	}
+	return resp, nil
+}
@@ -3,18 +3,20 @@ package adapters
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
-	"os"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"cex-price-diff-notifications/logging"
 	"cex-price-diff-notifications/shared"
-
-	"github.com/go-redis/redis/v8"
+	"cex-price-diff-notifications/shared/retry"
+	"cex-price-diff-notifications/storage"
 )
 
 const (
@@ -22,132 +24,252 @@ const (
 	mexcContractDetailPath = "/api/v1/contract/detail"
 	mexcTickersPath        = "/api/v1/contract/ticker"
 	mexcFundingRatePath    = "/api/v1/contract/funding_rate/" // Note the trailing slash
-	redisMexcFundingPrefix = "mexc:funding_rate:"
-	redisTTL               = 8 * time.Hour
+	mexcFundingHistoryPath = "/api/v1/contract/funding_rate/history"
+	MexcFundingCachePrefix = "mexc:funding_rate:"
+	mexcFundingMetaTTL     = 8 * time.Hour // How long a symbol's funding interval and settle time are trusted before re-fetching
 )
 
 // MexcAdapter holds state and logic for interacting with the Mexc API.
 type MexcAdapter struct {
-	FundingRates map[string]MexcFundingRateDto
-	mu           sync.RWMutex
-	redisClient  *redis.Client
+	fundingRates    map[string]MexcFundingRateDto
+	mu              sync.RWMutex
+	fundingCache    *storage.FundingCache[MexcCachedFundingRate] // Nil keeps funding rates in memory only
+	metaFetchedAt   map[string]time.Time                         // Last per-symbol funding metadata fetch, keyed by unified symbol
+	spotMarkets     spotMarkets
+	credentials     apiKeys                         // Only needed for private endpoints such as wallet status
+	owns            func(unifiedSymbol string) bool // Symbols whose funding metadata is fetched per symbol; nil for all
+	contractSizes   map[string]float64              // Base units per contract keyed by unified symbol, from the last contract specs fetch
+	streamURL       string                          // Base URL of the futures websocket
+	streamRebalance chan struct{}                   // Asks the funding rate stream to rebalance its subscriptions
+	fundingAt       atomic.Int64                    // Time of the last funding rate update, fetched, from tickers or streamed, in milliseconds since epoch
 }
 
 // NewMexcAdapter creates a new instance of the MexcAdapter.
-func NewMexcAdapter() (*MexcAdapter, error) {
+func NewMexcAdapter() *MexcAdapter {
 	slog.Info("Initializing Mexc adapter...")
+	return &MexcAdapter{
+		fundingRates:    make(map[string]MexcFundingRateDto),
+		metaFetchedAt:   make(map[string]time.Time),
+		streamURL:       mexcFuturesStreamURL,
+		streamRebalance: make(chan struct{}, 1),
+	}
+}
+
+// SetCredentials sets the API key pair used for signed endpoints. It can be called while fetching.
+func (a *MexcAdapter) SetCredentials(apiKey, apiSecret string) {
+	a.credentials.set(apiKey, apiSecret)
+}
 
-	redisPassword := os.Getenv("REDIS_PASSWORD")
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     "redis:6379", // Redis host and port
-		Password: redisPassword,
-		DB:       0, // default DB
-	})
+// SetFundingCache sets the cache funding rates are persisted to and loaded from.
+func (a *MexcAdapter) SetFundingCache(cache *storage.FundingCache[MexcCachedFundingRate]) {
+	a.fundingCache = cache
+}
+
+// SetSymbolFilter limits the per-symbol funding metadata requests to the symbols owns accepts, e.g., those
+// of this instance's shard.
+func (a *MexcAdapter) SetSymbolFilter(owns func(unifiedSymbol string) bool) {
+	a.owns = owns
+}
 
-	// Ping Redis to check connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// LoadFundingRates loads Mexc funding rates from the funding cache, if set.
+func (a *MexcAdapter) LoadFundingRates() {
+	if a.fundingCache == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	_, err := redisClient.Ping(ctx).Result()
+	rates, err := a.fundingCache.Load(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		slog.Error("Failed to load Mexc funding rates from Redis", "error", err)
 	}
-	slog.Info("Connected to Redis successfully.")
 
-	adapter := &MexcAdapter{
-		FundingRates: make(map[string]MexcFundingRateDto),
-		redisClient:  redisClient,
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for unifiedSymbol, cached := range rates {
+		a.fundingRates[unifiedSymbol] = cached.MexcFundingRateDto
+		// Entries cached without a fetch time are left to the per-symbol fallback
+		if cached.MetaFetchedAt > 0 {
+			a.metaFetchedAt[unifiedSymbol] = time.UnixMilli(cached.MetaFetchedAt)
+		}
 	}
-
-	return adapter, nil
+	slog.Info("Loaded Mexc funding rates from Redis.", "loaded_count", len(rates))
 }
 
-// Close closes the Redis client connection.
-func (a *MexcAdapter) Close() error {
-	if a.redisClient != nil {
-		slog.Info("Closing Redis client connection...")
-		return a.redisClient.Close()
+// UpdateFundingRates refreshes funding rates for all symbols from the bulk ticker endpoint, which carries
+// the current rate. Interval and next settle time are not part of the bulk payload, so the per-symbol
+// endpoint is only used as a rate-limited fallback for symbols whose metadata is missing or expired.
+func (a *MexcAdapter) UpdateFundingRates(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	slog.Info("Starting Mexc funding rate update...")
+
+	// 1. Fetch current rates for all symbols in one request
+	tickers, _, err := a.GetTickers(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch Mexc tickers for funding rates: %w", err)
 	}
-	return nil
-}
 
-// LoadFundingRatesFromRedis loads Mexc funding rates from Redis into the adapter's cache.
-func (a *MexcAdapter) LoadFundingRatesFromRedis() {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+	// 2. Find symbols without fresh interval/next settle metadata
+	a.mu.RLock()
+	var missing []string
+	for _, t := range tickers {
+		unifiedSymbol, err := UnwrapMexcSymbol(t.Symbol)
+		if err != nil || (a.owns != nil && !a.owns(unifiedSymbol)) {
+			continue
+		}
+		if fetchedAt, ok := a.metaFetchedAt[unifiedSymbol]; !ok || time.Since(fetchedAt) > mexcFundingMetaTTL {
+			missing = append(missing, t.Symbol)
+		}
+	}
+	a.mu.RUnlock()
+	slog.Info("Mexc symbols requiring funding metadata fallback", "count", len(missing), "total", len(tickers))
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// 3. Fetch missing metadata per symbol and merge it with the bulk rates
+	fetched := a.fetchFundingRatesPerSymbol(ctx, missing)
 
-	keys, err := a.redisClient.Keys(ctx, redisMexcFundingPrefix+"*").Result()
-	if err != nil {
-		slog.Error("Failed to get Redis keys for Mexc funding rates", "error", err)
-		return
+	a.mu.Lock()
+	newFundingRates := make(map[string]MexcFundingRateDto, len(a.fundingRates)+len(fetched))
+	for unifiedSymbol, dto := range a.fundingRates {
+		newFundingRates[unifiedSymbol] = dto
+	}
+	for unifiedSymbol, dto := range fetched {
+		newFundingRates[unifiedSymbol] = dto
+		a.metaFetchedAt[unifiedSymbol] = time.Now()
+	}
+	a.fundingRates = newFundingRates
+	a.mu.Unlock()
+	if len(fetched) > 0 {
+		a.fundingAt.Store(time.Now().UnixMilli())
 	}
 
-	if len(keys) == 0 {
-		slog.Info("No Mexc funding rates found in Redis to load.")
-		return
+	a.ApplyTickerFundingRates(tickers)
+
+	// 4. Persist new funding rates to Redis
+	a.mu.RLock()
+	snapshot := a.fundingRates
+	var cached map[string]MexcCachedFundingRate
+	if a.fundingCache != nil {
+		cached = make(map[string]MexcCachedFundingRate, len(snapshot))
+		for unifiedSymbol, dto := range snapshot {
+			entry := MexcCachedFundingRate{MexcFundingRateDto: dto}
+			if fetchedAt, ok := a.metaFetchedAt[unifiedSymbol]; ok {
+				entry.MetaFetchedAt = fetchedAt.UnixMilli()
+			}
+			cached[unifiedSymbol] = entry
+		}
 	}
+	a.mu.RUnlock()
 
-	slog.Info("Loading Mexc funding rates from Redis...", "count", len(keys))
-	for _, key := range keys {
-		val, err := a.redisClient.Get(ctx, key).Result()
+	if a.fundingCache != nil {
+		redisCtx, redisCancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer redisCancel()
+		err := retry.Do(redisCtx, cacheRetry, func(ctx context.Context) error { return a.fundingCache.Save(ctx, cached) })
 		if err != nil {
-			slog.Warn("Failed to get Mexc funding rate from Redis", "key", key, "error", err)
-			continue
+			slog.Error("Failed to save Mexc funding rates to Redis", "error", err)
+		} else {
+			slog.Info("Persisted Mexc funding rates to Redis.", "count", len(cached))
 		}
+	}
 
-		var dto MexcFundingRateDto
-		if err := json.Unmarshal([]byte(val), &dto); err != nil {
-			slog.Warn("Failed to unmarshal Mexc funding rate from Redis", "key", key, "error", err)
+	duration := time.Since(start)
+	slog.Info("Mexc funding rate update complete", "duration", duration, "updated_count", len(snapshot), "fallback_count", len(fetched))
+	return duration, nil
+}
+
+// UpdateListings applies contract listing changes between funding rate updates: the funding metadata of the
+// added unified symbols is fetched right away, the removed ones are dropped, and the funding rate stream, if
+// running, rebalances its subscriptions.
+func (a *MexcAdapter) UpdateListings(ctx context.Context, added, removed []string) {
+	var missing []string
+	for _, unifiedSymbol := range added {
+		if a.owns != nil && !a.owns(unifiedSymbol) {
 			continue
 		}
-		unifiedSymbol := strings.TrimPrefix(key, redisMexcFundingPrefix)
-		a.FundingRates[unifiedSymbol] = dto
+		if mexcSymbol, err := WrapMexcSymbol(unifiedSymbol); err == nil {
+			missing = append(missing, mexcSymbol)
+		}
 	}
-	slog.Info("Finished loading Mexc funding rates from Redis.", "loaded_count", len(a.FundingRates))
-}
+	fetched := a.fetchFundingRatesPerSymbol(ctx, missing)
 
-// UpdateFundingRates fetches funding rates for all symbols from Mexc using a rate-limited HTTP approach.
-func (a *MexcAdapter) UpdateFundingRates() (time.Duration, error) {
-	start := time.Now()
-	slog.Info("Starting Mexc funding rate update...")
+	a.mu.Lock()
+	newFundingRates := maps.Clone(a.fundingRates)
+	for unifiedSymbol, dto := range fetched {
+		newFundingRates[unifiedSymbol] = dto
+		a.metaFetchedAt[unifiedSymbol] = time.Now()
+	}
+	for _, unifiedSymbol := range removed {
+		delete(newFundingRates, unifiedSymbol)
+		delete(a.metaFetchedAt, unifiedSymbol)
+	}
+	a.fundingRates = newFundingRates
+	a.mu.Unlock()
+	slog.Info("Applied Mexc listing changes", "added", len(added), "fetched", len(fetched), "removed", len(removed))
 
-	// 1. Fetch all contract details to get the list of symbols
-	resp, err := http.Get(mexcFuturesURL + mexcContractDetailPath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to fetch Mexc contract details: %w", err)
+	select {
+	case a.streamRebalance <- struct{}{}:
+	default: // A rebalance is already pending
 	}
-	defer resp.Body.Close()
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read Mexc contract details response: %w", err)
+// ApplyTickerFundingRates updates the current funding rate of known symbols from a bulk ticker payload.
+// Symbols without interval metadata are skipped; they are picked up by UpdateFundingRates' fallback.
+// Next settle times that have already passed are rolled forward by the funding interval.
+func (a *MexcAdapter) ApplyTickerFundingRates(tickers []MexcTickerDto) {
+	now := time.Now().UnixMilli()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Copy-on-write so readers holding the previous map are not affected
+	newFundingRates := make(map[string]MexcFundingRateDto, len(a.fundingRates))
+	for unifiedSymbol, dto := range a.fundingRates {
+		newFundingRates[unifiedSymbol] = dto
 	}
 
-	var detailResponse MexcContractDetailResponse
-	if err := json.Unmarshal(body, &detailResponse); err != nil {
-		return 0, fmt.Errorf("failed to unmarshal Mexc contract details: %w", err)
+	var updated bool
+	for _, t := range tickers {
+		unifiedSymbol, err := UnwrapMexcSymbol(t.Symbol)
+		if err != nil {
+			continue
+		}
+		dto, ok := newFundingRates[unifiedSymbol]
+		if !ok || dto.CollectCycle <= 0 {
+			continue
+		}
+		dto.FundingRate = t.FundingRate
+		for dto.NextSettleTime > 0 && dto.NextSettleTime <= now {
+			dto.NextSettleTime += int64(dto.CollectCycle) * time.Hour.Milliseconds()
+		}
+		newFundingRates[unifiedSymbol] = dto
+		updated = true
 	}
-	if !detailResponse.Success {
-		return 0, fmt.Errorf("Mexc contract details API returned success: false")
+
+	a.fundingRates = newFundingRates
+	if updated {
+		a.fundingAt.Store(time.Now().UnixMilli())
 	}
+}
 
-	var symbols []string
-	for _, detail := range detailResponse.Data {
-		symbols = append(symbols, detail.Symbol)
+// FundingUpdatedAt returns the time of the last funding rate update, fetched, from tickers or streamed, or the
+// zero time before the first one; rates loaded from the cache don't count.
+func (a *MexcAdapter) FundingUpdatedAt() time.Time {
+	at := a.fundingAt.Load()
+	if at == 0 {
+		return time.Time{}
 	}
-	slog.Info("Fetched all Mexc symbols for funding rates", "count", len(symbols))
+	return time.UnixMilli(at)
+}
 
-	// 2. Fetch funding rates in rate-limited chunks
+// fetchFundingRatesPerSymbol fetches funding rates one symbol at a time, in chunks that respect Mexc's rate limits.
+func (a *MexcAdapter) fetchFundingRatesPerSymbol(ctx context.Context, symbols []string) map[string]MexcFundingRateDto {
 	const chunkSize = 10
 	const delay = 2 * time.Second
 
 	newFundingRates := make(map[string]MexcFundingRateDto)
 	var wg sync.WaitGroup
 	var mu sync.Mutex // Mutex to protect the newFundingRates map
+	var rateLimited atomic.Bool
 
-	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Minute) // Context for HTTP requests
+	ctx, cancel := context.WithTimeout(ctx, 6*time.Minute) // Context for HTTP requests
 	defer cancel()
 
 	for i := 0; i < len(symbols); i += chunkSize {
@@ -164,32 +286,28 @@ func (a *MexcAdapter) UpdateFundingRates() (time.Duration, error) {
 			go func(s string) {
 				defer wg.Done()
 				url := mexcFuturesURL + mexcFundingRatePath + s
-				req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-				if err != nil {
-					slog.Warn("Failed to create HTTP request for Mexc funding rate", "symbol", s, "error", err)
-					return
-				}
-				resp, err := http.DefaultClient.Do(req)
+				resp, err := httpGet(ctx, "Mexc", url)
 				if err != nil {
-					slog.Warn("Failed to fetch Mexc funding rate", "symbol", s, "error", err)
+					rateLimited.CompareAndSwap(false, errors.Is(err, ErrRateLimited))
+					logging.Warn("Failed to fetch Mexc funding rate", "symbol", s, "error", err)
 					return
 				}
 				defer resp.Body.Close()
 
 				if resp.StatusCode != http.StatusOK {
-					slog.Warn("Mexc funding rate API returned non-OK status", "symbol", s, "status", resp.StatusCode)
+					logging.Warn("Mexc funding rate API returned non-OK status", "symbol", s, "status", resp.StatusCode)
 					return
 				}
 
 				body, err := io.ReadAll(resp.Body)
 				if err != nil {
-					slog.Warn("Failed to read Mexc funding rate response body", "symbol", s, "error", err)
+					logging.Warn("Failed to read Mexc funding rate response body", "symbol", s, "error", err)
 					return
 				}
 
 				var fundingResponse MexcFundingRateResponse
 				if err := json.Unmarshal(body, &fundingResponse); err != nil {
-					slog.Warn("Failed to unmarshal Mexc funding rate", "symbol", s, "error", err)
+					logging.Warn("Failed to unmarshal Mexc funding rate", "symbol", s, "error", err)
 					return
 				}
 
@@ -201,7 +319,9 @@ func (a *MexcAdapter) UpdateFundingRates() (time.Duration, error) {
 						mu.Unlock()
 					}
 				} else {
-					slog.Warn("Mexc funding rate API returned success: false", "symbol", s, "code", fundingResponse.Code)
+					err := mexcError(fundingResponse.Code, fmt.Errorf("Mexc funding rate API returned success: false, code: %d", fundingResponse.Code))
+					rateLimited.CompareAndSwap(false, errors.Is(err, ErrRateLimited))
+					logging.Warn("Mexc funding rate API returned success: false", "symbol", s, "code", fundingResponse.Code)
 				}
 			}(symbol)
 		}
@@ -209,76 +329,201 @@ func (a *MexcAdapter) UpdateFundingRates() (time.Duration, error) {
 		// Wait for the current chunk to finish before sleeping
 		wg.Wait()
 
+		// The remaining symbols would be throttled too; they are retried on the next update
+		if rateLimited.Load() {
+			slog.Warn("Mexc funding rate fallback rate limited, stopping", "fetched", len(newFundingRates), "remaining", len(symbols)-end)
+			return newFundingRates
+		}
+
 		// If this is not the last chunk, sleep to respect rate limits
 		if end < len(symbols) {
-			time.Sleep(delay)
+			select {
+			case <-ctx.Done():
+				return newFundingRates // Shutting down; keep what was fetched
+			case <-time.After(delay):
+			}
 		}
 	}
 
-	// 3. Atomically update the adapter's funding rates map
-	a.mu.Lock()
-	a.FundingRates = newFundingRates
-	a.mu.Unlock()
+	return newFundingRates
+}
 
-	// 4. Persist new funding rates to Redis
-	redisCtx, redisCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer redisCancel()
-	for unifiedSymbol, dto := range newFundingRates {
-		key := redisMexcFundingPrefix + unifiedSymbol
-		val, err := json.Marshal(dto)
+// GetTickers fetches the latest book tickers from Mexc, decoding them into buf's storage: pass the tickers of
+// the previous call, once done with them, to avoid reallocating the multi-megabyte payload every cycle.
+func (a *MexcAdapter) GetTickers(ctx context.Context, buf []MexcTickerDto) ([]MexcTickerDto, time.Duration, error) {
+	start := time.Now()
+
+	resp, err := httpGet(ctx, "Mexc", mexcFuturesURL+mexcTickersPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to make HTTP request to Mexc: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, 0, statusError("Mexc", resp, bodyBytes, fmt.Errorf("Mexc API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes)))
+	}
+
+	mexcResponse := MexcTickersResponse{Data: reuse(buf)}
+	if err := decodeJSON(resp.Body, &mexcResponse); err != nil {
+		return nil, 0, decodeError("Mexc", fmt.Errorf("failed to decode Mexc tickers: %w", err))
+	}
+
+	if !mexcResponse.Success {
+		return nil, 0, mexcError(mexcResponse.Code, fmt.Errorf("Mexc API returned success: false, code: %d", mexcResponse.Code))
+	}
+
+	duration := time.Since(start)
+	return mexcResponse.Data, duration, nil
+}
+
+// Tickers converts tickers to unified tickers, skipping unsupported markets.
+func (a *MexcAdapter) Tickers(dtos []MexcTickerDto) []shared.TickerBidAsk {
+	tickers := make([]shared.TickerBidAsk, 0, len(dtos))
+	for _, dto := range dtos {
+		t, err := dto.ToTickerBidAsk()
 		if err != nil {
-			slog.Error("Failed to marshal Mexc funding rate for Redis", "symbol", unifiedSymbol, "error", err)
+			if !errors.Is(err, shared.ErrUnsupportedQuoteCurrency) {
+				logging.Warn("Failed to convert Mexc DTO", "symbol", dto.Symbol, "error", err)
+			}
 			continue
 		}
-		if err := a.redisClient.Set(redisCtx, key, val, redisTTL).Err(); err != nil {
-			slog.Error("Failed to save Mexc funding rate to Redis", "symbol", unifiedSymbol, "error", err)
-		}
+		tickers = append(tickers, t)
 	}
-	slog.Info("Persisted Mexc funding rates to Redis.", "count", len(newFundingRates))
+	return tickers
+}
 
-	duration := time.Since(start)
-	slog.Info("Mexc funding rate update complete", "duration", duration, "updated_count", len(newFundingRates))
-	return duration, nil
+// GetFundingRatesSnapshot returns a copy of the latest funding rates, keyed by unified symbol, that later
+// updates don't change.
+func (a *MexcAdapter) GetFundingRatesSnapshot() map[string]shared.FundingRateInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	rates := make(map[string]shared.FundingRateInfo, len(a.fundingRates))
+	for unifiedSymbol, dto := range a.fundingRates {
+		rates[unifiedSymbol] = dto.ToFundingRateInfo()
+	}
+	return rates
 }
 
-// GetTickers fetches the latest book tickers from Mexc.
-func (a *MexcAdapter) GetTickers() ([]MexcTickerDto, time.Duration, error) {
-	start := time.Now()
+// GetFundingHistory fetches the most recent settled funding rates for a unified symbol from Mexc.
+func (a *MexcAdapter) GetFundingHistory(ctx context.Context, unifiedSymbol string, limit int) ([]shared.FundingRatePoint, error) {
+	mexcSymbol, err := WrapMexcSymbol(unifiedSymbol)
+	if err != nil {
+		return nil, newError(CodeSymbolUnsupported, "Mexc", err)
+	}
 
-	resp, err := http.Get(mexcFuturesURL + mexcTickersPath)
+	url := fmt.Sprintf("%s%s?symbol=%s&page_num=1&page_size=%d", mexcFuturesURL, mexcFundingHistoryPath, mexcSymbol, limit)
+	resp, err := httpGet(ctx, "Mexc", url)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to make HTTP request to Mexc: %w", err)
+		return nil, fmt.Errorf("failed to make HTTP request to Mexc funding history: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("Mexc API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, statusError("Mexc", resp, bodyBytes, fmt.Errorf("Mexc funding history API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes)))
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to read Mexc response body: %w", err)
+		return nil, newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to read Mexc funding history response body: %w", err))
 	}
 
-	var mexcResponse MexcTickersResponse
-	if err := json.Unmarshal(body, &mexcResponse); err != nil {
-		return nil, 0, fmt.Errorf("failed to unmarshal Mexc tickers: %w", err)
+	var historyResponse MexcFundingRateHistoryResponse
+	if err := json.Unmarshal(body, &historyResponse); err != nil {
+		return nil, decodeError("Mexc", fmt.Errorf("failed to unmarshal Mexc funding history: %w", err))
+	}
+	if !historyResponse.Success {
+		return nil, mexcError(historyResponse.Code, fmt.Errorf("Mexc funding history API returned success: false, code: %d", historyResponse.Code))
 	}
 
-	if !mexcResponse.Success {
-		return nil, 0, fmt.Errorf("Mexc API returned success: false, code: %d", mexcResponse.Code)
+	points := make([]shared.FundingRatePoint, 0, len(historyResponse.Data.ResultList))
+	for _, h := range historyResponse.Data.ResultList {
+		points = append(points, shared.FundingRatePoint{Rate: h.FundingRate, SettleTime: h.SettleTime})
 	}
+	return points, nil
+}
 
-	duration := time.Since(start)
-	return mexcResponse.Data, duration, nil
+// GetContractSpecs fetches the trading rules of all contracts from Mexc, keyed by unified symbol.
+// Contract-denominated quantities are converted to base units using the contract size.
+func (a *MexcAdapter) GetContractSpecs(ctx context.Context) (map[string]shared.ContractSpec, error) {
+	resp, err := httpGet(ctx, "Mexc", mexcFuturesURL+mexcContractDetailPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Mexc contract details: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, statusError("Mexc", resp, bodyBytes, fmt.Errorf("Mexc contract details API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to read Mexc contract details response: %w", err))
+	}
+
+	var detailResponse MexcContractDetailResponse
+	if err := json.Unmarshal(body, &detailResponse); err != nil {
+		return nil, decodeError("Mexc", fmt.Errorf("failed to unmarshal Mexc contract details: %w", err))
+	}
+	if !detailResponse.Success {
+		return nil, mexcError(detailResponse.Code, fmt.Errorf("Mexc contract details API returned success: false, code: %d", detailResponse.Code))
+	}
+
+	specs := make(map[string]shared.ContractSpec)
+	for _, d := range detailResponse.Data {
+		unifiedSymbol, err := UnwrapMexcSymbol(d.Symbol)
+		if err != nil {
+			continue
+		}
+		specs[unifiedSymbol] = shared.ContractSpec{
+			TickSize:     d.PriceUnit,
+			StepSize:     d.VolUnit * d.ContractSize,
+			MinQty:       d.MinVol * d.ContractSize,
+			MaxLeverage:  d.MaxLeverage,
+			ContractSize: d.ContractSize,
+		}
+	}
+
+	// Kept to convert the contract quantities of positions
+	a.mu.Lock()
+	a.contractSizes = make(map[string]float64, len(specs))
+	for symbol, spec := range specs {
+		a.contractSizes[symbol] = spec.ContractSize
+	}
+	a.mu.Unlock()
+	return specs, nil
+}
+
+// GetSpotTickers fetches the latest spot book tickers from Mexc as unified spot tickers (e.g., "ETH/BTC:SPOT").
+// It is not safe for concurrent use.
+func (a *MexcAdapter) GetSpotTickers(ctx context.Context) ([]shared.TickerBidAsk, time.Duration, error) {
+	return getSpotTickers(ctx, "Mexc", mexcSpotURL, &a.spotMarkets)
+}
+
+// GetWalletStatus fetches the deposit/withdrawal status and fees of every asset on Mexc, keyed by asset.
+// It requires API credentials.
+func (a *MexcAdapter) GetWalletStatus(ctx context.Context) (map[string][]shared.AssetNetwork, error) {
+	return getWalletStatus(ctx, "Mexc", mexcSpotURL, mexcWalletConfigPath, mexcAPIKeyHeader, a.credentials.get())
+}
+
+// GetTransferHistory fetches the recent withdrawals and deposits of the Mexc account, to learn how long
+// transfers take. It requires API credentials.
+func (a *MexcAdapter) GetTransferHistory(ctx context.Context) (withdrawals, deposits []shared.Transfer, err error) {
+	return getTransferHistory(ctx, "Mexc", mexcSpotURL, mexcWithdrawalsPath, mexcDepositsPath, mexcAPIKeyHeader, a.credentials.get())
 }
 
 // ToTickerBidAsk converts a MexcTickerDto to a shared.TickerBidAsk.
 func (m MexcTickerDto) ToTickerBidAsk() (shared.TickerBidAsk, error) {
 	unifiedSymbol, err := UnwrapMexcSymbol(m.Symbol)
 	if err != nil {
-		return shared.TickerBidAsk{}, fmt.Errorf("failed to unwrap Mexc symbol %s: %w", m.Symbol, err)
+		return shared.TickerBidAsk{}, newError(CodeSymbolUnsupported, "Mexc", fmt.Errorf("failed to unwrap Mexc symbol %s: %w", m.Symbol, err))
+	}
+
+	timestamp := time.Now()
+	if m.Timestamp > 0 {
+		timestamp = time.UnixMilli(m.Timestamp)
 	}
 
 	return shared.TickerBidAsk{
@@ -287,14 +532,33 @@ func (m MexcTickerDto) ToTickerBidAsk() (shared.TickerBidAsk, error) {
 		Bid:           m.Bid1,
 		Ask:           m.Ask1,
 		VolumeUSD:     m.Amount24,
+		Timestamp:     timestamp,
 	}, nil
 }
 
-// UnwrapMexcSymbol converts a Mexc symbol (e.g., "BTC_USDT") to our unified format (e.g., "BTC/USDT:PERP").
+// ToFundingRateInfo converts a MexcFundingRateDto to a shared.FundingRateInfo.
+func (m MexcFundingRateDto) ToFundingRateInfo() shared.FundingRateInfo {
+	return shared.FundingRateInfo{
+		Rate:           m.FundingRate,
+		Interval:       m.CollectCycle,
+		NextSettleTime: m.NextSettleTime,
+	}
+}
+
+// UnwrapMexcSymbol converts a Mexc symbol (e.g., "BTC_USDT", "BTC_USDC") to our unified format (e.g., "BTC/USDT:PERP").
 func UnwrapMexcSymbol(mexcSymbol string) (string, error) {
-	if !strings.HasSuffix(mexcSymbol, "_USDT") {
-		return "", shared.ErrUnsupportedQuoteCurrency
+	symbol, err := shared.MexcSymbols.Parse(mexcSymbol, shared.MarketPerp)
+	if err != nil {
+		return "", err
+	}
+	return symbol.String(), nil
+}
+
+// WrapMexcSymbol converts a unified symbol (e.g., "BTC/USDT:PERP") to the Mexc format (e.g., "BTC_USDT").
+func WrapMexcSymbol(unifiedSymbol string) (string, error) {
+	symbol, err := shared.ParseSymbol(unifiedSymbol)
+	if err != nil {
+		return "", err
 	}
-	base := strings.TrimSuffix(mexcSymbol, "_USDT")
-	return base + "/USDT:PERP", nil
+	return shared.MexcSymbols.Format(symbol), nil
 }
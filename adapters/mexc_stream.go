@@ -0,0 +1,326 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"cex-price-diff-notifications/logging"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	mexcFuturesStreamURL     = "wss://contract.mexc.com"
+	mexcStreamPath           = "/edge"
+	mexcStreamSymbolsPerConn = 20               // Mexc caps the subscriptions of a connection
+	mexcStreamPingInterval   = 20 * time.Second // Mexc drops connections that don't ping within a minute
+	mexcStreamSymbolsRefresh = 10 * time.Minute // How often the streamed symbols are matched against the known ones
+	mexcStreamSymbolsWait    = 10 * time.Second // How often symbols are looked for until their funding intervals are known
+)
+
+// SetStreamURL sets the base URL of the futures websocket, e.g., to stream from a simulator. It must be called
+// before StreamFundingRates.
+func (a *MexcAdapter) SetStreamURL(url string) {
+	a.streamURL = url
+}
+
+// StreamFundingRates keeps the funding rates updated from the funding rate channel of the futures websocket
+// until ctx is cancelled. The symbols whose funding interval is known are spread across connections of at most
+// 20 subscriptions; each is reconnected with backoff and resubscribed when it drops, or when nothing, not even
+// a pong, arrived on it for silence. Symbols listed or delisted since are subscribed or unsubscribed on the
+// live connections at the next rebalance, every 10 minutes or when UpdateListings asks for one. It should be
+// run in a goroutine.
+func (a *MexcAdapter) StreamFundingRates(ctx context.Context, silence time.Duration) {
+	m := &mexcStream{adapter: a, silence: silence}
+	defer m.wg.Wait()
+	for {
+		symbols := a.streamSymbols()
+		m.rebalance(ctx, symbols)
+
+		wait := mexcStreamSymbolsRefresh
+		if len(symbols) == 0 {
+			wait = mexcStreamSymbolsWait
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.streamRebalance:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// mexcStream spreads the funding rate subscriptions across websocket connections.
+type mexcStream struct {
+	adapter *MexcAdapter
+	silence time.Duration
+	chunks  []*mexcStreamChunk
+	nextID  int
+	wg      sync.WaitGroup
+}
+
+// rebalance moves the subscriptions to symbols: symbols no longer streamed are unsubscribed from, closing the
+// connections left without any, and new ones fill the connections with room before new connections are opened.
+// The symbols that stay keep their connection.
+func (m *mexcStream) rebalance(ctx context.Context, symbols []string) {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[symbol] = true
+	}
+
+	var added, removed int
+	owned := make(map[string]bool, len(symbols))
+	kept := m.chunks[:0]
+	for _, c := range m.chunks {
+		for _, symbol := range c.list() {
+			if wanted[symbol] {
+				owned[symbol] = true
+				continue
+			}
+			c.set(symbol, false)
+			removed++
+		}
+		if len(c.list()) == 0 {
+			c.cancel()
+			continue
+		}
+		kept = append(kept, c)
+	}
+	m.chunks = kept
+
+	for _, symbol := range symbols {
+		if owned[symbol] {
+			continue
+		}
+		i := slices.IndexFunc(m.chunks, func(c *mexcStreamChunk) bool { return len(c.list()) < mexcStreamSymbolsPerConn })
+		if i < 0 {
+			m.chunks = append(m.chunks, m.start(ctx))
+			i = len(m.chunks) - 1
+		}
+		m.chunks[i].set(symbol, true)
+		added++
+	}
+	if added > 0 || removed > 0 {
+		slog.Info("Rebalanced Mexc funding rate subscriptions", "added", added, "removed", removed, "symbols", len(symbols), "connections", len(m.chunks))
+	}
+}
+
+// start opens a connection without symbols yet.
+func (m *mexcStream) start(ctx context.Context) *mexcStreamChunk {
+	ctx, cancel := context.WithCancel(ctx)
+	m.nextID++
+	c := &mexcStreamChunk{id: m.nextID, symbols: make(map[string]bool), changed: make(chan struct{}, 1), cancel: cancel}
+	m.wg.Go(func() { m.adapter.streamFundingChunk(ctx, c, m.silence) })
+	return c
+}
+
+// mexcStreamChunk is the share of the funding rate subscriptions of one connection. Its symbols can change
+// while it is connected.
+type mexcStreamChunk struct {
+	id      int
+	mu      sync.Mutex
+	symbols map[string]bool    // Mexc symbols the connection should be subscribed to
+	changed chan struct{}      // Tells the live connection the symbols changed
+	cancel  context.CancelFunc // Closes the connection for good
+}
+
+// list returns the symbols of the chunk.
+func (c *mexcStreamChunk) list() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Collect(maps.Keys(c.symbols))
+}
+
+// set adds a symbol to the chunk, or removes it, and tells the live connection.
+func (c *mexcStreamChunk) set(symbol string, subscribed bool) {
+	c.mu.Lock()
+	if subscribed {
+		c.symbols[symbol] = true
+	} else {
+		delete(c.symbols, symbol)
+	}
+	c.mu.Unlock()
+	select {
+	case c.changed <- struct{}{}:
+	default: // A change is already pending; the connection reads every symbol when it handles it
+	}
+}
+
+// write subscribes conn to the symbols of the chunk, then keeps its subscriptions in line with them and pings
+// it, until done is closed or a write fails.
+func (c *mexcStreamChunk) write(conn *websocket.Conn, silence time.Duration, done <-chan struct{}) error {
+	subscribed := make(map[string]bool) // Subscriptions don't outlive a connection
+	ticker := time.NewTicker(mexcStreamPingInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.sync(conn, subscribed, silence); err != nil {
+			return err
+		}
+		select {
+		case <-done:
+			return nil
+		case <-c.changed:
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(silence))
+			if err := conn.WriteJSON(MexcStreamRequest{Method: "ping"}); err != nil {
+				return newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to ping the Mexc funding rate stream: %w", err))
+			}
+		}
+	}
+}
+
+// sync subscribes conn to the symbols of the chunk it isn't subscribed to yet, and unsubscribes it from those
+// removed from the chunk, keeping subscribed up to date.
+func (c *mexcStreamChunk) sync(conn *websocket.Conn, subscribed map[string]bool, silence time.Duration) error {
+	c.mu.Lock()
+	want := maps.Clone(c.symbols)
+	c.mu.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(silence))
+	for symbol := range want {
+		if subscribed[symbol] {
+			continue
+		}
+		if err := conn.WriteJSON(MexcStreamRequest{Method: "sub.funding.rate", Param: map[string]string{"symbol": symbol}}); err != nil {
+			return newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to subscribe to the Mexc funding rate of %s: %w", symbol, err))
+		}
+		subscribed[symbol] = true
+	}
+	for symbol := range subscribed {
+		if want[symbol] {
+			continue
+		}
+		if err := conn.WriteJSON(MexcStreamRequest{Method: "unsub.funding.rate", Param: map[string]string{"symbol": symbol}}); err != nil {
+			return newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to unsubscribe from the Mexc funding rate of %s: %w", symbol, err))
+		}
+		delete(subscribed, symbol)
+	}
+	return nil
+}
+
+// streamSymbols returns the sorted Mexc symbols to stream: those with a known funding interval, which the
+// stream doesn't carry, accepted by the symbol filter.
+func (a *MexcAdapter) streamSymbols() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	symbols := make([]string, 0, len(a.fundingRates))
+	for unifiedSymbol, dto := range a.fundingRates {
+		if dto.CollectCycle <= 0 || (a.owns != nil && !a.owns(unifiedSymbol)) {
+			continue
+		}
+		if mexcSymbol, err := WrapMexcSymbol(unifiedSymbol); err == nil {
+			symbols = append(symbols, mexcSymbol)
+		}
+	}
+	slices.Sort(symbols)
+	return symbols
+}
+
+// streamFundingChunk keeps a connection streaming the funding rates of the symbols of a chunk until ctx is
+// cancelled, reconnecting with backoff whenever it drops.
+func (a *MexcAdapter) streamFundingChunk(ctx context.Context, chunk *mexcStreamChunk, silence time.Duration) {
+	for attempt := 1; ctx.Err() == nil; attempt++ {
+		received, err := a.streamFundingConn(ctx, chunk, silence)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			attempt = 1
+		}
+		delay := streamReconnect.Delay(attempt)
+		slog.Warn("Mexc funding rate stream disconnected, reconnecting", "connection", chunk.id, "symbols", len(chunk.list()), "code", CodeOf(err), "error", err, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// streamFundingConn subscribes a new connection to the funding rates of the symbols of a chunk and reads it
+// until it fails, reporting whether any message was received.
+func (a *MexcAdapter) streamFundingConn(ctx context.Context, chunk *mexcStreamChunk, silence time.Duration) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, a.streamURL+mexcStreamPath, nil)
+	if err != nil {
+		return false, newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to connect to the Mexc funding rate stream: %w", err))
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// The writes happen in their own goroutine, which closes the connection to fail the read when one fails
+	done := make(chan struct{})
+	defer close(done)
+	writeErr := make(chan error, 1)
+	go func() {
+		if err := chunk.write(conn, silence, done); err != nil {
+			writeErr <- err
+			conn.Close()
+		}
+	}()
+
+	var received bool
+	for {
+		// Pongs arrive at least every ping interval, so a connection silent for longer is dead even if still open
+		conn.SetReadDeadline(time.Now().Add(silence))
+		var msg MexcStreamMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			select {
+			case err := <-writeErr:
+				return received, err
+			default:
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("no message for %s", silence)
+			}
+			return received, newError(CodeExchangeDown, "Mexc", fmt.Errorf("failed to read the Mexc funding rate stream: %w", err))
+		}
+		received = true
+
+		switch msg.Channel {
+		case "push.funding.rate":
+			var push MexcStreamFundingDto
+			if err := json.Unmarshal(msg.Data, &push); err != nil {
+				logging.Warn("Failed to unmarshal Mexc funding rate push", "symbol", msg.Symbol, "error", err)
+				continue
+			}
+			a.applyFundingPush(push)
+		case "rs.error":
+			logging.Warn("Mexc funding rate stream returned an error", "symbol", msg.Symbol, "data", string(msg.Data))
+		}
+	}
+}
+
+// applyFundingPush stores a funding rate pushed on the stream. Symbols without interval metadata are skipped,
+// as by ApplyTickerFundingRates.
+func (a *MexcAdapter) applyFundingPush(push MexcStreamFundingDto) {
+	unifiedSymbol, err := UnwrapMexcSymbol(push.Symbol)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	dto, ok := a.fundingRates[unifiedSymbol]
+	if !ok || dto.CollectCycle <= 0 {
+		return
+	}
+	dto.FundingRate = push.Rate
+	if push.NextSettleTime > 0 {
+		dto.NextSettleTime = push.NextSettleTime
+	}
+
+	// Copy-on-write so readers holding the previous map are not affected
+	newFundingRates := maps.Clone(a.fundingRates)
+	newFundingRates[unifiedSymbol] = dto
+	a.fundingRates = newFundingRates
+	a.fundingAt.Store(time.Now().UnixMilli())
+}
@@ -0,0 +1,43 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	binancePingPath = "/v1/ping" // After the market's prefix
+	mexcPingPath    = "/api/v1/contract/ping"
+)
+
+// ping measures the round trip of a lightweight GET request. It isn't retried, as it measures one round trip.
+func ping(ctx context.Context, exchangeName, url string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s ping request: %w", exchangeName, err)
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, requestError(ctx, exchangeName, fmt.Errorf("failed to ping %s: %w", exchangeName, err))
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return 0, statusError(exchangeName, resp, body, fmt.Errorf("%s ping returned non-OK status: %d", exchangeName, resp.StatusCode))
+	}
+	return time.Since(start), nil
+}
+
+// Ping measures the round-trip latency to the futures API of the Binance market.
+func (a *BinanceAdapter) Ping(ctx context.Context) (time.Duration, error) {
+	return ping(ctx, a.market.Name, a.market.url(binancePingPath))
+}
+
+// Ping measures the round-trip latency to the Mexc futures API.
+func (a *MexcAdapter) Ping(ctx context.Context) (time.Duration, error) {
+	return ping(ctx, "Mexc", mexcFuturesURL+mexcPingPath)
+}
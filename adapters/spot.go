@@ -0,0 +1,120 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cex-price-diff-notifications/shared"
+)
+
+const (
+	binanceSpotURL          = "https://api.binance.com"
+	mexcSpotURL             = "https://api.mexc.com"
+	spotBookTickerPath      = "/api/v3/ticker/bookTicker"
+	spotExchangeInfoPath    = "/api/v3/exchangeInfo"
+	spotMarketsRefreshEvery = time.Hour
+)
+
+// spotMarkets caches the base/quote split of spot symbols, which cannot be derived from the symbol alone
+// (e.g., "ETHBTC" vs "BTCUSDT").
+type spotMarkets struct {
+	markets   map[string]SpotSymbolInfoDto
+	fetchedAt time.Time
+}
+
+// getSpotTickers fetches all spot book tickers from a Binance-compatible v3 API and converts them to unified
+// spot tickers, refreshing the market list if it is older than spotMarketsRefreshEvery.
+func getSpotTickers(ctx context.Context, exchangeName, baseURL string, cache *spotMarkets) ([]shared.TickerBidAsk, time.Duration, error) {
+	start := time.Now()
+
+	if cache.markets == nil || time.Since(cache.fetchedAt) > spotMarketsRefreshEvery {
+		markets, err := fetchSpotMarkets(ctx, exchangeName, baseURL)
+		if err != nil {
+			return nil, 0, err
+		}
+		cache.markets = markets
+		cache.fetchedAt = time.Now()
+	}
+
+	resp, err := httpGet(ctx, exchangeName, baseURL+spotBookTickerPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to make HTTP request to %s spot tickers: %w", exchangeName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, 0, statusError(exchangeName, resp, bodyBytes, fmt.Errorf("%s spot tickers API returned non-OK status: %d, body: %s", exchangeName, resp.StatusCode, string(bodyBytes)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, newError(CodeExchangeDown, exchangeName, fmt.Errorf("failed to read %s spot tickers response body: %w", exchangeName, err))
+	}
+
+	var dtos []SpotBookTickerDto
+	if err := json.Unmarshal(body, &dtos); err != nil {
+		return nil, 0, decodeError(exchangeName, fmt.Errorf("failed to unmarshal %s spot tickers: %w", exchangeName, err))
+	}
+
+	now := time.Now()
+	tickers := make([]shared.TickerBidAsk, 0, len(dtos))
+	for _, dto := range dtos {
+		market, ok := cache.markets[dto.Symbol]
+		if !ok {
+			continue // Not trading, or listed after the last market refresh.
+		}
+		bid, errBid := strconv.ParseFloat(dto.BidPrice, 64)
+		ask, errAsk := strconv.ParseFloat(dto.AskPrice, 64)
+		if errBid != nil || errAsk != nil || bid <= 0 || ask <= 0 {
+			continue
+		}
+		tickers = append(tickers, shared.TickerBidAsk{
+			Symbol:        dto.Symbol,
+			UnifiedSymbol: shared.SpotSymbol(market.BaseAsset, market.QuoteAsset).String(),
+			Bid:           bid,
+			Ask:           ask,
+			Timestamp:     now,
+		})
+	}
+
+	return tickers, time.Since(start), nil
+}
+
+// fetchSpotMarkets fetches the trading spot markets from a Binance-compatible v3 API, keyed by exchange symbol.
+func fetchSpotMarkets(ctx context.Context, exchangeName, baseURL string) (map[string]SpotSymbolInfoDto, error) {
+	resp, err := httpGet(ctx, exchangeName, baseURL+spotExchangeInfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request to %s spot exchange info: %w", exchangeName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, statusError(exchangeName, resp, bodyBytes, fmt.Errorf("%s spot exchange info API returned non-OK status: %d, body: %s", exchangeName, resp.StatusCode, string(bodyBytes)))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(CodeExchangeDown, exchangeName, fmt.Errorf("failed to read %s spot exchange info response body: %w", exchangeName, err))
+	}
+
+	var info SpotExchangeInfoResponse
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, decodeError(exchangeName, fmt.Errorf("failed to unmarshal %s spot exchange info: %w", exchangeName, err))
+	}
+
+	markets := make(map[string]SpotSymbolInfoDto, len(info.Symbols))
+	for _, s := range info.Symbols {
+		switch s.Status {
+		case "TRADING", "ENABLED", "1":
+			markets[s.Symbol] = s
+		}
+	}
+	return markets, nil
+}
@@ -0,0 +1,31 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const binanceSystemStatusPath = "/sapi/v1/system/status"
+
+// GetSystemStatus reports whether Binance is under system maintenance, with the reason it gives. Mexc has no
+// such endpoint; its maintenance is only known from scheduled windows.
+func (a *BinanceAdapter) GetSystemStatus(ctx context.Context) (bool, string, error) {
+	resp, err := httpGet(ctx, "Binance", binanceSpotURL+binanceSystemStatusPath)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to make HTTP request to Binance system status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return false, "", statusError("Binance", resp, bodyBytes, fmt.Errorf("Binance system status API returned non-OK status: %d, body: %s", resp.StatusCode, string(bodyBytes)))
+	}
+
+	var status BinanceSystemStatusDto
+	if err := decodeJSON(resp.Body, &status); err != nil {
+		return false, "", decodeError("Binance", fmt.Errorf("failed to decode Binance system status: %w", err))
+	}
+	return status.Status != 0, status.Msg, nil
+}
@@ -0,0 +1,58 @@
+package adapters
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Base URLs of the market streams of the Binance futures testnet, for USDⓈ-M and COIN-M.
+const (
+	BinanceTestnetStreamURL      = "wss://fstream.binancefuture.com"
+	BinanceCoinMTestnetStreamURL = "wss://dstream.binancefuture.com"
+)
+
+// testnetHosts maps the production API hosts of the exchanges that have a public testnet to the testnet hosts.
+// Mexc has none; its requests can be sent to the simulator instead.
+var testnetHosts = map[string]map[string]string{
+	"Binance": {
+		"fapi.binance.com": "testnet.binancefuture.com", // Futures testnet, also for orders
+		"dapi.binance.com": "testnet.binancefuture.com", // Serves COIN-M too
+		"api.binance.com":  "testnet.binance.vision",    // Spot testnet, without the wallet (/sapi) endpoints
+	},
+}
+
+// TestnetTransport routes the requests to the production API hosts of exchanges to their testnets, and the other
+// requests to next (nil for the default transport). It fails for exchanges without a testnet.
+func TestnetTransport(exchanges []string, next http.RoundTripper) (http.RoundTripper, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &testnetTransport{hosts: make(map[string]string), next: next}
+	for _, exchange := range exchanges {
+		hosts, ok := testnetHosts[exchange]
+		if !ok {
+			return nil, fmt.Errorf("%s has no testnet", exchange)
+		}
+		for production, testnet := range hosts {
+			t.hosts[production] = testnet
+		}
+	}
+	return t, nil
+}
+
+type testnetTransport struct {
+	hosts map[string]string // Production host -> testnet host
+	next  http.RoundTripper
+}
+
+func (t *testnetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host, ok := t.hosts[strings.ToLower(req.URL.Hostname())]
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Host = host
+	req.Host = host
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,218 @@
+package adapters
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cex-price-diff-notifications/shared"
+)
+
+const (
+	binanceWalletConfigPath = "/sapi/v1/capital/config/getall"
+	mexcWalletConfigPath    = "/api/v3/capital/config/getall"
+	binanceWithdrawalsPath  = "/sapi/v1/capital/withdraw/history"
+	mexcWithdrawalsPath     = "/api/v3/capital/withdraw/history"
+	binanceDepositsPath     = "/sapi/v1/capital/deposit/hisrec"
+	mexcDepositsPath        = "/api/v3/capital/deposit/hisrec"
+	binanceAPIKeyHeader     = "X-MBX-APIKEY"
+	mexcAPIKeyHeader        = "X-MEXC-APIKEY"
+)
+
+// ErrMissingCredentials is returned by endpoints that require an API key when none is configured.
+var ErrMissingCredentials = errors.New("API credentials are not configured")
+
+// credentials holds an exchange API key pair used for signed (private) endpoints.
+type credentials struct {
+	apiKey    string
+	apiSecret string
+}
+
+// apiKeys holds the credentials of an adapter, which can be replaced while it fetches, e.g., after a rotation.
+type apiKeys struct {
+	current atomic.Pointer[credentials]
+}
+
+func (k *apiKeys) set(apiKey, apiSecret string) {
+	k.current.Store(&credentials{apiKey: apiKey, apiSecret: apiSecret})
+}
+
+func (k *apiKeys) get() credentials {
+	if c := k.current.Load(); c != nil {
+		return *c
+	}
+	return credentials{}
+}
+
+// networkAliases maps exchange-specific network codes to a common code, so the same chain matches across exchanges.
+var networkAliases = map[string]string{
+	"ERC20":    "ETH",
+	"TRC20":    "TRX",
+	"BEP20":    "BSC",
+	"BEP20BSC": "BSC",
+	"SPL":      "SOL",
+	"ARBITRUM": "ARB",
+	"ARBONE":   "ARB",
+	"OPTIMISM": "OP",
+	"POLYGON":  "MATIC",
+	"AVAX_C":   "AVAXC",
+}
+
+// NormalizeNetwork converts an exchange network code (e.g., "TRC20", "BEP20(BSC)") to a common code (e.g., "TRX", "BSC").
+func NormalizeNetwork(network string) string {
+	code := strings.ToUpper(strings.NewReplacer("(", "", ")", "", " ", "", "-", "").Replace(network))
+	if alias, ok := networkAliases[code]; ok {
+		return alias
+	}
+	return code
+}
+
+// signedGet performs a GET request against a Binance-compatible signed endpoint: the query is extended with
+// a timestamp and an HMAC-SHA256 signature, and the API key is sent in apiKeyHeader.
+func signedGet(ctx context.Context, exchangeName, baseURL, path, apiKeyHeader string, creds credentials, params url.Values) ([]byte, error) {
+	if creds.apiKey == "" || creds.apiSecret == "" {
+		return nil, newError(CodeAuthFailed, exchangeName, fmt.Errorf("%s %s: %w", exchangeName, path, ErrMissingCredentials))
+	}
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	query := params.Encode()
+
+	mac := hmac.New(sha256.New, []byte(creds.apiSecret))
+	mac.Write([]byte(query))
+	query += "&signature=" + hex.EncodeToString(mac.Sum(nil))
+
+	header := http.Header{}
+	header.Set(apiKeyHeader, creds.apiKey)
+	resp, err := httpGetWithHeader(ctx, exchangeName, baseURL+path+"?"+query, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request to %s %s: %w", exchangeName, path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(CodeExchangeDown, exchangeName, fmt.Errorf("failed to read %s %s response body: %w", exchangeName, path, err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(exchangeName, resp, body, fmt.Errorf("%s %s returned non-OK status: %d, body: %s", exchangeName, path, resp.StatusCode, string(body)))
+	}
+	return body, nil
+}
+
+// getWalletStatus fetches the deposit/withdrawal status of every coin from a Binance-compatible capital config
+// endpoint, keyed by asset.
+func getWalletStatus(ctx context.Context, exchangeName, baseURL, path, apiKeyHeader string, creds credentials) (map[string][]shared.AssetNetwork, error) {
+	body, err := signedGet(ctx, exchangeName, baseURL, path, apiKeyHeader, creds, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var coins []WalletCoinDto
+	if err := json.Unmarshal(body, &coins); err != nil {
+		return nil, decodeError(exchangeName, fmt.Errorf("failed to unmarshal %s wallet config: %w", exchangeName, err))
+	}
+
+	status := make(map[string][]shared.AssetNetwork, len(coins))
+	for _, c := range coins {
+		for _, n := range c.NetworkList {
+			network := n.Network
+			if n.NetWork != "" {
+				network = n.NetWork
+			}
+			fee, _ := strconv.ParseFloat(n.WithdrawFee, 64)
+			status[c.Coin] = append(status[c.Coin], shared.AssetNetwork{
+				Network:         NormalizeNetwork(network),
+				DepositEnabled:  n.DepositEnable,
+				WithdrawEnabled: n.WithdrawEnable,
+				WithdrawFee:     fee,
+			})
+		}
+	}
+	return status, nil
+}
+
+// getTransferHistory fetches the recent withdrawals and deposits of the account from Binance-compatible capital
+// history endpoints.
+func getTransferHistory(ctx context.Context, exchangeName, baseURL, withdrawalsPath, depositsPath, apiKeyHeader string, creds credentials) (withdrawals, deposits []shared.Transfer, err error) {
+	body, err := signedGet(ctx, exchangeName, baseURL, withdrawalsPath, apiKeyHeader, creds, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var withdrawalDtos []WithdrawalDto
+	if err := json.Unmarshal(body, &withdrawalDtos); err != nil {
+		return nil, nil, decodeError(exchangeName, fmt.Errorf("failed to unmarshal %s withdrawal history: %w", exchangeName, err))
+	}
+	for _, w := range withdrawalDtos {
+		applied, ok := parseHistoryTime(w.ApplyTime)
+		if !ok || w.TxID == "" {
+			continue
+		}
+		fee, _ := strconv.ParseFloat(w.TransactionFee, 64)
+		withdrawals = append(withdrawals, shared.Transfer{
+			Asset:   w.Coin,
+			Network: NormalizeNetwork(w.Network),
+			TxID:    normalizeTxID(w.TxID),
+			Time:    applied,
+			Fee:     fee,
+		})
+	}
+
+	body, err = signedGet(ctx, exchangeName, baseURL, depositsPath, apiKeyHeader, creds, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var depositDtos []DepositDto
+	if err := json.Unmarshal(body, &depositDtos); err != nil {
+		return nil, nil, decodeError(exchangeName, fmt.Errorf("failed to unmarshal %s deposit history: %w", exchangeName, err))
+	}
+	for _, d := range depositDtos {
+		credited := d.CompleteTime
+		if credited <= 0 {
+			credited = d.InsertTime
+		}
+		if credited <= 0 || d.TxID == "" {
+			continue
+		}
+		deposits = append(deposits, shared.Transfer{
+			Asset:   d.Coin,
+			Network: NormalizeNetwork(d.Network),
+			TxID:    normalizeTxID(d.TxID),
+			Time:    time.UnixMilli(credited),
+		})
+	}
+	return withdrawals, deposits, nil
+}
+
+// parseHistoryTime parses a history timestamp, either milliseconds or a "2006-01-02 15:04:05" UTC string.
+func parseHistoryTime(raw json.RawMessage) (time.Time, bool) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		t, err := time.Parse(time.DateTime, s)
+		return t, err == nil
+	}
+	var ms int64
+	if err := json.Unmarshal(raw, &ms); err != nil || ms <= 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// normalizeTxID strips the output index some exchanges append to a transaction ID (e.g., "0xabc:0") and
+// lowercases it, as exchanges differ in the case of hex IDs.
+func normalizeTxID(txID string) string {
+	id, _, _ := strings.Cut(strings.TrimSpace(txID), ":")
+	return strings.ToLower(id)
+}
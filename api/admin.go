@@ -0,0 +1,289 @@
+package api
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/lifecycle"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings are the publishing settings that can be changed at runtime.
+type Settings struct {
+	MinEntrySpreadPct   float64  `json:"min_entry_spread_pct"`  // Default minimum entry spread, in percent
+	MaxPublishedSpreads int      `json:"max_published_spreads"` // Maximum spreads published per cycle (0 means unlimited)
+	BlockedSymbols      []string `json:"blocked_symbols"`       // Globs matched against the unified symbol, its pair or its base asset
+}
+
+// settingsPatch is the body of PATCH /admin/settings; omitted fields are left unchanged.
+type settingsPatch struct {
+	MinEntrySpreadPct   *float64  `json:"min_entry_spread_pct"`
+	MaxPublishedSpreads *int      `json:"max_published_spreads"`
+	BlockedSymbols      *[]string `json:"blocked_symbols"`
+}
+
+// RuntimeSettings holds the current settings. The main loop reads them every cycle and the admin API
+// changes them, so changes apply from the next cycle without a restart. They are not persisted.
+type RuntimeSettings struct {
+	mu       sync.RWMutex
+	settings Settings
+}
+
+// NewRuntimeSettings creates runtime settings starting from the configured ones.
+func NewRuntimeSettings(initial Settings) (*RuntimeSettings, error) {
+	initial.BlockedSymbols = normalizeSymbols(initial.BlockedSymbols)
+	if err := initial.validate(); err != nil {
+		return nil, err
+	}
+	return &RuntimeSettings{settings: initial}, nil
+}
+
+// Get returns a copy of the current settings.
+func (r *RuntimeSettings) Get() Settings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s := r.settings
+	s.BlockedSymbols = slices.Clone(s.BlockedSymbols)
+	return s
+}
+
+// Set replaces the settings, e.g., with the ones of a reloaded config file, discarding changes made through
+// the admin API.
+func (r *RuntimeSettings) Set(s Settings) error {
+	_, err := r.update(func(current *Settings) { *current = s })
+	return err
+}
+
+// Blocked reports whether a unified symbol (e.g., "BTC/USDT:PERP") matches a blocked symbol pattern.
+func (r *RuntimeSettings) Blocked(unifiedSymbol string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.settings.BlockedSymbols) == 0 {
+		return false
+	}
+	pair, _, _ := strings.Cut(unifiedSymbol, ":")
+	base, _, _ := strings.Cut(pair, "/")
+	for _, pattern := range r.settings.BlockedSymbols {
+		for _, v := range []string{unifiedSymbol, pair, base} {
+			if ok, _ := path.Match(pattern, v); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// update applies a change to the settings if the result is valid.
+func (r *RuntimeSettings) update(change func(*Settings)) (Settings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.settings
+	s.BlockedSymbols = slices.Clone(s.BlockedSymbols)
+	change(&s)
+	s.BlockedSymbols = normalizeSymbols(s.BlockedSymbols)
+	if err := s.validate(); err != nil {
+		return Settings{}, err
+	}
+	r.settings = s
+	return s, nil
+}
+
+// validate checks the settings.
+func (s Settings) validate() error {
+	if s.MinEntrySpreadPct < 0 {
+		return fmt.Errorf("min_entry_spread_pct must not be negative, got %v", s.MinEntrySpreadPct)
+	}
+	if s.MaxPublishedSpreads < 0 {
+		return fmt.Errorf("max_published_spreads must not be negative, got %d", s.MaxPublishedSpreads)
+	}
+	for _, pattern := range s.BlockedSymbols {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid blocked symbol pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// normalizeSymbols uppercases, sorts and deduplicates symbol patterns.
+func normalizeSymbols(symbols []string) []string {
+	normalized := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			normalized = append(normalized, s)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// Admin serves the authenticated admin endpoints, with "Authorization: Bearer <token>":
+//
+//	GET    /admin/settings                   current settings
+//	PATCH  /admin/settings                   change some settings, e.g., {"min_entry_spread_pct": 0.3}
+//	PUT    /admin/blocked-symbols/{symbol}   block a symbol pattern, e.g., /admin/blocked-symbols/LUNA*
+//	DELETE /admin/blocked-symbols/{symbol}   unblock a symbol pattern
+//	GET    /admin/account                    futures balances and open positions, when accounts are tracked
+//	GET    /admin/positions                  entered opportunities watched for exit signals
+//	PUT    /admin/positions                  report an entered opportunity, with the lifecycle event acted on
+//	DELETE /admin/positions/{id}             stop watching an entered opportunity, by opportunity ID or pair key
+type Admin struct {
+	settings *RuntimeSettings
+	token    string
+	mux      *http.ServeMux
+	account  func() any             // Snapshot of the tracked accounts; nil when they aren't tracked
+	exits    *lifecycle.ExitSignals // Nil when exit signals are disabled
+}
+
+// enteredPosition is the body of PUT /admin/positions: an opportunity_opened or opportunity_updated event, or
+// just its spread.
+type enteredPosition struct {
+	OpportunityID string           `json:"opportunity_id"`
+	Spread        arbitrage.Spread `json:"spread"`
+}
+
+// NewAdmin creates the admin endpoints, to be mounted on "/admin/".
+func NewAdmin(settings *RuntimeSettings, token string) *Admin {
+	a := &Admin{settings: settings, token: token, mux: http.NewServeMux()}
+	a.mux.HandleFunc("GET /admin/settings", a.handleGetSettings)
+	a.mux.HandleFunc("PATCH /admin/settings", a.handlePatchSettings)
+	a.mux.HandleFunc("PUT /admin/blocked-symbols/{symbol...}", a.handleBlockSymbol)
+	a.mux.HandleFunc("DELETE /admin/blocked-symbols/{symbol...}", a.handleUnblockSymbol)
+	a.mux.HandleFunc("GET /admin/account", a.handleAccount)
+	a.mux.HandleFunc("GET /admin/positions", a.handleGetPositions)
+	a.mux.HandleFunc("PUT /admin/positions", a.handleEnterPosition)
+	a.mux.HandleFunc("DELETE /admin/positions/{id...}", a.handleExitPosition)
+	return a
+}
+
+// SetAccount sets the source of GET /admin/account. It must be called before the admin API serves requests.
+func (a *Admin) SetAccount(snapshot func() any) {
+	a.account = snapshot
+}
+
+// SetExitSignals sets the entered opportunities of /admin/positions. It must be called before the admin API
+// serves requests.
+func (a *Admin) SetExitSignals(exits *lifecycle.ExitSignals) {
+	a.exits = exits
+}
+
+// ServeHTTP authenticates the request and routes it.
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	a.mux.ServeHTTP(w, r)
+}
+
+func (a *Admin) handleGetSettings(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, a.settings.Get())
+}
+
+func (a *Admin) handlePatchSettings(w http.ResponseWriter, r *http.Request) {
+	var patch settingsPatch
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	a.apply(w, r, func(s *Settings) {
+		if patch.MinEntrySpreadPct != nil {
+			s.MinEntrySpreadPct = *patch.MinEntrySpreadPct
+		}
+		if patch.MaxPublishedSpreads != nil {
+			s.MaxPublishedSpreads = *patch.MaxPublishedSpreads
+		}
+		if patch.BlockedSymbols != nil {
+			s.BlockedSymbols = *patch.BlockedSymbols
+		}
+	})
+}
+
+func (a *Admin) handleBlockSymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	a.apply(w, r, func(s *Settings) {
+		s.BlockedSymbols = append(s.BlockedSymbols, symbol)
+	})
+}
+
+func (a *Admin) handleUnblockSymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	a.apply(w, r, func(s *Settings) {
+		s.BlockedSymbols = slices.DeleteFunc(s.BlockedSymbols, func(v string) bool { return strings.ToUpper(v) == symbol })
+	})
+}
+
+func (a *Admin) handleAccount(w http.ResponseWriter, _ *http.Request) {
+	if a.account == nil {
+		writeError(w, http.StatusNotFound, "account tracking is disabled")
+		return
+	}
+	writeJSON(w, http.StatusOK, a.account())
+}
+
+func (a *Admin) handleGetPositions(w http.ResponseWriter, _ *http.Request) {
+	if a.exits == nil {
+		writeError(w, http.StatusNotFound, "exit signals are disabled")
+		return
+	}
+	writeJSON(w, http.StatusOK, a.exits.Entries())
+}
+
+func (a *Admin) handleEnterPosition(w http.ResponseWriter, r *http.Request) {
+	if a.exits == nil {
+		writeError(w, http.StatusNotFound, "exit signals are disabled")
+		return
+	}
+	var body enteredPosition
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+	s := body.Spread
+	if s.UnifiedSymbol == "" || s.ExchangeShort == "" || s.ExchangeLong == "" {
+		writeError(w, http.StatusBadRequest, "spread.unified_symbol, spread.exchange_short and spread.exchange_long are required")
+		return
+	}
+	a.exits.Enter(body.OpportunityID, s, time.Now(), false)
+	slog.Info("Opportunity entered", "remote", r.RemoteAddr, "pair", s.PairKey(), "entry_spread_%", s.EntrySpread)
+	writeJSON(w, http.StatusOK, a.exits.Entries())
+}
+
+func (a *Admin) handleExitPosition(w http.ResponseWriter, r *http.Request) {
+	if a.exits == nil {
+		writeError(w, http.StatusNotFound, "exit signals are disabled")
+		return
+	}
+	id := r.PathValue("id")
+	if !a.exits.Exit(id) {
+		writeError(w, http.StatusNotFound, "no entered opportunity "+id)
+		return
+	}
+	slog.Info("Opportunity exited", "remote", r.RemoteAddr, "id", id)
+	writeJSON(w, http.StatusOK, a.exits.Entries())
+}
+
+// apply changes the settings and replies with the result.
+func (a *Admin) apply(w http.ResponseWriter, r *http.Request, change func(*Settings)) {
+	s, err := a.settings.update(change)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	slog.Info("Runtime settings changed",
+		"remote", r.RemoteAddr,
+		"min_entry_spread_%", s.MinEntrySpreadPct,
+		"max_published", s.MaxPublishedSpreads,
+		"blocked_symbols", s.BlockedSymbols,
+	)
+	writeJSON(w, http.StatusOK, s)
+}
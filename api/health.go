@@ -0,0 +1,205 @@
+package api
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds each dependency check of a probe.
+const healthCheckTimeout = 2 * time.Second
+
+// checkMaintenance is the result of an adapter paused for maintenance, which doesn't fail readiness.
+const checkMaintenance = "maintenance"
+
+// Check reports whether a dependency (e.g., a broker or Redis) is reachable.
+type Check func(ctx context.Context) error
+
+// namedCheck is a registered dependency check.
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Health backs the Kubernetes probes:
+//
+//	GET /healthz  liveness: the main loop completed a cycle within the freshness window
+//	GET /readyz   readiness: every dependency check passes and every adapter fetched tickers within the window,
+//	              unless paused for maintenance
+//
+// Both reply with the state of every check, and 503 when failing.
+type Health struct {
+	maxAge  time.Duration
+	started time.Time
+
+	mu        sync.Mutex
+	checks    []namedCheck
+	fetches   map[string]time.Time          // Last successful ticker fetch per exchange
+	failures  map[string]adapters.ErrorCode // Error code of the ticker fetches failing since, per exchange
+	paused    map[string]bool               // Exchanges whose fetches are paused for maintenance
+	lastCycle time.Time
+}
+
+// healthResponse is the body of the probes.
+type healthResponse struct {
+	Status string            `json:"status"` // "ok" or "fail"
+	Checks map[string]string `json:"checks"` // "ok" or the failure
+}
+
+// NewHealth creates probes considering adapters and the main loop stale after maxAge without progress.
+func NewHealth(maxAge time.Duration) *Health {
+	return &Health{maxAge: maxAge, started: time.Now(), fetches: make(map[string]time.Time), failures: make(map[string]adapters.ErrorCode), paused: make(map[string]bool)}
+}
+
+// AddCheck registers a dependency check for readiness.
+func (h *Health) AddCheck(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// TrackAdapter registers an exchange whose ticker fetches must stay fresh for readiness.
+func (h *Health) TrackAdapter(exchange string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.fetches[exchange]; !ok {
+		h.fetches[exchange] = time.Time{}
+	}
+}
+
+// MarkFetched records a successful ticker fetch from an exchange.
+func (h *Health) MarkFetched(exchange string, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fetches[exchange] = at
+	delete(h.failures, exchange)
+}
+
+// MarkFailed records a failed ticker fetch from an exchange. An authentication failure fails readiness right
+// away, as it won't recover without intervention; other failures only once the adapter's fetches are stale.
+func (h *Health) MarkFailed(exchange string, err error) {
+	code := adapters.CodeOf(err)
+	if code == "" {
+		return // Cancelled
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[exchange] = code
+}
+
+// SetMaintenance records whether an exchange's fetches are paused for maintenance. Its staleness doesn't fail
+// readiness while they are, as nothing is expected from it.
+func (h *Health) SetMaintenance(exchange string, paused bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if paused {
+		h.paused[exchange] = true
+	} else {
+		delete(h.paused, exchange)
+	}
+}
+
+// MarkCycle records a completed main loop cycle.
+func (h *Health) MarkCycle(at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCycle = at
+}
+
+// ServeLiveness handles /healthz.
+func (h *Health) ServeLiveness(w http.ResponseWriter, _ *http.Request) {
+	h.mu.Lock()
+	lastCycle := h.lastCycle
+	h.mu.Unlock()
+
+	resp := healthResponse{Status: "ok", Checks: map[string]string{"main_loop": "ok"}}
+	if err := h.fresh(lastCycle, time.Now()); err != "" {
+		resp.Status, resp.Checks["main_loop"] = "fail", err
+	}
+	writeHealth(w, resp)
+}
+
+// ServeReadiness handles /readyz.
+func (h *Health) ServeReadiness(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	checks := slices.Clone(h.checks)
+	fetches := make(map[string]time.Time, len(h.fetches))
+	for exchange, at := range h.fetches {
+		fetches[exchange] = at
+	}
+	failures := maps.Clone(h.failures)
+	paused := maps.Clone(h.paused)
+	h.mu.Unlock()
+
+	resp := healthResponse{Status: "ok", Checks: make(map[string]string, len(checks)+len(fetches))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+			defer cancel()
+			result := "ok"
+			if err := c.check(ctx); err != nil {
+				result = err.Error()
+			}
+			mu.Lock()
+			resp.Checks[c.name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now()
+	for exchange, at := range fetches {
+		resp.Checks["adapter:"+exchange] = "ok"
+		if paused[exchange] {
+			resp.Checks["adapter:"+exchange] = checkMaintenance
+			continue
+		}
+		code := failures[exchange]
+		if err := h.fresh(at, now); err != "" {
+			if code != "" {
+				err += " (last error: " + string(code) + ")"
+			}
+			resp.Checks["adapter:"+exchange] = err
+		} else if code == adapters.CodeAuthFailed {
+			resp.Checks["adapter:"+exchange] = string(code)
+		}
+	}
+	for _, result := range resp.Checks {
+		if result != "ok" && result != checkMaintenance {
+			resp.Status = "fail"
+		}
+	}
+	writeHealth(w, resp)
+}
+
+// fresh returns why at is stale, or "" if it is within the window. Before anything happened, the window
+// starts at startup, so probes don't fail while the first cycle runs.
+func (h *Health) fresh(at, now time.Time) string {
+	if at.IsZero() {
+		if now.Sub(h.started) <= h.maxAge {
+			return ""
+		}
+		return "never succeeded"
+	}
+	if age := now.Sub(at); age > h.maxAge {
+		return "stale for " + age.Round(time.Second).String()
+	}
+	return ""
+}
+
+// writeHealth writes a probe response, with 503 when failing.
+func writeHealth(w http.ResponseWriter, resp healthResponse) {
+	status := http.StatusOK
+	if resp.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,585 @@
+package api
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/funding"
+	"cex-price-diff-notifications/history"
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/marketdata"
+	"cex-price-diff-notifications/persistence"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server is the embedded HTTP server exposing the in-memory state:
+//
+//	GET /api/spreads                published opportunities; ?all=true for every candidate, filtered by
+//	                                ?symbol=, ?exchange=, ?min_entry= (percent) and capped by ?limit=
+//	GET /api/tickers                latest tickers of every symbol; ?exchange= keeps one exchange
+//	GET /api/tickers/{symbol}       latest tickers of a unified symbol, e.g., /api/tickers/BTC/USDT:PERP
+//	GET /api/funding                current funding rates of every exchange
+//	GET /api/funding/{exchange}     current funding rates of an exchange; ?symbol= keeps one symbol
+//	GET /api/funding/schedule       funding settlements of every exchange within ?horizon= (e.g., 24h), in
+//	                                chronological slots, filtered by ?symbol= and ?exchange=
+//	GET /api/candles/{symbol}       OHLC of the entry spread of every pair of a unified symbol, for
+//	                                ?timeframe= (the first aggregated by default), filtered by ?exchange= and
+//	                                with the last ?limit= candles
+//	GET /api/impact                 expected average fill price and slippage of a market order of ?notional=
+//	                                (USD) on ?side= (buy by default) of the order book of ?symbol= on ?exchange=
+//	GET /api/convergence            how long past opportunities of every pair lasted before converging, as
+//	                                percentiles and a histogram, filtered by ?symbol= and ?exchange=
+//	GET /api/history/opportunities  stored spreads, newest first, filtered by ?symbol=, ?exchange=,
+//	                                ?min_spread= (percent), ?from= and ?to= (RFC 3339 or milliseconds since
+//	                                epoch); pages of ?limit= (100 by default) follow ?cursor=, the next_cursor
+//	                                of the previous page
+type Server struct {
+	state           *State
+	candles         *history.CandleAggregator // nil if spread candles are disabled
+	orderBooks      OrderBookSource           // nil if impact estimates are disabled
+	history         HistorySource             // nil if Postgres is disabled
+	convergence     *lifecycle.Convergence    // nil if convergence tracking is disabled
+	scheduleHorizon time.Duration             // Default horizon of /api/funding/schedule
+	mux             *http.ServeMux
+	server          *http.Server
+}
+
+// maxScheduleHorizon caps the horizon of /api/funding/schedule, whose 1h markets settle every hour of it.
+const maxScheduleHorizon = 7 * 24 * time.Hour
+
+// Page sizes of /api/history/opportunities.
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// OrderBookSource provides the order book of a unified symbol on an exchange.
+type OrderBookSource interface {
+	OrderBook(ctx context.Context, exchange, unifiedSymbol string) (shared.OrderBook, error)
+}
+
+// HistorySource queries the stored spreads.
+type HistorySource interface {
+	QueryOpportunities(ctx context.Context, filter persistence.OpportunityFilter) (persistence.OpportunityPage, error)
+}
+
+// NewServer creates a server listening on addr (e.g., ":8080") with the API routes registered.
+func NewServer(addr string, state *State) *Server {
+	s := &Server{state: state, scheduleHorizon: 8 * time.Hour, mux: http.NewServeMux()}
+	s.server = &http.Server{Addr: addr, Handler: s.mux, ReadHeaderTimeout: 10 * time.Second}
+
+	s.mux.HandleFunc("GET /api/spreads", s.handleSpreads)
+	s.mux.HandleFunc("GET /api/tickers", s.handleTickers)
+	s.mux.HandleFunc("GET /api/tickers/{symbol...}", s.handleSymbolTickers)
+	s.mux.HandleFunc("GET /api/funding", s.handleFunding)
+	s.mux.HandleFunc("GET /api/funding/{exchange}", s.handleExchangeFunding)
+	s.mux.HandleFunc("GET /api/funding/schedule", s.handleFundingSchedule)
+	s.mux.HandleFunc("GET /api/candles/{symbol...}", s.handleCandles)
+	s.mux.HandleFunc("GET /api/impact", s.handleImpact)
+	s.mux.HandleFunc("GET /api/convergence", s.handleConvergence)
+	s.mux.HandleFunc("GET /api/history/opportunities", s.handleHistoryOpportunities)
+	return s
+}
+
+// SetCandles serves the candles of an aggregator on /api/candles. It must be called before Start.
+func (s *Server) SetCandles(candles *history.CandleAggregator) {
+	s.candles = candles
+}
+
+// SetOrderBooks serves impact estimates on /api/impact from the order books of books. It must be called
+// before Start.
+func (s *Server) SetOrderBooks(books OrderBookSource) {
+	s.orderBooks = books
+}
+
+// SetConvergence serves the convergence times of convergence on /api/convergence. It must be called before Start.
+func (s *Server) SetConvergence(convergence *lifecycle.Convergence) {
+	s.convergence = convergence
+}
+
+// SetHistory serves the stored spreads of source on /api/history. It must be called before Start.
+func (s *Server) SetHistory(source HistorySource) {
+	s.history = source
+}
+
+// SetScheduleHorizon sets the default horizon of /api/funding/schedule (8h by default). It must be called
+// before Start.
+func (s *Server) SetScheduleHorizon(horizon time.Duration) {
+	s.scheduleHorizon = horizon
+}
+
+// Handle registers an additional route, e.g., for metrics or health checks. Routes must be registered
+// before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start serves requests in the background. A failing listener is logged; it doesn't stop the scanner.
+func (s *Server) Start() {
+	slog.Info("Starting HTTP API", "addr", s.server.Addr)
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Failed to serve the HTTP API", "addr", s.server.Addr, "error", err)
+		}
+	}()
+}
+
+// Shutdown stops accepting requests and waits for the active ones to complete.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// spreadsResponse is the body of /api/spreads.
+type spreadsResponse struct {
+	UpdatedAt int64              `json:"updated_at"` // Milliseconds since epoch, 0 before the first cycle
+	Cycle     uint64             `json:"cycle"`
+	Count     int                `json:"count"`
+	Spreads   []arbitrage.Spread `json:"spreads"`
+}
+
+// tickerResponse is a ticker as served by the API.
+type tickerResponse struct {
+	Exchange      string  `json:"exchange"`
+	UnifiedSymbol string  `json:"unified_symbol"`
+	Symbol        string  `json:"symbol"`
+	Bid           float64 `json:"bid"`
+	Ask           float64 `json:"ask"`
+	BidQty        float64 `json:"bid_qty,omitempty"`
+	AskQty        float64 `json:"ask_qty,omitempty"`
+	VolumeUSD     float64 `json:"volume_usd,omitempty"`
+	Timestamp     int64   `json:"timestamp"` // Milliseconds since epoch
+}
+
+// tickersResponse is the body of /api/tickers.
+type tickersResponse struct {
+	UpdatedAt int64            `json:"updated_at"`
+	Count     int              `json:"count"`
+	Tickers   []tickerResponse `json:"tickers"`
+}
+
+// fundingResponse is a funding rate as served by the API.
+type fundingResponse struct {
+	Exchange      string `json:"exchange"`
+	UnifiedSymbol string `json:"unified_symbol"`
+	shared.FundingRateInfo
+}
+
+// fundingListResponse is the body of /api/funding.
+type fundingListResponse struct {
+	UpdatedAt int64             `json:"updated_at"`
+	Count     int               `json:"count"`
+	Rates     []fundingResponse `json:"rates"`
+}
+
+func (s *Server) handleSpreads(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	minEntry, err := parseFloatParam(q.Get("min_entry"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid min_entry")
+		return
+	}
+	limit, err := parseIntParam(q.Get("limit"))
+	if err != nil || limit < 0 {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	symbol := strings.ToUpper(q.Get("symbol"))
+	exchange := q.Get("exchange")
+
+	s.state.mu.RLock()
+	resp := spreadsResponse{UpdatedAt: unixMilli(s.state.updatedAt), Cycle: s.state.snapshot.Cycle, Spreads: []arbitrage.Spread{}}
+	source := s.state.snapshot.Opportunities
+	if q.Get("all") == "true" {
+		source = s.state.spreads
+	}
+	for _, sp := range source {
+		if symbol != "" && sp.UnifiedSymbol != symbol {
+			continue
+		}
+		if exchange != "" && !strings.EqualFold(sp.ExchangeShort, exchange) && !strings.EqualFold(sp.ExchangeLong, exchange) {
+			continue
+		}
+		if q.Has("min_entry") && sp.EntrySpread < minEntry {
+			continue
+		}
+		resp.Spreads = append(resp.Spreads, sp)
+		if limit > 0 && len(resp.Spreads) == limit {
+			break
+		}
+	}
+	s.state.mu.RUnlock()
+
+	resp.Count = len(resp.Spreads)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleTickers(w http.ResponseWriter, r *http.Request) {
+	exchange := r.URL.Query().Get("exchange")
+
+	s.state.mu.RLock()
+	resp := tickersResponse{UpdatedAt: unixMilli(s.state.updatedAt), Tickers: []tickerResponse{}}
+	for _, byExchange := range s.state.tickers {
+		for ex, t := range byExchange {
+			if exchange == "" || strings.EqualFold(ex, exchange) {
+				resp.Tickers = append(resp.Tickers, newTickerResponse(ex, t))
+			}
+		}
+	}
+	s.state.mu.RUnlock()
+
+	sortTickers(resp.Tickers)
+	resp.Count = len(resp.Tickers)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSymbolTickers(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+
+	s.state.mu.RLock()
+	resp := tickersResponse{UpdatedAt: unixMilli(s.state.updatedAt), Tickers: []tickerResponse{}}
+	byExchange, ok := s.state.tickers[symbol]
+	for ex, t := range byExchange {
+		resp.Tickers = append(resp.Tickers, newTickerResponse(ex, t))
+	}
+	s.state.mu.RUnlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown symbol "+symbol)
+		return
+	}
+	sortTickers(resp.Tickers)
+	resp.Count = len(resp.Tickers)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleFunding(w http.ResponseWriter, r *http.Request) {
+	s.state.mu.RLock()
+	resp := fundingListResponse{UpdatedAt: unixMilli(s.state.updatedAt), Rates: []fundingResponse{}}
+	for ex, bySymbol := range s.state.funding {
+		resp.Rates = appendFunding(resp.Rates, ex, bySymbol, "")
+	}
+	s.state.mu.RUnlock()
+
+	sortFunding(resp.Rates)
+	resp.Count = len(resp.Rates)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleExchangeFunding(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+
+	s.state.mu.RLock()
+	resp := fundingListResponse{UpdatedAt: unixMilli(s.state.updatedAt), Rates: []fundingResponse{}}
+	found := false
+	for ex, bySymbol := range s.state.funding {
+		if strings.EqualFold(ex, exchange) {
+			found = true
+			resp.Rates = appendFunding(resp.Rates, ex, bySymbol, symbol)
+		}
+	}
+	s.state.mu.RUnlock()
+
+	if !found {
+		writeError(w, http.StatusNotFound, "unknown exchange "+exchange)
+		return
+	}
+	sortFunding(resp.Rates)
+	resp.Count = len(resp.Rates)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleFundingSchedule(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	horizon := s.scheduleHorizon
+	if v := q.Get("horizon"); v != "" {
+		var err error
+		if horizon, err = time.ParseDuration(v); err != nil || horizon <= 0 || horizon > maxScheduleHorizon {
+			writeError(w, http.StatusBadRequest, "invalid horizon, must be positive and at most "+maxScheduleHorizon.String())
+			return
+		}
+	}
+	symbol, exchange := strings.ToUpper(q.Get("symbol")), q.Get("exchange")
+	keep := func(ex, unifiedSymbol string) bool {
+		return (exchange == "" || strings.EqualFold(ex, exchange)) && (symbol == "" || unifiedSymbol == symbol)
+	}
+
+	s.state.mu.RLock()
+	calendar := funding.NewCalendar(s.state.funding, time.Now(), horizon, keep)
+	s.state.mu.RUnlock()
+	writeJSON(w, http.StatusOK, calendar)
+}
+
+// candlesResponse is the body of /api/candles.
+type candlesResponse struct {
+	Count  int                    `json:"count"`
+	Series []history.CandleSeries `json:"series"`
+}
+
+func (s *Server) handleCandles(w http.ResponseWriter, r *http.Request) {
+	if s.candles == nil {
+		writeError(w, http.StatusNotFound, "spread candles are disabled")
+		return
+	}
+	q := r.URL.Query()
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	exchange := q.Get("exchange")
+	timeframe := s.candles.Timeframes()[0]
+	if v := q.Get("timeframe"); v != "" {
+		var err error
+		if timeframe, err = time.ParseDuration(v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid timeframe")
+			return
+		}
+	}
+	limit, err := parseIntParam(q.Get("limit"))
+	if err != nil || limit < 0 {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	series, err := s.candles.Series(symbol, timeframe)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	resp := candlesResponse{Series: []history.CandleSeries{}}
+	for _, cs := range series {
+		if exchange != "" && !strings.EqualFold(cs.ExchangeShort, exchange) && !strings.EqualFold(cs.ExchangeLong, exchange) {
+			continue
+		}
+		if limit > 0 && len(cs.Candles) > limit {
+			cs.Candles = cs.Candles[len(cs.Candles)-limit:]
+		}
+		resp.Series = append(resp.Series, cs)
+	}
+	if len(resp.Series) == 0 {
+		writeError(w, http.StatusNotFound, "no candles for "+symbol)
+		return
+	}
+	slices.SortFunc(resp.Series, func(a, b history.CandleSeries) int {
+		return cmpPair(a.ExchangeShort, b.ExchangeShort, a.ExchangeLong, b.ExchangeLong)
+	})
+	resp.Count = len(resp.Series)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleImpact(w http.ResponseWriter, r *http.Request) {
+	if s.orderBooks == nil {
+		writeError(w, http.StatusNotFound, "impact estimates are disabled")
+		return
+	}
+	q := r.URL.Query()
+	symbol, exchange := strings.ToUpper(q.Get("symbol")), q.Get("exchange")
+	if symbol == "" || exchange == "" {
+		writeError(w, http.StatusBadRequest, "symbol and exchange are required")
+		return
+	}
+	// A bare base asset is quoted like the CLI's, e.g., BTC for BTC/USDT:PERP
+	if !strings.Contains(symbol, "/") {
+		symbol = shared.PerpSymbol(symbol, shared.DefaultQuoteCurrency()).String()
+	}
+	notional, err := parseFloatParam(q.Get("notional"))
+	if err != nil || notional <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid notional")
+		return
+	}
+	side := strings.ToLower(q.Get("side"))
+	if side == "" {
+		side = arbitrage.SideBuy
+	}
+
+	book, err := s.orderBooks.OrderBook(r.Context(), exchange, symbol)
+	switch {
+	case errors.Is(err, marketdata.ErrUnknownExchange), adapters.CodeOf(err) == adapters.CodeSymbolUnsupported:
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		slog.Warn("Failed to fetch the order book for an impact estimate", "exchange", exchange, "symbol", symbol, "error", err)
+		writeError(w, http.StatusBadGateway, "failed to fetch the order book")
+		return
+	}
+	impact, err := arbitrage.EstimateImpact(book, side, notional)
+	switch {
+	case errors.Is(err, arbitrage.ErrEmptyBook):
+		writeError(w, http.StatusNotFound, "no "+side+" liquidity for "+symbol+" on "+book.Exchange)
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, impact)
+	}
+}
+
+// convergenceResponse is the body of /api/convergence.
+type convergenceResponse struct {
+	Count int                         `json:"count"`
+	Pairs []lifecycle.PairConvergence `json:"pairs"`
+}
+
+func (s *Server) handleConvergence(w http.ResponseWriter, r *http.Request) {
+	if s.convergence == nil {
+		writeError(w, http.StatusNotFound, "convergence tracking is disabled")
+		return
+	}
+	q := r.URL.Query()
+	symbol := strings.ToUpper(q.Get("symbol"))
+	// A bare base asset is quoted like the CLI's, e.g., BTC for BTC/USDT:PERP
+	if symbol != "" && !strings.Contains(symbol, "/") {
+		symbol = shared.PerpSymbol(symbol, shared.DefaultQuoteCurrency()).String()
+	}
+	pairs := s.convergence.Pairs(symbol, q.Get("exchange"))
+	writeJSON(w, http.StatusOK, convergenceResponse{Count: len(pairs), Pairs: pairs})
+}
+
+func (s *Server) handleHistoryOpportunities(w http.ResponseWriter, r *http.Request) {
+	if s.history == nil {
+		writeError(w, http.StatusNotFound, "history queries are disabled")
+		return
+	}
+	q := r.URL.Query()
+	filter := persistence.OpportunityFilter{Symbol: strings.ToUpper(q.Get("symbol")), Exchange: q.Get("exchange"), Cursor: q.Get("cursor")}
+	// A bare base asset is quoted like the CLI's, e.g., BTC for BTC/USDT:PERP
+	if filter.Symbol != "" && !strings.Contains(filter.Symbol, "/") {
+		filter.Symbol = shared.PerpSymbol(filter.Symbol, shared.DefaultQuoteCurrency()).String()
+	}
+	var err error
+	if filter.MinSpread, err = parseFloatParam(q.Get("min_spread")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid min_spread")
+		return
+	}
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from")
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to")
+		return
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		writeError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	if filter.Limit, err = parseIntParam(q.Get("limit")); err != nil || filter.Limit < 0 || filter.Limit > maxHistoryLimit {
+		writeError(w, http.StatusBadRequest, "invalid limit, at most "+strconv.Itoa(maxHistoryLimit))
+		return
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+
+	page, err := s.history.QueryOpportunities(r.Context(), filter)
+	switch {
+	case errors.Is(err, persistence.ErrInvalidCursor):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		slog.Warn("Failed to query opportunity history", "error", err)
+		writeError(w, http.StatusBadGateway, "failed to query the history")
+	default:
+		writeJSON(w, http.StatusOK, page)
+	}
+}
+
+// newTickerResponse converts a ticker for the API.
+func newTickerResponse(exchange string, t shared.TickerBidAsk) tickerResponse {
+	return tickerResponse{
+		Exchange:      exchange,
+		UnifiedSymbol: t.UnifiedSymbol,
+		Symbol:        t.Symbol,
+		Bid:           t.Bid,
+		Ask:           t.Ask,
+		BidQty:        t.BidQty,
+		AskQty:        t.AskQty,
+		VolumeUSD:     t.VolumeUSD,
+		Timestamp:     unixMilli(t.Timestamp),
+	}
+}
+
+// appendFunding appends an exchange's funding rates, or only symbol's if set.
+func appendFunding(rates []fundingResponse, exchange string, bySymbol map[string]shared.FundingRateInfo, symbol string) []fundingResponse {
+	for unifiedSymbol, info := range bySymbol {
+		if symbol == "" || unifiedSymbol == symbol {
+			rates = append(rates, fundingResponse{Exchange: exchange, UnifiedSymbol: unifiedSymbol, FundingRateInfo: info})
+		}
+	}
+	return rates
+}
+
+// sortTickers orders tickers by symbol, then exchange, so responses are stable.
+func sortTickers(tickers []tickerResponse) {
+	slices.SortFunc(tickers, func(a, b tickerResponse) int {
+		return cmpPair(a.UnifiedSymbol, b.UnifiedSymbol, a.Exchange, b.Exchange)
+	})
+}
+
+// sortFunding orders funding rates by exchange, then symbol.
+func sortFunding(rates []fundingResponse) {
+	slices.SortFunc(rates, func(a, b fundingResponse) int {
+		return cmpPair(a.Exchange, b.Exchange, a.UnifiedSymbol, b.UnifiedSymbol)
+	})
+}
+
+// cmpPair compares by a first and b second.
+func cmpPair(a1, a2, b1, b2 string) int {
+	if c := strings.Compare(a1, a2); c != 0 {
+		return c
+	}
+	return strings.Compare(b1, b2)
+}
+
+// unixMilli returns t in milliseconds since epoch, or 0 for the zero time.
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// parseFloatParam parses an optional float query parameter, with 0 for an empty value.
+func parseFloatParam(v string) (float64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// parseIntParam parses an optional integer query parameter, with 0 for an empty value.
+func parseIntParam(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// parseTimeParam parses an optional time query parameter, in RFC 3339 or milliseconds since epoch, with the
+// zero time for an empty value.
+func parseTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("Failed to write HTTP API response", "error", err)
+	}
+}
+
+// writeError writes a JSON error body.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
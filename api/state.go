@@ -0,0 +1,37 @@
+package api
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/shared"
+	"sync"
+	"time"
+)
+
+// State holds the data of the latest completed cycle. The main loop replaces it after each cycle and the
+// HTTP handlers read it, so requests never touch the adapters' own maps.
+type State struct {
+	mu        sync.RWMutex
+	updatedAt time.Time
+	snapshot  arbitrage.Snapshot                           // Published opportunities, best first
+	spreads   []arbitrage.Spread                           // Every candidate spread, before the publish limits
+	tickers   map[string]map[string]shared.TickerBidAsk    // Keyed by unified symbol and exchange
+	funding   map[string]map[string]shared.FundingRateInfo // Keyed by exchange and unified symbol
+}
+
+// NewState creates an empty state.
+func NewState() *State {
+	return &State{snapshot: arbitrage.Snapshot{Opportunities: []arbitrage.Spread{}}}
+}
+
+// Update replaces the state with a cycle's results. The maps and slices are kept as they are, so callers
+// must not modify them afterwards.
+func (s *State) Update(at time.Time, snapshot arbitrage.Snapshot, spreads []arbitrage.Spread,
+	tickers map[string]map[string]shared.TickerBidAsk, funding map[string]map[string]shared.FundingRateInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updatedAt = at
+	s.snapshot = snapshot
+	s.spreads = spreads
+	s.tickers = tickers
+	s.funding = funding
+}
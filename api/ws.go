@@ -0,0 +1,251 @@
+package api
+
+import (
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/messaging"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingInterval = wsPongTimeout * 9 / 10
+	wsSendBuffer   = 256 // Messages queued per client before it is dropped as too slow
+	wsMaxMessage   = 4096
+)
+
+// subscribeMessage is sent by clients to filter the events they receive. Each subscribe replaces the
+// previous filter; without one, clients receive every event.
+//
+//	{"type": "subscribe", "symbols": ["BTC", "ETH/USDT:PERP"], "min_entry_spread": 0.3}
+type subscribeMessage struct {
+	Type           string   `json:"type"`
+	Symbols        []string `json:"symbols"`          // Globs matched against the unified symbol, its pair or its base asset (empty matches all)
+	MinEntrySpread float64  `json:"min_entry_spread"` // In percent
+}
+
+// wsFilter is a client's subscription.
+type wsFilter struct {
+	symbols        []string
+	minEntrySpread float64
+}
+
+// matches reports whether an event passes the filter.
+func (f wsFilter) matches(e lifecycle.Event) bool {
+	// Closed events are always delivered, so clients can drop what they show
+	if e.EventType != lifecycle.EventClosed && e.Spread.EntrySpread < f.minEntrySpread {
+		return false
+	}
+	if len(f.symbols) == 0 {
+		return true
+	}
+	pair, _, _ := strings.Cut(e.Spread.UnifiedSymbol, ":")
+	base, _, _ := strings.Cut(pair, "/")
+	for _, pattern := range f.symbols {
+		for _, v := range []string{e.Spread.UnifiedSymbol, pair, base} {
+			if ok, _ := path.Match(pattern, v); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsClient is a connected WebSocket client.
+type wsClient struct {
+	conn *websocket.Conn
+	send chan []byte
+
+	mu     sync.Mutex
+	filter wsFilter
+}
+
+// Hub streams opportunity opened/updated/closed events to WebSocket clients on /ws.
+type Hub struct {
+	encoder  messaging.Encoder
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	clients map[*wsClient]bool
+	closed  bool
+	writers sync.WaitGroup
+}
+
+// NewHub creates a hub encoding events like the other publishers. Connections from any origin are
+// accepted, as browser dashboards are typically served elsewhere.
+func NewHub(encoder messaging.Encoder) *Hub {
+	return &Hub{
+		encoder:  encoder,
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+		clients:  make(map[*wsClient]bool),
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and streams events to it until it disconnects.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Debug("Failed to upgrade WebSocket connection", "remote", r.RemoteAddr, "error", err)
+		return // The upgrader has already replied
+	}
+	c := &wsClient{conn: conn, send: make(chan []byte, wsSendBuffer)}
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(wsWriteTimeout))
+		conn.Close()
+		return
+	}
+	h.clients[c] = true
+	h.writers.Add(1)
+	h.mu.Unlock()
+	slog.Info("WebSocket client connected", "remote", r.RemoteAddr, "clients", h.Len())
+
+	go func() {
+		defer h.writers.Done()
+		h.write(c)
+	}()
+	h.read(c)
+}
+
+// Close disconnects every client and waits for their writers to send the close message. The HTTP
+// server's Shutdown doesn't track WebSocket connections, so this is called after it on shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	for c := range h.clients {
+		h.remove(c)
+	}
+	h.mu.Unlock()
+	h.writers.Wait()
+}
+
+// Len returns the number of connected clients.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Broadcast sends each event to the clients whose filter it matches. Clients that fall too far behind
+// are disconnected rather than holding up the others.
+func (h *Hub) Broadcast(events []lifecycle.Event) {
+	if len(events) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.clients) == 0 {
+		return
+	}
+	for _, e := range events {
+		body, err := h.encoder.Encode(e.EventType, e)
+		if err != nil {
+			slog.Error("Failed to marshal WebSocket event to JSON", "error", err)
+			continue
+		}
+		for c := range h.clients {
+			c.mu.Lock()
+			ok := c.filter.matches(e)
+			c.mu.Unlock()
+			if !ok {
+				continue
+			}
+			select {
+			case c.send <- body:
+			default:
+				slog.Warn("WebSocket client too slow, disconnecting", "remote", c.conn.RemoteAddr())
+				h.remove(c)
+			}
+		}
+	}
+}
+
+// remove unregisters a client and stops its writer. The caller must hold h.mu.
+func (h *Hub) remove(c *wsClient) {
+	if h.clients[c] {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// read handles subscribe messages until the connection fails, then unregisters the client.
+func (h *Hub) read(c *wsClient) {
+	defer func() {
+		h.mu.Lock()
+		h.remove(c)
+		h.mu.Unlock()
+		c.conn.Close()
+		slog.Info("WebSocket client disconnected", "remote", c.conn.RemoteAddr())
+	}()
+
+	c.conn.SetReadLimit(wsMaxMessage)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	for {
+		var msg subscribeMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				slog.Debug("Failed to read WebSocket message", "remote", c.conn.RemoteAddr(), "error", err)
+			}
+			return
+		}
+		if msg.Type != "subscribe" {
+			continue
+		}
+		filter := wsFilter{minEntrySpread: msg.MinEntrySpread}
+		for _, s := range msg.Symbols {
+			filter.symbols = append(filter.symbols, strings.ToUpper(s))
+		}
+		c.mu.Lock()
+		c.filter = filter
+		c.mu.Unlock()
+
+		ack, _ := json.Marshal(map[string]any{"type": "subscribed", "symbols": filter.symbols, "min_entry_spread": filter.minEntrySpread})
+		h.mu.Lock()
+		if h.clients[c] {
+			select {
+			case c.send <- ack:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// write sends queued messages and keepalive pings until the send channel is closed.
+func (h *Hub) write(c *wsClient) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case body, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, body); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package arbitrage
+
+import "cex-price-diff-notifications/shared"
+
+// Balances provides the margin available per exchange and asset.
+type Balances interface {
+	Available(exchange, asset string) (float64, bool)
+}
+
+// ApplyBalances marks each perpetual spread as tradable or not with the margin available on both exchanges:
+// each leg needs its notional divided by leverage, in its quote currency. Spreads whose balances are unknown
+// (not tracked or not fetched yet) and spot spreads are left unmarked.
+func ApplyBalances(spreads []Spread, balances Balances, leverage float64) {
+	leverage = max(leverage, 1)
+	for i := range spreads {
+		s := &spreads[i]
+		short, err := shared.ParseSymbol(s.UnifiedSymbol)
+		if err != nil || !short.IsPerp() {
+			continue
+		}
+		long, err := shared.ParseSymbol(s.LongSymbol())
+		if err != nil {
+			continue
+		}
+		shortMargin, okShort := balances.Available(s.ExchangeShort, short.Quote())
+		longMargin, okLong := balances.Available(s.ExchangeLong, long.Quote())
+		if !okShort || !okLong {
+			continue
+		}
+
+		notional := max(min(shortMargin, longMargin), 0) * leverage
+		tradable := notional > 0 && notional >= s.TargetNotionalUSD
+		s.TradableNotionalUSD = &notional
+		s.Tradable = &tradable
+	}
+}
+
+// SelectTradable drops the spreads marked untradable, returning the rest and how many were dropped. Unmarked
+// spreads are kept.
+func SelectTradable(spreads []Spread) ([]Spread, int) {
+	kept := spreads[:0:0]
+	for _, s := range spreads {
+		if s.Tradable == nil || *s.Tradable {
+			kept = append(kept, s)
+		}
+	}
+	return kept, len(spreads) - len(kept)
+}
@@ -1,147 +1,462 @@
 package arbitrage
 
 import (
-	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/fx"
 	"cex-price-diff-notifications/shared"
+	"cmp"
 	"log/slog"
-	"sort"
-	"strconv"
+	"slices"
+	"time"
 )
 
 // Spread represents a potential arbitrage opportunity between two exchanges.
 type Spread struct {
-	UnifiedSymbol    string                  `json:"unified_symbol"`
-	ExchangeShort    string                  `json:"exchange_short"`              // The exchange to sell on (higher bid).
-	ExchangeLong     string                  `json:"exchange_long"`               // The exchange to buy on (lower ask).
-	EntrySpread      float64                 `json:"entry_spread"`                // The calculated profit percentage for entering the trade.
-	OpenDiff         float64                 `json:"open_diff"`                   // The raw price difference (Bid_Short - Ask_Long).
-	ExitSpread       float64                 `json:"exit_spread"`                 // The calculated profit percentage for exiting the trade.
-	ExitDiff         float64                 `json:"exit_diff"`                   // The raw price difference (Bid_Long - Ask_Short).
-	FundingSpread8h  *float64                `json:"funding_spread_8h,omitempty"` // The 8-hour funding spread.
-	FundingRateShort *shared.FundingRateInfo `json:"funding_rate_short,omitempty"`
-	FundingRateLong  *shared.FundingRateInfo `json:"funding_rate_long,omitempty"`
-}
-
-// CalculateSpreads identifies arbitrage opportunities from a map of tickers and funding rates.
+	OpportunityID           string                  `json:"opportunity_id,omitempty"` // Same for every event of the opportunity while it lasts, see OpportunityIDs.
+	UnifiedSymbol           string                  `json:"unified_symbol"`
+	UnifiedSymbolLong       string                  `json:"unified_symbol_long,omitempty"`   // Set for cross-quote spreads, where the long leg trades a different quote.
+	ExchangeShort           string                  `json:"exchange_short"`                  // The exchange to sell on (higher bid).
+	ExchangeLong            string                  `json:"exchange_long"`                   // The exchange to buy on (lower ask).
+	EntrySpread             float64                 `json:"entry_spread"`                    // The calculated profit percentage for entering the trade.
+	ConservativeEntrySpread float64                 `json:"conservative_entry_spread"`       // The entry spread after applying the assumed slippage to both legs.
+	OpenDiff                float64                 `json:"open_diff"`                       // The raw price difference (Bid_Short - Ask_Long).
+	ExitSpread              float64                 `json:"exit_spread"`                     // The calculated profit percentage for exiting the trade.
+	ExitDiff                float64                 `json:"exit_diff"`                       // The raw price difference (Bid_Long - Ask_Short).
+	ConversionRate          float64                 `json:"conversion_rate,omitempty"`       // Multiplier applied to long leg prices to express them in the short leg's quote.
+	FundingSpread8h         *float64                `json:"funding_spread_8h,omitempty"`     // The 8-hour funding spread.
+	AvgFundingSpread8h      *float64                `json:"avg_funding_spread_8h,omitempty"` // The 8-hour funding spread from trailing settled rates.
+	RoundTripFeesPct        float64                 `json:"round_trip_fees_pct"`             // Taker fees for opening and closing both legs, in percent.
+	ExpectedPnL24h          *float64                `json:"expected_pnl_24h,omitempty"`      // Entry spread minus fees plus funding accrued over the holding horizon.
+	PnLHorizonHours         float64                 `json:"pnl_horizon_hours,omitempty"`     // The holding horizon used for ExpectedPnL24h (24 by default).
+	BreakEvenHours          *float64                `json:"break_even_hours,omitempty"`      // Holding time at which funding accrual cancels the entry edge (erodes) or covers its cost (recovers).
+	BreakEvenType           string                  `json:"break_even_type,omitempty"`       // "erodes" when funding eats a positive edge, "recovers" when funding makes up a negative one.
+	TargetNotionalUSD       float64                 `json:"target_notional_usd,omitempty"`   // Intended position size per leg for this symbol.
+	Tradable                *bool                   `json:"tradable,omitempty"`              // Both legs have the margin for the target notional; nil unless balances are tracked.
+	TradableNotionalUSD     *float64                `json:"tradable_notional_usd,omitempty"` // Largest notional per leg the available margin allows.
+	OrderQty                *float64                `json:"order_qty,omitempty"`             // Base quantity per leg for the target notional, valid on both contracts; 0 below their minimums.
+	OrderNotionalUSD        float64                 `json:"order_notional_usd,omitempty"`    // OrderQty at the long leg's ask, or its expected fill price once the legs are suggested.
+	OrderSizeLimit          string                  `json:"order_size_limit,omitempty"`      // What bounds OrderQty, one of the SizeLimit* constants.
+	LegShort                *LegOrder               `json:"leg_short,omitempty"`             // Suggested order of the short leg, for published spreads with a non-zero OrderQty.
+	LegLong                 *LegOrder               `json:"leg_long,omitempty"`              // Suggested order of the long leg.
+	FundingRateShort        *shared.FundingRateInfo `json:"funding_rate_short,omitempty"`
+	FundingRateLong         *shared.FundingRateInfo `json:"funding_rate_long,omitempty"`
+	FundingDegraded         bool                    `json:"funding_degraded,omitempty"`         // A leg's exchange had missing or stale funding rates, so the funding figures may be off.
+	SecondsToFundingShort   *int64                  `json:"seconds_to_funding_short,omitempty"` // Time until the short leg's next funding settlement.
+	SecondsToFundingLong    *int64                  `json:"seconds_to_funding_long,omitempty"`  // Time until the long leg's next funding settlement.
+	FavorableFundingSoon    bool                    `json:"favorable_funding_soon"`             // A leg collects funding within the configured window.
+	ContractShort           *shared.ContractSpec    `json:"contract_short,omitempty"`           // Trading constraints of the short leg's contract.
+	ContractLong            *shared.ContractSpec    `json:"contract_long,omitempty"`            // Trading constraints of the long leg's contract.
+	Transfer                *TransferRoute          `json:"transfer,omitempty"`                 // Set for spot-spot spreads: how the base asset moves between exchanges.
+	LatencyShortMs          int64                   `json:"latency_short_ms,omitempty"`         // Measured round-trip latency to the short leg's exchange.
+	LatencyLongMs           int64                   `json:"latency_long_ms,omitempty"`          // Measured round-trip latency to the long leg's exchange.
+	LatencyHaircutPct       float64                 `json:"latency_haircut_pct,omitempty"`      // Entry spread expected to be lost to execution latency, in percent.
+	LatencyAdjustedSpread   float64                 `json:"latency_adjusted_spread,omitempty"`  // Entry spread minus the latency haircut.
+	VolumeUSD               float64                 `json:"volume_usd,omitempty"`               // The smaller of the two legs' 24h quote volumes.
+	IndexPrice              float64                 `json:"index_price,omitempty"`              // Composite mid price of the symbol across exchanges, in the short leg's quote.
+	OutlierLeg              string                  `json:"outlier_leg,omitempty"`              // The leg deviating most from the index price ("short" or "long"), the side likely to mean-revert.
+	OutlierExchange         string                  `json:"outlier_exchange,omitempty"`         // The exchange of the outlier leg.
+	OutlierDeviationPct     float64                 `json:"outlier_deviation_pct,omitempty"`    // Deviation of the outlier leg's mid from the index price, in percent (negative below it).
+	Maintenance             *MaintenanceNotice      `json:"maintenance,omitempty"`              // Upcoming maintenance of either leg's exchange.
+	QuoteTime               time.Time               `json:"quote_time"`                         // Timestamp of the older of the two legs' tickers.
+	DataAgeMs               int64                   `json:"data_age_ms,omitempty"`              // Age of QuoteTime when the spread was published.
+	Score                   float64                 `json:"score"`                              // Composite ranking score assigned by the configured Scorer.
+	Stats                   *SpreadStats            `json:"stats,omitempty"`                    // Rolling statistics of this pair's entry spread.
+	MomentumBpsPerMin       *float64                `json:"momentum_bps_per_min,omitempty"`     // Rate of change of the entry spread over the recent history, in bps per minute: positive when widening.
+	Trend                   string                  `json:"trend,omitempty"`                    // One of the Trend* constants, from MomentumBpsPerMin.
+	Enrichment              *Enrichment             `json:"enrichment,omitempty"`               // Display names, trading pages and asset metadata.
+	Anomaly                 bool                    `json:"anomaly,omitempty"`                  // The entry spread jumped suddenly from its recent level, e.g., on a listing, depeg or outage.
+	AnomalyScore            float64                 `json:"anomaly_score,omitempty"`            // Deviations of the entry spread from its exponentially weighted mean.
+	Convergence             *ConvergenceStats       `json:"convergence,omitempty"`              // How long this pair's past opportunities lasted before converging.
+}
+
+// Enrichment is what consumers need to present an event without per-exchange knowledge: display names and
+// trading pages of its legs and the metadata of its base asset, set by the enrichment package.
+type Enrichment struct {
+	ExchangeShortName string            `json:"exchange_short_name"`
+	ExchangeLongName  string            `json:"exchange_long_name"`
+	TradeURLShort     string            `json:"trade_url_short,omitempty"` // Trading page of the short leg's market
+	TradeURLLong      string            `json:"trade_url_long,omitempty"`  // Trading page of the long leg's market
+	Asset             *shared.AssetInfo `json:"asset,omitempty"`           // Unknown until the asset metadata is fetched
+}
+
+// Trends of a spread's entry spread, from its momentum.
+const (
+	TrendWidening   = "widening"
+	TrendConverging = "converging"
+	TrendStable     = "stable"
+)
+
+// SpreadStats holds rolling statistics of a pair's entry spread over the history window.
+type SpreadStats struct {
+	Mean                float64  `json:"mean"`
+	StdDev              float64  `json:"std_dev"`
+	ZScore              float64  `json:"z_score"` // How many standard deviations the current entry spread is above the mean.
+	Samples             int      `json:"samples"`
+	PercentileThreshold *float64 `json:"percentile_threshold,omitempty"` // Entry spread at the configured percentile of the history window.
+}
+
+// ConvergenceStats is the distribution of how long a pair's past opportunities stayed published, from opening
+// to converging below the threshold, over the most recent ones.
+type ConvergenceStats struct {
+	Samples       int     `json:"samples"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P25Seconds    float64 `json:"p25_seconds"`
+	P75Seconds    float64 `json:"p75_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+	MaxSeconds    float64 `json:"max_seconds"`
+}
+
+// PairKey identifies the symbol-pair and direction of a spread (e.g., "BTC/USDT:PERP|Binance|Mexc").
+func (s Spread) PairKey() string {
+	key := s.UnifiedSymbol + "|" + s.ExchangeShort + "|" + s.ExchangeLong
+	if s.UnifiedSymbolLong != "" {
+		key += "|" + s.UnifiedSymbolLong
+	}
+	return key
+}
+
+// LongSymbol returns the unified symbol traded on the long leg, which differs for cross-quote spreads.
+func (s Spread) LongSymbol() string {
+	if s.UnifiedSymbolLong != "" {
+		return s.UnifiedSymbolLong
+	}
+	return s.UnifiedSymbol
+}
+
+// Options controls the filtering and cost assumptions applied during spread calculation.
+type Options struct {
+	MaxTickerAge    time.Duration    // Legs with tickers older than this are dropped (0 disables)
+	SlippageBps     float64          // Assumed slippage per leg in basis points, used for the conservative spread estimate
+	AllDirections   bool             // Evaluate both ordered directions of every exchange pair (legacy behavior) instead of one canonical direction
+	MinVolumeUSD    float64          // Legs with less 24h quote volume are dropped (0 disables)
+	MinTopOfBookUSD float64          // Legs whose best bid or ask is worth less are dropped (0 disables; unknown sizes pass)
+	Workers         int              // Size of the worker pool evaluating symbols in parallel (0 uses GOMAXPROCS)
+	Now             func() time.Time // Clock ticker ages are measured against; time.Now if nil (a replayed cycle's time in backtests)
+}
+
+// now returns the current time of the options' clock.
+func (o Options) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// leg is a single exchange's ticker taking part in a spread.
+type leg struct {
+	Exchange string
+	Ticker   shared.TickerBidAsk
+	Funding  *shared.FundingRateInfo // nil if the exchange reports no funding rate for the symbol
+}
+
+// maxStackLegs is the number of exchanges a symbol's legs are collected for without a heap allocation.
+const maxStackLegs = 8
+
+// CalculateSpreads identifies arbitrage opportunities from a map of tickers and funding rates, keyed by
+// exchange and unified symbol. Markets sharing a base but quoted in different stablecoins are compared using
+// fxRates. The maps are only read, so they must not be modified during the calculation: pass snapshots.
 func CalculateSpreads(
 	tickers map[string]map[string]shared.TickerBidAsk,
-	binanceFundingRates map[string]adapters.BinanceFundingRateDto,
-	mexcFundingRates map[string]adapters.MexcFundingRateDto,
+	fundingRates map[string]map[string]shared.FundingRateInfo,
+	fxRates fx.Rates,
+	opts Options,
+) []Spread {
+	if opts.MaxTickerAge > 0 {
+		tickers = dropStaleTickers(tickers, opts.MaxTickerAge, opts.now())
+	}
+	if opts.MinVolumeUSD > 0 || opts.MinTopOfBookUSD > 0 {
+		tickers = dropIlliquidTickers(tickers, opts.MinVolumeUSD, opts.MinTopOfBookUSD)
+	}
+
+	// Split the symbols with prices from at least two exchanges across the worker pool.
+	symbols := make([]map[string]shared.TickerBidAsk, 0, len(tickers))
+	for _, exchangeData := range tickers {
+		if len(exchangeData) >= 2 {
+			symbols = append(symbols, exchangeData)
+		}
+	}
+	// The few cross-quote spreads come first, so the same-quote ones are appended without copying them again.
+	spreads := calculateCrossQuoteSpreads(tickers, fundingRates, fxRates, opts)
+	spreads = runParallel(spreads, symbols, opts.Workers, func(spreads []Spread, exchangeData map[string]shared.TickerBidAsk) []Spread {
+		return symbolSpreads(spreads, exchangeData, fundingRates, opts)
+	})
+
+	// Sort spreads by the highest entry percentage, descending.
+	slices.SortFunc(spreads, func(a, b Spread) int {
+		return cmp.Compare(b.EntrySpread, a.EntrySpread)
+	})
+
+	return spreads
+}
+
+// symbolSpreads evaluates the exchange pairs of a single symbol and appends their spreads to spreads.
+func symbolSpreads(
+	spreads []Spread,
+	exchangeData map[string]shared.TickerBidAsk,
+	fundingRates map[string]map[string]shared.FundingRateInfo,
+	opts Options,
 ) []Spread {
-	var spreads []Spread
+	// Collect the symbol's legs once, rather than looking them up for every pair they take part in.
+	var buf [maxStackLegs]leg
+	legs := buf[:0]
+	for name, ticker := range exchangeData {
+		legs = append(legs, leg{Exchange: name, Ticker: ticker})
+	}
+	resolveFunding(legs, fundingRates)
+
+	// Evaluate each unordered pair of exchanges once, in whichever direction is profitable.
+	// In legacy mode, every ordered pair (A, B) and (B, A) is evaluated separately.
+	for i := range legs {
+		for j := firstPartner(i, opts); j < len(legs); j++ {
+			if i == j {
+				continue // Skip self-comparison.
+			}
 
-	// Iterate over each symbol that has prices from at least two exchanges.
+			short := legs[i] // Exchange where we potentially sell (short)
+			long := legs[j]  // Exchange where we potentially buy (long)
+
+			s, ok := evaluatePair(short, long, 1.0, opts)
+			if !ok && !opts.AllDirections {
+				s, ok = evaluatePair(long, short, 1.0, opts)
+			}
+			if ok {
+				spreads = append(spreads, s)
+			}
+		}
+	}
+
+	return spreads
+}
+
+// resolveFunding sets the funding rate of every leg, so each rate is parsed once rather than once per pair.
+// Spreads of the same leg share its funding rate.
+func resolveFunding(
+	legs []leg,
+	fundingRates map[string]map[string]shared.FundingRateInfo,
+) {
+	for i := range legs {
+		legs[i].Funding, _ = getFundingRateInfo(legs[i].Ticker.UnifiedSymbol, legs[i].Exchange, fundingRates)
+	}
+}
+
+// dropStaleTickers returns a copy of tickers without legs whose timestamp is older than maxAge.
+func dropStaleTickers(tickers map[string]map[string]shared.TickerBidAsk, maxAge time.Duration, now time.Time) map[string]map[string]shared.TickerBidAsk {
+	fresh := make(map[string]map[string]shared.TickerBidAsk, len(tickers))
+	dropped := 0
 	for symbol, exchangeData := range tickers {
-		if len(exchangeData) < 2 {
-			continue
+		for exchange, ticker := range exchangeData {
+			if now.Sub(ticker.Timestamp) > maxAge {
+				dropped++
+				continue
+			}
+			if _, ok := fresh[symbol]; !ok {
+				fresh[symbol] = make(map[string]shared.TickerBidAsk)
+			}
+			fresh[symbol][exchange] = ticker
 		}
+	}
+	if dropped > 0 {
+		slog.Debug("Dropped stale tickers", "count", dropped, "max_age", maxAge)
+	}
+	return fresh
+}
 
-		// Create a list of exchange names for the current symbol.
-		var exchanges []string
-		for name := range exchangeData {
-			exchanges = append(exchanges, name)
+// dropIlliquidTickers returns a copy of tickers without legs below the 24h volume or top-of-book size minimums.
+// Top-of-book sizes of 0 mean the exchange doesn't report them, so those legs are not checked.
+func dropIlliquidTickers(tickers map[string]map[string]shared.TickerBidAsk, minVolumeUSD, minTopOfBookUSD float64) map[string]map[string]shared.TickerBidAsk {
+	liquid := make(map[string]map[string]shared.TickerBidAsk, len(tickers))
+	dropped := 0
+	for symbol, exchangeData := range tickers {
+		for exchange, ticker := range exchangeData {
+			if ticker.VolumeUSD < minVolumeUSD {
+				dropped++
+				continue
+			}
+			if minTopOfBookUSD > 0 &&
+				((ticker.BidQty > 0 && ticker.BidQty*ticker.Bid < minTopOfBookUSD) ||
+					(ticker.AskQty > 0 && ticker.AskQty*ticker.Ask < minTopOfBookUSD)) {
+				dropped++
+				continue
+			}
+			if _, ok := liquid[symbol]; !ok {
+				liquid[symbol] = make(map[string]shared.TickerBidAsk)
+			}
+			liquid[symbol][exchange] = ticker
 		}
+	}
+	if dropped > 0 {
+		slog.Debug("Dropped illiquid tickers", "count", dropped, "min_volume_usd", minVolumeUSD, "min_top_of_book_usd", minTopOfBookUSD)
+	}
+	return liquid
+}
 
-		// Generate all unique pairs of exchanges (A, B) and (B, A).
-		for i := 0; i < len(exchanges); i++ {
-			for j := 0; j < len(exchanges); j++ {
-				if i == j {
-					continue // Skip self-comparison.
-				}
+// calculateCrossQuoteSpreads compares markets of the same base quoted in different currencies
+// on different exchanges (e.g., BTC/USDT on Binance vs BTC/USDC on Mexc), normalizing via fxRates.
+func calculateCrossQuoteSpreads(
+	tickers map[string]map[string]shared.TickerBidAsk,
+	fundingRates map[string]map[string]shared.FundingRateInfo,
+	fxRates fx.Rates,
+	opts Options,
+) []Spread {
+	// Only bases quoted in several currencies have cross-quote pairs, so the others aren't grouped.
+	quotesByBase := make(map[string]int, len(tickers))
+	for symbol := range tickers {
+		if sym, err := shared.ParseSymbol(symbol); err == nil {
+			quotesByBase[sym.Base()]++
+		}
+	}
 
-				exchangeA := exchanges[i] // Exchange where we potentially sell (short)
-				exchangeB := exchanges[j] // Exchange where we potentially buy (long)
+	// Group the legs of those bases by base asset.
+	legsByBase := make(map[string][]leg)
+	for symbol, exchangeData := range tickers {
+		sym, err := shared.ParseSymbol(symbol)
+		if err != nil || quotesByBase[sym.Base()] < 2 {
+			continue
+		}
+		for exchange, ticker := range exchangeData {
+			legsByBase[sym.Base()] = append(legsByBase[sym.Base()], leg{Exchange: exchange, Ticker: ticker})
+		}
+	}
 
-				tickerA := exchangeData[exchangeA]
-				tickerB := exchangeData[exchangeB]
+	evaluate := func(short, long leg) (Spread, bool) {
+		symShort, errShort := shared.ParseSymbol(short.Ticker.UnifiedSymbol)
+		symLong, errLong := shared.ParseSymbol(long.Ticker.UnifiedSymbol)
+		if errShort != nil || errLong != nil {
+			return Spread{}, false
+		}
 
-				// --- Entry Spread Calculation (Buy on B, Sell on A) ---
-				openDiff := tickerA.Bid - tickerB.Ask
-				entrySpread := 0.0
-				if openDiff > 0 { // Only calculate if there's a positive difference
-					openAvgPrice := (tickerA.Bid + tickerB.Ask) / 2
-					if openAvgPrice > 0 {
-						entrySpread = (openDiff / openAvgPrice) * 100
-					}
-				}
+		rate, ok := fxRates.ConversionRate(symLong.Quote(), symShort.Quote())
+		if !ok {
+			return Spread{}, false // No live rate for this quote pair.
+		}
+		return evaluatePair(short, long, rate, opts)
+	}
 
-				// --- Exit Spread Calculation (Buy on A, Sell on B) ---
-				exitDiff := tickerB.Bid - tickerA.Ask
-				exitSpread := 0.0
-				exitAvgPrice := (tickerB.Bid + tickerA.Ask) / 2
-				if exitAvgPrice > 0 {
-					exitSpread = (exitDiff / exitAvgPrice) * 100
-				}
+	groups := make([][]leg, 0, len(legsByBase))
+	for _, legs := range legsByBase {
+		resolveFunding(legs, fundingRates)
+		groups = append(groups, legs)
+	}
 
-				// --- Funding Rate Calculation ---
-				var fundingSpread8h *float64
-				fundingInfoA, foundA := getFundingRateInfo(symbol, exchangeA, binanceFundingRates, mexcFundingRates)
-				fundingInfoB, foundB := getFundingRateInfo(symbol, exchangeB, binanceFundingRates, mexcFundingRates)
-
-				if foundA && foundB && fundingInfoA.Interval > 0 && fundingInfoB.Interval > 0 {
-					// PnL = side * r * (8 / N)
-					pnlShort := +1.0 * fundingInfoA.Rate * (8.0 / float64(fundingInfoA.Interval))
-					pnlLong := -1.0 * fundingInfoB.Rate * (8.0 / float64(fundingInfoB.Interval))
-					totalFundingPnL := (pnlShort + pnlLong) * 100
-					fundingSpread8h = &totalFundingPnL
+	return runParallel(nil, groups, opts.Workers, func(spreads []Spread, legs []leg) []Spread {
+		for i := 0; i < len(legs); i++ {
+			for j := firstPartner(i, opts); j < len(legs); j++ {
+				short, long := legs[i], legs[j]
+				if short.Exchange == long.Exchange || short.Ticker.UnifiedSymbol == long.Ticker.UnifiedSymbol {
+					continue // Same-quote pairs are handled by CalculateSpreads.
 				}
 
-				// Only add a spread if there's a potential entry opportunity
-				if entrySpread > 0 {
-					spreads = append(spreads, Spread{
-						UnifiedSymbol:    symbol,
-						ExchangeShort:    exchangeA,
-						ExchangeLong:     exchangeB,
-						EntrySpread:      entrySpread,
-						OpenDiff:         openDiff,
-						ExitSpread:       exitSpread,
-						ExitDiff:         exitDiff,
-						FundingSpread8h:  fundingSpread8h,
-						FundingRateShort: fundingInfoA,
-						FundingRateLong:  fundingInfoB,
-					})
+				s, ok := evaluate(short, long)
+				if !ok && !opts.AllDirections {
+					s, ok = evaluate(long, short)
+				}
+				if ok {
+					spreads = append(spreads, s)
 				}
 			}
 		}
+		return spreads
+	})
+}
+
+// firstPartner returns the first index to pair with leg i: only later legs when evaluating
+// unordered pairs, or every leg when all ordered directions are requested.
+func firstPartner(i int, opts Options) int {
+	if opts.AllDirections {
+		return 0
 	}
+	return i + 1
+}
 
-	// Sort spreads by the highest entry percentage, descending.
-	sort.Slice(spreads, func(i, j int) bool {
-		return spreads[i].EntrySpread > spreads[j].EntrySpread
-	})
+// evaluatePair calculates the spread for selling on the short leg and buying on the long leg.
+// The long leg's prices are multiplied by conversionRate to express them in the short leg's quote.
+// It returns false if there is no entry opportunity.
+func evaluatePair(short, long leg, conversionRate float64, opts Options) (Spread, bool) {
+	tickerA := short.Ticker
+	tickerB := long.Ticker
+	bidB := tickerB.Bid * conversionRate
+	askB := tickerB.Ask * conversionRate
 
-	return spreads
+	// --- Entry Spread Calculation (Buy on B, Sell on A) ---
+	openDiff := tickerA.Bid - askB
+	entrySpread := 0.0
+	if openDiff > 0 { // Only calculate if there's a positive difference
+		openAvgPrice := (tickerA.Bid + askB) / 2
+		if openAvgPrice > 0 {
+			entrySpread = (openDiff / openAvgPrice) * 100
+		}
+	}
+
+	// Only add a spread if there's a potential entry opportunity
+	if entrySpread <= 0 {
+		return Spread{}, false
+	}
+
+	// --- Conservative Entry Spread (slippage applied against us on both legs) ---
+	slippage := opts.SlippageBps / 10_000
+	slippedBidA := tickerA.Bid * (1 - slippage)
+	slippedAskB := askB * (1 + slippage)
+	conservativeSpread := 0.0
+	if slippedAvgPrice := (slippedBidA + slippedAskB) / 2; slippedAvgPrice > 0 {
+		conservativeSpread = ((slippedBidA - slippedAskB) / slippedAvgPrice) * 100
+	}
+
+	// --- Exit Spread Calculation (Buy on A, Sell on B) ---
+	exitDiff := bidB - tickerA.Ask
+	exitSpread := 0.0
+	exitAvgPrice := (bidB + tickerA.Ask) / 2
+	if exitAvgPrice > 0 {
+		exitSpread = (exitDiff / exitAvgPrice) * 100
+	}
+
+	// --- Funding Rate Calculation ---
+	var fundingSpread8h *float64
+	fundingInfoA := short.Funding
+	fundingInfoB := long.Funding
+
+	if fundingInfoA != nil && fundingInfoB != nil && fundingInfoA.Interval > 0 && fundingInfoB.Interval > 0 {
+		// PnL = side * r * (8 / N)
+		pnlShort := +1.0 * fundingInfoA.ShortRate() * (8.0 / float64(fundingInfoA.Interval))
+		pnlLong := -1.0 * fundingInfoB.Rate * (8.0 / float64(fundingInfoB.Interval))
+		totalFundingPnL := (pnlShort + pnlLong) * 100
+		fundingSpread8h = &totalFundingPnL
+	}
+
+	s := Spread{
+		UnifiedSymbol:           tickerA.UnifiedSymbol,
+		ExchangeShort:           short.Exchange,
+		ExchangeLong:            long.Exchange,
+		EntrySpread:             entrySpread,
+		ConservativeEntrySpread: conservativeSpread,
+		OpenDiff:                openDiff,
+		ExitSpread:              exitSpread,
+		ExitDiff:                exitDiff,
+		FundingSpread8h:         fundingSpread8h,
+		FundingRateShort:        fundingInfoA,
+		FundingRateLong:         fundingInfoB,
+		VolumeUSD:               min(tickerA.VolumeUSD, tickerB.VolumeUSD),
+		QuoteTime:               tickerA.Timestamp,
+	}
+	if tickerB.Timestamp.Before(tickerA.Timestamp) {
+		s.QuoteTime = tickerB.Timestamp
+	}
+	if tickerB.UnifiedSymbol != tickerA.UnifiedSymbol {
+		s.UnifiedSymbolLong = tickerB.UnifiedSymbol
+		s.ConversionRate = conversionRate
+	}
+	return s, true
 }
 
-// getFundingRateInfo retrieves the standardized funding rate info for a given symbol and exchange.
+// getFundingRateInfo retrieves the funding rate info for a given symbol and exchange from funding rates keyed
+// by exchange and unified symbol.
 func getFundingRateInfo(
 	unifiedSymbol string,
 	exchangeName string,
-	binanceFundingRates map[string]adapters.BinanceFundingRateDto,
-	mexcFundingRates map[string]adapters.MexcFundingRateDto,
+	fundingRates map[string]map[string]shared.FundingRateInfo,
 ) (*shared.FundingRateInfo, bool) {
-	switch exchangeName {
-	case "Binance":
-		if dto, ok := binanceFundingRates[unifiedSymbol]; ok {
-			r, err := strconv.ParseFloat(dto.LastFundingRate, 64)
-			if err != nil {
-				slog.Warn("Failed to parse Binance funding rate", "symbol", unifiedSymbol, "rate_str", dto.LastFundingRate, "error", err)
-				return nil, false
-			}
-			return &shared.FundingRateInfo{
-				Rate:           r,
-				Interval:       dto.FundingIntervalHours,
-				NextSettleTime: dto.NextFundingTime,
-			}, true
-		}
-	case "Mexc":
-		if dto, ok := mexcFundingRates[unifiedSymbol]; ok {
-			return &shared.FundingRateInfo{
-				Rate:           dto.FundingRate,
-				Interval:       dto.CollectCycle,
-				NextSettleTime: dto.NextSettleTime,
-			}, true
-		}
+	info, ok := fundingRates[exchangeName][unifiedSymbol]
+	if !ok {
+		return nil, false
 	}
-	return nil, false
+	found := info // Only legs with a funding rate allocate one
+	return &found, true
 }
@@ -0,0 +1,94 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/fx"
+	"cex-price-diff-notifications/shared"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// BenchmarkCalculateSpreads measures CalculateSpreads on 2000 synthetic symbols listed on 3 to 6 exchanges,
+// comparing the sequential path against the worker pool.
+func BenchmarkCalculateSpreads(b *testing.B) {
+	const symbolCount = 2000
+	fxRates := fx.Rates{fx.ReferenceQuote: 1.0}
+	for exchangeCount := 3; exchangeCount <= 6; exchangeCount++ {
+		tickers := syntheticTickers(symbolCount, exchangeCount)
+		fundingRates := syntheticFundingRates(tickers)
+		for _, mode := range []struct {
+			name    string
+			workers int
+		}{{"sequential", 1}, {"pool", 0}} {
+			b.Run(fmt.Sprintf("exchanges=%d/%s", exchangeCount, mode.name), func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					CalculateSpreads(tickers, fundingRates, fxRates, Options{Workers: mode.workers})
+				}
+			})
+		}
+	}
+}
+
+// exchangeName names the e-th synthetic exchange. The first two are Binance and Mexc, so their funding rates
+// take part in the calculation as in production.
+func exchangeName(e int) string {
+	switch e {
+	case 0:
+		return "Binance"
+	case 1:
+		return "Mexc"
+	default:
+		return fmt.Sprintf("Exchange%d", e)
+	}
+}
+
+// syntheticTickers builds tickers for symbolCount symbols listed on every one of exchangeCount exchanges,
+// with small random price dislocations so a realistic share of pairs produce spreads.
+func syntheticTickers(symbolCount, exchangeCount int) map[string]map[string]shared.TickerBidAsk {
+	rng := rand.New(rand.NewSource(1))
+	now := time.Now()
+
+	tickers := make(map[string]map[string]shared.TickerBidAsk, symbolCount)
+	for i := 0; i < symbolCount; i++ {
+		symbol := shared.PerpSymbol(fmt.Sprintf("SYM%d", i), "USDT").String()
+		price := 1 + rng.Float64()*1000
+		exchangeData := make(map[string]shared.TickerBidAsk, exchangeCount)
+		for e := 0; e < exchangeCount; e++ {
+			mid := price * (1 + (rng.Float64()-0.5)*0.004)
+			exchangeData[exchangeName(e)] = shared.TickerBidAsk{
+				Symbol:        symbol,
+				UnifiedSymbol: symbol,
+				Bid:           mid * 0.9998,
+				Ask:           mid * 1.0002,
+				VolumeUSD:     1_000_000,
+				Timestamp:     now,
+			}
+		}
+		tickers[symbol] = exchangeData
+	}
+	return tickers
+}
+
+// syntheticFundingRates builds Binance and Mexc funding rates of up to ±0.05% for every symbol, keyed by
+// exchange and unified symbol.
+func syntheticFundingRates(tickers map[string]map[string]shared.TickerBidAsk) map[string]map[string]shared.FundingRateInfo {
+	rng := rand.New(rand.NewSource(2))
+	nextSettle := time.Now().Add(4 * time.Hour).UnixMilli()
+
+	funding := map[string]map[string]shared.FundingRateInfo{
+		"Binance": make(map[string]shared.FundingRateInfo, len(tickers)),
+		"Mexc":    make(map[string]shared.FundingRateInfo, len(tickers)),
+	}
+	for symbol := range tickers {
+		for _, exchange := range []string{"Binance", "Mexc"} {
+			funding[exchange][symbol] = shared.FundingRateInfo{
+				Rate:           (rng.Float64() - 0.5) * 0.001,
+				Interval:       8,
+				NextSettleTime: nextSettle,
+			}
+		}
+	}
+	return funding
+}
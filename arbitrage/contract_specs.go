@@ -0,0 +1,186 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"math"
+	"strconv"
+)
+
+// ContractSpecs provides contract trading constraints per exchange and symbol.
+type ContractSpecs interface {
+	Get(exchange, unifiedSymbol string) (shared.ContractSpec, bool)
+}
+
+// ApplyContractSpecs attaches the contract specs of both legs to each spread, when known.
+func ApplyContractSpecs(spreads []Spread, specs ContractSpecs) {
+	for i := range spreads {
+		s := &spreads[i]
+		if spec, ok := specs.Get(s.ExchangeShort, s.UnifiedSymbol); ok {
+			s.ContractShort = &spec
+		}
+		if spec, ok := specs.Get(s.ExchangeLong, s.LongSymbol()); ok {
+			s.ContractLong = &spec
+		}
+	}
+}
+
+// What bounds the order size of a spread.
+const (
+	SizeLimitTarget  = "target"  // The target notional
+	SizeLimitBalance = "balance" // The margin available on either leg
+	SizeLimitDepth   = "depth"   // The order book depth of either leg within the allowed slippage
+	SizeLimitMinimum = "minimum" // The size fell below either leg's minimums, so nothing is traded
+)
+
+// ApplyOrderSizes sizes an order for each perpetual spread whose legs' contract specs are known: the target
+// notional, capped by the tradable notional when balances are tracked, at the long leg's ask from tickers,
+// rounded down to both legs' step sizes. Spreads the size falls below a leg's minimums of get a zero quantity.
+func ApplyOrderSizes(spreads []Spread, tickers map[string]map[string]shared.TickerBidAsk) {
+	for i := range spreads {
+		s := &spreads[i]
+		if s.ContractShort == nil || s.ContractLong == nil || s.TargetNotionalUSD <= 0 {
+			continue
+		}
+		short := tickers[s.UnifiedSymbol][s.ExchangeShort]
+		long := tickers[s.LongSymbol()][s.ExchangeLong]
+		if short.Bid <= 0 || long.Ask <= 0 {
+			continue
+		}
+
+		notional, limit := s.TargetNotionalUSD, SizeLimitTarget
+		if s.TradableNotionalUSD != nil && *s.TradableNotionalUSD < notional {
+			notional, limit = *s.TradableNotionalUSD, SizeLimitBalance
+		}
+		qty := shared.HedgeQty(notional/long.Ask, *s.ContractShort, *s.ContractLong)
+		if !s.ContractShort.Accepts(qty, short.Bid) || !s.ContractLong.Accepts(qty, long.Ask) {
+			qty, limit = 0, SizeLimitMinimum
+		}
+		s.OrderQty = &qty
+		s.OrderNotionalUSD = qty * long.Ask
+		s.OrderSizeLimit = limit
+	}
+}
+
+// LegOrder is the suggested market order of one leg of a spread, ready to be placed as is.
+type LegOrder struct {
+	Exchange      string  `json:"exchange"`
+	UnifiedSymbol string  `json:"unified_symbol"`
+	Side          string  `json:"side"`                   // SideSell on the short leg, SideBuy on the long one
+	Qty           float64 `json:"qty"`                    // Base units, a multiple of the contract's step size
+	QtyText       string  `json:"qty_text"`               // Qty with the decimals of the step size, as the exchange expects it
+	Price         float64 `json:"price"`                  // Expected average fill price: the best bid or ask, or the volume-weighted price when the book is known
+	LimitPrice    float64 `json:"limit_price,omitempty"`  // Worst price of the book the order fills against, rounded to the tick size; 0 when the book is unknown
+	NotionalUSD   float64 `json:"notional_usd"`           // Qty at Price
+	SlippageBps   float64 `json:"slippage_bps,omitempty"` // Cost of Price over the best price
+}
+
+// ApplyLegOrders suggests the market order of each leg of the spreads sized by ApplyOrderSizes. When books
+// has the order books of both legs (keyed like tickers), the size is first capped by the quantity each book
+// offers within maxSlippageBps of its best price, and the legs are priced by walking their books; otherwise
+// they are priced at the best bid and ask of tickers. Spreads whose size is zero get no legs.
+func ApplyLegOrders(spreads []Spread, tickers map[string]map[string]shared.TickerBidAsk, books map[string]map[string]shared.OrderBook, maxSlippageBps float64) {
+	for i := range spreads {
+		s := &spreads[i]
+		if s.OrderQty == nil || *s.OrderQty <= 0 {
+			continue
+		}
+		qty := *s.OrderQty
+		shortBook, okShort := books[s.UnifiedSymbol][s.ExchangeShort]
+		longBook, okLong := books[s.LongSymbol()][s.ExchangeLong]
+		fromBooks := okShort && okLong
+		if fromBooks {
+			depth := min(depthQty(shortBook.Bids, SideSell, maxSlippageBps), depthQty(longBook.Asks, SideBuy, maxSlippageBps))
+			if depth < qty {
+				qty = shared.HedgeQty(depth, *s.ContractShort, *s.ContractLong)
+				s.OrderSizeLimit = SizeLimitDepth
+			}
+		} else {
+			shortBook = tickerBook(tickers[s.UnifiedSymbol][s.ExchangeShort])
+			longBook = tickerBook(tickers[s.LongSymbol()][s.ExchangeLong])
+		}
+
+		short, okShort := legOrder(shortBook, SideSell, qty, *s.ContractShort, fromBooks)
+		long, okLong := legOrder(longBook, SideBuy, qty, *s.ContractLong, fromBooks)
+		if !okShort || !okLong || !s.ContractShort.Accepts(qty, short.Price) || !s.ContractLong.Accepts(qty, long.Price) {
+			qty, s.OrderSizeLimit = 0, SizeLimitMinimum
+		}
+		s.OrderQty = &qty
+		s.OrderNotionalUSD = qty * long.Price
+		if qty == 0 {
+			continue
+		}
+		short.Exchange, short.UnifiedSymbol = s.ExchangeShort, s.UnifiedSymbol
+		long.Exchange, long.UnifiedSymbol = s.ExchangeLong, s.LongSymbol()
+		s.LegShort, s.LegLong = &short, &long
+	}
+}
+
+// depthQty returns the base quantity of levels, the side of a book a market order on side fills against,
+// priced within maxSlippageBps of the best one.
+func depthQty(levels []shared.PriceLevel, side string, maxSlippageBps float64) float64 {
+	var qty float64
+	for _, l := range levels {
+		if l.Price <= 0 || l.Qty <= 0 {
+			continue
+		}
+		if fillCostBps(side, l.Price, levels[0].Price) > maxSlippageBps {
+			break
+		}
+		qty += l.Qty
+	}
+	return qty
+}
+
+// tickerBook returns a book of the best bid and ask of a ticker, with room for any quantity.
+func tickerBook(t shared.TickerBidAsk) shared.OrderBook {
+	var book shared.OrderBook
+	if t.Bid > 0 {
+		book.Bids = []shared.PriceLevel{{Price: t.Bid, Qty: math.Inf(1)}}
+	}
+	if t.Ask > 0 {
+		book.Asks = []shared.PriceLevel{{Price: t.Ask, Qty: math.Inf(1)}}
+	}
+	return book
+}
+
+// legOrder prices a market order of qty on side by walking book, reporting false if the book can't fill it.
+// The limit price is only set for a fetched book, not one made of a ticker.
+func legOrder(book shared.OrderBook, side string, qty float64, spec shared.ContractSpec, fetched bool) (LegOrder, bool) {
+	levels := book.Asks
+	if side == SideSell {
+		levels = book.Bids
+	}
+	leg := LegOrder{Side: side, Qty: qty, QtyText: spec.FormatQty(qty)}
+	var best, worst float64
+	remaining := qty
+	for _, l := range levels {
+		if remaining <= 0 {
+			break
+		}
+		if l.Price <= 0 || l.Qty <= 0 {
+			continue
+		}
+		if best == 0 {
+			best = l.Price
+		}
+		filled := min(l.Qty, remaining)
+		leg.NotionalUSD += filled * l.Price
+		worst = l.Price
+		remaining -= filled
+	}
+	// A residue of a rounding error doesn't make the fill partial
+	if qty <= 0 || best == 0 || remaining > qty*1e-9 {
+		return leg, false
+	}
+	leg.Price = leg.NotionalUSD / qty
+	leg.SlippageBps = fillCostBps(side, leg.Price, best)
+	if fetched {
+		limit := spec.RoundPriceUp(worst)
+		if side == SideSell {
+			limit = spec.RoundPriceDown(worst)
+		}
+		// Formatted and parsed back to drop the floating-point error of the rounding, e.g., 85.62480000000001
+		leg.LimitPrice, _ = strconv.ParseFloat(spec.FormatPrice(limit), 64)
+	}
+	return leg, true
+}
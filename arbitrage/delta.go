@@ -0,0 +1,104 @@
+package arbitrage
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SpreadRef identifies an opportunity removed from the published set.
+type SpreadRef struct {
+	OpportunityID     string `json:"opportunity_id,omitempty"`
+	UnifiedSymbol     string `json:"unified_symbol"`
+	UnifiedSymbolLong string `json:"unified_symbol_long,omitempty"`
+	ExchangeShort     string `json:"exchange_short"`
+	ExchangeLong      string `json:"exchange_long"`
+}
+
+// SnapshotDelta is the change of the published set of opportunities since the previous delta, for consumers that
+// keep their own copy: the opportunities added, those whose entry spread moved materially since they were last
+// sent, and those removed. Applied in sequence to the snapshot of an earlier cycle, they give the current set.
+type SnapshotDelta struct {
+	Sequence    uint64      `json:"sequence"`     // Since startup, starting at 1; a gap means a delta was missed and the set should be rebuilt from a snapshot
+	Cycle       uint64      `json:"cycle"`        // Of the snapshot the delta leads to
+	GeneratedAt int64       `json:"generated_at"` // Milliseconds since epoch
+	Added       []Spread    `json:"added"`
+	Changed     []Spread    `json:"changed"`
+	Removed     []SpreadRef `json:"removed"`
+}
+
+// SnapshotDiff compares the published set of each cycle with the one last sent, emitting only its changes.
+// It is not safe for concurrent use.
+type SnapshotDiff struct {
+	sent              map[string]Spread // Pair key -> spread as last sent
+	materialChangeBps float64
+	sequence          uint64
+}
+
+// NewSnapshotDiff creates a SnapshotDiff that sends an opportunity again when its entry spread moves by at least
+// materialChangeBps since it was last sent.
+func NewSnapshotDiff(materialChangeBps float64) *SnapshotDiff {
+	return &SnapshotDiff{
+		sent:              make(map[string]Spread),
+		materialChangeBps: materialChangeBps,
+	}
+}
+
+// Diff returns the delta from the set last sent to the published spreads of a cycle, and false when nothing
+// changed, in which case no delta is sent.
+func (d *SnapshotDiff) Diff(cycle uint64, now time.Time, spreads []Spread) (SnapshotDelta, bool) {
+	delta := SnapshotDelta{Cycle: cycle, GeneratedAt: now.UnixMilli(), Added: []Spread{}, Changed: []Spread{}, Removed: []SpreadRef{}}
+	seen := make(map[string]bool, len(spreads))
+	for _, s := range spreads {
+		key := s.PairKey()
+		seen[key] = true
+		last, ok := d.sent[key]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, s)
+		// Spreads are in percent, so 1 bps = 0.01
+		case math.Abs(s.EntrySpread-last.EntrySpread)*100 >= d.materialChangeBps:
+			delta.Changed = append(delta.Changed, s)
+		default:
+			continue
+		}
+		d.sent[key] = s
+	}
+	for key, s := range d.sent {
+		if seen[key] {
+			continue
+		}
+		delta.Removed = append(delta.Removed, SpreadRef{
+			OpportunityID:     s.OpportunityID,
+			UnifiedSymbol:     s.UnifiedSymbol,
+			UnifiedSymbolLong: s.UnifiedSymbolLong,
+			ExchangeShort:     s.ExchangeShort,
+			ExchangeLong:      s.ExchangeLong,
+		})
+		delete(d.sent, key)
+	}
+	if len(delta.Added)+len(delta.Changed)+len(delta.Removed) == 0 {
+		return SnapshotDelta{}, false
+	}
+	slices.SortFunc(delta.Removed, func(a, b SpreadRef) int {
+		return cmp.Or(
+			strings.Compare(a.UnifiedSymbol, b.UnifiedSymbol),
+			strings.Compare(a.ExchangeShort, b.ExchangeShort),
+			strings.Compare(a.ExchangeLong, b.ExchangeLong),
+		)
+	})
+	d.sequence++
+	delta.Sequence = d.sequence
+	return delta, true
+}
+
+// BestEntrySpread returns the highest entry spread among the added and changed opportunities, 0 if none.
+func (d SnapshotDelta) BestEntrySpread() float64 {
+	var best float64
+	for _, s := range slices.Concat(d.Added, d.Changed) {
+		best = max(best, s.EntrySpread)
+	}
+	return best
+}
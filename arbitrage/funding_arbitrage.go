@@ -0,0 +1,61 @@
+package arbitrage
+
+import "cex-price-diff-notifications/shared"
+
+// FundingOpportunityEventType identifies funding-only opportunities in published messages.
+const FundingOpportunityEventType = "funding_opportunity"
+
+// FundingOpportunity represents a pure funding arbitrage: the price spread between two exchanges is
+// negligible, but shorting the higher-funding leg and longing the lower one collects the differential.
+type FundingOpportunity struct {
+	EventType        string                  `json:"event_type"`
+	OpportunityID    string                  `json:"opportunity_id,omitempty"` // Same for every event of the opportunity, see OpportunityIDs
+	UnifiedSymbol    string                  `json:"unified_symbol"`
+	ExchangeShort    string                  `json:"exchange_short"`    // The exchange whose shorts collect the higher (8h-normalized) funding rate.
+	ExchangeLong     string                  `json:"exchange_long"`     // The exchange whose longs pay the lower (8h-normalized) funding rate.
+	PriceSpread      float64                 `json:"price_spread"`      // Entry spread in percent; negative means entering costs money.
+	FundingSpread8h  float64                 `json:"funding_spread_8h"` // Funding collected per 8 hours, in percent.
+	AnnualizedRate   float64                 `json:"annualized_rate"`   // FundingSpread8h extrapolated to a year, in percent.
+	FundingRateShort *shared.FundingRateInfo `json:"funding_rate_short"`
+	FundingRateLong  *shared.FundingRateInfo `json:"funding_rate_long"`
+	Enrichment       *Enrichment             `json:"enrichment,omitempty"` // Display names, trading pages and asset metadata.
+}
+
+// PairKey identifies the opportunity's symbol and direction.
+func (f FundingOpportunity) PairKey() string {
+	return f.UnifiedSymbol + "|" + f.ExchangeShort + "|" + f.ExchangeLong
+}
+
+// FundingArbitrageOptions controls which pairs qualify as funding opportunities.
+type FundingArbitrageOptions struct {
+	MinAnnualizedRate float64 // Minimum annualized funding differential, in percent
+	MaxPriceSpread    float64 // Maximum entry cost, in percent: pairs with EntrySpread below -MaxPriceSpread are skipped
+}
+
+// FindFundingOpportunities scans every symbol listed on at least two exchanges for funding differentials
+// annualizing above the configured threshold while the price spread stays near zero.
+func FindFundingOpportunities(
+	tickers map[string]map[string]shared.TickerBidAsk,
+	fundingRates map[string]map[string]shared.FundingRateInfo,
+	opts FundingArbitrageOptions,
+) []FundingOpportunity {
+	var opportunities []FundingOpportunity
+	// Divergences come sorted by the highest annualized rate
+	for _, d := range FindFundingDivergences(tickers, fundingRates, opts.MinAnnualizedRate) {
+		if d.PriceSpread < -opts.MaxPriceSpread {
+			continue // Entering would cost more than the tolerated price spread.
+		}
+		opportunities = append(opportunities, FundingOpportunity{
+			EventType:        FundingOpportunityEventType,
+			UnifiedSymbol:    d.UnifiedSymbol,
+			ExchangeShort:    d.ExchangeShort,
+			ExchangeLong:     d.ExchangeLong,
+			PriceSpread:      d.PriceSpread,
+			FundingSpread8h:  d.FundingSpread8h,
+			AnnualizedRate:   d.AnnualizedRate,
+			FundingRateShort: d.FundingRateShort,
+			FundingRateLong:  d.FundingRateLong,
+		})
+	}
+	return opportunities
+}
@@ -0,0 +1,129 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"sort"
+)
+
+// FundingDivergenceEventType identifies funding divergence alerts in published messages.
+const FundingDivergenceEventType = "funding_divergence"
+
+// FundingDivergence reports that the funding rates of a symbol on two exchanges drifted apart beyond a threshold,
+// whatever the price spread, e.g., to rebalance positions holding the pair or watch it for an entry.
+type FundingDivergence struct {
+	EventType        string                  `json:"event_type"`
+	OpportunityID    string                  `json:"opportunity_id,omitempty"` // Same for every event of the divergence, see OpportunityIDs
+	UnifiedSymbol    string                  `json:"unified_symbol"`
+	ExchangeShort    string                  `json:"exchange_short"`    // The exchange whose shorts collect the higher (8h-normalized) funding rate.
+	ExchangeLong     string                  `json:"exchange_long"`     // The exchange whose longs pay the lower (8h-normalized) funding rate.
+	PriceSpread      float64                 `json:"price_spread"`      // Entry spread in percent, for information only.
+	FundingSpread8h  float64                 `json:"funding_spread_8h"` // Funding collected per 8 hours, in percent.
+	AnnualizedRate   float64                 `json:"annualized_rate"`   // FundingSpread8h extrapolated to a year, in percent.
+	FundingRateShort *shared.FundingRateInfo `json:"funding_rate_short"`
+	FundingRateLong  *shared.FundingRateInfo `json:"funding_rate_long"`
+	Enrichment       *Enrichment             `json:"enrichment,omitempty"` // Display names, trading pages and asset metadata.
+}
+
+// PairKey identifies the divergence's symbol and direction.
+func (d FundingDivergence) PairKey() string {
+	return d.UnifiedSymbol + "|" + d.ExchangeShort + "|" + d.ExchangeLong
+}
+
+// FindFundingDivergences returns, for every symbol listed on at least two exchanges, the pairs whose funding
+// differential annualizes to at least minAnnualizedRate, in percent, by descending annualized rate.
+func FindFundingDivergences(
+	tickers map[string]map[string]shared.TickerBidAsk,
+	fundingRates map[string]map[string]shared.FundingRateInfo,
+	minAnnualizedRate float64,
+) []FundingDivergence {
+	var divergences []FundingDivergence
+
+	for symbol, exchangeData := range tickers {
+		if len(exchangeData) < 2 {
+			continue
+		}
+
+		var exchanges []string
+		for name := range exchangeData {
+			exchanges = append(exchanges, name)
+		}
+
+		for i := 0; i < len(exchanges); i++ {
+			for j := i + 1; j < len(exchanges); j++ {
+				infoA, okA := getFundingRateInfo(symbol, exchanges[i], fundingRates)
+				infoB, okB := getFundingRateInfo(symbol, exchanges[j], fundingRates)
+				if !okA || !okB || infoA.Interval <= 0 || infoB.Interval <= 0 {
+					continue
+				}
+
+				// Short the leg whose shorts collect more than the other's longs pay. On venues charging both sides
+				// (e.g., GMX), what shorts collect isn't what longs pay, so both directions are evaluated.
+				shortExchange, longExchange := exchanges[i], exchanges[j]
+				shortInfo, longInfo := infoA, infoB
+				fundingSpread8h := fundingAccrualPct(infoA, infoB, 8)
+				if reversed := fundingAccrualPct(infoB, infoA, 8); reversed > fundingSpread8h {
+					shortExchange, longExchange = longExchange, shortExchange
+					shortInfo, longInfo = longInfo, shortInfo
+					fundingSpread8h = reversed
+				}
+
+				annualized := fundingSpread8h * 3 * 365
+				if annualized < minAnnualizedRate {
+					continue
+				}
+
+				short := exchangeData[shortExchange]
+				long := exchangeData[longExchange]
+				priceSpread := 0.0
+				if avg := (short.Bid + long.Ask) / 2; avg > 0 {
+					priceSpread = (short.Bid - long.Ask) / avg * 100
+				}
+
+				divergences = append(divergences, FundingDivergence{
+					EventType:        FundingDivergenceEventType,
+					UnifiedSymbol:    symbol,
+					ExchangeShort:    shortExchange,
+					ExchangeLong:     longExchange,
+					PriceSpread:      priceSpread,
+					FundingSpread8h:  fundingSpread8h,
+					AnnualizedRate:   annualized,
+					FundingRateShort: shortInfo,
+					FundingRateLong:  longInfo,
+				})
+			}
+		}
+	}
+
+	// Sort by the highest annualized rate, descending.
+	sort.Slice(divergences, func(i, j int) bool {
+		return divergences[i].AnnualizedRate > divergences[j].AnnualizedRate
+	})
+
+	return divergences
+}
+
+// DivergenceAlerts reports each funding divergence once, when it starts: a pair alerts again only after its
+// differential fell back under the threshold. It is not safe for concurrent use.
+type DivergenceAlerts struct {
+	active map[string]bool // Pair keys of the divergences already reported
+}
+
+// NewDivergenceAlerts creates a tracker without active divergences.
+func NewDivergenceAlerts() *DivergenceAlerts {
+	return &DivergenceAlerts{active: make(map[string]bool)}
+}
+
+// Update returns the divergences of the cycle that weren't active in the previous one.
+func (a *DivergenceAlerts) Update(divergences []FundingDivergence) []FundingDivergence {
+	active := make(map[string]bool, len(divergences))
+	var started []FundingDivergence
+	for _, d := range divergences {
+		key := d.PairKey()
+		active[key] = true
+		if !a.active[key] {
+			started = append(started, d)
+		}
+	}
+	a.active = active
+	return started
+}
@@ -0,0 +1,84 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFindFundingDivergences(t *testing.T) {
+	const symbol = "BTC/USDT:PERP"
+	ptr := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name       string
+		binance    shared.FundingRateInfo
+		gmx        shared.FundingRateInfo
+		wantShort  string
+		wantLong   string
+		wantSpread float64 // Funding collected per 8 hours, in percent
+	}{
+		{
+			name:       "symmetric rates short the higher rate",
+			binance:    shared.FundingRateInfo{Rate: 0.001, Interval: 8},
+			gmx:        shared.FundingRateInfo{Rate: 0.0002, Interval: 8},
+			wantShort:  "Binance",
+			wantLong:   "GMX",
+			wantSpread: 0.08,
+		},
+		{
+			name:       "hourly rates are normalized to 8 hours",
+			binance:    shared.FundingRateInfo{Rate: 0.0001, Interval: 8},
+			gmx:        shared.FundingRateInfo{Rate: 0.0001, Interval: 1},
+			wantShort:  "GMX",
+			wantLong:   "Binance",
+			wantSpread: 0.07,
+		},
+		{
+			// Longs pay less on GMX than on Binance, but GMX shorts collect more than Binance longs pay
+			name:       "asymmetric short rate shorts the leg collecting more",
+			binance:    shared.FundingRateInfo{Rate: 0.001, Interval: 8},
+			gmx:        shared.FundingRateInfo{Rate: 0.0005, RateShort: ptr(0.0025), Interval: 8},
+			wantShort:  "GMX",
+			wantLong:   "Binance",
+			wantSpread: 0.15,
+		},
+		{
+			// GMX shorts pay borrowing fees, so shorting Binance is the only side that collects
+			name:       "negative short rate keeps the other leg short",
+			binance:    shared.FundingRateInfo{Rate: 0.001, Interval: 8},
+			gmx:        shared.FundingRateInfo{Rate: -0.0005, RateShort: ptr(-0.0015), Interval: 8},
+			wantShort:  "Binance",
+			wantLong:   "GMX",
+			wantSpread: 0.15,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			tickers := map[string]map[string]shared.TickerBidAsk{symbol: {
+				"Binance": {UnifiedSymbol: symbol, Bid: 100, Ask: 100.1, Timestamp: now},
+				"GMX":     {UnifiedSymbol: symbol, Bid: 100, Ask: 100.1, Timestamp: now},
+			}}
+			fundingRates := map[string]map[string]shared.FundingRateInfo{
+				"Binance": {symbol: tt.binance},
+				"GMX":     {symbol: tt.gmx},
+			}
+
+			divergences := FindFundingDivergences(tickers, fundingRates, 0)
+			if len(divergences) != 1 {
+				t.Fatalf("got %d divergences, want 1", len(divergences))
+			}
+			d := divergences[0]
+			if d.ExchangeShort != tt.wantShort || d.ExchangeLong != tt.wantLong {
+				t.Errorf("got short %s, long %s, want short %s, long %s", d.ExchangeShort, d.ExchangeLong, tt.wantShort, tt.wantLong)
+			}
+			if math.Abs(d.FundingSpread8h-tt.wantSpread) > 1e-9 {
+				t.Errorf("got funding spread %g%%, want %g%%", d.FundingSpread8h, tt.wantSpread)
+			}
+			if want := tt.wantSpread * 3 * 365; math.Abs(d.AnnualizedRate-want) > 1e-6 {
+				t.Errorf("got annualized rate %g%%, want %g%%", d.AnnualizedRate, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package arbitrage
+
+// FundingHistory provides trailing funding statistics per exchange and symbol.
+type FundingHistory interface {
+	// TrailingAverage returns the mean settled funding rate (per interval, not normalized).
+	TrailingAverage(exchange, unifiedSymbol string) (float64, bool)
+}
+
+// ApplyFundingHistory sets AvgFundingSpread8h on spreads whose legs both have funding history,
+// normalizing each leg's average rate to 8 hours using its current funding interval.
+func ApplyFundingHistory(spreads []Spread, history FundingHistory) {
+	for i := range spreads {
+		s := &spreads[i]
+		if s.FundingRateShort == nil || s.FundingRateLong == nil || s.FundingRateShort.Interval <= 0 || s.FundingRateLong.Interval <= 0 {
+			continue
+		}
+
+		avgShort, okShort := history.TrailingAverage(s.ExchangeShort, s.UnifiedSymbol)
+		avgLong, okLong := history.TrailingAverage(s.ExchangeLong, s.LongSymbol())
+		if !okShort || !okLong {
+			continue
+		}
+
+		// Same PnL convention as the instantaneous funding spread: side * r * (8 / N)
+		pnlShort := +1.0 * avgShort * (8.0 / float64(s.FundingRateShort.Interval))
+		pnlLong := -1.0 * avgLong * (8.0 / float64(s.FundingRateLong.Interval))
+		avg := (pnlShort + pnlLong) * 100
+		s.AvgFundingSpread8h = &avg
+	}
+}
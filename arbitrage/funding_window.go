@@ -0,0 +1,76 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Funding window modes control how favorable upcoming settlements affect publishing.
+const (
+	FundingWindowTag   = "tag"   // Only tag spreads, publish everything
+	FundingWindowOnly  = "only"  // Publish only spreads with a favorable settlement inside the window
+	FundingWindowBoost = "boost" // Publish everything, but rank favorable spreads first
+)
+
+// ApplyFundingWindow tags each spread with the time until the next funding settlement of both legs and
+// marks it FavorableFundingSoon when a leg collects funding within window: the short leg with a positive
+// rate, or the long leg with a negative rate.
+func ApplyFundingWindow(spreads []Spread, window time.Duration, now time.Time) {
+	for i := range spreads {
+		s := &spreads[i]
+		s.SecondsToFundingShort = secondsUntilSettlement(s.FundingRateShort, now)
+		s.SecondsToFundingLong = secondsUntilSettlement(s.FundingRateLong, now)
+
+		if window <= 0 {
+			continue
+		}
+		limit := int64(window.Seconds())
+		shortCollects := s.FundingRateShort != nil && s.FundingRateShort.ShortRate() > 0 &&
+			s.SecondsToFundingShort != nil && *s.SecondsToFundingShort <= limit
+		longCollects := s.FundingRateLong != nil && s.FundingRateLong.Rate < 0 &&
+			s.SecondsToFundingLong != nil && *s.SecondsToFundingLong <= limit
+		s.FavorableFundingSoon = shortCollects || longCollects
+	}
+}
+
+// SelectByFundingWindow applies the funding window mode to the spreads ahead of publishing.
+func SelectByFundingWindow(spreads []Spread, mode string) []Spread {
+	switch mode {
+	case FundingWindowOnly:
+		var selected []Spread
+		for _, s := range spreads {
+			if s.FavorableFundingSoon {
+				selected = append(selected, s)
+			}
+		}
+		return selected
+	case FundingWindowBoost:
+		sort.SliceStable(spreads, func(i, j int) bool {
+			return spreads[i].FavorableFundingSoon && !spreads[j].FavorableFundingSoon
+		})
+		return spreads
+	default:
+		return spreads
+	}
+}
+
+// secondsUntilSettlement returns the seconds until the leg's next funding settlement, or nil if unknown.
+func secondsUntilSettlement(info *shared.FundingRateInfo, now time.Time) *int64 {
+	if info == nil || info.NextSettleTime <= 0 {
+		return nil
+	}
+	secs := (info.NextSettleTime - now.UnixMilli()) / 1000
+	if secs < 0 {
+		secs = 0
+	}
+	return &secs
+}
+
+// ApplyFundingCoverage marks the spreads with a leg on an exchange whose funding rates are missing or stale.
+func ApplyFundingCoverage(spreads []Spread, stale []string) {
+	for i := range spreads {
+		spreads[i].FundingDegraded = slices.Contains(stale, spreads[i].ExchangeShort) || slices.Contains(stale, spreads[i].ExchangeLong)
+	}
+}
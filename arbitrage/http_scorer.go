@@ -0,0 +1,57 @@
+package arbitrage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPScorer scores spreads with an external model behind an HTTP endpoint. Each cycle it POSTs
+// {"spreads": [...]} with the spreads as published, and expects {"results": [{"score": 1.2, "decision":
+// "keep"}, ...]} back, one result per spread, in order.
+type HTTPScorer struct {
+	client *http.Client
+	url    string
+}
+
+// NewHTTPScorer creates a scorer calling url, failing requests that take longer than timeout.
+func NewHTTPScorer(url string, timeout time.Duration) *HTTPScorer {
+	return &HTTPScorer{client: &http.Client{Timeout: timeout}, url: url}
+}
+
+// ScoreBatch sends the spreads to the endpoint and returns its results.
+func (h *HTTPScorer) ScoreBatch(ctx context.Context, spreads []Spread) ([]ScoreResult, error) {
+	body, err := json.Marshal(struct {
+		Spreads []Spread `json:"spreads"`
+	}{spreads})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal spreads: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scorer request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call scorer: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("scorer returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var out struct {
+		Results []ScoreResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode scorer response: %w", err)
+	}
+	return out.Results, nil
+}
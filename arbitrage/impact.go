@@ -0,0 +1,99 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"errors"
+	"fmt"
+)
+
+// Sides of a market order.
+const (
+	SideBuy  = "buy"  // Lifts the asks
+	SideSell = "sell" // Hits the bids
+)
+
+// ErrEmptyBook is returned when the side of the book a market order would fill against has no levels.
+var ErrEmptyBook = errors.New("order book side is empty")
+
+// Impact is the expected fill of a market order walking an order book.
+type Impact struct {
+	Exchange      string  `json:"exchange"`
+	UnifiedSymbol string  `json:"unified_symbol"`
+	Side          string  `json:"side"`
+	NotionalUSD   float64 `json:"notional_usd"`        // Notional asked for
+	FilledUSD     float64 `json:"filled_notional_usd"` // Notional the book could fill, below NotionalUSD if it ran out
+	FilledQty     float64 `json:"filled_qty"`          // Base units filled
+	BestPrice     float64 `json:"best_price"`          // Price of the first level filled against
+	MidPrice      float64 `json:"mid_price,omitempty"` // 0 if either side is empty
+	AvgPrice      float64 `json:"avg_price"`           // Volume-weighted average fill price
+	WorstPrice    float64 `json:"worst_price"`         // Price of the last level filled against
+	SlippageBps   float64 `json:"slippage_bps"`        // Cost of the average fill price over the best price
+	ImpactBps     float64 `json:"impact_bps"`          // Cost of the average fill price over the mid price, spread included
+	Levels        int     `json:"levels"`              // Levels filled against, the last one partially
+	Complete      bool    `json:"complete"`            // Whether the book was deep enough to fill the whole notional
+	BookTimestamp int64   `json:"book_timestamp"`      // Milliseconds since epoch
+}
+
+// EstimateImpact walks the side of book a market order of notionalUSD on side fills against, level by level,
+// for its volume-weighted average fill price and slippage. Slippage and impact are costs: positive when the
+// fill is worse than the reference price, whichever the side.
+func EstimateImpact(book shared.OrderBook, side string, notionalUSD float64) (Impact, error) {
+	var levels []shared.PriceLevel
+	switch side {
+	case SideBuy:
+		levels = book.Asks
+	case SideSell:
+		levels = book.Bids
+	default:
+		return Impact{}, fmt.Errorf("unknown side %q, must be %s or %s", side, SideBuy, SideSell)
+	}
+	if len(levels) == 0 {
+		return Impact{}, ErrEmptyBook
+	}
+
+	impact := Impact{
+		Exchange:      book.Exchange,
+		UnifiedSymbol: book.UnifiedSymbol,
+		Side:          side,
+		NotionalUSD:   notionalUSD,
+		BestPrice:     levels[0].Price,
+		BookTimestamp: book.Timestamp.UnixMilli(),
+	}
+	if len(book.Bids) > 0 && len(book.Asks) > 0 {
+		impact.MidPrice = (book.Bids[0].Price + book.Asks[0].Price) / 2
+	}
+	remaining := notionalUSD
+	for _, l := range levels {
+		if remaining <= 0 {
+			break
+		}
+		if l.Price <= 0 || l.Qty <= 0 {
+			continue
+		}
+		qty := min(l.Qty, remaining/l.Price)
+		impact.FilledQty += qty
+		impact.FilledUSD += qty * l.Price
+		impact.WorstPrice = l.Price
+		impact.Levels++
+		remaining -= qty * l.Price
+	}
+	if impact.FilledQty == 0 {
+		return Impact{}, ErrEmptyBook
+	}
+	// A residue of a rounding error doesn't make the fill partial
+	impact.Complete = remaining <= notionalUSD*1e-9
+	impact.AvgPrice = impact.FilledUSD / impact.FilledQty
+	impact.SlippageBps = fillCostBps(side, impact.AvgPrice, impact.BestPrice)
+	if impact.MidPrice > 0 {
+		impact.ImpactBps = fillCostBps(side, impact.AvgPrice, impact.MidPrice)
+	}
+	return impact, nil
+}
+
+// fillCostBps returns how much worse than ref a fill at price is for side, in basis points of ref.
+func fillCostBps(side string, price, ref float64) float64 {
+	if side == SideSell {
+		return (ref - price) / ref * 10_000
+	}
+	return (price - ref) / ref * 10_000
+}
@@ -0,0 +1,188 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/fx"
+	"cex-price-diff-notifications/shared"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LiveSpreads maintains a ranked set of spreads that is updated one ticker at a time.
+// A ticker update only recomputes the pairs of its base asset (same-quote and cross-quote),
+// instead of recalculating every symbol as CalculateSpreads does.
+// Funding rates and FX rates are read when a base is recomputed, so their changes show up
+// on the base's next price update.
+type LiveSpreads struct {
+	mu            sync.Mutex
+	opts          Options
+	tickers       map[string]map[string]shared.TickerBidAsk    // Latest ticker per unified symbol and exchange
+	symbolsByBase map[string]map[string]struct{}               // Unified symbols known for each base asset
+	spreadsByBase map[string][]Spread                          // Current spreads of each base asset
+	fundingRates  map[string]map[string]shared.FundingRateInfo // Keyed by exchange and unified symbol
+	fxRates       fx.Rates
+}
+
+// NewLiveSpreads creates an empty LiveSpreads evaluating pairs with opts.
+func NewLiveSpreads(opts Options) *LiveSpreads {
+	return &LiveSpreads{
+		opts:          opts,
+		tickers:       make(map[string]map[string]shared.TickerBidAsk),
+		symbolsByBase: make(map[string]map[string]struct{}),
+		spreadsByBase: make(map[string][]Spread),
+	}
+}
+
+// SetMarketData replaces the funding and FX rates used by subsequent recomputations.
+func (l *LiveSpreads) SetMarketData(fundingRates map[string]map[string]shared.FundingRateInfo, fxRates fx.Rates) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fundingRates = fundingRates
+	l.fxRates = fxRates
+}
+
+// Update stores a ticker for an exchange and recomputes the pairs of its base asset if the
+// quoted prices or sizes changed. It returns true if a recomputation happened.
+// Unchanged tickers only refresh the stored timestamp, keeping the leg from going stale.
+func (l *LiveSpreads) Update(exchange string, ticker shared.TickerBidAsk) bool {
+	sym, err := shared.ParseSymbol(ticker.UnifiedSymbol)
+	if err != nil {
+		return false
+	}
+	base := sym.Base()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	exchangeData, ok := l.tickers[ticker.UnifiedSymbol]
+	if !ok {
+		exchangeData = make(map[string]shared.TickerBidAsk)
+		l.tickers[ticker.UnifiedSymbol] = exchangeData
+		if _, ok := l.symbolsByBase[base]; !ok {
+			l.symbolsByBase[base] = make(map[string]struct{})
+		}
+		l.symbolsByBase[base][ticker.UnifiedSymbol] = struct{}{}
+	}
+
+	previous, known := exchangeData[exchange]
+	exchangeData[exchange] = ticker
+	if known && samePrices(previous, ticker) {
+		return false
+	}
+
+	l.recomputeBase(base)
+	return true
+}
+
+// Remove drops an exchange's ticker for a symbol and recomputes the pairs of its base asset.
+func (l *LiveSpreads) Remove(unifiedSymbol, exchange string) {
+	sym, err := shared.ParseSymbol(unifiedSymbol)
+	if err != nil {
+		return
+	}
+	base := sym.Base()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	exchangeData, ok := l.tickers[unifiedSymbol]
+	if !ok {
+		return
+	}
+	if _, ok := exchangeData[exchange]; !ok {
+		return
+	}
+	delete(exchangeData, exchange)
+	if len(exchangeData) == 0 {
+		delete(l.tickers, unifiedSymbol)
+		delete(l.symbolsByBase[base], unifiedSymbol)
+	}
+
+	l.recomputeBase(base)
+}
+
+// Sync applies a full ticker snapshot as a series of updates, removing legs that are no longer present.
+// It returns the number of tickers that triggered a recomputation.
+func (l *LiveSpreads) Sync(tickers map[string]map[string]shared.TickerBidAsk) int {
+	recomputed := 0
+	for _, exchangeData := range tickers {
+		for exchange, ticker := range exchangeData {
+			if l.Update(exchange, ticker) {
+				recomputed++
+			}
+		}
+	}
+
+	l.mu.Lock()
+	var removed [][2]string
+	for symbol, exchangeData := range l.tickers {
+		for exchange := range exchangeData {
+			if _, ok := tickers[symbol][exchange]; !ok {
+				removed = append(removed, [2]string{symbol, exchange})
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	for _, r := range removed {
+		l.Remove(r[0], r[1])
+	}
+	return recomputed
+}
+
+// Ranked returns a copy of the current spreads sorted by the highest entry percentage, descending.
+// Spreads with a leg older than the configured maximum ticker age are left out.
+func (l *LiveSpreads) Ranked(now time.Time) []Spread {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var spreads []Spread
+	for _, baseSpreads := range l.spreadsByBase {
+		for _, s := range baseSpreads {
+			if l.opts.MaxTickerAge > 0 &&
+				(now.Sub(l.tickers[s.UnifiedSymbol][s.ExchangeShort].Timestamp) > l.opts.MaxTickerAge ||
+					now.Sub(l.tickers[s.LongSymbol()][s.ExchangeLong].Timestamp) > l.opts.MaxTickerAge) {
+				continue
+			}
+			spreads = append(spreads, s)
+		}
+	}
+
+	sort.Slice(spreads, func(i, j int) bool {
+		return spreads[i].EntrySpread > spreads[j].EntrySpread
+	})
+	return spreads
+}
+
+// recomputeBase re-evaluates every pair of a base asset. The caller must hold l.mu.
+func (l *LiveSpreads) recomputeBase(base string) {
+	baseTickers := make(map[string]map[string]shared.TickerBidAsk, len(l.symbolsByBase[base]))
+	for symbol := range l.symbolsByBase[base] {
+		baseTickers[symbol] = l.tickers[symbol]
+	}
+	if l.opts.MaxTickerAge > 0 {
+		baseTickers = dropStaleTickers(baseTickers, l.opts.MaxTickerAge, l.opts.now())
+	}
+	if l.opts.MinVolumeUSD > 0 || l.opts.MinTopOfBookUSD > 0 {
+		baseTickers = dropIlliquidTickers(baseTickers, l.opts.MinVolumeUSD, l.opts.MinTopOfBookUSD)
+	}
+
+	var spreads []Spread
+	for _, exchangeData := range baseTickers {
+		if len(exchangeData) >= 2 {
+			spreads = symbolSpreads(spreads, exchangeData, l.fundingRates, l.opts)
+		}
+	}
+	spreads = append(spreads, calculateCrossQuoteSpreads(baseTickers, l.fundingRates, l.fxRates, l.opts)...)
+
+	if len(spreads) == 0 {
+		delete(l.spreadsByBase, base)
+		return
+	}
+	l.spreadsByBase[base] = spreads
+}
+
+// samePrices reports whether two tickers quote the same top of book.
+func samePrices(a, b shared.TickerBidAsk) bool {
+	return a.Bid == b.Bid && a.Ask == b.Ask && a.BidQty == b.BidQty && a.AskQty == b.AskQty
+}
@@ -0,0 +1,71 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"math"
+)
+
+// Outlier legs.
+const (
+	OutlierShort = "short"
+	OutlierLong  = "long"
+)
+
+// IndexPrice returns the composite mid price of a symbol across the exchanges quoting it: the mean of their
+// mids weighted by 24h quote volume, so thin venues move it least. Without volumes the mids weigh equally.
+// It returns 0 without valid tickers.
+func IndexPrice(exchangeData map[string]shared.TickerBidAsk) float64 {
+	var sum, weights, plain float64
+	var n int
+	for _, t := range exchangeData {
+		if t.Bid <= 0 || t.Ask <= 0 {
+			continue
+		}
+		mid := (t.Bid + t.Ask) / 2
+		sum += mid * t.VolumeUSD
+		weights += t.VolumeUSD
+		plain += mid
+		n++
+	}
+	switch {
+	case n == 0:
+		return 0
+	case weights > 0:
+		return sum / weights
+	default:
+		return plain / float64(n)
+	}
+}
+
+// ApplyIndexPrices attaches to each spread its symbol's composite index price, from the cycle's tickers keyed by
+// unified symbol then exchange, and flags the leg whose mid deviates most from it as the outlier, the side
+// likely to mean-revert. Long legs of cross-quote spreads are compared in the short leg's quote.
+func ApplyIndexPrices(spreads []Spread, tickers map[string]map[string]shared.TickerBidAsk) {
+	indexes := make(map[string]float64)
+	for i := range spreads {
+		s := &spreads[i]
+		index, ok := indexes[s.UnifiedSymbol]
+		if !ok {
+			index = IndexPrice(tickers[s.UnifiedSymbol])
+			indexes[s.UnifiedSymbol] = index
+		}
+		short, okShort := tickers[s.UnifiedSymbol][s.ExchangeShort]
+		long, okLong := tickers[s.LongSymbol()][s.ExchangeLong]
+		if index <= 0 || !okShort || !okLong {
+			continue
+		}
+		conversion := s.ConversionRate
+		if conversion == 0 {
+			conversion = 1
+		}
+		deviationShort := ((short.Bid+short.Ask)/2 - index) / index * 100
+		deviationLong := ((long.Bid+long.Ask)/2*conversion - index) / index * 100
+
+		s.IndexPrice = index
+		if math.Abs(deviationShort) >= math.Abs(deviationLong) {
+			s.OutlierLeg, s.OutlierExchange, s.OutlierDeviationPct = OutlierShort, s.ExchangeShort, deviationShort
+		} else {
+			s.OutlierLeg, s.OutlierExchange, s.OutlierDeviationPct = OutlierLong, s.ExchangeLong, deviationLong
+		}
+	}
+}
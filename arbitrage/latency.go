@@ -0,0 +1,33 @@
+package arbitrage
+
+import "time"
+
+// Latencies provides the measured round-trip latency per exchange.
+type Latencies interface {
+	Get(exchange string) (time.Duration, bool)
+}
+
+// ApplyLatencyPenalty attaches both legs' latencies to each spread and haircuts the entry spread by
+// penaltyPctPerSec for every second of the slower leg's latency. The haircut grows with how fast the pair moves:
+// it is scaled by 1 + the coefficient of variation of the pair's entry spread history, when statistics are known.
+// The haircut lowers the spread's score; a penaltyPctPerSec of 0 only records the latencies.
+func ApplyLatencyPenalty(spreads []Spread, latencies Latencies, penaltyPctPerSec float64) {
+	for i := range spreads {
+		s := &spreads[i]
+		short, okShort := latencies.Get(s.ExchangeShort)
+		long, okLong := latencies.Get(s.ExchangeLong)
+		if okShort {
+			s.LatencyShortMs = short.Milliseconds()
+		}
+		if okLong {
+			s.LatencyLongMs = long.Milliseconds()
+		}
+
+		volatility := 1.0
+		if s.Stats != nil && s.Stats.Mean > 0 {
+			volatility += s.Stats.StdDev / s.Stats.Mean
+		}
+		s.LatencyHaircutPct = penaltyPctPerSec * max(short, long).Seconds() * volatility
+		s.LatencyAdjustedSpread = s.EntrySpread - s.LatencyHaircutPct
+	}
+}
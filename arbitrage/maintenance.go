@@ -0,0 +1,37 @@
+package arbitrage
+
+import "time"
+
+// MaintenanceNotice warns that an exchange of a spread goes under maintenance soon, when its leg can't be
+// adjusted or closed.
+type MaintenanceNotice struct {
+	Exchange string    `json:"exchange"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end,omitzero"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// ApplyMaintenance attaches to each spread the earliest upcoming maintenance of its two exchanges, as told by
+// upcoming.
+func ApplyMaintenance(spreads []Spread, upcoming func(exchange string) (MaintenanceNotice, bool)) {
+	notices := make(map[string]*MaintenanceNotice)
+	notice := func(exchange string) *MaintenanceNotice {
+		n, ok := notices[exchange]
+		if !ok {
+			if found, ok := upcoming(exchange); ok {
+				n = &found
+			}
+			notices[exchange] = n
+		}
+		return n
+	}
+	for i := range spreads {
+		short, long := notice(spreads[i].ExchangeShort), notice(spreads[i].ExchangeLong)
+		switch {
+		case short != nil && (long == nil || !long.Start.Before(short.Start)):
+			spreads[i].Maintenance = short
+		case long != nil:
+			spreads[i].Maintenance = long
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package arbitrage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// OpportunityID returns the deterministic ID of the opportunity of a kind (e.g., "spread") under key that
+// opened at openedAt: a hash of both and the start of the bucket openedAt falls in. Instances that saw the
+// opportunity open within the same bucket give it the same ID, so consumers can deduplicate its events across
+// reconnects, redeliveries and failovers.
+func OpportunityID(kind, key string, openedAt time.Time, bucket time.Duration) string {
+	bucketStart := openedAt.Truncate(bucket).UnixMilli()
+	sum := sha256.Sum256([]byte(kind + "|" + key + "|" + strconv.FormatInt(bucketStart, 10)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// OpportunityIDs remembers when each opportunity of a kind opened, to give it the same ID for as long as it
+// lasts. An opportunity missing from a cycle is closed; if it comes back, it opens again with a new ID.
+// It is not safe for concurrent use.
+type OpportunityIDs struct {
+	kind   string
+	bucket time.Duration
+	opened map[string]time.Time // Key -> open time
+	seen   map[string]bool      // Keys of the current cycle
+}
+
+// NewOpportunityIDs creates a tracker for the opportunities of kind, whose open times are bucketed by bucket.
+func NewOpportunityIDs(kind string, bucket time.Duration) *OpportunityIDs {
+	return &OpportunityIDs{
+		kind:   kind,
+		bucket: bucket,
+		opened: make(map[string]time.Time),
+		seen:   make(map[string]bool),
+	}
+}
+
+// ID returns the ID of the opportunity under key in the current cycle, which opens now if it wasn't open in
+// the previous one.
+func (o *OpportunityIDs) ID(key string, now time.Time) string {
+	openedAt, ok := o.opened[key]
+	if !ok {
+		openedAt = now
+		o.opened[key] = now
+	}
+	o.seen[key] = true
+	return OpportunityID(o.kind, key, openedAt, o.bucket)
+}
+
+// EndCycle closes the opportunities whose ID wasn't asked for in the cycle.
+func (o *OpportunityIDs) EndCycle() {
+	for key := range o.opened {
+		if !o.seen[key] {
+			delete(o.opened, key)
+		}
+	}
+	clear(o.seen)
+}
+
+// ApplySpreads sets the ID of every spread of the cycle, by pair key, and ends the cycle.
+func (o *OpportunityIDs) ApplySpreads(spreads []Spread, now time.Time) {
+	for i := range spreads {
+		spreads[i].OpportunityID = o.ID(spreads[i].PairKey(), now)
+	}
+	o.EndCycle()
+}
@@ -0,0 +1,56 @@
+package arbitrage
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// runParallel applies fn to every item using a pool of workers and appends the results to dst. fn appends the
+// spreads of an item to the slice it is given and returns it, so each worker collects into one growing slice.
+// A workers value of 0 or less sizes the pool by GOMAXPROCS. The order of the results is not defined.
+func runParallel[T any](dst []Spread, items []T, workers int, fn func([]Spread, T) []Spread) []Spread {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+	if workers <= 1 {
+		for _, item := range items {
+			dst = fn(dst, item)
+		}
+		return dst
+	}
+
+	// Each worker takes a contiguous chunk and collects into its own slice, so no locking is needed.
+	results := make([][]Spread, workers)
+	chunkSize := (len(items) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := w * chunkSize
+		if start >= len(items) {
+			break
+		}
+		end := min(start+chunkSize, len(items))
+
+		wg.Add(1)
+		go func(w int, chunk []T) {
+			defer wg.Done()
+			for _, item := range chunk {
+				results[w] = fn(results[w], item)
+			}
+		}(w, items[start:end])
+	}
+	wg.Wait()
+
+	total := 0
+	for _, r := range results {
+		total += len(r)
+	}
+	dst = slices.Grow(dst, total)
+	for _, r := range results {
+		dst = append(dst, r...)
+	}
+	return dst
+}
@@ -0,0 +1,70 @@
+package arbitrage
+
+import "cex-price-diff-notifications/shared"
+
+// Params are the per-symbol assumptions used when evaluating and publishing spreads.
+type Params struct {
+	MinEntrySpreadPct float64     // Spreads below this entry spread (in percent) are not published
+	TargetNotionalUSD float64     // Intended position size per leg
+	TakerFeesBps      FeeSchedule // Taker fee per exchange in basis points
+}
+
+// SymbolOverride replaces selected global Params for one symbol. Nil or missing fields fall back to the defaults.
+type SymbolOverride struct {
+	MinEntrySpreadPct *float64           `json:"min_entry_spread_pct,omitempty"`
+	TargetNotionalUSD *float64           `json:"target_notional_usd,omitempty"`
+	TakerFeesBps      map[string]float64 `json:"taker_fees_bps,omitempty"` // Merged per exchange over the default fees
+}
+
+// ParamSet resolves Params per symbol from global defaults and overrides keyed by unified symbol
+// (e.g., "BTC/USDT:PERP") or base asset (e.g., "BTC"). Unified symbol overrides take precedence.
+type ParamSet struct {
+	Defaults  Params
+	Overrides map[string]SymbolOverride
+}
+
+// For returns the effective Params of a unified symbol.
+func (p ParamSet) For(unifiedSymbol string) Params {
+	params := p.Defaults
+	if len(p.Overrides) == 0 {
+		return params
+	}
+
+	if sym, err := shared.ParseSymbol(unifiedSymbol); err == nil {
+		if o, ok := p.Overrides[sym.Base()]; ok {
+			params = o.apply(params)
+		}
+	}
+	if o, ok := p.Overrides[unifiedSymbol]; ok {
+		params = o.apply(params)
+	}
+	return params
+}
+
+// apply returns params with the override's fields replaced.
+func (o SymbolOverride) apply(params Params) Params {
+	if o.MinEntrySpreadPct != nil {
+		params.MinEntrySpreadPct = *o.MinEntrySpreadPct
+	}
+	if o.TargetNotionalUSD != nil {
+		params.TargetNotionalUSD = *o.TargetNotionalUSD
+	}
+	if len(o.TakerFeesBps) > 0 {
+		fees := make(FeeSchedule, len(params.TakerFeesBps)+len(o.TakerFeesBps))
+		for exchange, bps := range params.TakerFeesBps {
+			fees[exchange] = bps
+		}
+		for exchange, bps := range o.TakerFeesBps {
+			fees[exchange] = bps
+		}
+		params.TakerFeesBps = fees
+	}
+	return params
+}
+
+// ApplyTargetNotional sets each spread's target notional from its symbol's params.
+func ApplyTargetNotional(spreads []Spread, params ParamSet) {
+	for i := range spreads {
+		spreads[i].TargetNotionalUSD = params.For(spreads[i].UnifiedSymbol).TargetNotionalUSD
+	}
+}
@@ -0,0 +1,69 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"time"
+)
+
+// Break-even types of a spread's funding carry.
+const (
+	BreakEvenErodes   = "erodes"   // Net edge is positive and funding is paid; the position stops being profitable after BreakEvenHours
+	BreakEvenRecovers = "recovers" // Net edge is negative and funding is collected; the position turns profitable after BreakEvenHours
+)
+
+// FeeSchedule maps an exchange name to its taker fee in basis points.
+type FeeSchedule map[string]float64
+
+// RoundTripPct returns the total fees in percent for opening and closing both legs with taker orders.
+func (f FeeSchedule) RoundTripPct(exchangeShort, exchangeLong string) float64 {
+	return 2 * (f[exchangeShort] + f[exchangeLong]) / 100
+}
+
+// ApplyExpectedPnL projects the PnL of holding each spread for horizon:
+// entry spread - round-trip taker fees + funding accrued on both legs over the horizon.
+// Fees are resolved per symbol from params. Funding is only included when both legs have a known rate and interval.
+func ApplyExpectedPnL(spreads []Spread, params ParamSet, horizon time.Duration) {
+	hours := horizon.Hours()
+	for i := range spreads {
+		s := &spreads[i]
+		s.RoundTripFeesPct = params.For(s.UnifiedSymbol).TakerFeesBps.RoundTripPct(s.ExchangeShort, s.ExchangeLong)
+
+		if s.FundingRateShort == nil || s.FundingRateLong == nil || s.FundingRateShort.Interval <= 0 || s.FundingRateLong.Interval <= 0 {
+			continue
+		}
+
+		fundingPct := fundingAccrualPct(s.FundingRateShort, s.FundingRateLong, hours)
+		pnl := s.EntrySpread - s.RoundTripFeesPct + fundingPct
+		s.ExpectedPnL24h = &pnl
+		s.PnLHorizonHours = hours
+
+		applyBreakEven(s, s.EntrySpread-s.RoundTripFeesPct, fundingAccrualPct(s.FundingRateShort, s.FundingRateLong, 1))
+	}
+}
+
+// applyBreakEven sets how long a spread must be held before the hourly funding accrual cancels
+// its net edge (both in percent). Spreads where funding and edge point the same way never break even.
+func applyBreakEven(s *Spread, netEdgePct, fundingPerHourPct float64) {
+	if fundingPerHourPct == 0 {
+		return
+	}
+	hours := -netEdgePct / fundingPerHourPct
+	if hours <= 0 {
+		return
+	}
+	s.BreakEvenHours = &hours
+	if netEdgePct > 0 {
+		s.BreakEvenType = BreakEvenErodes
+	} else {
+		s.BreakEvenType = BreakEvenRecovers
+	}
+}
+
+// fundingAccrualPct returns the funding collected (positive) or paid (negative) in percent
+// over the given hours, for being short on one leg and long on the other.
+func fundingAccrualPct(short, long *shared.FundingRateInfo, hours float64) float64 {
+	// PnL = side * r * (H / N)
+	pnlShort := +1.0 * short.ShortRate() * (hours / float64(short.Interval))
+	pnlLong := -1.0 * long.Rate * (hours / float64(long.Interval))
+	return (pnlShort + pnlLong) * 100
+}
@@ -0,0 +1,73 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"log/slog"
+	"math"
+	"sort"
+)
+
+// FilterStats counts the tickers rejected by FilterInvalidTickers, per reason.
+type FilterStats struct {
+	NonPositive int
+	Crossed     int
+	Outlier     int
+}
+
+// FilterInvalidTickers returns a copy of tickers without data points that would produce phantom spreads:
+// zero/negative prices, crossed books (bid >= ask), and mid prices deviating more than maxDeviationPct
+// from the cross-exchange median for the symbol. The outlier check is skipped when maxDeviationPct <= 0.
+func FilterInvalidTickers(tickers map[string]map[string]shared.TickerBidAsk, maxDeviationPct float64) (map[string]map[string]shared.TickerBidAsk, FilterStats) {
+	var stats FilterStats
+	valid := make(map[string]map[string]shared.TickerBidAsk, len(tickers))
+
+	for symbol, exchangeData := range tickers {
+		sane := make(map[string]shared.TickerBidAsk, len(exchangeData))
+		for exchange, ticker := range exchangeData {
+			if ticker.Bid <= 0 || ticker.Ask <= 0 {
+				stats.NonPositive++
+				continue
+			}
+			if ticker.Bid >= ticker.Ask {
+				stats.Crossed++
+				continue
+			}
+			sane[exchange] = ticker
+		}
+
+		// A median is only meaningful with at least three points; with two, both legs deviate
+		// equally, so the check still catches gross mismatches (e.g., different contract sizes).
+		if maxDeviationPct > 0 && len(sane) >= 2 {
+			median := medianMid(sane)
+			for exchange, ticker := range sane {
+				mid := (ticker.Bid + ticker.Ask) / 2
+				deviation := math.Abs(mid-median) / median * 100
+				if deviation > maxDeviationPct {
+					slog.Debug("Rejected outlier ticker", "symbol", symbol, "exchange", exchange, "mid", mid, "median", median, "deviation_%", deviation)
+					delete(sane, exchange)
+					stats.Outlier++
+				}
+			}
+		}
+
+		if len(sane) > 0 {
+			valid[symbol] = sane
+		}
+	}
+
+	return valid, stats
+}
+
+// medianMid returns the median mid price across the given tickers.
+func medianMid(exchangeData map[string]shared.TickerBidAsk) float64 {
+	mids := make([]float64, 0, len(exchangeData))
+	for _, t := range exchangeData {
+		mids = append(mids, (t.Bid+t.Ask)/2)
+	}
+	sort.Float64s(mids)
+	n := len(mids)
+	if n%2 == 1 {
+		return mids[n/2]
+	}
+	return (mids[n/2-1] + mids[n/2]) / 2
+}
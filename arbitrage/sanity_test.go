@@ -0,0 +1,143 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFilterInvalidTickers(t *testing.T) {
+	const symbol = "BTC/USDT:PERP"
+	now := time.Now()
+	ticker := func(bid, ask float64) shared.TickerBidAsk {
+		return shared.TickerBidAsk{UnifiedSymbol: symbol, Bid: bid, Ask: ask, Timestamp: now}
+	}
+	tests := []struct {
+		name            string
+		tickers         map[string]shared.TickerBidAsk // Exchange -> ticker of the symbol
+		maxDeviationPct float64
+		want            []string // Exchanges kept
+		wantStats       FilterStats
+	}{
+		{
+			name:    "valid books are kept",
+			tickers: map[string]shared.TickerBidAsk{"Binance": ticker(100, 100.1), "Mexc": ticker(100.2, 100.3)},
+			want:    []string{"Binance", "Mexc"},
+		},
+		{
+			name:      "zero bid",
+			tickers:   map[string]shared.TickerBidAsk{"Binance": ticker(0, 100.1), "Mexc": ticker(100.2, 100.3)},
+			want:      []string{"Mexc"},
+			wantStats: FilterStats{NonPositive: 1},
+		},
+		{
+			name:      "negative ask",
+			tickers:   map[string]shared.TickerBidAsk{"Binance": ticker(100, -1), "Mexc": ticker(100.2, 100.3)},
+			want:      []string{"Mexc"},
+			wantStats: FilterStats{NonPositive: 1},
+		},
+		{
+			name:      "crossed book",
+			tickers:   map[string]shared.TickerBidAsk{"Binance": ticker(100.2, 100.1), "Mexc": ticker(100.2, 100.3)},
+			want:      []string{"Mexc"},
+			wantStats: FilterStats{Crossed: 1},
+		},
+		{
+			name:      "locked book",
+			tickers:   map[string]shared.TickerBidAsk{"Binance": ticker(100.1, 100.1), "Mexc": ticker(100.2, 100.3)},
+			want:      []string{"Mexc"},
+			wantStats: FilterStats{Crossed: 1},
+		},
+		{
+			name:      "symbol without a valid ticker is dropped",
+			tickers:   map[string]shared.TickerBidAsk{"Binance": ticker(0, 0), "Mexc": ticker(101, 100)},
+			wantStats: FilterStats{NonPositive: 1, Crossed: 1},
+		},
+		{
+			name: "outlier from the median",
+			tickers: map[string]shared.TickerBidAsk{
+				"Binance": ticker(100, 100.1),
+				"Mexc":    ticker(100.1, 100.2),
+				"GMX":     ticker(150, 150.1),
+			},
+			maxDeviationPct: 5,
+			want:            []string{"Binance", "Mexc"},
+			wantStats:       FilterStats{Outlier: 1},
+		},
+		{
+			name: "outliers are kept with the check disabled",
+			tickers: map[string]shared.TickerBidAsk{
+				"Binance": ticker(100, 100.1),
+				"Mexc":    ticker(100.1, 100.2),
+				"GMX":     ticker(150, 150.1),
+			},
+			want: []string{"Binance", "GMX", "Mexc"},
+		},
+		{
+			// With two points both deviate equally from their median, so a gross mismatch drops both
+			name:            "gross mismatch between two exchanges",
+			tickers:         map[string]shared.TickerBidAsk{"Binance": ticker(100, 100.1), "Mexc": ticker(1000, 1000.1)},
+			maxDeviationPct: 50,
+			wantStats:       FilterStats{Outlier: 2},
+		},
+		{
+			// Ticker ages are checked by CalculateSpreads against Options.MaxTickerAge, see TestDropStaleTickers
+			name: "stale timestamps are left to the spread calculation",
+			tickers: map[string]shared.TickerBidAsk{
+				"Binance": {UnifiedSymbol: symbol, Bid: 100, Ask: 100.1, Timestamp: now.Add(-time.Hour)},
+				"Mexc":    ticker(100.2, 100.3),
+			},
+			maxDeviationPct: 5,
+			want:            []string{"Binance", "Mexc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tickers := map[string]map[string]shared.TickerBidAsk{symbol: tt.tickers}
+			got, stats := FilterInvalidTickers(tickers, tt.maxDeviationPct)
+			if kept := slices.Sorted(maps.Keys(got[symbol])); !slices.Equal(kept, tt.want) {
+				t.Errorf("got %v kept, want %v", kept, tt.want)
+			}
+			if _, ok := got[symbol]; ok && len(tt.want) == 0 {
+				t.Errorf("got the symbol without tickers, want it dropped")
+			}
+			if stats != tt.wantStats {
+				t.Errorf("got stats %+v, want %+v", stats, tt.wantStats)
+			}
+			if len(tickers[symbol]) != len(tt.tickers) {
+				t.Errorf("got the input modified, want it copied")
+			}
+		})
+	}
+}
+
+func TestDropStaleTickers(t *testing.T) {
+	const symbol = "BTC/USDT:PERP"
+	now := time.Now()
+	tests := []struct {
+		name string
+		age  time.Duration
+		zero bool // No timestamp
+		want bool // Kept
+	}{
+		{name: "fresh", age: time.Second, want: true},
+		{name: "at the maximum age", age: 10 * time.Second, want: true},
+		{name: "older than the maximum age", age: 11 * time.Second},
+		{name: "without a timestamp", zero: true},
+		{name: "from the future", age: -time.Second, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticker := shared.TickerBidAsk{UnifiedSymbol: symbol, Bid: 100, Ask: 100.1, Timestamp: now.Add(-tt.age)}
+			if tt.zero {
+				ticker.Timestamp = time.Time{}
+			}
+			got := dropStaleTickers(map[string]map[string]shared.TickerBidAsk{symbol: {"Binance": ticker}}, 10*time.Second, now)
+			if _, kept := got[symbol]["Binance"]; kept != tt.want {
+				t.Errorf("got kept %t, want %t", kept, tt.want)
+			}
+		})
+	}
+}
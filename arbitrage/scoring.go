@@ -0,0 +1,140 @@
+package arbitrage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Scorer assigns a ranking score to a spread; higher scores rank first.
+type Scorer interface {
+	Score(s Spread) float64
+}
+
+// ScorerFunc adapts a plain function to the Scorer interface.
+type ScorerFunc func(s Spread) float64
+
+// Score calls f(s).
+func (f ScorerFunc) Score(s Spread) float64 {
+	return f(s)
+}
+
+// ScoreWeights are the weights of each component of the default weighted score.
+type ScoreWeights struct {
+	NetSpread  float64 // Per percent of entry spread after fees and latency haircut
+	FundingPnL float64 // Per percent of funding accrued over the PnL horizon
+	Liquidity  float64 // Per order of magnitude of the thinner leg's 24h volume in USD
+	ZScore     float64 // Per standard deviation above the pair's historical mean
+	Staleness  float64 // Subtracted per second of age of the older leg's ticker
+	Momentum   float64 // Per bps per minute the entry spread widens (negative while it converges)
+}
+
+// WeightedScorer combines net spread, funding PnL, liquidity, z-score, staleness and momentum linearly.
+// Components that are unknown for a spread (e.g., no history yet) contribute 0.
+type WeightedScorer struct {
+	Weights ScoreWeights
+	Now     func() time.Time // Clock used for staleness; time.Now if nil
+}
+
+// Score returns the weighted sum of the spread's components.
+func (w WeightedScorer) Score(s Spread) float64 {
+	netSpread := s.EntrySpread - s.RoundTripFeesPct - s.LatencyHaircutPct
+
+	fundingPnL := 0.0
+	if s.ExpectedPnL24h != nil {
+		fundingPnL = *s.ExpectedPnL24h - (s.EntrySpread - s.RoundTripFeesPct)
+	}
+
+	liquidity := 0.0
+	if s.VolumeUSD > 1 {
+		liquidity = math.Log10(s.VolumeUSD)
+	}
+
+	zScore := 0.0
+	if s.Stats != nil {
+		zScore = s.Stats.ZScore
+	}
+
+	momentum := 0.0
+	if s.MomentumBpsPerMin != nil {
+		momentum = *s.MomentumBpsPerMin
+	}
+
+	staleness := 0.0
+	if !s.QuoteTime.IsZero() {
+		now := time.Now
+		if w.Now != nil {
+			now = w.Now
+		}
+		staleness = max(now().Sub(s.QuoteTime).Seconds(), 0)
+	}
+
+	return w.Weights.NetSpread*netSpread +
+		w.Weights.FundingPnL*fundingPnL +
+		w.Weights.Liquidity*liquidity +
+		w.Weights.ZScore*zScore +
+		w.Weights.Momentum*momentum -
+		w.Weights.Staleness*staleness
+}
+
+// ApplyScores sets the score of every spread and sorts them by score, descending.
+func ApplyScores(spreads []Spread, scorer Scorer) {
+	for i := range spreads {
+		spreads[i].Score = scorer.Score(spreads[i])
+	}
+	sort.SliceStable(spreads, func(i, j int) bool {
+		return spreads[i].Score > spreads[j].Score
+	})
+}
+
+// Decisions of a BatchScorer on a spread. An empty decision keeps the spread.
+const (
+	DecisionKeep = "keep"
+	DecisionDrop = "drop"
+)
+
+// ScoreResult is a batch scorer's verdict on a spread.
+type ScoreResult struct {
+	Score    float64 `json:"score"`
+	Decision string  `json:"decision,omitempty"` // DecisionDrop suppresses the spread for the cycle
+}
+
+// BatchScorer scores the spreads of a cycle at once, e.g., with an external model. Spreads carry their
+// history features (Stats, Anomaly, AnomalyScore) and the heuristic Score as inputs. It returns one result
+// per spread, in order.
+type BatchScorer interface {
+	ScoreBatch(ctx context.Context, spreads []Spread) ([]ScoreResult, error)
+}
+
+// ApplyBatchScores sets the scores of a batch scorer, drops the spreads it rejects and sorts the rest by
+// score, descending. It returns the kept spreads and the number dropped. On error, spreads are returned
+// unchanged.
+func ApplyBatchScores(ctx context.Context, spreads []Spread, scorer BatchScorer) ([]Spread, int, error) {
+	if len(spreads) == 0 {
+		return spreads, 0, nil
+	}
+	results, err := scorer.ScoreBatch(ctx, spreads)
+	if err != nil {
+		return spreads, 0, err
+	}
+	if len(results) != len(spreads) {
+		return spreads, 0, fmt.Errorf("scorer returned %d results for %d spreads", len(results), len(spreads))
+	}
+
+	kept := spreads[:0]
+	for i, r := range results {
+		if r.Decision == DecisionDrop {
+			continue
+		}
+		s := spreads[i]
+		s.Score = r.Score
+		kept = append(kept, s)
+	}
+	dropped := len(spreads) - len(kept)
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Score > kept[j].Score
+	})
+	return kept, dropped, nil
+}
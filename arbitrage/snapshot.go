@@ -0,0 +1,35 @@
+package arbitrage
+
+import "time"
+
+// Snapshot is the full ranked set of opportunities published at the end of a cycle, with cycle metadata.
+type Snapshot struct {
+	Cycle          uint64   `json:"cycle"`                   // Cycle number since startup, starting at 1
+	StartedAt      int64    `json:"started_at"`              // Milliseconds since epoch
+	GeneratedAt    int64    `json:"generated_at"`            // Milliseconds since epoch
+	DurationMs     int64    `json:"duration_ms"`             // Time from the start of fetching to the snapshot
+	SpreadMode     string   `json:"spread_mode"`             // How spreads were computed ("full" or "incremental")
+	SymbolCount    int      `json:"symbol_count"`            // Unified symbols with valid tickers
+	CandidateCount int      `json:"candidate_count"`         // Spreads evaluated before the publish limits
+	Count          int      `json:"count"`                   // Number of opportunities in the snapshot
+	Opportunities  []Spread `json:"opportunities"`           // Ranked, best first
+	FundingStale   []string `json:"funding_stale,omitempty"` // Exchanges whose funding rates were missing or stale
+}
+
+// NewSnapshot builds the snapshot of a cycle that started at startedAt from its published spreads.
+func NewSnapshot(cycle uint64, startedAt, now time.Time, spreadMode string, symbolCount, candidateCount int, spreads []Spread) Snapshot {
+	if spreads == nil {
+		spreads = []Spread{} // Consumers get an empty array rather than null
+	}
+	return Snapshot{
+		Cycle:          cycle,
+		StartedAt:      startedAt.UnixMilli(),
+		GeneratedAt:    now.UnixMilli(),
+		DurationMs:     now.Sub(startedAt).Milliseconds(),
+		SpreadMode:     spreadMode,
+		SymbolCount:    symbolCount,
+		CandidateCount: candidateCount,
+		Count:          len(spreads),
+		Opportunities:  spreads,
+	}
+}
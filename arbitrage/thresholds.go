@@ -0,0 +1,45 @@
+package arbitrage
+
+// SelectAboveDynamicThreshold keeps spreads whose entry spread exceeds their pair's historical
+// percentile threshold. Pairs without enough history to derive a threshold are kept.
+func SelectAboveDynamicThreshold(spreads []Spread) ([]Spread, int) {
+	selected := spreads[:0:0]
+	suppressed := 0
+	for _, s := range spreads {
+		if s.Stats != nil && s.Stats.PercentileThreshold != nil && s.EntrySpread <= *s.Stats.PercentileThreshold {
+			suppressed++
+			continue
+		}
+		selected = append(selected, s)
+	}
+	return selected, suppressed
+}
+
+// SelectForPublishing drops spreads below their symbol's minimum entry spread (in percent) and keeps at most
+// maxCount of the remaining ones (0 means unlimited), preserving order. It returns the counts filtered by each rule.
+func SelectForPublishing(spreads []Spread, params ParamSet, maxCount int) (selected []Spread, belowMin, overLimit int) {
+	selected, belowMin = SelectAboveMinimum(spreads, params)
+	selected, overLimit = SelectTop(selected, maxCount)
+	return selected, belowMin, overLimit
+}
+
+// SelectAboveMinimum drops spreads below their symbol's minimum entry spread (in percent), preserving order, and
+// returns the number dropped.
+func SelectAboveMinimum(spreads []Spread, params ParamSet) (selected []Spread, belowMin int) {
+	for _, s := range spreads {
+		if s.EntrySpread < params.For(s.UnifiedSymbol).MinEntrySpreadPct {
+			belowMin++
+			continue
+		}
+		selected = append(selected, s)
+	}
+	return selected, belowMin
+}
+
+// SelectTop keeps at most maxCount spreads (0 means unlimited), preserving order, and returns the number dropped.
+func SelectTop(spreads []Spread, maxCount int) ([]Spread, int) {
+	if maxCount <= 0 || len(spreads) <= maxCount {
+		return spreads, 0
+	}
+	return spreads[:maxCount], len(spreads) - maxCount
+}
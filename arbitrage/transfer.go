@@ -0,0 +1,144 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"time"
+)
+
+// WalletStatus provides deposit/withdrawal status per exchange and asset.
+type WalletStatus interface {
+	Get(exchange, asset string) ([]shared.AssetNetwork, bool)
+}
+
+// TransferTimes estimates how long moving an asset between exchanges over a network takes, and what it costs.
+type TransferTimes interface {
+	Estimate(asset, network, from, to string) shared.TransferEstimate
+}
+
+// TransferRoute describes how the base asset of a spot-spot spread moves from the long (buy) exchange
+// to the short (sell) exchange.
+type TransferRoute struct {
+	Feasible    bool     `json:"feasible"`
+	Reason      string   `json:"reason,omitempty"`         // Why the transfer is not feasible
+	Network     string   `json:"network,omitempty"`        // Cheapest network enabled on both sides
+	WithdrawFee float64  `json:"withdraw_fee,omitempty"`   // Withdrawal fee in base asset units
+	FeePct      *float64 `json:"fee_pct,omitempty"`        // Withdrawal fee relative to the target notional, in percent; nil if it can't be priced
+	NetSpread   *float64 `json:"net_spread_pct,omitempty"` // Entry spread minus the transfer fee, in percent; nil with FeePct
+	// Typical transfer time and fee of the route, and whether the spread is expected to outlast it
+	TransferMinutes  float64  `json:"transfer_minutes,omitempty"`
+	TransferSource   string   `json:"transfer_source,omitempty"`    // "learned" from past transfers, "config" or "default"
+	TransferSamples  int      `json:"transfer_samples,omitempty"`   // Past transfers the learned time is based on
+	TypicalFee       float64  `json:"typical_fee,omitempty"`        // Median fee of past withdrawals, in base asset units
+	ArrivalSpread    *float64 `json:"arrival_spread_pct,omitempty"` // Net spread expected once the asset arrives, in percent
+	SurvivesTransfer *bool    `json:"survives_transfer,omitempty"`  // ArrivalSpread is positive; nil without spread history
+}
+
+// Reasons a transfer route is not feasible.
+const (
+	TransferUnknownAsset     = "unknown_asset"     // Wallet status of the asset is not known on one of the exchanges
+	TransferWithdrawDisabled = "withdraw_disabled" // No network allows withdrawals on the long exchange
+	TransferDepositDisabled  = "deposit_disabled"  // No network allows deposits on the short exchange
+	TransferNoCommonNetwork  = "no_common_network" // Withdrawals and deposits are open, but not on a shared network
+)
+
+// ApplyTransferFeasibility attaches the cheapest transfer route to each spot-spot spread: withdrawing the base
+// asset from the long exchange and depositing it on the short exchange over a network both support.
+// The fee percentage uses the long leg's ask from tickers and the spread's target notional; without either, a
+// route with a withdrawal fee is left without fee percentage and net spread rather than priced as free. Feasible
+// routes get their typical transfer time from times, if not nil, and with a priced fee and the spread's history,
+// the net spread expected on arrival: a spread above its pair's mean is assumed to revert to it during the
+// transfer.
+func ApplyTransferFeasibility(spreads []Spread, tickers map[string]map[string]shared.TickerBidAsk, wallets WalletStatus, times TransferTimes) {
+	for i := range spreads {
+		s := &spreads[i]
+		sym, err := shared.ParseSymbol(s.UnifiedSymbol)
+		if err != nil {
+			continue
+		}
+		base := sym.Base()
+
+		route := transferRoute(base, s.ExchangeLong, s.ExchangeShort, wallets)
+		if route.Feasible {
+			var feePct float64
+			priced := route.WithdrawFee == 0
+			if ask := tickers[s.LongSymbol()][s.ExchangeLong].Ask; ask > 0 && s.TargetNotionalUSD > 0 {
+				feePct = route.WithdrawFee * ask / s.TargetNotionalUSD * 100
+				priced = true
+			}
+			if priced {
+				netSpread := s.EntrySpread - feePct
+				route.FeePct, route.NetSpread = &feePct, &netSpread
+			}
+			if times != nil {
+				applyTransferEstimate(&route, times.Estimate(base, route.Network, s.ExchangeLong, s.ExchangeShort))
+			}
+			if route.FeePct != nil && s.Stats != nil {
+				arrival := min(s.EntrySpread, s.Stats.Mean) - *route.FeePct
+				survives := arrival > 0
+				route.ArrivalSpread = &arrival
+				route.SurvivesTransfer = &survives
+			}
+		}
+		s.Transfer = &route
+	}
+}
+
+// applyTransferEstimate sets the typical transfer time and fee of a route.
+func applyTransferEstimate(route *TransferRoute, estimate shared.TransferEstimate) {
+	route.TransferMinutes = estimate.Duration.Round(time.Second).Minutes()
+	route.TransferSource = estimate.Source
+	route.TransferSamples = estimate.Samples
+	route.TypicalFee = estimate.TypicalFee
+}
+
+// transferRoute finds the cheapest network on which asset can be withdrawn from one exchange and deposited to another.
+func transferRoute(asset, from, to string, wallets WalletStatus) TransferRoute {
+	withdrawals, okFrom := wallets.Get(from, asset)
+	deposits, okTo := wallets.Get(to, asset)
+	if !okFrom || !okTo {
+		return TransferRoute{Reason: TransferUnknownAsset}
+	}
+
+	depositNetworks := make(map[string]bool)
+	for _, n := range deposits {
+		if n.DepositEnabled {
+			depositNetworks[n.Network] = true
+		}
+	}
+	if len(depositNetworks) == 0 {
+		return TransferRoute{Reason: TransferDepositDisabled}
+	}
+
+	route := TransferRoute{Reason: TransferWithdrawDisabled}
+	for _, n := range withdrawals {
+		if !n.WithdrawEnabled {
+			continue
+		}
+		if !depositNetworks[n.Network] {
+			if !route.Feasible {
+				route.Reason = TransferNoCommonNetwork
+			}
+			continue
+		}
+		if !route.Feasible || n.WithdrawFee < route.WithdrawFee {
+			route = TransferRoute{Feasible: true, Network: n.Network, WithdrawFee: n.WithdrawFee}
+		}
+	}
+	return route
+}
+
+// SelectTransferable drops spreads whose transfer route is known to be infeasible, whose transfer fee
+// consumes the entry spread or that aren't expected to outlast the transfer. Spreads without a route, or whose
+// transfer fee couldn't be priced, are kept. It returns the number of dropped spreads.
+func SelectTransferable(spreads []Spread) ([]Spread, int) {
+	selected := spreads[:0:0]
+	suppressed := 0
+	for _, s := range spreads {
+		if s.Transfer != nil && (!s.Transfer.Feasible || (s.Transfer.NetSpread != nil && *s.Transfer.NetSpread <= 0) || (s.Transfer.SurvivesTransfer != nil && !*s.Transfer.SurvivesTransfer)) {
+			suppressed++
+			continue
+		}
+		selected = append(selected, s)
+	}
+	return selected, suppressed
+}
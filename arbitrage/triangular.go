@@ -0,0 +1,113 @@
+package arbitrage
+
+import (
+	"cex-price-diff-notifications/shared"
+	"sort"
+	"strings"
+)
+
+// TriangularOpportunityEventType identifies triangular opportunities in published messages.
+const TriangularOpportunityEventType = "triangular_opportunity"
+
+// TriangularLeg is a single conversion step of a triangular cycle.
+type TriangularLeg struct {
+	UnifiedSymbol string  `json:"unified_symbol"`
+	Side          string  `json:"side"` // "buy" or "sell" the base asset of the market.
+	Price         float64 `json:"price"`
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+}
+
+// TriangularOpportunity represents a cycle of three conversions on a single exchange (e.g., USDT -> BTC -> ETH -> USDT)
+// that ends with more of the starting currency than it began with, after taker fees.
+type TriangularOpportunity struct {
+	EventType     string          `json:"event_type"`
+	OpportunityID string          `json:"opportunity_id,omitempty"` // Same for every event of the opportunity, see OpportunityIDs
+	Exchange      string          `json:"exchange"`
+	Path          []string        `json:"path"` // Currencies visited, starting and ending with the same one.
+	Legs          []TriangularLeg `json:"legs"`
+	ProfitPct     float64         `json:"profit_pct"` // Net gain of the cycle after fees, in percent.
+}
+
+// Key identifies the opportunity's exchange and currency cycle.
+func (t TriangularOpportunity) Key() string {
+	return t.Exchange + "|" + strings.Join(t.Path, ">")
+}
+
+// conversion is a directed edge of the currency graph: converting one unit of From yields Rate units of To.
+type conversion struct {
+	Rate float64
+	Leg  TriangularLeg
+}
+
+// FindTriangularOpportunities builds a currency graph from one exchange's spot tickers and returns every
+// three-step cycle whose product of conversion rates, after feeBps per step, exceeds minProfitPct.
+func FindTriangularOpportunities(exchange string, tickers []shared.TickerBidAsk, feeBps, minProfitPct float64) []TriangularOpportunity {
+	feeFactor := 1 - feeBps/10_000
+
+	// Each market BASE/QUOTE gives two edges: selling BASE at the bid, and buying BASE at the ask.
+	graph := make(map[string]map[string]conversion)
+	addEdge := func(from, to string, c conversion) {
+		if _, ok := graph[from]; !ok {
+			graph[from] = make(map[string]conversion)
+		}
+		graph[from][to] = c
+	}
+	for _, t := range tickers {
+		if t.Bid <= 0 || t.Ask <= 0 {
+			continue
+		}
+		sym, err := shared.ParseSymbol(t.UnifiedSymbol)
+		if err != nil {
+			continue
+		}
+		base, quote := sym.Base(), sym.Quote()
+		addEdge(base, quote, conversion{
+			Rate: t.Bid * feeFactor,
+			Leg:  TriangularLeg{UnifiedSymbol: t.UnifiedSymbol, Side: "sell", Price: t.Bid, From: base, To: quote},
+		})
+		addEdge(quote, base, conversion{
+			Rate: feeFactor / t.Ask,
+			Leg:  TriangularLeg{UnifiedSymbol: t.UnifiedSymbol, Side: "buy", Price: t.Ask, From: quote, To: base},
+		})
+	}
+
+	var opportunities []TriangularOpportunity
+	for a, edgesA := range graph {
+		for b, ab := range edgesA {
+			// Only report each cycle once, starting from its lexicographically smallest currency.
+			if b < a {
+				continue
+			}
+			for c, bc := range graph[b] {
+				if c == a || c < a {
+					continue
+				}
+				ca, ok := graph[c][a]
+				if !ok {
+					continue
+				}
+
+				profitPct := (ab.Rate*bc.Rate*ca.Rate - 1) * 100
+				if profitPct <= minProfitPct {
+					continue
+				}
+
+				opportunities = append(opportunities, TriangularOpportunity{
+					EventType: TriangularOpportunityEventType,
+					Exchange:  exchange,
+					Path:      []string{a, b, c, a},
+					Legs:      []TriangularLeg{ab.Leg, bc.Leg, ca.Leg},
+					ProfitPct: profitPct,
+				})
+			}
+		}
+	}
+
+	// Sort by the highest profit, descending.
+	sort.Slice(opportunities, func(i, j int) bool {
+		return opportunities[i].ProfitPct > opportunities[j].ProfitPct
+	})
+
+	return opportunities
+}
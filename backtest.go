@@ -0,0 +1,238 @@
+package main
+
+import (
+	"cex-price-diff-notifications/api"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/fx"
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/persistence"
+	"cex-price-diff-notifications/risk"
+	"cex-price-diff-notifications/shared"
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// Backtest sources.
+const (
+	backtestSourceAuto      = "auto"      // Recordings if any, else tickers if any were exported, else spreads
+	backtestSourceRecording = "recording" // Recalculate spreads from the recorder's raw tickers and funding rates
+	backtestSourceTickers   = "tickers"   // Recalculate spreads from the exported tickers and funding rates
+	backtestSourceSpreads   = "spreads"   // Reuse the exported spreads as calculated live
+)
+
+// backtestSymbol accumulates the replayed opportunities of one symbol.
+type backtestSymbol struct {
+	symbol         string
+	opened         int
+	closed         int
+	totalDuration  float64 // Seconds, over closed opportunities
+	maxEntrySpread float64
+}
+
+// runBacktest replays exported market data as fast as it can be read and reports the opportunities that would
+// have been published. Recorded tickers and funding rates go through the spread calculation, so slippage,
+// fees and liquidity filters can be tuned too; recorded spreads only go through the publish rules (minimum
+// entry spread, blocked symbols, hysteresis and the per-cycle limit).
+func runBacktest(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	dir := fs.String("dir", cmp.Or(cfg.RecordDir, cfg.ExportDir), "directory of recordings or JSON lines exports (see RECORD_DIR, EXPORT_DIR and EXPORT_TICKERS)")
+	source := fs.String("source", backtestSourceAuto, "data to replay: recording or tickers (recalculating spreads), spreads, or auto")
+	slippage := fs.Float64("slippage", cfg.SlippageBps, "assumed slippage per leg in basis points, when replaying tickers")
+	minEntry := fs.Float64("min", cfg.MinEntrySpreadPct, "minimum entry spread, in percent")
+	maxPublished := fs.Int("max", cfg.MaxPublishedSpreads, "maximum spreads published per cycle (0 means unlimited)")
+	hysteresis := fs.Int("hysteresis", cfg.HysteresisCycles, "cycles a spread must stay above the minimum before it is published (0 disables)")
+	exitSpread := fs.Float64("exit", cfg.HysteresisExitSpreadPct, "entry spread, in percent, below which a gated spread is dropped")
+	top := fs.Int("top", 20, "number of symbols to print, by opportunities opened")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	src, err := backtestSource(*dir, *source)
+	if err != nil {
+		return err
+	}
+
+	settings, err := api.NewRuntimeSettings(api.Settings{
+		MinEntrySpreadPct:   *minEntry,
+		MaxPublishedSpreads: *maxPublished,
+		BlockedSymbols:      cfg.BlockedSymbols,
+	})
+	if err != nil {
+		return err
+	}
+	params := paramSet(cfg)
+	params.Defaults.MinEntrySpreadPct = *minEntry
+	gate := lifecycle.NewGate(*hysteresis, *exitSpread)
+	tracker := lifecycle.NewTracker(cfg.LifecycleMaterialChangeBps)
+	// Each opened opportunity is simulated as a position of the target notional per leg; the kill switch only
+	// halts live trading
+	limits := riskLimits(cfg)
+	limits.KillSwitch, limits.KillSwitchFile = false, ""
+	positions := risk.NewManager(limits)
+
+	var (
+		cycles, candidates, published, updated, profitable, refused int
+		first, last                                                 time.Time
+	)
+	symbols := make(map[string]*backtestSymbol)
+	record := func(events []lifecycle.Event) {
+		for _, e := range events {
+			s, ok := symbols[e.Spread.UnifiedSymbol]
+			if !ok {
+				s = &backtestSymbol{symbol: e.Spread.UnifiedSymbol}
+				symbols[e.Spread.UnifiedSymbol] = s
+			}
+			s.maxEntrySpread = max(s.maxEntrySpread, e.MaxEntrySpread)
+			switch e.EventType {
+			case lifecycle.EventOpened:
+				s.opened++
+				if e.Spread.ExpectedPnL24h != nil && *e.Spread.ExpectedPnL24h > 0 {
+					profitable++
+				}
+				err := positions.Open(risk.Position{
+					ID:            e.Spread.PairKey(),
+					UnifiedSymbol: e.Spread.UnifiedSymbol,
+					Notional:      map[string]float64{e.Spread.ExchangeShort: e.Spread.TargetNotionalUSD, e.Spread.ExchangeLong: e.Spread.TargetNotionalUSD},
+				})
+				if err != nil {
+					refused++
+				}
+			case lifecycle.EventUpdated:
+				updated++
+			case lifecycle.EventClosed:
+				s.closed++
+				s.totalDuration += e.DurationSeconds
+				positions.Close(e.Spread.PairKey())
+			}
+		}
+	}
+
+	replay := func(at time.Time, spreads []arbitrage.Spread) {
+		if first.IsZero() {
+			first = at
+		}
+		last = at
+		cycles++
+		candidates += len(spreads)
+
+		spreads = slices.DeleteFunc(spreads, func(s arbitrage.Spread) bool { return settings.Blocked(s.UnifiedSymbol) })
+		if *hysteresis > 0 {
+			spreads, _ = gate.Apply(spreads, params)
+		} else {
+			spreads, _ = arbitrage.SelectAboveMinimum(spreads, params)
+		}
+		events := tracker.Update(spreads, at)
+		spreads, _ = arbitrage.SelectTop(spreads, *maxPublished)
+		published += len(spreads)
+		record(tracker.Publish(events, spreads, at))
+	}
+
+	if err := replayMarketData(cfg, *dir, src, *slippage, params, replay); err != nil {
+		return err
+	}
+
+	ranked := make([]*backtestSymbol, 0, len(symbols))
+	var opened, closed int
+	var totalDuration float64
+	for _, s := range symbols {
+		ranked = append(ranked, s)
+		opened += s.opened
+		closed += s.closed
+		totalDuration += s.totalDuration
+	}
+	slices.SortFunc(ranked, func(a, b *backtestSymbol) int {
+		return cmp.Or(cmp.Compare(b.opened, a.opened), cmp.Compare(a.symbol, b.symbol))
+	})
+
+	fmt.Printf("Replayed %d cycles of recorded %s from %s to %s (%s)\n", cycles, src, first.Format(time.RFC3339), last.Format(time.RFC3339), last.Sub(first).Round(time.Second))
+	fmt.Printf("Candidate spreads: %d, published: %d\n", candidates, published)
+	fmt.Printf("Opportunities opened: %d, updated: %d, closed: %d, still open: %d\n", opened, updated, closed, opened-closed)
+	fmt.Printf("Opened with a positive expected PnL after fees: %d\n", profitable)
+	fmt.Printf("Opened beyond the risk limits (not taken): %d\n", refused)
+	if closed > 0 {
+		fmt.Printf("Average duration of closed opportunities: %s\n", averageDuration(totalDuration, closed))
+	}
+	if len(ranked) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "SYMBOL\tOPENED\tAVG DURATION\tMAX ENTRY %\t")
+	for i, s := range ranked {
+		if *top > 0 && i >= *top {
+			break
+		}
+		avg := "-"
+		if s.closed > 0 {
+			avg = averageDuration(s.totalDuration, s.closed).String()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.3f\t\n", s.symbol, s.opened, avg, s.maxEntrySpread)
+	}
+	return w.Flush()
+}
+
+// backtestSource resolves the auto source to the data found in dir and validates the others.
+func backtestSource(dir, source string) (string, error) {
+	if dir == "" {
+		return "", errors.New("no data directory, set -dir, RECORD_DIR or EXPORT_DIR")
+	}
+	if source == backtestSourceAuto {
+		source = backtestSourceSpreads
+		if persistence.HasRecordings(dir) {
+			source = backtestSourceRecording
+		} else if tickerFiles, _ := filepath.Glob(filepath.Join(dir, "tickers-*."+persistence.ExportFormatJSONL+"*")); len(tickerFiles) > 0 {
+			source = backtestSourceTickers
+		}
+	}
+	if source != backtestSourceRecording && source != backtestSourceTickers && source != backtestSourceSpreads {
+		return "", fmt.Errorf("unknown source %q, must be recording, tickers, spreads or auto", source)
+	}
+	return source, nil
+}
+
+// replayMarketData reads the recorded data of a resolved source in dir and calls replay with the candidate
+// spreads of each cycle, in order. Recorded market data is recalculated as of its recording time, as the live
+// loop did, with slippageBps per leg and the fees and notionals of params.
+func replayMarketData(cfg *config.Config, dir, source string, slippageBps float64, params arbitrage.ParamSet, replay func(at time.Time, spreads []arbitrage.Spread)) error {
+	spreadOpts := spreadOptions(cfg)
+	spreadOpts.SlippageBps = slippageBps
+	recalculate := func(at time.Time, tickers map[string]map[string]shared.TickerBidAsk, funding map[string]map[string]shared.FundingRateInfo) error {
+		clock := func() time.Time { return at }
+		spreadOpts.Now = clock
+		tickers, _ = arbitrage.FilterInvalidTickers(tickers, cfg.MaxPriceDevPct)
+		spreads := arbitrage.CalculateSpreads(tickers, funding, fx.RatesFromTickers(tickers, cfg.QuoteCurrencies), spreadOpts)
+		arbitrage.ApplyIndexPrices(spreads, tickers)
+		arbitrage.ApplyExpectedPnL(spreads, params, cfg.HoldingHorizon)
+		arbitrage.ApplyTargetNotional(spreads, params)
+		arbitrage.ApplyScores(spreads, arbitrage.WeightedScorer{Weights: arbitrage.ScoreWeights(cfg.ScoreWeights), Now: clock})
+		replay(at, spreads)
+		return nil
+	}
+
+	switch source {
+	case backtestSourceRecording:
+		return persistence.ReadRecordings(dir, func(rec persistence.Recording) error {
+			return recalculate(rec.Time, rec.TickersBySymbol(), rec.Funding)
+		})
+	case backtestSourceTickers:
+		return persistence.ReadExportedMarket(dir, recalculate)
+	default:
+		return persistence.ReadExportedSpreads(dir, func(_ uint64, at time.Time, spreads []arbitrage.Spread) error {
+			replay(at, spreads)
+			return nil
+		})
+	}
+}
+
+// averageDuration returns the average of count durations totalling totalSeconds.
+func averageDuration(totalSeconds float64, count int) time.Duration {
+	return time.Duration(totalSeconds / float64(count) * float64(time.Second)).Round(time.Second)
+}
@@ -0,0 +1,142 @@
+// Package chaos injects failures into exchange requests, sink publishes and broker connections, so the retries,
+// reconnects, buffering and health checks can be exercised end to end. It is for test deployments only.
+package chaos
+
+import (
+	"bytes"
+	"cex-price-diff-notifications/messaging"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrInjected is the cause of every injected failure, so they can be told apart in logs.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Options sets how often each failure is injected. Rates are fractions from 0 to 1; zero values inject nothing.
+type Options struct {
+	ErrorRate          float64       // Exchange requests failing with a network error or a 503
+	SlowRate           float64       // Exchange requests delayed by SlowDelay
+	SlowDelay          time.Duration // Delay of slow exchange requests
+	MalformedRate      float64       // Exchange responses with a truncated or garbled body
+	PublishErrorRate   float64       // Sink publishes failing
+	DisconnectInterval time.Duration // Mean time between broker disconnects (0 disables them)
+}
+
+// Injector injects failures per its options.
+type Injector struct {
+	opts Options
+}
+
+// New creates an injector.
+func New(opts Options) *Injector {
+	return &Injector{opts: opts}
+}
+
+// hit reports whether a failure with the given rate happens this time.
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// Transport wraps an HTTP transport (nil for the default one) with injected errors, delays and malformed bodies.
+func (i *Injector) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{opts: i.opts, next: next}
+}
+
+type transport struct {
+	opts Options
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if hit(t.opts.SlowRate) {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(t.opts.SlowDelay):
+		}
+	}
+	if hit(t.opts.ErrorRate) {
+		if rand.IntN(2) == 0 {
+			return nil, ErrInjected
+		}
+		return &http.Response{
+			Status:     strconv.Itoa(http.StatusServiceUnavailable) + " " + http.StatusText(http.StatusServiceUnavailable),
+			StatusCode: http.StatusServiceUnavailable,
+			Proto:      req.Proto,
+			ProtoMajor: req.ProtoMajor,
+			ProtoMinor: req.ProtoMinor,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(bytes.NewBufferString(ErrInjected.Error())),
+			Request:    req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !hit(t.opts.MalformedRate) {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if rand.IntN(2) == 0 {
+		body = body[:len(body)/2] // Truncated, as if the connection dropped mid-response
+	} else {
+		body = []byte(`{"chaos":`) // Not the expected shape, nor valid JSON
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// Publisher wraps a sink so that some of its publishes fail.
+func (i *Injector) Publisher(p messaging.Publisher) messaging.Publisher {
+	if i.opts.PublishErrorRate <= 0 {
+		return p
+	}
+	return &publisher{Publisher: p, rate: i.opts.PublishErrorRate}
+}
+
+type publisher struct {
+	messaging.Publisher
+	rate float64
+}
+
+func (p *publisher) Publish(ctx context.Context, event messaging.Event) error {
+	if hit(p.rate) {
+		return ErrInjected
+	}
+	return p.Publisher.Publish(ctx, event)
+}
+
+// RunDisconnects calls disconnect at random intervals averaging DisconnectInterval, e.g., to drop a broker
+// connection. It blocks until ctx is cancelled and should be run in a goroutine.
+func (i *Injector) RunDisconnects(ctx context.Context, name string, disconnect func() error) {
+	if i.opts.DisconnectInterval <= 0 {
+		return
+	}
+	for {
+		// Exponential intervals, so disconnects can come in bursts as real ones do
+		wait := time.Duration(rand.ExpFloat64() * float64(i.opts.DisconnectInterval))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		slog.Warn("Injecting a disconnect", "target", name)
+		if err := disconnect(); err != nil {
+			slog.Warn("Failed to inject a disconnect", "target", name, "error", err)
+		}
+	}
+}
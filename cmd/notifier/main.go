@@ -0,0 +1,284 @@
+// Command notifier consumes the arbitrage queue and forwards each opportunity to chat, email and push
+// channels (Telegram, Discord, Slack, SMTP, Pushover, ntfy), so users don't have to write their own consumer.
+// Run with: go run ./cmd/notifier
+package main
+
+import (
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/logging"
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/notifier"
+	"cex-price-diff-notifications/storage"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load .env file. It's not an error if it doesn't exist.
+	_ = godotenv.Load()
+
+	cfg, err := config.LoadNotifier()
+	if err != nil {
+		// Every invalid setting is listed, one per line
+		fmt.Fprintln(os.Stderr, "Invalid configuration:")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  "+line)
+		}
+		os.Exit(1)
+	}
+	slog.SetDefault(slog.New(logging.NewHandler(os.Stdout, logging.Options{
+		Level:        cfg.LogLevel,
+		Format:       cfg.LogFormat,
+		ModuleLevels: cfg.LogModuleLevels,
+	})))
+
+	// Chats subscribing through the bot keep their filters in Redis, across reloads and restarts
+	var subs *notifier.Subscriptions
+	if cfg.TelegramSubscriptions {
+		redisClient, err := storage.NewRedisClient()
+		if err != nil {
+			slog.Error("Failed to connect the Telegram subscriptions to Redis", "error", err)
+			os.Exit(1)
+		}
+		defer redisClient.Close()
+		subs, err = notifier.LoadSubscriptions(context.Background(), notifier.NewRedisSubscriptionStore(redisClient, cfg.RedisKey(cfg.TelegramSubscriptionsKey)))
+		if err != nil {
+			slog.Error("Failed to load the Telegram subscriptions", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	dispatcher, email, err := buildDispatcher(cfg, subs)
+	if err != nil {
+		slog.Error("Failed to set up the notification channels", "error", err)
+		os.Exit(1)
+	}
+	var current atomic.Pointer[notifier.Dispatcher]
+	current.Store(dispatcher)
+
+	rabbit, err := messaging.NewRabbitMQ(messaging.RabbitMQOptions{
+		URL:                 messaging.RabbitMQURLFromEnv(),
+		Durable:             cfg.RabbitMQDurable,
+		ReconnectMaxBackoff: 30 * time.Second,
+		MaxPriority:         uint8(min(max(cfg.RabbitMQMaxPriority, 0), 255)),
+		DeadLetterExchange:  cfg.RabbitMQDeadLetterExchange,
+		Namespace:           cfg.Namespace,
+	})
+	if err != nil {
+		slog.Error("Failed to set up RabbitMQ", "error", err)
+		os.Exit(1)
+	}
+	defer rabbit.Close()
+
+	if err := rabbit.DeclareQueue(cfg.Queue); err != nil {
+		slog.Error("Failed to declare the notifier queue", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Exchange != "" {
+		if err := rabbit.DeclareTopicExchange(cfg.Exchange); err != nil {
+			slog.Error("Failed to declare the RabbitMQ exchange", "error", err)
+			os.Exit(1)
+		}
+		for _, binding := range cfg.Bindings {
+			if err := rabbit.BindQueue(cfg.Queue, cfg.Exchange, binding); err != nil {
+				slog.Error("Failed to bind the notifier queue", "binding", binding, "error", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// The bot answers the subscription commands with the startup token; a reload doesn't restart it
+	if subs != nil {
+		go notifier.NewTelegramBot(cfg.TelegramBotToken, subs, cfg.TelegramFilter.MinEntrySpread).Run(ctx)
+	}
+
+	// The email digest runs until a reload replaces the email channel
+	emailCtx, stopEmail := context.WithCancel(ctx)
+	if email != nil {
+		go email.Run(emailCtx)
+	}
+
+	// SIGHUP reloads the channels, their filters and templates from the config file and the environment.
+	// Cooldowns and the pending email digest start over. The queue and RabbitMQ settings need a restart.
+	// Tokens rotated in the secret store reload the channels the same way.
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	if cfg.Secrets != nil && cfg.SecretsRefresh > 0 {
+		go cfg.Secrets.Run(ctx, cfg.SecretsRefresh, func() {
+			select {
+			case hupChan <- syscall.SIGHUP:
+			default: // A reload is already pending
+			}
+		})
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				stopEmail()
+				return
+			case <-hupChan:
+			}
+			reloaded, err := config.LoadNotifier()
+			if err != nil {
+				slog.Error("Failed to reload the configuration, keeping the current one", "error", err)
+				continue
+			}
+			dispatcher, email, err := buildDispatcher(reloaded, subs)
+			if err != nil {
+				slog.Error("Failed to reload the notification channels, keeping the current ones", "error", err)
+				continue
+			}
+			current.Store(dispatcher)
+			stopEmail()
+			emailCtx, stopEmail = context.WithCancel(ctx)
+			if email != nil {
+				go email.Run(emailCtx)
+			}
+			slog.Info("Configuration reloaded", "channels", dispatcher.Len())
+		}
+	}()
+
+	slog.Info("Notifier started", "queue", cfg.Queue, "channels", dispatcher.Len())
+	for ctx.Err() == nil {
+		err := rabbit.Consume(ctx, cfg.Queue, func(body []byte) error {
+			return current.Load().Handle(ctx, body)
+		})
+		if err != nil {
+			slog.Error("Failed to consume alerts, retrying", "error", err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+			}
+		}
+	}
+	slog.Info("Shutdown signal received, notifier stopped.")
+}
+
+// buildDispatcher creates the notification channels of the configuration, with their filters. The email
+// channel, if any, is also returned, as its digest must be run. Alerts are sent to the chats of subs, if not
+// nil, by their own filters.
+func buildDispatcher(cfg *config.NotifierConfig, subs *notifier.Subscriptions) (*notifier.Dispatcher, *notifier.Email, error) {
+	dispatcher := notifier.NewDispatcher()
+	if cfg.TelegramBotToken != "" {
+		tmpl, err := parseTemplate("telegram", cfg.TelegramTemplate)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, chatID := range cfg.TelegramChatIDs {
+			telegram := notifier.NewTelegram(cfg.TelegramBotToken, chatID)
+			telegram.SetTemplate(tmpl)
+			dispatcher.Add(telegram, cfg.TelegramFilter)
+		}
+		if subs != nil && cfg.TelegramSubscriptions {
+			subscribers := notifier.NewTelegramSubscribers(cfg.TelegramBotToken, subs, cfg.TelegramFilter.MinEntrySpread)
+			subscribers.SetTemplate(tmpl)
+			// Each subscription filters the symbols and spreads; the cooldown still applies to all of them
+			dispatcher.Add(subscribers, notifier.Filter{CooldownSeconds: cfg.TelegramFilter.CooldownSeconds})
+		}
+	}
+	for _, ch := range cfg.DiscordChannels {
+		var discord *notifier.Discord
+		switch {
+		case ch.WebhookURL != "":
+			discord = notifier.NewDiscordWebhook(ch.WebhookURL)
+		case ch.ChannelID != "" && cfg.DiscordBotToken != "":
+			discord = notifier.NewDiscordBot(cfg.DiscordBotToken, ch.ChannelID)
+		default:
+			slog.Warn("Skipping a Discord channel without a webhook URL or bot channel", "channel_id", ch.ChannelID)
+			continue
+		}
+		tmpl, err := parseTemplate(discord.Name(), cmp.Or(ch.Template, cfg.DiscordTemplate))
+		if err != nil {
+			return nil, nil, err
+		}
+		discord.SetTemplate(tmpl)
+		dispatcher.Add(discord, ch.Filter)
+	}
+	for _, ch := range cfg.SlackChannels {
+		var slack *notifier.Slack
+		switch {
+		case ch.WebhookURL != "":
+			slack = notifier.NewSlackWebhook(ch.WebhookURL)
+		case ch.ChannelID != "" && cfg.SlackBotToken != "":
+			slack = notifier.NewSlackBot(cfg.SlackBotToken, ch.ChannelID)
+		default:
+			slog.Warn("Skipping a Slack channel without a webhook URL or bot channel", "channel_id", ch.ChannelID)
+			continue
+		}
+		tmpl, err := parseTemplate(slack.Name(), cmp.Or(ch.Template, cfg.SlackTemplate))
+		if err != nil {
+			return nil, nil, err
+		}
+		slack.SetTemplate(tmpl)
+		dispatcher.Add(slack, ch.Filter)
+	}
+	var email *notifier.Email
+	if cfg.SMTPHost != "" && len(cfg.EmailTo) > 0 {
+		email = notifier.NewEmail(notifier.EmailOptions{
+			Host:             cfg.SMTPHost,
+			Port:             cfg.SMTPPort,
+			Username:         cfg.SMTPUsername,
+			Password:         cfg.SMTPPassword,
+			From:             cfg.EmailFrom,
+			To:               cfg.EmailTo,
+			InstantMinSpread: cfg.EmailInstantMinSpread,
+			DigestInterval:   cfg.EmailDigestInterval,
+			DigestSize:       cfg.EmailDigestSize,
+		})
+		tmpl, err := parseTemplate("email", cfg.EmailTemplate)
+		if err != nil {
+			return nil, nil, err
+		}
+		email.SetTemplate(tmpl)
+		dispatcher.Add(email, cfg.EmailFilter)
+	}
+	severity := notifier.SeverityThresholds{High: cfg.PushHighSpread, Urgent: cfg.PushUrgentSpread}
+	pushTemplate, err := parseTemplate("push", cfg.PushTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.PushoverAppToken != "" && cfg.PushoverUserKey != "" {
+		pushover := notifier.NewPushover(cfg.PushoverAppToken, cfg.PushoverUserKey, severity)
+		pushover.SetTemplate(pushTemplate)
+		dispatcher.Add(pushover, cfg.PushFilter)
+	}
+	if cfg.NtfyTopic != "" {
+		ntfy := notifier.NewNtfy(cfg.NtfyServer, cfg.NtfyTopic, cfg.NtfyToken, severity)
+		ntfy.SetTemplate(pushTemplate)
+		dispatcher.Add(ntfy, cfg.PushFilter)
+	}
+	if dispatcher.Len() == 0 {
+		return nil, nil, errors.New("no notification channels configured")
+	}
+	return dispatcher, email, nil
+
+}
+
+// parseTemplate parses a channel's message template. An empty text returns nil, keeping the channel's
+// built-in format.
+func parseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := notifier.ParseTemplate(name, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,80 @@
+// Command simulator serves synthetic Binance and Mexc futures APIs, for integration testing the scanner without
+// the live APIs. Point the scanner at it with SIMULATOR_BINANCE_URL and SIMULATOR_MEXC_URL, then force spreads
+// with a script or the control API, e.g.:
+//
+//	curl -X POST localhost:8089/divergences -d '{"symbol":"BTC/USDT:PERP","exchange":"Mexc","offset_pct":1.5,"duration_seconds":60}'
+//
+// Run with: go run ./cmd/simulator -symbols 200 -script divergences.json
+package main
+
+import (
+	"cex-price-diff-notifications/simulator"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	binanceAddr := flag.String("binance-addr", ":8081", "listen address of the Binance API")
+	mexcAddr := flag.String("mexc-addr", ":8082", "listen address of the Mexc API")
+	controlAddr := flag.String("control-addr", ":8089", "listen address of the control API")
+	symbols := flag.Int("symbols", 100, "number of synthetic symbols besides BTC and ETH")
+	step := flag.Duration("step", time.Second, "interval between two price moves")
+	volatility := flag.Float64("volatility", 0.0005, "standard deviation of a price move, as a fraction")
+	noise := flag.Float64("noise", 0.0003, "standard deviation of each exchange's deviation from the mid price, as a fraction")
+	spreadBps := flag.Float64("spread-bps", 2, "bid-ask spread of every book, in basis points")
+	fundingRate := flag.Float64("funding-rate", 0.0005, "maximum funding rate magnitude")
+	seed := flag.Uint64("seed", 1, "seed of the random walk")
+	scriptPath := flag.String("script", "", "JSON file of divergences to play from the start (empty plays none)")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sim := simulator.New(simulator.Options{
+		Symbols:     *symbols,
+		Volatility:  *volatility,
+		Noise:       *noise,
+		SpreadBps:   *spreadBps,
+		FundingRate: *fundingRate,
+		Seed:        *seed,
+	})
+	if *scriptPath != "" {
+		script, err := simulator.LoadScript(*scriptPath)
+		if err != nil {
+			slog.Error("Failed to load simulator script", "error", err)
+			os.Exit(1)
+		}
+		go sim.Play(ctx, script)
+	}
+	go sim.Run(ctx, *step)
+
+	servers := []*http.Server{
+		{Addr: *binanceAddr, Handler: sim.BinanceHandler()},
+		{Addr: *mexcAddr, Handler: sim.MexcHandler()},
+		{Addr: *controlAddr, Handler: sim.ControlHandler()},
+	}
+	for _, server := range servers {
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Failed to serve simulator API", "addr", server.Addr, "error", err)
+				stop()
+			}
+		}()
+	}
+	slog.Info("Simulator started", "binance", *binanceAddr, "mexc", *mexcAddr, "control", *controlAddr, "symbols", *symbols+2)
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, server := range servers {
+		_ = server.Shutdown(shutdownCtx)
+	}
+	slog.Info("Simulator stopped")
+}
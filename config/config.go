@@ -0,0 +1,920 @@
+package config
+
+import (
+	"cex-price-diff-notifications/logging"
+	"cex-price-diff-notifications/maintenance"
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/secrets"
+	"cex-price-diff-notifications/wallet"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Publish modes select what is sent to the arbitrage queue each cycle.
+const (
+	PublishModeRaw       = "raw"       // Every spread, every cycle
+	PublishModeLifecycle = "lifecycle" // Opened/updated/closed events per opportunity
+	PublishModeSnapshot  = "snapshot"  // One message per cycle with the full ranked array of opportunities
+	PublishModeDiff      = "diff"      // One message per cycle with the opportunities added, removed or materially changed
+
+	PublishBackendRabbitMQ = "rabbitmq" // Spread events go to the RabbitMQ queue or topic exchange
+	PublishBackendKafka    = "kafka"    // Spread events go to a Kafka topic keyed by unified symbol
+	PublishBackendNATS     = "nats"     // Spread events go to NATS JetStream subjects per symbol
+	PublishBackendRedis    = "redis"    // Spread events go to a Redis Stream and/or pub/sub channel
+	PublishBackendFile     = "file"     // Spread events are appended to a JSON lines file
+	PublishBackendMQTT     = "mqtt"     // Spread events go to MQTT topics per symbol
+	PublishBackendWebhook  = "webhook"  // Spread events are POSTed to HTTP callbacks
+	PublishBackendRules    = "rules"    // Spread events are routed to the destinations of matching rules
+
+	SpreadModeFull        = "full"        // Recalculate every symbol each cycle
+	SpreadModeIncremental = "incremental" // Recompute only the base assets whose tickers changed
+)
+
+// SymbolOverride replaces selected global parameters for one symbol or base asset. Nil or missing fields fall
+// back to the defaults.
+type SymbolOverride struct {
+	MinEntrySpreadPct *float64           `json:"min_entry_spread_pct,omitempty"`
+	TargetNotionalUSD *float64           `json:"target_notional_usd,omitempty"`
+	TakerFeesBps      map[string]float64 `json:"taker_fees_bps,omitempty"` // Merged per exchange over the default fees
+}
+
+// ScoreWeights are the weights of each component of the composite opportunity score.
+type ScoreWeights struct {
+	NetSpread  float64 // Per percent of entry spread after fees and latency haircut
+	FundingPnL float64 // Per percent of funding accrued over the PnL horizon
+	Liquidity  float64 // Per order of magnitude of the thinner leg's 24h volume in USD
+	ZScore     float64 // Per standard deviation above the pair's historical mean
+	Staleness  float64 // Subtracted per second of age of the older leg's ticker
+	Momentum   float64 // Per bps per minute the entry spread widens (negative while it converges)
+}
+
+// DefaultScoreWeights ranks mostly by net spread, with the other components as tie-breakers.
+var DefaultScoreWeights = ScoreWeights{
+	NetSpread:  1,
+	FundingPnL: 0.5,
+	Liquidity:  0.1,
+	ZScore:     0.1,
+	Staleness:  0.05,
+	Momentum:   0.01,
+}
+
+// Config holds the application settings loaded from the environment.
+type Config struct {
+	QuoteCurrencies             []string                  // Ordered list of quote currencies to monitor (e.g., "USDT", "USDC")
+	MaxTickerAge                time.Duration             // Tickers older than this are ignored in spread calculation (0 disables)
+	MaxPriceDevPct              float64                   // Tickers deviating more than this % from the cross-exchange median are rejected (0 disables)
+	FundingHistoryLimit         int                       // Number of past settlements used for trailing funding averages
+	FundingHistoryTTL           time.Duration             // How long fetched funding history is considered fresh
+	FundingWindow               time.Duration             // Settlements within this window count as "soon" (0 disables tagging)
+	FundingWindowMode           string                    // "tag", "only" or "boost"
+	SlippageBps                 float64                   // Assumed slippage per leg in basis points
+	SpreadAllDirections         bool                      // Evaluate both directions of every exchange pair (legacy behavior)
+	TakerFeesBps                map[string]float64        // Taker fee per exchange in basis points
+	HoldingHorizon              time.Duration             // Holding horizon for expected PnL projection
+	FundingArbEnabled           bool                      // Scan for funding-only arbitrage opportunities
+	FundingArbMinAPR            float64                   // Minimum annualized funding differential, in percent
+	FundingArbMaxPriceSpread    float64                   // Maximum tolerated entry cost for funding opportunities, in percent
+	FundingDivergenceAlerts     bool                      // Publish funding_divergence events when the funding of a pair drifts apart, whatever the price spread
+	FundingDivergenceMinAPR     float64                   // Annualized funding differential, in percent, from which a pair alerts
+	FundingScheduleHorizon      time.Duration             // How far ahead the funding settlement calendar looks, by default on the API and in events
+	FundingScheduleInterval     time.Duration             // How often a funding_schedule event is published (0 disables)
+	FundingMaxAge               time.Duration             // Funding rates of an exchange not updated for this long are stale, marking its spreads
+	FundingWarmupTimeout        time.Duration             // Longest wait at startup for fresh funding rates on every exchange before publishing (0 disables)
+	ReportPeriods               []string                  // Summary reports generated from Postgres at the end of each period ("daily", "weekly")
+	ReportDir                   string                    // Directory the reports are also written to as JSON
+	ReportTop                   int                       // Opportunities and pairs listed in a report
+	FeedbackQueue               string                    // RabbitMQ queue consumers report acted/skipped opportunities on, for the reports (empty disables)
+	FeedbackRetention           time.Duration             // How long published opportunities are remembered to match the reports with
+	TriangularEnabled           bool                      // Scan spot markets for triangular arbitrage within each exchange
+	TriangularMinProfit         float64                   // Minimum net cycle profit, in percent
+	SpotTakerFeesBps            map[string]float64        // Spot taker fee per exchange in basis points
+	FeeTierRefresh              time.Duration             // How often the accounts' fee tiers replacing the taker fees are fetched, with API keys (0 disables)
+	SpreadHistoryWindow         time.Duration             // Rolling window of spread history used for statistics
+	SpreadHistoryMinSamples     int                       // Minimum samples before statistics are attached
+	SpreadHistoryRedis          bool                      // Mirror spread history to Redis so it survives restarts
+	SpreadMomentumWindow        time.Duration             // Recent span each pair's spread momentum is fitted over (0 disables)
+	SpreadMomentumStableBps     float64                   // Momentum, in bps per minute, under which a pair's spread is reported stable
+	SpreadCandlesEnabled        bool                      // Aggregate each pair's entry spread into OHLC candles, served by the API and stored in Postgres
+	SpreadCandlesTimeframes     []time.Duration           // Timeframes of the spread candles
+	SpreadCandlesLimit          int                       // Candles kept in memory per pair and timeframe
+	SpreadAnomalyEnabled        bool                      // Flag sudden spikes of a pair's entry spread as anomalies
+	SpreadAnomalyAlpha          float64                   // Weight of the latest spread in the pair's moving mean and deviation, in (0, 1)
+	SpreadAnomalyThreshold      float64                   // Deviations from the moving mean from which a spread is an anomaly
+	SpreadAnomalyMinSamples     int                       // Observations of a pair before it is scored
+	DynamicThresholdPercentile  float64                   // Only publish spreads above this percentile of their pair's history (0 disables)
+	PublishMode                 string                    // One of the PublishMode* constants
+	PublishSnapshot             bool                      // Also publish the per-cycle snapshot when streaming raw or lifecycle events
+	PublishMaxDataAge           time.Duration             // Events whose market data is older when published are dropped (0 disables)
+	PublishHeartbeat            bool                      // Publish each cycle's statistics to the arbitrage_stats queue, for monitoring
+	LifecycleMaterialChangeBps  float64                   // Entry spread change that triggers an updated event in lifecycle mode
+	DiffMaterialChangeBps       float64                   // Entry spread change since last sent that includes an opportunity in the next delta in diff mode
+	ConvergenceEnabled          bool                      // Track how long opportunities last, attached to spreads and served on the API's /api/convergence endpoint
+	ConvergenceSamples          int                       // Most recent opportunity lifetimes kept per pair
+	ConvergenceMinSamples       int                       // Lifetimes a pair needs before its spreads carry their distribution
+	MinEntrySpreadPct           float64                   // Spreads below this entry spread (in percent) are not published
+	MaxPublishedSpreads         int                       // Maximum spreads published per cycle (0 means unlimited)
+	MinVolumeUSD                float64                   // Legs with less 24h quote volume are excluded from spreads (0 disables)
+	MinTopOfBookUSD             float64                   // Legs with a smaller best bid/ask value are excluded from spreads (0 disables)
+	DefaultNotionalUSD          float64                   // Default intended position size per leg
+	OrderSizeDepth              bool                      // Cap the order sizes of published spreads by their legs' order book depth, fetched each cycle
+	OrderSizeMaxSlippageBps     float64                   // Slippage from the best price within which order book depth counts towards an order size
+	SymbolOverrides             map[string]SymbolOverride // Per-symbol (or per-base) parameter overrides
+	SpreadWorkers               int                       // Worker pool size for spread calculation (0 uses GOMAXPROCS)
+	SpreadMode                  string                    // One of the SpreadMode* constants
+	SpotArbEnabled              bool                      // Scan spot-spot spreads between exchanges (requires transferring the base asset)
+	SpotTransferSuppress        bool                      // Drop spot spreads whose transfer route is infeasible instead of only annotating them
+	WalletStatusRefresh         time.Duration             // How often deposit/withdrawal status is re-fetched
+	TransferTimes               map[string]time.Duration  // Typical transfer time per network or "ASSET/NETWORK", until learned from history
+	TransferTimeDefault         time.Duration             // Transfer time of networks neither learned nor configured
+	TransferHistoryRefresh      time.Duration             // How often the accounts' transfer history is fetched to learn transfer times (0 disables)
+	NetworkStatusEvents         bool                      // Poll deposit/withdrawal status even without spot arbitrage, publishing network_status_changed events
+	ContractRefreshInterval     time.Duration             // How often contract specs are re-fetched, detecting listed and delisted symbols
+	ListingEvents               bool                      // Publish a new_listing event for each symbol an exchange lists
+	BinanceAPIKey               string                    // Binance API key, needed for wallet status, execution and account tracking
+	BinanceAPISecret            string                    // Binance API secret
+	MexcAPIKey                  string                    // Mexc API key, needed for wallet status, execution and account tracking
+	MexcAPISecret               string                    // Mexc API secret
+	Secrets                     *secrets.Store            // Secret store the settings above may come from, re-fetched to rotate the API keys (nil if none)
+	SecretsRefresh              time.Duration             // How often the secret store is re-fetched (0 disables)
+	LatencyPenaltyPctPerSec     float64                   // Entry spread haircut per second of the slower leg's latency, in percent (0 disables)
+	LatencyPingInterval         time.Duration             // How often exchanges are pinged to measure latency
+	MaintenanceWindows          []maintenance.Window      // Scheduled maintenance, during which an exchange's fetches are paused
+	MaintenanceStatusInterval   time.Duration             // How often the exchanges' system status is polled for unscheduled maintenance (0 disables)
+	MaintenanceLead             time.Duration             // How long before a scheduled maintenance spreads on its exchange are annotated (0 disables)
+	OutageDetectionEnabled      bool                      // Exclude exchanges whose data went stale or whose fetches keep failing from spread calculation
+	OutageMaxDataAge            time.Duration             // Longest time since an exchange's last successful ticker fetch before it is degraded (0 disables)
+	OutageErrorRate             float64                   // Failed share of an exchange's recent ticker fetches that degrades it (0 disables)
+	OutageErrorWindow           int                       // Number of recent ticker fetches the error rate is measured over
+	OutageRecoveryFetches       int                       // Consecutive successful ticker fetches before a degraded exchange is included again
+	ScoreWeights                ScoreWeights              // Weights of the composite opportunity score
+	ScorerURL                   string                    // External model endpoint rescoring each cycle's spreads (empty ranks by ScoreWeights only)
+	ScorerTimeout               time.Duration             // Timeout of a request to the external scorer
+	EnrichmentEnabled           bool                      // Attach display names, trading pages and asset metadata to events
+	EnrichmentAssetsRefresh     time.Duration             // How often asset metadata is fetched from CoinGecko (0 disables asset metadata)
+	CoinGeckoURL                string                    // CoinGecko API base URL
+	CoinGeckoAPIKey             string                    // CoinGecko demo or pro API key (optional)
+	CoinGeckoIDs                map[string]string         // CoinGecko ID per ticker shared by several coins, e.g., "PEPE" -> "pepe"
+	HysteresisCycles            int                       // Consecutive cycles above the entry threshold before a spread is published (0 disables hysteresis)
+	HysteresisExitSpreadPct     float64                   // Published spreads keep publishing until they drop below this entry spread, in percent
+	AlertCooldown               time.Duration             // An already published opportunity is not republished within this period (0 disables deduplication)
+	AlertMinChangeBps           float64                   // Entry spread change, in basis points, that republishes an opportunity during its cooldown (0 never does)
+	RabbitMQConfigured          bool                      // RABBITMQ_HOST or RABBITMQ_DEFAULT_USER is set; without, RabbitMQ messages go to an in-memory queue printed on stdout
+	RedisConfigured             bool                      // REDIS_ADDR or REDIS_PASSWORD is set; without, caches are kept in process
+	RabbitMQDurable             bool                      // Declare queues as durable (existing non-durable queues must be deleted first)
+	RabbitMQPersistent          bool                      // Publish messages with persistent delivery mode
+	RabbitMQConfirms            bool                      // Use publisher confirms and resend nacked messages
+	RabbitMQConfirmTimeout      time.Duration             // How long to wait for a publisher confirm
+	RabbitMQMaxResends          int                       // Resend attempts for nacked or unconfirmed messages
+	RabbitMQExchange            string                    // Topic exchange for spreads, routed by symbol and exchange pair (empty publishes to the legacy queue only)
+	RabbitMQReconnectMaxBackoff time.Duration             // Upper bound of the exponential backoff between reconnect attempts
+	RabbitMQBufferSize          int                       // Messages buffered while disconnected from RabbitMQ (oldest dropped first)
+	RabbitMQMessageTTL          time.Duration             // Expiry of published messages; stale spreads are dropped by the broker (0 disables)
+	RabbitMQMaxPriority         int                       // Maximum priority of the declared queues (0 disables; existing queues must be deleted first)
+	RabbitMQPriorityScalePct    float64                   // Entry spread, in percent, that gets the maximum priority
+	RabbitMQDeadLetterExchange  string                    // Exchange receiving rejected, expired and malformed messages (empty disables; existing queues must be deleted first)
+	RabbitMQDeadLetterQueue     string                    // Queue bound to the dead-letter exchange
+	EventEnvelope               bool                      // Wrap published payloads in a versioned envelope with event metadata
+	InstanceID                  string                    // Producer instance ID reported in event envelopes
+	Namespace                   string                    // Deployment tag (e.g., "prod") prefixing queues, routing keys, topics and Redis keys, and labelling metrics (empty disables)
+	OpportunityIDBucket         time.Duration             // Open times hashed into opportunity IDs are truncated to it, so HA instances agree on the IDs
+	PublishSinks                []string                  // Sinks receiving spread events, each one of the PublishBackend* constants
+	PublishSinkQueueSize        int                       // Events buffered per sink before new ones are dropped
+	FileSinkPath                string                    // Path of the JSON lines file written by the file sink
+	KafkaBrokers                []string                  // Kafka bootstrap brokers (host:port)
+	KafkaTopic                  string                    // Kafka topic for spread events
+	NATSURL                     string                    // NATS server URL
+	NATSSubjectPrefix           string                    // Subject prefix; spreads go to "<prefix>.<BASE>-<QUOTE>"
+	NATSStream                  string                    // JetStream stream capturing the spread subjects
+	NATSCreateStream            bool                      // Create or update the stream on startup
+	NATSRetryAttempts           int                       // Publish retries when JetStream doesn't ack
+	RedisStreamEnabled          bool                      // Append spread events to a Redis Stream
+	RedisStream                 string                    // Redis Stream for spread events
+	RedisStreamMaxLen           int64                     // Approximate maximum length of the Redis Stream (0 means unbounded)
+	RedisPubSubEnabled          bool                      // Broadcast spread events on a Redis pub/sub channel
+	RedisChannel                string                    // Redis pub/sub channel for spread events
+	RedisLatestEnabled          bool                      // Write each cycle's ranked opportunities to a Redis key
+	RedisLatestKey              string                    // Redis key holding the latest snapshot
+	RedisLatestTTL              time.Duration             // Expiry of the latest snapshot, so a stalled scanner leaves no stale data
+	BinanceFundingCacheTTL      time.Duration             // Expiry of Binance funding rates persisted to Redis
+	MexcFundingCacheTTL         time.Duration             // Expiry of Mexc funding rates persisted to Redis
+	MQTTBrokerURL               string                    // MQTT broker URL (e.g., "tcp://localhost:1883")
+	MQTTClientID                string                    // MQTT client ID
+	MQTTUsername                string                    // MQTT username (empty for anonymous)
+	MQTTPassword                string                    // MQTT password
+	MQTTTopicPrefix             string                    // Topic prefix; spreads go to "<prefix>/<BASE>-<QUOTE>"
+	MQTTQoS                     int                       // MQTT quality of service (0, 1 or 2)
+	MQTTRetain                  bool                      // Retain the latest message of each topic on the broker
+	WebhookTargets              []messaging.WebhookTarget // Webhook URLs with their signing secrets and filters
+	WebhookTimeout              time.Duration             // Timeout of a single webhook request
+	WebhookMaxRetries           int                       // Webhook retries on network errors, 429 and 5xx responses
+	RulesFile                   string                    // JSON file of routing rules used by the rules sink
+	TelegramBotToken            string                    // Telegram bot token used by rules with Telegram destinations
+	PostgresURL                 string                    // Postgres connection URL for spread history (empty disables)
+	PostgresTimescale           bool                      // Enable TimescaleDB and store spreads and tickers in hypertables
+	PostgresStoreTickers        bool                      // Also store every cycle's raw tickers
+	InfluxWriteURL              string                    // Line protocol write endpoint for Grafana series (empty disables)
+	InfluxToken                 string                    // InfluxDB API token
+	InfluxTimeout               time.Duration             // Timeout of a single line protocol write
+	ClickHouseURL               string                    // ClickHouse HTTP interface for recording every spread (empty disables)
+	ClickHouseDatabase          string                    // ClickHouse database
+	ClickHouseUsername          string                    // ClickHouse user
+	ClickHousePassword          string                    // ClickHouse password
+	ClickHouseTable             string                    // ClickHouse table for spreads
+	ClickHouseBatchSize         int                       // Rows buffered before an insert is triggered
+	ClickHouseFlushInterval     time.Duration             // Longest time rows wait before being inserted
+	ClickHouseMaxBuffered       int                       // Rows kept while ClickHouse is unreachable (oldest dropped first)
+	ClickHouseAsyncInsert       bool                      // Use server-side asynchronous inserts
+	ClickHouseCreateTable       bool                      // Create the spreads table on startup
+	ClickHouseTimeout           time.Duration             // Timeout of a single ClickHouse request
+	ExportDir                   string                    // Directory of rotating spread export files (empty disables)
+	ExportFormat                string                    // Export file format: "jsonl" or "csv"
+	ExportGzip                  bool                      // Gzip the export files
+	ExportMaxBytes              int64                     // Rotate export files after this many uncompressed bytes (0 disables)
+	ExportRotateInterval        time.Duration             // Rotate export files this often, e.g., 1h (0 disables)
+	ExportTickers               bool                      // Also export every cycle's raw tickers and funding rates, for backtests
+	RetentionInterval           time.Duration             // How often retention jobs prune stored history
+	RetentionSpreads            time.Duration             // Age after which stored spreads are deleted from Postgres and ClickHouse (0 keeps them)
+	RetentionTickers            time.Duration             // Age after which stored tickers are deleted from Postgres (0 keeps them)
+	RetentionCandles            time.Duration             // Age after which stored spread candles are deleted from Postgres (0 keeps them)
+	RetentionExportFiles        time.Duration             // Age after which export files are deleted (0 keeps them)
+	RetentionRedisStream        time.Duration             // Age after which Redis Stream entries are trimmed (0 keeps them)
+	RetentionSpreadHistory      bool                      // Trim Redis spread history samples older than the history window
+	APIAddr                     string                    // Listen address of the HTTP API, e.g., ":8080" (empty disables)
+	WSEnabled                   bool                      // Stream opportunity lifecycle events on the API's /ws endpoint
+	MetricsEnabled              bool                      // Serve Prometheus metrics on the API's /metrics endpoint
+	ImpactEnabled               bool                      // Serve market order impact estimates from the exchanges' order books on the API's /api/impact endpoint
+	OrderBookDepth              int                       // Levels per side of the order books fetched for impact estimates
+	OrderBookMaxAge             time.Duration             // How long a fetched order book is reused for impact estimates (0 fetches every time)
+	HealthMaxAge                time.Duration             // Longest time without a cycle or ticker fetch before probes fail
+	AdminToken                  string                    // Bearer token of the API's /admin endpoints (empty disables them)
+	BlockedSymbols              []string                  // Symbol globs never published, e.g., "LUNA" or "*/EUR:PERP"; changeable at runtime
+	PollInterval                time.Duration             // Interval of the main loop's cycles
+	PollAdaptive                bool                      // Adapt the interval of the cycles between PollIntervalMin and PollIntervalMax, from POLL_INTERVAL
+	PollIntervalMin             time.Duration             // Shortest adapted interval, reached while the top spreads are volatile
+	PollIntervalMax             time.Duration             // Longest adapted interval, reached while the exchanges signal rate limit pressure
+	PollVolatilityBps           float64                   // Mean move of the top spreads between cycles, in basis points, that speeds polling up
+	PollRateLimitPressure       float64                   // Fraction of an exchange's rate limit used, from 0 to 1, that slows polling down
+	BinanceTickerInterval       time.Duration             // How often Binance tickers are fetched; cycles in between reuse the last ones (0 fetches every cycle)
+	MexcTickerInterval          time.Duration             // How often Mexc tickers are fetched; cycles in between reuse the last ones (0 fetches every cycle)
+	GmxEnabled                  bool                      // Add GMX v2 perp prices and funding to the spreads (read-only: GMX legs can't be executed)
+	GmxTickerInterval           time.Duration             // How often GMX prices and funding are fetched; cycles in between reuse the last ones (0 fetches every cycle)
+	BinanceFundingInterval      time.Duration             // How often Binance funding rates are fetched (0 fetches every cycle)
+	BinanceFundingStream        bool                      // Stream Binance funding rates from the mark price stream, fetching them only while it is down
+	MexcFundingInterval         time.Duration             // How often Mexc funding rates are fetched
+	MexcFundingStream           bool                      // Stream Mexc funding rates from the futures websocket between fetches
+	MexcStreamSilenceTimeout    time.Duration             // Silence after which a Mexc websocket connection is reconnected
+	BinanceVolumeInterval       time.Duration             // How often Binance 24h volumes are fetched
+	RateLimitBackoff            time.Duration             // Pause of an exchange's fetches after it rate limits them, unless it asks for another
+	LogLevel                    slog.Level                // Minimum level of the logs (debug, info, warn or error)
+	LogFormat                   string                    // "text" (colored) or "json" (for log aggregation)
+	LogModuleLevels             map[string]slog.Level     // Per-module level overrides, e.g., "adapters:warn,arbitrage:info"
+	LogWarningDetail            int                       // Occurrences of each repeated per-symbol warning logged in full per cycle; the rest are summarized
+	TracingEndpoint             string                    // OTLP/HTTP collector URL cycle traces are exported to, e.g., "http://localhost:4318" (empty disables)
+	TracingSampleRatio          float64                   // Fraction of the cycles traced, from 0 to 1
+	LeaderElection              bool                      // Only publish while holding a lease in Redis, so redundant instances can run
+	LeaderKey                   string                    // Redis key of the leader lease, shared by the instances
+	LeaderLeaseTTL              time.Duration             // Time without a renewal after which another instance takes over
+	PublishDedupEnabled         bool                      // Claim each opportunity in Redis before publishing, so only one instance publishes its events
+	PublishDedupKeyPrefix       string                    // Prefix of the Redis keys of the claims, followed by the opportunity ID
+	PublishDedupTTL             time.Duration             // Time after its owner's last event before another instance may claim an opportunity
+	ShardIndex                  int                       // Shard of the symbols this instance scans, from 0
+	ShardCount                  int                       // Number of instances splitting the symbols (1 disables sharding)
+	ChaosEnabled                bool                      // Inject failures to exercise resilience; for test deployments only
+	ChaosErrorRate              float64                   // Fraction of exchange requests failing with a network error or a 503
+	ChaosSlowRate               float64                   // Fraction of exchange requests delayed by ChaosSlowDelay
+	ChaosSlowDelay              time.Duration             // Delay of the slowed exchange requests
+	ChaosMalformedRate          float64                   // Fraction of exchange responses with a truncated or garbled body
+	ChaosPublishErrorRate       float64                   // Fraction of sink publishes failing
+	ChaosDisconnectInterval     time.Duration             // Mean time between RabbitMQ disconnects (0 disables them)
+	RecordDir                   string                    // Directory of the per-cycle market data recordings (empty disables)
+	RecordRotateInterval        time.Duration             // Start a new recording file this often (0 disables rotation)
+	RecordRetention             time.Duration             // Age after which recording files are deleted (0 keeps them)
+	ExecutionEnabled            bool                      // Allow the "execute" command to place real orders with the API keys
+	ExecutionMaxNotional        float64                   // Largest notional per leg of an executed opportunity, in the quote currency
+	ExecutionOrderType          string                    // Order type of both legs: "limit" or "market"
+	ExecutionLimitSlippageBps   float64                   // How far past the touch limit orders are priced, in basis points
+	ExecutionFillTimeout        time.Duration             // Longest wait for both legs to fill before the rest is cancelled and unwound
+	ExecutionLeverage           int                       // Leverage of Mexc positions (Binance uses the account's setting)
+	AccountTracking             bool                      // Poll futures balances and positions with the API keys and mark spreads tradable or not
+	AccountRefresh              time.Duration             // How often balances and positions are polled
+	AccountLeverage             float64                   // Leverage assumed when checking the margin of both legs
+	AccountSkipUntradable       bool                      // Don't publish spreads the available margin can't cover
+	RiskMaxSymbolNotional       float64                   // Largest notional per leg of the open positions in one symbol (0 disables)
+	RiskMaxOpenPositions        int                       // Most positions open at once (0 disables)
+	RiskMaxExchangeExposure     map[string]float64        // Largest notional of the open legs per exchange, e.g., "Binance:5000,Mexc:2000"
+	RiskKillSwitch              bool                      // Refuse to open any position
+	RiskKillSwitchFile          string                    // Refuse to open any position while this file exists
+	ExitSignalsEnabled          bool                      // Publish exit signals for entered opportunities
+	ExitSignalsMinProfitPct     float64                   // Entry plus exit spread after fees plus funding, in percent, that signals a profitable exit
+	ExitSignalsFundingWindow    time.Duration             // Signal a settlement charging the position this long before it (0 disables)
+	ExitSignalsSimulate         bool                      // Enter every opened opportunity, as a simulated position
+	ExitSignalsMaxHold          time.Duration             // Stop watching simulated entries after this long (0 keeps them)
+	BinanceTestnet              bool                      // Send the Binance requests, orders included, to the Binance testnets (Mexc has none)
+	SimulatorBinanceURL         string                    // Fake exchange serving the Binance requests instead (empty uses Binance); for test deployments only
+	SimulatorMexcURL            string                    // Fake exchange serving the Mexc requests instead (empty uses Mexc); for test deployments only
+}
+
+// PublishesTo reports whether spread events are sent to the given sink.
+func (c *Config) PublishesTo(sink string) bool {
+	return slices.Contains(c.PublishSinks, sink)
+}
+
+// Namespaced returns name in the deployment's namespace, "<namespace><sep><name>", or name without one.
+func (c *Config) Namespaced(name, sep string) string {
+	return namespaced(c.Namespace, name, sep)
+}
+
+// RedisKey returns a Redis key or key prefix in the deployment's namespace, e.g., "prod:arb:leader".
+func (c *Config) RedisKey(key string) string {
+	return namespaced(c.Namespace, key, ":")
+}
+
+// namespaced prefixes name with namespace and sep, unless namespace is empty.
+func namespaced(namespace, name, sep string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + sep + name
+}
+
+// Load reads the application settings from the YAML config file named by CONFIG_FILE (config.yaml if it
+// exists) and from environment variables, which take precedence, applying defaults where unset. It fails
+// listing every invalid setting.
+func Load() (*Config, error) {
+	if err := beginLoad(os.Getenv("CONFIG_FILE"), "config.yaml"); err != nil {
+		return nil, err
+	}
+	markRead(externalKeys...)
+	store, err := loadSecrets()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		QuoteCurrencies:             getEnvList("QUOTE_CURRENCIES", []string{"USDT", "USDC"}),
+		MaxTickerAge:                getEnvDuration("TICKER_MAX_AGE", 30*time.Second),
+		MaxPriceDevPct:              getEnvFloat("MAX_PRICE_DEVIATION_PCT", 10),
+		FundingHistoryLimit:         getEnvInt("FUNDING_HISTORY_LIMIT", 21),
+		FundingHistoryTTL:           getEnvDuration("FUNDING_HISTORY_TTL", time.Hour),
+		FundingWindow:               getEnvDuration("FUNDING_WINDOW", 30*time.Minute),
+		FundingWindowMode:           getEnv("FUNDING_WINDOW_MODE", "tag"),
+		SlippageBps:                 getEnvFloat("SLIPPAGE_BPS", 5),
+		SpreadAllDirections:         getEnvBool("SPREAD_ALL_DIRECTIONS", false),
+		TakerFeesBps:                getEnvFloatMap("TAKER_FEES_BPS", map[string]float64{"Binance": 5, "Mexc": 2, "GMX": 6}),
+		HoldingHorizon:              getEnvDuration("HOLDING_HORIZON", 24*time.Hour),
+		FundingArbEnabled:           getEnvBool("FUNDING_ARB_ENABLED", false),
+		FundingArbMinAPR:            getEnvFloat("FUNDING_ARB_MIN_APR", 20),
+		FundingArbMaxPriceSpread:    getEnvFloat("FUNDING_ARB_MAX_PRICE_SPREAD_PCT", 0.1),
+		FundingDivergenceAlerts:     getEnvBool("FUNDING_DIVERGENCE_ALERTS", false),
+		FundingDivergenceMinAPR:     getEnvFloat("FUNDING_DIVERGENCE_MIN_APR", 50),
+		FundingScheduleHorizon:      getEnvDuration("FUNDING_SCHEDULE_HORIZON", 8*time.Hour),
+		FundingScheduleInterval:     getEnvDuration("FUNDING_SCHEDULE_INTERVAL", time.Hour),
+		FundingMaxAge:               getEnvDuration("FUNDING_MAX_AGE", 15*time.Minute),
+		FundingWarmupTimeout:        getEnvDuration("FUNDING_WARMUP_TIMEOUT", 2*time.Minute),
+		ReportPeriods:               getEnvStrings("REPORT_PERIODS", nil),
+		ReportDir:                   getEnv("REPORT_DIR", "reports"),
+		ReportTop:                   getEnvInt("REPORT_TOP", 10),
+		FeedbackQueue:               getEnv("FEEDBACK_QUEUE", ""),
+		FeedbackRetention:           getEnvDuration("FEEDBACK_RETENTION", 24*time.Hour),
+		TriangularEnabled:           getEnvBool("TRIANGULAR_ENABLED", false),
+		TriangularMinProfit:         getEnvFloat("TRIANGULAR_MIN_PROFIT_PCT", 0.1),
+		SpotTakerFeesBps:            getEnvFloatMap("SPOT_TAKER_FEES_BPS", map[string]float64{"Binance": 10, "Mexc": 5}),
+		FeeTierRefresh:              getEnvDuration("FEE_TIER_REFRESH", 24*time.Hour),
+		SpreadHistoryWindow:         getEnvDuration("SPREAD_HISTORY_WINDOW", time.Hour),
+		SpreadHistoryMinSamples:     getEnvInt("SPREAD_HISTORY_MIN_SAMPLES", 30),
+		SpreadHistoryRedis:          getEnvBool("SPREAD_HISTORY_REDIS", false),
+		SpreadMomentumWindow:        getEnvDuration("SPREAD_MOMENTUM_WINDOW", 5*time.Minute),
+		SpreadMomentumStableBps:     getEnvFloat("SPREAD_MOMENTUM_STABLE_BPS", 0.5),
+		SpreadCandlesEnabled:        getEnvBool("SPREAD_CANDLES_ENABLED", true),
+		SpreadCandlesTimeframes:     getEnvDurations("SPREAD_CANDLES_TIMEFRAMES", []time.Duration{time.Minute, 5 * time.Minute, time.Hour}),
+		SpreadCandlesLimit:          getEnvInt("SPREAD_CANDLES_LIMIT", 120),
+		SpreadAnomalyEnabled:        getEnvBool("SPREAD_ANOMALY_ENABLED", false),
+		SpreadAnomalyAlpha:          getEnvFloat("SPREAD_ANOMALY_ALPHA", 0.1),
+		SpreadAnomalyThreshold:      getEnvFloat("SPREAD_ANOMALY_THRESHOLD", 4),
+		SpreadAnomalyMinSamples:     getEnvInt("SPREAD_ANOMALY_MIN_SAMPLES", 30),
+		DynamicThresholdPercentile:  getEnvFloat("DYNAMIC_THRESHOLD_PERCENTILE", 0),
+		PublishMode:                 getEnv("PUBLISH_MODE", PublishModeRaw),
+		PublishSnapshot:             getEnvBool("PUBLISH_SNAPSHOT", false),
+		PublishMaxDataAge:           getEnvDuration("PUBLISH_MAX_DATA_AGE", 0),
+		PublishHeartbeat:            getEnvBool("PUBLISH_HEARTBEAT", false),
+		LifecycleMaterialChangeBps:  getEnvFloat("LIFECYCLE_MATERIAL_CHANGE_BPS", 5),
+		DiffMaterialChangeBps:       getEnvFloat("DIFF_MATERIAL_CHANGE_BPS", 5),
+		ConvergenceEnabled:          getEnvBool("CONVERGENCE_ENABLED", true),
+		ConvergenceSamples:          getEnvInt("CONVERGENCE_SAMPLES", 100),
+		ConvergenceMinSamples:       getEnvInt("CONVERGENCE_MIN_SAMPLES", 5),
+		MinEntrySpreadPct:           getEnvFloat("MIN_ENTRY_SPREAD_PCT", 0.1),
+		MaxPublishedSpreads:         getEnvInt("MAX_PUBLISHED_SPREADS", 50),
+		MinVolumeUSD:                getEnvFloat("MIN_VOLUME_USD", 100_000),
+		MinTopOfBookUSD:             getEnvFloat("MIN_TOP_OF_BOOK_USD", 0),
+		DefaultNotionalUSD:          getEnvFloat("DEFAULT_NOTIONAL_USD", 1000),
+		OrderSizeDepth:              getEnvBool("ORDER_SIZE_DEPTH", false),
+		OrderSizeMaxSlippageBps:     getEnvFloat("ORDER_SIZE_MAX_SLIPPAGE_BPS", 10),
+		SymbolOverrides:             getEnvSymbolOverrides("SYMBOL_OVERRIDES"),
+		SpreadWorkers:               getEnvInt("SPREAD_WORKERS", 0),
+		SpreadMode:                  getEnv("SPREAD_MODE", SpreadModeFull),
+		SpotArbEnabled:              getEnvBool("SPOT_ARB_ENABLED", false),
+		SpotTransferSuppress:        getEnvBool("SPOT_TRANSFER_SUPPRESS", false),
+		WalletStatusRefresh:         getEnvDuration("WALLET_STATUS_REFRESH", 30*time.Minute),
+		TransferTimes:               getEnvDurationMap("TRANSFER_TIMES", wallet.DefaultTransferTimes),
+		TransferTimeDefault:         getEnvDuration("TRANSFER_TIME_DEFAULT", 30*time.Minute),
+		TransferHistoryRefresh:      getEnvDuration("TRANSFER_HISTORY_REFRESH", time.Hour),
+		NetworkStatusEvents:         getEnvBool("NETWORK_STATUS_EVENTS", false),
+		ContractRefreshInterval:     getEnvDuration("CONTRACT_REFRESH_INTERVAL", 5*time.Minute),
+		ListingEvents:               getEnvBool("LISTING_EVENTS", true),
+		BinanceAPIKey:               getEnv("BINANCE_API_KEY", ""),
+		BinanceAPISecret:            getEnv("BINANCE_API_SECRET", ""),
+		MexcAPIKey:                  getEnv("MEXC_API_KEY", ""),
+		MexcAPISecret:               getEnv("MEXC_API_SECRET", ""),
+		Secrets:                     store,
+		SecretsRefresh:              getEnvDuration("SECRETS_REFRESH", 5*time.Minute),
+		LatencyPenaltyPctPerSec:     getEnvFloat("LATENCY_PENALTY_PCT_PER_SEC", 0),
+		LatencyPingInterval:         getEnvDuration("LATENCY_PING_INTERVAL", 30*time.Second),
+		MaintenanceWindows:          getEnvMaintenanceWindows("MAINTENANCE_WINDOWS"),
+		MaintenanceStatusInterval:   getEnvDuration("MAINTENANCE_STATUS_INTERVAL", time.Minute),
+		MaintenanceLead:             getEnvDuration("MAINTENANCE_LEAD", time.Hour),
+		OutageDetectionEnabled:      getEnvBool("OUTAGE_DETECTION_ENABLED", true),
+		OutageMaxDataAge:            getEnvDuration("OUTAGE_MAX_DATA_AGE", time.Minute),
+		OutageErrorRate:             getEnvFloat("OUTAGE_ERROR_RATE", 0.5),
+		OutageErrorWindow:           getEnvInt("OUTAGE_ERROR_WINDOW", 10),
+		OutageRecoveryFetches:       getEnvInt("OUTAGE_RECOVERY_FETCHES", 3),
+		ScoreWeights:                getEnvScoreWeights("SCORE_WEIGHTS", DefaultScoreWeights),
+		ScorerURL:                   getEnv("SCORER_URL", ""),
+		ScorerTimeout:               getEnvDuration("SCORER_TIMEOUT", 2*time.Second),
+		EnrichmentEnabled:           getEnvBool("ENRICHMENT_ENABLED", true),
+		EnrichmentAssetsRefresh:     getEnvDuration("ENRICHMENT_ASSETS_REFRESH", 24*time.Hour),
+		CoinGeckoURL:                getEnv("COINGECKO_URL", "https://api.coingecko.com/api/v3"),
+		CoinGeckoAPIKey:             getEnv("COINGECKO_API_KEY", ""),
+		CoinGeckoIDs:                getEnvStringMap("COINGECKO_IDS"),
+		HysteresisCycles:            getEnvInt("HYSTERESIS_CYCLES", 0),
+		HysteresisExitSpreadPct:     getEnvFloat("HYSTERESIS_EXIT_SPREAD_PCT", 0.05),
+		AlertCooldown:               getEnvDuration("ALERT_COOLDOWN", 0),
+		AlertMinChangeBps:           getEnvFloat("ALERT_MIN_CHANGE_BPS", 5),
+		RabbitMQConfigured:          lookupEnv("RABBITMQ_HOST") != "" || lookupEnv("RABBITMQ_DEFAULT_USER") != "",
+		RedisConfigured:             redisConfigured(),
+		RabbitMQDurable:             getEnvBool("RABBITMQ_DURABLE", false),
+		RabbitMQPersistent:          getEnvBool("RABBITMQ_PERSISTENT", false),
+		RabbitMQConfirms:            getEnvBool("RABBITMQ_CONFIRMS", false),
+		RabbitMQConfirmTimeout:      getEnvDuration("RABBITMQ_CONFIRM_TIMEOUT", 5*time.Second),
+		RabbitMQMaxResends:          getEnvInt("RABBITMQ_MAX_RESENDS", 3),
+		RabbitMQExchange:            getEnv("RABBITMQ_EXCHANGE", ""),
+		RabbitMQReconnectMaxBackoff: getEnvDuration("RABBITMQ_RECONNECT_MAX_BACKOFF", 30*time.Second),
+		RabbitMQBufferSize:          getEnvInt("RABBITMQ_BUFFER_SIZE", 1000),
+		RabbitMQMessageTTL:          getEnvDuration("RABBITMQ_MESSAGE_TTL", 5*time.Second),
+		RabbitMQMaxPriority:         getEnvInt("RABBITMQ_MAX_PRIORITY", 0),
+		RabbitMQPriorityScalePct:    getEnvFloat("RABBITMQ_PRIORITY_FULL_SCALE_PCT", 2),
+		RabbitMQDeadLetterExchange:  getEnv("RABBITMQ_DEAD_LETTER_EXCHANGE", ""),
+		RabbitMQDeadLetterQueue:     getEnv("RABBITMQ_DEAD_LETTER_QUEUE", "arbitrage_event_dlq"),
+		EventEnvelope:               getEnvBool("EVENT_ENVELOPE", false),
+		InstanceID:                  getEnv("INSTANCE_ID", messaging.DefaultProducerID()),
+		Namespace:                   getEnv("NAMESPACE", ""),
+		OpportunityIDBucket:         getEnvDuration("OPPORTUNITY_ID_BUCKET", time.Minute),
+		PublishSinks:                getEnvStrings("PUBLISH_SINKS", getEnvStrings("PUBLISH_BACKEND", []string{PublishBackendRabbitMQ})),
+		PublishSinkQueueSize:        getEnvInt("PUBLISH_SINK_QUEUE_SIZE", 1000),
+		FileSinkPath:                getEnv("FILE_SINK_PATH", "spreads.jsonl"),
+		KafkaBrokers:                getEnvStrings("KAFKA_BROKERS", []string{"kafka:9092"}),
+		KafkaTopic:                  getEnv("KAFKA_TOPIC", "arbitrage_events"),
+		NATSURL:                     getEnv("NATS_URL", "nats://nats:4222"),
+		NATSSubjectPrefix:           getEnv("NATS_SUBJECT_PREFIX", "arb.spread"),
+		NATSStream:                  getEnv("NATS_STREAM", "ARB_SPREADS"),
+		NATSCreateStream:            getEnvBool("NATS_CREATE_STREAM", true),
+		NATSRetryAttempts:           getEnvInt("NATS_RETRY_ATTEMPTS", 3),
+		RedisStreamEnabled:          getEnvBool("REDIS_STREAM_ENABLED", true),
+		RedisStream:                 getEnv("REDIS_STREAM", "arb:spreads"),
+		RedisStreamMaxLen:           int64(getEnvInt("REDIS_STREAM_MAXLEN", 100000)),
+		RedisPubSubEnabled:          getEnvBool("REDIS_PUBSUB_ENABLED", false),
+		RedisChannel:                getEnv("REDIS_CHANNEL", ""),
+		RedisLatestEnabled:          getEnvBool("REDIS_LATEST_ENABLED", false),
+		RedisLatestKey:              getEnv("REDIS_LATEST_KEY", "arb:spreads:latest"),
+		RedisLatestTTL:              getEnvDuration("REDIS_LATEST_TTL", 15*time.Second),
+		BinanceFundingCacheTTL:      getEnvDuration("BINANCE_FUNDING_CACHE_TTL", 8*time.Hour),
+		MexcFundingCacheTTL:         getEnvDuration("MEXC_FUNDING_CACHE_TTL", 8*time.Hour),
+		MQTTBrokerURL:               getEnv("MQTT_BROKER_URL", "tcp://localhost:1883"),
+		MQTTClientID:                getEnv("MQTT_CLIENT_ID", "cex-arbitrage"),
+		MQTTUsername:                getEnv("MQTT_USERNAME", ""),
+		MQTTPassword:                getEnv("MQTT_PASSWORD", ""),
+		MQTTTopicPrefix:             getEnv("MQTT_TOPIC_PREFIX", "arb/spread"),
+		MQTTQoS:                     getEnvInt("MQTT_QOS", 1),
+		MQTTRetain:                  getEnvBool("MQTT_RETAIN", true),
+		WebhookTargets:              getEnvWebhookTargets("WEBHOOK_TARGETS", getEnvStrings("WEBHOOK_URLS", nil), getEnv("WEBHOOK_SECRET", "")),
+		WebhookTimeout:              getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxRetries:           getEnvInt("WEBHOOK_MAX_RETRIES", 3),
+		RulesFile:                   getEnv("RULES_FILE", "rules.json"),
+		TelegramBotToken:            getEnv("TELEGRAM_BOT_TOKEN", ""),
+		PostgresURL:                 getEnv("POSTGRES_URL", ""),
+		PostgresTimescale:           getEnvBool("POSTGRES_TIMESCALE", false),
+		PostgresStoreTickers:        getEnvBool("POSTGRES_STORE_TICKERS", false),
+		InfluxWriteURL:              getEnv("INFLUX_WRITE_URL", ""),
+		InfluxToken:                 getEnv("INFLUX_TOKEN", ""),
+		InfluxTimeout:               getEnvDuration("INFLUX_TIMEOUT", 5*time.Second),
+		ClickHouseURL:               getEnv("CLICKHOUSE_URL", ""),
+		ClickHouseDatabase:          getEnv("CLICKHOUSE_DATABASE", "default"),
+		ClickHouseUsername:          getEnv("CLICKHOUSE_USERNAME", ""),
+		ClickHousePassword:          getEnv("CLICKHOUSE_PASSWORD", ""),
+		ClickHouseTable:             getEnv("CLICKHOUSE_TABLE", "spreads"),
+		ClickHouseBatchSize:         getEnvInt("CLICKHOUSE_BATCH_SIZE", 50000),
+		ClickHouseFlushInterval:     getEnvDuration("CLICKHOUSE_FLUSH_INTERVAL", 10*time.Second),
+		ClickHouseMaxBuffered:       getEnvInt("CLICKHOUSE_MAX_BUFFERED", 1000000),
+		ClickHouseAsyncInsert:       getEnvBool("CLICKHOUSE_ASYNC_INSERT", true),
+		ClickHouseCreateTable:       getEnvBool("CLICKHOUSE_CREATE_TABLE", true),
+		ClickHouseTimeout:           getEnvDuration("CLICKHOUSE_TIMEOUT", 30*time.Second),
+		ExportDir:                   getEnv("EXPORT_DIR", ""),
+		ExportFormat:                getEnv("EXPORT_FORMAT", "jsonl"),
+		ExportGzip:                  getEnvBool("EXPORT_GZIP", true),
+		ExportMaxBytes:              int64(getEnvInt("EXPORT_MAX_BYTES", 256<<20)),
+		ExportRotateInterval:        getEnvDuration("EXPORT_ROTATE_INTERVAL", time.Hour),
+		ExportTickers:               getEnvBool("EXPORT_TICKERS", false),
+		RetentionInterval:           getEnvDuration("RETENTION_INTERVAL", time.Hour),
+		RetentionSpreads:            getEnvDuration("RETENTION_SPREADS", 0),
+		RetentionTickers:            getEnvDuration("RETENTION_TICKERS", 0),
+		RetentionCandles:            getEnvDuration("RETENTION_CANDLES", 0),
+		RetentionExportFiles:        getEnvDuration("RETENTION_EXPORT_FILES", 0),
+		RetentionRedisStream:        getEnvDuration("RETENTION_REDIS_STREAM", 0),
+		RetentionSpreadHistory:      getEnvBool("RETENTION_SPREAD_HISTORY", true),
+		APIAddr:                     getEnv("API_ADDR", ""),
+		WSEnabled:                   getEnvBool("WS_ENABLED", true),
+		MetricsEnabled:              getEnvBool("METRICS_ENABLED", true),
+		ImpactEnabled:               getEnvBool("IMPACT_ENABLED", true),
+		OrderBookDepth:              getEnvInt("ORDER_BOOK_DEPTH", 100),
+		OrderBookMaxAge:             getEnvDuration("ORDER_BOOK_MAX_AGE", time.Second),
+		HealthMaxAge:                getEnvDuration("HEALTH_MAX_AGE", 30*time.Second),
+		AdminToken:                  getEnv("ADMIN_TOKEN", ""),
+		BlockedSymbols:              getEnvStrings("BLOCKED_SYMBOLS", nil),
+		PollInterval:                getEnvDuration("POLL_INTERVAL", 5*time.Second),
+		PollAdaptive:                getEnvBool("POLL_ADAPTIVE", false),
+		PollIntervalMin:             getEnvDuration("POLL_INTERVAL_MIN", time.Second),
+		PollIntervalMax:             getEnvDuration("POLL_INTERVAL_MAX", 20*time.Second),
+		PollVolatilityBps:           getEnvFloat("POLL_VOLATILITY_BPS", 10),
+		PollRateLimitPressure:       getEnvFloat("POLL_RATE_LIMIT_PRESSURE", 0.8),
+		BinanceTickerInterval:       getEnvDuration("BINANCE_TICKER_INTERVAL", 0),
+		MexcTickerInterval:          getEnvDuration("MEXC_TICKER_INTERVAL", 0),
+		GmxEnabled:                  getEnvBool("GMX_ENABLED", false),
+		GmxTickerInterval:           getEnvDuration("GMX_TICKER_INTERVAL", 0),
+		BinanceFundingInterval:      getEnvDuration("BINANCE_FUNDING_INTERVAL", 0),
+		BinanceFundingStream:        getEnvBool("BINANCE_FUNDING_STREAM", true),
+		MexcFundingInterval:         getEnvDuration("MEXC_FUNDING_INTERVAL", 10*time.Minute),
+		MexcFundingStream:           getEnvBool("MEXC_FUNDING_STREAM", true),
+		MexcStreamSilenceTimeout:    getEnvDuration("MEXC_STREAM_SILENCE_TIMEOUT", time.Minute),
+		BinanceVolumeInterval:       getEnvDuration("BINANCE_VOLUME_INTERVAL", time.Minute),
+		RateLimitBackoff:            getEnvDuration("RATE_LIMIT_BACKOFF", 30*time.Second),
+		LogLevel:                    getEnvLogLevel("LOG_LEVEL", slog.LevelInfo),
+		LogFormat:                   getEnv("LOG_FORMAT", logging.FormatText),
+		LogModuleLevels:             getEnvLogLevels("LOG_MODULE_LEVELS"),
+		LogWarningDetail:            getEnvInt("LOG_WARNING_DETAIL", 3),
+		TracingEndpoint:             getEnv("TRACING_ENDPOINT", ""),
+		TracingSampleRatio:          getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		LeaderElection:              getEnvBool("LEADER_ELECTION", false),
+		LeaderKey:                   getEnv("LEADER_KEY", "arb:leader"),
+		LeaderLeaseTTL:              getEnvDuration("LEADER_LEASE_TTL", 15*time.Second),
+		PublishDedupEnabled:         getEnvBool("PUBLISH_DEDUP_ENABLED", false),
+		PublishDedupKeyPrefix:       getEnv("PUBLISH_DEDUP_KEY_PREFIX", "arb:claim:"),
+		PublishDedupTTL:             getEnvDuration("PUBLISH_DEDUP_TTL", time.Minute),
+		ShardIndex:                  getEnvInt("SHARD_INDEX", 0),
+		ShardCount:                  getEnvInt("SHARD_COUNT", 1),
+		ChaosEnabled:                getEnvBool("CHAOS_ENABLED", false),
+		ChaosErrorRate:              getEnvFloat("CHAOS_ERROR_RATE", 0.05),
+		ChaosSlowRate:               getEnvFloat("CHAOS_SLOW_RATE", 0.05),
+		ChaosSlowDelay:              getEnvDuration("CHAOS_SLOW_DELAY", 3*time.Second),
+		ChaosMalformedRate:          getEnvFloat("CHAOS_MALFORMED_RATE", 0.02),
+		ChaosPublishErrorRate:       getEnvFloat("CHAOS_PUBLISH_ERROR_RATE", 0.05),
+		ChaosDisconnectInterval:     getEnvDuration("CHAOS_DISCONNECT_INTERVAL", 5*time.Minute),
+		RecordDir:                   getEnv("RECORD_DIR", ""),
+		RecordRotateInterval:        getEnvDuration("RECORD_ROTATE_INTERVAL", time.Hour),
+		RecordRetention:             getEnvDuration("RECORD_RETENTION", 0),
+		ExecutionEnabled:            getEnvBool("EXECUTION_ENABLED", false),
+		ExecutionMaxNotional:        getEnvFloat("EXECUTION_MAX_NOTIONAL", 100),
+		ExecutionOrderType:          getEnv("EXECUTION_ORDER_TYPE", "limit"),
+		ExecutionLimitSlippageBps:   getEnvFloat("EXECUTION_LIMIT_SLIPPAGE_BPS", 10),
+		ExecutionFillTimeout:        getEnvDuration("EXECUTION_FILL_TIMEOUT", 10*time.Second),
+		ExecutionLeverage:           getEnvInt("EXECUTION_LEVERAGE", 1),
+		AccountTracking:             getEnvBool("ACCOUNT_TRACKING", false),
+		AccountRefresh:              getEnvDuration("ACCOUNT_REFRESH", 30*time.Second),
+		AccountLeverage:             getEnvFloat("ACCOUNT_LEVERAGE", 1),
+		AccountSkipUntradable:       getEnvBool("ACCOUNT_SKIP_UNTRADABLE", false),
+		RiskMaxSymbolNotional:       getEnvFloat("RISK_MAX_SYMBOL_NOTIONAL", 0),
+		RiskMaxOpenPositions:        getEnvInt("RISK_MAX_OPEN_POSITIONS", 0),
+		RiskMaxExchangeExposure:     getEnvFloatMap("RISK_MAX_EXCHANGE_EXPOSURE", nil),
+		RiskKillSwitch:              getEnvBool("RISK_KILL_SWITCH", false),
+		RiskKillSwitchFile:          getEnv("RISK_KILL_SWITCH_FILE", ""),
+		ExitSignalsEnabled:          getEnvBool("EXIT_SIGNALS_ENABLED", false),
+		ExitSignalsMinProfitPct:     getEnvFloat("EXIT_SIGNALS_MIN_PROFIT_PCT", 0.05),
+		ExitSignalsFundingWindow:    getEnvDuration("EXIT_SIGNALS_FUNDING_WINDOW", 30*time.Minute),
+		ExitSignalsSimulate:         getEnvBool("EXIT_SIGNALS_SIMULATE", true),
+		ExitSignalsMaxHold:          getEnvDuration("EXIT_SIGNALS_MAX_HOLD", 72*time.Hour),
+		BinanceTestnet:              getEnvBool("BINANCE_TESTNET", false),
+		SimulatorBinanceURL:         getEnv("SIMULATOR_BINANCE_URL", ""),
+		SimulatorMexcURL:            getEnv("SIMULATOR_MEXC_URL", ""),
+	}
+	if err := endLoad(cfg.validate()); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// redisConfigured reports whether Redis is set up, as storage.NewRedisClient otherwise targets the
+// docker-compose service, which isn't there in local runs.
+func redisConfigured() bool {
+	return lookupEnv("REDIS_ADDR") != "" || lookupEnv("REDIS_PASSWORD") != ""
+}
+
+// getEnv reads a string from the environment or the config file, falling back to def if unset or empty.
+func getEnv(key, def string) string {
+	if v := lookupEnv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getEnvList reads a comma-separated list from the environment, falling back to def if unset or empty.
+func getEnvList(key string, def []string) []string {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return def
+	}
+	return values
+}
+
+// getEnvStrings reads a comma-separated list of values, kept as written (e.g., host names or URLs).
+func getEnvStrings(key string, def []string) []string {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return def
+	}
+	return values
+}
+
+// getEnvDurations reads a comma-separated list of durations (e.g., "1m,5m,1h") from the environment, falling
+// back to def if unset. Invalid values are reported by Load.
+func getEnvDurations(key string, def []time.Duration) []time.Duration {
+	raw := getEnvStrings(key, nil)
+	if raw == nil {
+		return def
+	}
+	values := make([]time.Duration, 0, len(raw))
+	for _, v := range raw {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			invalidValue(key, fmt.Errorf("invalid duration %q", v))
+			continue
+		}
+		values = append(values, d)
+	}
+	return values
+}
+
+// getEnvDuration reads a duration (e.g., "30s") from the environment, falling back to def if unset.
+// Invalid values are reported by Load.
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		invalidValue(key, fmt.Errorf("invalid duration %q", raw))
+		return def
+	}
+	return d
+}
+
+// getEnvFloat reads a float from the environment, falling back to def if unset. Invalid values are reported by Load.
+func getEnvFloat(key string, def float64) float64 {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		invalidValue(key, fmt.Errorf("invalid number %q", raw))
+		return def
+	}
+	return f
+}
+
+// getEnvInt reads an integer from the environment, falling back to def if unset. Invalid values are reported by Load.
+func getEnvInt(key string, def int) int {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	i, err := strconv.Atoi(raw)
+	if err != nil {
+		invalidValue(key, fmt.Errorf("invalid integer %q", raw))
+		return def
+	}
+	return i
+}
+
+// getEnvBool reads a boolean from the environment, falling back to def if unset. Invalid values are reported by Load.
+func getEnvBool(key string, def bool) bool {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		invalidValue(key, fmt.Errorf("invalid boolean %q", raw))
+		return def
+	}
+	return b
+}
+
+// getEnvFloatMap reads "key:value" pairs separated by commas (e.g., "Binance:5,Mexc:2") from the environment,
+// falling back to def if unset. Invalid pairs are reported by Load.
+func getEnvFloatMap(key string, def map[string]float64) map[string]float64 {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	values := make(map[string]float64)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			invalidValue(key, fmt.Errorf("invalid key:value pair %q", pair))
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			invalidValue(key, fmt.Errorf("invalid number in pair %q", pair))
+			continue
+		}
+		values[strings.TrimSpace(k)] = f
+	}
+	return values
+}
+
+// getEnvStringMap reads "key:value" pairs separated by commas (e.g., "PEPE:pepe,TON:the-open-network") from the
+// environment, with uppercase keys. Invalid pairs are reported by Load.
+func getEnvStringMap(key string) map[string]string {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return nil
+	}
+	values := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(pair), ":")
+		k, v = strings.ToUpper(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if !found || k == "" || v == "" {
+			invalidValue(key, fmt.Errorf("invalid key:value pair %q", pair))
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
+
+// getEnvDurationMap reads "key:duration" pairs separated by commas (e.g., "ETH:5m,USDT/TRX:2m") from the
+// environment, falling back to def if unset. Invalid pairs are reported by Load.
+func getEnvDurationMap(key string, def map[string]time.Duration) map[string]time.Duration {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	values := make(map[string]time.Duration)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			invalidValue(key, fmt.Errorf("invalid key:duration pair %q", pair))
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			invalidValue(key, fmt.Errorf("invalid duration in pair %q", pair))
+			continue
+		}
+		values[strings.TrimSpace(k)] = d
+	}
+	return values
+}
+
+// getEnvLogLevel reads a log level (e.g., "warn"), falling back to def if unset. Invalid values are reported by Load.
+func getEnvLogLevel(key string, def slog.Level) slog.Level {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return def
+	}
+	level, err := logging.ParseLevel(raw)
+	if err != nil {
+		invalidValue(key, err)
+		return def
+	}
+	return level
+}
+
+// getEnvLogLevels reads "module:level" pairs separated by commas (e.g., "adapters:warn,shared/retry:error").
+// Invalid pairs are reported by Load.
+func getEnvLogLevels(key string) map[string]slog.Level {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return nil
+	}
+	levels := make(map[string]slog.Level)
+	for _, pair := range strings.Split(raw, ",") {
+		module, name, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			invalidValue(key, fmt.Errorf("invalid module:level pair %q", pair))
+			continue
+		}
+		level, err := logging.ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			invalidValue(key, fmt.Errorf("%w in pair %q", err, pair))
+			continue
+		}
+		levels[strings.TrimSpace(module)] = level
+	}
+	return levels
+}
+
+// getEnvSymbolOverrides reads per-symbol overrides as a JSON object keyed by unified symbol or base asset,
+// e.g. {"BTC":{"min_entry_spread_pct":0.05},"PEPE/USDT:PERP":{"min_entry_spread_pct":1}}.
+func getEnvSymbolOverrides(key string) map[string]SymbolOverride {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return nil
+	}
+	var overrides map[string]SymbolOverride
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		invalidValue(key, fmt.Errorf("invalid symbol overrides: %w", err))
+		return nil
+	}
+	return overrides
+}
+
+// getEnvMaintenanceWindows reads scheduled maintenance windows from a JSON array, e.g.
+// [{"exchange":"Binance","start":"2026-01-10T02:00:00Z","end":"2026-01-10T04:00:00Z","reason":"wallet upgrade"}].
+func getEnvMaintenanceWindows(key string) []maintenance.Window {
+	raw := lookupEnv(key)
+	if raw == "" {
+		return nil
+	}
+	var windows []maintenance.Window
+	if err := json.Unmarshal([]byte(raw), &windows); err != nil {
+		invalidValue(key, fmt.Errorf("invalid maintenance windows: %w", err))
+		return nil
+	}
+	return windows
+}
+
+// getEnvWebhookTargets reads webhook targets with filters from a JSON array, followed by one unfiltered target
+// per URL in urls, all signed with secret.
+func getEnvWebhookTargets(key string, urls []string, secret string) []messaging.WebhookTarget {
+	var targets []messaging.WebhookTarget
+	if raw := lookupEnv(key); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &targets); err != nil {
+			invalidValue(key, fmt.Errorf("invalid webhook targets: %w", err))
+			targets = nil
+		}
+	}
+	for _, url := range urls {
+		targets = append(targets, messaging.WebhookTarget{URL: url, Secret: secret})
+	}
+	return targets
+}
+
+// getEnvScoreWeights reads score weights from "component:weight" pairs (e.g., "net_spread:1,z_score:0.2").
+// Components that are not listed keep their default weight.
+func getEnvScoreWeights(key string, def ScoreWeights) ScoreWeights {
+	weights := def
+	for component, w := range getEnvFloatMap(key, nil) {
+		switch component {
+		case "net_spread":
+			weights.NetSpread = w
+		case "funding_pnl":
+			weights.FundingPnL = w
+		case "liquidity":
+			weights.Liquidity = w
+		case "z_score":
+			weights.ZScore = w
+		case "staleness":
+			weights.Staleness = w
+		case "momentum":
+			weights.Momentum = w
+		default:
+			invalidValue(key, fmt.Errorf("unknown score component %q", component))
+		}
+	}
+	return weights
+}
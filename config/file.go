@@ -0,0 +1,209 @@
+package config
+
+import (
+	"cex-price-diff-notifications/secrets"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// externalKeys are read directly from the environment by other packages (messaging.RabbitMQURLFromEnv and
+// storage.NewRedisClient), so values from a config file are exported to the environment for them.
+var externalKeys = []string{"RABBITMQ_DEFAULT_USER", "RABBITMQ_DEFAULT_PASS", "RABBITMQ_HOST", "REDIS_ADDR", "REDIS_PASSWORD"}
+
+// loadSource holds what a Load function reads besides the environment, and what it found wrong.
+type loadSource struct {
+	mu      sync.Mutex
+	file    string              // Path of the loaded config file, if any
+	values  map[string]string   // Config file values, keyed like the environment variables they stand for
+	leaves  map[string][]string // Path of every scalar or list in the config file, keyed like values
+	read    map[string]bool     // Keys looked up by the Load function
+	invalid []error             // Values that could not be parsed
+	secrets *secrets.Store      // Secret store whose values take precedence, if any
+}
+
+// source is the state of the running Load function.
+var source loadSource
+
+// beginLoad loads the config file at path, or defaultPath if path is empty and it exists, and starts
+// recording the keys read and the invalid values.
+func beginLoad(path, defaultPath string) error {
+	source.mu.Lock()
+	source.file, source.values, source.leaves = "", nil, nil
+	source.read, source.invalid, source.secrets = make(map[string]bool), nil, nil
+	source.mu.Unlock()
+
+	if path == "" {
+		if _, err := os.Stat(defaultPath); err != nil {
+			return nil
+		}
+		path = defaultPath
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	leaves := make(map[string][]string)
+	for k, v := range doc {
+		flatten([]string{k}, v, values, leaves)
+	}
+	for _, key := range externalKeys {
+		if v, ok := values[key]; ok && os.Getenv(key) == "" {
+			os.Setenv(key, v)
+		}
+	}
+
+	source.mu.Lock()
+	source.file, source.values, source.leaves = path, values, leaves
+	source.mu.Unlock()
+	return nil
+}
+
+// endLoad returns every problem found while loading: invalid values, config file settings that were not
+// read (usually typos) and the errors of the semantic checks.
+func endLoad(checks []error) error {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	errs := slices.Clone(source.invalid)
+	var unknown []string
+	for key, path := range source.leaves {
+		if !source.readAny(path) {
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown setting in %s", strings.Join(source.leaves[key], "."), source.file))
+	}
+	return errors.Join(append(errs, checks...)...)
+}
+
+// readAny reports whether the setting at path, or a section containing it, was read.
+func (s *loadSource) readAny(path []string) bool {
+	for i := len(path); i > 0; i-- {
+		if s.read[envKey(path[:i])] {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupEnv returns the value of a setting: the secret store value if any, else the environment variable if
+// set, else the config file value.
+func lookupEnv(key string) string {
+	source.mu.Lock()
+	store := source.secrets
+	source.mu.Unlock()
+	if store != nil {
+		if v, ok := store.Lookup(key); ok {
+			markRead(key)
+			return v
+		}
+	}
+	if v := os.Getenv(key); v != "" {
+		markRead(key)
+		return v
+	}
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	source.read[key] = true
+	return source.values[key]
+}
+
+// markRead records keys read outside lookupEnv, so the config file settings they stand for are known.
+func markRead(keys ...string) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	for _, key := range keys {
+		source.read[key] = true
+	}
+}
+
+// invalidValue records a value of key that could not be parsed.
+func invalidValue(key string, err error) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	source.invalid = append(source.invalid, fmt.Errorf("%s: %w", key, err))
+}
+
+// flatten stores the config file value at path under its environment variable name, e.g., rabbitmq.durable
+// under RABBITMQ_DURABLE. Lists of scalars are joined with commas, sections of scalars become "key:value"
+// pairs (e.g., taker_fees_bps: {Binance: 5} is "Binance:5") and anything deeper becomes JSON, matching
+// what the environment variables take. Sections are also flattened key by key.
+func flatten(path []string, v any, values map[string]string, leaves map[string][]string) {
+	key := envKey(path)
+	switch v := v.(type) {
+	case map[string]any:
+		if encoded, ok := encodePairs(v); ok {
+			values[key] = encoded
+		} else if encoded, err := json.Marshal(v); err == nil {
+			values[key] = string(encoded)
+		}
+		for k, child := range v {
+			flatten(append(slices.Clip(path), k), child, values, leaves)
+		}
+	case []any:
+		if encoded, ok := encodeList(v); ok {
+			values[key] = encoded
+		} else if encoded, err := json.Marshal(v); err == nil {
+			values[key] = string(encoded)
+		}
+		leaves[key] = path
+	case nil:
+		leaves[key] = path
+	default:
+		values[key] = fmt.Sprint(v)
+		leaves[key] = path
+	}
+}
+
+// envKey returns the environment variable name of a config file path.
+func envKey(path []string) string {
+	return strings.ToUpper(strings.Join(path, "_"))
+}
+
+// encodePairs encodes a section of scalars as sorted "key:value" pairs.
+func encodePairs(m map[string]any) (string, bool) {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		if !isScalar(v) {
+			return "", false
+		}
+		pairs = append(pairs, fmt.Sprintf("%s:%v", k, v))
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ","), true
+}
+
+// encodeList encodes a list of scalars as comma-separated values.
+func encodeList(list []any) (string, bool) {
+	values := make([]string, 0, len(list))
+	for _, v := range list {
+		if !isScalar(v) {
+			return "", false
+		}
+		values = append(values, fmt.Sprint(v))
+	}
+	return strings.Join(values, ","), true
+}
+
+// isScalar reports whether a decoded YAML value is a string, number or boolean.
+func isScalar(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any, nil:
+		return false
+	}
+	return true
+}
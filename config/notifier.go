@@ -0,0 +1,183 @@
+package config
+
+import (
+	"cex-price-diff-notifications/logging"
+	"cex-price-diff-notifications/notifier"
+	"cex-price-diff-notifications/secrets"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// ChatChannel is a Discord or Slack destination: an incoming webhook, or a channel the bot posts to.
+type ChatChannel struct {
+	WebhookURL string `json:"webhook_url"`
+	ChannelID  string `json:"channel_id"` // Used with the bot token when WebhookURL is empty
+	Template   string `json:"template"`   // Message template overriding the channel type's template
+	notifier.Filter
+}
+
+// NotifierConfig holds the settings of the notifier command, loaded from the environment.
+type NotifierConfig struct {
+	Queue                      string                // Queue consumed for alerts
+	Exchange                   string                // Topic exchange the queue is bound to (empty consumes the queue as is)
+	Bindings                   []string              // Routing key patterns used when binding to the exchange
+	RabbitMQDurable            bool                  // Must match the publisher's queue durability
+	RabbitMQMaxPriority        int                   // Must match the publisher's queue maximum priority
+	RabbitMQDeadLetterExchange string                // Must match the publisher's dead-letter exchange
+	Namespace                  string                // Must match the publisher's namespace, prefixing the queue, bindings and Redis keys
+	TelegramBotToken           string                // Telegram bot token (empty disables Telegram)
+	TelegramChatIDs            []string              // Telegram chats receiving alerts
+	TelegramFilter             notifier.Filter       // Alerts sent to the Telegram chats
+	TelegramTemplate           string                // Go template of Telegram messages, in Telegram HTML (empty uses the built-in format)
+	TelegramSubscriptions      bool                  // Let chats subscribe by talking to the bot (/subscribe, /threshold, /mute), their filters kept in Redis
+	TelegramSubscriptionsKey   string                // Redis hash holding the subscriptions
+	RedisConfigured            bool                  // REDIS_ADDR or REDIS_PASSWORD is set
+	DiscordBotToken            string                // Discord bot token, for channels without a webhook
+	DiscordChannels            []ChatChannel         // Discord destinations with their filters
+	DiscordTemplate            string                // Go template of the Discord embed description, in Markdown
+	SlackBotToken              string                // Slack bot token, for channels without a webhook
+	SlackChannels              []ChatChannel         // Slack destinations with their filters
+	SlackTemplate              string                // Go template of the Slack message section, in mrkdwn
+	SMTPHost                   string                // SMTP server (empty disables email)
+	SMTPPort                   int                   // SMTP port; 587 uses STARTTLS when the server offers it
+	SMTPUsername               string                // SMTP username (empty disables authentication)
+	SMTPPassword               string                // SMTP password
+	EmailFrom                  string                // Sender address
+	EmailTo                    []string              // Recipient addresses
+	EmailFilter                notifier.Filter       // Alerts considered for email
+	EmailTemplate              string                // Go template of each alert in emails and digests, in plain text
+	EmailInstantMinSpread      float64               // Entry spread, in percent, mailed right away (0 disables instant emails)
+	EmailDigestInterval        time.Duration         // Period of the digest of the best opportunities, e.g., 1h or 24h (0 disables)
+	EmailDigestSize            int                   // Opportunities listed in a digest
+	PushoverAppToken           string                // Pushover application token (empty disables Pushover)
+	PushoverUserKey            string                // Pushover user or group key
+	NtfyServer                 string                // ntfy server URL
+	NtfyTopic                  string                // ntfy topic (empty disables ntfy)
+	NtfyToken                  string                // ntfy access token for protected topics
+	PushFilter                 notifier.Filter       // Alerts sent as push notifications
+	PushTemplate               string                // Go template of push notification bodies, in plain text
+	PushHighSpread             float64               // Entry spread, in percent, sent with high priority (0 disables)
+	PushUrgentSpread           float64               // Entry spread, in percent, sent with the highest priority (0 disables)
+	Secrets                    *secrets.Store        // Secret store the tokens above may come from, re-fetched to reload the channels on rotation (nil if none)
+	SecretsRefresh             time.Duration         // How often the secret store is re-fetched (0 disables)
+	LogLevel                   slog.Level            // Minimum level of the logs (debug, info, warn or error)
+	LogFormat                  string                // "text" (colored) or "json" (for log aggregation)
+	LogModuleLevels            map[string]slog.Level // Per-module level overrides, e.g., "notifier:debug"
+}
+
+// RedisKey returns a Redis key in the namespace, like Config.RedisKey.
+func (c *NotifierConfig) RedisKey(key string) string {
+	return namespaced(c.Namespace, key, ":")
+}
+
+// LoadNotifier reads the notifier configuration from the YAML config file named by NOTIFIER_CONFIG_FILE
+// (notifier.yaml if it exists) and from environment variables, which take precedence. It fails listing
+// every invalid setting.
+func LoadNotifier() (*NotifierConfig, error) {
+	if err := beginLoad(os.Getenv("NOTIFIER_CONFIG_FILE"), "notifier.yaml"); err != nil {
+		return nil, err
+	}
+	markRead(externalKeys...)
+	store, err := loadSecrets()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &NotifierConfig{
+		Queue:                      getEnv("NOTIFIER_QUEUE", "arbitrage_event"),
+		Exchange:                   getEnv("NOTIFIER_EXCHANGE", ""),
+		Bindings:                   getEnvStrings("NOTIFIER_BINDING", []string{"spread.#", "funding.#", "report.#"}),
+		RabbitMQDurable:            getEnvBool("RABBITMQ_DURABLE", false),
+		RabbitMQMaxPriority:        getEnvInt("RABBITMQ_MAX_PRIORITY", 0),
+		RabbitMQDeadLetterExchange: getEnv("RABBITMQ_DEAD_LETTER_EXCHANGE", ""),
+		Namespace:                  getEnv("NAMESPACE", ""),
+		TelegramBotToken:           getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramChatIDs:            getEnvStrings("TELEGRAM_CHAT_IDS", nil),
+		TelegramFilter:             getEnvFilter("TELEGRAM"),
+		TelegramTemplate:           getEnvTemplate("TELEGRAM"),
+		TelegramSubscriptions:      getEnvBool("TELEGRAM_SUBSCRIPTIONS", false),
+		RedisConfigured:            redisConfigured(),
+		TelegramSubscriptionsKey:   getEnv("TELEGRAM_SUBSCRIPTIONS_KEY", "notifier:telegram:subscriptions"),
+		DiscordBotToken:            getEnv("DISCORD_BOT_TOKEN", ""),
+		DiscordChannels:            getEnvChatChannels("DISCORD_CHANNELS", getEnvStrings("DISCORD_WEBHOOK_URLS", nil), getEnvFilter("DISCORD")),
+		DiscordTemplate:            getEnvTemplate("DISCORD"),
+		SlackBotToken:              getEnv("SLACK_BOT_TOKEN", ""),
+		SlackChannels:              getEnvChatChannels("SLACK_CHANNELS", getEnvStrings("SLACK_WEBHOOK_URLS", nil), getEnvFilter("SLACK")),
+		SlackTemplate:              getEnvTemplate("SLACK"),
+		SMTPHost:                   getEnv("SMTP_HOST", ""),
+		SMTPPort:                   getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:               getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:               getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:                  getEnv("EMAIL_FROM", ""),
+		EmailTo:                    getEnvStrings("EMAIL_TO", nil),
+		EmailFilter:                getEnvFilter("EMAIL"),
+		EmailTemplate:              getEnvTemplate("EMAIL"),
+		EmailInstantMinSpread:      getEnvFloat("EMAIL_INSTANT_MIN_SPREAD", 2),
+		EmailDigestInterval:        getEnvDuration("EMAIL_DIGEST_INTERVAL", time.Hour),
+		EmailDigestSize:            getEnvInt("EMAIL_DIGEST_SIZE", 20),
+		PushoverAppToken:           getEnv("PUSHOVER_APP_TOKEN", ""),
+		PushoverUserKey:            getEnv("PUSHOVER_USER_KEY", ""),
+		NtfyServer:                 getEnv("NTFY_SERVER", "https://ntfy.sh"),
+		NtfyTopic:                  getEnv("NTFY_TOPIC", ""),
+		NtfyToken:                  getEnv("NTFY_TOKEN", ""),
+		PushFilter:                 getEnvFilter("PUSH"),
+		PushTemplate:               getEnvTemplate("PUSH"),
+		PushHighSpread:             getEnvFloat("PUSH_HIGH_SPREAD", 1),
+		PushUrgentSpread:           getEnvFloat("PUSH_URGENT_SPREAD", 3),
+		Secrets:                    store,
+		SecretsRefresh:             getEnvDuration("SECRETS_REFRESH", 5*time.Minute),
+		LogLevel:                   getEnvLogLevel("LOG_LEVEL", slog.LevelInfo),
+		LogFormat:                  getEnv("LOG_FORMAT", logging.FormatText),
+		LogModuleLevels:            getEnvLogLevels("LOG_MODULE_LEVELS"),
+	}
+	if err := endLoad(cfg.validate()); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// getEnvFilter reads an alert filter from <prefix>_MIN_ENTRY_SPREAD, <prefix>_SYMBOLS and
+// <prefix>_COOLDOWN_SECONDS, falling back to the NOTIFIER_* variables of the same name.
+func getEnvFilter(prefix string) notifier.Filter {
+	return notifier.Filter{
+		MinEntrySpread:  getEnvFloat(prefix+"_MIN_ENTRY_SPREAD", getEnvFloat("NOTIFIER_MIN_ENTRY_SPREAD", 0)),
+		Symbols:         getEnvStrings(prefix+"_SYMBOLS", getEnvStrings("NOTIFIER_SYMBOLS", nil)),
+		CooldownSeconds: getEnvFloat(prefix+"_COOLDOWN_SECONDS", getEnvFloat("NOTIFIER_COOLDOWN_SECONDS", 0)),
+	}
+}
+
+// getEnvTemplate reads a message template from <prefix>_TEMPLATE, or from the file named by
+// <prefix>_TEMPLATE_FILE if the former is empty.
+func getEnvTemplate(prefix string) string {
+	if text := getEnv(prefix+"_TEMPLATE", ""); text != "" {
+		return text
+	}
+	path := getEnv(prefix+"_TEMPLATE_FILE", "")
+	if path == "" {
+		return ""
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		invalidValue(prefix+"_TEMPLATE_FILE", fmt.Errorf("failed to read template file: %w", err))
+		return ""
+	}
+	return string(raw)
+}
+
+// getEnvChatChannels reads chat channels with filters from a JSON array, followed by one channel
+// per webhook URL in webhookURLs, all with filter.
+func getEnvChatChannels(key string, webhookURLs []string, filter notifier.Filter) []ChatChannel {
+	var channels []ChatChannel
+	if raw := lookupEnv(key); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &channels); err != nil {
+			invalidValue(key, fmt.Errorf("invalid chat channels: %w", err))
+			channels = nil
+		}
+	}
+	for _, url := range webhookURLs {
+		channels = append(channels, ChatChannel{WebhookURL: url, Filter: filter})
+	}
+	return channels
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"cex-price-diff-notifications/secrets"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Secret store providers of SECRETS_PROVIDER.
+const (
+	SecretsVault = "vault"
+	SecretsAWS   = "aws"
+	SecretsSOPS  = "sops"
+)
+
+// secretStoreKeys are the settings locating the secret store, only read for the selected provider.
+var secretStoreKeys = []string{
+	"SECRETS_PROVIDER", "SECRETS_FILE", "VAULT_ADDR", "VAULT_TOKEN", "VAULT_SECRET_PATH",
+	"AWS_REGION", "AWS_SECRET_ID", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+}
+
+// loadSecrets fetches the secrets of the store selected by SECRETS_PROVIDER, if any, so the settings they hold
+// take precedence over the environment and the config file. It must be called right after beginLoad.
+func loadSecrets() (*secrets.Store, error) {
+	markRead(secretStoreKeys...)
+	var provider secrets.Provider
+	switch name := lookupEnv("SECRETS_PROVIDER"); name {
+	case "":
+		return nil, nil
+	case SecretsVault:
+		addr, token, path := lookupEnv("VAULT_ADDR"), lookupEnv("VAULT_TOKEN"), lookupEnv("VAULT_SECRET_PATH")
+		if addr == "" || token == "" || path == "" {
+			return nil, errors.New("SECRETS_PROVIDER: vault needs VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH")
+		}
+		provider = secrets.NewVault(addr, token, path)
+	case SecretsAWS:
+		region, secretID := lookupEnv("AWS_REGION"), lookupEnv("AWS_SECRET_ID")
+		creds := secrets.AWSCredentials{
+			AccessKeyID:     lookupEnv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: lookupEnv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    lookupEnv("AWS_SESSION_TOKEN"),
+		}
+		if region == "" || secretID == "" || creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return nil, errors.New("SECRETS_PROVIDER: aws needs AWS_REGION, AWS_SECRET_ID, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		provider = secrets.NewAWSSecretsManager(region, secretID, creds)
+	case SecretsSOPS:
+		path := lookupEnv("SECRETS_FILE")
+		if path == "" {
+			return nil, errors.New("SECRETS_PROVIDER: sops needs SECRETS_FILE")
+		}
+		provider = secrets.NewSOPSFile(path)
+	default:
+		return nil, fmt.Errorf("SECRETS_PROVIDER: must be one of %v, got %q", []string{SecretsVault, SecretsAWS, SecretsSOPS}, name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := secrets.NewStore(ctx, provider)
+	if err != nil {
+		return nil, fmt.Errorf("SECRETS_PROVIDER: failed to load secrets: %w", err)
+	}
+	for _, key := range externalKeys {
+		if v, ok := store.Lookup(key); ok {
+			os.Setenv(key, v)
+		}
+	}
+	source.mu.Lock()
+	source.secrets = store
+	source.mu.Unlock()
+	return store, nil
+}
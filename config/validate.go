@@ -0,0 +1,401 @@
+package config
+
+import (
+	"cex-price-diff-notifications/logging"
+	"cex-price-diff-notifications/report"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// checks collects the invalid settings found by a validate method.
+type checks []error
+
+// add records an invalid setting.
+func (c *checks) add(key, format string, args ...any) {
+	*c = append(*c, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+// oneOf checks that a setting is one of the allowed values.
+func (c *checks) oneOf(key, v string, allowed ...string) {
+	if !slices.Contains(allowed, v) {
+		c.add(key, "must be one of %v, got %q", allowed, v)
+	}
+}
+
+// namespace checks that a namespace only has characters that queue names, NATS stream names, Redis keys and
+// metric labels all accept.
+func (c *checks) namespace(v string) {
+	invalid := func(r rune) bool { return (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' }
+	if strings.ContainsFunc(v, invalid) {
+		c.add("NAMESPACE", "must only contain lowercase letters, digits, '-' and '_', got %q", v)
+	}
+}
+
+// nonNegative checks that a number or duration is not negative.
+func nonNegative[T int | int64 | float64 | time.Duration](c *checks, key string, v T) {
+	if v < 0 {
+		c.add(key, "must not be negative, got %v", v)
+	}
+}
+
+// positive checks that a number or duration is greater than zero.
+func positive[T int | int64 | float64 | time.Duration](c *checks, key string, v T) {
+	if v <= 0 {
+		c.add(key, "must be positive, got %v", v)
+	}
+}
+
+// validate checks the settings that parsed but make no sense, e.g., an unknown mode or a negative threshold.
+func (c *Config) validate() []error {
+	var errs checks
+	errs.oneOf("FUNDING_WINDOW_MODE", c.FundingWindowMode, "tag", "only", "boost")
+	errs.oneOf("PUBLISH_MODE", c.PublishMode, PublishModeRaw, PublishModeLifecycle, PublishModeSnapshot, PublishModeDiff)
+	nonNegative(&errs, "DIFF_MATERIAL_CHANGE_BPS", c.DiffMaterialChangeBps)
+	errs.oneOf("SPREAD_MODE", c.SpreadMode, SpreadModeFull, SpreadModeIncremental)
+	errs.oneOf("LOG_FORMAT", c.LogFormat, logging.FormatText, logging.FormatJSON)
+	errs.namespace(c.Namespace)
+	nonNegative(&errs, "LOG_WARNING_DETAIL", c.LogWarningDetail)
+	if c.ExportDir != "" {
+		errs.oneOf("EXPORT_FORMAT", c.ExportFormat, "jsonl", "csv")
+	}
+	if c.AccountTracking {
+		if c.BinanceAPIKey == "" || c.BinanceAPISecret == "" || c.MexcAPIKey == "" || c.MexcAPISecret == "" {
+			errs.add("ACCOUNT_TRACKING", "needs the Binance and Mexc API keys and secrets")
+		}
+		positive(&errs, "ACCOUNT_REFRESH", c.AccountRefresh)
+		if c.AccountLeverage < 1 {
+			errs.add("ACCOUNT_LEVERAGE", "must be at least 1, got %v", c.AccountLeverage)
+		}
+	}
+	if c.ExecutionEnabled {
+		errs.oneOf("EXECUTION_ORDER_TYPE", c.ExecutionOrderType, "limit", "market")
+		if c.BinanceAPIKey == "" || c.BinanceAPISecret == "" || c.MexcAPIKey == "" || c.MexcAPISecret == "" {
+			errs.add("EXECUTION_ENABLED", "needs the Binance and Mexc API keys and secrets")
+		}
+		positive(&errs, "EXECUTION_MAX_NOTIONAL", c.ExecutionMaxNotional)
+		positive(&errs, "EXECUTION_FILL_TIMEOUT", c.ExecutionFillTimeout)
+		positive(&errs, "EXECUTION_LEVERAGE", c.ExecutionLeverage)
+		nonNegative(&errs, "EXECUTION_LIMIT_SLIPPAGE_BPS", c.ExecutionLimitSlippageBps)
+	}
+	for _, sink := range c.PublishSinks {
+		errs.oneOf("PUBLISH_SINKS", sink, PublishBackendRabbitMQ, PublishBackendKafka, PublishBackendNATS, PublishBackendRedis,
+			PublishBackendFile, PublishBackendMQTT, PublishBackendWebhook, PublishBackendRules)
+	}
+	if len(c.PublishSinks) == 0 {
+		errs.add("PUBLISH_SINKS", "must list at least one sink")
+	}
+	if c.PublishesTo(PublishBackendRedis) && !c.RedisConfigured {
+		errs.add("PUBLISH_SINKS", "the redis sink needs REDIS_ADDR or REDIS_PASSWORD")
+	}
+	if c.PublishesTo(PublishBackendWebhook) && len(c.WebhookTargets) == 0 {
+		errs.add("WEBHOOK_URLS", "must be set when publishing to webhooks (or set WEBHOOK_TARGETS)")
+	}
+	if c.PublishesTo(PublishBackendKafka) && len(c.KafkaBrokers) == 0 {
+		errs.add("KAFKA_BROKERS", "must be set when publishing to Kafka")
+	}
+	if c.MQTTQoS < 0 || c.MQTTQoS > 2 {
+		errs.add("MQTT_QOS", "must be 0, 1 or 2, got %d", c.MQTTQoS)
+	}
+	if c.RabbitMQMaxPriority < 0 || c.RabbitMQMaxPriority > 255 {
+		errs.add("RABBITMQ_MAX_PRIORITY", "must be between 0 and 255, got %d", c.RabbitMQMaxPriority)
+	}
+	if c.DynamicThresholdPercentile < 0 || c.DynamicThresholdPercentile >= 100 {
+		errs.add("DYNAMIC_THRESHOLD_PERCENTILE", "must be between 0 and 100, got %v", c.DynamicThresholdPercentile)
+	}
+	positive(&errs, "SHARD_COUNT", c.ShardCount)
+	if c.ShardIndex < 0 || c.ShardIndex >= max(c.ShardCount, 1) {
+		errs.add("SHARD_INDEX", "must be between 0 and SHARD_COUNT-1 (%d), got %d", max(c.ShardCount, 1)-1, c.ShardIndex)
+	}
+	if c.ChaosEnabled {
+		rates := []struct {
+			key  string
+			rate float64
+		}{
+			{"CHAOS_ERROR_RATE", c.ChaosErrorRate},
+			{"CHAOS_SLOW_RATE", c.ChaosSlowRate},
+			{"CHAOS_MALFORMED_RATE", c.ChaosMalformedRate},
+			{"CHAOS_PUBLISH_ERROR_RATE", c.ChaosPublishErrorRate},
+		}
+		for _, r := range rates {
+			if r.rate < 0 || r.rate > 1 {
+				errs.add(r.key, "must be between 0 and 1, got %v", r.rate)
+			}
+		}
+		nonNegative(&errs, "CHAOS_SLOW_DELAY", c.ChaosSlowDelay)
+		nonNegative(&errs, "CHAOS_DISCONNECT_INTERVAL", c.ChaosDisconnectInterval)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs.add("TRACING_SAMPLE_RATIO", "must be between 0 and 1, got %v", c.TracingSampleRatio)
+	}
+	for exchange, fee := range c.TakerFeesBps {
+		nonNegative(&errs, "TAKER_FEES_BPS["+exchange+"]", fee)
+	}
+	for exchange, fee := range c.SpotTakerFeesBps {
+		nonNegative(&errs, "SPOT_TAKER_FEES_BPS["+exchange+"]", fee)
+	}
+	nonNegative(&errs, "FEE_TIER_REFRESH", c.FeeTierRefresh)
+	for exchange, limit := range c.RiskMaxExchangeExposure {
+		nonNegative(&errs, "RISK_MAX_EXCHANGE_EXPOSURE["+exchange+"]", limit)
+	}
+	nonNegative(&errs, "RISK_MAX_SYMBOL_NOTIONAL", c.RiskMaxSymbolNotional)
+	nonNegative(&errs, "RISK_MAX_OPEN_POSITIONS", c.RiskMaxOpenPositions)
+	nonNegative(&errs, "EXIT_SIGNALS_FUNDING_WINDOW", c.ExitSignalsFundingWindow)
+	nonNegative(&errs, "EXIT_SIGNALS_MAX_HOLD", c.ExitSignalsMaxHold)
+	// Quotes are matched as symbol suffixes in order, so one listed before a longer quote ending in it (e.g.,
+	// USD before BUSD) would split BTCBUSD into BTCB and USD
+	for i, q := range c.QuoteCurrencies {
+		for _, longer := range c.QuoteCurrencies[i+1:] {
+			if longer != q && strings.HasSuffix(longer, q) {
+				errs.add("QUOTE_CURRENCIES", "%s must be listed before %s, a suffix of it", longer, q)
+			}
+		}
+		if slices.Index(c.QuoteCurrencies, q) != i {
+			errs.add("QUOTE_CURRENCIES", "%s is listed twice", q)
+		}
+	}
+	for _, pattern := range c.BlockedSymbols {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs.add("BLOCKED_SYMBOLS", "invalid pattern %q", pattern)
+		}
+	}
+
+	nonNegative(&errs, "TICKER_MAX_AGE", c.MaxTickerAge)
+	nonNegative(&errs, "PUBLISH_MAX_DATA_AGE", c.PublishMaxDataAge)
+	nonNegative(&errs, "MAX_PRICE_DEVIATION_PCT", c.MaxPriceDevPct)
+	nonNegative(&errs, "FUNDING_WINDOW", c.FundingWindow)
+	nonNegative(&errs, "SLIPPAGE_BPS", c.SlippageBps)
+	nonNegative(&errs, "MIN_ENTRY_SPREAD_PCT", c.MinEntrySpreadPct)
+	nonNegative(&errs, "MAX_PUBLISHED_SPREADS", c.MaxPublishedSpreads)
+	nonNegative(&errs, "MIN_VOLUME_USD", c.MinVolumeUSD)
+	nonNegative(&errs, "MIN_TOP_OF_BOOK_USD", c.MinTopOfBookUSD)
+	nonNegative(&errs, "DEFAULT_NOTIONAL_USD", c.DefaultNotionalUSD)
+	nonNegative(&errs, "ORDER_SIZE_MAX_SLIPPAGE_BPS", c.OrderSizeMaxSlippageBps)
+	nonNegative(&errs, "SPREAD_WORKERS", c.SpreadWorkers)
+	nonNegative(&errs, "LATENCY_PENALTY_PCT_PER_SEC", c.LatencyPenaltyPctPerSec)
+	nonNegative(&errs, "HYSTERESIS_CYCLES", c.HysteresisCycles)
+	if c.ConvergenceEnabled {
+		positive(&errs, "CONVERGENCE_SAMPLES", c.ConvergenceSamples)
+		positive(&errs, "CONVERGENCE_MIN_SAMPLES", c.ConvergenceMinSamples)
+		if c.ConvergenceMinSamples > c.ConvergenceSamples {
+			errs.add("CONVERGENCE_MIN_SAMPLES", "must be at most CONVERGENCE_SAMPLES (%d), got %d", c.ConvergenceSamples, c.ConvergenceMinSamples)
+		}
+	}
+	nonNegative(&errs, "ALERT_COOLDOWN", c.AlertCooldown)
+	nonNegative(&errs, "RABBITMQ_MAX_RESENDS", c.RabbitMQMaxResends)
+	nonNegative(&errs, "RABBITMQ_BUFFER_SIZE", c.RabbitMQBufferSize)
+	nonNegative(&errs, "RABBITMQ_MESSAGE_TTL", c.RabbitMQMessageTTL)
+	nonNegative(&errs, "NATS_RETRY_ATTEMPTS", c.NATSRetryAttempts)
+	nonNegative(&errs, "REDIS_STREAM_MAXLEN", c.RedisStreamMaxLen)
+	nonNegative(&errs, "WEBHOOK_MAX_RETRIES", c.WebhookMaxRetries)
+	nonNegative(&errs, "EXPORT_MAX_BYTES", c.ExportMaxBytes)
+	nonNegative(&errs, "EXPORT_ROTATE_INTERVAL", c.ExportRotateInterval)
+	nonNegative(&errs, "RETENTION_SPREADS", c.RetentionSpreads)
+	nonNegative(&errs, "RETENTION_TICKERS", c.RetentionTickers)
+	nonNegative(&errs, "RETENTION_CANDLES", c.RetentionCandles)
+	nonNegative(&errs, "RETENTION_EXPORT_FILES", c.RetentionExportFiles)
+	nonNegative(&errs, "RETENTION_REDIS_STREAM", c.RetentionRedisStream)
+	nonNegative(&errs, "RECORD_ROTATE_INTERVAL", c.RecordRotateInterval)
+	nonNegative(&errs, "RECORD_RETENTION", c.RecordRetention)
+
+	positive(&errs, "FUNDING_HISTORY_LIMIT", c.FundingHistoryLimit)
+	if c.FundingDivergenceAlerts {
+		positive(&errs, "FUNDING_DIVERGENCE_MIN_APR", c.FundingDivergenceMinAPR)
+	}
+	positive(&errs, "FUNDING_SCHEDULE_HORIZON", c.FundingScheduleHorizon)
+	if c.FundingScheduleHorizon > 7*24*time.Hour {
+		errs.add("FUNDING_SCHEDULE_HORIZON", "must be at most 168h, got %s", c.FundingScheduleHorizon)
+	}
+	nonNegative(&errs, "FUNDING_SCHEDULE_INTERVAL", c.FundingScheduleInterval)
+	nonNegative(&errs, "FUNDING_WARMUP_TIMEOUT", c.FundingWarmupTimeout)
+	// Funding rates polled slower than they may age would be stale between polls
+	slowestFunding := max(c.BinanceFundingInterval, c.MexcFundingInterval)
+	if c.FundingMaxAge <= slowestFunding {
+		errs.add("FUNDING_MAX_AGE", "must be longer than the slowest funding interval (%s), got %s", slowestFunding, c.FundingMaxAge)
+	}
+	for _, period := range c.ReportPeriods {
+		errs.oneOf("REPORT_PERIODS", period, report.PeriodDaily, report.PeriodWeekly)
+	}
+	if len(c.ReportPeriods) > 0 {
+		if c.PostgresURL == "" {
+			errs.add("REPORT_PERIODS", "needs POSTGRES_URL, as reports are built from the stored spreads")
+		}
+		positive(&errs, "REPORT_TOP", c.ReportTop)
+	}
+	if c.FeedbackQueue != "" {
+		if c.PostgresURL == "" {
+			errs.add("FEEDBACK_QUEUE", "needs POSTGRES_URL, as the reports are stored there")
+		}
+		positive(&errs, "FEEDBACK_RETENTION", c.FeedbackRetention)
+	}
+	positive(&errs, "HOLDING_HORIZON", c.HoldingHorizon)
+	positive(&errs, "SPREAD_HISTORY_WINDOW", c.SpreadHistoryWindow)
+	nonNegative(&errs, "SPREAD_MOMENTUM_WINDOW", c.SpreadMomentumWindow)
+	nonNegative(&errs, "SPREAD_MOMENTUM_STABLE_BPS", c.SpreadMomentumStableBps)
+	if c.SpreadCandlesEnabled {
+		if len(c.SpreadCandlesTimeframes) == 0 {
+			errs.add("SPREAD_CANDLES_TIMEFRAMES", "must list at least one timeframe")
+		}
+		for i, tf := range c.SpreadCandlesTimeframes {
+			positive(&errs, "SPREAD_CANDLES_TIMEFRAMES", tf)
+			if slices.Index(c.SpreadCandlesTimeframes, tf) != i {
+				errs.add("SPREAD_CANDLES_TIMEFRAMES", "%s is listed twice", tf)
+			}
+		}
+		positive(&errs, "SPREAD_CANDLES_LIMIT", c.SpreadCandlesLimit)
+	}
+	if c.ScorerURL != "" {
+		positive(&errs, "SCORER_TIMEOUT", c.ScorerTimeout)
+	}
+	nonNegative(&errs, "ENRICHMENT_ASSETS_REFRESH", c.EnrichmentAssetsRefresh)
+	if c.SpreadAnomalyEnabled {
+		if c.SpreadAnomalyAlpha <= 0 || c.SpreadAnomalyAlpha >= 1 {
+			errs.add("SPREAD_ANOMALY_ALPHA", "must be between 0 and 1, got %v", c.SpreadAnomalyAlpha)
+		}
+		positive(&errs, "SPREAD_ANOMALY_THRESHOLD", c.SpreadAnomalyThreshold)
+		nonNegative(&errs, "SPREAD_ANOMALY_MIN_SAMPLES", c.SpreadAnomalyMinSamples)
+	}
+	positive(&errs, "WALLET_STATUS_REFRESH", c.WalletStatusRefresh)
+	positive(&errs, "CONTRACT_REFRESH_INTERVAL", c.ContractRefreshInterval)
+	for key, d := range c.TransferTimes {
+		positive(&errs, "TRANSFER_TIMES["+key+"]", d)
+	}
+	positive(&errs, "TRANSFER_TIME_DEFAULT", c.TransferTimeDefault)
+	nonNegative(&errs, "TRANSFER_HISTORY_REFRESH", c.TransferHistoryRefresh)
+	positive(&errs, "LATENCY_PING_INTERVAL", c.LatencyPingInterval)
+	nonNegative(&errs, "MAINTENANCE_STATUS_INTERVAL", c.MaintenanceStatusInterval)
+	nonNegative(&errs, "MAINTENANCE_LEAD", c.MaintenanceLead)
+	for _, w := range c.MaintenanceWindows {
+		switch {
+		case w.Exchange == "" || w.Start.IsZero():
+			errs.add("MAINTENANCE_WINDOWS", "every window needs an exchange and a start")
+		case w.End.IsZero():
+			errs.add("MAINTENANCE_WINDOWS", "the %s window starting %s needs an end", w.Exchange, w.Start.Format(time.RFC3339))
+		case !w.End.After(w.Start):
+			errs.add("MAINTENANCE_WINDOWS", "the %s window must end after it starts, got %s to %s", w.Exchange, w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+		}
+	}
+	nonNegative(&errs, "OUTAGE_MAX_DATA_AGE", c.OutageMaxDataAge)
+	if c.OutageErrorRate < 0 || c.OutageErrorRate > 1 {
+		errs.add("OUTAGE_ERROR_RATE", "must be between 0 and 1, got %v", c.OutageErrorRate)
+	}
+	positive(&errs, "OUTAGE_ERROR_WINDOW", c.OutageErrorWindow)
+	positive(&errs, "OUTAGE_RECOVERY_FETCHES", c.OutageRecoveryFetches)
+	// An exchange fetched slower than its data may age would be degraded between fetches
+	slowestTickers := max(c.BinanceTickerInterval, c.MexcTickerInterval)
+	if c.GmxEnabled {
+		slowestTickers = max(slowestTickers, c.GmxTickerInterval)
+	}
+	if c.OutageDetectionEnabled && c.OutageMaxDataAge > 0 && c.OutageMaxDataAge <= slowestTickers {
+		errs.add("OUTAGE_MAX_DATA_AGE", "must be longer than the slowest ticker interval (%s), got %s", slowestTickers, c.OutageMaxDataAge)
+	}
+	positive(&errs, "RABBITMQ_CONFIRM_TIMEOUT", c.RabbitMQConfirmTimeout)
+	positive(&errs, "RABBITMQ_RECONNECT_MAX_BACKOFF", c.RabbitMQReconnectMaxBackoff)
+	positive(&errs, "PUBLISH_SINK_QUEUE_SIZE", c.PublishSinkQueueSize)
+	positive(&errs, "WEBHOOK_TIMEOUT", c.WebhookTimeout)
+	positive(&errs, "INFLUX_TIMEOUT", c.InfluxTimeout)
+	positive(&errs, "CLICKHOUSE_BATCH_SIZE", c.ClickHouseBatchSize)
+	positive(&errs, "CLICKHOUSE_FLUSH_INTERVAL", c.ClickHouseFlushInterval)
+	positive(&errs, "OPPORTUNITY_ID_BUCKET", c.OpportunityIDBucket)
+	positive(&errs, "CLICKHOUSE_TIMEOUT", c.ClickHouseTimeout)
+	positive(&errs, "RETENTION_INTERVAL", c.RetentionInterval)
+	positive(&errs, "HEALTH_MAX_AGE", c.HealthMaxAge)
+	if c.OrderBookDepth < 1 || c.OrderBookDepth > 1000 {
+		errs.add("ORDER_BOOK_DEPTH", "must be between 1 and 1000, got %d", c.OrderBookDepth)
+	}
+	nonNegative(&errs, "ORDER_BOOK_MAX_AGE", c.OrderBookMaxAge)
+	// The lease is renewed every third of its TTL, in milliseconds
+	if c.LeaderElection && c.LeaderLeaseTTL < time.Second {
+		errs.add("LEADER_LEASE_TTL", "must be at least 1s, got %s", c.LeaderLeaseTTL)
+	}
+	if c.LeaderElection && !c.RedisConfigured {
+		errs.add("LEADER_ELECTION", "needs REDIS_ADDR or REDIS_PASSWORD, as the lease is kept in Redis")
+	}
+	if c.PublishDedupEnabled {
+		if !c.RedisConfigured {
+			errs.add("PUBLISH_DEDUP_ENABLED", "needs REDIS_ADDR or REDIS_PASSWORD, as the claims are kept in Redis")
+		}
+		// The owner extends its claims every cycle; a shorter TTL lets them lapse between cycles
+		longest, key := c.PollInterval, "POLL_INTERVAL"
+		if c.PollAdaptive && c.PollIntervalMax > longest {
+			longest, key = c.PollIntervalMax, "POLL_INTERVAL_MAX"
+		}
+		if c.PublishDedupTTL <= longest {
+			errs.add("PUBLISH_DEDUP_TTL", "must be longer than %s (%s), got %s", key, longest, c.PublishDedupTTL)
+		}
+	}
+
+	positive(&errs, "POLL_INTERVAL", c.PollInterval)
+	if c.PollAdaptive {
+		positive(&errs, "POLL_INTERVAL_MIN", c.PollIntervalMin)
+		if c.PollIntervalMin > c.PollInterval || c.PollIntervalMax < c.PollInterval {
+			errs.add("POLL_INTERVAL", "must be between POLL_INTERVAL_MIN (%s) and POLL_INTERVAL_MAX (%s), got %s", c.PollIntervalMin, c.PollIntervalMax, c.PollInterval)
+		}
+		if c.HealthMaxAge <= c.PollIntervalMax {
+			errs.add("POLL_INTERVAL_MAX", "must be shorter than HEALTH_MAX_AGE (%s), got %s", c.HealthMaxAge, c.PollIntervalMax)
+		}
+		nonNegative(&errs, "POLL_VOLATILITY_BPS", c.PollVolatilityBps)
+		if c.PollRateLimitPressure < 0 || c.PollRateLimitPressure > 1 {
+			errs.add("POLL_RATE_LIMIT_PRESSURE", "must be between 0 and 1, got %v", c.PollRateLimitPressure)
+		}
+	}
+	nonNegative(&errs, "BINANCE_TICKER_INTERVAL", c.BinanceTickerInterval)
+	nonNegative(&errs, "MEXC_TICKER_INTERVAL", c.MexcTickerInterval)
+	nonNegative(&errs, "GMX_TICKER_INTERVAL", c.GmxTickerInterval)
+	nonNegative(&errs, "BINANCE_FUNDING_INTERVAL", c.BinanceFundingInterval)
+	positive(&errs, "MEXC_FUNDING_INTERVAL", c.MexcFundingInterval)
+	if c.MexcFundingStream && c.MexcStreamSilenceTimeout <= 20*time.Second {
+		errs.add("MEXC_STREAM_SILENCE_TIMEOUT", "must be longer than the 20s websocket ping interval, got %v", c.MexcStreamSilenceTimeout)
+	}
+	positive(&errs, "BINANCE_VOLUME_INTERVAL", c.BinanceVolumeInterval)
+	nonNegative(&errs, "RATE_LIMIT_BACKOFF", c.RateLimitBackoff)
+	nonNegative(&errs, "SECRETS_REFRESH", c.SecretsRefresh)
+	if c.BinanceTestnet && c.SimulatorBinanceURL != "" {
+		errs.add("BINANCE_TESTNET", "cannot be combined with SIMULATOR_BINANCE_URL")
+	}
+	// Reused tickers must still be fresh enough for spreads
+	if c.MaxTickerAge > 0 && c.BinanceTickerInterval >= c.MaxTickerAge {
+		errs.add("BINANCE_TICKER_INTERVAL", "must be shorter than TICKER_MAX_AGE (%s), got %s", c.MaxTickerAge, c.BinanceTickerInterval)
+	}
+	if c.MaxTickerAge > 0 && c.MexcTickerInterval >= c.MaxTickerAge {
+		errs.add("MEXC_TICKER_INTERVAL", "must be shorter than TICKER_MAX_AGE (%s), got %s", c.MaxTickerAge, c.MexcTickerInterval)
+	}
+	if c.GmxEnabled && c.MaxTickerAge > 0 && c.GmxTickerInterval >= c.MaxTickerAge {
+		errs.add("GMX_TICKER_INTERVAL", "must be shorter than TICKER_MAX_AGE (%s), got %s", c.MaxTickerAge, c.GmxTickerInterval)
+	}
+	return errs
+}
+
+// validate checks the notifier settings that parsed but make no sense.
+func (c *NotifierConfig) validate() []error {
+	var errs checks
+	errs.oneOf("LOG_FORMAT", c.LogFormat, logging.FormatText, logging.FormatJSON)
+	errs.namespace(c.Namespace)
+	if c.SMTPHost != "" && (c.SMTPPort <= 0 || c.SMTPPort > 65535) {
+		errs.add("SMTP_PORT", "must be a port number, got %d", c.SMTPPort)
+	}
+	if c.SMTPHost != "" && (c.EmailFrom == "" || len(c.EmailTo) == 0) {
+		errs.add("EMAIL_TO", "EMAIL_FROM and EMAIL_TO must be set when SMTP_HOST is")
+	}
+	if c.TelegramBotToken != "" && len(c.TelegramChatIDs) == 0 && !c.TelegramSubscriptions {
+		errs.add("TELEGRAM_CHAT_IDS", "must be set when TELEGRAM_BOT_TOKEN is, unless TELEGRAM_SUBSCRIPTIONS is enabled")
+	}
+	if c.TelegramSubscriptions && c.TelegramBotToken == "" {
+		errs.add("TELEGRAM_SUBSCRIPTIONS", "needs TELEGRAM_BOT_TOKEN")
+	}
+	if c.TelegramSubscriptions && !c.RedisConfigured {
+		errs.add("TELEGRAM_SUBSCRIPTIONS", "needs REDIS_ADDR or REDIS_PASSWORD, as the subscriptions are kept in Redis")
+	}
+	if c.RabbitMQMaxPriority < 0 || c.RabbitMQMaxPriority > 255 {
+		errs.add("RABBITMQ_MAX_PRIORITY", "must be between 0 and 255, got %d", c.RabbitMQMaxPriority)
+	}
+	nonNegative(&errs, "EMAIL_INSTANT_MIN_SPREAD", c.EmailInstantMinSpread)
+	nonNegative(&errs, "EMAIL_DIGEST_INTERVAL", c.EmailDigestInterval)
+	positive(&errs, "EMAIL_DIGEST_SIZE", c.EmailDigestSize)
+	nonNegative(&errs, "PUSH_HIGH_SPREAD", c.PushHighSpread)
+	nonNegative(&errs, "PUSH_URGENT_SPREAD", c.PushUrgentSpread)
+	nonNegative(&errs, "SECRETS_REFRESH", c.SecretsRefresh)
+	return errs
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateQuoteCurrencies(t *testing.T) {
+	tests := []struct {
+		name   string
+		quotes []string
+		want   int // Number of QUOTE_CURRENCIES errors
+	}{
+		{name: "distinct quotes", quotes: []string{"USDT", "USDC"}},
+		{name: "longer quote first", quotes: []string{"BUSD", "USD"}},
+		{name: "shorter quote first", quotes: []string{"USD", "BUSD"}, want: 1},
+		{name: "shorter quote first of two", quotes: []string{"USD", "BUSD", "TUSD"}, want: 2},
+		{name: "listed twice", quotes: []string{"USDT", "USDC", "USDT"}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{QuoteCurrencies: tt.quotes}
+			var got []error
+			for _, err := range cfg.validate() {
+				if strings.HasPrefix(err.Error(), "QUOTE_CURRENCIES:") {
+					got = append(got, err)
+				}
+			}
+			if len(got) != tt.want {
+				t.Errorf("got errors %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
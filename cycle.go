@@ -0,0 +1,720 @@
+package main
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/fx"
+	"cex-price-diff-notifications/history"
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/logging"
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/metrics"
+	"cex-price-diff-notifications/persistence"
+	"cex-price-diff-notifications/shared"
+	"cex-price-diff-notifications/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// runCycle fetches the exchanges due, calculates the spreads and publishes them. It returns false when a
+// shutdown aborted the fetches, leaving the cycle without data.
+func (s *scanner) runCycle(ctx context.Context, cycleStart time.Time) bool {
+	cfg := s.cfg
+	// A reloaded config changes the per-symbol parameters and score weights from this cycle
+	select {
+	case reloaded := <-s.reloads:
+		s.symbolParams = paramSet(reloaded)
+		s.scorer = arbitrage.WeightedScorer{Weights: arbitrage.ScoreWeights(reloaded.ScoreWeights)}
+	default:
+	}
+	if s.feeTiers != nil {
+		s.symbolParams.Defaults.TakerFeesBps = s.feeTiers.TakerFeesBps(s.symbolParams.Defaults.TakerFeesBps)
+	}
+	s.cycle++
+	cycleCtx, cycleSpan := tracing.Start(ctx, "cycle", attribute.Int64("cycle", int64(s.cycle)))
+	slog.Info("Fetching data...")
+	fetches, spotTickers := s.fetch(cycleCtx, cycleStart)
+
+	// Fetches aborted by a shutdown leave the cycle without data; publishing it would close every opportunity
+	if ctx.Err() != nil {
+		tracing.End(cycleSpan, ctx.Err())
+		return false
+	}
+	// A degraded exchange keeps being fetched, to tell when it recovers, but its tickers are left out
+	publishExchangeChanges(s.outages.Evaluate(time.Now()), s.encoder, s.publisher, s.leading())
+	for exchange := range s.tickerSchedules {
+		if reason, degraded := s.outages.Degraded(exchange); degraded {
+			slog.Debug("Exchange degraded, excluding it from spread calculation", "exchange", exchange, "reason", reason)
+			s.tickerStore.Remove(exchange)
+			delete(spotTickers, exchange)
+		}
+	}
+	tickerSnapshot := s.tickerStore.Snapshot()
+	// Funding rates are copied once per cycle, as the background updates change the adapters' own
+	fundingRates := fundingSnapshot(s.binanceAdapter, s.mexcAdapter, s.gmxAdapter)
+	staleFunding := s.fundingHealth.stale(time.Now())
+	warmingUp := s.fundingHealth.warmingUp(staleFunding, time.Now())
+	// The recording holds what the exchanges returned, before any filtering, so every cycle can be replayed
+	if s.recorder != nil {
+		if err := s.recorder.Record(persistence.NewRecording(s.cycle, cycleStart, tickerSnapshot.ByExchange, fundingRates)); err != nil {
+			slog.Error("Failed to record market data", "error", err)
+		}
+	}
+	// Publishes are not cut short by a shutdown, so a finished cycle is published whole
+	publishCtx := context.WithoutCancel(cycleCtx)
+	// Standby instances keep their state warm (lifecycle, deduplication) but leave publishing and the shared
+	// stores to the leader, so a takeover doesn't republish every open opportunity
+	leading := s.leading()
+	if !leading {
+		slog.Info("Standing by, the leader publishes this cycle")
+	}
+
+	// Exchanges that weren't due this cycle contribute the tickers of their last fetch. Bad data points are
+	// rejected before they surface as phantom opportunities; the filtered copy is the cycle's own
+	allTickers, filterStats := arbitrage.FilterInvalidTickers(tickerSnapshot.Tickers, cfg.MaxPriceDevPct)
+	// FX rates need the stablecoin pairs whatever the shard; only the shard's symbols are scanned
+	fxRates := fx.RatesFromTickers(allTickers, cfg.QuoteCurrencies)
+	maps.DeleteFunc(allTickers, func(symbol string, _ map[string]shared.TickerBidAsk) bool { return !s.symbolShard.Owns(symbol) })
+	tickerCounts := map[string]int{"Binance": 0, "Mexc": 0}
+	if s.gmxAdapter != nil {
+		tickerCounts["GMX"] = 0
+	}
+	for _, byExchange := range allTickers {
+		for exchange := range byExchange {
+			tickerCounts[exchange]++
+		}
+	}
+	for exchange, n := range tickerCounts {
+		metrics.Tickers.WithLabelValues(exchange).Set(float64(n))
+	}
+	if filterStats != (arbitrage.FilterStats{}) {
+		slog.Info("Filtered invalid tickers",
+			"non_positive", filterStats.NonPositive,
+			"crossed", filterStats.Crossed,
+			"outlier", filterStats.Outlier,
+		)
+	}
+
+	// Calculate and log arbitrage opportunities
+	slog.Info("Calculating arbitrage opportunities...")
+	_, calculateSpan := tracing.Start(cycleCtx, "calculate spreads", attribute.String("mode", cfg.SpreadMode), attribute.Int("symbols", len(allTickers)))
+	var spreads []arbitrage.Spread
+	switch cfg.SpreadMode {
+	case config.SpreadModeIncremental:
+		s.liveSpreads.SetMarketData(fundingRates, fxRates)
+		recomputed := s.liveSpreads.Sync(allTickers)
+		spreads = s.liveSpreads.Ranked(time.Now())
+		slog.Info("Recomputed spreads for changed tickers", "changed", recomputed)
+	default:
+		spreads = arbitrage.CalculateSpreads(allTickers, fundingRates, fxRates, s.spreadOpts)
+	}
+	arbitrage.ApplyIndexPrices(spreads, allTickers)
+	if cfg.MaintenanceLead > 0 {
+		arbitrage.ApplyMaintenance(spreads, func(exchange string) (arbitrage.MaintenanceNotice, bool) {
+			w, ok := s.maintenanceMonitor.Upcoming(exchange, cycleStart, cfg.MaintenanceLead)
+			return arbitrage.MaintenanceNotice{Exchange: w.Exchange, Start: w.Start, End: w.End, Reason: w.Reason}, ok
+		})
+	}
+	arbitrage.ApplyFundingHistory(spreads, s.fundingHistory)
+	arbitrage.ApplyContractSpecs(spreads, s.contractSpecs)
+	arbitrage.ApplyExpectedPnL(spreads, s.symbolParams, cfg.HoldingHorizon)
+	arbitrage.ApplyFundingCoverage(spreads, staleFunding)
+	arbitrage.ApplyTargetNotional(spreads, s.symbolParams)
+	if s.accounts != nil {
+		arbitrage.ApplyBalances(spreads, s.accounts, cfg.AccountLeverage)
+	}
+	arbitrage.ApplyOrderSizes(spreads, allTickers)
+	s.spreadHistory.Observe(spreads, time.Now())
+	var closedCandles []history.ClosedCandle
+	if s.spreadCandles != nil {
+		closedCandles = s.spreadCandles.Observe(spreads, time.Now())
+	}
+	if s.anomalyDetector != nil {
+		if anomalies := s.anomalyDetector.Apply(spreads, time.Now()); anomalies > 0 {
+			slog.Info("Detected spread anomalies", "count", anomalies)
+		}
+	}
+	// Runtime settings apply from the cycle after they change
+	settings := s.runtimeSettings.Get()
+	s.symbolParams.Defaults.MinEntrySpreadPct = settings.MinEntrySpreadPct
+	// The gate sees every candidate, so the filters below don't end the hysteresis of a pair still above its
+	// exit threshold
+	if cfg.HysteresisCycles > 0 {
+		s.entryGate.Observe(spreads, s.symbolParams)
+	}
+	if cfg.DynamicThresholdPercentile > 0 {
+		var suppressed int
+		spreads, suppressed = arbitrage.SelectAboveDynamicThreshold(spreads)
+		slog.Info("Applied dynamic spread thresholds", "percentile", cfg.DynamicThresholdPercentile, "suppressed", suppressed)
+	}
+	arbitrage.ApplyLatencyPenalty(spreads, s.exchangeLatency, cfg.LatencyPenaltyPctPerSec)
+	arbitrage.ApplyScores(spreads, s.scorer)
+	if s.modelScorer != nil {
+		scored, dropped, err := arbitrage.ApplyBatchScores(cycleCtx, spreads, s.modelScorer)
+		if err != nil {
+			slog.Warn("Failed to score spreads with the external scorer, ranking by weighted score", "error", err)
+		} else {
+			spreads = scored
+			slog.Info("Applied external scores", "dropped", dropped)
+		}
+	}
+	arbitrage.ApplyFundingWindow(spreads, cfg.FundingWindow, time.Now())
+	spreads = arbitrage.SelectByFundingWindow(spreads, cfg.FundingWindowMode)
+	calculateSpan.SetAttributes(attribute.Int("spreads", len(spreads)))
+	calculateSpan.End()
+
+	candidates := len(spreads)
+	metrics.SetTopSpreads(spreads)
+	if s.poller != nil {
+		s.poller.ObserveSpreads(spreads)
+	}
+	cycleSpreads := slices.Clone(spreads) // Kept for persistence; selection reorders in place
+
+	spreads = slices.DeleteFunc(spreads, func(sp arbitrage.Spread) bool { return s.runtimeSettings.Blocked(sp.UnifiedSymbol) })
+	blocked := candidates - len(spreads)
+	var untradable int
+	if cfg.AccountSkipUntradable {
+		spreads, untradable = arbitrage.SelectTradable(spreads)
+	}
+
+	var belowMin, overLimit int
+	if cfg.HysteresisCycles > 0 {
+		// The gate replaces the plain minimum entry spread rule
+		spreads, belowMin = s.entryGate.Select(spreads)
+	} else {
+		spreads, belowMin = arbitrage.SelectAboveMinimum(spreads, s.symbolParams)
+	}
+	// Opportunities are tracked above the entry threshold, so one ranked out of the publish limit for a cycle
+	// keeps its ID and stays open; the limit applies to the published spreads and lifecycle events
+	eligible := spreads
+	spreads, overLimit = arbitrage.SelectTop(spreads, settings.MaxPublishedSpreads)
+	slog.Info("Applied publish limits",
+		"min_entry_spread_%", settings.MinEntrySpreadPct,
+		"max_published", settings.MaxPublishedSpreads,
+		"blocked", blocked,
+		"untradable", untradable,
+		"below_min", belowMin,
+		"over_limit", overLimit,
+	)
+	// Nothing is published while warming up, so the lifecycle opens what is left once funding is fresh
+	if warmingUp {
+		eligible, spreads = eligible[:0], spreads[:0]
+	}
+	s.spreadIDs.ApplySpreads(eligible, cycleStart)
+	if s.enricher != nil {
+		s.enricher.ApplySpreads(spreads)
+	}
+	var legBooks map[string]map[string]shared.OrderBook
+	if cfg.OrderSizeDepth {
+		legBooks = fetchLegBooks(cycleCtx, s.orderBooks, spreads)
+	}
+	arbitrage.ApplyLegOrders(spreads, allTickers, legBooks, cfg.OrderSizeMaxSlippageBps)
+	if s.convergence != nil {
+		s.convergence.Apply(spreads)
+	}
+	if s.feedbackCollector != nil {
+		s.feedbackCollector.Remember(spreads, time.Now())
+	}
+
+	if len(spreads) == 0 {
+		slog.Info("No arbitrage opportunities found in this cycle.")
+	} else {
+		slog.Info("Top arbitrage opportunities found:")
+		for i, sp := range spreads {
+			if i >= 5 { // Log top 5
+				break
+			}
+			slog.Info("Opportunity",
+				"symbol", sp.UnifiedSymbol,
+				"buy_at", sp.ExchangeLong,
+				"sell_at", sp.ExchangeShort,
+				"entry_spread_%", sp.EntrySpread,
+				"exit_spread_%", sp.ExitSpread,
+			)
+		}
+	}
+
+	// Lifecycle events of every tracked opportunity feed convergence times, the published ones feed the lifecycle
+	// publish mode, WebSocket clients and simulated entries
+	var lifecycleEvents, publishedEvents []lifecycle.Event
+	if cfg.PublishMode == config.PublishModeLifecycle || s.wsHub != nil || s.convergence != nil || (s.exitSignals != nil && cfg.ExitSignalsSimulate) {
+		lifecycleEvents = s.opportunityTracker.Update(eligible, time.Now())
+		publishedEvents = s.opportunityTracker.Publish(lifecycleEvents, spreads, time.Now())
+	}
+	if s.convergence != nil {
+		s.convergence.Observe(lifecycleEvents, time.Now())
+	}
+	// Exit signals are published whatever the publish mode
+	var exitEvents []lifecycle.Event
+	if s.exitSignals != nil {
+		if cfg.ExitSignalsSimulate {
+			s.exitSignals.EnterOpened(publishedEvents, time.Now())
+		}
+		exitEvents = s.exitSignals.Update(allTickers, fundingRates, time.Now())
+		if len(exitEvents) > 0 {
+			slog.Info("Exit signals", "count", len(exitEvents), "watched", len(s.exitSignals.Entries()))
+		}
+	}
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(publishedEvents)
+		s.wsHub.Broadcast(exitEvents)
+	}
+
+	// Build the messages for the configured publish mode
+	var messages []any
+	switch cfg.PublishMode {
+	case config.PublishModeLifecycle:
+		for _, e := range publishedEvents {
+			messages = append(messages, e)
+		}
+	case config.PublishModeSnapshot:
+		// Only the snapshot below is published
+	case config.PublishModeDiff:
+		if delta, changed := s.snapshotDiff.Diff(s.cycle, time.Now(), spreads); changed {
+			slog.Info("Published set changed", "sequence", delta.Sequence, "added", len(delta.Added), "changed", len(delta.Changed), "removed", len(delta.Removed))
+			messages = append(messages, delta)
+		}
+	default:
+		published := spreads
+		if cfg.AlertCooldown > 0 {
+			var duplicates int
+			published, duplicates = s.alertDedup.Apply(spreads, time.Now())
+			slog.Info("Deduplicated alerts", "cooldown", cfg.AlertCooldown, "suppressed", duplicates)
+		}
+		for _, sp := range published {
+			messages = append(messages, sp)
+		}
+	}
+	for _, e := range exitEvents {
+		messages = append(messages, e)
+	}
+	// Funding divergences are published whatever the publish mode and the price spread
+	if cfg.FundingDivergenceAlerts && !warmingUp {
+		found := arbitrage.FindFundingDivergences(allTickers, fundingRates, cfg.FundingDivergenceMinAPR)
+		for i := range found {
+			found[i].OpportunityID = s.divergenceIDs.ID(found[i].PairKey(), cycleStart)
+		}
+		s.divergenceIDs.EndCycle()
+		divergences := s.divergenceAlerts.Update(found)
+		if s.enricher != nil {
+			s.enricher.ApplyFundingDivergences(divergences)
+		}
+		if len(divergences) > 0 {
+			slog.Info("Funding divergences", "count", len(divergences), "min_apr", cfg.FundingDivergenceMinAPR)
+		}
+		for _, d := range divergences {
+			messages = append(messages, d)
+		}
+	}
+
+	// Dashboards get the whole ranked set in one message, even when it's empty
+	snapshot := arbitrage.NewSnapshot(s.cycle, cycleStart, time.Now(), cfg.SpreadMode, len(allTickers), candidates, spreads)
+	snapshot.FundingStale = staleFunding
+	metrics.CycleSpreads.WithLabelValues("candidates").Set(float64(candidates))
+	metrics.CycleSpreads.WithLabelValues("published").Set(float64(len(spreads)))
+	metrics.CycleDuration.Observe(time.Since(cycleStart).Seconds())
+	logging.FlushWarnings()
+	s.health.MarkCycle(time.Now())
+	s.apiState.Update(time.Now(), snapshot, cycleSpreads, allTickers, fundingRates)
+	if cfg.FundingScheduleInterval > 0 && leading && s.fundingCalendarSchedule.due(cycleStart) {
+		publishFundingSchedule(publishCtx, fundingRates, cycleSpreads, cfg.FundingScheduleHorizon, s.encoder, s.publisher)
+		s.fundingCalendarSchedule.done(cycleStart)
+	}
+	if (cfg.PublishMode == config.PublishModeSnapshot || cfg.PublishSnapshot) && !warmingUp {
+		messages = append(messages, snapshot)
+	}
+	if s.latestRedis != nil && leading {
+		if body, err := s.encoder.Encode(messaging.EventSpreadSnapshot, snapshot); err != nil {
+			slog.Error("Failed to marshal the latest snapshot to JSON", "error", err)
+		} else if err := s.latestRedis.Set(context.Background(), cfg.RedisKey(cfg.RedisLatestKey), body, cfg.RedisLatestTTL).Err(); err != nil {
+			slog.Error("Failed to write the latest snapshot to Redis", "key", cfg.RedisKey(cfg.RedisLatestKey), "error", err)
+		}
+	}
+
+	if len(messages) > 0 && leading {
+		s.publishMessages(publishCtx, messages)
+	}
+	if leading {
+		s.store(cycleStart, cycleSpreads, allTickers, tickerCounts, closedCandles)
+	}
+	s.export(cycleStart, cycleSpreads, allTickers, fundingRates)
+
+	// Funding-only, spot-spot and triangular opportunities go through their own queues
+	if cfg.FundingArbEnabled && leading && !warmingUp {
+		s.publishFundingOpportunities(publishCtx, cycleStart, allTickers, fundingRates)
+	}
+	if cfg.SpotArbEnabled && leading {
+		s.publishSpotSpreads(publishCtx, cycleStart, spotTickers, fxRates, settings.MaxPublishedSpreads)
+	}
+	// Triangles span symbols of several shards, so the first shard publishes them all
+	if cfg.TriangularEnabled && leading && s.symbolShard.Index() == 0 {
+		s.publishTriangular(publishCtx, cycleStart, spotTickers)
+	}
+
+	// The heartbeat goes out every cycle, even without opportunities, so a stuck producer shows
+	if cfg.PublishHeartbeat {
+		stats := newHeartbeat(s.cycle, cfg.InstanceID, leading, cycleStart, fetches, s.paused, tickerCounts)
+		stats.Symbols, stats.Candidates, stats.Published = len(allTickers), candidates, len(spreads)
+		if leading {
+			stats.Messages = len(messages)
+		}
+		if body, err := s.encoder.Encode(messaging.EventCycleStats, stats); err != nil {
+			slog.Error("Failed to marshal the cycle heartbeat to JSON", "error", err)
+		} else if err := s.publishToQueue(publishCtx, rabbitMQStatsQueueName, body); err != nil {
+			slog.Error("Failed to publish the cycle heartbeat", "error", err)
+		}
+	}
+	cycleSpan.End()
+	return true
+}
+
+// fetch pauses the exchanges under maintenance, fetches the tickers and funding rates of the others that are
+// due into the ticker store, and returns the outcome of the fetches and the spot tickers, keyed by exchange.
+func (s *scanner) fetch(cycleCtx context.Context, cycleStart time.Time) (*cycleFetches, map[string][]shared.TickerBidAsk) {
+	cfg := s.cfg
+	fetches := &cycleFetches{}
+	// An exchange under maintenance isn't fetched, and its last tickers are dropped rather than reused
+	for exchange := range s.tickerSchedules {
+		w, down := s.maintenanceMonitor.Active(exchange, cycleStart)
+		if down != s.paused[exchange] {
+			if down {
+				slog.Warn("Exchange under maintenance, pausing its fetches", "exchange", exchange, "reason", w.Reason, "until", w.End)
+			} else {
+				slog.Info("Exchange maintenance is over, resuming its fetches", "exchange", exchange)
+			}
+			s.health.SetMaintenance(exchange, down)
+			s.outages.SetPaused(exchange, down, cycleStart)
+		}
+		s.paused[exchange] = down
+		if down {
+			s.tickerStore.Remove(exchange)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Fetch the tickers of each exchange due
+	for exchange, fetch := range s.tickerFetchers {
+		if s.paused[exchange] || !s.tickerSchedules[exchange].due(cycleStart) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetchCtx, span := tracing.Start(cycleCtx, "fetch tickers", attribute.String("exchange", exchange))
+			tickers, duration, err := fetch(fetchCtx)
+			span.SetAttributes(attribute.Int("count", len(tickers)))
+			tracing.End(span, err)
+			fetches.observe(exchange, metrics.FetchTickers, duration, err)
+			s.outages.Observe(exchange, err, time.Now())
+			if err != nil {
+				slog.Error("Failed to get tickers", "exchange", exchange, "code", adapters.CodeOf(err), "error", err)
+				s.health.MarkFailed(exchange, err)
+				s.tickerStore.Remove(exchange)
+				if backoff := s.tickerSchedules[exchange].failed(err, cycleStart, cfg.RateLimitBackoff); backoff > 0 {
+					slog.Warn("Exchange rate limited the ticker fetches, backing off", "exchange", exchange, "backoff", backoff)
+				}
+				return
+			}
+			slog.Info("Tickers fetched", "exchange", exchange, "count", len(tickers), "duration", duration)
+			s.health.MarkFetched(exchange, time.Now())
+
+			s.tickerStore.Set(exchange, tickers, time.Now())
+			s.tickerSchedules[exchange].done(cycleStart)
+		}()
+	}
+
+	// Update Binance funding rates, unless the mark price stream is pushing them
+	if !s.paused["Binance"] && s.binanceFundingSchedule.due(cycleStart) && !(cfg.BinanceFundingStream && s.binanceAdapter.FundingStreamLive(cycleStart)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetchCtx, span := tracing.Start(cycleCtx, "fetch funding rates", attribute.String("exchange", "Binance"))
+			duration, err := s.binanceAdapter.UpdateFundingRates(fetchCtx)
+			tracing.End(span, err)
+			fetches.observe("Binance", metrics.FetchFunding, duration, err)
+			if err != nil {
+				slog.Error("Failed to update Binance funding rates", "code", adapters.CodeOf(err), "error", err)
+				if backoff := s.binanceFundingSchedule.failed(err, cycleStart, cfg.RateLimitBackoff); backoff > 0 {
+					slog.Warn("Binance rate limited the funding rate fetches, backing off", "backoff", backoff)
+				}
+				return
+			}
+			slog.Info("Binance funding rates updated", "duration", duration)
+			s.binanceFundingSchedule.done(cycleStart)
+		}()
+	}
+
+	// Fetch spot tickers for triangular and spot-spot scanning, keyed by exchange
+	spotTickers := make(map[string][]shared.TickerBidAsk)
+	if cfg.TriangularEnabled || cfg.SpotArbEnabled {
+		spotFetchers := map[string]func(context.Context) ([]shared.TickerBidAsk, time.Duration, error){
+			"Binance": s.binanceAdapter.GetSpotTickers,
+			"Mexc":    s.mexcAdapter.GetSpotTickers,
+		}
+		for exchange, fetch := range spotFetchers {
+			if s.paused[exchange] {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fetchCtx, span := tracing.Start(cycleCtx, "fetch spot tickers", attribute.String("exchange", exchange))
+				tickers, duration, err := fetch(fetchCtx)
+				span.SetAttributes(attribute.Int("count", len(tickers)))
+				tracing.End(span, err)
+				fetches.observe(exchange, metrics.FetchSpotTickers, duration, err)
+				if err != nil {
+					slog.Error("Failed to get spot tickers", "exchange", exchange, "error", err)
+					return
+				}
+				slog.Info("Spot tickers fetched", "exchange", exchange, "count", len(tickers), "duration", duration)
+				mu.Lock()
+				spotTickers[exchange] = tickers
+				mu.Unlock()
+			}()
+		}
+	}
+
+	wg.Wait()
+	return fetches, spotTickers
+}
+
+// publishMessages publishes a cycle's events to every sink, leaving out those of opportunities another
+// instance claimed and those built from data older than the publish SLO.
+func (s *scanner) publishMessages(ctx context.Context, messages []any) {
+	cfg := s.cfg
+	// Each sink's publish is traced under this span, once its queue gets to the event
+	ctx, publishSpan := tracing.Start(ctx, "publish events", attribute.Int("count", len(messages)))
+	defer publishSpan.End()
+	// The events of opportunities another instance claimed are left to it
+	var claimed []bool
+	if s.claims != nil {
+		ids := make([]string, len(messages))
+		for i, m := range messages {
+			ids[i] = opportunityID(m)
+		}
+		var err error
+		if claimed, err = s.claims.Claim(ctx, ids); err != nil {
+			metrics.ClaimErrors.Inc()
+			slog.Error("Failed to claim opportunities, publishing them unclaimed", "error", err)
+		}
+	}
+	var stale, duplicates int
+	var maxDataAge time.Duration
+	for i, m := range messages {
+		if claimed != nil && !claimed[i] {
+			duplicates++
+			metrics.DuplicatesSuppressed.WithLabelValues(eventType(m)).Inc()
+			continue
+		}
+		m, age, fresh := applyDataAgeSLO(m, eventType(m), time.Now(), cfg.PublishMaxDataAge)
+		maxDataAge = max(maxDataAge, age)
+		if !fresh {
+			stale++
+			continue
+		}
+
+		// Publish to every sink
+		body, err := s.encoder.Encode(eventType(m), m)
+		if err != nil {
+			slog.Error("Failed to marshal message to JSON", "error", err)
+			// Malformed messages are kept in the dead-letter queue for inspection
+			if s.rabbit != nil && cfg.RabbitMQDeadLetterExchange != "" {
+				if err := s.rabbit.DeadLetter(context.Background(), routingKey(m), fmt.Appendf(nil, "%+v", m), err.Error()); err != nil {
+					slog.Error("Failed to dead-letter a malformed message", "error", err)
+				}
+			}
+			continue
+		}
+
+		err = s.publisher.Publish(ctx, messaging.Event{
+			Type:        eventType(m),
+			Key:         symbolKey(m),
+			RoutingKey:  routingKey(m),
+			Body:        body,
+			Priority:    messaging.SpreadPriority(entrySpread(m), cfg.RabbitMQPriorityScalePct, s.maxPriority),
+			EntrySpread: entrySpread(m),
+		})
+		if err != nil {
+			slog.Error("Failed to publish a message", "error", err)
+		}
+	}
+	slog.Info("Published arbitrage opportunities", "sinks", cfg.PublishSinks, "count", len(messages)-stale-duplicates, "mode", cfg.PublishMode,
+		"stale", stale, "duplicates", duplicates, "max_data_age", maxDataAge)
+}
+
+// store writes a cycle's spreads, tickers and closed candles to the shared history stores, which only the
+// leader writes.
+func (s *scanner) store(cycleStart time.Time, cycleSpreads []arbitrage.Spread, allTickers map[string]map[string]shared.TickerBidAsk, tickerCounts map[string]int, closedCandles []history.ClosedCandle) {
+	cfg := s.cfg
+	if s.postgres != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := s.postgres.WriteSpreads(ctx, s.cycle, cycleStart, cycleSpreads); err != nil {
+			slog.Error("Failed to store spreads in Postgres", "error", err)
+		}
+		if cfg.PostgresStoreTickers {
+			if err := s.postgres.WriteTickers(ctx, cycleStart, allTickers); err != nil {
+				slog.Error("Failed to store tickers in Postgres", "error", err)
+			}
+		}
+		if err := s.postgres.WriteExchangeCycles(ctx, cycleStart, tickerCounts); err != nil {
+			slog.Error("Failed to store exchange cycles in Postgres", "error", err)
+		}
+		if len(closedCandles) > 0 {
+			if err := s.postgres.WriteCandles(ctx, closedCandles); err != nil {
+				slog.Error("Failed to store spread candles in Postgres", "error", err)
+			}
+		}
+		cancel()
+	}
+	if s.clickhouse != nil {
+		s.clickhouse.Add(s.cycle, cycleStart, cycleSpreads)
+	}
+	if s.influx != nil {
+		if err := s.influx.Write(context.Background(), cycleStart, cycleSpreads, s.exchangeLatency.All()); err != nil {
+			slog.Error("Failed to write series to InfluxDB", "error", err)
+		}
+	}
+}
+
+// export writes a cycle's spreads, tickers and funding rates to the instance's own export files.
+func (s *scanner) export(cycleStart time.Time, cycleSpreads []arbitrage.Spread, allTickers map[string]map[string]shared.TickerBidAsk, fundingRates map[string]map[string]shared.FundingRateInfo) {
+	if s.exporter == nil {
+		return
+	}
+	if err := s.exporter.WriteSpreads(s.cycle, cycleStart, cycleSpreads); err != nil {
+		slog.Error("Failed to export spreads", "error", err)
+	}
+	if err := s.exporter.WriteTickers(cycleStart, allTickers); err != nil {
+		slog.Error("Failed to export tickers", "error", err)
+	}
+	if s.cfg.ExportTickers {
+		if err := s.exporter.WriteFunding(cycleStart, fundingRates); err != nil {
+			slog.Error("Failed to export funding rates", "error", err)
+		}
+	}
+}
+
+// publishFundingOpportunities publishes the funding-only opportunities to their own queue.
+func (s *scanner) publishFundingOpportunities(ctx context.Context, cycleStart time.Time, allTickers map[string]map[string]shared.TickerBidAsk, fundingRates map[string]map[string]shared.FundingRateInfo) {
+	fundingOpportunities := arbitrage.FindFundingOpportunities(allTickers, fundingRates, arbitrage.FundingArbitrageOptions{
+		MinAnnualizedRate: s.cfg.FundingArbMinAPR,
+		MaxPriceSpread:    s.cfg.FundingArbMaxPriceSpread,
+	})
+	for i := range fundingOpportunities {
+		fundingOpportunities[i].OpportunityID = s.fundingIDs.ID(fundingOpportunities[i].PairKey(), cycleStart)
+	}
+	s.fundingIDs.EndCycle()
+	if s.enricher != nil {
+		s.enricher.ApplyFundingOpportunities(fundingOpportunities)
+	}
+	for _, o := range fundingOpportunities {
+		body, err := s.encoder.Encode(messaging.EventFundingOpportunity, o)
+		if err != nil {
+			slog.Error("Failed to marshal funding opportunity to JSON", "error", err)
+			continue
+		}
+
+		err = s.publishToQueue(ctx, rabbitMQFundingQueueName, body)
+		if err != nil {
+			slog.Error("Failed to publish a message to RabbitMQ", "error", err)
+		}
+	}
+	slog.Info("Published funding opportunities to RabbitMQ", "count", len(fundingOpportunities))
+}
+
+// publishSpotSpreads publishes the spot-spot spreads to their own queue, annotated with the transfer route.
+func (s *scanner) publishSpotSpreads(ctx context.Context, cycleStart time.Time, spotTickers map[string][]shared.TickerBidAsk, fxRates fx.Rates, maxPublished int) {
+	cfg := s.cfg
+	spotBySymbol := make(map[string]map[string]shared.TickerBidAsk)
+	for exchange, tickers := range spotTickers {
+		for _, t := range tickers {
+			if !s.symbolShard.Owns(t.UnifiedSymbol) {
+				continue
+			}
+			if _, ok := spotBySymbol[t.UnifiedSymbol]; !ok {
+				spotBySymbol[t.UnifiedSymbol] = make(map[string]shared.TickerBidAsk)
+			}
+			spotBySymbol[t.UnifiedSymbol][exchange] = t
+		}
+	}
+	spotBySymbol, _ = arbitrage.FilterInvalidTickers(spotBySymbol, cfg.MaxPriceDevPct)
+
+	// Spot tickers carry no volume, so the liquidity filters are not applied
+	spotSpreads := arbitrage.CalculateSpreads(spotBySymbol, nil, fxRates, arbitrage.Options{
+		MaxTickerAge:  cfg.MaxTickerAge,
+		SlippageBps:   cfg.SlippageBps,
+		AllDirections: cfg.SpreadAllDirections,
+		Workers:       cfg.SpreadWorkers,
+	})
+	arbitrage.ApplyTargetNotional(spotSpreads, s.symbolParams)
+	s.spotHistory.Observe(spotSpreads, time.Now())
+	arbitrage.ApplyTransferFeasibility(spotSpreads, spotBySymbol, s.walletStatus, s.transferTimes)
+	if cfg.SpotTransferSuppress {
+		var suppressed int
+		spotSpreads, suppressed = arbitrage.SelectTransferable(spotSpreads)
+		slog.Info("Suppressed spot spreads without a transfer route", "count", suppressed)
+	}
+	spotSpreads = slices.DeleteFunc(spotSpreads, func(sp arbitrage.Spread) bool { return s.runtimeSettings.Blocked(sp.UnifiedSymbol) })
+	spotSpreads, _, _ = arbitrage.SelectForPublishing(spotSpreads, s.symbolParams, maxPublished)
+	s.spotIDs.ApplySpreads(spotSpreads, cycleStart)
+	if s.enricher != nil {
+		s.enricher.ApplySpreads(spotSpreads)
+	}
+
+	for _, sp := range spotSpreads {
+		m, _, fresh := applyDataAgeSLO(sp, messaging.EventSpotSpread, time.Now(), cfg.PublishMaxDataAge)
+		if !fresh {
+			continue
+		}
+		body, err := s.encoder.Encode(messaging.EventSpotSpread, m)
+		if err != nil {
+			slog.Error("Failed to marshal spot spread to JSON", "error", err)
+			continue
+		}
+
+		err = s.publishToQueue(ctx, rabbitMQSpotQueueName, body)
+		if err != nil {
+			slog.Error("Failed to publish a message to RabbitMQ", "error", err)
+		}
+	}
+	slog.Info("Published spot opportunities to RabbitMQ", "count", len(spotSpreads))
+}
+
+// publishTriangular publishes each exchange's triangular opportunities to their own queue.
+func (s *scanner) publishTriangular(ctx context.Context, cycleStart time.Time, spotTickers map[string][]shared.TickerBidAsk) {
+	spotFees := s.cfg.SpotTakerFeesBps
+	if s.feeTiers != nil {
+		spotFees = s.feeTiers.SpotTakerFeesBps(spotFees)
+	}
+	for exchange, tickers := range spotTickers {
+		triangular := arbitrage.FindTriangularOpportunities(exchange, tickers, spotFees[exchange], s.cfg.TriangularMinProfit)
+		for i := range triangular {
+			triangular[i].OpportunityID = s.triangularIDs.ID(triangular[i].Key(), cycleStart)
+		}
+		for _, o := range triangular {
+			body, err := s.encoder.Encode(messaging.EventTriangularOpportunity, o)
+			if err != nil {
+				slog.Error("Failed to marshal triangular opportunity to JSON", "error", err)
+				continue
+			}
+
+			err = s.publishToQueue(ctx, rabbitMQTriangularQueueName, body)
+			if err != nil {
+				slog.Error("Failed to publish a message to RabbitMQ", "error", err)
+			}
+		}
+		slog.Info("Published triangular opportunities to RabbitMQ", "exchange", exchange, "count", len(triangular))
+	}
+	s.triangularIDs.EndCycle()
+}
@@ -0,0 +1,120 @@
+package enrichment
+
+import (
+	"cex-price-diff-notifications/shared"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	coinGeckoPageSize = 250 // Largest page of /coins/markets
+	coinGeckoPages    = 4   // Pages of coins by market cap fetched, so the top 1000 are known
+)
+
+// CoinGecko reads asset metadata from the CoinGecko API.
+type CoinGecko struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewCoinGecko creates a client of the CoinGecko API at baseURL (e.g., "https://api.coingecko.com/api/v3"),
+// authenticated by apiKey if set: a demo key for the public API, a pro key for pro-api.coingecko.com.
+func NewCoinGecko(baseURL, apiKey string) *CoinGecko {
+	return &CoinGecko{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// coinGeckoMarket is an entry of /coins/markets.
+type coinGeckoMarket struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+}
+
+// Fetch returns the metadata of the largest coins by market cap, by uppercase ticker, plus the coins of ids
+// (ticker -> CoinGecko ID), which take precedence: tickers are not unique, and the largest coin with a ticker
+// isn't always the one the exchanges list.
+func (c *CoinGecko) Fetch(ctx context.Context, ids map[string]string) (map[string]shared.AssetInfo, error) {
+	assets := make(map[string]shared.AssetInfo, coinGeckoPages*coinGeckoPageSize)
+	for page := 1; page <= coinGeckoPages; page++ {
+		markets, err := c.markets(ctx, url.Values{"order": {"market_cap_desc"}, "page": {strconv.Itoa(page)}})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range markets {
+			ticker := strings.ToUpper(m.Symbol)
+			if _, ok := assets[ticker]; !ok {
+				assets[ticker] = m.assetInfo()
+			}
+		}
+		if len(markets) < coinGeckoPageSize {
+			break
+		}
+	}
+
+	if len(ids) == 0 {
+		return assets, nil
+	}
+	tickers := make(map[string]string, len(ids)) // CoinGecko ID -> ticker
+	list := make([]string, 0, len(ids))
+	for ticker, id := range ids {
+		tickers[id] = ticker
+		list = append(list, id)
+	}
+	markets, err := c.markets(ctx, url.Values{"ids": {strings.Join(list, ",")}})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range markets {
+		assets[tickers[m.ID]] = m.assetInfo()
+	}
+	return assets, nil
+}
+
+func (m coinGeckoMarket) assetInfo() shared.AssetInfo {
+	return shared.AssetInfo{CoinGeckoID: m.ID, Name: m.Name, IconURL: m.Image}
+}
+
+// markets reads a page of /coins/markets, priced in USD.
+func (c *CoinGecko) markets(ctx context.Context, query url.Values) ([]coinGeckoMarket, error) {
+	query.Set("vs_currency", "usd")
+	query.Set("per_page", strconv.Itoa(coinGeckoPageSize))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/coins/markets?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CoinGecko request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		header := "x-cg-demo-api-key"
+		if strings.Contains(c.baseURL, "pro-api.") {
+			header = "x-cg-pro-api-key"
+		}
+		req.Header.Set(header, c.apiKey)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CoinGecko markets: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("CoinGecko returned %s: %s", resp.Status, body)
+	}
+	var markets []coinGeckoMarket
+	if err := json.NewDecoder(resp.Body).Decode(&markets); err != nil {
+		return nil, fmt.Errorf("failed to decode CoinGecko markets: %w", err)
+	}
+	return markets, nil
+}
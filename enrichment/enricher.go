@@ -0,0 +1,127 @@
+// Package enrichment attaches what consumers need to present events to them: exchange display names, trading
+// pages of both legs and base asset metadata, so notifiers and dashboards don't hardcode per-exchange formats.
+package enrichment
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Enricher sets the enrichment of events. Asset metadata is cached in memory and refreshed by Run; the links
+// and names of each market are computed once.
+type Enricher struct {
+	coinGecko *CoinGecko        // nil disables asset metadata
+	ids       map[string]string // Ticker -> CoinGecko ID, for tickers shared by several coins
+
+	mu     sync.RWMutex
+	assets map[string]shared.AssetInfo // Uppercase ticker -> metadata
+	links  map[string]string           // Exchange "|" unified symbol -> trading page
+}
+
+// NewEnricher creates an enricher reading asset metadata from coinGecko, if not nil, with ids overriding the
+// coin a ticker stands for (e.g., "PEPE" -> "pepe").
+func NewEnricher(coinGecko *CoinGecko, ids map[string]string) *Enricher {
+	return &Enricher{
+		coinGecko: coinGecko,
+		ids:       ids,
+		assets:    make(map[string]shared.AssetInfo),
+		links:     make(map[string]string),
+	}
+}
+
+// Refresh re-fetches the asset metadata. On failure the cached metadata is kept.
+func (e *Enricher) Refresh(ctx context.Context) {
+	if e.coinGecko == nil {
+		return
+	}
+	assets, err := e.coinGecko.Fetch(ctx, e.ids)
+	if err != nil {
+		slog.Error("Failed to refresh asset metadata", "error", err)
+		return
+	}
+	e.mu.Lock()
+	e.assets = assets
+	e.mu.Unlock()
+	slog.Info("Refreshed asset metadata", "assets", len(assets))
+}
+
+// Run refreshes the asset metadata now and then every interval until ctx is cancelled. It should be run in a
+// goroutine.
+func (e *Enricher) Run(ctx context.Context, interval time.Duration) {
+	e.Refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Refresh(ctx)
+		}
+	}
+}
+
+// Enrichment returns the enrichment of an event on a symbol between two exchanges. longSymbol is the long
+// leg's market, which differs from symbol for cross-quote spreads.
+func (e *Enricher) Enrichment(exchangeShort, symbol, exchangeLong, longSymbol string) *arbitrage.Enrichment {
+	enrichment := &arbitrage.Enrichment{
+		ExchangeShortName: DisplayName(exchangeShort),
+		ExchangeLongName:  DisplayName(exchangeLong),
+		TradeURLShort:     e.tradeURL(exchangeShort, symbol),
+		TradeURLLong:      e.tradeURL(exchangeLong, longSymbol),
+	}
+	if sym, err := shared.ParseSymbol(symbol); err == nil {
+		e.mu.RLock()
+		asset, ok := e.assets[strings.ToUpper(sym.Base())]
+		e.mu.RUnlock()
+		if ok {
+			enrichment.Asset = &asset
+		}
+	}
+	return enrichment
+}
+
+// tradeURL returns the trading page of a market, computing it on first use.
+func (e *Enricher) tradeURL(exchange, symbol string) string {
+	key := exchange + "|" + symbol
+	e.mu.RLock()
+	link, ok := e.links[key]
+	e.mu.RUnlock()
+	if ok {
+		return link
+	}
+	link = TradeURL(exchange, symbol)
+	e.mu.Lock()
+	e.links[key] = link
+	e.mu.Unlock()
+	return link
+}
+
+// ApplySpreads sets the enrichment of every spread.
+func (e *Enricher) ApplySpreads(spreads []arbitrage.Spread) {
+	for i := range spreads {
+		s := &spreads[i]
+		s.Enrichment = e.Enrichment(s.ExchangeShort, s.UnifiedSymbol, s.ExchangeLong, s.LongSymbol())
+	}
+}
+
+// ApplyFundingOpportunities sets the enrichment of every funding opportunity.
+func (e *Enricher) ApplyFundingOpportunities(opportunities []arbitrage.FundingOpportunity) {
+	for i := range opportunities {
+		o := &opportunities[i]
+		o.Enrichment = e.Enrichment(o.ExchangeShort, o.UnifiedSymbol, o.ExchangeLong, o.UnifiedSymbol)
+	}
+}
+
+// ApplyFundingDivergences sets the enrichment of every funding divergence.
+func (e *Enricher) ApplyFundingDivergences(divergences []arbitrage.FundingDivergence) {
+	for i := range divergences {
+		d := &divergences[i]
+		d.Enrichment = e.Enrichment(d.ExchangeShort, d.UnifiedSymbol, d.ExchangeLong, d.UnifiedSymbol)
+	}
+}
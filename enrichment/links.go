@@ -0,0 +1,71 @@
+package enrichment
+
+import (
+	"cex-price-diff-notifications/shared"
+	"strings"
+)
+
+// displayNames are the exchanges' names as they brand themselves, by lowercase exchange name.
+var displayNames = map[string]string{
+	"binance": "Binance",
+	"mexc":    "MEXC",
+	"gmx":     "GMX",
+}
+
+// DisplayName returns the name an exchange brands itself with (e.g., "MEXC" for "Mexc"), or the exchange
+// name itself if unknown.
+func DisplayName(exchange string) string {
+	if name, ok := displayNames[strings.ToLower(exchange)]; ok {
+		return name
+	}
+	return exchange
+}
+
+// TradeURL returns the trading page of a unified symbol on an exchange, or "" for unknown exchanges and
+// symbols. Perpetuals link to the futures UI and spot markets to the spot UI.
+func TradeURL(exchange, unifiedSymbol string) string {
+	sym, err := shared.ParseSymbol(unifiedSymbol)
+	if err != nil {
+		return ""
+	}
+	base, quote := sym.Base(), sym.Quote()
+
+	switch strings.ToLower(exchange) {
+	case "binance":
+		if sym.IsSpot() {
+			return "https://www.binance.com/en/trade/" + base + "_" + quote
+		}
+		return "https://www.binance.com/en/futures/" + base + quote
+	case "mexc":
+		if sym.IsSpot() {
+			return "https://www.mexc.com/exchange/" + base + "_" + quote
+		}
+		return "https://futures.mexc.com/exchange/" + base + "_" + quote
+	case "gmx":
+		if sym.IsSpot() {
+			return ""
+		}
+		return "https://app.gmx.io/#/trade" // The market is picked in the app
+	default:
+		return ""
+	}
+}
+
+// ChartURL returns a TradingView chart of a unified symbol on an exchange, or "" for unknown exchanges and
+// symbols.
+func ChartURL(exchange, unifiedSymbol string) string {
+	switch strings.ToLower(exchange) {
+	case "binance", "mexc":
+	default:
+		return ""
+	}
+	sym, err := shared.ParseSymbol(unifiedSymbol)
+	if err != nil {
+		return ""
+	}
+	ticker := strings.ToUpper(exchange) + ":" + sym.Base() + sym.Quote()
+	if !sym.IsSpot() {
+		ticker += ".P" // TradingView's suffix for perpetual contracts
+	}
+	return "https://www.tradingview.com/chart/?symbol=" + ticker
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/execution"
+	"cex-price-diff-notifications/risk"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// runExecute places real orders on both legs of one symbol's best spread, after printing the plan and
+// asking for the symbol to be typed back. It refuses to run unless EXECUTION_ENABLED is set.
+func runExecute(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("execute", flag.ContinueOnError)
+	sell := fs.String("sell", "", "exchange of the short leg (default: that of the best spread)")
+	buy := fs.String("buy", "", "exchange of the long leg (default: that of the best spread)")
+	notional := fs.Float64("notional", cfg.ExecutionMaxNotional, "notional per leg, in the quote currency, at most EXECUTION_MAX_NOTIONAL")
+	minEntry := fs.Float64("min", cfg.MinEntrySpreadPct, "abort if the entry spread is below this, in percent, when planned or re-checked")
+	dryRun := fs.Bool("dry-run", false, "print the plan without placing orders")
+	// The symbol may come before or after the flags
+	var symbol string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		symbol, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if symbol == "" {
+		symbol = fs.Arg(0)
+	}
+	if symbol == "" {
+		return errors.New(`usage: execute <symbol> [flags], e.g., "execute BTC -sell Mexc -buy Binance"`)
+	}
+	if !cfg.ExecutionEnabled && !*dryRun {
+		return errors.New("live execution is disabled, set EXECUTION_ENABLED=true (or pass -dry-run)")
+	}
+	if *notional <= 0 || *notional > cfg.ExecutionMaxNotional {
+		return fmt.Errorf("-notional must be positive and at most EXECUTION_MAX_NOTIONAL (%g)", cfg.ExecutionMaxNotional)
+	}
+	symbol = strings.ToUpper(symbol)
+	if !strings.Contains(symbol, "/") {
+		symbol = shared.PerpSymbol(symbol, shared.DefaultQuoteCurrency()).String()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opts := execution.Options{
+		MaxNotional:      *notional,
+		OrderType:        execution.OrderType(cfg.ExecutionOrderType),
+		LimitSlippageBps: cfg.ExecutionLimitSlippageBps,
+		FillTimeout:      cfg.ExecutionFillTimeout,
+		PollInterval:     500 * time.Millisecond,
+	}
+
+	m, err := fetchMarket(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	specs := make(map[string]map[string]shared.ContractSpec)
+	if specs["Binance"], err = m.binance.GetContractSpecs(ctx); err != nil {
+		return fmt.Errorf("failed to get Binance contract specs: %w", err)
+	}
+	if specs["Mexc"], err = m.mexc.GetContractSpecs(ctx); err != nil {
+		return fmt.Errorf("failed to get Mexc contract specs: %w", err)
+	}
+	// plan sizes the best spread of the symbol in the requested direction from the last fetch
+	plan := func() (execution.Plan, error) {
+		spreadOpts := spreadOptions(cfg)
+		spreadOpts.AllDirections = true
+		var best *arbitrage.Spread
+		for _, s := range m.spreads(cfg, spreadOpts) {
+			if s.UnifiedSymbol != symbol || s.UnifiedSymbolLong != "" ||
+				(*sell != "" && !strings.EqualFold(s.ExchangeShort, *sell)) || (*buy != "" && !strings.EqualFold(s.ExchangeLong, *buy)) {
+				continue
+			}
+			if best == nil || s.EntrySpread > best.EntrySpread {
+				best = &s
+			}
+		}
+		if best == nil {
+			return execution.Plan{}, fmt.Errorf("no spread for %s in that direction", symbol)
+		}
+		if best.EntrySpread < *minEntry {
+			return execution.Plan{}, fmt.Errorf("entry spread of %s is %.4f%%, below %.4f%%", symbol, best.EntrySpread, *minEntry)
+		}
+		shortSpec, ok := specs[best.ExchangeShort][symbol]
+		if !ok {
+			return execution.Plan{}, fmt.Errorf("no %s contract spec for %s", best.ExchangeShort, symbol)
+		}
+		longSpec, ok := specs[best.ExchangeLong][symbol]
+		if !ok {
+			return execution.Plan{}, fmt.Errorf("no %s contract spec for %s", best.ExchangeLong, symbol)
+		}
+		tickers := m.tickers[symbol]
+		return execution.NewPlan(*best, tickers[best.ExchangeShort], tickers[best.ExchangeLong], shortSpec, longSpec, opts)
+	}
+
+	p, err := plan()
+	if err != nil {
+		return err
+	}
+	if err := printPlan(p); err != nil {
+		return err
+	}
+
+	// The risk limits account for the positions already open on the accounts
+	limits := risk.NewManager(riskLimits(cfg))
+	open := make(map[string][]shared.Position)
+	if open["Binance"], err = m.binance.GetPositions(ctx); err == nil {
+		open["Mexc"], err = m.mexc.GetPositions(ctx)
+	}
+	if err != nil {
+		if !*dryRun {
+			return fmt.Errorf("failed to get the open positions for the risk limits: %w", err)
+		}
+		fmt.Printf("\nRisk limits not checked against the open positions: %v\n", err)
+	}
+	limits.Load(risk.PositionsFromAccounts(open))
+	riskErr := limits.Check(p.Position())
+	if riskErr != nil {
+		fmt.Printf("\nRefused by the risk limits: %v\n", riskErr)
+	} else {
+		fmt.Printf("\nWithin the risk limits (%d positions open)\n", limits.OpenCount())
+	}
+	if *dryRun {
+		return nil
+	}
+	if riskErr != nil {
+		return riskErr
+	}
+
+	fmt.Printf("\nThis places real orders. Type %s to confirm: ", p.UnifiedSymbol)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != p.UnifiedSymbol {
+		return errors.New("not confirmed, no order placed")
+	}
+
+	// Prices moved while waiting for the confirmation: re-check the spread, and never trade more than confirmed
+	if err := m.fetch(ctx, false); err != nil {
+		return err
+	}
+	confirmed := p
+	if p, err = plan(); err != nil {
+		return fmt.Errorf("aborted after re-checking: %w", err)
+	}
+	if p.Short.Exchange != confirmed.Short.Exchange || p.Long.Exchange != confirmed.Long.Exchange {
+		return errors.New("aborted after re-checking: the best direction changed, run the command again")
+	}
+	p.Quantity = min(p.Quantity, confirmed.Quantity)
+
+	engine := execution.NewEngine(opts,
+		execution.NewBinance(cfg.BinanceAPIKey, cfg.BinanceAPISecret),
+		execution.NewMexc(cfg.MexcAPIKey, cfg.MexcAPISecret, cfg.ExecutionLeverage))
+	engine.SetRisk(limits)
+	result, execErr := engine.Execute(ctx, p)
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "LEG\tEXCHANGE\tORDER\tSTATUS\tFILLED\tAVG PRICE\t")
+	fmt.Fprintf(w, "short\t%s\t%s\t%s\t%g\t%g\t\n", p.Short.Exchange, result.Short.ID, result.Short.Status, result.Short.FilledQty, result.Short.AvgPrice)
+	fmt.Fprintf(w, "long\t%s\t%s\t%s\t%g\t%g\t\n", p.Long.Exchange, result.Long.ID, result.Long.Status, result.Long.FilledQty, result.Long.AvgPrice)
+	if u := result.Unwind; u != nil {
+		exchange := p.Short.Exchange
+		if u.Side == execution.SideSell {
+			exchange = p.Long.Exchange
+		}
+		fmt.Fprintf(w, "unwind\t%s\t%s\t%s\t%g\t%g\t\n", exchange, u.ID, u.Status, u.FilledQty, u.AvgPrice)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Hedged %g %s\n", result.Hedged, p.UnifiedSymbol)
+	return execErr
+}
+
+// printPlan prints the orders of a plan.
+func printPlan(p execution.Plan) error {
+	fmt.Printf("%s: entry spread %.4f%%, %g per leg (%.2f notional), %s orders\n\n", p.UnifiedSymbol, p.EntrySpread, p.Quantity, p.Notional, p.Type)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "SIDE\tEXCHANGE\tSYMBOL\tQUANTITY\tTOUCH\tLIMIT\t")
+	for _, leg := range []execution.Leg{p.Short, p.Long} {
+		limit := "-"
+		if p.Type == execution.OrderTypeLimit {
+			limit = fmt.Sprintf("%g", leg.LimitPrice)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%g\t%g\t%s\t\n", leg.Side, leg.Exchange, leg.Instrument.Symbol, p.Quantity, leg.Price, limit)
+	}
+	return w.Flush()
+}
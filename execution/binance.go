@@ -0,0 +1,173 @@
+package execution
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	binanceFuturesURL = "https://fapi.binance.com"
+	binanceOrderPath  = "/fapi/v1/order"
+	binanceRecvWindow = "5000"
+)
+
+// binanceOrderDto is an order as returned by the Binance USDⓈ-M futures order endpoints.
+type binanceOrderDto struct {
+	OrderID       int64  `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	Status        string `json:"status"`
+	OrigQty       string `json:"origQty"`
+	ExecutedQty   string `json:"executedQty"`
+	AvgPrice      string `json:"avgPrice"`
+}
+
+// binanceErrorDto is the body of a failed Binance request.
+type binanceErrorDto struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// Binance trades Binance USDⓈ-M perpetual futures in one-way position mode, at the leverage set on the account.
+type Binance struct {
+	client    *http.Client
+	baseURL   string
+	apiKey    string
+	apiSecret string
+}
+
+// NewBinance creates a Binance client with an API key pair allowed to trade futures.
+func NewBinance(apiKey, apiSecret string) *Binance {
+	return &Binance{client: &http.Client{Transport: transport, Timeout: 10 * time.Second}, baseURL: binanceFuturesURL, apiKey: apiKey, apiSecret: apiSecret}
+}
+
+// Name returns "Binance".
+func (b *Binance) Name() string {
+	return "Binance"
+}
+
+// PlaceOrder places an order. Limit orders are good till cancelled.
+func (b *Binance) PlaceOrder(ctx context.Context, req OrderRequest) (Order, error) {
+	params := url.Values{}
+	params.Set("symbol", req.Instrument.Symbol)
+	params.Set("side", strings.ToUpper(string(req.Side)))
+	params.Set("quantity", req.Instrument.Spec.FormatQty(req.Quantity))
+	params.Set("newClientOrderId", req.ClientID)
+	params.Set("newOrderRespType", "RESULT")
+	if req.ReduceOnly {
+		params.Set("reduceOnly", "true")
+	}
+	switch req.Type {
+	case OrderTypeMarket:
+		params.Set("type", "MARKET")
+	case OrderTypeLimit:
+		params.Set("type", "LIMIT")
+		params.Set("timeInForce", "GTC")
+		params.Set("price", req.Instrument.Spec.FormatPrice(req.Price))
+	default:
+		return Order{}, fmt.Errorf("unknown order type %q", req.Type)
+	}
+	return b.order(ctx, http.MethodPost, params)
+}
+
+// GetOrder fetches the state of an order.
+func (b *Binance) GetOrder(ctx context.Context, instrument Instrument, id string) (Order, error) {
+	params := url.Values{}
+	params.Set("symbol", instrument.Symbol)
+	params.Set("orderId", id)
+	return b.order(ctx, http.MethodGet, params)
+}
+
+// CancelOrder cancels an order.
+func (b *Binance) CancelOrder(ctx context.Context, instrument Instrument, id string) error {
+	params := url.Values{}
+	params.Set("symbol", instrument.Symbol)
+	params.Set("orderId", id)
+	_, err := b.order(ctx, http.MethodDelete, params)
+	return err
+}
+
+// order sends a signed request to the order endpoint. Requests are not retried: a lost response doesn't tell
+// whether an order was placed, so the caller looks it up instead.
+func (b *Binance) order(ctx context.Context, method string, params url.Values) (Order, error) {
+	params.Set("recvWindow", binanceRecvWindow)
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	query := params.Encode()
+	mac := hmac.New(sha256.New, []byte(b.apiSecret))
+	mac.Write([]byte(query))
+	query += "&signature=" + hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+binanceOrderPath+"?"+query, nil)
+	if err != nil {
+		return Order{}, err
+	}
+	req.Header.Set("X-MBX-APIKEY", b.apiKey)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to make HTTP request to Binance %s %s: %w", method, binanceOrderPath, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to read Binance %s %s response body: %w", method, binanceOrderPath, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr binanceErrorDto
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Msg != "" {
+			return Order{}, fmt.Errorf("Binance %s %s failed: %s (code %d)", method, binanceOrderPath, apiErr.Msg, apiErr.Code)
+		}
+		return Order{}, fmt.Errorf("Binance %s %s returned non-OK status: %d, body: %s", method, binanceOrderPath, resp.StatusCode, string(body))
+	}
+
+	var dto binanceOrderDto
+	if err := json.Unmarshal(body, &dto); err != nil {
+		return Order{}, fmt.Errorf("failed to unmarshal Binance order: %w", err)
+	}
+	return dto.toOrder()
+}
+
+// toOrder converts a Binance order.
+func (d binanceOrderDto) toOrder() (Order, error) {
+	order := Order{
+		ID:       strconv.FormatInt(d.OrderID, 10),
+		ClientID: d.ClientOrderID,
+		Symbol:   d.Symbol,
+		Side:     Side(strings.ToLower(d.Side)),
+	}
+	switch d.Status {
+	case "NEW":
+		order.Status = StatusNew
+	case "PARTIALLY_FILLED":
+		order.Status = StatusPartiallyFilled
+	case "FILLED":
+		order.Status = StatusFilled
+	case "CANCELED", "EXPIRED", "EXPIRED_IN_MATCH":
+		order.Status = StatusCanceled
+	case "REJECTED":
+		order.Status = StatusRejected
+	default:
+		return Order{}, fmt.Errorf("unknown Binance order status %q", d.Status)
+	}
+	var err error
+	if order.Quantity, err = parseFloat("origQty", d.OrigQty); err != nil {
+		return Order{}, err
+	}
+	if order.FilledQty, err = parseFloat("executedQty", d.ExecutedQty); err != nil {
+		return Order{}, err
+	}
+	if order.AvgPrice, err = parseFloat("avgPrice", d.AvgPrice); err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
@@ -0,0 +1,331 @@
+package execution
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/risk"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options sets how opportunities are executed.
+type Options struct {
+	MaxNotional      float64       // Largest notional per leg, in the quote currency
+	OrderType        OrderType     // How both legs are priced
+	LimitSlippageBps float64       // How far past the touch limit orders are priced, in basis points
+	FillTimeout      time.Duration // Longest wait for both legs to fill before the rest is cancelled
+	PollInterval     time.Duration // How often order states are fetched while waiting
+}
+
+// Leg is one side of a planned execution.
+type Leg struct {
+	Exchange   string
+	Instrument Instrument
+	Side       Side
+	Price      float64 // Touch price when planned: the bid of the short leg, the ask of the long leg
+	LimitPrice float64 // Price of a limit order, Price moved by the limit slippage and rounded to the tick size
+}
+
+// Plan is the orders that would execute an opportunity: a sell on the short leg and a buy of the same
+// quantity on the long leg.
+type Plan struct {
+	UnifiedSymbol string
+	EntrySpread   float64 // Percent, when planned
+	Quantity      float64 // Base units, on each leg
+	Notional      float64 // Quote currency, on the long leg
+	Type          OrderType
+	Short         Leg
+	Long          Leg
+}
+
+// NewPlan sizes the orders of a spread from the tickers and contract specs of its legs. The quantity is the
+// smallest of the notional cap, the spread's target notional and the top-of-book sizes, rounded down to both
+// legs' step sizes.
+func NewPlan(spread arbitrage.Spread, short, long shared.TickerBidAsk, shortSpec, longSpec shared.ContractSpec, opts Options) (Plan, error) {
+	if sym, err := shared.ParseSymbol(spread.UnifiedSymbol); err != nil || !sym.IsPerp() || spread.UnifiedSymbolLong != "" || spread.Transfer != nil {
+		return Plan{}, ErrUnsupportedSpread
+	}
+	if short.Bid <= 0 || long.Ask <= 0 {
+		return Plan{}, fmt.Errorf("no price to size %s", spread.UnifiedSymbol)
+	}
+
+	notional := opts.MaxNotional
+	if spread.TargetNotionalUSD > 0 {
+		notional = min(notional, spread.TargetNotionalUSD)
+	}
+	quantity := notional / long.Ask
+	if short.BidQty > 0 {
+		quantity = min(quantity, short.BidQty)
+	}
+	if long.AskQty > 0 {
+		quantity = min(quantity, long.AskQty)
+	}
+	quantity = shared.HedgeQty(quantity, shortSpec, longSpec)
+	for _, leg := range []struct {
+		exchange string
+		spec     shared.ContractSpec
+		price    float64
+	}{{spread.ExchangeShort, shortSpec, short.Bid}, {spread.ExchangeLong, longSpec, long.Ask}} {
+		if !leg.spec.Accepts(quantity, leg.price) {
+			return Plan{}, fmt.Errorf("%w: %g %s on %s (minimum quantity %g, minimum notional %g)",
+				ErrBelowMinimum, quantity, spread.UnifiedSymbol, leg.exchange, leg.spec.MinQty, leg.spec.MinNotional)
+		}
+	}
+
+	slippage := opts.LimitSlippageBps / 10000
+	return Plan{
+		UnifiedSymbol: spread.UnifiedSymbol,
+		EntrySpread:   spread.EntrySpread,
+		Quantity:      quantity,
+		Notional:      quantity * long.Ask,
+		Type:          opts.OrderType,
+		Short: Leg{
+			Exchange:   spread.ExchangeShort,
+			Instrument: Instrument{Symbol: short.Symbol, Spec: shortSpec},
+			Side:       SideSell,
+			Price:      short.Bid,
+			LimitPrice: shortSpec.RoundPriceDown(short.Bid * (1 - slippage)),
+		},
+		Long: Leg{
+			Exchange:   spread.ExchangeLong,
+			Instrument: Instrument{Symbol: long.Symbol, Spec: longSpec},
+			Side:       SideBuy,
+			Price:      long.Ask,
+			LimitPrice: longSpec.RoundPriceUp(long.Ask * (1 + slippage)),
+		},
+	}, nil
+}
+
+// Result is the outcome of an execution.
+type Result struct {
+	Short  Order  // Final state of the short leg's order
+	Long   Order  // Final state of the long leg's order
+	Unwind *Order // Order flattening the unhedged quantity, if any
+	Hedged float64
+}
+
+// Engine executes plans on the exchanges of their legs.
+type Engine struct {
+	exchanges map[string]Exchange
+	opts      Options
+	risk      *risk.Manager // Nil checks no limit
+}
+
+// NewEngine creates an engine trading on the given exchanges, keyed by their names.
+func NewEngine(opts Options, exchanges ...Exchange) *Engine {
+	e := &Engine{exchanges: make(map[string]Exchange, len(exchanges)), opts: opts}
+	for _, x := range exchanges {
+		e.exchanges[x.Name()] = x
+	}
+	return e
+}
+
+// SetRisk sets the risk limits plans are checked against before their orders are placed.
+func (e *Engine) SetRisk(manager *risk.Manager) {
+	e.risk = manager
+}
+
+// Position returns the position a plan opens, to check it against the risk limits.
+func (p Plan) Position() risk.Position {
+	return risk.Position{
+		ID:            p.UnifiedSymbol,
+		UnifiedSymbol: p.UnifiedSymbol,
+		Notional:      map[string]float64{p.Short.Exchange: p.Quantity * p.Short.Price, p.Long.Exchange: p.Quantity * p.Long.Price},
+	}
+}
+
+// legState tracks the order of one leg.
+type legState struct {
+	leg      Leg
+	exchange Exchange
+	clientID string
+	order    Order
+	err      error // Placement failure; the order may still exist under its client ID
+}
+
+// Execute places both legs at once and waits up to the fill timeout for them to fill. Whatever is still
+// open then is cancelled, and if one leg filled more than the other, the difference is closed with a
+// reduce-only market order, so no unhedged position is left behind. Once orders are placed, the cancel and
+// unwind steps run even if ctx is cancelled, e.g., by Ctrl-C, which only cuts the wait short. Plans breaking
+// a risk limit are refused before any order is placed; the hedged quantity is then recorded as open.
+func (e *Engine) Execute(ctx context.Context, plan Plan) (Result, error) {
+	if e.risk != nil {
+		if err := e.risk.Check(plan.Position()); err != nil {
+			return Result{}, fmt.Errorf("refused by the risk limits: %w", err)
+		}
+	}
+	short, long := &legState{leg: plan.Short}, &legState{leg: plan.Long}
+	for _, s := range []*legState{short, long} {
+		x, ok := e.exchanges[s.leg.Exchange]
+		if !ok {
+			return Result{}, fmt.Errorf("%w %s", ErrUnknownExchange, s.leg.Exchange)
+		}
+		s.exchange = x
+	}
+
+	// Both legs go out at once, as the edge lasts for a moment only
+	prefix := "arb" + strconv.FormatInt(time.Now().UnixMilli(), 36)
+	var wg sync.WaitGroup
+	for i, s := range []*legState{short, long} {
+		s.clientID = prefix + strconv.Itoa(i)
+		wg.Go(func() {
+			req := OrderRequest{
+				Instrument: s.leg.Instrument,
+				ClientID:   s.clientID,
+				Side:       s.leg.Side,
+				Type:       plan.Type,
+				Quantity:   plan.Quantity,
+				Price:      s.leg.Price,
+			}
+			if plan.Type == OrderTypeLimit {
+				req.Price = s.leg.LimitPrice
+			}
+			s.order, s.err = s.exchange.PlaceOrder(ctx, req)
+			if s.err != nil {
+				slog.Error("Failed to place order", "exchange", s.leg.Exchange, "symbol", s.leg.Instrument.Symbol, "side", s.leg.Side, "error", s.err)
+				return
+			}
+			slog.Info("Order placed", "exchange", s.leg.Exchange, "symbol", s.leg.Instrument.Symbol, "side", s.leg.Side, "order_id", s.order.ID,
+				"quantity", plan.Quantity, "price", req.Price, "type", plan.Type)
+		})
+	}
+	wg.Wait()
+	placeErr := errors.Join(short.err, long.err)
+
+	// Cleanup must finish whatever happened to ctx
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*e.opts.FillTimeout+30*time.Second)
+	defer cancel()
+	if placeErr == nil {
+		e.await(ctx, short, long)
+	}
+	for _, s := range []*legState{short, long} {
+		if s.err == nil {
+			e.settle(cleanupCtx, s)
+		}
+	}
+
+	result := Result{Short: short.order, Long: long.order, Hedged: min(short.order.FilledQty, long.order.FilledQty)}
+	if e.risk != nil && result.Hedged > 0 {
+		hedged := plan
+		hedged.Quantity = result.Hedged
+		e.risk.Record(hedged.Position())
+	}
+	unwind, err := e.unwind(cleanupCtx, short, long)
+	result.Unwind = unwind
+	if err != nil {
+		return result, errors.Join(placeErr, err)
+	}
+	if placeErr != nil {
+		return result, placeErr
+	}
+	return result, nil
+}
+
+// await polls both orders until they are done, the fill timeout passes or ctx is cancelled.
+func (e *Engine) await(ctx context.Context, legs ...*legState) {
+	deadline := time.NewTimer(e.opts.FillTimeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(e.opts.PollInterval)
+	defer poll.Stop()
+	for {
+		done := true
+		for _, s := range legs {
+			if s.order.Status.Terminal() {
+				continue
+			}
+			order, err := s.exchange.GetOrder(ctx, s.leg.Instrument, s.order.ID)
+			if err != nil {
+				slog.Warn("Failed to fetch order state", "exchange", s.leg.Exchange, "order_id", s.order.ID, "error", err)
+				done = false
+				continue
+			}
+			s.order = order
+			done = done && order.Status.Terminal()
+		}
+		if done {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			slog.Warn("Execution interrupted, cancelling open orders")
+			return
+		case <-deadline.C:
+			slog.Warn("Orders not filled within the fill timeout, cancelling the rest", "timeout", e.opts.FillTimeout)
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// settle cancels an order that can still fill and fetches its final state.
+func (e *Engine) settle(ctx context.Context, s *legState) {
+	if s.order.Status.Terminal() {
+		return
+	}
+	if err := s.exchange.CancelOrder(ctx, s.leg.Instrument, s.order.ID); err != nil {
+		// It may have filled in the meantime; its state tells
+		slog.Warn("Failed to cancel order", "exchange", s.leg.Exchange, "order_id", s.order.ID, "error", err)
+	}
+	for attempt := range 5 {
+		order, err := s.exchange.GetOrder(ctx, s.leg.Instrument, s.order.ID)
+		if err == nil {
+			s.order = order
+			if order.Status.Terminal() {
+				return
+			}
+		} else {
+			slog.Warn("Failed to fetch order state", "exchange", s.leg.Exchange, "order_id", s.order.ID, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(attempt+1) * e.opts.PollInterval):
+		}
+	}
+}
+
+// unwind closes the quantity one leg filled beyond the other with a reduce-only market order.
+func (e *Engine) unwind(ctx context.Context, short, long *legState) (*Order, error) {
+	for _, s := range []*legState{short, long} {
+		if s.err == nil && !s.order.Status.Terminal() {
+			return nil, fmt.Errorf("order %s on %s is still open, unwind it manually", s.order.ID, s.leg.Exchange)
+		}
+	}
+	excess := short.order.FilledQty - long.order.FilledQty
+	over, side := short, SideBuy // The short leg sold too much: buy it back
+	if excess < 0 {
+		over, side = long, SideSell
+	}
+	quantity := over.leg.Instrument.Spec.RoundQty(math.Abs(excess))
+	if quantity <= 0 {
+		return nil, nil
+	}
+
+	slog.Warn("Legs filled unevenly, unwinding the excess", "exchange", over.leg.Exchange, "symbol", over.leg.Instrument.Symbol,
+		"short_filled", short.order.FilledQty, "long_filled", long.order.FilledQty, "quantity", quantity)
+	order, err := over.exchange.PlaceOrder(ctx, OrderRequest{
+		Instrument: over.leg.Instrument,
+		ClientID:   over.clientID + "u",
+		Side:       side,
+		Type:       OrderTypeMarket,
+		Quantity:   quantity,
+		Price:      over.leg.Price,
+		ReduceOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwind %g %s on %s, close it manually: %w", quantity, over.leg.Instrument.Symbol, over.leg.Exchange, err)
+	}
+	unwound := &legState{leg: over.leg, exchange: over.exchange, clientID: over.clientID + "u", order: order}
+	e.await(ctx, unwound)
+	e.settle(ctx, unwound)
+	if unwound.order.Status != StatusFilled {
+		return &unwound.order, fmt.Errorf("unwind order %s on %s ended %s, check the position manually", order.ID, over.leg.Exchange, unwound.order.Status)
+	}
+	return &unwound.order, nil
+}
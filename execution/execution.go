@@ -0,0 +1,109 @@
+// Package execution places the orders of an opportunity on both legs, tracks them until they fill and unwinds
+// whatever is left unhedged. It trades real money with the configured API keys, so nothing else in the
+// repository imports it; it only runs from the "execute" command, when enabled and confirmed.
+package execution
+
+import (
+	"cex-price-diff-notifications/shared"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// transport sends the order requests; replaced by SetTransport.
+var transport http.RoundTripper = http.DefaultTransport
+
+// SetTransport replaces the transport of the order requests, e.g., to trade on a testnet. It must be called
+// before the exchanges are created.
+func SetTransport(t http.RoundTripper) {
+	transport = t
+}
+
+// Side is the direction of an order.
+type Side string
+
+const (
+	SideBuy  Side = "buy"
+	SideSell Side = "sell"
+)
+
+// OrderType is how an order is priced.
+type OrderType string
+
+const (
+	OrderTypeMarket OrderType = "market"
+	OrderTypeLimit  OrderType = "limit" // Priced past the touch, so it fills at once unless the book moved
+)
+
+// OrderStatus is the state of an order on the exchange.
+type OrderStatus string
+
+const (
+	StatusNew             OrderStatus = "new"
+	StatusPartiallyFilled OrderStatus = "partially_filled"
+	StatusFilled          OrderStatus = "filled"
+	StatusCanceled        OrderStatus = "canceled" // Also expired orders; some quantity may have filled before
+	StatusRejected        OrderStatus = "rejected"
+)
+
+// Terminal reports whether an order can no longer fill.
+func (s OrderStatus) Terminal() bool {
+	return s == StatusFilled || s == StatusCanceled || s == StatusRejected
+}
+
+var (
+	ErrUnsupportedSpread = errors.New("only perpetual spreads of the same quote currency can be executed")
+	ErrBelowMinimum      = errors.New("order size below the exchange minimum")
+	ErrUnknownExchange   = errors.New("no execution client for exchange")
+)
+
+// Instrument is a contract traded on an exchange.
+type Instrument struct {
+	Symbol string              // Exchange symbol, e.g., "BTCUSDT" or "BTC_USDT"
+	Spec   shared.ContractSpec // Quantities are in base units; the exchange converts to contracts if needed
+}
+
+// OrderRequest describes an order to place.
+type OrderRequest struct {
+	Instrument Instrument
+	ClientID   string // Our identifier of the order, to find it if the response is lost
+	Side       Side
+	Type       OrderType
+	Quantity   float64 // In base units, a multiple of the step size
+	Price      float64 // Limit price, ignored for market orders
+	ReduceOnly bool    // Only reduce a position, used to unwind
+}
+
+// Order is the state of a placed order.
+type Order struct {
+	ID        string
+	ClientID  string
+	Symbol    string
+	Side      Side
+	Status    OrderStatus
+	Quantity  float64 // In base units
+	FilledQty float64 // In base units
+	AvgPrice  float64 // Average fill price, 0 if nothing filled
+}
+
+// Exchange places and tracks orders on one exchange's perpetual contracts.
+type Exchange interface {
+	Name() string
+	PlaceOrder(ctx context.Context, req OrderRequest) (Order, error)
+	GetOrder(ctx context.Context, instrument Instrument, id string) (Order, error)
+	CancelOrder(ctx context.Context, instrument Instrument, id string) error
+}
+
+// parseFloat parses a decimal field of an exchange response.
+func parseFloat(field, s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, s, err)
+	}
+	return v, nil
+}
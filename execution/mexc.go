@@ -0,0 +1,258 @@
+package execution
+
+import (
+	"bytes"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	mexcFuturesURL      = "https://contract.mexc.com"
+	mexcSubmitOrderPath = "/api/v1/private/order/submit"
+	mexcGetOrderPath    = "/api/v1/private/order/get/"
+	mexcCancelOrderPath = "/api/v1/private/order/cancel"
+)
+
+// Mexc order sides and types.
+const (
+	mexcOpenLong   = 1
+	mexcCloseShort = 2
+	mexcOpenShort  = 3
+	mexcCloseLong  = 4
+	mexcLimit      = 1
+	mexcMarket     = 5
+	mexcIsolated   = 1
+)
+
+// mexcResponse is the envelope of every Mexc futures response.
+type mexcResponse struct {
+	Success bool            `json:"success"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// mexcOrderRequestDto is the body of an order submission.
+type mexcOrderRequestDto struct {
+	Symbol      string      `json:"symbol"`
+	Price       json.Number `json:"price"`
+	Vol         json.Number `json:"vol"` // In contracts
+	Side        int         `json:"side"`
+	Type        int         `json:"type"`
+	OpenType    int         `json:"openType"`
+	Leverage    int         `json:"leverage,omitempty"`
+	ExternalOid string      `json:"externalOid"`
+}
+
+// mexcOrderDto is an order as returned by the Mexc futures order endpoint.
+type mexcOrderDto struct {
+	OrderID      string  `json:"orderId"`
+	Symbol       string  `json:"symbol"`
+	Vol          float64 `json:"vol"`     // In contracts
+	DealVol      float64 `json:"dealVol"` // In contracts
+	DealAvgPrice float64 `json:"dealAvgPrice"`
+	Side         int     `json:"side"`
+	State        int     `json:"state"` // 1 uninformed, 2 uncompleted, 3 completed, 4 cancelled, 5 invalid
+	ExternalOid  string  `json:"externalOid"`
+}
+
+// mexcCancelResultDto is the outcome of cancelling one order.
+type mexcCancelResultDto struct {
+	OrderID   string `json:"orderId"`
+	ErrorCode int    `json:"errorCode"`
+	ErrorMsg  string `json:"errorMsg"`
+}
+
+// Mexc trades Mexc perpetual futures with isolated margin. Mexc only opens its futures order endpoints to
+// some accounts; others get an error for every order.
+type Mexc struct {
+	client    *http.Client
+	baseURL   string
+	apiKey    string
+	apiSecret string
+	leverage  int
+}
+
+// NewMexc creates a Mexc client with an API key pair allowed to trade futures, opening positions at leverage.
+func NewMexc(apiKey, apiSecret string, leverage int) *Mexc {
+	return &Mexc{client: &http.Client{Transport: transport, Timeout: 10 * time.Second}, baseURL: mexcFuturesURL, apiKey: apiKey, apiSecret: apiSecret, leverage: leverage}
+}
+
+// Name returns "Mexc".
+func (m *Mexc) Name() string {
+	return "Mexc"
+}
+
+// PlaceOrder places an order. Mexc only returns the order ID, so the order is reported as new.
+func (m *Mexc) PlaceOrder(ctx context.Context, req OrderRequest) (Order, error) {
+	spec := req.Instrument.Spec
+	contractSize := mexcContractSize(spec)
+	body := mexcOrderRequestDto{
+		Symbol:      req.Instrument.Symbol,
+		Price:       json.Number(spec.FormatPrice(req.Price)),
+		Vol:         json.Number(shared.FormatDecimal(req.Quantity/contractSize, spec.StepSize/contractSize)),
+		OpenType:    mexcIsolated,
+		Leverage:    m.leverage,
+		ExternalOid: req.ClientID,
+	}
+	switch {
+	case req.Side == SideBuy && !req.ReduceOnly:
+		body.Side = mexcOpenLong
+	case req.Side == SideSell && !req.ReduceOnly:
+		body.Side = mexcOpenShort
+	case req.Side == SideBuy:
+		body.Side = mexcCloseShort
+	default:
+		body.Side = mexcCloseLong
+	}
+	switch req.Type {
+	case OrderTypeMarket:
+		body.Type = mexcMarket
+	case OrderTypeLimit:
+		body.Type = mexcLimit
+	default:
+		return Order{}, fmt.Errorf("unknown order type %q", req.Type)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return Order{}, err
+	}
+	data, err := m.do(ctx, http.MethodPost, mexcSubmitOrderPath, payload)
+	if err != nil {
+		return Order{}, err
+	}
+	return Order{
+		ID:       strings.Trim(string(data), `"`), // A number or a string, depending on the API version
+		ClientID: req.ClientID,
+		Symbol:   req.Instrument.Symbol,
+		Side:     req.Side,
+		Status:   StatusNew,
+		Quantity: req.Quantity,
+	}, nil
+}
+
+// GetOrder fetches the state of an order.
+func (m *Mexc) GetOrder(ctx context.Context, instrument Instrument, id string) (Order, error) {
+	data, err := m.do(ctx, http.MethodGet, mexcGetOrderPath+id, nil)
+	if err != nil {
+		return Order{}, err
+	}
+	var dto mexcOrderDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return Order{}, fmt.Errorf("failed to unmarshal Mexc order: %w", err)
+	}
+
+	contractSize := mexcContractSize(instrument.Spec)
+	order := Order{
+		ID:        dto.OrderID,
+		ClientID:  dto.ExternalOid,
+		Symbol:    dto.Symbol,
+		Side:      SideSell,
+		Quantity:  dto.Vol * contractSize,
+		FilledQty: dto.DealVol * contractSize,
+		AvgPrice:  dto.DealAvgPrice,
+	}
+	if dto.Side == mexcOpenLong || dto.Side == mexcCloseShort {
+		order.Side = SideBuy
+	}
+	switch dto.State {
+	case 1, 2:
+		order.Status = StatusNew
+		if dto.DealVol > 0 {
+			order.Status = StatusPartiallyFilled
+		}
+	case 3:
+		order.Status = StatusFilled
+	case 4:
+		order.Status = StatusCanceled
+	case 5:
+		order.Status = StatusRejected
+	default:
+		return Order{}, fmt.Errorf("unknown Mexc order state %d", dto.State)
+	}
+	return order, nil
+}
+
+// CancelOrder cancels an order.
+func (m *Mexc) CancelOrder(ctx context.Context, _ Instrument, id string) error {
+	payload, err := json.Marshal([]string{id})
+	if err != nil {
+		return err
+	}
+	data, err := m.do(ctx, http.MethodPost, mexcCancelOrderPath, payload)
+	if err != nil {
+		return err
+	}
+	var results []mexcCancelResultDto
+	if err := json.Unmarshal(data, &results); err != nil {
+		return fmt.Errorf("failed to unmarshal Mexc cancel result: %w", err)
+	}
+	for _, r := range results {
+		if r.ErrorCode != 0 {
+			return fmt.Errorf("Mexc failed to cancel order %s: %s (code %d)", r.OrderID, r.ErrorMsg, r.ErrorCode)
+		}
+	}
+	return nil
+}
+
+// mexcContractSize returns the base units per contract of a spec, 1 if unknown.
+func mexcContractSize(spec shared.ContractSpec) float64 {
+	if spec.ContractSize <= 0 {
+		return 1
+	}
+	return spec.ContractSize
+}
+
+// do sends a signed request and returns the data of a successful response. The signature covers the API key,
+// the request time and the JSON body (there are no query parameters). Requests are not retried: a lost
+// response doesn't tell whether an order was placed.
+func (m *Mexc) do(ctx context.Context, method, path string, payload []byte) (json.RawMessage, error) {
+	requestTime := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(m.apiSecret))
+	mac.Write([]byte(m.apiKey + requestTime + string(payload)))
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("ApiKey", m.apiKey)
+	req.Header.Set("Request-Time", requestTime)
+	req.Header.Set("Signature", hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request to Mexc %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Mexc %s %s response body: %w", method, path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Mexc %s %s returned non-OK status: %d, body: %s", method, path, resp.StatusCode, string(respBody))
+	}
+
+	var envelope mexcResponse
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Mexc %s %s response: %w", method, path, err)
+	}
+	if !envelope.Success {
+		return nil, fmt.Errorf("Mexc %s %s failed: %s (code %d)", method, path, envelope.Message, envelope.Code)
+	}
+	return envelope.Data, nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"cex-price-diff-notifications/feedback"
+	"cex-price-diff-notifications/messaging"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// consumeFeedback hands the consumers' reports on the feedback queue to collector until ctx is done, consuming
+// again after a lost connection.
+func consumeFeedback(ctx context.Context, rabbit *messaging.RabbitMQ, queue string, collector *feedback.Collector) {
+	for ctx.Err() == nil {
+		err := rabbit.Consume(ctx, queue, func(body []byte) error {
+			return collector.Handle(ctx, body)
+		})
+		if err != nil {
+			slog.Error("Failed to consume consumer feedback, retrying", "queue", queue, "error", err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package feedback closes the loop on alert quality: downstream consumers report on a queue whether they acted
+// on a published opportunity and the spread they realized, which is matched with the opportunity as this service
+// published it and stored, so summary reports can compare realized and published spreads.
+package feedback
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Actions a consumer reports.
+const (
+	ActionActed   = "acted"   // Both legs were traded
+	ActionSkipped = "skipped" // The opportunity was seen but not traded, e.g., too late or too small
+)
+
+// Ack is a consumer's report on a published opportunity, as sent to the feedback queue.
+type Ack struct {
+	OpportunityID  string    `json:"opportunity_id"`
+	Action         string    `json:"action"`
+	RealizedSpread *float64  `json:"realized_spread,omitempty"`  // Entry spread of the fills, in percent, when acted
+	RealizedPnLUSD *float64  `json:"realized_pnl_usd,omitempty"` // Once the position is closed
+	NotionalUSD    float64   `json:"notional_usd,omitempty"`     // Per leg
+	Consumer       string    `json:"consumer,omitempty"`         // Reports of the same consumer replace each other
+	Note           string    `json:"note,omitempty"`             // e.g., why it was skipped
+	ReportedAt     time.Time `json:"reported_at"`                // Defaults to the reception time
+}
+
+// Record is an acknowledgment matched with the opportunity as published, as stored.
+type Record struct {
+	Ack
+	ReceivedAt      time.Time
+	Matched         bool // Whether the opportunity was published by this instance within the retention
+	UnifiedSymbol   string
+	ExchangeShort   string
+	ExchangeLong    string
+	PublishedSpread float64 // Entry spread when first published, in percent
+	PublishedAt     time.Time
+}
+
+// Store persists the records.
+type Store interface {
+	WriteFeedback(ctx context.Context, r Record) error
+}
+
+// published is an opportunity as first published.
+type published struct {
+	spread arbitrage.Spread
+	at     time.Time
+}
+
+// Collector remembers the opportunities published over the retention, by ID, and matches the acknowledgments
+// of consumers with them before storing them. It is safe for concurrent use.
+type Collector struct {
+	mu        sync.Mutex
+	published map[string]published // Opportunity ID -> first publication
+	retention time.Duration
+	store     Store
+}
+
+// NewCollector creates a Collector storing the records to store, matching acknowledgments with the
+// opportunities published within retention.
+func NewCollector(store Store, retention time.Duration) *Collector {
+	return &Collector{
+		published: make(map[string]published),
+		retention: retention,
+		store:     store,
+	}
+}
+
+// Remember records the first publication of each spread's opportunity and forgets those older than the retention.
+func (c *Collector) Remember(spreads []arbitrage.Spread, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range spreads {
+		if s.OpportunityID == "" {
+			continue
+		}
+		if _, ok := c.published[s.OpportunityID]; !ok {
+			c.published[s.OpportunityID] = published{spread: s, at: now}
+		}
+	}
+	for id, p := range c.published {
+		if now.Sub(p.at) > c.retention {
+			delete(c.published, id)
+		}
+	}
+}
+
+// Match returns the record of an acknowledgment received at now.
+func (c *Collector) Match(ack Ack, now time.Time) Record {
+	r := Record{Ack: ack, ReceivedAt: now}
+	if r.ReportedAt.IsZero() {
+		r.ReportedAt = now
+	}
+	c.mu.Lock()
+	p, ok := c.published[ack.OpportunityID]
+	c.mu.Unlock()
+	if ok {
+		r.Matched = true
+		r.UnifiedSymbol, r.ExchangeShort, r.ExchangeLong = p.spread.UnifiedSymbol, p.spread.ExchangeShort, p.spread.ExchangeLong
+		r.PublishedSpread, r.PublishedAt = p.spread.EntrySpread, p.at
+	}
+	return r
+}
+
+// Handle decodes an acknowledgment from the feedback queue, matches it and stores it. Invalid acknowledgments
+// and storage failures are returned, so the message is rejected.
+func (c *Collector) Handle(ctx context.Context, body []byte) error {
+	ack, err := ParseAck(body)
+	if err != nil {
+		return err
+	}
+	r := c.Match(ack, time.Now())
+	if err := c.store.WriteFeedback(ctx, r); err != nil {
+		return err
+	}
+	slog.Info("Consumer feedback received",
+		"opportunity_id", r.OpportunityID,
+		"action", r.Action,
+		"consumer", r.Consumer,
+		"matched", r.Matched,
+		"symbol", r.UnifiedSymbol,
+	)
+	return nil
+}
+
+// ParseAck decodes and validates an acknowledgment.
+func ParseAck(body []byte) (Ack, error) {
+	var ack Ack
+	if err := json.Unmarshal(body, &ack); err != nil {
+		return Ack{}, fmt.Errorf("failed to unmarshal feedback: %w", err)
+	}
+	if ack.OpportunityID == "" {
+		return Ack{}, errors.New("feedback has no opportunity_id")
+	}
+	if ack.Action != ActionActed && ack.Action != ActionSkipped {
+		return Ack{}, fmt.Errorf("feedback action must be %s or %s, got %q", ActionActed, ActionSkipped, ack.Action)
+	}
+	if ack.Action == ActionSkipped && (ack.RealizedSpread != nil || ack.RealizedPnLUSD != nil) {
+		return Ack{}, errors.New("skipped feedback can't have a realized spread or PnL")
+	}
+	return ack, nil
+}
@@ -0,0 +1,73 @@
+package funding
+
+import (
+	"cex-price-diff-notifications/shared"
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Settlement is an upcoming funding settlement of a symbol on an exchange.
+type Settlement struct {
+	Exchange      string  `json:"exchange"`
+	UnifiedSymbol string  `json:"unified_symbol"`
+	IntervalHours int     `json:"interval_hours"`
+	Rate          float64 `json:"rate"`      // Current rate, which settles at the next settlement
+	Projected     bool    `json:"projected"` // A later settlement, extrapolated from the next one by the interval
+}
+
+// Slot is the settlements due at the same time, across exchanges.
+type Slot struct {
+	Time        int64        `json:"time"` // Milliseconds since epoch
+	Settlements []Settlement `json:"settlements"`
+}
+
+// Calendar is the funding settlements of every exchange within a horizon, in chronological slots, so
+// entries can be planned around them whatever each exchange's interval (1h, 4h, 8h).
+type Calendar struct {
+	From  int64  `json:"from"`  // Milliseconds since epoch
+	Until int64  `json:"until"` // Milliseconds since epoch
+	Count int    `json:"count"` // Settlements in every slot
+	Slots []Slot `json:"slots"`
+}
+
+// NewCalendar lays out the settlements of rates, keyed by exchange and unified symbol, after from and up to
+// from+horizon. Each symbol settles at its next settlement time, then every interval after it. keep, if set,
+// selects the symbols included.
+func NewCalendar(rates map[string]map[string]shared.FundingRateInfo, from time.Time, horizon time.Duration, keep func(exchange, unifiedSymbol string) bool) Calendar {
+	until := from.Add(horizon)
+	cal := Calendar{From: from.UnixMilli(), Until: until.UnixMilli(), Slots: []Slot{}}
+	slots := make(map[int64][]Settlement)
+	for exchange, bySymbol := range rates {
+		for unifiedSymbol, info := range bySymbol {
+			if info.Interval <= 0 || info.NextSettleTime <= 0 || (keep != nil && !keep(exchange, unifiedSymbol)) {
+				continue
+			}
+			interval := time.Duration(info.Interval) * time.Hour
+			next := time.UnixMilli(info.NextSettleTime)
+			// A settlement time not refreshed since it passed is moved on to the one that follows
+			for !next.After(from) {
+				next = next.Add(interval)
+			}
+			for t, projected := next, false; !t.After(until); t, projected = t.Add(interval), true {
+				slots[t.UnixMilli()] = append(slots[t.UnixMilli()], Settlement{
+					Exchange:      exchange,
+					UnifiedSymbol: unifiedSymbol,
+					IntervalHours: info.Interval,
+					Rate:          info.Rate,
+					Projected:     projected,
+				})
+				cal.Count++
+			}
+		}
+	}
+
+	for t, settlements := range slots {
+		slices.SortFunc(settlements, func(a, b Settlement) int {
+			return cmp.Or(cmp.Compare(a.Exchange, b.Exchange), cmp.Compare(a.UnifiedSymbol, b.UnifiedSymbol))
+		})
+		cal.Slots = append(cal.Slots, Slot{Time: t, Settlements: settlements})
+	}
+	slices.SortFunc(cal.Slots, func(a, b Slot) int { return cmp.Compare(a.Time, b.Time) })
+	return cal
+}
@@ -0,0 +1,140 @@
+package funding
+
+import (
+	"cex-price-diff-notifications/shared"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Fetcher retrieves up to limit settled funding rates for a unified symbol from a single exchange.
+type Fetcher func(ctx context.Context, unifiedSymbol string, limit int) ([]shared.FundingRatePoint, error)
+
+// idleTTLs is how many TTLs an entry may go without a lookup before it is evicted, e.g., after its symbol
+// was delisted.
+const idleTTLs = 3
+
+// History caches historical funding rates per exchange and symbol. Symbols are fetched lazily:
+// a lookup for an unknown or expired entry queues it for the background refresher started by Run.
+type History struct {
+	mu       sync.Mutex
+	entries  map[historyKey]*historyEntry
+	fetchers map[string]Fetcher
+	limit    int
+	ttl      time.Duration
+}
+
+type historyKey struct {
+	Exchange      string
+	UnifiedSymbol string
+}
+
+type historyEntry struct {
+	points      []shared.FundingRatePoint
+	fetchedAt   time.Time
+	requestedAt time.Time // Last lookup
+	pending     bool
+}
+
+// NewHistory creates a History that keeps the last limit settlements per symbol and refreshes them after ttl.
+func NewHistory(limit int, ttl time.Duration) *History {
+	return &History{
+		entries:  make(map[historyKey]*historyEntry),
+		fetchers: make(map[string]Fetcher),
+		limit:    limit,
+		ttl:      ttl,
+	}
+}
+
+// RegisterFetcher sets the history source for an exchange (e.g., "Binance").
+func (h *History) RegisterFetcher(exchange string, f Fetcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fetchers[exchange] = f
+}
+
+// TrailingAverage returns the mean settled funding rate for a symbol on an exchange.
+// If no fresh history is cached, the symbol is queued for fetching and the cached value (if any) is returned.
+func (h *History) TrailingAverage(exchange, unifiedSymbol string) (float64, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.fetchers[exchange]; !ok {
+		return 0, false
+	}
+
+	key := historyKey{Exchange: exchange, UnifiedSymbol: unifiedSymbol}
+	entry, ok := h.entries[key]
+	if !ok {
+		h.entries[key] = &historyEntry{requestedAt: time.Now(), pending: true}
+		return 0, false
+	}
+	entry.requestedAt = time.Now()
+	if time.Since(entry.fetchedAt) > h.ttl {
+		entry.pending = true
+	}
+	if len(entry.points) == 0 {
+		return 0, false
+	}
+
+	var sum float64
+	for _, p := range entry.points {
+		sum += p.Rate
+	}
+	return sum / float64(len(entry.points)), true
+}
+
+// Run fetches up to batchSize pending entries every interval. It blocks until ctx is cancelled and should be run in a goroutine.
+func (h *History) Run(ctx context.Context, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.refreshPending(ctx, batchSize)
+		}
+	}
+}
+
+// refreshPending evicts the entries not looked up for idleTTLs and fetches history for up to batchSize queued
+// entries.
+func (h *History) refreshPending(ctx context.Context, batchSize int) {
+	h.mu.Lock()
+	var keys []historyKey
+	for key, entry := range h.entries {
+		if time.Since(entry.requestedAt) > idleTTLs*h.ttl {
+			delete(h.entries, key)
+			continue
+		}
+		if entry.pending && len(keys) < batchSize {
+			keys = append(keys, key)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, key := range keys {
+		h.mu.Lock()
+		fetch := h.fetchers[key.Exchange]
+		h.mu.Unlock()
+
+		points, err := fetch(ctx, key.UnifiedSymbol, h.limit)
+
+		h.mu.Lock()
+		entry := h.entries[key]
+		entry.pending = false
+		entry.fetchedAt = time.Now()
+		if err != nil {
+			slog.Warn("Failed to fetch funding history", "exchange", key.Exchange, "symbol", key.UnifiedSymbol, "error", err)
+		} else {
+			entry.points = points
+		}
+		h.mu.Unlock()
+	}
+
+	if len(keys) > 0 {
+		slog.Debug("Refreshed funding history", "count", len(keys))
+	}
+}
@@ -0,0 +1,52 @@
+package fx
+
+import (
+	"cex-price-diff-notifications/shared"
+)
+
+// ReferenceQuote is the currency all conversion rates are expressed in.
+const ReferenceQuote = "USDT"
+
+// Rates maps a quote currency to its value in ReferenceQuote (e.g., "USDC" -> 0.9998).
+type Rates map[string]float64
+
+// RatesFromTickers derives stablecoin conversion rates from the mid prices of the
+// "<QUOTE>/USDT:PERP" markets found in the tickers, averaged across exchanges.
+func RatesFromTickers(tickers map[string]map[string]shared.TickerBidAsk, quotes []string) Rates {
+	rates := Rates{ReferenceQuote: 1.0}
+	for _, quote := range quotes {
+		if quote == ReferenceQuote {
+			continue
+		}
+		exchangeData, ok := tickers[shared.PerpSymbol(quote, ReferenceQuote).String()]
+		if !ok {
+			continue
+		}
+		var sum float64
+		var count int
+		for _, t := range exchangeData {
+			if t.Bid <= 0 || t.Ask <= 0 {
+				continue
+			}
+			sum += (t.Bid + t.Ask) / 2
+			count++
+		}
+		if count > 0 {
+			rates[quote] = sum / float64(count)
+		}
+	}
+	return rates
+}
+
+// ConversionRate returns the multiplier converting a price quoted in `from` into `to`.
+func (r Rates) ConversionRate(from, to string) (float64, bool) {
+	if from == to {
+		return 1.0, true
+	}
+	fromRate, okFrom := r[from]
+	toRate, okTo := r[to]
+	if !okFrom || !okTo || toRate == 0 {
+		return 0, false
+	}
+	return fromRate / toRate, true
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"cex-price-diff-notifications/metrics"
+	"sync"
+	"time"
+)
+
+// fetchStat is the outcome of one exchange request of a cycle.
+type fetchStat struct {
+	Exchange   string `json:"exchange"`
+	Kind       string `json:"kind"` // One of the metrics.Fetch* kinds
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// cycleFetches collects the exchange requests of a cycle, for its heartbeat. It is safe for concurrent use.
+type cycleFetches struct {
+	mu      sync.Mutex
+	fetches []fetchStat
+}
+
+// observe records the outcome of an exchange request, in the metrics and for the heartbeat.
+func (c *cycleFetches) observe(exchange, kind string, d time.Duration, err error) {
+	metrics.ObserveFetch(exchange, kind, d, err)
+	stat := fetchStat{Exchange: exchange, Kind: kind, DurationMs: d.Milliseconds()}
+	if err != nil {
+		stat.Error = err.Error()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetches = append(c.fetches, stat)
+}
+
+// heartbeat is the statistics of a cycle, published to the stats queue every cycle, whether or not it found
+// opportunities, so monitoring can tell a stuck producer from a quiet market.
+type heartbeat struct {
+	Cycle      uint64         `json:"cycle"`
+	InstanceID string         `json:"instance_id"`
+	Leading    bool           `json:"leading"` // Standby instances publish heartbeats too
+	StartedAt  time.Time      `json:"started_at"`
+	DurationMs int64          `json:"duration_ms"`
+	Fetches    []fetchStat    `json:"fetches"`
+	Paused     []string       `json:"paused,omitempty"` // Exchanges skipped for maintenance
+	Tickers    map[string]int `json:"tickers"`          // Symbols with a ticker, per exchange
+	Symbols    int            `json:"symbols"`
+	Candidates int            `json:"candidates"` // Spreads computed
+	Published  int            `json:"published"`  // Spreads that passed the publish limits
+	Messages   int            `json:"messages"`   // Messages published to the spread sinks
+	Errors     int            `json:"errors"`     // Failed exchange requests
+}
+
+// newHeartbeat summarizes a cycle.
+func newHeartbeat(cycle uint64, instanceID string, leading bool, startedAt time.Time, fetches *cycleFetches, paused map[string]bool, tickers map[string]int) heartbeat {
+	fetches.mu.Lock()
+	defer fetches.mu.Unlock()
+	h := heartbeat{
+		Cycle:      cycle,
+		InstanceID: instanceID,
+		Leading:    leading,
+		StartedAt:  startedAt,
+		DurationMs: time.Since(startedAt).Milliseconds(),
+		Fetches:    append([]fetchStat{}, fetches.fetches...),
+		Tickers:    tickers,
+	}
+	for exchange, down := range paused {
+		if down {
+			h.Paused = append(h.Paused, exchange)
+		}
+	}
+	for _, f := range h.Fetches {
+		if f.Error != "" {
+			h.Errors++
+		}
+	}
+	return h
+}
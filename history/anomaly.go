@@ -0,0 +1,87 @@
+package history
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"math"
+	"sync"
+	"time"
+)
+
+// minAnomalyStdDev floors the deviation of a pair's entry spread, in percent, so a pair whose spread barely
+// moved doesn't flag every basis point as a spike.
+const minAnomalyStdDev = 0.01
+
+// ewma is the exponentially weighted mean and variance of a pair's entry spread.
+type ewma struct {
+	mean     float64
+	variance float64
+	samples  int
+	lastSeen time.Time
+}
+
+// AnomalyDetector flags sudden spikes of a pair's entry spread, the mark of real events (listings, depegs,
+// outages), apart from slow drifts. Each pair's spread is scored against its exponentially weighted mean
+// and deviation, which follow a drift but not a spike. It is safe for concurrent use.
+type AnomalyDetector struct {
+	mu         sync.Mutex
+	pairs      map[string]*ewma // Keyed by pair key
+	alpha      float64          // Weight of the latest observation, in (0, 1]; higher forgets faster
+	threshold  float64          // Score, in deviations, from which a spread is an anomaly
+	minSamples int              // Observations of a pair before it is scored
+	expiry     time.Duration    // Pairs unseen for this long start over
+}
+
+// NewAnomalyDetector creates a detector weighting each observation by alpha, flagging spreads at least
+// threshold deviations from their pair's mean once the pair has minSamples observations. Pairs unseen for
+// expiry are forgotten.
+func NewAnomalyDetector(alpha, threshold float64, minSamples int, expiry time.Duration) *AnomalyDetector {
+	return &AnomalyDetector{
+		pairs:      make(map[string]*ewma),
+		alpha:      alpha,
+		threshold:  threshold,
+		minSamples: minSamples,
+		expiry:     expiry,
+	}
+}
+
+// Apply scores each spread against its pair's previous observations, flagging anomalies, then records it.
+// It returns the number of anomalies.
+func (d *AnomalyDetector) Apply(spreads []arbitrage.Spread, now time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var anomalies int
+	for i := range spreads {
+		s := &spreads[i]
+		key := s.PairKey()
+		e, ok := d.pairs[key]
+		if !ok || now.Sub(e.lastSeen) > d.expiry {
+			e = &ewma{mean: s.EntrySpread}
+			d.pairs[key] = e
+		}
+
+		if e.samples >= d.minSamples {
+			score := (s.EntrySpread - e.mean) / max(math.Sqrt(e.variance), minAnomalyStdDev)
+			s.AnomalyScore = score
+			if math.Abs(score) >= d.threshold {
+				s.Anomaly = true
+				anomalies++
+			}
+		}
+
+		// West's incremental update of the weighted mean and variance
+		diff := s.EntrySpread - e.mean
+		incr := d.alpha * diff
+		e.mean += incr
+		e.variance = (1 - d.alpha) * (e.variance + diff*incr)
+		e.samples++
+		e.lastSeen = now
+	}
+
+	for key, e := range d.pairs {
+		if now.Sub(e.lastSeen) > d.expiry {
+			delete(d.pairs, key)
+		}
+	}
+	return anomalies
+}
@@ -0,0 +1,164 @@
+package history
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Candle is the OHLC of a pair's entry spread over one period of a timeframe.
+type Candle struct {
+	Start   time.Time `json:"start"`
+	Open    float64   `json:"open"`
+	High    float64   `json:"high"`
+	Low     float64   `json:"low"`
+	Close   float64   `json:"close"`
+	Samples int       `json:"samples"` // Cycles the pair was seen in during the period
+}
+
+// CandlePair identifies the pair and direction a candle series is of.
+type CandlePair struct {
+	UnifiedSymbol     string `json:"unified_symbol"`
+	UnifiedSymbolLong string `json:"unified_symbol_long,omitempty"`
+	ExchangeShort     string `json:"exchange_short"`
+	ExchangeLong      string `json:"exchange_long"`
+}
+
+// CandleSeries is a pair's candles of one timeframe, oldest first; the last one may still be open.
+type CandleSeries struct {
+	CandlePair
+	Timeframe string   `json:"timeframe"` // e.g., "5m"
+	Candles   []Candle `json:"candles"`
+}
+
+// ClosedCandle is a candle whose period ended, as handed to storage.
+type ClosedCandle struct {
+	CandlePair
+	Timeframe string
+	Candle
+}
+
+// candleSeries holds the candles of a pair for every timeframe.
+type candleSeries struct {
+	pair     CandlePair
+	lastSeen time.Time
+	candles  [][]Candle // Per timeframe, oldest first
+	open     []bool     // Per timeframe, whether the last candle's period hasn't been reported closed
+}
+
+// CandleAggregator maintains OHLC candles of each pair's entry spread over several timeframes in memory, so
+// whether a spread persisted or was a one-cycle blip can be told without storing every cycle.
+type CandleAggregator struct {
+	mu         sync.RWMutex
+	timeframes []time.Duration
+	limit      int                      // Candles kept per pair and timeframe
+	series     map[string]*candleSeries // Pair key -> series
+}
+
+// NewCandleAggregator creates an aggregator of candles of the timeframes (e.g., 1m, 5m, 1h), keeping the last
+// limit of each.
+func NewCandleAggregator(timeframes []time.Duration, limit int) *CandleAggregator {
+	return &CandleAggregator{
+		timeframes: slices.Clone(timeframes),
+		limit:      limit,
+		series:     make(map[string]*candleSeries),
+	}
+}
+
+// Observe adds the cycle's entry spreads to the candles of their pairs and returns the candles whose period
+// ended, including those of pairs missing from the cycle.
+func (a *CandleAggregator) Observe(spreads []arbitrage.Spread, now time.Time) []ClosedCandle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var closed []ClosedCandle
+	for _, s := range spreads {
+		key := s.PairKey()
+		series, ok := a.series[key]
+		if !ok {
+			series = &candleSeries{
+				pair:    CandlePair{UnifiedSymbol: s.UnifiedSymbol, UnifiedSymbolLong: s.UnifiedSymbolLong, ExchangeShort: s.ExchangeShort, ExchangeLong: s.ExchangeLong},
+				candles: make([][]Candle, len(a.timeframes)),
+				open:    make([]bool, len(a.timeframes)),
+			}
+			a.series[key] = series
+		}
+		series.lastSeen = now
+		for i, tf := range a.timeframes {
+			start := now.Truncate(tf)
+			candles := series.candles[i]
+			if n := len(candles); n > 0 && candles[n-1].Start.Equal(start) {
+				c := &candles[n-1]
+				c.High = max(c.High, s.EntrySpread)
+				c.Low = min(c.Low, s.EntrySpread)
+				c.Close = s.EntrySpread
+				c.Samples++
+				continue
+			}
+			if series.open[i] {
+				closed = append(closed, ClosedCandle{CandlePair: series.pair, Timeframe: FormatTimeframe(tf), Candle: candles[len(candles)-1]})
+			}
+			candles = append(candles, Candle{Start: start, Open: s.EntrySpread, High: s.EntrySpread, Low: s.EntrySpread, Close: s.EntrySpread, Samples: 1})
+			if len(candles) > a.limit {
+				candles = slices.Delete(candles, 0, len(candles)-a.limit)
+			}
+			series.candles[i] = candles
+			series.open[i] = true
+		}
+	}
+
+	// Candles of pairs missing from the cycle close when their period ends; pairs whose candles all expired
+	// are dropped
+	longest := slices.Max(a.timeframes)
+	for key, series := range a.series {
+		if now.Sub(series.lastSeen) > longest*time.Duration(a.limit) {
+			delete(a.series, key)
+			continue
+		}
+		for i, tf := range a.timeframes {
+			candles := series.candles[i]
+			if series.open[i] && !candles[len(candles)-1].Start.Add(tf).After(now) {
+				closed = append(closed, ClosedCandle{CandlePair: series.pair, Timeframe: FormatTimeframe(tf), Candle: candles[len(candles)-1]})
+				series.open[i] = false
+			}
+		}
+	}
+	return closed
+}
+
+// Series returns copies of the candle series of a timeframe for every pair of a unified symbol.
+func (a *CandleAggregator) Series(unifiedSymbol string, timeframe time.Duration) ([]CandleSeries, error) {
+	i := slices.Index(a.timeframes, timeframe)
+	if i < 0 {
+		return nil, fmt.Errorf("timeframe %s is not aggregated", FormatTimeframe(timeframe))
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var result []CandleSeries
+	for _, series := range a.series {
+		if series.pair.UnifiedSymbol != unifiedSymbol {
+			continue
+		}
+		result = append(result, CandleSeries{CandlePair: series.pair, Timeframe: FormatTimeframe(timeframe), Candles: slices.Clone(series.candles[i])})
+	}
+	return result, nil
+}
+
+// Timeframes returns the aggregated timeframes.
+func (a *CandleAggregator) Timeframes() []time.Duration {
+	return slices.Clone(a.timeframes)
+}
+
+// FormatTimeframe formats a timeframe in its largest whole unit, e.g., "5m" rather than "5m0s".
+func FormatTimeframe(tf time.Duration) string {
+	switch {
+	case tf%time.Hour == 0:
+		return fmt.Sprintf("%dh", tf/time.Hour)
+	case tf%time.Minute == 0:
+		return fmt.Sprintf("%dm", tf/time.Minute)
+	default:
+		return tf.String()
+	}
+}
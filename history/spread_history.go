@@ -0,0 +1,341 @@
+package history
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisKeyPrefix is the default prefix of the Redis keys of the samples, followed by the pair key.
+const RedisKeyPrefix = "spread:history:"
+
+const (
+	maxRedisSamples    = 20_000 // Upper bound on samples kept per pair in Redis
+	minMomentumSamples = 3      // Observations, the current one included, needed to fit the momentum
+)
+
+// Sample is a single observed entry spread of a pair.
+type Sample struct {
+	Time  int64   `json:"t"` // Observation time in milliseconds
+	Value float64 `json:"v"` // Entry spread in percent
+}
+
+// SpreadHistory keeps a rolling window of observed entry spreads per symbol-pair and derives
+// statistics from it. When a Redis client is set, samples are mirrored there so history survives restarts.
+type SpreadHistory struct {
+	mu          sync.Mutex
+	series      map[string][]Sample // Pair key -> samples, oldest first
+	window      time.Duration
+	minSamples  int
+	percentile  float64 // Percentile (0-100) reported as the pair's dynamic threshold; 0 disables
+	redisClient *redis.Client
+	keyPrefix   string // Of the Redis keys, followed by the pair key
+
+	momentumWindow time.Duration // Recent span the momentum is fitted over; 0 disables
+	stableBps      float64       // Momentum, in bps per minute, under which a pair's trend is stable
+}
+
+// NewSpreadHistory creates a history keeping samples for window. Statistics are only attached
+// once a pair has at least minSamples samples. If percentile is above 0, the value at that percentile
+// of the window is attached as the pair's dynamic threshold. redisClient may be nil to keep history in memory only.
+func NewSpreadHistory(window time.Duration, minSamples int, percentile float64, redisClient *redis.Client) *SpreadHistory {
+	return &SpreadHistory{
+		series:      make(map[string][]Sample),
+		window:      window,
+		minSamples:  minSamples,
+		percentile:  percentile,
+		redisClient: redisClient,
+		keyPrefix:   RedisKeyPrefix,
+	}
+}
+
+// SetRedisKeyPrefix replaces the prefix of the Redis keys, e.g., to keep deployments sharing Redis apart. It must
+// be called before LoadFromRedis.
+func (h *SpreadHistory) SetRedisKeyPrefix(prefix string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keyPrefix = prefix
+}
+
+// SetMomentum enables the momentum of each spread: the slope of its entry spread over the last window, fitted
+// by least squares, in bps per minute. Pairs moving by less than stableBpsPerMin are reported as stable.
+func (h *SpreadHistory) SetMomentum(window time.Duration, stableBpsPerMin float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.momentumWindow = window
+	h.stableBps = stableBpsPerMin
+}
+
+// Observe attaches rolling statistics (computed from previous cycles) to each spread and then
+// records the spreads' current values, from which the momentum, if enabled, is fitted.
+func (h *SpreadHistory) Observe(spreads []arbitrage.Spread, now time.Time) {
+	// Redis is written after unlocking, so a slow round trip doesn't hold up readers of the history
+	if pending := h.observe(spreads, now); len(pending) > 0 {
+		h.persist(pending)
+	}
+}
+
+// observe attaches the statistics and records the spreads, see Observe. It returns the samples to persist, by
+// Redis key, or nil without a Redis client.
+func (h *SpreadHistory) observe(spreads []arbitrage.Spread, now time.Time) map[string]Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := now.Add(-h.window).UnixMilli()
+	nowMs := now.UnixMilli()
+	var pending map[string]Sample
+	if h.redisClient != nil {
+		pending = make(map[string]Sample, len(spreads))
+	}
+
+	for i := range spreads {
+		s := &spreads[i]
+		key := s.PairKey()
+		samples := pruneBefore(h.series[key], cutoff)
+
+		if len(samples) >= h.minSamples {
+			s.Stats = computeStats(samples, s.EntrySpread)
+			if h.percentile > 0 {
+				threshold := percentileOf(samples, h.percentile)
+				s.Stats.PercentileThreshold = &threshold
+			}
+		}
+
+		sample := Sample{Time: nowMs, Value: s.EntrySpread}
+		h.series[key] = append(samples, sample)
+		if pending != nil {
+			pending[h.keyPrefix+key] = sample
+		}
+		if h.momentumWindow > 0 {
+			h.applyMomentum(s, h.series[key], now.Add(-h.momentumWindow).UnixMilli())
+		}
+	}
+
+	// Drop pairs that haven't been seen for a whole window.
+	for key, samples := range h.series {
+		if samples[len(samples)-1].Time < cutoff {
+			delete(h.series, key)
+		}
+	}
+
+	return pending
+}
+
+// Samples returns a copy of the samples within the window for a pair key.
+func (h *SpreadHistory) Samples(pairKey string) []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Sample(nil), h.series[pairKey]...)
+}
+
+// LoadFromRedis restores samples persisted by previous runs. It is a no-op without a Redis client.
+func (h *SpreadHistory) LoadFromRedis() {
+	if h.redisClient == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	h.mu.Lock()
+	prefix := h.keyPrefix
+	h.mu.Unlock()
+
+	// The lists are read before locking, so a slow Redis doesn't hold up the history
+	cutoff := time.Now().Add(-h.window).UnixMilli()
+	loaded := make(map[string][]Sample)
+	iter := h.redisClient.Scan(ctx, 0, prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		values, err := h.redisClient.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			slog.Warn("Failed to get spread history from Redis", "key", key, "error", err)
+			continue
+		}
+		var samples []Sample
+		for _, v := range values {
+			var sample Sample
+			if err := json.Unmarshal([]byte(v), &sample); err != nil || sample.Time < cutoff {
+				continue
+			}
+			samples = append(samples, sample)
+		}
+		if len(samples) > 0 {
+			loaded[strings.TrimPrefix(key, prefix)] = samples
+		}
+	}
+	if err := iter.Err(); err != nil {
+		slog.Error("Failed to scan Redis keys for spread history", "error", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	maps.Copy(h.series, loaded)
+	slog.Info("Finished loading spread history from Redis.", "pairs", len(h.series))
+}
+
+// PruneRedis trims samples older than the window from the head of every pair's list in Redis. Lists of
+// pairs that are still observed never expire, so without pruning they keep their oldest samples up to
+// the per-pair cap. It returns the number of samples removed.
+func (h *SpreadHistory) PruneRedis(ctx context.Context, cutoff time.Time) (int64, error) {
+	if h.redisClient == nil {
+		return 0, nil
+	}
+	cutoffMs := cutoff.UnixMilli()
+	var removed int64
+	iter := h.redisClient.Scan(ctx, 0, h.keyPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		values, err := h.redisClient.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return removed, fmt.Errorf("failed to get spread history %s from Redis: %w", key, err)
+		}
+		// Samples are appended in time order, so the stale ones form a prefix
+		stale := 0
+		for _, v := range values {
+			var sample Sample
+			if err := json.Unmarshal([]byte(v), &sample); err == nil && sample.Time >= cutoffMs {
+				break
+			}
+			stale++
+		}
+		if stale == 0 {
+			continue
+		}
+		if err := h.redisClient.LTrim(ctx, key, int64(stale), -1).Err(); err != nil {
+			return removed, fmt.Errorf("failed to trim spread history %s in Redis: %w", key, err)
+		}
+		removed += int64(stale)
+	}
+	return removed, iter.Err()
+}
+
+// persist appends the samples to Redis under their keys, trimming each list and refreshing its TTL.
+func (h *SpreadHistory) persist(samples map[string]Sample) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipe := h.redisClient.Pipeline()
+	for key, sample := range samples {
+		val, err := json.Marshal(sample)
+		if err != nil {
+			continue
+		}
+		pipe.RPush(ctx, key, val)
+		pipe.LTrim(ctx, key, -maxRedisSamples, -1)
+		pipe.Expire(ctx, key, h.window)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Error("Failed to persist spread history to Redis", "error", err)
+	}
+}
+
+// applyMomentum sets the momentum and trend of s from the samples since cutoff, the current one included.
+func (h *SpreadHistory) applyMomentum(s *arbitrage.Spread, samples []Sample, cutoff int64) {
+	recent := pruneBefore(samples, cutoff)
+	if len(recent) < minMomentumSamples {
+		return
+	}
+	slope, ok := slopePerMs(recent)
+	if !ok {
+		return
+	}
+	// Spreads are in percent, so 1 bps = 0.01
+	bpsPerMin := slope * 100 * float64(time.Minute.Milliseconds())
+	s.MomentumBpsPerMin = &bpsPerMin
+	switch {
+	case bpsPerMin >= h.stableBps:
+		s.Trend = arbitrage.TrendWidening
+	case bpsPerMin <= -h.stableBps:
+		s.Trend = arbitrage.TrendConverging
+	default:
+		s.Trend = arbitrage.TrendStable
+	}
+}
+
+// slopePerMs returns the least squares slope of the samples' values over their times, per millisecond. It fails
+// if every sample has the same time.
+func slopePerMs(samples []Sample) (float64, bool) {
+	// Times are taken relative to the first sample, so their squares don't lose precision
+	t0 := samples[0].Time
+	var sumT, sumV float64
+	for _, s := range samples {
+		sumT += float64(s.Time - t0)
+		sumV += s.Value
+	}
+	n := float64(len(samples))
+	meanT, meanV := sumT/n, sumV/n
+	var cov, varT float64
+	for _, s := range samples {
+		dt := float64(s.Time-t0) - meanT
+		cov += dt * (s.Value - meanV)
+		varT += dt * dt
+	}
+	if varT == 0 {
+		return 0, false
+	}
+	return cov / varT, true
+}
+
+// pruneBefore drops samples older than cutoff. Samples are ordered by time.
+func pruneBefore(samples []Sample, cutoff int64) []Sample {
+	i := 0
+	for i < len(samples) && samples[i].Time < cutoff {
+		i++
+	}
+	return samples[i:]
+}
+
+// computeStats returns the mean and standard deviation of the samples, and the z-score of current against them.
+func computeStats(samples []Sample, current float64) *arbitrage.SpreadStats {
+	var sum float64
+	for _, s := range samples {
+		sum += s.Value
+	}
+	mean := sum / float64(len(samples))
+
+	var sqDiff float64
+	for _, s := range samples {
+		sqDiff += (s.Value - mean) * (s.Value - mean)
+	}
+	stdDev := math.Sqrt(sqDiff / float64(len(samples)))
+
+	zScore := 0.0
+	if stdDev > 0 {
+		zScore = (current - mean) / stdDev
+	}
+
+	return &arbitrage.SpreadStats{
+		Mean:    mean,
+		StdDev:  stdDev,
+		ZScore:  zScore,
+		Samples: len(samples),
+	}
+}
+
+// percentileOf returns the value at percentile p (0-100) of the samples, interpolating between closest ranks.
+func percentileOf(samples []Sample, p float64) float64 {
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.Value
+	}
+	sort.Float64s(values)
+
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if upper >= len(values) {
+		return values[len(values)-1]
+	}
+	return values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,105 @@
+package latency
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ewmaWeight is the weight of a new observation in the moving average.
+const ewmaWeight = 0.2
+
+// Pinger measures one round trip to an exchange.
+type Pinger func(ctx context.Context) (time.Duration, error)
+
+// Tracker keeps an exponentially weighted moving average of the round-trip latency to each exchange.
+// It is fed by its own periodic pings, whose round trips carry no payload.
+type Tracker struct {
+	mu        sync.RWMutex
+	latencies map[string]time.Duration
+	pingers   map[string]Pinger
+}
+
+// NewTracker creates an empty latency tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		latencies: make(map[string]time.Duration),
+		pingers:   make(map[string]Pinger),
+	}
+}
+
+// RegisterPinger sets the ping function for an exchange (e.g., "Binance").
+func (t *Tracker) RegisterPinger(exchange string, p Pinger) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pingers[exchange] = p
+}
+
+// Observe adds a measured round trip to an exchange's moving average.
+func (t *Tracker) Observe(exchange string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current, ok := t.latencies[exchange]
+	if !ok {
+		t.latencies[exchange] = d
+		return
+	}
+	t.latencies[exchange] = time.Duration(ewmaWeight*float64(d) + (1-ewmaWeight)*float64(current))
+}
+
+// Get returns the average latency of an exchange, if any round trip has been observed.
+func (t *Tracker) Get(exchange string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	d, ok := t.latencies[exchange]
+	return d, ok
+}
+
+// All returns the average latency of every exchange with an observed round trip.
+func (t *Tracker) All() map[string]time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	latencies := make(map[string]time.Duration, len(t.latencies))
+	for exchange, d := range t.latencies {
+		latencies[exchange] = d
+	}
+	return latencies
+}
+
+// Ping pings every registered exchange once and records the round trips.
+func (t *Tracker) Ping(ctx context.Context) {
+	t.mu.RLock()
+	pingers := make(map[string]Pinger, len(t.pingers))
+	for exchange, p := range t.pingers {
+		pingers[exchange] = p
+	}
+	t.mu.RUnlock()
+
+	for exchange, ping := range pingers {
+		d, err := ping(ctx)
+		if err != nil {
+			slog.Warn("Failed to ping exchange", "exchange", exchange, "error", err)
+			continue
+		}
+		t.Observe(exchange, d)
+	}
+}
+
+// Run pings immediately and then every interval. It blocks until ctx is cancelled and should be run in a goroutine.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	t.Ping(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Ping(ctx)
+		}
+	}
+}
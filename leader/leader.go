@@ -0,0 +1,129 @@
+// Package leader elects one instance among several sharing a Redis, so redundant instances can run while
+// only one of them publishes.
+package leader
+
+import (
+	"cex-price-diff-notifications/metrics"
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// renewScript extends the lease if it is still held by this instance.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript deletes the lease if it is still held by this instance.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Elector campaigns for a lease stored under a Redis key. The leader renews its lease every third of the
+// TTL; when it stops (crash, deploy, network partition), the lease expires and another instance takes over.
+type Elector struct {
+	client    *redis.Client
+	key       string
+	id        string
+	ttl       time.Duration
+	leader    atomic.Bool
+	renewedAt time.Time // Last successful acquisition or renewal, only used by Run
+}
+
+// NewElector creates an elector for the lease under key, identifying this instance by id (which must be
+// unique among the instances).
+func NewElector(client *redis.Client, key, id string, ttl time.Duration) *Elector {
+	return &Elector{client: client, key: key, id: id, ttl: ttl}
+}
+
+// IsLeader reports whether this instance currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns immediately and then every third of the TTL. It blocks until ctx is cancelled, then gives up
+// the lease so another instance takes over without waiting for it to expire; it should be run in a goroutine.
+func (e *Elector) Run(ctx context.Context) {
+	e.campaign(ctx)
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+			e.campaign(ctx)
+		}
+	}
+}
+
+// campaign renews the lease if this instance holds it, or tries to acquire it otherwise.
+func (e *Elector) campaign(ctx context.Context) {
+	ttlMs := e.ttl.Milliseconds()
+	if e.IsLeader() {
+		renewed, err := renewScript.Run(ctx, e.client, []string{e.key}, e.id, ttlMs).Int()
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// Stopping; Run releases the lease
+		case err != nil:
+			slog.Warn("Failed to renew the leader lease", "key", e.key, "error", err)
+			// Step down before the lease can lapse, so two instances never publish at once
+			if time.Since(e.renewedAt) >= e.ttl*2/3 {
+				e.setLeader(false, "the lease could not be renewed")
+			}
+		case renewed == 0:
+			e.setLeader(false, "the lease was taken over")
+		default:
+			e.renewedAt = time.Now()
+		}
+		return
+	}
+
+	acquired, err := e.client.SetNX(ctx, e.key, e.id, e.ttl).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("Failed to campaign for the leader lease", "key", e.key, "error", err)
+		}
+		return
+	}
+	if acquired {
+		e.renewedAt = time.Now()
+		e.setLeader(true, "the lease was acquired")
+	}
+}
+
+// release gives up the lease if this instance holds it.
+func (e *Elector) release() {
+	if !e.IsLeader() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := releaseScript.Run(ctx, e.client, []string{e.key}, e.id).Err(); err != nil {
+		slog.Warn("Failed to release the leader lease, it will expire", "key", e.key, "error", err)
+		return
+	}
+	e.leader.Store(false)
+	metrics.Leader.Set(0)
+	slog.Info("Released the leader lease", "instance", e.id)
+}
+
+// setLeader records a change of role.
+func (e *Elector) setLeader(leader bool, reason string) {
+	e.leader.Store(leader)
+	if leader {
+		metrics.Leader.Set(1)
+		slog.Info("Became the leader, publishing", "instance", e.id, "reason", reason)
+	} else {
+		metrics.Leader.Set(0)
+		slog.Warn("Stepped down as the leader, no longer publishing", "instance", e.id, "reason", reason)
+	}
+}
@@ -0,0 +1,169 @@
+package lifecycle
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// convergenceBuckets are the upper bounds of the histogram of convergence times, the last bucket holding the rest.
+var convergenceBuckets = []time.Duration{
+	10 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 4 * time.Hour,
+}
+
+// ConvergenceBucket counts the convergence times up to an upper bound.
+type ConvergenceBucket struct {
+	LESeconds float64 `json:"le_seconds,omitempty"` // Inclusive upper bound; omitted on the last bucket, which has none
+	Count     int     `json:"count"`
+}
+
+// PairConvergence is the convergence-time distribution of a pair, as served by the API.
+type PairConvergence struct {
+	UnifiedSymbol     string                     `json:"unified_symbol"`
+	UnifiedSymbolLong string                     `json:"unified_symbol_long,omitempty"`
+	ExchangeShort     string                     `json:"exchange_short"`
+	ExchangeLong      string                     `json:"exchange_long"`
+	Stats             arbitrage.ConvergenceStats `json:"stats"`
+	Histogram         []ConvergenceBucket        `json:"histogram"`
+	LastClosedAt      int64                      `json:"last_closed_at"` // Milliseconds since epoch
+}
+
+// convergencePair holds the recent convergence times of a pair.
+type convergencePair struct {
+	spread       arbitrage.Spread // Last closed observation, for the pair's identity
+	durations    []float64        // Seconds, oldest first
+	lastClosedAt time.Time
+}
+
+// Convergence records how long each opportunity stayed published before converging, from the closed events of
+// a Tracker, and attaches the distribution of its pair's past ones to each spread so consumers can judge whether
+// they have time to act. The last maxSamples per pair are kept, in memory. It is safe for concurrent use.
+type Convergence struct {
+	mu         sync.RWMutex
+	pairs      map[string]*convergencePair // Pair key -> convergence times
+	maxSamples int
+	minSamples int
+}
+
+// NewConvergence creates a Convergence keeping maxSamples convergence times per pair and attaching their
+// distribution once a pair has at least minSamples.
+func NewConvergence(maxSamples, minSamples int) *Convergence {
+	return &Convergence{
+		pairs:      make(map[string]*convergencePair),
+		maxSamples: max(maxSamples, 1),
+		minSamples: max(minSamples, 1),
+	}
+}
+
+// Observe records the lifetime of the opportunities closed by events.
+func (c *Convergence) Observe(events []Event, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range events {
+		if e.EventType != EventClosed {
+			continue
+		}
+		key := e.Spread.PairKey()
+		p, ok := c.pairs[key]
+		if !ok {
+			p = &convergencePair{}
+			c.pairs[key] = p
+		}
+		p.spread, p.lastClosedAt = e.Spread, now
+		p.durations = append(p.durations, e.DurationSeconds)
+		if len(p.durations) > c.maxSamples {
+			p.durations = slices.Delete(p.durations, 0, len(p.durations)-c.maxSamples)
+		}
+	}
+}
+
+// Apply sets the convergence stats of each spread whose pair has enough samples.
+func (c *Convergence) Apply(spreads []arbitrage.Spread) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for i := range spreads {
+		s := &spreads[i]
+		if p, ok := c.pairs[s.PairKey()]; ok && len(p.durations) >= c.minSamples {
+			stats := convergenceStats(p.durations)
+			s.Convergence = &stats
+		}
+	}
+}
+
+// Pairs returns the convergence-time distributions of every pair with a sample, or only those of symbol and
+// of exchange (on either leg) if set, by symbol then exchanges.
+func (c *Convergence) Pairs(symbol, exchange string) []PairConvergence {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	pairs := make([]PairConvergence, 0, len(c.pairs))
+	for _, p := range c.pairs {
+		s := p.spread
+		if symbol != "" && s.UnifiedSymbol != symbol {
+			continue
+		}
+		if exchange != "" && !strings.EqualFold(s.ExchangeShort, exchange) && !strings.EqualFold(s.ExchangeLong, exchange) {
+			continue
+		}
+		pairs = append(pairs, PairConvergence{
+			UnifiedSymbol:     s.UnifiedSymbol,
+			UnifiedSymbolLong: s.UnifiedSymbolLong,
+			ExchangeShort:     s.ExchangeShort,
+			ExchangeLong:      s.ExchangeLong,
+			Stats:             convergenceStats(p.durations),
+			Histogram:         convergenceHistogram(p.durations),
+			LastClosedAt:      p.lastClosedAt.UnixMilli(),
+		})
+	}
+	slices.SortFunc(pairs, func(a, b PairConvergence) int {
+		return cmp.Or(
+			strings.Compare(a.UnifiedSymbol, b.UnifiedSymbol),
+			strings.Compare(a.ExchangeShort, b.ExchangeShort),
+			strings.Compare(a.ExchangeLong, b.ExchangeLong),
+		)
+	})
+	return pairs
+}
+
+// convergenceStats returns the distribution of durations, in seconds.
+func convergenceStats(durations []float64) arbitrage.ConvergenceStats {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	return arbitrage.ConvergenceStats{
+		Samples:       len(sorted),
+		MedianSeconds: percentileOf(sorted, 50),
+		P25Seconds:    percentileOf(sorted, 25),
+		P75Seconds:    percentileOf(sorted, 75),
+		P90Seconds:    percentileOf(sorted, 90),
+		MaxSeconds:    sorted[len(sorted)-1],
+	}
+}
+
+// convergenceHistogram counts durations, in seconds, per bucket of convergenceBuckets.
+func convergenceHistogram(durations []float64) []ConvergenceBucket {
+	histogram := make([]ConvergenceBucket, len(convergenceBuckets)+1)
+	for i, bound := range convergenceBuckets {
+		histogram[i].LESeconds = bound.Seconds()
+	}
+	for _, d := range durations {
+		i, _ := slices.BinarySearchFunc(convergenceBuckets, d, func(bound time.Duration, d float64) int {
+			return cmp.Compare(bound.Seconds(), d)
+		})
+		histogram[i].Count++
+	}
+	return histogram
+}
+
+// percentileOf returns the value at percentile p (0-100) of sorted values, interpolating between closest ranks.
+func percentileOf(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
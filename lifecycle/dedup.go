@@ -0,0 +1,62 @@
+package lifecycle
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"math"
+	"time"
+)
+
+// published is the last published state of a single (symbol, exchange pair, direction).
+type published struct {
+	at          time.Time
+	entrySpread float64
+}
+
+// Deduplicator suppresses repeated alerts for an opportunity that is still open: once a spread is published,
+// it is held back until the cooldown elapses or its entry spread moves by at least minChangeBps.
+// It is not safe for concurrent use.
+type Deduplicator struct {
+	last         map[string]published // Pair key -> last published state
+	cooldown     time.Duration
+	minChangeBps float64
+}
+
+// NewDeduplicator creates a Deduplicator. A minChangeBps of 0 republishes only after the cooldown.
+func NewDeduplicator(cooldown time.Duration, minChangeBps float64) *Deduplicator {
+	return &Deduplicator{
+		last:         make(map[string]published),
+		cooldown:     cooldown,
+		minChangeBps: minChangeBps,
+	}
+}
+
+// Apply returns the spreads that should be published this cycle, preserving order, and the number suppressed
+// as duplicates. Pairs missing from spreads are forgotten, so a reopened opportunity is published right away.
+func (d *Deduplicator) Apply(spreads []arbitrage.Spread, now time.Time) ([]arbitrage.Spread, int) {
+	selected := spreads[:0:0]
+	suppressed := 0
+	seen := make(map[string]bool, len(spreads))
+
+	for _, s := range spreads {
+		key := s.PairKey()
+		seen[key] = true
+
+		if p, ok := d.last[key]; ok && now.Sub(p.at) < d.cooldown {
+			// Spreads are in percent, so 1 bps = 0.01.
+			if d.minChangeBps <= 0 || math.Abs(s.EntrySpread-p.entrySpread)*100 < d.minChangeBps {
+				suppressed++
+				continue
+			}
+		}
+
+		d.last[key] = published{at: now, entrySpread: s.EntrySpread}
+		selected = append(selected, s)
+	}
+
+	for key := range d.last {
+		if !seen[key] {
+			delete(d.last, key)
+		}
+	}
+	return selected, suppressed
+}
@@ -0,0 +1,219 @@
+package lifecycle
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/shared"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exit signal reasons.
+const (
+	ExitReasonProfit      = "profit"       // Closing both legs now captures at least the profit threshold after fees
+	ExitReasonFundingFlip = "funding_flip" // The next funding settlement within the window charges the position
+)
+
+// Entry is an opportunity a position entered, watched for exit signals.
+type Entry struct {
+	OpportunityID string           `json:"opportunity_id"`
+	EnteredAt     time.Time        `json:"entered_at"`
+	Simulated     bool             `json:"simulated"` // Entered when the opportunity opened, rather than reported by a consumer
+	Spread        arbitrage.Spread `json:"spread"`    // As entered, with the latest exit spread and funding
+	Funding       FundingLedger    `json:"funding"`   // Funding settled on both legs since the entry
+}
+
+// entry is the watched state of an entered opportunity.
+type entry struct {
+	Entry
+	signalled map[string]bool // Reasons already signalled, until their condition clears
+}
+
+// ExitSignals watches entered opportunities and signals when to exit them: once the exit spread and the funding
+// settled since the entry capture the profit threshold, or when funding is about to flip against the position.
+// Each reason is signalled once, then again only after its condition has cleared. Simulated entries stop being
+// watched after their profit signal or after the maximum holding time. It is safe for concurrent use.
+type ExitSignals struct {
+	mu            sync.Mutex
+	entries       map[string]*entry // Pair key -> entry
+	minProfitPct  float64
+	fundingWindow time.Duration
+	maxHold       time.Duration
+}
+
+// NewExitSignals creates exit signals for a profit threshold after fees, in percent, a window before funding
+// settlements, and a maximum holding time of simulated entries (0 keeps them until their profit signal).
+func NewExitSignals(minProfitPct float64, fundingWindow, maxHold time.Duration) *ExitSignals {
+	return &ExitSignals{
+		entries:       make(map[string]*entry),
+		minProfitPct:  minProfitPct,
+		fundingWindow: fundingWindow,
+		maxHold:       maxHold,
+	}
+}
+
+// Enter watches an opportunity entered at the spread s. Entering a watched pair again replaces its entry.
+func (x *ExitSignals) Enter(opportunityID string, s arbitrage.Spread, at time.Time, simulated bool) {
+	if opportunityID == "" {
+		opportunityID = newID()
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.entries[s.PairKey()] = &entry{
+		Entry:     Entry{OpportunityID: opportunityID, EnteredAt: at, Simulated: simulated, Spread: s, Funding: newFundingLedger(s.TargetNotionalUSD)},
+		signalled: make(map[string]bool),
+	}
+}
+
+// EnterOpened watches the opportunities opened by lifecycle events as simulated entries.
+func (x *ExitSignals) EnterOpened(events []Event, now time.Time) {
+	for _, e := range events {
+		if e.EventType == EventOpened {
+			x.Enter(e.OpportunityID, e.Spread, now, true)
+		}
+	}
+}
+
+// Exit stops watching an entered opportunity, by pair key or opportunity ID. It reports whether one was watched.
+func (x *ExitSignals) Exit(id string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for key, e := range x.entries {
+		if key == id || strings.EqualFold(e.OpportunityID, id) {
+			delete(x.entries, key)
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns the watched entries, oldest first.
+func (x *ExitSignals) Entries() []Entry {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	entries := make([]Entry, 0, len(x.entries))
+	for _, e := range x.entries {
+		entry := e.Entry
+		entry.Funding = e.Funding.clone()
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return a.EnteredAt.Compare(b.EnteredAt) })
+	return entries
+}
+
+// Update prices the exit of every entry with the cycle's tickers and funding rates, keyed by unified symbol
+// then exchange and by exchange then unified symbol, and returns the exit signals.
+func (x *ExitSignals) Update(
+	tickers map[string]map[string]shared.TickerBidAsk,
+	funding map[string]map[string]shared.FundingRateInfo,
+	now time.Time,
+) []Event {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var events []Event
+	for _, key := range slices.Sorted(maps.Keys(x.entries)) {
+		e := x.entries[key]
+		if e.Simulated && x.maxHold > 0 && now.Sub(e.EnteredAt) >= x.maxHold {
+			delete(x.entries, key)
+			continue
+		}
+		s := &e.Spread
+		if info, ok := funding[s.ExchangeShort][s.UnifiedSymbol]; ok {
+			s.FundingRateShort = &info
+		}
+		if info, ok := funding[s.ExchangeLong][s.LongSymbol()]; ok {
+			s.FundingRateLong = &info
+		}
+		one := []arbitrage.Spread{*s}
+		arbitrage.ApplyFundingWindow(one, 0, now)
+		*s = one[0]
+		e.Funding.observe(LegShort, s.ExchangeShort, s.FundingRateShort, e.EnteredAt, now)
+		e.Funding.observe(LegLong, s.ExchangeLong, s.FundingRateLong, e.EnteredAt, now)
+
+		// Closing buys back the short leg at its ask and sells the long leg at its bid
+		short, okShort := tickers[s.UnifiedSymbol][s.ExchangeShort]
+		long, okLong := tickers[s.LongSymbol()][s.ExchangeLong]
+		priced := okShort && okLong && short.Ask > 0 && long.Bid > 0
+		var captured *float64
+		if priced {
+			conversion := s.ConversionRate
+			if conversion == 0 {
+				conversion = 1
+			}
+			bidLong := long.Bid * conversion
+			s.ExitDiff = bidLong - short.Ask
+			s.ExitSpread = s.ExitDiff / ((bidLong + short.Ask) / 2) * 100
+			s.QuoteTime = short.Timestamp
+			if long.Timestamp.Before(short.Timestamp) {
+				s.QuoteTime = long.Timestamp
+			}
+			net := s.EntrySpread + s.ExitSpread - s.RoundTripFeesPct
+			captured = &net
+		}
+
+		if e.signal(ExitReasonProfit, captured != nil && *captured+e.Funding.PnLPct >= x.minProfitPct) {
+			events = append(events, e.event(ExitReasonProfit, captured, now))
+			if e.Simulated {
+				delete(x.entries, key)
+				continue
+			}
+		}
+		if e.signal(ExitReasonFundingFlip, x.fundingAgainst(*s)) {
+			events = append(events, e.event(ExitReasonFundingFlip, captured, now))
+		}
+	}
+	return events
+}
+
+// signal reports whether reason should be signalled now that its condition holds or not, and re-arms it
+// once the condition clears.
+func (e *entry) signal(reason string, holds bool) bool {
+	if !holds {
+		delete(e.signalled, reason)
+		return false
+	}
+	if e.signalled[reason] {
+		return false
+	}
+	e.signalled[reason] = true
+	return true
+}
+
+// fundingAgainst reports whether the legs settling within the funding window charge the position more than
+// they pay it: the short leg pays a negative rate and the long leg a positive one.
+func (x *ExitSignals) fundingAgainst(s arbitrage.Spread) bool {
+	if x.fundingWindow <= 0 {
+		return false
+	}
+	limit := int64(x.fundingWindow.Seconds())
+	var net float64
+	var due bool
+	if s.FundingRateShort != nil && s.SecondsToFundingShort != nil && *s.SecondsToFundingShort <= limit {
+		net += s.FundingRateShort.ShortRate()
+		due = true
+	}
+	if s.FundingRateLong != nil && s.SecondsToFundingLong != nil && *s.SecondsToFundingLong <= limit {
+		net -= s.FundingRateLong.Rate
+		due = true
+	}
+	return due && net < 0
+}
+
+// event builds an exit signal of an entry.
+func (e *entry) event(reason string, captured *float64, now time.Time) Event {
+	funding := e.Funding.PnLPct
+	return Event{
+		EventType:       EventExitSignal,
+		OpportunityID:   e.OpportunityID,
+		OpenedAt:        e.EnteredAt.UnixMilli(),
+		DurationSeconds: now.Sub(e.EnteredAt).Seconds(),
+		MaxEntrySpread:  e.Spread.EntrySpread,
+		Spread:          e.Spread,
+		ExitReason:      reason,
+		CapturedSpread:  captured,
+		FundingPnLPct:   &funding,
+	}
+}
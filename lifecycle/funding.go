@@ -0,0 +1,81 @@
+package lifecycle
+
+import (
+	"cex-price-diff-notifications/shared"
+	"slices"
+	"time"
+)
+
+// Position legs.
+const (
+	LegShort = "short"
+	LegLong  = "long"
+)
+
+// FundingSettlement is a funding payment settled on one leg of an entered position.
+type FundingSettlement struct {
+	Leg        string  `json:"leg"` // LegShort or LegLong
+	Exchange   string  `json:"exchange"`
+	SettleTime int64   `json:"settle_time"` // Milliseconds since epoch
+	Rate       float64 `json:"rate"`        // Last rate seen before the settlement
+	PnLPct     float64 `json:"pnl_pct"`     // Received (positive) or paid, in percent of the leg notional
+}
+
+// FundingLedger records the funding settled on the legs of an entered position. Exchanges only report the
+// upcoming settlement, so a settlement is recorded at the last rate seen once its time has passed.
+type FundingLedger struct {
+	Settlements []FundingSettlement               `json:"settlements"`
+	PnLPct      float64                           `json:"pnl_pct"`           // Funding received minus paid since the entry, in percent of the leg notional
+	PnLUSD      *float64                          `json:"pnl_usd,omitempty"` // The same at the target notional, when known
+	notional    float64                           // Notional per leg
+	upcoming    map[string]shared.FundingRateInfo // Leg -> last seen upcoming settlement
+	settled     map[string]int64                  // Leg -> time of the last recorded settlement
+}
+
+// newFundingLedger creates an empty ledger for legs of a notional, 0 if unknown.
+func newFundingLedger(notional float64) FundingLedger {
+	l := FundingLedger{
+		notional: notional,
+		upcoming: make(map[string]shared.FundingRateInfo),
+		settled:  make(map[string]int64),
+	}
+	if notional > 0 {
+		l.PnLUSD = new(float64)
+	}
+	return l
+}
+
+// observe records the settlement of a leg that has passed since it was last observed, then remembers the
+// upcoming one. Settlements before since are ignored. A short leg receives the rate, a long leg pays it.
+func (l *FundingLedger) observe(leg, exchange string, info *shared.FundingRateInfo, since, now time.Time) {
+	if last, ok := l.upcoming[leg]; ok && last.NextSettleTime > l.settled[leg] && last.NextSettleTime >= since.UnixMilli() &&
+		(now.UnixMilli() >= last.NextSettleTime || (info != nil && info.NextSettleTime > last.NextSettleTime)) {
+		rate := last.Rate
+		if leg == LegShort {
+			rate = last.ShortRate()
+		}
+		pnl := rate * 100
+		if leg == LegLong {
+			pnl = -pnl
+		}
+		l.Settlements = append(l.Settlements, FundingSettlement{Leg: leg, Exchange: exchange, SettleTime: last.NextSettleTime, Rate: rate, PnLPct: pnl})
+		l.settled[leg] = last.NextSettleTime
+		l.PnLPct += pnl
+		if l.PnLUSD != nil {
+			*l.PnLUSD += pnl / 100 * l.notional
+		}
+	}
+	if info != nil && info.NextSettleTime > 0 {
+		l.upcoming[leg] = *info
+	}
+}
+
+// clone returns a copy sharing nothing mutable with l.
+func (l FundingLedger) clone() FundingLedger {
+	l.Settlements = slices.Clone(l.Settlements)
+	if l.PnLUSD != nil {
+		usd := *l.PnLUSD
+		l.PnLUSD = &usd
+	}
+	return l
+}
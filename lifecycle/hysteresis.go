@@ -0,0 +1,84 @@
+package lifecycle
+
+import "cex-price-diff-notifications/arbitrage"
+
+// gateState is the hysteresis state of a single (symbol, exchange pair, direction).
+type gateState struct {
+	cyclesAbove int  // Consecutive cycles above the entry threshold while inactive
+	active      bool // Confirmed and not yet dropped below the exit threshold
+}
+
+// Gate applies hysteresis to the entry threshold so borderline spreads don't flap: a spread must stay above
+// its symbol's minimum entry spread for confirmCycles consecutive cycles before it passes, and then keeps
+// passing until it falls below the lower exit threshold. It is not safe for concurrent use.
+type Gate struct {
+	states        map[string]*gateState // Pair key -> state
+	confirmCycles int
+	exitSpreadPct float64
+}
+
+// NewGate creates a Gate. exitSpreadPct is capped at each symbol's entry threshold.
+func NewGate(confirmCycles int, exitSpreadPct float64) *Gate {
+	return &Gate{
+		states:        make(map[string]*gateState),
+		confirmCycles: confirmCycles,
+		exitSpreadPct: exitSpreadPct,
+	}
+}
+
+// Apply observes the spreads and returns the active ones, see Observe and Select.
+func (g *Gate) Apply(spreads []arbitrage.Spread, params arbitrage.ParamSet) ([]arbitrage.Spread, int) {
+	g.Observe(spreads, params)
+	return g.Select(spreads)
+}
+
+// Observe updates the state of each pair from its spread this cycle. spreads should be every candidate, before
+// any filter that drops a pair still above its exit threshold: an active pair stays active until its spread is
+// seen below it, while one pending confirmation and missing from a cycle starts over.
+func (g *Gate) Observe(spreads []arbitrage.Spread, params arbitrage.ParamSet) {
+	seen := make(map[string]bool, len(spreads))
+	for _, s := range spreads {
+		key := s.PairKey()
+		seen[key] = true
+
+		st, ok := g.states[key]
+		if !ok {
+			st = &gateState{}
+			g.states[key] = st
+		}
+
+		entry := params.For(s.UnifiedSymbol).MinEntrySpreadPct
+		exit := min(g.exitSpreadPct, entry)
+		switch {
+		case st.active && s.EntrySpread < exit:
+			st.active = false
+			st.cyclesAbove = 0
+		case !st.active && s.EntrySpread >= entry:
+			st.cyclesAbove++
+			st.active = st.cyclesAbove >= g.confirmCycles
+		case !st.active:
+			st.cyclesAbove = 0
+		}
+	}
+
+	for key, st := range g.states {
+		if !seen[key] && !st.active {
+			delete(g.states, key)
+		}
+	}
+}
+
+// Select returns the spreads of the active pairs, preserving order, and the number held back (pending
+// confirmation or below the thresholds).
+func (g *Gate) Select(spreads []arbitrage.Spread) ([]arbitrage.Spread, int) {
+	selected := spreads[:0:0]
+	held := 0
+	for _, s := range spreads {
+		if st, ok := g.states[s.PairKey()]; !ok || !st.active {
+			held++
+			continue
+		}
+		selected = append(selected, s)
+	}
+	return selected, held
+}
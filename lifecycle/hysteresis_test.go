@@ -0,0 +1,103 @@
+package lifecycle
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"math"
+	"testing"
+)
+
+// missing marks a cycle in which the pair has no spread.
+var missing = math.NaN()
+
+// testSpread returns a BTC spread between Binance and Mexc with the given entry spread.
+func testSpread(entrySpread float64) arbitrage.Spread {
+	return arbitrage.Spread{
+		UnifiedSymbol: "BTC/USDT:PERP",
+		ExchangeShort: "Binance",
+		ExchangeLong:  "Mexc",
+		EntrySpread:   entrySpread,
+	}
+}
+
+func TestGate(t *testing.T) {
+	tests := []struct {
+		name          string
+		confirmCycles int
+		exitSpreadPct float64
+		spreads       []float64 // Entry spread of each cycle, missing for none; the entry threshold is 0.5
+		want          []bool    // Whether the spread of each cycle passes
+	}{
+		{
+			name:          "opens above the threshold",
+			confirmCycles: 1,
+			exitSpreadPct: 0.3,
+			spreads:       []float64{0.4, 0.5, 0.6},
+			want:          []bool{false, true, true},
+		},
+		{
+			name:          "opens after the confirmation cycles",
+			confirmCycles: 3,
+			exitSpreadPct: 0.3,
+			spreads:       []float64{0.6, 0.6, 0.6, 0.6},
+			want:          []bool{false, false, true, true},
+		},
+		{
+			name:          "confirmation starts over below the threshold or missing",
+			confirmCycles: 2,
+			exitSpreadPct: 0.3,
+			spreads:       []float64{0.6, 0.4, 0.6, missing, 0.6, 0.6},
+			want:          []bool{false, false, false, false, false, true},
+		},
+		{
+			name:          "stays open inside the band",
+			confirmCycles: 1,
+			exitSpreadPct: 0.3,
+			spreads:       []float64{0.6, 0.45, 0.3, 0.49},
+			want:          []bool{true, true, true, true},
+		},
+		{
+			name:          "stays open while missing",
+			confirmCycles: 1,
+			exitSpreadPct: 0.3,
+			spreads:       []float64{0.6, missing, 0.4},
+			want:          []bool{true, false, true},
+		},
+		{
+			name:          "closes below the exit threshold",
+			confirmCycles: 1,
+			exitSpreadPct: 0.3,
+			spreads:       []float64{0.6, 0.4, 0.29, 0.4},
+			want:          []bool{true, true, false, false},
+		},
+		{
+			name:          "reopens only after confirming again",
+			confirmCycles: 2,
+			exitSpreadPct: 0.3,
+			spreads:       []float64{0.6, 0.6, 0.2, 0.6, 0.6},
+			want:          []bool{false, true, false, false, true},
+		},
+		{
+			name:          "exit threshold is capped at the entry threshold",
+			confirmCycles: 1,
+			exitSpreadPct: 0.8,
+			spreads:       []float64{0.6, 0.5, 0.49},
+			want:          []bool{true, true, false},
+		},
+	}
+	params := arbitrage.ParamSet{Defaults: arbitrage.Params{MinEntrySpreadPct: 0.5}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate := NewGate(tt.confirmCycles, tt.exitSpreadPct)
+			for cycle, entrySpread := range tt.spreads {
+				var spreads []arbitrage.Spread
+				if !math.IsNaN(entrySpread) {
+					spreads = append(spreads, testSpread(entrySpread))
+				}
+				selected, _ := gate.Apply(spreads, params)
+				if got := len(selected) == 1; got != tt.want[cycle] {
+					t.Errorf("cycle %d, entry spread %g: got passed %t, want %t", cycle, entrySpread, got, tt.want[cycle])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package lifecycle
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"time"
+)
+
+// Lifecycle event types.
+const (
+	EventOpened  = "opportunity_opened"
+	EventUpdated = "opportunity_updated"
+	EventClosed  = "opportunity_closed"
+
+	EventExitSignal = "opportunity_exit_signal" // An entered opportunity should be exited, see ExitSignals
+)
+
+// Event describes a change in an opportunity's lifecycle.
+type Event struct {
+	EventType       string           `json:"event_type"`
+	OpportunityID   string           `json:"opportunity_id"`
+	OpenedAt        int64            `json:"opened_at"`        // Milliseconds since epoch
+	DurationSeconds float64          `json:"duration_seconds"` // Time since the opportunity opened
+	MaxEntrySpread  float64          `json:"max_entry_spread"` // Highest entry spread observed while open
+	Spread          arbitrage.Spread `json:"spread"`           // Latest observation; for closed events, the last one seen
+
+	// Set for exit signals only
+	ExitReason     string   `json:"exit_reason,omitempty"`     // One of the ExitReason* constants
+	CapturedSpread *float64 `json:"captured_spread,omitempty"` // Entry spread when entered plus the exit spread now, minus fees, in percent
+	FundingPnLPct  *float64 `json:"funding_pnl_pct,omitempty"` // Funding received minus paid since the entry, in percent of the leg notional
+}
+
+// opportunity is the tracked state of a single (symbol, exchange pair, direction).
+type opportunity struct {
+	id                 string
+	openedAt           time.Time
+	maxEntrySpread     float64
+	lastSpread         arbitrage.Spread
+	lastPublishedEntry float64
+}
+
+// Tracker turns per-cycle spreads into opened/updated/closed events for each opportunity.
+// It is not safe for concurrent use.
+type Tracker struct {
+	open              map[string]*opportunity // Pair key -> opportunity
+	published         map[string]bool         // IDs of the open opportunities whose events are published, see Publish
+	materialChangeBps float64
+}
+
+// NewTracker creates a tracker that emits an updated event when an open opportunity's entry spread
+// moves by at least materialChangeBps since the last published event.
+func NewTracker(materialChangeBps float64) *Tracker {
+	return &Tracker{
+		open:              make(map[string]*opportunity),
+		published:         make(map[string]bool),
+		materialChangeBps: materialChangeBps,
+	}
+}
+
+// Update compares the current cycle's spreads with the tracked opportunities and returns the resulting events.
+// Opportunities missing from spreads are considered converged and closed. An opening spread's OpportunityID
+// becomes the opportunity's ID; spreads without one get a random ID.
+func (t *Tracker) Update(spreads []arbitrage.Spread, now time.Time) []Event {
+	var events []Event
+	seen := make(map[string]bool, len(spreads))
+
+	for _, s := range spreads {
+		key := s.PairKey()
+		seen[key] = true
+
+		o, ok := t.open[key]
+		if !ok {
+			o = &opportunity{
+				id:                 cmp.Or(s.OpportunityID, newID()),
+				openedAt:           now,
+				maxEntrySpread:     s.EntrySpread,
+				lastSpread:         s,
+				lastPublishedEntry: s.EntrySpread,
+			}
+			t.open[key] = o
+			events = append(events, o.event(EventOpened, now))
+			continue
+		}
+
+		o.lastSpread = s
+		o.maxEntrySpread = math.Max(o.maxEntrySpread, s.EntrySpread)
+		// Spreads are in percent, so 1 bps = 0.01.
+		if math.Abs(s.EntrySpread-o.lastPublishedEntry)*100 >= t.materialChangeBps {
+			o.lastPublishedEntry = s.EntrySpread
+			events = append(events, o.event(EventUpdated, now))
+		}
+	}
+
+	for key, o := range t.open {
+		if seen[key] {
+			continue
+		}
+		events = append(events, o.event(EventClosed, now))
+		delete(t.open, key)
+	}
+
+	return events
+}
+
+// Publish returns the events of an Update to publish when only the published spreads, a subset of the updated
+// ones, are: an opened event for each opportunity published for the first time, whether it just opened or
+// climbed into the publish limit, then the updated events of the published spreads and the closed events of the
+// opportunities published before. Tracking every spread above the entry threshold and limiting the events keeps
+// an opportunity ranked out of the limit for a cycle open.
+func (t *Tracker) Publish(events []Event, published []arbitrage.Spread, now time.Time) []Event {
+	var selected []Event
+	keys := make(map[string]bool, len(published))
+	announced := make(map[string]bool)
+	for _, s := range published {
+		key := s.PairKey()
+		keys[key] = true
+		o, ok := t.open[key]
+		if !ok || t.published[o.id] {
+			continue
+		}
+		t.published[o.id] = true
+		announced[o.id] = true
+		o.lastPublishedEntry = o.lastSpread.EntrySpread
+		selected = append(selected, o.event(EventOpened, now))
+	}
+
+	for _, e := range events {
+		switch {
+		case e.EventType == EventClosed:
+			if t.published[e.OpportunityID] {
+				delete(t.published, e.OpportunityID)
+				selected = append(selected, e)
+			}
+		case e.EventType == EventUpdated:
+			if keys[e.Spread.PairKey()] && !announced[e.OpportunityID] {
+				selected = append(selected, e)
+			}
+		}
+	}
+	return selected
+}
+
+// event builds a lifecycle event of the given type from the opportunity's current state.
+func (o *opportunity) event(eventType string, now time.Time) Event {
+	return Event{
+		EventType:       eventType,
+		OpportunityID:   o.id,
+		OpenedAt:        o.openedAt.UnixMilli(),
+		DurationSeconds: now.Sub(o.openedAt).Seconds(),
+		MaxEntrySpread:  o.maxEntrySpread,
+		Spread:          o.lastSpread,
+	}
+}
+
+// newID returns a random 16-byte hex identifier.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
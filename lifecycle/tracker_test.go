@@ -0,0 +1,137 @@
+package lifecycle
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	type cycle struct {
+		entrySpread   float64 // Missing for no spread
+		opportunityID string  // Of the spread, empty for a random one
+		want          string  // Event type, empty for none
+		opportunity   int     // Events of the same opportunity have the same ID, of another one a different ID
+	}
+	tests := []struct {
+		name   string
+		cycles []cycle
+	}{
+		{
+			name: "opens with the first spread",
+			cycles: []cycle{
+				{entrySpread: 0.6, want: EventOpened, opportunity: 1},
+			},
+		},
+		{
+			name: "updates on a material change only",
+			cycles: []cycle{
+				{entrySpread: 0.6, want: EventOpened, opportunity: 1},
+				{entrySpread: 0.65},
+				{entrySpread: 0.72, want: EventUpdated, opportunity: 1},
+				{entrySpread: 0.65},
+				{entrySpread: 0.6, want: EventUpdated, opportunity: 1},
+			},
+		},
+		{
+			name: "closes when the spread is missing",
+			cycles: []cycle{
+				{entrySpread: 0.6, want: EventOpened, opportunity: 1},
+				{entrySpread: missing, want: EventClosed, opportunity: 1},
+				{entrySpread: missing},
+			},
+		},
+		{
+			name: "reopens with a new random ID",
+			cycles: []cycle{
+				{entrySpread: 0.6, want: EventOpened, opportunity: 1},
+				{entrySpread: missing, want: EventClosed, opportunity: 1},
+				{entrySpread: 0.6, want: EventOpened, opportunity: 2},
+			},
+		},
+		{
+			name: "keeps the opening spread's ID and reopens with the new one",
+			cycles: []cycle{
+				{entrySpread: 0.6, opportunityID: "a", want: EventOpened, opportunity: 1},
+				{entrySpread: 0.75, opportunityID: "b", want: EventUpdated, opportunity: 1},
+				{entrySpread: missing, want: EventClosed, opportunity: 1},
+				{entrySpread: 0.6, opportunityID: "c", want: EventOpened, opportunity: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(10)
+			start := time.Now()
+			ids := make(map[int]string) // Opportunity -> ID
+			for i, c := range tt.cycles {
+				var spreads []arbitrage.Spread
+				if !math.IsNaN(c.entrySpread) {
+					s := testSpread(c.entrySpread)
+					s.OpportunityID = c.opportunityID
+					spreads = append(spreads, s)
+				}
+				events := tracker.Update(spreads, start.Add(time.Duration(i)*time.Minute))
+				if c.want == "" {
+					if len(events) > 0 {
+						t.Errorf("cycle %d: got %s event, want none", i, events[0].EventType)
+					}
+					continue
+				}
+				if len(events) != 1 || events[0].EventType != c.want {
+					t.Fatalf("cycle %d: got events %v, want one %s", i, events, c.want)
+				}
+				e := events[0]
+				if c.opportunityID != "" && c.want == EventOpened && e.OpportunityID != c.opportunityID {
+					t.Errorf("cycle %d: got ID %q, want the spread's %q", i, e.OpportunityID, c.opportunityID)
+				}
+				if id, ok := ids[c.opportunity]; ok && e.OpportunityID != id {
+					t.Errorf("cycle %d: got ID %q, want %q of opportunity %d", i, e.OpportunityID, id, c.opportunity)
+				}
+				for other, id := range ids {
+					if other != c.opportunity && e.OpportunityID == id {
+						t.Errorf("cycle %d: got ID %q of opportunity %d, want a new one", i, id, other)
+					}
+				}
+				ids[c.opportunity] = e.OpportunityID
+			}
+		})
+	}
+}
+
+func TestTrackerPublish(t *testing.T) {
+	btc, eth := testSpread(0.8), testSpread(0.6)
+	eth.UnifiedSymbol = "ETH/USDT:PERP"
+	tracker := NewTracker(10)
+	now := time.Now()
+	typesOf := func(events []Event) map[string]string { // Symbol -> event type
+		types := make(map[string]string, len(events))
+		for _, e := range events {
+			types[e.Spread.UnifiedSymbol] = e.EventType
+		}
+		return types
+	}
+
+	// Both open, but only BTC is published
+	events := tracker.Update([]arbitrage.Spread{btc, eth}, now)
+	published := typesOf(tracker.Publish(events, []arbitrage.Spread{btc}, now))
+	if len(published) != 1 || published[btc.UnifiedSymbol] != EventOpened {
+		t.Fatalf("got published events %v, want BTC opened", published)
+	}
+
+	// ETH climbs into the limit and is announced as opened; BTC ranked out stays open, without a closed event
+	btc.EntrySpread, eth.EntrySpread = 0.55, 0.9
+	events = tracker.Update([]arbitrage.Spread{btc, eth}, now)
+	published = typesOf(tracker.Publish(events, []arbitrage.Spread{eth}, now))
+	if len(published) != 1 || published[eth.UnifiedSymbol] != EventOpened {
+		t.Fatalf("got published events %v, want ETH opened", published)
+	}
+
+	// Both close, as both were published
+	events = tracker.Update(nil, now)
+	published = typesOf(tracker.Publish(events, nil, now))
+	if len(published) != 2 || published[btc.UnifiedSymbol] != EventClosed || published[eth.UnifiedSymbol] != EventClosed {
+		t.Fatalf("got published events %v, want BTC and ETH closed", published)
+	}
+}
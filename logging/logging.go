@@ -0,0 +1,138 @@
+// Package logging builds the slog handler of the commands: colored text or JSON, with a global level and
+// per-module level overrides.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lmittmann/tint"
+)
+
+// modulePath is the module of the repository, stripped from package paths.
+const modulePath = "cex-price-diff-notifications"
+
+// Output formats.
+const (
+	FormatText = "text" // Colored, human-readable lines
+	FormatJSON = "json" // One JSON object per line, for log aggregation
+)
+
+// Options configures a handler.
+type Options struct {
+	Level        slog.Level            // Minimum level of modules without an override
+	Format       string                // FormatText or FormatJSON
+	ModuleLevels map[string]slog.Level // Minimum level per module, keyed by package path within the repository (e.g., "adapters" or "shared/retry"); "main" is the command itself
+}
+
+// ParseLevel parses a level name: debug, info, warn or error, optionally with an offset such as "warn+2".
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// NewHandler creates a handler writing to w. Records are filtered by the level of the module logging them,
+// the most specific module override winning: "shared" applies to "shared/retry" unless it has its own.
+func NewHandler(w io.Writer, opts Options) slog.Handler {
+	minLevel := opts.Level
+	for _, level := range opts.ModuleLevels {
+		minLevel = min(minLevel, level)
+	}
+
+	var inner slog.Handler
+	if opts.Format == FormatJSON {
+		inner = slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: minLevel})
+	} else {
+		inner = tint.NewHandler(w, &tint.Options{AddSource: true, Level: minLevel, TimeFormat: time.Kitchen})
+	}
+	return &moduleHandler{
+		inner:    inner,
+		minLevel: minLevel,
+		filter:   &moduleFilter{level: opts.Level, modules: opts.ModuleLevels},
+	}
+}
+
+// moduleHandler drops the records below the level of the module that logged them.
+type moduleHandler struct {
+	inner    slog.Handler
+	minLevel slog.Level
+	filter   *moduleFilter
+}
+
+func (h *moduleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.minLevel && h.inner.Enabled(ctx, level)
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.filter.levelAt(r.PC) {
+		return nil
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &moduleHandler{inner: h.inner.WithAttrs(attrs), minLevel: h.minLevel, filter: h.filter}
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	return &moduleHandler{inner: h.inner.WithGroup(name), minLevel: h.minLevel, filter: h.filter}
+}
+
+// moduleFilter resolves the level of the module logging at a program counter, caching it per call site.
+type moduleFilter struct {
+	level   slog.Level
+	modules map[string]slog.Level
+	cache   sync.Map // uintptr -> slog.Level
+}
+
+// levelAt returns the minimum level of the module of the function containing pc.
+func (f *moduleFilter) levelAt(pc uintptr) slog.Level {
+	if len(f.modules) == 0 || pc == 0 {
+		return f.level
+	}
+	if level, ok := f.cache.Load(pc); ok {
+		return level.(slog.Level)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	level := f.level
+	for module := packageOf(frame.Function); module != ""; {
+		if l, ok := f.modules[module]; ok {
+			level = l
+			break
+		}
+		i := strings.LastIndex(module, "/")
+		if i < 0 {
+			break
+		}
+		module = module[:i]
+	}
+	f.cache.Store(pc, level)
+	return level
+}
+
+// packageOf returns the package path within the repository of a function name as reported by the runtime,
+// e.g., "cex-price-diff-notifications/adapters.(*MexcAdapter).GetTickers" is in "adapters" and
+// "main.run" in "main".
+func packageOf(function string) string {
+	pkg := function
+	slash := strings.LastIndex(pkg, "/")
+	if dot := strings.Index(pkg[slash+1:], "."); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	if rest, found := strings.CutPrefix(pkg, modulePath+"/"); found {
+		return rest
+	}
+	if pkg == modulePath {
+		return "main" // The command as named in test binaries
+	}
+	return pkg
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+)
+
+// repeated counts the occurrences of a warning message since the last flush.
+type repeated struct {
+	count int
+	pc    uintptr // Call site of the first occurrence, for the summary's source and module level
+}
+
+// aggregator counts the warnings logged with Warn, per message.
+type aggregator struct {
+	mu       sync.Mutex
+	counts   map[string]*repeated
+	detail   int                         // Occurrences of a message logged in full per flush
+	observed func(message string, n int) // Called with each message's count at flush; nil when unset
+}
+
+// warnings is the process-wide aggregator.
+var warnings = &aggregator{counts: make(map[string]*repeated), detail: 3}
+
+// SetWarningAggregation sets how many occurrences of each warning are logged in full between flushes (0 logs
+// only the summaries), and a function called at each flush with the count of each message, e.g., to export it
+// as a metric.
+func SetWarningAggregation(detail int, observe func(message string, n int)) {
+	warnings.mu.Lock()
+	defer warnings.mu.Unlock()
+	warnings.detail = max(detail, 0)
+	warnings.observed = observe
+}
+
+// Warn logs a warning that bad data can repeat many times in a cycle, e.g., per symbol: the first occurrences
+// of msg since the last flush are logged in full, with args, and the others only counted, to be summarized by
+// FlushWarnings.
+func Warn(msg string, args ...any) {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // Skip runtime.Callers and Warn, for the caller's source and module level
+
+	warnings.mu.Lock()
+	r, ok := warnings.counts[msg]
+	if !ok {
+		r = &repeated{pc: pcs[0]}
+		warnings.counts[msg] = r
+	}
+	r.count++
+	full := r.count <= warnings.detail
+	warnings.mu.Unlock()
+
+	if full {
+		logAt(pcs[0], slog.LevelWarn, msg, args...)
+	}
+}
+
+// FlushWarnings logs one summary per warning message that occurred more often than was logged in full since
+// the last flush, reports every message's count to the observer, and starts counting again. It should be called
+// at the end of each cycle.
+func FlushWarnings() {
+	warnings.mu.Lock()
+	counts := warnings.counts
+	detail, observed := warnings.detail, warnings.observed
+	warnings.counts = make(map[string]*repeated)
+	warnings.mu.Unlock()
+
+	for _, msg := range slices.Sorted(maps.Keys(counts)) {
+		r := counts[msg]
+		if observed != nil {
+			observed(msg, r.count)
+		}
+		if r.count > detail {
+			logAt(r.pc, slog.LevelWarn, "Repeated warning suppressed", "warning", msg, "count", r.count, "suppressed", r.count-detail)
+		}
+	}
+}
+
+// logAt logs a record as if from the call site at pc, so its source and module level are the caller's.
+func logAt(pc uintptr, level slog.Level, msg string, args ...any) {
+	logger := slog.Default()
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), level, msg, pc)
+	r.Add(args...)
+	_ = logger.Handler().Handle(context.Background(), r)
+}
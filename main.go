@@ -2,250 +2,744 @@ package main
 
 import (
 	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/api"
 	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/execution"
+	"cex-price-diff-notifications/funding"
+	"cex-price-diff-notifications/leader"
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/logging"
+	"cex-price-diff-notifications/marketdata"
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/metadata"
+	"cex-price-diff-notifications/metrics"
+	"cex-price-diff-notifications/outage"
+	"cex-price-diff-notifications/risk"
+	"cex-price-diff-notifications/shard"
 	"cex-price-diff-notifications/shared"
+	"cex-price-diff-notifications/simulator"
+	"cex-price-diff-notifications/storage"
+	"cex-price-diff-notifications/tracing"
+	"cex-price-diff-notifications/wallet"
 	"context"
-	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/lmittmann/tint"
-	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 const (
-	rabbitMQQueueName = "arbitrage_event"
+	rabbitMQQueueName           = "arbitrage_event"
+	rabbitMQFundingQueueName    = "funding_arbitrage_event"
+	rabbitMQTriangularQueueName = "triangular_arbitrage_event"
+	rabbitMQSpotQueueName       = "spot_arbitrage_event"
+	rabbitMQStatsQueueName      = "arbitrage_stats"
 )
 
+// usage is printed by "help" and for unknown commands.
+const usage = `Usage: app [command] [flags]
+
+Commands:
+  run              scan continuously and publish opportunities (default; --dry-run only logs them)
+  top              fetch once and print the best spreads
+  symbols          list the unified symbols of each exchange
+  check <symbol>   show tickers, funding rates and spreads of one symbol
+  report           print the summary report of the last day or week, from the spreads stored in Postgres
+  backtest         replay recorded market data or exported spreads through the spread calculation and publish rules
+  tune             sweep entry thresholds, fee assumptions and holding horizons over recorded data and print the best config
+  execute <symbol> place real orders on both legs of a spread (needs EXECUTION_ENABLED; -dry-run only plans)
+  tui              show a live table of the best spreads (also "app --tui")
+
+Run "app <command> -h" for the flags of a command.
+`
+
 func main() {
 	// Load .env file. It's not an error if it doesn't exist.
 	_ = godotenv.Load()
 
-	// set up a new colorful handler
-	handler := tint.NewHandler(os.Stdout, &tint.Options{
-		AddSource:  true,
-		Level:      slog.LevelDebug,
-		TimeFormat: time.Kitchen,
-	})
+	command, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	} else if len(args) > 0 && (args[0] == "--tui" || args[0] == "-tui") {
+		command, args = "tui", args[1:]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		// Every invalid setting is listed, one per line
+		fmt.Fprintln(os.Stderr, "Invalid configuration:")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  "+line)
+		}
+		os.Exit(1)
+	}
+
+	// The scanner logs at the configured levels; one-shot commands print results on stdout and only log problems
+	logOptions := logging.Options{Level: cfg.LogLevel, Format: cfg.LogFormat, ModuleLevels: cfg.LogModuleLevels}
+	logOutput := os.Stdout
+	if command != "run" {
+		logOptions.Level, logOutput = max(cfg.LogLevel, slog.LevelWarn), os.Stderr
+	}
+	slog.SetDefault(slog.New(logging.NewHandler(logOutput, logOptions)))
+	logging.SetWarningAggregation(cfg.LogWarningDetail, metrics.ObserveWarnings)
+	shared.SetQuoteCurrencies(cfg.QuoteCurrencies)
+	transport, err := testnetTransport(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if transport != nil {
+		adapters.SetTransport(transport)
+		execution.SetTransport(transport)
+	}
+
+	switch command {
+	case "run":
+		err = run(cfg, args)
+	case "top":
+		err = runTop(cfg, args)
+	case "symbols":
+		err = runSymbols(cfg, args)
+	case "check":
+		err = runCheck(cfg, args)
+	case "report":
+		err = runReport(cfg, args)
+	case "backtest":
+		err = runBacktest(cfg, args)
+	case "tune":
+		err = runTune(cfg, args)
+	case "execute":
+		err = runExecute(cfg, args)
+	case "tui":
+		err = runTUI(cfg, args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+	// The warnings of one-shot commands are summarized once they are done
+	logging.FlushWarnings()
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// run scans the exchanges every cycle and publishes the opportunities until the process is stopped.
+func run(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "log opportunities instead of publishing them, without connecting to RabbitMQ or any other sink")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
-	// create a new logger
-	logger := slog.New(handler)
-	slog.SetDefault(logger)
+	slog.Info("Application starting, initializing adapters...", "quote_currencies", cfg.QuoteCurrencies)
+	if *dryRun {
+		// The full pipeline runs, but no sink or broker is connected, so consumers see nothing
+		slog.Warn("Dry run: opportunities are logged, not published")
+		cfg.PublishSinks = nil
+	}
+
+	// SIGINT and SIGTERM cancel the root context: background jobs and exchange requests stop, the main
+	// loop finishes its in-flight cycle, and the deferred closes drain the sinks and close connections
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Each cycle is optionally traced, so a slow cycle can be pinned on an exchange or a sink
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Endpoint:    cfg.TracingEndpoint,
+		ServiceName: "cex-arbitrage-scanner",
+		InstanceID:  cfg.InstanceID,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		// Runs last, after the sinks have drained, so their publish spans are exported too
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+		cancel()
+	}()
 
-	slog.Info("Application starting, initializing adapters...")
+	transport, injector, err := setupTransport(cfg)
+	if err != nil {
+		return err
+	}
+	if transport != nil {
+		adapters.SetTransport(transport)
+	}
 
 	// Create adapter instances
+	adapters.SetPayloadObserver(metrics.ObservePayload)
 	binanceAdapter := adapters.NewBinanceAdapter()
-	mexcAdapter, err := adapters.NewMexcAdapter()
+	binanceAdapter.SetCredentials(cfg.BinanceAPIKey, cfg.BinanceAPISecret)
+	switch {
+	case cfg.SimulatorBinanceURL != "":
+		binanceAdapter.SetStreamURL(simulator.StreamURL(cfg.SimulatorBinanceURL))
+	case cfg.BinanceTestnet:
+		binanceAdapter.SetStreamURL(adapters.BinanceTestnetStreamURL)
+	}
+	mexcAdapter := adapters.NewMexcAdapter()
+	mexcAdapter.SetCredentials(cfg.MexcAPIKey, cfg.MexcAPISecret)
+	if cfg.SimulatorMexcURL != "" {
+		mexcAdapter.SetStreamURL(simulator.StreamURL(cfg.SimulatorMexcURL))
+	}
+	// API keys rotated in the secret store replace the adapters' keys without a restart
+	if cfg.Secrets != nil && cfg.SecretsRefresh > 0 {
+		go cfg.Secrets.Run(ctx, cfg.SecretsRefresh, func() {
+			binanceAdapter.SetCredentials(cfg.Secrets.Get("BINANCE_API_KEY", cfg.BinanceAPIKey), cfg.Secrets.Get("BINANCE_API_SECRET", cfg.BinanceAPISecret))
+			mexcAdapter.SetCredentials(cfg.Secrets.Get("MEXC_API_KEY", cfg.MexcAPIKey), cfg.Secrets.Get("MEXC_API_SECRET", cfg.MexcAPISecret))
+		})
+	}
+	// GMX is read-only: its prices and funding join the spreads, nil when disabled
+	var gmxAdapter *adapters.GmxAdapter
+	if cfg.GmxEnabled {
+		gmxAdapter = adapters.NewGmxAdapter()
+	}
+
+	// Probes report dependency connectivity and adapter freshness on the HTTP API
+	health := api.NewHealth(cfg.HealthMaxAge)
+	health.TrackAdapter("Binance")
+	health.TrackAdapter("Mexc")
+	if gmxAdapter != nil {
+		health.TrackAdapter("GMX")
+	}
+	// Their funding ages are exported for alerting, along with the age of every kind of fetch
+	fundingUpdatedAt := map[string]func() time.Time{
+		"Binance": binanceAdapter.FundingUpdatedAt,
+		"Mexc":    mexcAdapter.FundingUpdatedAt,
+	}
+	if gmxAdapter != nil {
+		fundingUpdatedAt["GMX"] = gmxAdapter.FundingUpdatedAt
+	}
+	for exchange, updatedAt := range fundingUpdatedAt {
+		metrics.TrackFundingAge(exchange, updatedAt)
+	}
+
+	stores, closeStores, err := setupStorage(cfg)
 	if err != nil {
-		slog.Error("Failed to initialize Mexc adapter", "error", err)
-		os.Exit(1) // Exit if a critical component fails to start
+		return err
+	}
+	defer closeStores()
+	if stores.fundingRedis != nil {
+		health.AddCheck("redis", func(ctx context.Context) error { return stores.fundingRedis.Ping(ctx).Err() })
+		binanceAdapter.SetFundingCache(storage.NewFundingCache[adapters.BinanceFundingRateDto](stores.fundingRedis, cfg.RedisKey(adapters.BinanceFundingCachePrefix), cfg.BinanceFundingCacheTTL))
+		mexcAdapter.SetFundingCache(storage.NewFundingCache[adapters.MexcCachedFundingRate](stores.fundingRedis, cfg.RedisKey(adapters.MexcFundingCachePrefix), cfg.MexcFundingCacheTTL))
 	}
-	defer mexcAdapter.Close() // Ensure Redis client is closed on exit
 
-	// Load initial funding rates from Redis
-	mexcAdapter.LoadFundingRatesFromRedis()
+	// With sharding, each instance scans and publishes its share of the symbols; a shard is deployed like a
+	// whole scanner, with its own leader and latest snapshot
+	symbolShard := shard.New(cfg.ShardIndex, cfg.ShardCount)
+	if symbolShard != nil {
+		slog.Info("Scanning a shard of the symbols", "shard", symbolShard.Index(), "shards", symbolShard.Count())
+		cfg.LeaderKey = fmt.Sprintf("%s:shard-%d", cfg.LeaderKey, symbolShard.Index())
+		cfg.RedisLatestKey = fmt.Sprintf("%s:shard-%d", cfg.RedisLatestKey, symbolShard.Index())
+		mexcAdapter.SetSymbolFilter(symbolShard.Owns)
+	}
 
-	// Set up RabbitMQ
-	rabbitUser := os.Getenv("RABBITMQ_DEFAULT_USER")
-	rabbitPass := os.Getenv("RABBITMQ_DEFAULT_PASS")
-	rabbitHost := os.Getenv("RABBITMQ_HOST")
-	if rabbitHost == "" {
-		rabbitHost = "rabbitmq" // Default to localhost if not set
+	// With leader election, redundant instances all scan but only the lease holder publishes
+	var elector *leader.Elector
+	if cfg.LeaderElection {
+		elector = leader.NewElector(stores.fundingRedis, cfg.RedisKey(cfg.LeaderKey), cfg.InstanceID, cfg.LeaderLeaseTTL)
+	}
+	// Claims keep instances publishing the same opportunities, e.g., overlapping shards or a new leader while
+	// the old one still publishes, from both publishing their events
+	var claims *messaging.Claims
+	if cfg.PublishDedupEnabled {
+		claims = messaging.NewClaims(stores.fundingRedis, cfg.RedisKey(cfg.PublishDedupKeyPrefix), cfg.InstanceID, cfg.PublishDedupTTL)
 	}
-	rabbitMQURL := fmt.Sprintf("amqp://%s:%s@%s:5672/", rabbitUser, rabbitPass, rabbitHost)
-	slog.Info("Connecting to RabbitMQ", "url", rabbitMQURL)
 
-	conn, err := amqp.Dial(rabbitMQURL)
+	// Load initial funding rates from Redis
+	binanceAdapter.LoadFundingRates()
+	mexcAdapter.LoadFundingRates()
+
+	sinks, closeSinks, err := setupSinks(cfg, *dryRun, health, injector)
 	if err != nil {
-		slog.Error("Failed to connect to RabbitMQ", "error", err)
-		os.Exit(1)
+		return err
 	}
-	defer conn.Close()
+	defer closeSinks()
 
-	ch, err := conn.Channel()
+	// The publish threshold, limit and blocked symbols can be changed at runtime through the admin API
+	runtimeSettings, err := api.NewRuntimeSettings(api.Settings{
+		MinEntrySpreadPct:   cfg.MinEntrySpreadPct,
+		MaxPublishedSpreads: cfg.MaxPublishedSpreads,
+		BlockedSymbols:      cfg.BlockedSymbols,
+	})
 	if err != nil {
-		slog.Error("Failed to open a RabbitMQ channel", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("invalid publish settings: %w", err)
 	}
-	defer ch.Close()
 
-	q, err := ch.QueueDeclare(
-		rabbitMQQueueName, // name
-		false,             // durable
-		false,             // delete when unused
-		false,             // exclusive
-		false,             // no-wait
-		nil,               // arguments
-	)
-	if err != nil {
-		slog.Error("Failed to declare a RabbitMQ queue", "error", err)
-		os.Exit(1)
+	// Background jobs start once every connection is set up, so a failed setup returns with none to stop
+	s := &scanner{
+		cfg:              cfg,
+		stores:           stores,
+		sinks:            sinks,
+		binanceAdapter:   binanceAdapter,
+		mexcAdapter:      mexcAdapter,
+		gmxAdapter:       gmxAdapter,
+		health:           health,
+		fundingUpdatedAt: fundingUpdatedAt,
+		injector:         injector,
+		symbolShard:      symbolShard,
+		elector:          elector,
+		claims:           claims,
+		runtimeSettings:  runtimeSettings,
 	}
-	slog.Info("RabbitMQ queue declared", "queue_name", q.Name)
+	s.startBackgroundJobs(ctx)
+	s.initCycles()
 
-	// Set up a channel to listen for OS signals (like Ctrl+C)
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	slog.Info("Adapters initialized, starting main loop.", "spread_mode", cfg.SpreadMode)
 
-	// Goroutine to handle graceful shutdown
-	go func() {
-		<-sigChan
-		slog.Info("Shutdown signal received, closing connections...")
-		mexcAdapter.Close() // Close Redis client
-		ch.Close()
-		conn.Close()
-		os.Exit(0)
-	}()
+	pollInterval := cfg.PollInterval
+	metrics.PollInterval.Set(pollInterval.Seconds())
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	// A cycle that overruns the poll interval skips the ticks it missed instead of starting late on them
+	var lastCycleStart, lastCycleEnd time.Time
+cycles:
+	for {
+		var tick time.Time
+		select {
+		case <-ctx.Done():
+			break cycles
+		case tick = <-ticker.C:
+		}
+		// The ticker keeps one tick while a cycle runs; starting on it would run the next cycle back to back
+		if tick.Before(lastCycleEnd) {
+			overrun := lastCycleEnd.Sub(lastCycleStart)
+			skipped := max(1, int(overrun/pollInterval))
+			metrics.CyclesSkipped.Add(float64(skipped))
+			slog.Warn("Cycle overran the poll interval, skipping", "duration", overrun, "skipped", skipped)
+			continue
+		}
 
-	// Goroutine to update Mexc funding rates periodically
-	go func() {
-		// Run once at the start
-		if _, err := mexcAdapter.UpdateFundingRates(); err != nil {
-			slog.Error("Failed to perform initial Mexc funding rate update", "error", err)
-		}
-		// Then run every 10 minutes
-		ticker := time.NewTicker(10 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			if _, err := mexcAdapter.UpdateFundingRates(); err != nil {
-				slog.Error("Failed to update Mexc funding rates", "error", err)
+		cycleStart := time.Now()
+		if !s.runCycle(ctx, cycleStart) {
+			break
+		}
+		lastCycleStart, lastCycleEnd = cycleStart, time.Now()
+		if s.poller != nil {
+			if next, reason := s.poller.Next(); reason != "" {
+				slog.Info("Poll interval adapted", "interval", next, "previous", pollInterval, "reason", reason, "volatility_bps", s.poller.Volatility())
+				pollInterval = next
+				ticker.Reset(pollInterval)
+				metrics.PollInterval.Set(pollInterval.Seconds())
 			}
 		}
-	}()
+		slog.Info("Ticker fetching cycle complete.")
+	}
 
-	slog.Info("Adapters initialized, starting main loop.")
+	slog.Info("Shutdown signal received, stopping...")
+	// Background jobs stop before the deferred closes drain the sink queues and flush the buffered writes
+	s.shutdown()
+	slog.Info("Background jobs stopped, closing connections...")
+	return nil
+}
 
-	// Create a ticker that fires every 5 seconds
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+// paramSet returns the per-symbol parameters, falling back to the global defaults.
+func paramSet(cfg *config.Config) arbitrage.ParamSet {
+	var overrides map[string]arbitrage.SymbolOverride
+	if len(cfg.SymbolOverrides) > 0 {
+		overrides = make(map[string]arbitrage.SymbolOverride, len(cfg.SymbolOverrides))
+		for symbol, o := range cfg.SymbolOverrides {
+			overrides[symbol] = arbitrage.SymbolOverride(o)
+		}
+	}
+	return arbitrage.ParamSet{
+		Defaults: arbitrage.Params{
+			MinEntrySpreadPct: cfg.MinEntrySpreadPct,
+			TargetNotionalUSD: cfg.DefaultNotionalUSD,
+			TakerFeesBps:      cfg.TakerFeesBps,
+		},
+		Overrides: overrides,
+	}
+}
 
-	for range ticker.C {
-		slog.Info("Fetching data...")
+// fundingSnapshot returns copies of the adapters' funding rates, keyed by exchange and unified symbol. GMX is
+// left out when nil (disabled).
+func fundingSnapshot(binance *adapters.BinanceAdapter, mexc *adapters.MexcAdapter, gmx *adapters.GmxAdapter) map[string]map[string]shared.FundingRateInfo {
+	rates := map[string]map[string]shared.FundingRateInfo{
+		"Binance": binance.GetFundingRatesSnapshot(),
+		"Mexc":    mexc.GetFundingRatesSnapshot(),
+	}
+	if gmx != nil {
+		rates["GMX"] = gmx.GetFundingRatesSnapshot()
+	}
+	return rates
+}
 
-		allTickers := make(map[string]map[string]shared.TickerBidAsk)
-		var mu sync.Mutex
-		var wg sync.WaitGroup
+// publishNetworkChanges logs network status changes and, when leading, publishes them to every sink.
+func publishNetworkChanges(changes []wallet.Change, encoder messaging.Encoder, publisher *messaging.Fanout, leading bool) {
+	for _, c := range changes {
+		metrics.NetworkStatusChanges.WithLabelValues(c.Exchange).Inc()
+		slog.Info("Network status changed", "exchange", c.Exchange, "asset", c.Asset, "network", c.Network,
+			"deposit", c.DepositEnabled, "withdraw", c.WithdrawEnabled)
+		if !leading {
+			continue
+		}
+		body, err := encoder.Encode(messaging.EventNetworkStatusChanged, c)
+		if err != nil {
+			slog.Error("Failed to marshal network status change to JSON", "error", err)
+			continue
+		}
+		err = publisher.Publish(context.Background(), messaging.Event{
+			Type:       messaging.EventNetworkStatusChanged,
+			Key:        c.Asset,
+			RoutingKey: messaging.NetworkRoutingKey(c.Exchange, c.Asset, c.Network),
+			Body:       body,
+		})
+		if err != nil {
+			slog.Error("Failed to publish a network status change", "error", err)
+		}
+	}
+}
 
-		// Fetch Binance tickers
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			binanceTickersDto, duration, err := binanceAdapter.GetTickers()
-			if err != nil {
-				slog.Error("Failed to get Binance tickers", "error", err)
-				return
-			}
-			slog.Info("Binance tickers fetched", "count", len(binanceTickersDto), "duration", duration)
-
-			for _, dto := range binanceTickersDto {
-				genericTicker, err := dto.ToTickerBidAsk()
-				if err != nil {
-					if !errors.Is(err, shared.ErrUnsupportedQuoteCurrency) {
-						slog.Warn("Failed to convert Binance DTO", "symbol", dto.Symbol, "error", err)
-					}
-					continue
-				}
-				mu.Lock()
-				if _, ok := allTickers[genericTicker.UnifiedSymbol]; !ok {
-					allTickers[genericTicker.UnifiedSymbol] = make(map[string]shared.TickerBidAsk)
-				}
-				allTickers[genericTicker.UnifiedSymbol]["Binance"] = genericTicker
-				mu.Unlock()
-			}
-		}()
+// publishListings logs the symbols an exchange listed or delisted, and publishes the listed ones. Standby
+// instances, or with listing events off, only log them.
+func publishListings(listings []metadata.Listing, encoder messaging.Encoder, publisher *messaging.Fanout, publish bool) {
+	for _, l := range listings {
+		if !l.Listed {
+			metrics.ListingChanges.WithLabelValues(l.Exchange, "delisted").Inc()
+			slog.Info("Symbol delisted", "exchange", l.Exchange, "symbol", l.UnifiedSymbol)
+			continue
+		}
+		metrics.ListingChanges.WithLabelValues(l.Exchange, "listed").Inc()
+		slog.Info("New listing", "exchange", l.Exchange, "symbol", l.UnifiedSymbol, "other_exchanges", l.OtherExchanges)
+		if !publish {
+			continue
+		}
+		body, err := encoder.Encode(messaging.EventNewListing, l)
+		if err != nil {
+			slog.Error("Failed to marshal new listing to JSON", "error", err)
+			continue
+		}
+		err = publisher.Publish(context.Background(), messaging.Event{
+			Type:       messaging.EventNewListing,
+			Key:        l.UnifiedSymbol,
+			RoutingKey: messaging.ListingRoutingKey(l.Exchange, l.UnifiedSymbol),
+			Body:       body,
+		})
+		if err != nil {
+			slog.Error("Failed to publish a new listing", "error", err)
+		}
+	}
+}
+
+// publishExchangeChanges logs and publishes the exchanges degraded or included again this cycle. Standby
+// instances only log them.
+func publishExchangeChanges(changes []outage.Change, encoder messaging.Encoder, publisher *messaging.Fanout, leading bool) {
+	for _, c := range changes {
+		eventType := messaging.EventExchangeRecovered
+		if c.Degraded {
+			eventType = messaging.EventExchangeDegraded
+			metrics.ExchangeDegraded.WithLabelValues(c.Exchange).Set(1)
+			slog.Warn("Exchange degraded", "exchange", c.Exchange, "reason", c.Reason, "error_rate", c.ErrorRate, "last_fetch", c.LastFetch)
+		} else {
+			metrics.ExchangeDegraded.WithLabelValues(c.Exchange).Set(0)
+			slog.Info("Exchange recovered, including it in spread calculation again", "exchange", c.Exchange, "degraded_for", c.RecoveredAt.Sub(c.Since))
+		}
+		if !leading {
+			continue
+		}
+		body, err := encoder.Encode(eventType, c)
+		if err != nil {
+			slog.Error("Failed to marshal exchange health change to JSON", "error", err)
+			continue
+		}
+		err = publisher.Publish(context.Background(), messaging.Event{
+			Type:       eventType,
+			Key:        c.Exchange,
+			RoutingKey: messaging.ExchangeRoutingKey(c.Exchange, c.Degraded),
+			Body:       body,
+		})
+		if err != nil {
+			slog.Error("Failed to publish an exchange health change", "error", err)
+		}
+	}
+}
+
+// legBookFetches caps the order books fetched at once for the legs of published spreads.
+const legBookFetches = 8
+
+// fetchLegBooks fetches the order books of both legs of spreads, keyed by unified symbol and exchange like
+// tickers. Books that fail to fetch are left out, so their spreads' legs are priced from tickers.
+func fetchLegBooks(ctx context.Context, orderBooks *marketdata.OrderBooks, spreads []arbitrage.Spread) map[string]map[string]shared.OrderBook {
+	type leg struct{ symbol, exchange string }
+	legs := make(map[leg]bool, 2*len(spreads))
+	for _, s := range spreads {
+		if s.OrderQty != nil && *s.OrderQty > 0 {
+			legs[leg{s.UnifiedSymbol, s.ExchangeShort}] = true
+			legs[leg{s.LongSymbol(), s.ExchangeLong}] = true
+		}
+	}
 
-		// Fetch Mexc tickers
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			mexcTickersDto, duration, err := mexcAdapter.GetTickers()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, legBookFetches)
+	books := make(map[string]map[string]shared.OrderBook, len(legs))
+	for l := range legs {
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			book, err := orderBooks.OrderBook(ctx, l.exchange, l.symbol)
+			if errors.Is(err, marketdata.ErrUnknownExchange) {
+				return // No order books, e.g., on GMX
+			}
 			if err != nil {
-				slog.Error("Failed to get Mexc tickers", "error", err)
+				slog.Warn("Failed to fetch an order book for order sizes", "exchange", l.exchange, "symbol", l.symbol, "error", err)
 				return
 			}
-			slog.Info("Mexc tickers fetched", "count", len(mexcTickersDto), "duration", duration)
-
-			for _, dto := range mexcTickersDto {
-				genericTicker, err := dto.ToTickerBidAsk()
-				if err != nil {
-					if !errors.Is(err, shared.ErrUnsupportedQuoteCurrency) {
-						slog.Warn("Failed to convert Mexc DTO", "symbol", dto.Symbol, "error", err)
-					}
-					continue
-				}
-				mu.Lock()
-				if _, ok := allTickers[genericTicker.UnifiedSymbol]; !ok {
-					allTickers[genericTicker.UnifiedSymbol] = make(map[string]shared.TickerBidAsk)
-				}
-				allTickers[genericTicker.UnifiedSymbol]["Mexc"] = genericTicker
-				mu.Unlock()
+			mu.Lock()
+			defer mu.Unlock()
+			if books[l.symbol] == nil {
+				books[l.symbol] = make(map[string]shared.OrderBook)
 			}
-		}()
+			books[l.symbol][l.exchange] = book
+		})
+	}
+	wg.Wait()
+	return books
+}
 
-		// Update Binance funding rates
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			duration, err := binanceAdapter.UpdateFundingRates()
-			if err != nil {
-				slog.Error("Failed to update Binance funding rates", "error", err)
-				return
-			}
-			slog.Info("Binance funding rates updated", "duration", duration)
-		}()
+// publishFundingSchedule publishes the funding settlement calendar of the legs of spreads over horizon.
+// The whole calendar, every listed symbol included, is served by the API instead.
+func publishFundingSchedule(ctx context.Context, rates map[string]map[string]shared.FundingRateInfo, spreads []arbitrage.Spread, horizon time.Duration, encoder messaging.Encoder, publisher *messaging.Fanout) {
+	legs := make(map[string]bool, 2*len(spreads))
+	for _, s := range spreads {
+		legs[s.ExchangeShort+"|"+s.UnifiedSymbol] = true
+		legs[s.ExchangeLong+"|"+s.LongSymbol()] = true
+	}
+	calendar := funding.NewCalendar(rates, time.Now(), horizon, func(exchange, unifiedSymbol string) bool {
+		return legs[exchange+"|"+unifiedSymbol]
+	})
+	body, err := encoder.Encode(messaging.EventFundingSchedule, calendar)
+	if err != nil {
+		slog.Error("Failed to marshal the funding schedule to JSON", "error", err)
+		return
+	}
+	err = publisher.Publish(ctx, messaging.Event{
+		Type:       messaging.EventFundingSchedule,
+		Key:        "funding_schedule",
+		RoutingKey: "schedule.funding",
+		Body:       body,
+	})
+	if err != nil {
+		slog.Error("Failed to publish the funding schedule", "error", err)
+		return
+	}
+	slog.Info("Published the funding schedule", "slots", len(calendar.Slots), "settlements", calendar.Count, "horizon", horizon)
+}
 
-		wg.Wait()
+// outageOptions returns the outage detection settings, which never degrade an exchange when it is disabled.
+func outageOptions(cfg *config.Config) outage.Options {
+	if !cfg.OutageDetectionEnabled {
+		return outage.Options{}
+	}
+	return outage.Options{
+		MaxDataAge:      cfg.OutageMaxDataAge,
+		ErrorRate:       cfg.OutageErrorRate,
+		ErrorWindow:     cfg.OutageErrorWindow,
+		RecoveryFetches: cfg.OutageRecoveryFetches,
+	}
+}
 
-		// Calculate and log arbitrage opportunities
-		slog.Info("Calculating arbitrage opportunities...")
-		spreads := arbitrage.CalculateSpreads(allTickers, binanceAdapter.FundingRates, mexcAdapter.FundingRates)
+// simulatorURLs returns the URLs of the simulated exchanges, keyed by exchange.
+func simulatorURLs(cfg *config.Config) map[string]string {
+	urls := make(map[string]string)
+	if cfg.SimulatorBinanceURL != "" {
+		urls["Binance"] = cfg.SimulatorBinanceURL
+	}
+	if cfg.SimulatorMexcURL != "" {
+		urls["Mexc"] = cfg.SimulatorMexcURL
+	}
+	return urls
+}
 
-		if len(spreads) == 0 {
-			slog.Info("No arbitrage opportunities found in this cycle.")
-		} else {
-			slog.Info("Top arbitrage opportunities found:")
-			for i, s := range spreads {
-				if i < 5 { // Log top 5
-					slog.Info("Opportunity",
-						"symbol", s.UnifiedSymbol,
-						"buy_at", s.ExchangeLong,
-						"sell_at", s.ExchangeShort,
-						"entry_spread_%", s.EntrySpread,
-						"exit_spread_%", s.ExitSpread,
-					)
-				}
-
-				// Publish to RabbitMQ
-				body, err := json.Marshal(s)
-				if err != nil {
-					slog.Error("Failed to marshal spread to JSON", "error", err)
-					continue
-				}
-
-				err = ch.PublishWithContext(context.Background(),
-					"",     // exchange
-					q.Name, // routing key
-					false,  // mandatory
-					false,  // immediate
-					amqp.Publishing{
-						ContentType: "application/json",
-						Body:        body,
-					})
-				if err != nil {
-					slog.Error("Failed to publish a message to RabbitMQ", "error", err)
-				}
-			}
-			slog.Info("Published arbitrage opportunities to RabbitMQ", "count", len(spreads))
+// testnetTransport returns the transport sending the requests of the exchanges with a testnet enabled to it, or
+// nil if none is.
+func testnetTransport(cfg *config.Config) (http.RoundTripper, error) {
+	if !cfg.BinanceTestnet {
+		return nil, nil
+	}
+	transport, err := adapters.TestnetTransport([]string{"Binance"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the testnets: %w", err)
+	}
+	slog.Warn("Binance testnet: market data and orders go to the Binance testnets")
+	return transport, nil
+}
+
+// printMessage writes a message of the in-memory queue on stdout, prefixed with its queue or exchange and
+// routing key.
+func printMessage(m messaging.MemoryMessage) {
+	name := m.Queue
+	if m.RoutingKey != "" {
+		name += " " + m.RoutingKey
+	}
+	fmt.Printf("%s %s\n", name, m.Body)
+}
+
+// riskLimits returns the risk limits of new positions.
+func riskLimits(cfg *config.Config) risk.Limits {
+	return risk.Limits{
+		MaxSymbolNotional:   cfg.RiskMaxSymbolNotional,
+		MaxOpenPositions:    cfg.RiskMaxOpenPositions,
+		MaxExchangeExposure: cfg.RiskMaxExchangeExposure,
+		KillSwitch:          cfg.RiskKillSwitch,
+		KillSwitchFile:      cfg.RiskKillSwitchFile,
+	}
+}
+
+// spreadOptions returns the options of the futures spread calculation.
+func spreadOptions(cfg *config.Config) arbitrage.Options {
+	return arbitrage.Options{
+		MaxTickerAge:    cfg.MaxTickerAge,
+		SlippageBps:     cfg.SlippageBps,
+		AllDirections:   cfg.SpreadAllDirections,
+		MinVolumeUSD:    cfg.MinVolumeUSD,
+		MinTopOfBookUSD: cfg.MinTopOfBookUSD,
+		Workers:         cfg.SpreadWorkers,
+	}
+}
+
+// eventType returns the envelope event type of a published arbitrage message.
+func eventType(m any) string {
+	switch m := m.(type) {
+	case lifecycle.Event:
+		return m.EventType
+	case arbitrage.Snapshot:
+		return messaging.EventSpreadSnapshot
+	case arbitrage.SnapshotDelta:
+		return messaging.EventSpreadDelta
+	case arbitrage.FundingDivergence:
+		return messaging.EventFundingDivergence
+	default:
+		return messaging.EventSpread
+	}
+}
+
+// opportunityID returns the opportunity ID of a published arbitrage message, empty for snapshots and deltas.
+func opportunityID(m any) string {
+	switch m := m.(type) {
+	case arbitrage.Spread:
+		return m.OpportunityID
+	case lifecycle.Event:
+		return m.OpportunityID
+	case arbitrage.FundingDivergence:
+		return m.OpportunityID
+	default:
+		return ""
+	}
+}
+
+// symbolKey returns the unified symbol of a published arbitrage message, used as the Kafka key, NATS subject, MQTT topic and Redis stream tag.
+func symbolKey(m any) string {
+	switch m := m.(type) {
+	case arbitrage.Spread:
+		return m.UnifiedSymbol
+	case lifecycle.Event:
+		return m.Spread.UnifiedSymbol
+	case arbitrage.Snapshot:
+		return "snapshot" // Snapshots cover every symbol
+	case arbitrage.SnapshotDelta:
+		return "delta" // As do deltas
+	case arbitrage.FundingDivergence:
+		return m.UnifiedSymbol
+	default:
+		return ""
+	}
+}
+
+// routingKey returns the topic routing key of a published arbitrage message.
+func routingKey(m any) string {
+	switch m := m.(type) {
+	case arbitrage.Spread:
+		return messaging.SpreadRoutingKey(m.UnifiedSymbol, m.ExchangeShort, m.ExchangeLong)
+	case lifecycle.Event:
+		return messaging.SpreadRoutingKey(m.Spread.UnifiedSymbol, m.Spread.ExchangeShort, m.Spread.ExchangeLong)
+	case arbitrage.Snapshot:
+		return "spread.snapshot"
+	case arbitrage.SnapshotDelta:
+		return "spread.delta"
+	case arbitrage.FundingDivergence:
+		return messaging.FundingRoutingKey(m.UnifiedSymbol, m.ExchangeShort, m.ExchangeLong)
+	default:
+		return "spread.unknown"
+	}
+}
+
+// entrySpread returns the entry spread of a published arbitrage message, used for its delivery priority and filters.
+// Snapshots use their best opportunity, and deltas their best added or changed one.
+func entrySpread(m any) float64 {
+	switch m := m.(type) {
+	case arbitrage.Spread:
+		return m.EntrySpread
+	case lifecycle.Event:
+		return m.Spread.EntrySpread
+	case arbitrage.Snapshot:
+		if len(m.Opportunities) == 0 {
+			return 0
 		}
+		return m.Opportunities[0].EntrySpread
+	case arbitrage.SnapshotDelta:
+		return m.BestEntrySpread()
+	default:
+		return 0
+	}
+}
 
-		slog.Info("Ticker fetching cycle complete.")
+// applyDataAgeSLO stamps a message of an event type with the age at now of its market data, recorded in
+// metrics, and reports whether it may be published: not if the data is older than maxAge (0 disables).
+func applyDataAgeSLO(m any, event string, now time.Time, maxAge time.Duration) (any, time.Duration, bool) {
+	m, age, actionable := stampDataAge(m, now)
+	if !actionable {
+		return m, 0, true
+	}
+	metrics.PublishDataAge.WithLabelValues(event).Observe(age.Seconds())
+	if maxAge > 0 && age > maxAge {
+		metrics.PublishStale.WithLabelValues(event).Inc()
+		return m, age, false
+	}
+	return m, age, true
+}
+
+// stampDataAge sets the age at now of the market data behind a message acted on, a spread or a lifecycle
+// event, and returns it. Closed opportunities and snapshots report false.
+func stampDataAge(m any, now time.Time) (any, time.Duration, bool) {
+	switch m := m.(type) {
+	case arbitrage.Spread:
+		if m.QuoteTime.IsZero() {
+			return m, 0, false
+		}
+		age := now.Sub(m.QuoteTime)
+		m.DataAgeMs = age.Milliseconds()
+		return m, age, true
+	case lifecycle.Event:
+		if m.EventType == lifecycle.EventClosed || m.Spread.QuoteTime.IsZero() {
+			return m, 0, false
+		}
+		age := now.Sub(m.Spread.QuoteTime)
+		m.Spread.DataAgeMs = age.Milliseconds()
+		return m, age, true
+	default:
+		return m, 0, false
 	}
 }
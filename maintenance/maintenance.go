@@ -0,0 +1,147 @@
+// Package maintenance tracks exchange maintenance windows, scheduled in the config or reported live by the
+// exchanges' system status endpoints, so the scanner can pause an exchange's fetches while it is down and
+// warn about spreads whose legs are about to become unmanageable, instead of logging a wall of fetch errors.
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Window is a period during which an exchange is unavailable.
+type Window struct {
+	Exchange string    `json:"exchange"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end,omitzero"`     // Zero while a live maintenance lasts, as its end isn't known
+	Reason   string    `json:"reason,omitempty"` // e.g., "system upgrade"
+}
+
+// Active reports whether the window covers t.
+func (w Window) Active(t time.Time) bool {
+	return !t.Before(w.Start) && (w.End.IsZero() || t.Before(w.End))
+}
+
+// StatusFetcher reports whether an exchange is under maintenance right now, with the reason it gives.
+type StatusFetcher func(ctx context.Context) (maintenance bool, reason string, err error)
+
+// Monitor combines scheduled windows with the live status of the exchanges. It is safe for concurrent use.
+type Monitor struct {
+	mu        sync.RWMutex
+	scheduled []Window
+	live      map[string]Window // Ongoing maintenance reported by the status fetchers, keyed by exchange
+	fetchers  map[string]StatusFetcher
+}
+
+// NewMonitor creates a monitor of the scheduled windows.
+func NewMonitor(scheduled []Window) *Monitor {
+	return &Monitor{
+		scheduled: slices.Clone(scheduled),
+		live:      make(map[string]Window),
+		fetchers:  make(map[string]StatusFetcher),
+	}
+}
+
+// RegisterFetcher sets the live status source of an exchange (e.g., "Binance").
+func (m *Monitor) RegisterFetcher(exchange string, f StatusFetcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetchers[exchange] = f
+}
+
+// Active returns the window an exchange is in at t, live maintenance first.
+func (m *Monitor) Active(exchange string, t time.Time) (Window, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if w, ok := m.live[exchange]; ok {
+		return w, true
+	}
+	for _, w := range m.scheduled {
+		if w.Exchange == exchange && w.Active(t) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// Upcoming returns the earliest scheduled window of an exchange starting after t and within lead of it.
+func (m *Monitor) Upcoming(exchange string, t time.Time, lead time.Duration) (Window, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var next Window
+	var found bool
+	for _, w := range m.scheduled {
+		if w.Exchange != exchange || !w.Start.After(t) || w.Start.Sub(t) > lead {
+			continue
+		}
+		if !found || w.Start.Before(next.Start) {
+			next, found = w, true
+		}
+	}
+	return next, found
+}
+
+// Windows returns the windows active at t or later, live maintenance included, by start time.
+func (m *Monitor) Windows(t time.Time) []Window {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var windows []Window
+	for _, w := range m.live {
+		windows = append(windows, w)
+	}
+	for _, w := range m.scheduled {
+		if w.End.IsZero() || w.End.After(t) {
+			windows = append(windows, w)
+		}
+	}
+	slices.SortFunc(windows, func(a, b Window) int { return a.Start.Compare(b.Start) })
+	return windows
+}
+
+// Refresh polls the live status of all registered exchanges. Exchanges that fail keep their previous status.
+func (m *Monitor) Refresh(ctx context.Context) {
+	m.mu.RLock()
+	fetchers := make(map[string]StatusFetcher, len(m.fetchers))
+	for exchange, f := range m.fetchers {
+		fetchers[exchange] = f
+	}
+	m.mu.RUnlock()
+
+	for exchange, fetch := range fetchers {
+		down, reason, err := fetch(ctx)
+		if err != nil {
+			slog.Error("Failed to refresh system status", "exchange", exchange, "error", err)
+			continue
+		}
+		m.mu.Lock()
+		_, wasDown := m.live[exchange]
+		switch {
+		case down && !wasDown:
+			m.live[exchange] = Window{Exchange: exchange, Start: time.Now(), Reason: reason}
+		case !down && wasDown:
+			delete(m.live, exchange)
+		}
+		m.mu.Unlock()
+		if down != wasDown {
+			slog.Warn("Exchange system status changed", "exchange", exchange, "maintenance", down, "reason", reason)
+		}
+	}
+}
+
+// Run refreshes the live status immediately and then every interval. It blocks until ctx is cancelled and
+// should be run in a goroutine.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	m.Refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Refresh(ctx)
+		}
+	}
+}
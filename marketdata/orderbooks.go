@@ -0,0 +1,82 @@
+package marketdata
+
+import (
+	"cex-price-diff-notifications/shared"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownExchange is returned for an exchange without an order book fetcher.
+var ErrUnknownExchange = errors.New("no order book for exchange")
+
+// OrderBookFetcher fetches the top depth levels of each side of the order book of a unified symbol.
+type OrderBookFetcher func(ctx context.Context, unifiedSymbol string, depth int) (shared.OrderBook, error)
+
+// cachedBook is an order book and when it was fetched.
+type cachedBook struct {
+	book      shared.OrderBook
+	fetchedAt time.Time
+}
+
+// OrderBooks fetches order books on demand, keeping each for maxAge so that bursts of queries about the same
+// symbol cost one request to the exchange. It is safe for concurrent use.
+type OrderBooks struct {
+	depth  int
+	maxAge time.Duration
+
+	mu       sync.Mutex
+	fetchers map[string]OrderBookFetcher
+	books    map[string]cachedBook // Keyed by exchange and unified symbol
+}
+
+// NewOrderBooks creates order books of depth levels per side, reused for maxAge (0 fetches every time).
+func NewOrderBooks(depth int, maxAge time.Duration) *OrderBooks {
+	return &OrderBooks{depth: depth, maxAge: maxAge, fetchers: make(map[string]OrderBookFetcher), books: make(map[string]cachedBook)}
+}
+
+// RegisterFetcher sets the order book source of an exchange (e.g., "Binance").
+func (o *OrderBooks) RegisterFetcher(exchange string, f OrderBookFetcher) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.fetchers[exchange] = f
+}
+
+// OrderBook returns the order book of a unified symbol on an exchange, matched case-insensitively, fetched
+// within maxAge.
+func (o *OrderBooks) OrderBook(ctx context.Context, exchange, unifiedSymbol string) (shared.OrderBook, error) {
+	now := time.Now()
+	o.mu.Lock()
+	var fetch OrderBookFetcher
+	for name, f := range o.fetchers {
+		if strings.EqualFold(name, exchange) {
+			exchange, fetch = name, f
+		}
+	}
+	key := exchange + "|" + unifiedSymbol
+	cached, fresh := o.books[key]
+	o.mu.Unlock()
+	if fetch == nil {
+		return shared.OrderBook{}, ErrUnknownExchange
+	}
+	if fresh && now.Sub(cached.fetchedAt) < o.maxAge {
+		return cached.book, nil
+	}
+
+	book, err := fetch(ctx, unifiedSymbol, o.depth)
+	if err != nil {
+		return shared.OrderBook{}, err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// Expired books are dropped as new ones come in, so the cache doesn't grow with every symbol ever asked for
+	for k, b := range o.books {
+		if now.Sub(b.fetchedAt) >= o.maxAge {
+			delete(o.books, k)
+		}
+	}
+	o.books[key] = cachedBook{book: book, fetchedAt: now}
+	return book, nil
+}
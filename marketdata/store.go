@@ -0,0 +1,84 @@
+// Package marketdata holds the latest tickers of every exchange, written by the fetchers as they arrive and
+// read by the spread calculation as consistent snapshots.
+package marketdata
+
+import (
+	"cex-price-diff-notifications/shared"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is the tickers of every exchange at one point. It is shared between readers and must not be
+// modified.
+type Snapshot struct {
+	Tickers    map[string]map[string]shared.TickerBidAsk // Keyed by unified symbol and exchange
+	ByExchange map[string][]shared.TickerBidAsk          // As fetched, keyed by exchange
+	UpdatedAt  map[string]time.Time                      // Time of each exchange's last fetch
+}
+
+// Store holds the latest tickers of every exchange. Each write publishes a new snapshot (copy-on-write), so
+// readers never wait on fetchers and never see an exchange half-updated. It is safe for concurrent use.
+type Store struct {
+	mu       sync.Mutex // Serializes writers
+	snapshot atomic.Pointer[Snapshot]
+}
+
+// NewStore creates a store without tickers.
+func NewStore() *Store {
+	s := &Store{}
+	s.snapshot.Store(&Snapshot{
+		Tickers:    make(map[string]map[string]shared.TickerBidAsk),
+		ByExchange: make(map[string][]shared.TickerBidAsk),
+		UpdatedAt:  make(map[string]time.Time),
+	})
+	return s
+}
+
+// Set replaces the tickers of an exchange, fetched at.
+func (s *Store) Set(exchange string, tickers []shared.TickerBidAsk, at time.Time) {
+	s.update(func(byExchange map[string][]shared.TickerBidAsk, updatedAt map[string]time.Time) {
+		byExchange[exchange] = tickers
+		updatedAt[exchange] = at
+	})
+}
+
+// Remove drops the tickers of an exchange, e.g., after a failed fetch, so stale prices aren't traded on.
+func (s *Store) Remove(exchange string) {
+	s.update(func(byExchange map[string][]shared.TickerBidAsk, updatedAt map[string]time.Time) {
+		delete(byExchange, exchange)
+		delete(updatedAt, exchange)
+	})
+}
+
+// Snapshot returns the latest snapshot.
+func (s *Store) Snapshot() *Snapshot {
+	return s.snapshot.Load()
+}
+
+// update publishes a snapshot with a change applied to copies of the latest one's tickers by exchange and
+// fetch times, merging the tickers by symbol again.
+func (s *Store) update(change func(map[string][]shared.TickerBidAsk, map[string]time.Time)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last := s.snapshot.Load()
+	next := &Snapshot{
+		ByExchange: maps.Clone(last.ByExchange),
+		UpdatedAt:  maps.Clone(last.UpdatedAt),
+	}
+	change(next.ByExchange, next.UpdatedAt)
+
+	next.Tickers = make(map[string]map[string]shared.TickerBidAsk, len(last.Tickers))
+	for exchange, tickers := range next.ByExchange {
+		for _, t := range tickers {
+			byExchange, ok := next.Tickers[t.UnifiedSymbol]
+			if !ok {
+				byExchange = make(map[string]shared.TickerBidAsk, len(next.ByExchange))
+				next.Tickers[t.UnifiedSymbol] = byExchange
+			}
+			byExchange[exchange] = t
+		}
+	}
+	s.snapshot.Store(next)
+}
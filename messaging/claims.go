@@ -0,0 +1,59 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// claimScript claims an opportunity for an instance, or extends its claim, unless another instance holds it.
+var claimScript = redis.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if owner == false or owner == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0`)
+
+// Claims deduplicates the events of opportunities across instances publishing to the same sinks, such as
+// shards scanning overlapping symbols or a new leader taking over from one still publishing. Before publishing
+// an opportunity's events, an instance claims its ID in Redis; the first to claim it publishes its events and
+// extends the claim with each of them, while the others suppress theirs until the claim lapses.
+type Claims struct {
+	client *redis.Client
+	prefix string
+	owner  string
+	ttl    time.Duration
+}
+
+// NewClaims creates claims stored under prefix followed by the opportunity ID, held by owner (which must be
+// unique among the instances) for ttl after its last event.
+func NewClaims(client *redis.Client, prefix, owner string, ttl time.Duration) *Claims {
+	return &Claims{client: client, prefix: prefix, owner: owner, ttl: ttl}
+}
+
+// Claim claims the opportunities with ids, in one round trip, and reports for each whether this instance may
+// publish its events. Messages without an ID (empty) are always this instance's. If Redis fails, every
+// opportunity is reported this instance's along with the error, so an outage duplicates events rather than
+// losing them.
+func (c *Claims) Claim(ctx context.Context, ids []string) ([]bool, error) {
+	claimed := make([]bool, len(ids))
+	cmds := make([]*redis.Cmd, len(ids))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			if id != "" {
+				cmds[i] = claimScript.Eval(ctx, pipe, []string{c.prefix + id}, c.owner, c.ttl.Milliseconds())
+			}
+		}
+		return nil
+	})
+	for i, cmd := range cmds {
+		claimed[i] = err != nil || cmd == nil || cmd.Val() == int64(1)
+	}
+	if err != nil {
+		return claimed, fmt.Errorf("failed to claim opportunities in Redis: %w", err)
+	}
+	return claimed, nil
+}
@@ -0,0 +1,78 @@
+package messaging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SchemaVersion is the version of the published payload schemas. Bump it on breaking changes.
+const SchemaVersion = 1
+
+// Event types carried in the envelope. Lifecycle events use their own event type (e.g., "opportunity_opened").
+const (
+	EventSpread                = "spread"
+	EventFundingOpportunity    = "funding_opportunity"
+	EventTriangularOpportunity = "triangular_opportunity"
+	EventSpotSpread            = "spot_spread"
+	EventSpreadSnapshot        = "spread_snapshot"
+	EventSpreadDelta           = "spread_delta"
+	EventNetworkStatusChanged  = "network_status_changed"
+	EventFundingDivergence     = "funding_divergence"
+	EventSummaryReport         = "summary_report"
+	EventCycleStats            = "cycle_stats"
+	EventExchangeDegraded      = "exchange_degraded"
+	EventExchangeRecovered     = "exchange_recovered"
+	EventFundingSchedule       = "funding_schedule"
+	EventNewListing            = "new_listing"
+)
+
+// Envelope wraps a published payload with metadata that lets consumers detect schema changes and deduplicate redeliveries.
+type Envelope struct {
+	SchemaVersion int    `json:"schema_version"`
+	EventType     string `json:"event_type"`
+	EventID       string `json:"event_id"`     // Unique per published event
+	ProducerID    string `json:"producer_id"`  // Instance that generated the event
+	GeneratedAt   int64  `json:"generated_at"` // Milliseconds since epoch
+	Payload       any    `json:"payload"`
+}
+
+// Encoder serializes payloads to JSON, optionally wrapped in an Envelope.
+type Encoder struct {
+	ProducerID string
+	Envelope   bool // Wrap payloads in an Envelope; when false, payloads are published bare (legacy format)
+}
+
+// Encode returns the JSON body for a payload of the given event type.
+func (e Encoder) Encode(eventType string, payload any) ([]byte, error) {
+	if !e.Envelope {
+		return json.Marshal(payload)
+	}
+	return json.Marshal(Envelope{
+		SchemaVersion: SchemaVersion,
+		EventType:     eventType,
+		EventID:       newEventID(),
+		ProducerID:    e.ProducerID,
+		GeneratedAt:   time.Now().UnixMilli(),
+		Payload:       payload,
+	})
+}
+
+// DefaultProducerID identifies this process as "<hostname>-<random suffix>".
+func DefaultProducerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%s", host, newEventID()[:8])
+}
+
+// newEventID returns a random 128-bit hex identifier.
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,40 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// File appends every event as one JSON line to a file.
+type File struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFile opens (or creates) path for appending.
+func NewFile(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file %s: %w", path, err)
+	}
+	return &File{file: f}, nil
+}
+
+// Publish appends the event body followed by a newline.
+func (f *File) Publish(_ context.Context, event Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	line := make([]byte, 0, len(event.Body)+1)
+	line = append(append(line, event.Body...), '\n')
+	if _, err := f.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write event to %s: %w", f.file.Name(), err)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.file.Close()
+}
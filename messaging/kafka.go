@@ -0,0 +1,50 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka publishes messages to a single Kafka topic.
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+// NewKafka creates a Kafka publisher writing to topic on the given brokers. Messages are partitioned by key,
+// so all events of a symbol stay ordered. The topic is created on first write if the brokers allow it.
+func NewKafka(brokers []string, topic string) *Kafka {
+	slog.Info("Initializing Kafka publisher", "brokers", brokers, "topic", topic)
+	return &Kafka{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+// Publish writes the event body keyed by the event's unified symbol.
+func (k *Kafka) Publish(ctx context.Context, event Event) error {
+	err := retryPublish(ctx, "kafka", func(ctx context.Context) error {
+		return k.writer.WriteMessages(ctx, kafka.Message{
+			Key:   []byte(event.Key),
+			Value: event.Body,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to Kafka topic %s: %w", k.writer.Topic, err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the writer.
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,26 @@
+package messaging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Log logs every event instead of publishing it, for dry runs.
+type Log struct{}
+
+// Publish logs the event; the body is only logged at debug level.
+func (Log) Publish(_ context.Context, event Event) error {
+	slog.Info("Dry run, event not published",
+		"type", event.Type,
+		"key", event.Key,
+		"routing_key", event.RoutingKey,
+		"entry_spread_%", event.EntrySpread,
+	)
+	slog.Debug("Dry run event body", "key", event.Key, "body", string(event.Body))
+	return nil
+}
+
+// Close does nothing.
+func (Log) Close() error {
+	return nil
+}
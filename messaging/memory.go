@@ -0,0 +1,73 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMemoryQueueFull is returned when a message is published to a full in-memory queue; the message is dropped.
+var ErrMemoryQueueFull = errors.New("in-memory queue is full")
+
+// MemoryMessage is a message published to a MemoryQueue.
+type MemoryMessage struct {
+	Queue      string // Queue the message was published to, or exchange for topic messages
+	RoutingKey string // Topic routing key, if published to an exchange
+	Body       []byte
+}
+
+// MemoryQueue stands in for RabbitMQ when it isn't configured, for local runs: the messages of every queue
+// and exchange are buffered in process until drained, e.g., to stdout.
+type MemoryQueue struct {
+	messages chan MemoryMessage
+}
+
+// NewMemoryQueue creates a queue buffering up to size messages before new ones are dropped.
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{messages: make(chan MemoryMessage, max(size, 1))}
+}
+
+// Publish buffers the body for queue, like RabbitMQ.Publish.
+func (q *MemoryQueue) Publish(_ context.Context, queue string, body []byte) error {
+	return q.push(MemoryMessage{Queue: queue, Body: body})
+}
+
+// Drain hands every buffered message to handle until ctx is cancelled. It should be run in a goroutine.
+func (q *MemoryQueue) Drain(ctx context.Context, handle func(MemoryMessage)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-q.messages:
+			handle(m)
+		}
+	}
+}
+
+func (q *MemoryQueue) push(m MemoryMessage) error {
+	select {
+	case q.messages <- m:
+		return nil
+	default:
+		return ErrMemoryQueueFull
+	}
+}
+
+// MemoryQueueSink publishes events to a MemoryQueue, like RabbitMQSink does to RabbitMQ.
+type MemoryQueueSink struct {
+	MemoryQueue *MemoryQueue
+	Queue       string // Used when Exchange is empty
+	Exchange    string
+}
+
+// Publish buffers the event body for the sink's exchange, with the event's routing key, or queue.
+func (s MemoryQueueSink) Publish(_ context.Context, event Event) error {
+	if s.Exchange != "" {
+		return s.MemoryQueue.push(MemoryMessage{Queue: s.Exchange, RoutingKey: event.RoutingKey, Body: event.Body})
+	}
+	return s.MemoryQueue.push(MemoryMessage{Queue: s.Queue, Body: event.Body})
+}
+
+// Close is a no-op; the queue is shared.
+func (s MemoryQueueSink) Close() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTTOptions configures the MQTT publisher.
+type MQTTOptions struct {
+	BrokerURL   string // e.g., "tcp://localhost:1883"
+	ClientID    string
+	Username    string
+	Password    string
+	TopicPrefix string // Topics are "<prefix>/<BASE>-<QUOTE>" (e.g., "arb/spread/BTC-USDT")
+	QoS         byte   // 0, 1 or 2
+	Retain      bool   // Keep the latest message of each topic on the broker for new subscribers
+}
+
+// MQTT publishes messages to an MQTT broker, one topic per symbol, for home automation consumers
+// such as Home Assistant or Node-RED. The client reconnects automatically.
+type MQTT struct {
+	client mqtt.Client
+	opts   MQTTOptions
+}
+
+// NewMQTT connects to the MQTT broker.
+func NewMQTT(opts MQTTOptions) (*MQTT, error) {
+	clientOpts := mqtt.NewClientOptions().
+		AddBroker(opts.BrokerURL).
+		SetClientID(opts.ClientID).
+		SetUsername(opts.Username).
+		SetPassword(opts.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("MQTT connection lost, reconnecting...", "error", err)
+		})
+
+	client := mqtt.NewClient(clientOpts)
+	token := client.Connect()
+	if !token.WaitTimeout(10 * time.Second) {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: timed out", opts.BrokerURL)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", opts.BrokerURL, err)
+	}
+
+	slog.Info("Connected to MQTT broker", "broker", opts.BrokerURL, "topic_prefix", opts.TopicPrefix)
+	return &MQTT{client: client, opts: opts}, nil
+}
+
+// Publish sends the event body to the topic of its unified symbol and waits for the broker to accept it.
+func (m *MQTT) Publish(ctx context.Context, event Event) error {
+	topic := m.Topic(event.Key)
+	err := retryPublish(ctx, "mqtt", func(ctx context.Context) error {
+		token := m.client.Publish(topic, m.opts.QoS, m.opts.Retain, event.Body)
+		select {
+		case <-token.Done():
+			return token.Error()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to MQTT topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Check reports whether the connection to the broker is up.
+func (m *MQTT) Check(context.Context) error {
+	if !m.client.IsConnectionOpen() {
+		return errors.New("mqtt is disconnected")
+	}
+	return nil
+}
+
+// Topic returns the topic of a unified symbol (e.g., "BTC/USDT:PERP" -> "arb/spread/BTC-USDT").
+func (m *MQTT) Topic(unifiedSymbol string) string {
+	pair, _, _ := strings.Cut(unifiedSymbol, ":")
+	return m.opts.TopicPrefix + "/" + strings.ReplaceAll(pair, "/", "-")
+}
+
+// Close disconnects from the broker, giving in-flight messages up to a second to complete.
+func (m *MQTT) Close() error {
+	m.client.Disconnect(1000)
+	return nil
+}
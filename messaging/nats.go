@@ -0,0 +1,88 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSOptions controls the NATS JetStream publisher.
+type NATSOptions struct {
+	URL           string
+	SubjectPrefix string // Subjects are "<prefix>.<BASE>-<QUOTE>" (e.g., "arb.spread.BTC-USDT")
+	Stream        string // JetStream stream capturing "<prefix>.>"
+	CreateStream  bool   // Create or update the stream on startup instead of expecting it to exist
+	RetryAttempts int    // Publish retries when no ack is received
+}
+
+// NATS publishes messages to JetStream and waits for the stream's ack, giving at-least-once delivery.
+type NATS struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+	opts NATSOptions
+}
+
+// NewNATS connects to NATS and, if requested, creates the stream for the configured subject prefix.
+func NewNATS(opts NATSOptions) (*NATS, error) {
+	slog.Info("Connecting to NATS", "url", opts.URL, "stream", opts.Stream)
+	conn, err := nats.Connect(opts.URL, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if opts.CreateStream {
+		_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:     opts.Stream,
+			Subjects: []string{opts.SubjectPrefix + ".>"},
+		})
+	} else {
+		_, err = js.Stream(ctx, opts.Stream)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set up JetStream stream %s: %w", opts.Stream, err)
+	}
+
+	return &NATS{conn: conn, js: js, opts: opts}, nil
+}
+
+// Publish sends the event body to the subject of its unified symbol and waits for the stream to ack it.
+func (n *NATS) Publish(ctx context.Context, event Event) error {
+	subject := n.Subject(event.Key)
+	if _, err := n.js.Publish(ctx, subject, event.Body, jetstream.WithRetryAttempts(n.opts.RetryAttempts)); err != nil {
+		return fmt.Errorf("failed to publish to NATS subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// Check reports whether the connection is up.
+func (n *NATS) Check(context.Context) error {
+	if !n.conn.IsConnected() {
+		return fmt.Errorf("nats is %s", n.conn.Status())
+	}
+	return nil
+}
+
+// Subject returns the subject of a unified symbol (e.g., "BTC/USDT:PERP" -> "arb.spread.BTC-USDT").
+func (n *NATS) Subject(unifiedSymbol string) string {
+	pair, _, _ := strings.Cut(unifiedSymbol, ":")
+	return n.opts.SubjectPrefix + "." + strings.ReplaceAll(pair, "/", "-")
+}
+
+// Close drains pending messages and closes the connection.
+func (n *NATS) Close() error {
+	return n.conn.Drain()
+}
@@ -0,0 +1,129 @@
+package messaging
+
+import (
+	"cex-price-diff-notifications/metrics"
+	"cex-price-diff-notifications/shared/retry"
+	"cex-price-diff-notifications/tracing"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// publishTimeout bounds a single sink publish, so a hanging sink only delays its own queue.
+const publishTimeout = 30 * time.Second
+
+// publishRetry retries publishes to sinks without delivery retries of their own (Kafka, Redis and MQTT).
+var publishRetry = retry.Policy{MaxAttempts: 3, InitialDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second, Jitter: 0.2}
+
+// retryPublish runs a sink's publish per publishRetry, logging each failure that is retried.
+func retryPublish(ctx context.Context, sink string, publish func(ctx context.Context) error) error {
+	return retry.DoNotify(ctx, publishRetry, publish, func(err error, attempt int, delay time.Duration) {
+		slog.Warn("Failed to publish event, retrying", "sink", sink, "attempt", attempt, "retry_in", delay, "error", err)
+	})
+}
+
+// Event is an encoded message ready to be published.
+type Event struct {
+	Type        string  // Envelope event type (e.g., "spread")
+	Key         string  // Unified symbol, used for partitioning and subjects
+	RoutingKey  string  // Topic routing key (e.g., "spread.BTC-USDT.binance.mexc")
+	Body        []byte  // Encoded JSON payload
+	Priority    uint8   // Delivery priority for sinks that support it (higher first)
+	EntrySpread float64 // Entry spread of the opportunity (the best one for snapshots), in percent, for filtering
+}
+
+// Publisher is an output sink for events.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+	Close() error
+}
+
+// fanoutSink is a sink with its own queue and worker.
+type fanoutSink struct {
+	name      string
+	publisher Publisher
+	queue     chan queuedEvent
+}
+
+// queuedEvent is an event waiting for a sink's worker, with the span it was published under.
+type queuedEvent struct {
+	event  Event
+	parent trace.SpanContext
+}
+
+// Fanout publishes every event to all of its sinks. Each sink has its own bounded queue and worker,
+// so a failing or slow sink doesn't block or fail the others; when a sink's queue is full, events for it are dropped.
+type Fanout struct {
+	sinks     []*fanoutSink
+	queueSize int
+	wg        sync.WaitGroup
+}
+
+// NewFanout creates a Fanout whose sinks buffer up to queueSize events each.
+func NewFanout(queueSize int) *Fanout {
+	return &Fanout{queueSize: queueSize}
+}
+
+// Add registers a sink under a name used in logs and starts its worker.
+func (f *Fanout) Add(name string, p Publisher) {
+	s := &fanoutSink{name: name, publisher: p, queue: make(chan queuedEvent, f.queueSize)}
+	f.sinks = append(f.sinks, s)
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for queued := range s.queue {
+			event := queued.event
+			ctx, cancel := context.WithTimeout(trace.ContextWithSpanContext(context.Background(), queued.parent), publishTimeout)
+			ctx, span := tracing.Start(ctx, "publish", attribute.String("sink", s.name), attribute.String("event_type", event.Type))
+			err := s.publisher.Publish(ctx, event)
+			if err != nil {
+				slog.Error("Failed to publish event", "sink", s.name, "event_type", event.Type, "error", err)
+				metrics.PublishTotal.WithLabelValues(s.name, metrics.PublishFailure).Inc()
+			} else {
+				metrics.PublishTotal.WithLabelValues(s.name, metrics.PublishSuccess).Inc()
+			}
+			tracing.End(span, err)
+			cancel()
+		}
+	}()
+	slog.Info("Publisher sink added", "sink", name)
+}
+
+// Publish queues the event on every sink. It only fails if the event was dropped by a sink with a full queue.
+// Each sink's publish is traced as a child of the span in ctx, if any.
+func (f *Fanout) Publish(ctx context.Context, event Event) error {
+	queued := queuedEvent{event: event, parent: trace.SpanContextFromContext(ctx)}
+	var errs []error
+	for _, s := range f.sinks {
+		select {
+		case s.queue <- queued:
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			errs = append(errs, errors.New("queue of sink "+s.name+" is full, event dropped"))
+			metrics.PublishTotal.WithLabelValues(s.name, metrics.PublishDropped).Inc()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close drains the queues, waits for the workers and closes every sink.
+func (f *Fanout) Close() error {
+	for _, s := range f.sinks {
+		close(s.queue)
+	}
+	f.wg.Wait()
+
+	var errs []error
+	for _, s := range f.sinks {
+		if err := s.publisher.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
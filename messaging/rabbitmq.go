@@ -0,0 +1,603 @@
+package messaging
+
+import (
+	"cex-price-diff-notifications/metrics"
+	"cex-price-diff-notifications/shared"
+	"cex-price-diff-notifications/shared/retry"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrBuffered is returned by Publish when the connection is down and the message was buffered for later delivery.
+var ErrBuffered = errors.New("RabbitMQ is disconnected, message buffered")
+
+// RabbitMQOptions controls the delivery guarantees and reconnection behavior of the RabbitMQ publisher.
+type RabbitMQOptions struct {
+	URL                 string
+	Durable             bool          // Declare queues as durable so they survive broker restarts
+	Persistent          bool          // Publish messages with persistent delivery mode
+	Confirms            bool          // Wait for publisher confirms and resend nacked messages
+	ConfirmTimeout      time.Duration // How long to wait for a single confirm
+	MaxResends          int           // How many times a nacked or unconfirmed message is resent
+	ReconnectMaxBackoff time.Duration // Upper bound of the exponential reconnect backoff
+	BufferSize          int           // Messages kept while disconnected; the oldest are dropped when full
+	MessageTTL          time.Duration // Per-message expiry; consumers never see older messages (0 means no expiry)
+	MaxPriority         uint8         // Declare queues as priority queues with this maximum priority (0 disables priorities)
+	DeadLetterExchange  string        // Exchange receiving rejected, expired and undeliverable messages (empty disables dead-lettering)
+	Namespace           string        // Prefixes queue names, routing keys, binding patterns and the dead-letter exchange with "<namespace>." (empty disables)
+}
+
+// topologyStep declares part of the broker topology. Steps are replayed after every reconnect.
+type topologyStep func(ch *amqp.Channel) error
+
+// pendingMessage is a message to publish. While disconnected, it is kept in the buffer.
+type pendingMessage struct {
+	exchange   string
+	routingKey string
+	body       []byte
+	priority   uint8
+	queuedAt   time.Time
+}
+
+// RabbitMQ publishes messages on a single connection and channel. When the connection drops it reconnects
+// in the background with exponential backoff, re-declares the topology and flushes the messages buffered meanwhile.
+type RabbitMQ struct {
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	opts     RabbitMQOptions
+	topology []topologyStep
+	buffer   []pendingMessage
+	dropped  int // Buffered messages dropped because the buffer was full, since the last reconnect
+	closing  bool
+}
+
+// RabbitMQURLFromEnv builds the broker URL from the RABBITMQ_DEFAULT_USER, RABBITMQ_DEFAULT_PASS and
+// RABBITMQ_HOST environment variables.
+func RabbitMQURLFromEnv() string {
+	rabbitUser := os.Getenv("RABBITMQ_DEFAULT_USER")
+	rabbitPass := os.Getenv("RABBITMQ_DEFAULT_PASS")
+	rabbitHost := os.Getenv("RABBITMQ_HOST")
+	if rabbitHost == "" {
+		rabbitHost = "rabbitmq" // Default to the docker-compose service name if not set
+	}
+	return fmt.Sprintf("amqp://%s:%s@%s:5672/", rabbitUser, rabbitPass, rabbitHost)
+}
+
+// NewRabbitMQ connects to RabbitMQ and opens a channel, in confirm mode if requested.
+// The initial connection must succeed; later disconnections are handled automatically.
+func NewRabbitMQ(opts RabbitMQOptions) (*RabbitMQ, error) {
+	r := &RabbitMQ{opts: opts}
+	// The dead-letter exchange is a fanout, so deployments sharing it would get each other's messages
+	r.opts.DeadLetterExchange = r.name(opts.DeadLetterExchange)
+	conn, ch, err := r.dial(nil)
+	if err != nil {
+		return nil, err
+	}
+	r.attach(conn, ch)
+	return r, nil
+}
+
+// dial connects to RabbitMQ, opens the channel and replays the given topology.
+func (r *RabbitMQ) dial(topology []topologyStep) (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(r.opts.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to open a RabbitMQ channel: %w", err)
+	}
+
+	if r.opts.Confirms {
+		if err := ch.Confirm(false); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("failed to put RabbitMQ channel into confirm mode: %w", err)
+		}
+	}
+
+	for _, step := range topology {
+		if err := step(ch); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	return conn, ch, nil
+}
+
+// attach makes conn and ch current and starts watching them for closure. The caller must hold r.mu or own r exclusively.
+func (r *RabbitMQ) attach(conn *amqp.Connection, ch *amqp.Channel) {
+	r.conn = conn
+	r.ch = ch
+	go r.watch(conn.NotifyClose(make(chan *amqp.Error, 1)), ch.NotifyClose(make(chan *amqp.Error, 1)))
+}
+
+// watch waits for the connection or channel to close and reconnects unless Close was called.
+func (r *RabbitMQ) watch(connClosed, chClosed chan *amqp.Error) {
+	var reason *amqp.Error
+	select {
+	case reason = <-connClosed:
+	case reason = <-chClosed:
+	}
+
+	r.mu.Lock()
+	if r.closing {
+		r.mu.Unlock()
+		return
+	}
+	slog.Warn("RabbitMQ connection lost, reconnecting...", "reason", reason)
+	r.conn.Close()
+	r.ch = nil
+	r.mu.Unlock()
+
+	backoff := retry.Policy{InitialDelay: time.Second, MaxDelay: r.opts.ReconnectMaxBackoff, Jitter: 0.2}
+	for attempt := 1; ; attempt++ {
+		r.mu.Lock()
+		topology := append([]topologyStep(nil), r.topology...)
+		r.mu.Unlock()
+
+		conn, ch, err := r.dial(topology)
+		if err == nil {
+			r.mu.Lock()
+			if r.closing {
+				r.mu.Unlock()
+				conn.Close()
+				return
+			}
+			r.attach(conn, ch)
+			r.flush()
+			r.mu.Unlock()
+			metrics.RabbitMQReconnects.Inc()
+			return
+		}
+
+		delay := backoff.Delay(attempt)
+		slog.Error("Failed to reconnect to RabbitMQ", "error", err, "retry_in", delay)
+		time.Sleep(delay)
+
+		r.mu.Lock()
+		closing := r.closing
+		r.mu.Unlock()
+		if closing {
+			return
+		}
+	}
+}
+
+// flush republishes the buffered messages after a reconnect. The caller must hold r.mu.
+func (r *RabbitMQ) flush() {
+	pending := r.buffer
+	r.buffer = nil
+	slog.Info("RabbitMQ reconnected, flushing buffered messages", "count", len(pending), "dropped", r.dropped)
+	r.dropped = 0
+
+	expired := 0
+	for i, m := range pending {
+		if r.opts.MessageTTL > 0 && time.Since(m.queuedAt) >= r.opts.MessageTTL {
+			expired++
+			continue
+		}
+		if err := r.publish(context.Background(), m); err != nil {
+			slog.Error("Failed to flush buffered RabbitMQ messages", "error", err)
+			r.buffer = append(r.buffer, pending[i:]...)
+			return
+		}
+	}
+	if expired > 0 {
+		slog.Info("Skipped expired buffered RabbitMQ messages", "count", expired)
+	}
+}
+
+// enqueue buffers a message while disconnected, dropping the oldest one when the buffer is full.
+// The caller must hold r.mu.
+func (r *RabbitMQ) enqueue(m pendingMessage) {
+	if r.opts.BufferSize <= 0 {
+		r.dropped++
+		return
+	}
+	if len(r.buffer) >= r.opts.BufferSize {
+		r.buffer = r.buffer[1:]
+		r.dropped++
+	}
+	r.buffer = append(r.buffer, m)
+}
+
+// declare runs a topology step now and records it for replay after reconnects.
+func (r *RabbitMQ) declare(step topologyStep) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ch == nil {
+		return fmt.Errorf("failed to declare RabbitMQ topology: not connected")
+	}
+	if err := step(r.ch); err != nil {
+		return err
+	}
+	r.topology = append(r.topology, step)
+	return nil
+}
+
+// name returns a queue name, routing key or binding pattern in the namespace. Topic exchanges are shared by
+// the deployments, their routing keys telling them apart.
+func (r *RabbitMQ) name(s string) string {
+	if r.opts.Namespace == "" || s == "" {
+		return s
+	}
+	return r.opts.Namespace + "." + s
+}
+
+// DeclareQueue declares a queue with the configured durability, as a priority queue if MaxPriority is set
+// and dead-lettering to DeadLetterExchange if set. Note that RabbitMQ refuses to redeclare an existing queue
+// with different durability or arguments.
+func (r *RabbitMQ) DeclareQueue(name string) error {
+	name = r.name(name)
+	args := amqp.Table{}
+	if r.opts.MaxPriority > 0 {
+		args["x-max-priority"] = int32(r.opts.MaxPriority)
+	}
+	if r.opts.DeadLetterExchange != "" {
+		args["x-dead-letter-exchange"] = r.opts.DeadLetterExchange
+	}
+	return r.declare(func(ch *amqp.Channel) error {
+		q, err := ch.QueueDeclare(
+			name,           // name
+			r.opts.Durable, // durable
+			false,          // delete when unused
+			false,          // exclusive
+			false,          // no-wait
+			args,           // arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare RabbitMQ queue %s: %w", name, err)
+		}
+		slog.Info("RabbitMQ queue declared", "queue_name", q.Name, "durable", r.opts.Durable, "max_priority", r.opts.MaxPriority)
+		return nil
+	})
+}
+
+// DeclareTopicExchange declares a topic exchange with the configured durability.
+func (r *RabbitMQ) DeclareTopicExchange(name string) error {
+	return r.declare(func(ch *amqp.Channel) error {
+		err := ch.ExchangeDeclare(
+			name,           // name
+			"topic",        // kind
+			r.opts.Durable, // durable
+			false,          // auto-deleted
+			false,          // internal
+			false,          // no-wait
+			nil,            // arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare RabbitMQ exchange %s: %w", name, err)
+		}
+		slog.Info("RabbitMQ exchange declared", "exchange", name, "durable", r.opts.Durable)
+		return nil
+	})
+}
+
+// DeclareDeadLetterQueue declares the dead-letter exchange as a fanout exchange and binds the given queue to it.
+// It must be called before DeclareQueue, so the other queues can dead-letter to the exchange.
+func (r *RabbitMQ) DeclareDeadLetterQueue(queue string) error {
+	queue = r.name(queue)
+	exchange := r.opts.DeadLetterExchange
+	if exchange == "" {
+		return fmt.Errorf("failed to declare RabbitMQ dead-letter queue %s: no dead-letter exchange configured", queue)
+	}
+	return r.declare(func(ch *amqp.Channel) error {
+		if err := ch.ExchangeDeclare(exchange, "fanout", r.opts.Durable, false, false, false, nil); err != nil {
+			return fmt.Errorf("failed to declare RabbitMQ dead-letter exchange %s: %w", exchange, err)
+		}
+		if _, err := ch.QueueDeclare(queue, r.opts.Durable, false, false, false, nil); err != nil {
+			return fmt.Errorf("failed to declare RabbitMQ dead-letter queue %s: %w", queue, err)
+		}
+		if err := ch.QueueBind(queue, "", exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind RabbitMQ dead-letter queue %s: %w", queue, err)
+		}
+		slog.Info("RabbitMQ dead-letter queue declared", "exchange", exchange, "queue", queue)
+		return nil
+	})
+}
+
+// BindQueue binds a queue to an exchange with a routing key pattern (e.g., "spread.#").
+func (r *RabbitMQ) BindQueue(queue, exchange, pattern string) error {
+	queue, pattern = r.name(queue), r.name(pattern)
+	return r.declare(func(ch *amqp.Channel) error {
+		if err := ch.QueueBind(queue, pattern, exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind RabbitMQ queue %s to %s: %w", queue, exchange, err)
+		}
+		slog.Info("RabbitMQ queue bound", "queue", queue, "exchange", exchange, "pattern", pattern)
+		return nil
+	})
+}
+
+// Publish sends a JSON body to a queue through the default exchange.
+func (r *RabbitMQ) Publish(ctx context.Context, queue string, body []byte) error {
+	return r.PublishToExchange(ctx, "", queue, body)
+}
+
+// PublishToExchange sends a JSON body to an exchange with a routing key and the lowest priority.
+func (r *RabbitMQ) PublishToExchange(ctx context.Context, exchange, routingKey string, body []byte) error {
+	return r.PublishWithPriority(ctx, exchange, routingKey, body, 0)
+}
+
+// PublishWithPriority sends a JSON body to an exchange with a routing key and a priority, capped at MaxPriority.
+// With confirms enabled it blocks until the broker acks the message, resending it up to MaxResends times
+// on nack or timeout. While disconnected, the message is buffered and ErrBuffered is returned.
+func (r *RabbitMQ) PublishWithPriority(ctx context.Context, exchange, routingKey string, body []byte, priority uint8) error {
+	m := pendingMessage{
+		exchange:   exchange,
+		routingKey: r.name(routingKey),
+		body:       body,
+		priority:   min(priority, r.opts.MaxPriority),
+		queuedAt:   time.Now(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ch == nil || r.ch.IsClosed() {
+		r.enqueue(m)
+		return ErrBuffered
+	}
+	err := r.publish(ctx, m)
+	if err != nil && r.ch.IsClosed() {
+		r.enqueue(m)
+		return ErrBuffered
+	}
+	return err
+}
+
+// publish sends a message on the current channel. The caller must hold r.mu.
+func (r *RabbitMQ) publish(ctx context.Context, m pendingMessage) error {
+	exchange, routingKey := m.exchange, m.routingKey
+	msg := amqp.Publishing{
+		ContentType: "application/json",
+		Body:        m.body,
+		Priority:    m.priority,
+	}
+	if r.opts.Persistent {
+		msg.DeliveryMode = amqp.Persistent
+	}
+	if r.opts.MessageTTL > 0 {
+		// Buffered messages only get what is left of their TTL
+		remaining := max(r.opts.MessageTTL-time.Since(m.queuedAt), time.Millisecond)
+		msg.Expiration = strconv.FormatInt(remaining.Milliseconds(), 10)
+	}
+
+	if !r.opts.Confirms {
+		return r.ch.PublishWithContext(ctx,
+			exchange,   // exchange
+			routingKey, // routing key
+			false,      // mandatory
+			false,      // immediate
+			msg)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= r.opts.MaxResends; attempt++ {
+		confirmation, err := r.ch.PublishWithDeferredConfirmWithContext(ctx,
+			exchange,   // exchange
+			routingKey, // routing key
+			false,      // mandatory
+			false,      // immediate
+			msg)
+		if err != nil {
+			return fmt.Errorf("failed to publish to RabbitMQ %s: %w", routingKey, err)
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, r.opts.ConfirmTimeout)
+		acked, err := confirmation.WaitContext(waitCtx)
+		cancel()
+		if err == nil && acked {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to publish to RabbitMQ %s: %w", routingKey, ctx.Err())
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("timed out waiting for RabbitMQ confirm: %w", err)
+		} else {
+			lastErr = fmt.Errorf("RabbitMQ nacked the message")
+		}
+		slog.Warn("RabbitMQ publish not confirmed, resending", "routing_key", routingKey, "attempt", attempt+1, "error", lastErr)
+	}
+	if err := r.deadLetter(ctx, m, "application/json", lastErr.Error()); err != nil {
+		slog.Error("Failed to dead-letter an unconfirmed RabbitMQ message", "routing_key", routingKey, "error", err)
+	}
+	return fmt.Errorf("failed to publish to RabbitMQ %s after %d resends: %w", routingKey, r.opts.MaxResends, lastErr)
+}
+
+// DeadLetter publishes a message that could not be processed (e.g., failed to encode) to the dead-letter exchange,
+// recording the reason in the "x-failure-reason" header. It is a no-op when dead-lettering is disabled.
+func (r *RabbitMQ) DeadLetter(ctx context.Context, routingKey string, body []byte, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ch == nil || r.ch.IsClosed() {
+		return fmt.Errorf("failed to dead-letter RabbitMQ message %s: not connected", routingKey)
+	}
+	return r.deadLetter(ctx, pendingMessage{routingKey: r.name(routingKey), body: body, queuedAt: time.Now()}, "text/plain", reason)
+}
+
+// deadLetter publishes m to the dead-letter exchange without waiting for a confirm, keeping its original
+// exchange and routing key in headers. The caller must hold r.mu.
+func (r *RabbitMQ) deadLetter(ctx context.Context, m pendingMessage, contentType, reason string) error {
+	if r.opts.DeadLetterExchange == "" {
+		return nil
+	}
+	msg := amqp.Publishing{
+		ContentType: contentType,
+		Body:        m.body,
+		Headers: amqp.Table{
+			"x-original-exchange":    m.exchange,
+			"x-original-routing-key": m.routingKey,
+			"x-failure-reason":       reason,
+		},
+		Timestamp: m.queuedAt,
+	}
+	if r.opts.Persistent {
+		msg.DeliveryMode = amqp.Persistent
+	}
+	if err := r.ch.PublishWithContext(ctx, r.opts.DeadLetterExchange, m.routingKey, false, false, msg); err != nil {
+		return fmt.Errorf("failed to dead-letter RabbitMQ message %s: %w", m.routingKey, err)
+	}
+	return nil
+}
+
+// Consume delivers the messages of a queue to handle on a dedicated channel, one at a time. Messages are acked
+// when handle succeeds and rejected otherwise, which dead-letters them if a dead-letter exchange is configured.
+// It blocks until ctx is done (returning nil) or the channel closes (returning an error, so the caller can retry
+// once the connection is back).
+func (r *RabbitMQ) Consume(ctx context.Context, queue string, handle func(body []byte) error) error {
+	queue = r.name(queue)
+	r.mu.Lock()
+	if r.conn == nil || r.conn.IsClosed() {
+		r.mu.Unlock()
+		return fmt.Errorf("failed to consume RabbitMQ queue %s: not connected", queue)
+	}
+	ch, err := r.conn.Channel()
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to open a RabbitMQ consumer channel: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.Qos(1, 0, false); err != nil {
+		return fmt.Errorf("failed to set RabbitMQ prefetch: %w", err)
+	}
+	deliveries, err := ch.ConsumeWithContext(ctx, queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume RabbitMQ queue %s: %w", queue, err)
+	}
+	slog.Info("Consuming RabbitMQ queue", "queue", queue)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("RabbitMQ consumer channel for %s closed", queue)
+			}
+			if err := handle(d.Body); err != nil {
+				slog.Error("Failed to handle a RabbitMQ message, rejecting it", "queue", queue, "error", err)
+				d.Nack(false, false)
+				continue
+			}
+			d.Ack(false)
+		}
+	}
+}
+
+// RabbitMQSink publishes events to a queue, or to a topic exchange using each event's routing key, with each event's priority.
+// Closing the sink does not close the shared connection.
+type RabbitMQSink struct {
+	RabbitMQ *RabbitMQ
+	Queue    string // Used when Exchange is empty
+	Exchange string
+}
+
+// Publish sends the event body to the sink's exchange or queue.
+func (s RabbitMQSink) Publish(ctx context.Context, event Event) error {
+	if s.Exchange != "" {
+		return s.RabbitMQ.PublishWithPriority(ctx, s.Exchange, event.RoutingKey, event.Body, event.Priority)
+	}
+	return s.RabbitMQ.PublishWithPriority(ctx, "", s.Queue, event.Body, event.Priority)
+}
+
+// Close is a no-op; the connection is owned by the RabbitMQ publisher.
+func (s RabbitMQSink) Close() error {
+	return nil
+}
+
+// SpreadRoutingKey builds the topic routing key of a spread: "spread.<BASE>-<QUOTE>.<short exchange>.<long exchange>"
+// (e.g., "spread.BTC-USDT.binance.mexc"). The market type suffix of the unified symbol is dropped.
+func SpreadRoutingKey(unifiedSymbol, exchangeShort, exchangeLong string) string {
+	return "spread." + routingPair(unifiedSymbol) + "." + strings.ToLower(exchangeShort) + "." + strings.ToLower(exchangeLong)
+}
+
+// FundingRoutingKey builds the topic routing key of a funding divergence: "funding.<BASE>-<QUOTE>.<short exchange>.<long exchange>"
+// (e.g., "funding.BTC-USDT.binance.mexc").
+func FundingRoutingKey(unifiedSymbol, exchangeShort, exchangeLong string) string {
+	return "funding" + strings.TrimPrefix(SpreadRoutingKey(unifiedSymbol, exchangeShort, exchangeLong), "spread")
+}
+
+// NetworkRoutingKey returns the topic routing key of a network status change, e.g., "network.binance.usdt.trx".
+func NetworkRoutingKey(exchange, asset, network string) string {
+	return "network." + strings.ToLower(exchange) + "." + strings.ToLower(asset) + "." + strings.ToLower(network)
+}
+
+// ListingRoutingKey returns the topic routing key of a new listing, e.g., "listing.mexc.BTC-USDT".
+func ListingRoutingKey(exchange, unifiedSymbol string) string {
+	return "listing." + strings.ToLower(exchange) + "." + routingPair(unifiedSymbol)
+}
+
+// routingPair returns the "<BASE>-<QUOTE>" routing key segment of a unified symbol. A symbol that doesn't parse is
+// used as is, with its slashes replaced.
+func routingPair(unifiedSymbol string) string {
+	pair := unifiedSymbol
+	if symbol, err := shared.ParseSymbol(unifiedSymbol); err == nil {
+		pair = symbol.Pair()
+	}
+	return strings.ReplaceAll(pair, "/", "-")
+}
+
+// ExchangeRoutingKey returns the topic routing key of an exchange health change, e.g., "exchange.binance.degraded".
+func ExchangeRoutingKey(exchange string, degraded bool) string {
+	if degraded {
+		return "exchange." + strings.ToLower(exchange) + ".degraded"
+	}
+	return "exchange." + strings.ToLower(exchange) + ".recovered"
+}
+
+// SpreadPriority maps an entry spread to an AMQP priority, linearly from 0 at no spread to maxPriority
+// at fullScalePct and above, so the biggest opportunities are delivered first.
+func SpreadPriority(entrySpreadPct, fullScalePct float64, maxPriority uint8) uint8 {
+	if maxPriority == 0 || fullScalePct <= 0 || entrySpreadPct <= 0 {
+		return 0
+	}
+	scaled := math.Round(entrySpreadPct / fullScalePct * float64(maxPriority))
+	return uint8(min(scaled, float64(maxPriority)))
+}
+
+// Check reports whether the connection is up. While reconnecting, messages are only buffered.
+func (r *RabbitMQ) Check(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ch == nil || r.closing {
+		return errors.New("rabbitmq is disconnected")
+	}
+	return nil
+}
+
+// Drop closes the current connection as a network failure would, so the RabbitMQ reconnects and flushes its
+// buffer. It is used to inject failures.
+func (r *RabbitMQ) Drop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil || r.closing {
+		return nil
+	}
+	return r.conn.Close()
+}
+
+// Close stops reconnecting and closes the channel and the connection.
+func (r *RabbitMQ) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closing = true
+	if r.conn == nil {
+		return nil
+	}
+	return r.conn.Close()
+}
@@ -0,0 +1,76 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisOptions controls where spreads are written in Redis. Either output can be disabled with an empty name.
+type RedisOptions struct {
+	Stream       string // Stream receiving one entry per event via XADD (e.g., "arb:spreads")
+	StreamMaxLen int64  // Approximate cap of the stream length (0 means unbounded)
+	Channel      string // Pub/sub channel receiving every event
+}
+
+// Redis publishes messages to a Redis Stream and/or a pub/sub channel, so consumers need no message broker.
+type Redis struct {
+	client *redis.Client
+	opts   RedisOptions
+}
+
+// NewRedis creates a Redis publisher on an existing client.
+func NewRedis(client *redis.Client, opts RedisOptions) *Redis {
+	return &Redis{client: client, opts: opts}
+}
+
+// Publish appends the event body to the stream, tagged with its unified symbol, and broadcasts it on the channel.
+func (r *Redis) Publish(ctx context.Context, event Event) error {
+	if r.opts.Stream != "" {
+		err := retryPublish(ctx, "redis", func(ctx context.Context) error {
+			return r.client.XAdd(ctx, &redis.XAddArgs{
+				Stream: r.opts.Stream,
+				MaxLen: r.opts.StreamMaxLen,
+				Approx: r.opts.StreamMaxLen > 0,
+				Values: map[string]any{"symbol": event.Key, "type": event.Type, "payload": event.Body},
+			}).Err()
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add to Redis stream %s: %w", r.opts.Stream, err)
+		}
+	}
+	if r.opts.Channel != "" {
+		err := retryPublish(ctx, "redis", func(ctx context.Context) error {
+			return r.client.Publish(ctx, r.opts.Channel, event.Body).Err()
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish to Redis channel %s: %w", r.opts.Channel, err)
+		}
+	}
+	return nil
+}
+
+// Check pings Redis.
+func (r *Redis) Check(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Prune removes stream entries added before cutoff. Stream entry IDs start with their Unix time in milliseconds.
+func (r *Redis) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	if r.opts.Stream == "" {
+		return 0, nil
+	}
+	removed, err := r.client.XTrimMinID(ctx, r.opts.Stream, strconv.FormatInt(cutoff.UnixMilli(), 10)+"-0").Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim Redis stream %s: %w", r.opts.Stream, err)
+	}
+	return removed, nil
+}
+
+// Close closes the Redis client.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,139 @@
+package messaging
+
+import (
+	"bytes"
+	"cex-price-diff-notifications/shared/retry"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookTarget is a URL receiving events, with optional filters. Empty filters match everything.
+type WebhookTarget struct {
+	URL            string   `json:"url"`
+	Secret         string   `json:"secret"`           // HMAC-SHA256 signing key (empty sends unsigned requests)
+	EventTypes     []string `json:"event_types"`      // Envelope event types to send (e.g., "spread", "spread_snapshot")
+	Symbols        []string `json:"symbols"`          // Base assets, pairs or unified symbols (e.g., "BTC", "BTC/USDT", "BTC/USDT:PERP")
+	MinEntrySpread float64  `json:"min_entry_spread"` // Minimum entry spread, in percent
+}
+
+// WebhookOptions configures the webhook publisher.
+type WebhookOptions struct {
+	Targets    []WebhookTarget
+	Timeout    time.Duration // Timeout of a single request
+	MaxRetries int           // Retries on network errors, 429 and 5xx responses
+}
+
+// Webhook POSTs event bodies as JSON to HTTP callbacks. When a target has a secret, requests carry an
+// "X-Signature: sha256=<hex>" header, the HMAC-SHA256 of "<X-Timestamp>.<body>", so receivers can verify
+// the sender and reject replays.
+type Webhook struct {
+	client *http.Client
+	opts   WebhookOptions
+}
+
+// NewWebhook creates a webhook publisher.
+func NewWebhook(opts WebhookOptions) *Webhook {
+	slog.Info("Initializing webhook publisher", "targets", len(opts.Targets))
+	return &Webhook{
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+	}
+}
+
+// Publish sends the event to every target whose filters match it.
+func (w *Webhook) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, target := range w.opts.Targets {
+		if !target.matches(event) {
+			continue
+		}
+		if err := w.post(ctx, target, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post delivers an event to a target, retrying transient failures with exponential backoff.
+func (w *Webhook) post(ctx context.Context, target WebhookTarget, event Event) error {
+	policy := retry.Policy{MaxAttempts: w.opts.MaxRetries + 1, InitialDelay: 500 * time.Millisecond, Jitter: 0.2}
+	err := retry.DoNotify(ctx, policy, func(ctx context.Context) error {
+		return w.send(ctx, target, event)
+	}, func(err error, attempt int, delay time.Duration) {
+		slog.Warn("Webhook delivery failed, retrying", "url", target.URL, "attempt", attempt, "retry_in", delay, "error", err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to post webhook to %s: %w", target.URL, err)
+	}
+	return nil
+}
+
+// send makes a single request. Failures that are not worth retrying are permanent.
+func (w *Webhook) send(ctx context.Context, target WebhookTarget, event Event) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(event.Body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", event.Type)
+	if target.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Timestamp", timestamp)
+		req.Header.Set("X-Signature", "sha256="+SignWebhook(target.Secret, timestamp, event.Body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("unexpected status %s", resp.Status)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return retry.Permanent(err)
+}
+
+// matches reports whether an event passes the target's filters.
+func (t WebhookTarget) matches(event Event) bool {
+	if len(t.EventTypes) > 0 && !slices.Contains(t.EventTypes, event.Type) {
+		return false
+	}
+	if len(t.Symbols) > 0 {
+		pair, _, _ := strings.Cut(event.Key, ":")
+		base, _, _ := strings.Cut(pair, "/")
+		if !slices.ContainsFunc(t.Symbols, func(s string) bool { return s == event.Key || s == pair || s == base }) {
+			return false
+		}
+	}
+	return event.EntrySpread >= t.MinEntrySpread
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" with the given secret.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Close releases idle connections.
+func (w *Webhook) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
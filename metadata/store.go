@@ -0,0 +1,154 @@
+package metadata
+
+import (
+	"cex-price-diff-notifications/shared"
+	"cmp"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SpecsFetcher retrieves all contract specs of a single exchange, keyed by unified symbol.
+type SpecsFetcher func(ctx context.Context) (map[string]shared.ContractSpec, error)
+
+// Listing is a symbol an exchange started or stopped listing between two refreshes.
+type Listing struct {
+	Exchange       string              `json:"exchange"`
+	UnifiedSymbol  string              `json:"unified_symbol"`
+	Listed         bool                `json:"listed"` // False when delisted
+	Spec           shared.ContractSpec `json:"spec"`
+	OtherExchanges []string            `json:"other_exchanges"` // Other exchanges listing the symbol, which it can be arbitraged across
+	DetectedAt     int64               `json:"detected_at"`     // Milliseconds since epoch
+}
+
+// ListingHandler is told the symbols listed or delisted found by a refresh of an exchange.
+type ListingHandler func(listings []Listing)
+
+// Store caches contract specs per exchange and unified symbol.
+type Store struct {
+	mu        sync.RWMutex
+	specs     map[string]map[string]shared.ContractSpec // exchange -> unified symbol -> spec
+	fetchers  map[string]SpecsFetcher
+	onListing ListingHandler // Nil ignores listings
+}
+
+// NewStore creates an empty contract metadata store.
+func NewStore() *Store {
+	return &Store{
+		specs:    make(map[string]map[string]shared.ContractSpec),
+		fetchers: make(map[string]SpecsFetcher),
+	}
+}
+
+// RegisterFetcher sets the contract specs source for an exchange (e.g., "Binance").
+func (s *Store) RegisterFetcher(exchange string, f SpecsFetcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetchers[exchange] = f
+}
+
+// OnListing sets the handler of symbols listed or delisted. The first refresh of an exchange only sets its
+// baseline, so it reports none.
+func (s *Store) OnListing(handle ListingHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onListing = handle
+}
+
+// Get returns the contract spec of a symbol on an exchange.
+func (s *Store) Get(exchange, unifiedSymbol string) (shared.ContractSpec, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	spec, ok := s.specs[exchange][unifiedSymbol]
+	return spec, ok
+}
+
+// Refresh re-fetches the specs of all registered exchanges. Exchanges that fail keep their previous specs.
+func (s *Store) Refresh(ctx context.Context) {
+	s.mu.RLock()
+	fetchers := make(map[string]SpecsFetcher, len(s.fetchers))
+	for exchange, f := range s.fetchers {
+		fetchers[exchange] = f
+	}
+	s.mu.RUnlock()
+
+	previous := make(map[string]map[string]shared.ContractSpec, len(fetchers))
+	for exchange, fetch := range fetchers {
+		specs, err := fetch(ctx)
+		if err != nil {
+			slog.Error("Failed to refresh contract specs", "exchange", exchange, "error", err)
+			continue
+		}
+		s.mu.Lock()
+		if known, ok := s.specs[exchange]; ok {
+			previous[exchange] = known
+		}
+		s.specs[exchange] = specs
+		s.mu.Unlock()
+		slog.Info("Contract specs refreshed", "exchange", exchange, "count", len(specs))
+	}
+
+	// Diffed once every exchange is refreshed, so a symbol listed on several at once names them all
+	now := time.Now()
+	s.mu.RLock()
+	handle := s.onListing
+	byExchange := make(map[string][]Listing, len(previous))
+	for exchange, known := range previous {
+		if listings := s.listings(exchange, known, s.specs[exchange], now); len(listings) > 0 {
+			byExchange[exchange] = listings
+		}
+	}
+	s.mu.RUnlock()
+	if handle == nil {
+		return
+	}
+	for _, exchange := range slices.Sorted(maps.Keys(byExchange)) {
+		handle(byExchange[exchange])
+	}
+}
+
+// Run refreshes the specs immediately and then every interval. It blocks until ctx is cancelled and should be run in a goroutine.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	s.Refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
+
+// listings returns the symbols of an exchange listed or delisted between two refreshes, sorted by symbol. s.mu
+// must be held.
+func (s *Store) listings(exchange string, previous, current map[string]shared.ContractSpec, at time.Time) []Listing {
+	var listings []Listing
+	for unifiedSymbol, spec := range current {
+		if _, ok := previous[unifiedSymbol]; !ok {
+			listings = append(listings, Listing{Exchange: exchange, UnifiedSymbol: unifiedSymbol, Listed: true, Spec: spec})
+		}
+	}
+	for unifiedSymbol, spec := range previous {
+		if _, ok := current[unifiedSymbol]; !ok {
+			listings = append(listings, Listing{Exchange: exchange, UnifiedSymbol: unifiedSymbol, Spec: spec})
+		}
+	}
+	for i := range listings {
+		listings[i].DetectedAt = at.UnixMilli()
+		listings[i].OtherExchanges = []string{}
+		for other, specs := range s.specs {
+			if _, ok := specs[listings[i].UnifiedSymbol]; ok && other != exchange {
+				listings[i].OtherExchanges = append(listings[i].OtherExchanges, other)
+			}
+		}
+		slices.Sort(listings[i].OtherExchanges)
+	}
+	slices.SortFunc(listings, func(a, b Listing) int { return cmp.Compare(a.UnifiedSymbol, b.UnifiedSymbol) })
+	return listings
+}
@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/arbitrage"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Fetch kinds, used as the "kind" label of the adapter metrics.
+const (
+	FetchTickers     = "tickers"
+	FetchSpotTickers = "spot_tickers"
+	FetchFunding     = "funding"
+	FetchVolumes     = "volumes"
+)
+
+// Publish results, used as the "result" label of PublishTotal.
+const (
+	PublishSuccess = "success"
+	PublishFailure = "failure"
+	PublishDropped = "dropped" // The sink's queue was full
+)
+
+var (
+	// FetchDuration is the duration of successful exchange requests.
+	FetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "arb_adapter_fetch_duration_seconds",
+		Help:    "Duration of successful exchange requests.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10},
+	}, []string{"exchange", "kind"})
+
+	// FetchErrors counts failed exchange requests, by adapter error code ("other" for unclassified failures,
+	// e.g., cancellations).
+	FetchErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "arb_adapter_fetch_errors_total",
+		Help: "Failed exchange requests, by error code.",
+	}, []string{"exchange", "kind", "code"})
+
+	// PayloadBytes counts the bytes of exchange responses, as received ("wire") and decompressed ("decoded").
+	PayloadBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "arb_adapter_payload_bytes_total",
+		Help: "Bytes of exchange responses, as received (wire) and after decompression (decoded).",
+	}, []string{"exchange", "encoding"})
+
+	// Tickers is the number of usable tickers fetched from each exchange in the last cycle.
+	Tickers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "arb_tickers",
+		Help: "Usable tickers fetched from each exchange in the last cycle.",
+	}, []string{"exchange"})
+
+	// CycleSpreads is the number of spreads in the last cycle, as candidates and as published.
+	CycleSpreads = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "arb_cycle_spreads",
+		Help: "Spreads found in the last cycle, by stage (candidates or published).",
+	}, []string{"stage"})
+
+	// CycleDuration is the duration of a whole scan cycle.
+	CycleDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "arb_cycle_duration_seconds",
+		Help:    "Duration of a scan cycle, from fetching to the ranked snapshot.",
+		Buckets: []float64{0.25, 0.5, 1, 2, 3, 5, 10, 20},
+	})
+
+	// CyclesSkipped counts poll ticks skipped because the cycle running at the time overran the poll interval.
+	CyclesSkipped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "arb_cycles_skipped_total",
+		Help: "Poll ticks skipped because a scan cycle was still running.",
+	})
+
+	// PollInterval is the interval of the scan cycles, which changes with adaptive polling.
+	PollInterval = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "arb_poll_interval_seconds",
+		Help: "Interval of the scan cycles.",
+	})
+
+	// TopSpread is the best entry spread of each symbol among the last cycle's candidates.
+	TopSpread = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "arb_top_entry_spread_percent",
+		Help: "Best entry spread of each symbol in the last cycle, in percent.",
+	}, []string{"symbol"})
+
+	// PublishTotal counts events handed to each sink, by result.
+	PublishTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "arb_publish_total",
+		Help: "Events published to each sink, by result (success, failure or dropped).",
+	}, []string{"sink", "result"})
+
+	// PublishDataAge is the age of the market data behind published events, from the older leg's exchange
+	// timestamp to the publish.
+	PublishDataAge = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "arb_publish_data_age_seconds",
+		Help:    "Age of the market data behind published events, from the exchange timestamp to the publish.",
+		Buckets: []float64{0.25, 0.5, 1, 2, 3, 5, 10, 30},
+	}, []string{"event"})
+
+	// PublishStale counts events dropped because their market data was older than the data age SLO.
+	PublishStale = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "arb_publish_stale_total",
+		Help: "Events dropped because their market data was older than PUBLISH_MAX_DATA_AGE.",
+	}, []string{"event"})
+
+	// Leader is 1 while this instance holds the leader lease and publishes, 0 while it stands by.
+	Leader = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "arb_leader",
+		Help: "Whether this instance is the leader and publishes (1) or stands by (0).",
+	})
+
+	// AvailableBalance is the margin available to open positions, by exchange and asset, when accounts are tracked.
+	AvailableBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "arb_available_balance",
+		Help: "Margin available to open positions in each exchange's futures account, by asset.",
+	}, []string{"exchange", "asset"})
+
+	// OpenPositions is the number of open positions of each exchange's futures account, when accounts are tracked.
+	OpenPositions = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "arb_open_positions",
+		Help: "Open positions in each exchange's futures account.",
+	}, []string{"exchange"})
+
+	// NetworkStatusChanges counts changes of the deposit/withdrawal availability of asset networks, by exchange.
+	NetworkStatusChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "arb_network_status_changes_total",
+		Help: "Changes of the deposit or withdrawal availability of an asset network on each exchange.",
+	}, []string{"exchange"})
+
+	// DuplicatesSuppressed counts the events not published because another instance claimed their opportunity.
+	DuplicatesSuppressed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "arb_duplicates_suppressed_total",
+		Help: "Events not published because another instance claimed their opportunity, by event type.",
+	}, []string{"event_type"})
+
+	// ClaimErrors counts the cycles whose opportunities couldn't be claimed in Redis, published unclaimed.
+	ClaimErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "arb_claim_errors_total",
+		Help: "Cycles whose opportunities couldn't be claimed in Redis, so their events were published without deduplication.",
+	})
+
+	// ListingChanges counts the symbols listed or delisted, by exchange and change ("listed" or "delisted").
+	ListingChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "arb_listing_changes_total",
+		Help: "Symbols each exchange listed or delisted since startup.",
+	}, []string{"exchange", "change"})
+
+	// ExchangeDegraded is 1 while an exchange is excluded from the spread calculation for an outage, else 0.
+	ExchangeDegraded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "arb_exchange_degraded",
+		Help: "Whether an exchange is excluded from the spread calculation because its data went stale or its fetches keep failing.",
+	}, []string{"exchange"})
+
+	// Warnings counts the repeated warnings aggregated by the logger, by message, whether logged in full or not.
+	Warnings = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "arb_warnings_total",
+		Help: "Repeated warnings, such as DTOs that failed to convert, by message, including those only summarized in the logs.",
+	}, []string{"message"})
+
+	// RabbitMQReconnects counts successful reconnections to RabbitMQ.
+	RabbitMQReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "arb_rabbitmq_reconnects_total",
+		Help: "Successful reconnections to RabbitMQ after a lost connection.",
+	})
+)
+
+// ObserveFetch records the outcome of an exchange request.
+func ObserveFetch(exchange, kind string, d time.Duration, err error) {
+	if err != nil {
+		code := string(adapters.CodeOf(err))
+		if code == "" {
+			code = "other"
+		}
+		FetchErrors.WithLabelValues(exchange, kind, code).Inc()
+		return
+	}
+	FetchDuration.WithLabelValues(exchange, kind).Observe(d.Seconds())
+	sources.fetched(exchange, kind, time.Now())
+}
+
+// ObserveWarnings records n occurrences of a repeated warning.
+func ObserveWarnings(message string, n int) {
+	Warnings.WithLabelValues(message).Add(float64(n))
+}
+
+// ObservePayload records the size of an exchange response, on the wire and decompressed.
+func ObservePayload(exchange string, wire, decoded int64) {
+	PayloadBytes.WithLabelValues(exchange, "wire").Add(float64(wire))
+	PayloadBytes.WithLabelValues(exchange, "decoded").Add(float64(decoded))
+}
+
+// SetTopSpreads replaces the per-symbol top spread gauges with the best entry spread of each symbol, so
+// symbols without spreads this cycle disappear instead of keeping a stale value.
+func SetTopSpreads(spreads []arbitrage.Spread) {
+	top := make(map[string]float64)
+	for _, s := range spreads {
+		if best, ok := top[s.UnifiedSymbol]; !ok || s.EntrySpread > best {
+			top[s.UnifiedSymbol] = s.EntrySpread
+		}
+	}
+	TopSpread.Reset()
+	for symbol, v := range top {
+		TopSpread.WithLabelValues(symbol).Set(v)
+	}
+}
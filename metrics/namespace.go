@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Handler serves the registered metrics, each labelled with namespace="<namespace>" unless namespace is empty,
+// so deployments scraped into the same Prometheus can be told apart.
+func Handler(namespace string) http.Handler {
+	if namespace == "" {
+		return promhttp.Handler()
+	}
+	gatherer := namespacedGatherer{Gatherer: prometheus.DefaultGatherer, namespace: namespace}
+	return promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+}
+
+// namespacedGatherer adds the namespace label to every metric gathered.
+type namespacedGatherer struct {
+	prometheus.Gatherer
+	namespace string
+}
+
+// Gather implements prometheus.Gatherer.
+func (g namespacedGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.Gatherer.Gather()
+	name := "namespace"
+	for _, family := range families {
+		for _, m := range family.Metric {
+			m.Label = append(m.Label, &dto.LabelPair{Name: &name, Value: &g.namespace})
+		}
+	}
+	return families, err
+}
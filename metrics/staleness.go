@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	lastFetchDesc = prometheus.NewDesc(
+		"arb_last_successful_fetch_seconds",
+		"Seconds since the last successful exchange request, by kind. Absent until the first one.",
+		[]string{"exchange", "kind"}, nil,
+	)
+	fundingAgeDesc = prometheus.NewDesc(
+		"arb_funding_age_seconds",
+		"Seconds since each exchange's funding rates were last updated, polled or streamed. Absent until the first update.",
+		[]string{"exchange"}, nil,
+	)
+)
+
+// fetchKey identifies the requests of a kind to an exchange.
+type fetchKey struct {
+	exchange string
+	kind     string
+}
+
+// staleness exports the age of the data sources at scrape time, so alerting rules can page on a threshold
+// (e.g., arb_last_successful_fetch_seconds{kind="tickers"} > 60) without computing it from timestamps.
+type staleness struct {
+	mu         sync.Mutex
+	lastFetch  map[fetchKey]time.Time
+	fundingAge map[string]func() time.Time // Exchange -> time of its last funding rate update
+}
+
+var sources = &staleness{
+	lastFetch:  make(map[fetchKey]time.Time),
+	fundingAge: make(map[string]func() time.Time),
+}
+
+func init() {
+	prometheus.MustRegister(sources)
+}
+
+// Describe implements prometheus.Collector.
+func (s *staleness) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lastFetchDesc
+	ch <- fundingAgeDesc
+}
+
+// Collect implements prometheus.Collector.
+func (s *staleness) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, at := range s.lastFetch {
+		ch <- prometheus.MustNewConstMetric(lastFetchDesc, prometheus.GaugeValue, now.Sub(at).Seconds(), key.exchange, key.kind)
+	}
+	for exchange, updatedAt := range s.fundingAge {
+		if at := updatedAt(); !at.IsZero() {
+			ch <- prometheus.MustNewConstMetric(fundingAgeDesc, prometheus.GaugeValue, now.Sub(at).Seconds(), exchange)
+		}
+	}
+}
+
+// TrackFundingAge exports the age of an exchange's funding rates, from the time of their last update as
+// returned by updatedAt (the zero time before the first one).
+func TrackFundingAge(exchange string, updatedAt func() time.Time) {
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+	sources.fundingAge[exchange] = updatedAt
+}
+
+// fetched records a successful exchange request.
+func (s *staleness) fetched(exchange, kind string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastFetch[fetchKey{exchange, kind}] = at
+}
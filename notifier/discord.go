@@ -0,0 +1,198 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// discordAPI is the Discord REST API base URL used with bot tokens.
+const discordAPI = "https://discord.com/api/v10"
+
+// Embed colors per alert event type.
+var discordColors = map[string]int{
+	"spread":                  0x3498db,
+	"opportunity_opened":      0x2ecc71,
+	"opportunity_updated":     0xf1c40f,
+	"opportunity_closed":      0x95a5a6,
+	"opportunity_exit_signal": 0xe67e22,
+	"funding_divergence":      0x9b59b6,
+	"summary_report":          0x1abc9c,
+}
+
+// Discord sends alerts as embeds, either through an incoming webhook or as a bot posting to a channel.
+type Discord struct {
+	templated
+	client     *http.Client
+	webhookURL string
+	botToken   string
+	channelID  string
+}
+
+// NewDiscordWebhook creates a channel posting to a Discord incoming webhook.
+func NewDiscordWebhook(webhookURL string) *Discord {
+	return &Discord{client: &http.Client{Timeout: 10 * time.Second}, webhookURL: webhookURL}
+}
+
+// NewDiscordBot creates a channel posting to channelID as the bot with botToken.
+func NewDiscordBot(botToken, channelID string) *Discord {
+	return &Discord{client: &http.Client{Timeout: 10 * time.Second}, botToken: botToken, channelID: channelID}
+}
+
+// Name identifies the channel in logs without leaking the webhook token.
+func (d *Discord) Name() string {
+	if d.webhookURL != "" {
+		return "discord:webhook"
+	}
+	return "discord:" + d.channelID
+}
+
+// discordEmbedField is a name/value pair of an embed.
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// discordEmbed is a Discord rich embed.
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	URL         string              `json:"url,omitempty"`
+	Description string              `json:"description"`
+	Color       int                 `json:"color"`
+	Fields      []discordEmbedField `json:"fields"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+}
+
+// Send posts the alert as an embed. When Discord rate-limits the request, it waits once for the
+// requested time and retries.
+func (d *Discord) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(map[string]any{"embeds": []discordEmbed{d.newEmbed(alert)}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Discord message: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := d.post(ctx, body)
+		if err == nil || retryAfter == 0 || attempt > 0 {
+			return err
+		}
+		select {
+		case <-time.After(retryAfter):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// post creates the message, returning the retry delay when rate-limited.
+func (d *Discord) post(ctx context.Context, body []byte) (time.Duration, error) {
+	url := d.webhookURL
+	if url == "" {
+		url = discordAPI + "/channels/" + d.channelID + "/messages"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.botToken != "" {
+		req.Header.Set("Authorization", "Bot "+d.botToken)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send Discord message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var limit struct {
+			RetryAfter float64 `json:"retry_after"` // Seconds
+		}
+		json.NewDecoder(resp.Body).Decode(&limit)
+		return time.Duration(limit.RetryAfter * float64(time.Second)), fmt.Errorf("discord rate limit exceeded")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("discord rejected the message: %s", resp.Status)
+	}
+	return 0, nil
+}
+
+// newEmbed renders an alert as an embed with spread, funding and volume fields, linking the title
+// to a chart of the short leg. A template replaces the description.
+func (d *Discord) newEmbed(alert Alert) discordEmbed {
+	if r := alert.Report; r != nil {
+		return discordEmbed{
+			Title:       r.Title(),
+			Description: "```\n" + r.Text() + "\n```",
+			Color:       discordColors[alert.EventType],
+			Timestamp:   r.GeneratedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	s := alert.Spread
+	title := alertTitle(alert.EventType)
+
+	embed := discordEmbed{
+		Title:       title + ": " + s.UnifiedSymbol,
+		URL:         ChartURL(s.ExchangeShort, s.UnifiedSymbol),
+		Description: d.render(alert, markdownDirection),
+		Color:       discordColors[alert.EventType],
+		Fields: []discordEmbedField{
+			{Name: "Entry spread", Value: fmt.Sprintf("%.3f%%", s.EntrySpread), Inline: true},
+			{Name: "Conservative", Value: fmt.Sprintf("%.3f%%", s.ConservativeEntrySpread), Inline: true},
+			{Name: "Exit spread", Value: fmt.Sprintf("%.3f%%", s.ExitSpread), Inline: true},
+		},
+	}
+	if s.FundingSpread8h != nil {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Funding (8h)", Value: fmt.Sprintf("%.4f%%", *s.FundingSpread8h), Inline: true})
+	}
+	if alert.FundingAPR != 0 {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Funding (annualized)", Value: fmt.Sprintf("%.1f%%", alert.FundingAPR), Inline: true})
+	}
+	if s.ExpectedPnL24h != nil {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: fmt.Sprintf("Expected PnL (%.0fh)", s.PnLHorizonHours), Value: fmt.Sprintf("%.3f%%", *s.ExpectedPnL24h), Inline: true})
+	}
+	if s.VolumeUSD > 0 {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Volume (24h)", Value: formatUSD(s.VolumeUSD), Inline: true})
+	}
+	if alert.ExitReason != "" {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Exit reason", Value: alert.ExitReason, Inline: true})
+	}
+	if !s.QuoteTime.IsZero() {
+		embed.Timestamp = s.QuoteTime.UTC().Format(time.RFC3339)
+	}
+	return embed
+}
+
+// markdownDirection renders the trade direction with links to both exchanges.
+func markdownDirection(alert Alert) string {
+	s := alert.Spread
+	return "Sell on " + markdownLink(s.ExchangeShort, s.UnifiedSymbol) + ", buy on " + markdownLink(s.ExchangeLong, s.LongSymbol())
+}
+
+// markdownLink links an exchange name to its trading page, if known.
+func markdownLink(exchange, unifiedSymbol string) string {
+	url := ExchangeURL(exchange, unifiedSymbol)
+	if url == "" {
+		return exchange
+	}
+	return "[" + exchange + "](" + url + ")"
+}
+
+// formatUSD abbreviates a dollar amount (e.g., 1234567 -> "$1.23M").
+func formatUSD(v float64) string {
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("$%.2fB", v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("$%.2fM", v/1e6)
+	case v >= 1e3:
+		return fmt.Sprintf("$%.1fK", v/1e3)
+	default:
+		return fmt.Sprintf("$%.0f", v)
+	}
+}
@@ -0,0 +1,166 @@
+package notifier
+
+import (
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/messaging"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmailOptions configures the email channel.
+type EmailOptions struct {
+	Host             string
+	Port             int
+	Username         string // Empty disables SMTP authentication
+	Password         string
+	From             string
+	To               []string
+	InstantMinSpread float64       // Alerts at or above this entry spread, in percent, are mailed right away (0 disables)
+	DigestInterval   time.Duration // How often the digest of the best opportunities is mailed (0 disables)
+	DigestSize       int           // Opportunities listed in a digest
+}
+
+// Email mails alerts over SMTP: big spreads immediately, and the best opportunities of each period as a digest.
+type Email struct {
+	templated
+	opts EmailOptions
+
+	mu   sync.Mutex
+	best map[string]Alert // Pair key -> the alert with the highest entry spread since the last digest
+}
+
+// NewEmail creates an email channel. Call Run to send digests.
+func NewEmail(opts EmailOptions) *Email {
+	return &Email{opts: opts, best: make(map[string]Alert)}
+}
+
+// Name identifies the channel in logs.
+func (e *Email) Name() string {
+	return "email:" + strings.Join(e.opts.To, ",")
+}
+
+// Send mails the alert right away if its spread reaches the instant threshold, and records it for the digest.
+// Funding divergences and summary reports are always mailed right away, as the digest ranks spreads.
+func (e *Email) Send(ctx context.Context, alert Alert) error {
+	if alert.Report != nil {
+		return e.send(alert.Report.Title()+": "+alert.Report.Summary(), alert.Report.Text())
+	}
+	if alert.EventType == messaging.EventFundingDivergence {
+		subject := fmt.Sprintf("%s %s: %.1f%% annualized (%s -> %s)", alertTitle(alert.EventType), alert.Spread.UnifiedSymbol,
+			alert.FundingAPR, alert.Spread.ExchangeLong, alert.Spread.ExchangeShort)
+		return e.send(subject, e.render(alert, formatEmailAlert))
+	}
+	if e.opts.DigestInterval > 0 && alert.EventType != lifecycle.EventClosed {
+		e.mu.Lock()
+		key := alert.Spread.PairKey()
+		if prev, ok := e.best[key]; !ok || alert.Spread.EntrySpread > prev.Spread.EntrySpread {
+			e.best[key] = alert
+		}
+		e.mu.Unlock()
+	}
+
+	if e.opts.InstantMinSpread <= 0 || alert.Spread.EntrySpread < e.opts.InstantMinSpread {
+		return nil
+	}
+	subject := fmt.Sprintf("%s %s: %.3f%% (%s -> %s)", alertTitle(alert.EventType), alert.Spread.UnifiedSymbol,
+		alert.Spread.EntrySpread, alert.Spread.ExchangeLong, alert.Spread.ExchangeShort)
+	return e.send(subject, e.render(alert, formatEmailAlert))
+}
+
+// Run mails a digest every DigestInterval until ctx is done. Periods without opportunities send nothing.
+func (e *Email) Run(ctx context.Context) {
+	if e.opts.DigestInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(e.opts.DigestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.sendDigest(); err != nil {
+				slog.Error("Failed to send the email digest", "error", err)
+			}
+		}
+	}
+}
+
+// sendDigest mails the best opportunities since the last digest, highest entry spread first.
+func (e *Email) sendDigest() error {
+	e.mu.Lock()
+	alerts := make([]Alert, 0, len(e.best))
+	for _, a := range e.best {
+		alerts = append(alerts, a)
+	}
+	e.best = make(map[string]Alert)
+	e.mu.Unlock()
+
+	if len(alerts) == 0 {
+		return nil
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Spread.EntrySpread > alerts[j].Spread.EntrySpread
+	})
+	total := len(alerts)
+	if e.opts.DigestSize > 0 && total > e.opts.DigestSize {
+		alerts = alerts[:e.opts.DigestSize]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Best of %d opportunities seen in the last %s:\n\n", total, e.opts.DigestInterval)
+	for i, a := range alerts {
+		fmt.Fprintf(&b, "%d. %s\n\n", i+1, e.render(a, formatEmailAlert))
+	}
+	subject := fmt.Sprintf("Arbitrage digest: %d opportunities, best %.3f%% on %s", total, alerts[0].Spread.EntrySpread, alerts[0].Spread.UnifiedSymbol)
+	return e.send(subject, b.String())
+}
+
+// send mails a plain-text message to all recipients.
+func (e *Email) send(subject, body string) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.opts.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.opts.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if e.opts.Username != "" {
+		auth = smtp.PlainAuth("", e.opts.Username, e.opts.Password, e.opts.Host)
+	}
+	addr := net.JoinHostPort(e.opts.Host, strconv.Itoa(e.opts.Port))
+	if err := smtp.SendMail(addr, auth, e.opts.From, e.opts.To, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", addr, err)
+	}
+	return nil
+}
+
+// formatEmailAlert renders an alert as a few plain-text lines with links to both exchanges.
+func formatEmailAlert(alert Alert) string {
+	s := alert.Spread
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: entry spread %.3f%%, exit spread %.3f%%\n", s.UnifiedSymbol, s.EntrySpread, s.ExitSpread)
+	fmt.Fprintf(&b, "   Sell on %s %s\n", s.ExchangeShort, ExchangeURL(s.ExchangeShort, s.UnifiedSymbol))
+	fmt.Fprintf(&b, "   Buy on %s %s", s.ExchangeLong, ExchangeURL(s.ExchangeLong, s.LongSymbol()))
+	if s.FundingSpread8h != nil {
+		fmt.Fprintf(&b, "\n   Funding spread (8h): %.4f%%", *s.FundingSpread8h)
+	}
+	if alert.FundingAPR != 0 {
+		fmt.Fprintf(&b, "\n   Funding spread (annualized): %.1f%%", alert.FundingAPR)
+	}
+	if s.ExpectedPnL24h != nil {
+		fmt.Fprintf(&b, "\n   Expected PnL (%.0fh): %.3f%%", s.PnLHorizonHours, *s.ExpectedPnL24h)
+	}
+	return b.String()
+}
@@ -0,0 +1,15 @@
+package notifier
+
+import "cex-price-diff-notifications/enrichment"
+
+// ChartURL returns a TradingView chart of a unified symbol on an exchange, or "" for unknown exchanges and
+// symbols.
+func ChartURL(exchange, unifiedSymbol string) string {
+	return enrichment.ChartURL(exchange, unifiedSymbol)
+}
+
+// ExchangeURL returns the trading page of a unified symbol on an exchange, or "" for unknown exchanges and
+// symbols. Perpetuals link to the futures UI and spot markets to the spot UI.
+func ExchangeURL(exchange, unifiedSymbol string) string {
+	return enrichment.TradeURL(exchange, unifiedSymbol)
+}
@@ -0,0 +1,220 @@
+package notifier
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/report"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Alert is a spread event decoded from the arbitrage queue.
+type Alert struct {
+	EventType       string           // "spread", "funding_divergence", "summary_report" or a lifecycle event type (e.g., "opportunity_opened")
+	Spread          arbitrage.Spread // Latest observation of the opportunity
+	DurationSeconds float64          // Time since a lifecycle opportunity opened (0 for raw spreads)
+	ExitReason      string           // Why an entered opportunity should be exited, for exit signals
+	FundingAPR      float64          // Annualized funding differential, in percent, for funding divergences
+	Report          *report.Report   // Set for summary reports, which carry no spread
+}
+
+// alertTitles are the headlines of each alert event type.
+var alertTitles = map[string]string{
+	"spread":                  "Arbitrage spread",
+	"opportunity_opened":      "Opportunity opened",
+	"opportunity_updated":     "Opportunity updated",
+	"opportunity_closed":      "Opportunity closed",
+	"opportunity_exit_signal": "Exit signal",
+	"funding_divergence":      "Funding divergence",
+	"summary_report":          "Summary report",
+}
+
+// alertTitle returns the headline of an alert event type, falling back to the type itself.
+func alertTitle(eventType string) string {
+	if title, ok := alertTitles[eventType]; ok {
+		return title
+	}
+	return eventType
+}
+
+// alertHeadline returns the title of an alert with its symbol, or the report's own title.
+func alertHeadline(alert Alert) string {
+	if alert.Report != nil {
+		return alert.Report.Title()
+	}
+	return alertTitle(alert.EventType) + ": " + alert.Spread.UnifiedSymbol
+}
+
+// Channel is a destination for alerts (e.g., a Telegram chat).
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, alert Alert) error
+}
+
+// envelopeProbe detects enveloped and lifecycle messages without decoding their payloads.
+type envelopeProbe struct {
+	SchemaVersion *int            `json:"schema_version"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Decode parses a message published to the arbitrage queue, with or without an envelope.
+// It returns false for messages that are not spread alerts (e.g., snapshots).
+func Decode(body []byte) (Alert, bool, error) {
+	var probe envelopeProbe
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return Alert{}, false, fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	eventType, payload := probe.EventType, body
+	if probe.SchemaVersion != nil {
+		payload = probe.Payload
+	} else if eventType == "" {
+		eventType = messaging.EventSpread // Bare spreads carry no event type
+	}
+
+	switch {
+	case eventType == messaging.EventSpread:
+		var s arbitrage.Spread
+		if err := json.Unmarshal(payload, &s); err != nil {
+			return Alert{}, false, fmt.Errorf("failed to decode spread: %w", err)
+		}
+		return Alert{EventType: eventType, Spread: s}, true, nil
+	case eventType == messaging.EventFundingDivergence:
+		var d arbitrage.FundingDivergence
+		if err := json.Unmarshal(payload, &d); err != nil {
+			return Alert{}, false, fmt.Errorf("failed to decode funding divergence: %w", err)
+		}
+		// The divergence reads as a spread of the pair, its price spread as the entry spread
+		s := arbitrage.Spread{
+			UnifiedSymbol:    d.UnifiedSymbol,
+			ExchangeShort:    d.ExchangeShort,
+			ExchangeLong:     d.ExchangeLong,
+			EntrySpread:      d.PriceSpread,
+			FundingSpread8h:  &d.FundingSpread8h,
+			FundingRateShort: d.FundingRateShort,
+			FundingRateLong:  d.FundingRateLong,
+		}
+		return Alert{EventType: eventType, Spread: s, FundingAPR: d.AnnualizedRate}, true, nil
+	case eventType == messaging.EventSummaryReport:
+		var r report.Report
+		if err := json.Unmarshal(payload, &r); err != nil {
+			return Alert{}, false, fmt.Errorf("failed to decode summary report: %w", err)
+		}
+		return Alert{EventType: eventType, Report: &r}, true, nil
+	case strings.HasPrefix(eventType, "opportunity_"):
+		var e lifecycle.Event
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return Alert{}, false, fmt.Errorf("failed to decode lifecycle event: %w", err)
+		}
+		return Alert{EventType: e.EventType, Spread: e.Spread, DurationSeconds: e.DurationSeconds, ExitReason: e.ExitReason}, true, nil
+	default:
+		return Alert{}, false, nil
+	}
+}
+
+// Filter selects the alerts a channel receives. The zero value passes everything.
+type Filter struct {
+	MinEntrySpread  float64  `json:"min_entry_spread"` // Minimum entry spread, in percent
+	Symbols         []string `json:"symbols"`          // Base assets, pairs or unified symbols (e.g., "BTC", "BTC/USDT", "BTC/USDT:PERP"); empty matches all
+	CooldownSeconds float64  `json:"cooldown_seconds"` // Minimum time between two alerts of the same opportunity (0 disables)
+}
+
+// Matches reports whether an alert passes the filter's symbol and spread conditions. Closed opportunities
+// pass the spread condition, so a channel that saw an opportunity open also sees it close, and so do funding
+// divergences, which alert whatever the price spread. Summary reports pass every condition.
+func (f Filter) Matches(alert Alert) bool {
+	if alert.Report != nil {
+		return true
+	}
+	if len(f.Symbols) > 0 {
+		symbol := alert.Spread.UnifiedSymbol
+		pair, _, _ := strings.Cut(symbol, ":")
+		base, _, _ := strings.Cut(pair, "/")
+		if !slices.ContainsFunc(f.Symbols, func(s string) bool { return s == symbol || s == pair || s == base }) {
+			return false
+		}
+	}
+	return alert.EventType == lifecycle.EventClosed || alert.EventType == messaging.EventFundingDivergence ||
+		alert.Spread.EntrySpread >= f.MinEntrySpread
+}
+
+// route is a channel with its filter and the cooldown state of each opportunity.
+type route struct {
+	channel  Channel
+	filter   Filter
+	lastSent map[string]time.Time // Pair key -> time of the last alert sent
+}
+
+// allow applies the cooldown. Closed opportunities always pass and reset it, and summary reports always pass.
+func (r *route) allow(alert Alert, now time.Time) bool {
+	if r.filter.CooldownSeconds <= 0 || alert.Report != nil {
+		return true
+	}
+	key := alert.Spread.PairKey()
+	if alert.EventType == messaging.EventFundingDivergence {
+		key += "|funding" // Cooled down apart from the pair's spreads
+	}
+	if alert.EventType == lifecycle.EventClosed {
+		delete(r.lastSent, key)
+		return true
+	}
+	cooldown := time.Duration(r.filter.CooldownSeconds * float64(time.Second))
+	if last, ok := r.lastSent[key]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	r.lastSent[key] = now
+	return true
+}
+
+// Dispatcher sends each alert to the channels whose filters match it. It is not safe for concurrent use.
+type Dispatcher struct {
+	routes []*route
+}
+
+// NewDispatcher creates a dispatcher without channels.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Add registers a channel receiving the alerts that pass filter.
+func (d *Dispatcher) Add(ch Channel, filter Filter) {
+	d.routes = append(d.routes, &route{channel: ch, filter: filter, lastSent: make(map[string]time.Time)})
+}
+
+// Len returns the number of registered channels.
+func (d *Dispatcher) Len() int {
+	return len(d.routes)
+}
+
+// Dispatch sends an alert to every matching channel outside its cooldown. A failing channel is logged
+// and doesn't stop the others.
+func (d *Dispatcher) Dispatch(ctx context.Context, alert Alert) {
+	now := time.Now()
+	for _, r := range d.routes {
+		if !r.filter.Matches(alert) || !r.allow(alert, now) {
+			continue
+		}
+		if err := r.channel.Send(ctx, alert); err != nil {
+			slog.Error("Failed to send an alert", "channel", r.channel.Name(), "symbol", alert.Spread.UnifiedSymbol, "error", err)
+		}
+	}
+}
+
+// Handle decodes a message and dispatches it. Undecodable messages return an error so they can be rejected.
+func (d *Dispatcher) Handle(ctx context.Context, body []byte) error {
+	alert, ok, err := Decode(body)
+	if err != nil {
+		return err
+	}
+	if ok {
+		d.Dispatch(ctx, alert)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/messaging"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity is the urgency of a push notification, mapped to each provider's priority scale.
+type Severity int
+
+// Severity levels, from least to most urgent.
+const (
+	SeverityLow    Severity = iota // Closed opportunities
+	SeverityNormal                 // Spreads below the high threshold
+	SeverityHigh                   // Spreads at or above the high threshold
+	SeverityUrgent                 // Spreads at or above the urgent threshold; may bypass quiet hours
+)
+
+// SeverityThresholds maps entry spreads, in percent, to severities.
+type SeverityThresholds struct {
+	High   float64
+	Urgent float64
+}
+
+// For returns the severity of an alert.
+func (t SeverityThresholds) For(alert Alert) Severity {
+	switch {
+	case alert.EventType == lifecycle.EventClosed:
+		return SeverityLow
+	case alert.EventType == messaging.EventFundingDivergence || alert.Report != nil:
+		return SeverityNormal // The entry spread thresholds don't apply
+	case t.Urgent > 0 && alert.Spread.EntrySpread >= t.Urgent:
+		return SeverityUrgent
+	case t.High > 0 && alert.Spread.EntrySpread >= t.High:
+		return SeverityHigh
+	default:
+		return SeverityNormal
+	}
+}
+
+// pushoverPriorities and ntfyPriorities map severities to each provider's priority.
+var (
+	pushoverPriorities = map[Severity]int{SeverityLow: -1, SeverityNormal: 0, SeverityHigh: 1, SeverityUrgent: 2}
+	ntfyPriorities     = map[Severity]int{SeverityLow: 2, SeverityNormal: 3, SeverityHigh: 4, SeverityUrgent: 5}
+)
+
+// pushoverURL is the Pushover messages endpoint.
+const pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// Pushover sends alerts as Pushover notifications.
+type Pushover struct {
+	templated
+	client     *http.Client
+	appToken   string
+	userKey    string
+	thresholds SeverityThresholds
+}
+
+// NewPushover creates a channel notifying the Pushover user (or group) userKey through the application appToken.
+func NewPushover(appToken, userKey string, thresholds SeverityThresholds) *Pushover {
+	return &Pushover{client: &http.Client{Timeout: 10 * time.Second}, appToken: appToken, userKey: userKey, thresholds: thresholds}
+}
+
+// Name identifies the channel in logs.
+func (p *Pushover) Name() string {
+	return "pushover"
+}
+
+// Send posts the alert. Urgent alerts use Pushover's emergency priority, repeating every minute for up to
+// an hour until acknowledged.
+func (p *Pushover) Send(ctx context.Context, alert Alert) error {
+	priority := pushoverPriorities[p.thresholds.For(alert)]
+	form := url.Values{
+		"token":    {p.appToken},
+		"user":     {p.userKey},
+		"title":    {alertHeadline(alert)},
+		"message":  {p.render(alert, pushMessage)},
+		"priority": {strconv.Itoa(priority)},
+	}
+	if priority == 2 {
+		form.Set("retry", "60")
+		form.Set("expire", "3600")
+	}
+	if chart := ChartURL(alert.Spread.ExchangeShort, alert.Spread.UnifiedSymbol); chart != "" {
+		form.Set("url", chart)
+		form.Set("url_title", "Chart")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pushoverURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doPush(p.client, req, "Pushover")
+}
+
+// Ntfy sends alerts to an ntfy topic (ntfy.sh or a self-hosted server).
+type Ntfy struct {
+	templated
+	client     *http.Client
+	server     string
+	topic      string
+	token      string
+	thresholds SeverityThresholds
+}
+
+// NewNtfy creates a channel publishing to topic on server. token is optional, for protected topics.
+func NewNtfy(server, topic, token string, thresholds SeverityThresholds) *Ntfy {
+	return &Ntfy{client: &http.Client{Timeout: 10 * time.Second}, server: strings.TrimSuffix(server, "/"), topic: topic, token: token, thresholds: thresholds}
+}
+
+// Name identifies the channel in logs.
+func (n *Ntfy) Name() string {
+	return "ntfy:" + n.topic
+}
+
+// Send publishes the alert with a priority matching its severity.
+func (n *Ntfy) Send(ctx context.Context, alert Alert) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.server+"/"+n.topic, strings.NewReader(n.render(alert, pushMessage)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", alertHeadline(alert))
+	req.Header.Set("Priority", strconv.Itoa(ntfyPriorities[n.thresholds.For(alert)]))
+	if chart := ChartURL(alert.Spread.ExchangeShort, alert.Spread.UnifiedSymbol); chart != "" {
+		req.Header.Set("Click", chart)
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+	return doPush(n.client, req, "ntfy")
+}
+
+// pushMessage renders the short body of a push notification.
+func pushMessage(alert Alert) string {
+	if alert.Report != nil {
+		return alert.Report.Summary()
+	}
+	s := alert.Spread
+	msg := fmt.Sprintf("Sell on %s, buy on %s\nEntry %.3f%%, exit %.3f%%", s.ExchangeShort, s.ExchangeLong, s.EntrySpread, s.ExitSpread)
+	if s.FundingSpread8h != nil {
+		msg += fmt.Sprintf("\nFunding (8h) %.4f%%", *s.FundingSpread8h)
+	}
+	if alert.FundingAPR != 0 {
+		msg += fmt.Sprintf(", annualized %.1f%%", alert.FundingAPR)
+	}
+	return msg
+}
+
+// doPush sends a push request and checks the response status.
+func doPush(client *http.Client, req *http.Request, provider string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", provider, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s rejected the notification: %s", provider, resp.Status)
+	}
+	return nil
+}
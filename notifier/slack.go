@@ -0,0 +1,180 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// slackPostMessageURL is the Web API method used with bot tokens.
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// Slack sends alerts formatted with Block Kit, either through an incoming webhook or as a bot posting to a channel.
+type Slack struct {
+	templated
+	client     *http.Client
+	webhookURL string
+	botToken   string
+	channelID  string
+}
+
+// NewSlackWebhook creates a channel posting to a Slack incoming webhook.
+func NewSlackWebhook(webhookURL string) *Slack {
+	return &Slack{client: &http.Client{Timeout: 10 * time.Second}, webhookURL: webhookURL}
+}
+
+// NewSlackBot creates a channel posting to channelID as the bot with botToken.
+func NewSlackBot(botToken, channelID string) *Slack {
+	return &Slack{client: &http.Client{Timeout: 10 * time.Second}, botToken: botToken, channelID: channelID}
+}
+
+// Name identifies the channel in logs without leaking the webhook token.
+func (s *Slack) Name() string {
+	if s.webhookURL != "" {
+		return "slack:webhook"
+	}
+	return "slack:" + s.channelID
+}
+
+// Send posts the alert. When Slack rate-limits the request, it waits once for the requested time and retries.
+func (s *Slack) Send(ctx context.Context, alert Alert) error {
+	msg := map[string]any{
+		"text":   FormatPlain(alert), // Shown in notifications and clients without Block Kit
+		"blocks": slackBlocks(alert, s.render(alert, slackDirection)),
+	}
+	if s.webhookURL == "" {
+		msg["channel"] = s.channelID
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := s.post(ctx, body)
+		if err == nil || retryAfter == 0 || attempt > 0 {
+			return err
+		}
+		select {
+		case <-time.After(retryAfter):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// post sends the message, returning the retry delay when rate-limited.
+func (s *Slack) post(ctx context.Context, body []byte) (time.Duration, error) {
+	url := s.webhookURL
+	if url == "" {
+		url = slackPostMessageURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if s.botToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.botToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send Slack message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return time.Duration(max(seconds, 1)) * time.Second, fmt.Errorf("slack rate limit exceeded")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("slack rejected the message: %s", resp.Status)
+	}
+	if s.webhookURL != "" {
+		return 0, nil // Webhooks answer with a plain "ok"
+	}
+
+	// The Web API reports errors in the body with a 200 status
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode Slack response: %w", err)
+	}
+	if !result.OK {
+		return 0, fmt.Errorf("slack rejected the message: %s", result.Error)
+	}
+	return 0, nil
+}
+
+// slackBlocks renders an alert as Block Kit blocks: a header, the message text, the key numbers as fields,
+// and a chart link.
+func slackBlocks(alert Alert, text string) []map[string]any {
+	s := alert.Spread
+	title := alertTitle(alert.EventType)
+
+	mrkdwn := func(text string) map[string]any { return map[string]any{"type": "mrkdwn", "text": text} }
+	if r := alert.Report; r != nil {
+		return []map[string]any{
+			{"type": "header", "text": map[string]any{"type": "plain_text", "text": r.Title()}},
+			{"type": "section", "text": mrkdwn("```" + r.Text() + "```")},
+		}
+	}
+	fields := []map[string]any{
+		mrkdwn(fmt.Sprintf("*Entry spread*\n%.3f%%", s.EntrySpread)),
+		mrkdwn(fmt.Sprintf("*Exit spread*\n%.3f%%", s.ExitSpread)),
+	}
+	if s.FundingSpread8h != nil {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Funding (8h)*\n%.4f%%", *s.FundingSpread8h)))
+	}
+	if alert.FundingAPR != 0 {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Funding (annualized)*\n%.1f%%", alert.FundingAPR)))
+	}
+	if s.ExpectedPnL24h != nil {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*Expected PnL (%.0fh)*\n%.3f%%", s.PnLHorizonHours, *s.ExpectedPnL24h)))
+	}
+	if s.VolumeUSD > 0 {
+		fields = append(fields, mrkdwn("*Volume (24h)*\n"+formatUSD(s.VolumeUSD)))
+	}
+
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": title + ": " + s.UnifiedSymbol}},
+		{"type": "section", "text": mrkdwn(text)},
+		{"type": "section", "fields": fields},
+	}
+	if chart := ChartURL(s.ExchangeShort, s.UnifiedSymbol); chart != "" {
+		blocks = append(blocks, map[string]any{"type": "context", "elements": []map[string]any{mrkdwn("<" + chart + "|Chart>")}})
+	}
+	return blocks
+}
+
+// slackDirection renders the trade direction with links to both exchanges.
+func slackDirection(alert Alert) string {
+	s := alert.Spread
+	return "Sell on " + slackLink(s.ExchangeShort, s.UnifiedSymbol) + ", buy on " + slackLink(s.ExchangeLong, s.LongSymbol())
+}
+
+// slackLink links an exchange name to its trading page, if known.
+func slackLink(exchange, unifiedSymbol string) string {
+	url := ExchangeURL(exchange, unifiedSymbol)
+	if url == "" {
+		return exchange
+	}
+	return "<" + url + "|" + exchange + ">"
+}
+
+// FormatPlain renders a one-line plain-text summary of an alert.
+func FormatPlain(alert Alert) string {
+	if alert.Report != nil {
+		return alert.Report.Title() + ": " + alert.Report.Summary()
+	}
+	s := alert.Spread
+	title := alertTitle(alert.EventType)
+	return fmt.Sprintf("%s: %s, sell on %s, buy on %s, entry %.3f%%", title, s.UnifiedSymbol, s.ExchangeShort, s.ExchangeLong, s.EntrySpread)
+}
@@ -0,0 +1,161 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Subscription is the alert filter a Telegram chat set up by talking to the bot.
+type Subscription struct {
+	ChatID         string    `json:"chat_id"`
+	Symbols        []string  `json:"symbols,omitempty"`          // Base assets, pairs or unified symbols; empty matches all
+	MinEntrySpread *float64  `json:"min_entry_spread,omitempty"` // Minimum entry spread, in percent; nil uses the default
+	MutedUntil     time.Time `json:"muted_until,omitzero"`       // No alerts are sent before this time
+}
+
+// SubscriptionStore persists subscriptions, so they survive restarts.
+type SubscriptionStore interface {
+	Load(ctx context.Context) ([]Subscription, error)
+	Save(ctx context.Context, sub Subscription) error
+	Delete(ctx context.Context, chatID string) error
+}
+
+// RedisSubscriptionStore keeps subscriptions in a Redis hash, one JSON value per chat ID.
+type RedisSubscriptionStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisSubscriptionStore creates a store of the subscriptions in the hash at key.
+func NewRedisSubscriptionStore(client *redis.Client, key string) *RedisSubscriptionStore {
+	return &RedisSubscriptionStore{client: client, key: key}
+}
+
+// Load returns every stored subscription. Malformed values are skipped.
+func (s *RedisSubscriptionStore) Load(ctx context.Context) ([]Subscription, error) {
+	values, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subscriptions from Redis key %s: %w", s.key, err)
+	}
+	subs := make([]Subscription, 0, len(values))
+	for chatID, value := range values {
+		var sub Subscription
+		if err := json.Unmarshal([]byte(value), &sub); err != nil {
+			slog.Warn("Failed to unmarshal subscription from Redis", "chat_id", chatID, "error", err)
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// Save stores a subscription, replacing the chat's previous one.
+func (s *RedisSubscriptionStore) Save(ctx context.Context, sub Subscription) error {
+	value, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+	if err := s.client.HSet(ctx, s.key, sub.ChatID, value).Err(); err != nil {
+		return fmt.Errorf("failed to save subscription to Redis: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a chat's subscription.
+func (s *RedisSubscriptionStore) Delete(ctx context.Context, chatID string) error {
+	if err := s.client.HDel(ctx, s.key, chatID).Err(); err != nil {
+		return fmt.Errorf("failed to delete subscription from Redis: %w", err)
+	}
+	return nil
+}
+
+// Subscriptions holds the subscription of every chat, written through to a store. It is safe for concurrent use.
+type Subscriptions struct {
+	mu    sync.RWMutex
+	store SubscriptionStore
+	subs  map[string]Subscription // Keyed by chat ID
+}
+
+// LoadSubscriptions creates the subscriptions from those in store.
+func LoadSubscriptions(ctx context.Context, store SubscriptionStore) (*Subscriptions, error) {
+	stored, err := store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs := make(map[string]Subscription, len(stored))
+	for _, sub := range stored {
+		subs[sub.ChatID] = sub
+	}
+	slog.Info("Loaded Telegram subscriptions", "count", len(subs))
+	return &Subscriptions{store: store, subs: subs}, nil
+}
+
+// Get returns a chat's subscription.
+func (s *Subscriptions) Get(chatID string) (Subscription, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sub, ok := s.subs[chatID]
+	return sub, ok
+}
+
+// Update changes a chat's subscription with update, creating it if needed, and stores it. The subscription
+// is left unchanged if it can't be stored.
+func (s *Subscriptions) Update(ctx context.Context, chatID string, update func(sub *Subscription)) (Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[chatID]
+	if !ok {
+		sub = Subscription{ChatID: chatID}
+	}
+	sub.Symbols = slices.Clone(sub.Symbols)
+	update(&sub)
+	if err := s.store.Save(ctx, sub); err != nil {
+		return Subscription{}, err
+	}
+	s.subs[chatID] = sub
+	return sub, nil
+}
+
+// Remove deletes a chat's subscription.
+func (s *Subscriptions) Remove(ctx context.Context, chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.store.Delete(ctx, chatID); err != nil {
+		return err
+	}
+	delete(s.subs, chatID)
+	return nil
+}
+
+// Matching returns the chats whose subscription lets an alert through at now, those without a threshold
+// using defaultMinSpread.
+func (s *Subscriptions) Matching(alert Alert, defaultMinSpread float64, now time.Time) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var chats []string
+	for _, chatID := range slices.Sorted(maps.Keys(s.subs)) {
+		sub := s.subs[chatID]
+		if now.Before(sub.MutedUntil) || !sub.Filter(defaultMinSpread).Matches(alert) {
+			continue
+		}
+		chats = append(chats, chatID)
+	}
+	return chats
+}
+
+// Filter returns the subscription's symbol and spread filter, defaultMinSpread applying without a threshold.
+func (sub Subscription) Filter(defaultMinSpread float64) Filter {
+	filter := Filter{Symbols: sub.Symbols, MinEntrySpread: defaultMinSpread}
+	if sub.MinEntrySpread != nil {
+		filter.MinEntrySpread = *sub.MinEntrySpread
+	}
+	return filter
+}
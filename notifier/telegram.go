@@ -0,0 +1,162 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// telegramAPI is the Telegram Bot API base URL.
+const telegramAPI = "https://api.telegram.org"
+
+// telegramClient calls the Bot API as a bot.
+type telegramClient struct {
+	client *http.Client
+	token  string
+}
+
+// newTelegramClient creates a client of the bot with token.
+func newTelegramClient(token string) telegramClient {
+	return telegramClient{client: &http.Client{Timeout: 10 * time.Second}, token: token}
+}
+
+// Telegram sends alerts to a Telegram chat through a bot.
+type Telegram struct {
+	templated
+	telegramClient
+	chatID string
+}
+
+// NewTelegram creates a channel posting as the bot with token to chatID (a chat ID or "@channelname").
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{telegramClient: newTelegramClient(token), chatID: chatID}
+}
+
+// Name identifies the channel in logs.
+func (t *Telegram) Name() string {
+	return "telegram:" + t.chatID
+}
+
+// telegramResponse is the envelope of Bot API responses.
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// Send posts the alert as an HTML message. When Telegram rate-limits the bot, it waits once for the
+// requested time and retries.
+func (t *Telegram) Send(ctx context.Context, alert Alert) error {
+	return t.sendMessage(ctx, t.chatID, t.render(alert, FormatHTML))
+}
+
+// sendMessage posts an HTML message to a chat. When Telegram rate-limits the bot, it waits once for the
+// requested time and retries.
+func (c telegramClient) sendMessage(ctx context.Context, chatID, text string) error {
+	params := map[string]any{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.call(ctx, "sendMessage", params, nil)
+		if err == nil || retryAfter == 0 || attempt > 0 {
+			return err
+		}
+		select {
+		case <-time.After(time.Duration(retryAfter) * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// call calls a Bot API method, decoding its result into result unless nil. It returns the retry delay in
+// seconds when rate-limited.
+func (c telegramClient) call(ctx context.Context, method string, params map[string]any, result any) (int, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal Telegram %s request: %w", method, err)
+	}
+	url := telegramAPI + "/bot" + c.token + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call Telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var response telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("failed to decode Telegram response (status %s): %w", resp.Status, err)
+	}
+	if !response.OK {
+		return response.Parameters.RetryAfter, fmt.Errorf("telegram rejected the %s request: %s", method, response.Description)
+	}
+	if result != nil {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return 0, fmt.Errorf("failed to decode Telegram %s result: %w", method, err)
+		}
+	}
+	return 0, nil
+}
+
+// FormatHTML renders an alert as Telegram HTML: symbol, direction with links to both exchanges,
+// spreads, funding and expected PnL.
+func FormatHTML(alert Alert) string {
+	if alert.Report != nil {
+		return "<b>" + html.EscapeString(alert.Report.Title()) + "</b>\n<pre>" + html.EscapeString(alert.Report.Text()) + "</pre>"
+	}
+	s := alert.Spread
+	title := alertTitle(alert.EventType)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>%s: %s</b>\n", html.EscapeString(title), html.EscapeString(s.UnifiedSymbol))
+	fmt.Fprintf(&b, "Sell on %s, buy on %s\n", htmlLink(s.ExchangeShort, s.UnifiedSymbol), htmlLink(s.ExchangeLong, s.LongSymbol()))
+	fmt.Fprintf(&b, "Entry spread: <b>%.3f%%</b> (conservative %.3f%%)\n", s.EntrySpread, s.ConservativeEntrySpread)
+	fmt.Fprintf(&b, "Exit spread: %.3f%%\n", s.ExitSpread)
+	if s.FundingSpread8h != nil {
+		fmt.Fprintf(&b, "Funding spread (8h): %.4f%%", *s.FundingSpread8h)
+		if s.FundingRateShort != nil && s.FundingRateLong != nil {
+			fmt.Fprintf(&b, " (short %.4f%%, long %.4f%%)", s.FundingRateShort.ShortRate()*100, s.FundingRateLong.Rate*100)
+		}
+		b.WriteString("\n")
+	}
+	if alert.FundingAPR != 0 {
+		fmt.Fprintf(&b, "Funding spread (annualized): <b>%.1f%%</b>\n", alert.FundingAPR)
+	}
+	if s.ExpectedPnL24h != nil {
+		fmt.Fprintf(&b, "Expected PnL (%.0fh): %.3f%%\n", s.PnLHorizonHours, *s.ExpectedPnL24h)
+	}
+	if alert.DurationSeconds > 0 {
+		fmt.Fprintf(&b, "Open for %s\n", (time.Duration(alert.DurationSeconds) * time.Second).String())
+	}
+	if alert.ExitReason != "" {
+		fmt.Fprintf(&b, "Exit reason: <b>%s</b>\n", html.EscapeString(alert.ExitReason))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// htmlLink links an exchange name to its trading page, if known.
+func htmlLink(exchange, unifiedSymbol string) string {
+	name := html.EscapeString(exchange)
+	url := ExchangeURL(exchange, unifiedSymbol)
+	if url == "" {
+		return name
+	}
+	return `<a href="` + html.EscapeString(url) + `">` + name + `</a>`
+}
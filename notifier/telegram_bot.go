@@ -0,0 +1,256 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegramPollTimeout is how long a getUpdates call waits for new messages.
+const telegramPollTimeout = 30 * time.Second
+
+// telegramHelp lists the bot's commands.
+const telegramHelp = `Commands:
+/subscribe BTC ETH: get alerts for these base assets, pairs or symbols (no argument: every symbol)
+/unsubscribe BTC: stop the alerts of these symbols (no argument: stop every alert)
+/threshold 0.5: only get alerts with an entry spread of at least 0.5% (no argument: the default)
+/mute 1h: pause the alerts for a while, e.g., 30m or 2h
+/unmute: resume the alerts
+/status: show your settings`
+
+// telegramUpdate is an incoming update of the bot: only messages are requested.
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// TelegramBot answers the commands chats send the bot to manage their subscription (see telegramHelp).
+type TelegramBot struct {
+	telegramClient
+	subs             *Subscriptions
+	defaultMinSpread float64 // Threshold of subscriptions without their own
+}
+
+// NewTelegramBot creates a bot with token managing subs.
+func NewTelegramBot(token string, subs *Subscriptions, defaultMinSpread float64) *TelegramBot {
+	client := telegramClient{client: &http.Client{Timeout: telegramPollTimeout + 10*time.Second}, token: token}
+	return &TelegramBot{telegramClient: client, subs: subs, defaultMinSpread: defaultMinSpread}
+}
+
+// Run long-polls the bot's messages and answers the commands. It blocks until ctx is cancelled and should be
+// run in a goroutine.
+func (b *TelegramBot) Run(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		var updates []telegramUpdate
+		params := map[string]any{"offset": offset, "timeout": int(telegramPollTimeout.Seconds()), "allowed_updates": []string{"message"}}
+		if _, err := b.call(ctx, "getUpdates", params, &updates); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Failed to get Telegram updates, retrying", "error", err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil || !strings.HasPrefix(u.Message.Text, "/") {
+				continue
+			}
+			chatID := strconv.FormatInt(u.Message.Chat.ID, 10)
+			reply := b.handle(ctx, chatID, u.Message.Text, time.Now())
+			if err := b.sendMessage(ctx, chatID, reply); err != nil {
+				slog.Error("Failed to answer a Telegram command", "chat_id", chatID, "error", err)
+			}
+		}
+	}
+}
+
+// handle runs a command sent by a chat at now, returning the reply in Telegram HTML.
+func (b *TelegramBot) handle(ctx context.Context, chatID, text string, now time.Time) string {
+	fields := strings.Fields(text)
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@") // Groups address commands as /command@bot
+	args := fields[1:]
+
+	current, subscribed := b.subs.Get(chatID)
+	switch command {
+	case "/start", "/help":
+		return html.EscapeString(telegramHelp)
+	case "/status":
+		return b.status(chatID, now)
+	case "/subscribe":
+		symbols := normalizeSymbols(args)
+		sub, err := b.subs.Update(ctx, chatID, func(sub *Subscription) {
+			if len(symbols) == 0 {
+				sub.Symbols = nil
+				return
+			}
+			for _, symbol := range symbols {
+				if !slices.Contains(sub.Symbols, symbol) {
+					sub.Symbols = append(sub.Symbols, symbol)
+				}
+			}
+		})
+		if err != nil {
+			return b.failed(chatID, command, err)
+		}
+		return "Subscribed to " + html.EscapeString(describeSymbols(sub.Symbols)) + "."
+	case "/unsubscribe":
+		if !subscribed {
+			return "You aren't subscribed."
+		}
+		if len(args) > 0 && len(current.Symbols) == 0 {
+			return "You're subscribed to every symbol: /subscribe to some instead, or /unsubscribe from every alert."
+		}
+		symbols := normalizeSymbols(args)
+		remaining := slices.DeleteFunc(slices.Clone(current.Symbols), func(s string) bool { return slices.Contains(symbols, s) })
+		if len(args) == 0 || len(remaining) == 0 {
+			if err := b.subs.Remove(ctx, chatID); err != nil {
+				return b.failed(chatID, command, err)
+			}
+			return "Unsubscribed from every alert."
+		}
+		if _, err := b.subs.Update(ctx, chatID, func(sub *Subscription) { sub.Symbols = remaining }); err != nil {
+			return b.failed(chatID, command, err)
+		}
+		return "Subscribed to " + html.EscapeString(describeSymbols(remaining)) + "."
+	case "/threshold":
+		if !subscribed {
+			return "You aren't subscribed: send /subscribe first."
+		}
+		var threshold *float64
+		if len(args) > 0 {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "%"), 64)
+			if err != nil || v < 0 {
+				return "The threshold must be a non-negative percentage, e.g., /threshold 0.5"
+			}
+			threshold = &v
+		}
+		sub, err := b.subs.Update(ctx, chatID, func(sub *Subscription) { sub.MinEntrySpread = threshold })
+		if err != nil {
+			return b.failed(chatID, command, err)
+		}
+		return fmt.Sprintf("Alerts need an entry spread of at least %.2f%%.", sub.Filter(b.defaultMinSpread).MinEntrySpread)
+	case "/mute":
+		if !subscribed {
+			return "You aren't subscribed: send /subscribe first."
+		}
+		if len(args) == 0 {
+			return "Say for how long, e.g., /mute 1h"
+		}
+		d, err := time.ParseDuration(args[0])
+		if err != nil || d <= 0 {
+			return "The duration must be positive, e.g., 30m or 2h."
+		}
+		until := now.Add(d)
+		if _, err := b.subs.Update(ctx, chatID, func(sub *Subscription) { sub.MutedUntil = until }); err != nil {
+			return b.failed(chatID, command, err)
+		}
+		return "Muted until " + until.UTC().Format("2006-01-02 15:04 MST") + "."
+	case "/unmute":
+		if !subscribed {
+			return "You aren't subscribed: send /subscribe first."
+		}
+		if _, err := b.subs.Update(ctx, chatID, func(sub *Subscription) { sub.MutedUntil = time.Time{} }); err != nil {
+			return b.failed(chatID, command, err)
+		}
+		return "Alerts resumed."
+	default:
+		return "Unknown command.\n\n" + html.EscapeString(telegramHelp)
+	}
+}
+
+// status describes a chat's subscription.
+func (b *TelegramBot) status(chatID string, now time.Time) string {
+	sub, ok := b.subs.Get(chatID)
+	if !ok {
+		return "You aren't subscribed: send /subscribe to get alerts."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Symbols: %s\n", html.EscapeString(describeSymbols(sub.Symbols)))
+	fmt.Fprintf(&sb, "Threshold: %.2f%%", sub.Filter(b.defaultMinSpread).MinEntrySpread)
+	if sub.MinEntrySpread == nil {
+		sb.WriteString(" (default)")
+	}
+	if now.Before(sub.MutedUntil) {
+		fmt.Fprintf(&sb, "\nMuted until %s", sub.MutedUntil.UTC().Format("2006-01-02 15:04 MST"))
+	}
+	return sb.String()
+}
+
+// failed logs a command that couldn't be saved and returns the reply telling so.
+func (b *TelegramBot) failed(chatID, command string, err error) string {
+	slog.Error("Failed to update a Telegram subscription", "chat_id", chatID, "command", command, "error", err)
+	return "Sorry, your settings couldn't be saved. Please try again later."
+}
+
+// normalizeSymbols upper-cases symbols, as filters compare them as written.
+func normalizeSymbols(args []string) []string {
+	symbols := make([]string, 0, len(args))
+	for _, arg := range args {
+		for _, s := range strings.Split(arg, ",") {
+			if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+				symbols = append(symbols, s)
+			}
+		}
+	}
+	return symbols
+}
+
+// describeSymbols lists a subscription's symbols.
+func describeSymbols(symbols []string) string {
+	if len(symbols) == 0 {
+		return "every symbol"
+	}
+	return strings.Join(symbols, ", ")
+}
+
+// TelegramSubscribers sends each alert to the chats whose subscription lets it through, rather than to a
+// fixed list of chats.
+type TelegramSubscribers struct {
+	templated
+	telegramClient
+	subs             *Subscriptions
+	defaultMinSpread float64 // Threshold of subscriptions without their own
+}
+
+// NewTelegramSubscribers creates a channel posting as the bot with token to the chats of subs.
+func NewTelegramSubscribers(token string, subs *Subscriptions, defaultMinSpread float64) *TelegramSubscribers {
+	return &TelegramSubscribers{telegramClient: newTelegramClient(token), subs: subs, defaultMinSpread: defaultMinSpread}
+}
+
+// Name identifies the channel in logs.
+func (t *TelegramSubscribers) Name() string {
+	return "telegram:subscribers"
+}
+
+// Send posts the alert as an HTML message to every matching chat. A failing chat doesn't stop the others.
+func (t *TelegramSubscribers) Send(ctx context.Context, alert Alert) error {
+	chats := t.subs.Matching(alert, t.defaultMinSpread, time.Now())
+	if len(chats) == 0 {
+		return nil
+	}
+	text := t.render(alert, FormatHTML)
+	var errs []error
+	for _, chatID := range chats {
+		if err := t.sendMessage(ctx, chatID, text); err != nil {
+			errs = append(errs, fmt.Errorf("chat %s: %w", chatID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
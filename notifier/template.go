@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the helpers available in alert templates, e.g.:
+//
+//	{{title .EventType}} {{.Spread.UnifiedSymbol}}: {{pct .Spread.EntrySpread}}
+//	Sell on {{.Spread.ExchangeShort}} {{exchangeURL .Spread.ExchangeShort .Spread.UnifiedSymbol}}
+//	{{with .Spread.FundingSpread8h}}Funding: {{pct4 .}}{{end}}
+var templateFuncs = template.FuncMap{
+	"title":       alertTitle,
+	"pct":         func(v any) string { return fmt.Sprintf("%.3f%%", derefFloat(v)) },
+	"pct4":        func(v any) string { return fmt.Sprintf("%.4f%%", derefFloat(v)) },
+	"rate":        func(v float64) string { return fmt.Sprintf("%.4f%%", v*100) }, // Raw funding rates are fractions
+	"usd":         formatUSD,
+	"exchangeURL": ExchangeURL,
+	"chartURL":    ChartURL,
+	"duration":    func(seconds float64) string { return (time.Duration(seconds) * time.Second).String() },
+	"upper":       strings.ToUpper,
+	"lower":       strings.ToLower,
+}
+
+// derefFloat accepts float64 and *float64 (optional Spread fields), treating nil as 0.
+func derefFloat(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case *float64:
+		if v != nil {
+			return *v
+		}
+	}
+	return 0
+}
+
+// ParseTemplate parses an alert template. The template is executed with the Alert as data, so it can use
+// {{.EventType}}, {{.DurationSeconds}}, {{.ExitReason}}, {{.FundingAPR}} and every Spread field, plus the
+// helpers in templateFuncs.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// templated lets a channel replace its built-in message format with a user template.
+type templated struct {
+	tmpl *template.Template
+}
+
+// SetTemplate replaces the channel's message text with t. A nil template restores the built-in format.
+func (t *templated) SetTemplate(tmpl *template.Template) {
+	t.tmpl = tmpl
+}
+
+// render executes the template, falling back to the built-in format if none is set or it fails. Summary
+// reports always use the built-in format, as templates render spreads.
+func (t *templated) render(alert Alert, builtin func(Alert) string) string {
+	if t.tmpl == nil || alert.Report != nil {
+		return builtin(alert)
+	}
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, alert); err != nil {
+		slog.Error("Failed to render an alert template, using the built-in format", "template", t.tmpl.Name(), "error", err)
+		return builtin(alert)
+	}
+	return strings.TrimSpace(b.String())
+}
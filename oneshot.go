@@ -0,0 +1,367 @@
+package main
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/fx"
+	"cex-price-diff-notifications/marketdata"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// market is the data of a fetch from every exchange, for the one-shot commands and the terminal UI.
+type market struct {
+	binance *adapters.BinanceAdapter
+	mexc    *adapters.MexcAdapter
+	gmx     *adapters.GmxAdapter                      // Nil unless GMX is enabled
+	tickers map[string]map[string]shared.TickerBidAsk // Keyed by unified symbol and exchange
+	status  map[string]exchangeStatus                 // Outcome of the last ticker fetch, keyed by exchange
+
+	// Ticker payloads of the last fetch, decoded into again by the next one
+	binanceDtos []adapters.BinanceBookTickerDto
+	mexcDtos    []adapters.MexcTickerDto
+}
+
+// exchangeStatus is the outcome of an exchange's last ticker fetch.
+type exchangeStatus struct {
+	tickers  int
+	duration time.Duration
+	err      error
+	at       time.Time
+}
+
+// newMarket creates the adapters of every exchange.
+func newMarket(cfg *config.Config) *market {
+	m := &market{binance: adapters.NewBinanceAdapter(), mexc: adapters.NewMexcAdapter()}
+	m.binance.SetCredentials(cfg.BinanceAPIKey, cfg.BinanceAPISecret)
+	m.mexc.SetCredentials(cfg.MexcAPIKey, cfg.MexcAPISecret)
+	if cfg.GmxEnabled {
+		m.gmx = adapters.NewGmxAdapter()
+	}
+	return m
+}
+
+// exchanges returns the names of the exchanges a market fetches.
+func exchanges(cfg *config.Config) []string {
+	if cfg.GmxEnabled {
+		return []string{"Binance", "Mexc", "GMX"}
+	}
+	return []string{"Binance", "Mexc"}
+}
+
+// fetchMarket creates a market and fetches it once.
+func fetchMarket(ctx context.Context, cfg *config.Config, withFunding bool) (*market, error) {
+	m := newMarket(cfg)
+	if err := m.fetch(ctx, withFunding); err != nil {
+		return nil, err
+	}
+	for _, st := range m.status {
+		if st.err != nil {
+			slog.Warn("Partial market data", "error", st.err)
+		}
+	}
+	return m, nil
+}
+
+// fetch replaces the tickers with fresh ones from every exchange, after updating 24h volumes and, if
+// requested, funding rates, like a scanner cycle. It fails only if no exchange returned tickers.
+func (m *market) fetch(ctx context.Context, withFunding bool) error {
+	store := marketdata.NewStore()
+	status := make(map[string]exchangeStatus)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := m.binance.UpdateVolumes(ctx); err != nil {
+			slog.Warn("Failed to update Binance volumes", "error", err)
+		}
+		if withFunding {
+			if _, err := m.binance.UpdateFundingRates(ctx); err != nil {
+				slog.Warn("Failed to update Binance funding rates", "error", err)
+			}
+		}
+		dtos, duration, err := m.binance.GetTickers(ctx, m.binanceDtos)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			status["Binance"] = exchangeStatus{err: fmt.Errorf("failed to get Binance tickers: %w", err), at: time.Now()}
+			return
+		}
+		m.binanceDtos = dtos
+		store.Set("Binance", m.binance.Tickers(dtos), time.Now())
+		status["Binance"] = exchangeStatus{tickers: len(dtos), duration: duration, at: time.Now()}
+	}()
+	go func() {
+		defer wg.Done()
+		if withFunding {
+			if _, err := m.mexc.UpdateFundingRates(ctx); err != nil {
+				slog.Warn("Failed to update Mexc funding rates", "error", err)
+			}
+		}
+		dtos, duration, err := m.mexc.GetTickers(ctx, m.mexcDtos)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			status["Mexc"] = exchangeStatus{err: fmt.Errorf("failed to get Mexc tickers: %w", err), at: time.Now()}
+			return
+		}
+		m.mexcDtos = dtos
+		m.mexc.ApplyTickerFundingRates(dtos)
+		store.Set("Mexc", m.mexc.Tickers(dtos), time.Now())
+		status["Mexc"] = exchangeStatus{tickers: len(dtos), duration: duration, at: time.Now()}
+	}()
+	if m.gmx != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tickers, duration, err := m.gmx.GetTickers(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				status["GMX"] = exchangeStatus{err: fmt.Errorf("failed to get GMX tickers: %w", err), at: time.Now()}
+				return
+			}
+			store.Set("GMX", tickers, time.Now())
+			status["GMX"] = exchangeStatus{tickers: len(tickers), duration: duration, at: time.Now()}
+		}()
+	}
+	wg.Wait()
+
+	tickers := store.Snapshot().Tickers
+	m.tickers, m.status = tickers, status
+	if len(tickers) == 0 {
+		errs := []error{errors.New("no tickers fetched")}
+		for _, st := range status {
+			errs = append(errs, st.err)
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// spreads calculates the scored spreads of the fetched market, best first.
+func (m *market) spreads(cfg *config.Config, opts arbitrage.Options) []arbitrage.Spread {
+	tickers, _ := arbitrage.FilterInvalidTickers(m.tickers, cfg.MaxPriceDevPct)
+	fxRates := fx.RatesFromTickers(tickers, cfg.QuoteCurrencies)
+	spreads := arbitrage.CalculateSpreads(tickers, fundingSnapshot(m.binance, m.mexc, m.gmx), fxRates, opts)
+	arbitrage.ApplyIndexPrices(spreads, tickers)
+	params := paramSet(cfg)
+	arbitrage.ApplyExpectedPnL(spreads, params, cfg.HoldingHorizon)
+	arbitrage.ApplyTargetNotional(spreads, params)
+	arbitrage.ApplyScores(spreads, arbitrage.WeightedScorer{Weights: arbitrage.ScoreWeights(cfg.ScoreWeights)})
+	return spreads
+}
+
+// runTop fetches the market once and prints the best spreads.
+func runTop(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("top", flag.ContinueOnError)
+	count := fs.Int("n", 20, "number of spreads to print (0 prints all)")
+	minEntry := fs.Float64("min", cfg.MinEntrySpreadPct, "minimum entry spread, in percent")
+	withFunding := fs.Bool("funding", true, "fetch funding rates (slower)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m, err := fetchMarket(context.Background(), cfg, *withFunding)
+	if err != nil {
+		return err
+	}
+	params := paramSet(cfg)
+	params.Defaults.MinEntrySpreadPct = *minEntry
+	spreads, _, _ := arbitrage.SelectForPublishing(m.spreads(cfg, spreadOptions(cfg)), params, *count)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "SYMBOL\tSELL\tBUY\tENTRY %\tEXIT %\tFUNDING 8H %\tPNL 24H %\tOUTLIER %\tVOLUME USD\tSCORE\t")
+	for _, s := range spreads {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.3f\t%.3f\t%s\t%s\t%s\t%.0f\t%.3f\t\n",
+			s.UnifiedSymbol, s.ExchangeShort, s.ExchangeLong, s.EntrySpread, s.ExitSpread,
+			optionalPct(s.FundingSpread8h), optionalPct(s.ExpectedPnL24h), outlier(s), s.VolumeUSD, s.Score)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d spreads at or above %.3f%% across %d symbols\n", len(spreads), *minEntry, len(m.tickers))
+	return nil
+}
+
+// runSymbols fetches the market once and prints the unified symbols with each exchange's native symbol.
+func runSymbols(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("symbols", flag.ContinueOnError)
+	exchange := fs.String("exchange", "", "only list symbols traded on this exchange")
+	common := fs.Bool("common", false, "only list symbols traded on every exchange")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	exchanges := exchanges(cfg)
+	if *exchange != "" {
+		i := slices.IndexFunc(exchanges, func(e string) bool { return strings.EqualFold(e, *exchange) })
+		if i < 0 {
+			return fmt.Errorf("unknown exchange %q, expected one of %s", *exchange, strings.Join(exchanges, ", "))
+		}
+		*exchange = exchanges[i]
+	}
+
+	m, err := fetchMarket(context.Background(), cfg, false)
+	if err != nil {
+		return err
+	}
+	symbols := make([]string, 0, len(m.tickers))
+	for symbol, byExchange := range m.tickers {
+		if *common && len(byExchange) < len(exchanges) {
+			continue
+		}
+		if _, ok := byExchange[*exchange]; *exchange != "" && !ok {
+			continue
+		}
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\t"+strings.ToUpper(strings.Join(exchanges, "\t")))
+	counts := make(map[string]int)
+	for _, symbol := range symbols {
+		row := []string{symbol}
+		for _, e := range exchanges {
+			native := "-"
+			if t, ok := m.tickers[symbol][e]; ok {
+				native = t.Symbol
+				counts[e]++
+			}
+			row = append(row, native)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d symbols (Binance %d, Mexc %d)\n", len(symbols), counts["Binance"], counts["Mexc"])
+	return nil
+}
+
+// runCheck fetches the market once and prints the tickers, funding rates and spreads of one symbol in
+// every direction.
+func runCheck(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	withFunding := fs.Bool("funding", true, "fetch funding rates (slower)")
+	// The symbol may come before or after the flags
+	var symbol string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		symbol, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if symbol == "" {
+		symbol = fs.Arg(0)
+	}
+	if symbol == "" {
+		return errors.New(`usage: check <symbol>, e.g., "check BTC" or "check BTC/USDT:PERP"`)
+	}
+	symbol = strings.ToUpper(symbol)
+	if !strings.Contains(symbol, "/") {
+		symbol = shared.PerpSymbol(symbol, shared.DefaultQuoteCurrency()).String()
+	}
+
+	m, err := fetchMarket(context.Background(), cfg, *withFunding)
+	if err != nil {
+		return err
+	}
+	byExchange, ok := m.tickers[symbol]
+	if !ok {
+		return fmt.Errorf("no exchange lists %s", symbol)
+	}
+	exchanges := make([]string, 0, len(byExchange))
+	for e := range byExchange {
+		exchanges = append(exchanges, e)
+	}
+	slices.Sort(exchanges)
+	now := time.Now()
+
+	fmt.Printf("%s\n\nTickers\n", symbol)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "EXCHANGE\tSYMBOL\tBID\tASK\tBID QTY\tASK QTY\tBID-ASK %\tVOLUME USD\tAGE\t")
+	for _, e := range exchanges {
+		t := byExchange[e]
+		fmt.Fprintf(w, "%s\t%s\t%g\t%g\t%g\t%g\t%.4f\t%.0f\t%s\t\n", e, t.Symbol, t.Bid, t.Ask, t.BidQty, t.AskQty,
+			(t.Ask-t.Bid)/t.Bid*100, t.VolumeUSD, now.Sub(t.Timestamp).Round(time.Millisecond))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nFunding\n")
+	funding := fundingSnapshot(m.binance, m.mexc, m.gmx)
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "EXCHANGE\tRATE %\tINTERVAL\tNEXT SETTLEMENT\t")
+	for _, e := range exchanges {
+		info, ok := funding[e][symbol]
+		if !ok {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t\n", e)
+			continue
+		}
+		next := "-"
+		if info.NextSettleTime > 0 {
+			next = "in " + time.UnixMilli(info.NextSettleTime).Sub(now).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%.4f\t%dh\t%s\t\n", e, info.Rate*100, info.Interval, next)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Every direction is shown, whatever its sign
+	opts := spreadOptions(cfg)
+	opts.AllDirections = true
+	var spreads []arbitrage.Spread
+	for _, s := range m.spreads(cfg, opts) {
+		if s.UnifiedSymbol == symbol {
+			spreads = append(spreads, s)
+		}
+	}
+	fmt.Printf("\nSpreads\n")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "SELL\tBUY\tENTRY %\tCONSERVATIVE %\tEXIT %\tFEES %\tFUNDING 8H %\tPNL 24H %\tSCORE\t")
+	for _, s := range spreads {
+		fmt.Fprintf(w, "%s\t%s\t%.4f\t%.4f\t%.4f\t%.4f\t%s\t%s\t%.3f\t\n", s.ExchangeShort, s.ExchangeLong,
+			s.EntrySpread, s.ConservativeEntrySpread, s.ExitSpread, s.RoundTripFeesPct,
+			optionalPct(s.FundingSpread8h), optionalPct(s.ExpectedPnL24h), s.Score)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(spreads) == 0 {
+		fmt.Println("No spread: the symbol is listed on fewer than two exchanges, or its tickers were filtered out")
+	}
+	return nil
+}
+
+// optionalPct formats an optional percentage, with "-" for nil.
+func optionalPct(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f", *v)
+}
+
+// outlier formats the outlier leg of a spread as its exchange and deviation from the index price, or "-".
+func outlier(s arbitrage.Spread) string {
+	if s.OutlierExchange == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s %+.3f", s.OutlierExchange, s.OutlierDeviationPct)
+}
@@ -0,0 +1,175 @@
+// Package outage detects exchanges whose data can't be trusted: their last successful fetch is too old, or
+// too many of their recent fetches failed. A degraded exchange is excluded from the spread calculation, rather
+// than compared against its last stale prices, until enough consecutive fetches succeed again.
+package outage
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reasons an exchange is degraded.
+const (
+	ReasonStale     = "stale"
+	ReasonErrorRate = "error_rate"
+)
+
+// Options configure a Detector.
+type Options struct {
+	MaxDataAge      time.Duration // Longest time since the last successful fetch (0 disables)
+	ErrorRate       float64       // Failed share of the last ErrorWindow fetches that degrades the exchange (0 disables)
+	ErrorWindow     int           // Number of recent fetches the error rate is measured over
+	RecoveryFetches int           // Consecutive successful fetches before a degraded exchange is included again
+}
+
+// Change is a transition of an exchange in or out of the degraded state.
+type Change struct {
+	Exchange    string    `json:"exchange"`
+	Degraded    bool      `json:"degraded"`
+	Reason      string    `json:"reason,omitempty"` // Why it was degraded: "stale" or "error_rate"
+	ErrorRate   float64   `json:"error_rate"`       // Failed share of the recent fetches
+	LastFetch   time.Time `json:"last_fetch,omitzero"`
+	Since       time.Time `json:"since"` // When it was degraded
+	RecoveredAt time.Time `json:"recovered_at,omitzero"`
+}
+
+// state is the fetch history of an exchange.
+type state struct {
+	lastFetch time.Time // Last successful fetch
+	results   []bool    // Outcomes of the recent fetches, oldest first, true when failed
+	streak    int       // Consecutive successful fetches
+	degraded  bool
+	reason    string
+	since     time.Time
+}
+
+// errorRate returns the failed share of the recent fetches, and whether the window is full.
+func (s *state) errorRate(window int) (float64, bool) {
+	if len(s.results) == 0 {
+		return 0, false
+	}
+	var failed int
+	for _, f := range s.results {
+		if f {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(s.results)), len(s.results) >= window
+}
+
+// Detector tracks the fetches of each exchange. It is safe for concurrent use.
+type Detector struct {
+	opts    Options
+	started time.Time
+
+	mu     sync.Mutex
+	states map[string]*state
+	paused map[string]bool // Exchanges whose fetches are paused, e.g., for maintenance
+}
+
+// NewDetector creates a detector of exchanges that went stale or whose fetches keep failing since now.
+func NewDetector(opts Options, now time.Time) *Detector {
+	opts.ErrorWindow = max(opts.ErrorWindow, 1)
+	opts.RecoveryFetches = max(opts.RecoveryFetches, 1)
+	return &Detector{opts: opts, started: now, states: make(map[string]*state), paused: make(map[string]bool)}
+}
+
+// Track registers an exchange, so it goes stale if it never fetches successfully.
+func (d *Detector) Track(exchange string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.state(exchange)
+}
+
+// Observe records the outcome of a fetch from an exchange, failed if err isn't nil.
+func (d *Detector) Observe(exchange string, err error, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.state(exchange)
+	s.results = append(s.results, err != nil)
+	if len(s.results) > d.opts.ErrorWindow {
+		s.results = s.results[len(s.results)-d.opts.ErrorWindow:]
+	}
+	if err != nil {
+		s.streak = 0
+		return
+	}
+	s.lastFetch = at
+	s.streak++
+}
+
+// SetPaused records whether an exchange's fetches are paused, during which it isn't expected to fetch. Once
+// they resume, its history starts over, as if it had just been registered.
+func (d *Detector) SetPaused(exchange string, paused bool, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if paused == d.paused[exchange] {
+		return
+	}
+	if paused {
+		d.paused[exchange] = true
+		return
+	}
+	delete(d.paused, exchange)
+	s := d.state(exchange)
+	s.lastFetch, s.results = now, nil
+}
+
+// Evaluate updates the state of every exchange observed so far, and returns those that were degraded or
+// included again since the last evaluation.
+func (d *Detector) Evaluate(now time.Time) []Change {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var changes []Change
+	for exchange, s := range d.states {
+		if d.paused[exchange] {
+			continue
+		}
+		rate, full := s.errorRate(d.opts.ErrorWindow)
+		var reason string
+		lastFetch := s.lastFetch
+		if lastFetch.IsZero() {
+			lastFetch = d.started
+		}
+		switch {
+		case d.opts.MaxDataAge > 0 && now.Sub(lastFetch) > d.opts.MaxDataAge:
+			reason = ReasonStale
+		case d.opts.ErrorRate > 0 && full && rate >= d.opts.ErrorRate:
+			reason = ReasonErrorRate
+		}
+
+		switch {
+		case !s.degraded && reason != "":
+			s.degraded, s.reason, s.since, s.streak = true, reason, now, 0
+			changes = append(changes, Change{Exchange: exchange, Degraded: true, Reason: reason, ErrorRate: rate, LastFetch: s.lastFetch, Since: now})
+		case s.degraded && reason != ReasonStale && s.streak >= d.opts.RecoveryFetches:
+			// The failures of the outage stay in the window for a while; the streak is what proves recovery
+			changes = append(changes, Change{Exchange: exchange, Reason: s.reason, ErrorRate: rate, LastFetch: s.lastFetch, Since: s.since, RecoveredAt: now})
+			s.degraded, s.reason, s.since, s.results = false, "", time.Time{}, nil
+		}
+	}
+	slices.SortFunc(changes, func(a, b Change) int { return strings.Compare(a.Exchange, b.Exchange) })
+	return changes
+}
+
+// Degraded reports whether an exchange is degraded, and why.
+func (d *Detector) Degraded(exchange string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, ok := d.states[exchange]
+	if !ok || !s.degraded {
+		return "", false
+	}
+	return s.reason, true
+}
+
+func (d *Detector) state(exchange string) *state {
+	s, ok := d.states[exchange]
+	if !ok {
+		s = &state{}
+		d.states[exchange] = s
+	}
+	return s
+}
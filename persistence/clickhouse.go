@@ -0,0 +1,254 @@
+package persistence
+
+import (
+	"bytes"
+	"cex-price-diff-notifications/arbitrage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clickHouseTimeFormat is the DateTime64(3) text format.
+const clickHouseTimeFormat = "2006-01-02 15:04:05.000"
+
+// ClickHouseOptions configures the ClickHouse writer.
+type ClickHouseOptions struct {
+	URL           string // HTTP interface, e.g., "http://clickhouse:8123"
+	Database      string
+	Username      string
+	Password      string
+	Table         string
+	BatchSize     int           // Rows buffered before an insert is triggered
+	FlushInterval time.Duration // Longest time rows wait in the buffer
+	MaxBuffered   int           // Rows kept while ClickHouse is unreachable (oldest dropped first)
+	AsyncInsert   bool          // Let the server batch inserts further (async_insert=1)
+	CreateTable   bool          // Create the table on startup if it doesn't exist
+	Timeout       time.Duration // Timeout of a single request
+}
+
+// clickHouseRow is a spread as stored in ClickHouse, encoded as JSONEachRow.
+type clickHouseRow struct {
+	Time                    string   `json:"time"`
+	Cycle                   uint64   `json:"cycle"`
+	UnifiedSymbol           string   `json:"unified_symbol"`
+	ExchangeShort           string   `json:"exchange_short"`
+	ExchangeLong            string   `json:"exchange_long"`
+	EntrySpread             float64  `json:"entry_spread"`
+	ConservativeEntrySpread float64  `json:"conservative_entry_spread"`
+	ExitSpread              float64  `json:"exit_spread"`
+	FundingSpread8h         *float64 `json:"funding_spread_8h"`
+	FundingRateShort        *float64 `json:"funding_rate_short"`
+	FundingRateLong         *float64 `json:"funding_rate_long"`
+	ExpectedPnL             *float64 `json:"expected_pnl"`
+	VolumeUSD               float64  `json:"volume_usd"`
+	Score                   float64  `json:"score"`
+}
+
+// clickHouseSchema creates the spreads table. Rows are sorted by pair and time, which suits per-symbol
+// research queries, and partitioned by month so old data can be dropped cheaply.
+const clickHouseSchema = `CREATE TABLE IF NOT EXISTS %s (
+	time                      DateTime64(3, 'UTC'),
+	cycle                     UInt64,
+	unified_symbol            LowCardinality(String),
+	exchange_short            LowCardinality(String),
+	exchange_long             LowCardinality(String),
+	entry_spread              Float64,
+	conservative_entry_spread Float64,
+	exit_spread               Float64,
+	funding_spread_8h         Nullable(Float64),
+	funding_rate_short        Nullable(Float64),
+	funding_rate_long         Nullable(Float64),
+	expected_pnl              Nullable(Float64),
+	volume_usd                Float64,
+	score                     Float64
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(time)
+ORDER BY (unified_symbol, exchange_short, exchange_long, time)`
+
+// ClickHouse records every spread of every cycle in ClickHouse. Rows are buffered and inserted in batches
+// over the HTTP interface, so the main loop never waits for the database.
+type ClickHouse struct {
+	client *http.Client
+	opts   ClickHouseOptions
+
+	mu    sync.Mutex
+	rows  []clickHouseRow
+	flush chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewClickHouse creates a ClickHouse writer, creating its table if configured to, and starts the flush worker.
+func NewClickHouse(opts ClickHouseOptions) (*ClickHouse, error) {
+	c := &ClickHouse{
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		flush:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if opts.CreateTable {
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+		defer cancel()
+		if err := c.exec(ctx, fmt.Sprintf(clickHouseSchema, opts.Table), nil, nil); err != nil {
+			return nil, fmt.Errorf("failed to create ClickHouse table: %w", err)
+		}
+	}
+	slog.Info("Initializing ClickHouse writer", "url", opts.URL, "table", opts.Table, "batch_size", opts.BatchSize)
+
+	c.wg.Add(1)
+	go c.run()
+	return c, nil
+}
+
+// SetRetention makes ClickHouse delete rows older than retention in the background, as parts are merged.
+func (c *ClickHouse) SetRetention(ctx context.Context, retention time.Duration) error {
+	query := fmt.Sprintf("ALTER TABLE %s MODIFY TTL toDateTime(time) + INTERVAL %d SECOND", c.opts.Table, int64(retention.Seconds()))
+	if err := c.exec(ctx, query, nil, nil); err != nil {
+		return fmt.Errorf("failed to set the ClickHouse retention: %w", err)
+	}
+	return nil
+}
+
+// Add buffers a cycle's spreads for insertion.
+func (c *ClickHouse) Add(cycle uint64, at time.Time, spreads []arbitrage.Spread) {
+	ts := at.UTC().Format(clickHouseTimeFormat)
+	c.mu.Lock()
+	for _, s := range spreads {
+		row := clickHouseRow{
+			Time:                    ts,
+			Cycle:                   cycle,
+			UnifiedSymbol:           s.UnifiedSymbol,
+			ExchangeShort:           s.ExchangeShort,
+			ExchangeLong:            s.ExchangeLong,
+			EntrySpread:             s.EntrySpread,
+			ConservativeEntrySpread: s.ConservativeEntrySpread,
+			ExitSpread:              s.ExitSpread,
+			FundingSpread8h:         s.FundingSpread8h,
+			ExpectedPnL:             s.ExpectedPnL24h,
+			VolumeUSD:               s.VolumeUSD,
+			Score:                   s.Score,
+		}
+		// The rows keep copies of the rates received by the short leg and paid by the long one
+		if s.FundingRateShort != nil {
+			rate := s.FundingRateShort.ShortRate()
+			row.FundingRateShort = &rate
+		}
+		if s.FundingRateLong != nil {
+			rate := s.FundingRateLong.Rate
+			row.FundingRateLong = &rate
+		}
+		c.rows = append(c.rows, row)
+	}
+	if overflow := len(c.rows) - c.opts.MaxBuffered; c.opts.MaxBuffered > 0 && overflow > 0 {
+		c.rows = c.rows[overflow:]
+		slog.Warn("ClickHouse buffer full, dropped the oldest rows", "dropped", overflow)
+	}
+	full := len(c.rows) >= c.opts.BatchSize
+	c.mu.Unlock()
+
+	if full {
+		select {
+		case c.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run inserts the buffered rows when a batch is full or the flush interval elapses, until Close.
+func (c *ClickHouse) run() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			c.insert()
+			return
+		case <-ticker.C:
+		case <-c.flush:
+		}
+		c.insert()
+	}
+}
+
+// insert sends the buffered rows in one INSERT. On failure, they go back to the buffer for the next attempt.
+func (c *ClickHouse) insert() {
+	c.mu.Lock()
+	rows := c.rows
+	c.rows = nil
+	c.mu.Unlock()
+	if len(rows) == 0 {
+		return
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			slog.Error("Failed to encode a ClickHouse row", "error", err)
+			return
+		}
+	}
+
+	settings := url.Values{}
+	if c.opts.AsyncInsert {
+		settings.Set("async_insert", "1")
+		settings.Set("wait_for_async_insert", "1")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	err := c.exec(ctx, "INSERT INTO "+c.opts.Table+" FORMAT JSONEachRow", settings, &body)
+	if err != nil {
+		slog.Error("Failed to insert spreads into ClickHouse, keeping them for the next batch", "rows", len(rows), "error", err)
+		c.mu.Lock()
+		c.rows = append(rows, c.rows...)
+		c.mu.Unlock()
+		return
+	}
+	slog.Debug("Inserted spreads into ClickHouse", "rows", len(rows))
+}
+
+// exec runs a query over the HTTP interface, with data as the request body for inserts.
+func (c *ClickHouse) exec(ctx context.Context, query string, settings url.Values, data io.Reader) error {
+	params := url.Values{"query": {query}}
+	if c.opts.Database != "" {
+		params.Set("database", c.opts.Database)
+	}
+	for k, v := range settings {
+		params[k] = v
+	}
+	if data == nil {
+		data = strings.NewReader("")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.opts.URL, "/")+"/?"+params.Encode(), data)
+	if err != nil {
+		return err
+	}
+	if c.opts.Username != "" {
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("clickhouse returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Close inserts the remaining rows and stops the flush worker.
+func (c *ClickHouse) Close() {
+	close(c.done)
+	c.wg.Wait()
+}
@@ -0,0 +1,566 @@
+package persistence
+
+import (
+	"bufio"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/shared"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Export file formats.
+const (
+	ExportFormatJSONL = "jsonl" // One JSON object per line, with every spread field
+	ExportFormatCSV   = "csv"   // A header row followed by the main columns
+)
+
+// ExportOptions configures the file exporter.
+type ExportOptions struct {
+	Dir            string        // Directory of the exported files, created if missing
+	Format         string        // ExportFormatJSONL or ExportFormatCSV
+	Gzip           bool          // Compress files; they get a ".gz" suffix
+	MaxBytes       int64         // Rotate a file once this many uncompressed bytes were written to it (0 disables)
+	RotateInterval time.Duration // Rotate files this often (0 disables)
+	Tickers        bool          // Also export every cycle's raw tickers and funding rates, for backtests
+}
+
+// exportedSpread is a spread as written to JSON lines.
+type exportedSpread struct {
+	Time  time.Time `json:"time"`
+	Cycle uint64    `json:"cycle"`
+	arbitrage.Spread
+}
+
+// exportedTicker is a ticker as written to JSON lines.
+type exportedTicker struct {
+	Time          time.Time `json:"time"`
+	Exchange      string    `json:"exchange"`
+	UnifiedSymbol string    `json:"unified_symbol"`
+	Symbol        string    `json:"symbol"`
+	Bid           float64   `json:"bid"`
+	Ask           float64   `json:"ask"`
+	BidQty        float64   `json:"bid_qty,omitempty"`
+	AskQty        float64   `json:"ask_qty,omitempty"`
+	VolumeUSD     float64   `json:"volume_usd,omitempty"`
+	QuoteTime     time.Time `json:"quote_time"`
+}
+
+// exportedFunding is a funding rate as written to JSON lines.
+type exportedFunding struct {
+	Time           time.Time `json:"time"`
+	Exchange       string    `json:"exchange"`
+	UnifiedSymbol  string    `json:"unified_symbol"`
+	Rate           float64   `json:"rate"`
+	IntervalHours  int       `json:"interval_hours"`
+	NextSettleTime int64     `json:"next_settle_time"`
+}
+
+// CSV columns of the exported files.
+var (
+	spreadColumns = []string{"time", "cycle", "unified_symbol", "exchange_short", "exchange_long", "entry_spread",
+		"conservative_entry_spread", "exit_spread", "funding_spread_8h", "expected_pnl", "volume_usd", "score"}
+	tickerColumns = []string{"time", "exchange", "unified_symbol", "symbol", "bid", "ask", "bid_qty", "ask_qty",
+		"volume_usd", "quote_time"}
+	fundingColumns = []string{"time", "exchange", "unified_symbol", "rate", "interval_hours", "next_settle_time"}
+)
+
+// Exporter writes spreads, and optionally tickers, to rotating files for offline analysis, e.g.,
+// "spreads-2025-01-02T15-04-05.000Z.csv.gz".
+type Exporter struct {
+	opts    ExportOptions
+	mu      sync.Mutex // Guards the open files against concurrent pruning
+	spreads *rotatingFile
+	tickers *rotatingFile
+	funding *rotatingFile
+
+	lastFunding map[string]shared.FundingRateInfo // Last exported rate, keyed by exchange and unified symbol
+}
+
+// NewExporter creates the export directory and an exporter writing to it.
+func NewExporter(opts ExportOptions) (*Exporter, error) {
+	if opts.Format != ExportFormatJSONL && opts.Format != ExportFormatCSV {
+		return nil, fmt.Errorf("unsupported export format %q", opts.Format)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory %s: %w", opts.Dir, err)
+	}
+	e := &Exporter{opts: opts, spreads: newRotatingFile("spreads", spreadColumns, opts)}
+	if opts.Tickers {
+		e.tickers = newRotatingFile("tickers", tickerColumns, opts)
+		e.funding = newRotatingFile("funding", fundingColumns, opts)
+		e.lastFunding = make(map[string]shared.FundingRateInfo)
+	}
+	return e, nil
+}
+
+// WriteSpreads appends a cycle's spreads.
+func (e *Exporter) WriteSpreads(cycle uint64, at time.Time, spreads []arbitrage.Spread) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	records := make([]any, 0, len(spreads))
+	for _, s := range spreads {
+		if e.opts.Format == ExportFormatJSONL {
+			records = append(records, exportedSpread{Time: at, Cycle: cycle, Spread: s})
+			continue
+		}
+		records = append(records, []string{
+			at.UTC().Format(time.RFC3339Nano), strconv.FormatUint(cycle, 10), s.UnifiedSymbol, s.ExchangeShort, s.ExchangeLong,
+			formatFloat(s.EntrySpread), formatFloat(s.ConservativeEntrySpread), formatFloat(s.ExitSpread),
+			formatOptionalFloat(s.FundingSpread8h), formatOptionalFloat(s.ExpectedPnL24h), formatFloat(s.VolumeUSD), formatFloat(s.Score),
+		})
+	}
+	return e.spreads.write(at, records)
+}
+
+// WriteTickers appends a cycle's tickers, keyed by unified symbol and exchange. It does nothing unless
+// ticker export is enabled.
+func (e *Exporter) WriteTickers(at time.Time, tickers map[string]map[string]shared.TickerBidAsk) error {
+	if e.tickers == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var records []any
+	for _, byExchange := range tickers {
+		for exchange, t := range byExchange {
+			if e.opts.Format == ExportFormatJSONL {
+				records = append(records, exportedTicker{
+					Time: at, Exchange: exchange, UnifiedSymbol: t.UnifiedSymbol, Symbol: t.Symbol, Bid: t.Bid, Ask: t.Ask,
+					BidQty: t.BidQty, AskQty: t.AskQty, VolumeUSD: t.VolumeUSD, QuoteTime: t.Timestamp,
+				})
+				continue
+			}
+			records = append(records, []string{
+				at.UTC().Format(time.RFC3339Nano), exchange, t.UnifiedSymbol, t.Symbol, formatFloat(t.Bid), formatFloat(t.Ask),
+				formatFloat(t.BidQty), formatFloat(t.AskQty), formatFloat(t.VolumeUSD), t.Timestamp.UTC().Format(time.RFC3339Nano),
+			})
+		}
+	}
+	return e.tickers.write(at, records)
+}
+
+// WriteFunding appends the funding rates, keyed by exchange and unified symbol, that changed since the last
+// call. Each new file starts with every rate, so that it replays on its own. It does nothing unless ticker
+// export is enabled.
+func (e *Exporter) WriteFunding(at time.Time, rates map[string]map[string]shared.FundingRateInfo) error {
+	if e.funding == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	full := e.funding.file == nil || e.funding.due(at)
+	changed := make(map[string]shared.FundingRateInfo)
+	var records []any
+	for exchange, bySymbol := range rates {
+		for symbol, info := range bySymbol {
+			key := exchange + "|" + symbol
+			if last, ok := e.lastFunding[key]; ok && last == info && !full {
+				continue
+			}
+			changed[key] = info
+			if e.opts.Format == ExportFormatJSONL {
+				records = append(records, exportedFunding{
+					Time: at, Exchange: exchange, UnifiedSymbol: symbol, Rate: info.Rate, IntervalHours: info.Interval,
+					NextSettleTime: info.NextSettleTime,
+				})
+				continue
+			}
+			records = append(records, []string{
+				at.UTC().Format(time.RFC3339Nano), exchange, symbol, formatFloat(info.Rate), strconv.Itoa(info.Interval),
+				strconv.FormatInt(info.NextSettleTime, 10),
+			})
+		}
+	}
+	if err := e.funding.write(at, records); err != nil {
+		return err
+	}
+	maps.Copy(e.lastFunding, changed)
+	return nil
+}
+
+// PruneSpreads deletes spread files last written before cutoff.
+func (e *Exporter) PruneSpreads(_ context.Context, cutoff time.Time) (int64, error) {
+	return e.prune(e.spreads, cutoff)
+}
+
+// PruneTickers deletes ticker and funding rate files last written before cutoff.
+func (e *Exporter) PruneTickers(_ context.Context, cutoff time.Time) (int64, error) {
+	if e.tickers == nil {
+		return 0, nil
+	}
+	removed, err := e.prune(e.tickers, cutoff)
+	if err != nil {
+		return removed, err
+	}
+	removedFunding, err := e.prune(e.funding, cutoff)
+	return removed + removedFunding, err
+}
+
+// prune deletes the files of a series last modified before cutoff, except the one being written.
+func (e *Exporter) prune(r *rotatingFile, cutoff time.Time) (int64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return r.prune(cutoff)
+}
+
+// Close flushes and closes the open files.
+func (e *Exporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	err := e.spreads.close()
+	for _, r := range []*rotatingFile{e.tickers, e.funding} {
+		if r == nil {
+			continue
+		}
+		if rErr := r.close(); err == nil {
+			err = rErr
+		}
+	}
+	return err
+}
+
+// ReadExportedSpreads replays the spreads exported as JSON lines to dir, oldest file first, calling fn once
+// per exported cycle. Gzipped files are decompressed; CSV files are not supported, as they lack most fields.
+func ReadExportedSpreads(dir string, fn func(cycle uint64, at time.Time, spreads []arbitrage.Spread) error) error {
+	paths, err := exportPaths(dir, "spreads")
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no JSON lines spread exports in %s", dir)
+	}
+
+	var (
+		cycle   uint64
+		at      time.Time
+		pending []arbitrage.Spread
+	)
+	for _, path := range paths {
+		err := readJSONLines(path, func(line []byte) error {
+			var rec exportedSpread
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return err
+			}
+			if len(pending) > 0 && (rec.Cycle != cycle || !rec.Time.Equal(at)) {
+				if err := fn(cycle, at, pending); err != nil {
+					return err
+				}
+				pending = nil
+			}
+			cycle, at = rec.Cycle, rec.Time
+			pending = append(pending, rec.Spread)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read export file %s: %w", path, err)
+		}
+	}
+	if len(pending) > 0 {
+		return fn(cycle, at, pending)
+	}
+	return nil
+}
+
+// ReadExportedMarket replays the tickers and funding rates exported as JSON lines to dir, oldest file first,
+// calling fn once per exported cycle with its tickers, keyed by unified symbol and exchange, and the funding
+// rates known at that time, keyed by exchange and unified symbol. The funding map is updated in place between
+// calls. CSV files are not supported.
+func ReadExportedMarket(dir string, fn func(at time.Time, tickers map[string]map[string]shared.TickerBidAsk, funding map[string]map[string]shared.FundingRateInfo) error) error {
+	tickerPaths, err := exportPaths(dir, "tickers")
+	if err != nil {
+		return err
+	}
+	if len(tickerPaths) == 0 {
+		return fmt.Errorf("no JSON lines ticker exports in %s (see EXPORT_TICKERS)", dir)
+	}
+	fundingPaths, err := exportPaths(dir, "funding")
+	if err != nil {
+		return err
+	}
+
+	// Funding rates only change now and then, so all of them are loaded and applied as the replay catches up
+	var changes []exportedFunding
+	for _, path := range fundingPaths {
+		err := readJSONLines(path, func(line []byte) error {
+			var rec exportedFunding
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return err
+			}
+			changes = append(changes, rec)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read export file %s: %w", path, err)
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b exportedFunding) int { return a.Time.Compare(b.Time) })
+
+	funding := make(map[string]map[string]shared.FundingRateInfo)
+	emit := func(at time.Time, tickers map[string]map[string]shared.TickerBidAsk) error {
+		for len(changes) > 0 && !changes[0].Time.After(at) {
+			c := changes[0]
+			if funding[c.Exchange] == nil {
+				funding[c.Exchange] = make(map[string]shared.FundingRateInfo)
+			}
+			funding[c.Exchange][c.UnifiedSymbol] = shared.FundingRateInfo{Rate: c.Rate, Interval: c.IntervalHours, NextSettleTime: c.NextSettleTime}
+			changes = changes[1:]
+		}
+		return fn(at, tickers, funding)
+	}
+
+	var (
+		at      time.Time
+		pending map[string]map[string]shared.TickerBidAsk
+	)
+	for _, path := range tickerPaths {
+		err := readJSONLines(path, func(line []byte) error {
+			var rec exportedTicker
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return err
+			}
+			if pending != nil && !rec.Time.Equal(at) {
+				if err := emit(at, pending); err != nil {
+					return err
+				}
+				pending = nil
+			}
+			if pending == nil {
+				at, pending = rec.Time, make(map[string]map[string]shared.TickerBidAsk)
+			}
+			if pending[rec.UnifiedSymbol] == nil {
+				pending[rec.UnifiedSymbol] = make(map[string]shared.TickerBidAsk)
+			}
+			pending[rec.UnifiedSymbol][rec.Exchange] = shared.TickerBidAsk{
+				Symbol: rec.Symbol, UnifiedSymbol: rec.UnifiedSymbol, Bid: rec.Bid, Ask: rec.Ask, VolumeUSD: rec.VolumeUSD,
+				BidQty: rec.BidQty, AskQty: rec.AskQty, Timestamp: rec.QuoteTime,
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read export file %s: %w", path, err)
+		}
+	}
+	if pending != nil {
+		return emit(at, pending)
+	}
+	return nil
+}
+
+// exportPaths returns the JSON lines files of an export series in dir, oldest first.
+func exportPaths(dir, prefix string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, prefix+"-*."+ExportFormatJSONL+"*"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths) // File names start with their UTC creation time
+	return paths, nil
+}
+
+// readJSONLines calls fn with every line of a JSON lines file, decompressing it if gzipped.
+func readJSONLines(path string, fn func(line []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	// A file still being written, or cut short by a crash, may end with a partial gzip stream
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	return nil
+}
+
+// rotatingFile is a series of export files, switching to a new file by size or age.
+type rotatingFile struct {
+	prefix  string
+	columns []string
+	opts    ExportOptions
+
+	file     *os.File
+	gz       *gzip.Writer
+	w        io.Writer // gz or file
+	written  int64
+	openedAt time.Time
+}
+
+// newRotatingFile creates a file series named after prefix. The first file is opened on the first write.
+func newRotatingFile(prefix string, columns []string, opts ExportOptions) *rotatingFile {
+	return &rotatingFile{prefix: prefix, columns: columns, opts: opts}
+}
+
+// write appends records (JSON values, or CSV rows as []string), rotating first if the current file is due.
+func (r *rotatingFile) write(at time.Time, records []any) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if r.file != nil && r.due(at) {
+		if err := r.close(); err != nil {
+			return err
+		}
+	}
+	if r.file == nil {
+		if err := r.open(at); err != nil {
+			return err
+		}
+	}
+
+	counter := &countingWriter{w: r.w}
+	var err error
+	if r.opts.Format == ExportFormatCSV {
+		cw := csv.NewWriter(counter)
+		for _, rec := range records {
+			if err = cw.Write(rec.([]string)); err != nil {
+				break
+			}
+		}
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+	} else {
+		enc := json.NewEncoder(counter)
+		for _, rec := range records {
+			if err = enc.Encode(rec); err != nil {
+				break
+			}
+		}
+	}
+	r.written += counter.n
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", r.file.Name(), err)
+	}
+	return nil
+}
+
+// prune deletes the files of the series last modified before cutoff, except the one being written.
+func (r *rotatingFile) prune(cutoff time.Time) (int64, error) {
+	entries, err := os.ReadDir(r.opts.Dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list export directory %s: %w", r.opts.Dir, err)
+	}
+	var removed int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), r.prefix+"-") {
+			continue
+		}
+		path := filepath.Join(r.opts.Dir, entry.Name())
+		if r.file != nil && r.file.Name() == path {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove export file %s: %w", path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// due reports whether the current file has reached its size or age limit.
+func (r *rotatingFile) due(at time.Time) bool {
+	return (r.opts.MaxBytes > 0 && r.written >= r.opts.MaxBytes) ||
+		(r.opts.RotateInterval > 0 && at.Sub(r.openedAt) >= r.opts.RotateInterval)
+}
+
+// open starts a new file, writing the header row for CSV.
+func (r *rotatingFile) open(at time.Time) error {
+	name := r.prefix + "-" + at.UTC().Format("2006-01-02T15-04-05.000Z") + "." + r.opts.Format
+	if r.opts.Gzip {
+		name += ".gz"
+	}
+	path := filepath.Join(r.opts.Dir, name)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open export file %s: %w", path, err)
+	}
+	r.file, r.w, r.written, r.openedAt = f, f, 0, at
+	if r.opts.Gzip {
+		r.gz = gzip.NewWriter(f)
+		r.w = r.gz
+	}
+
+	if r.opts.Format == ExportFormatCSV {
+		cw := csv.NewWriter(r.w)
+		cw.Write(r.columns)
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("failed to write header to %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// close finishes the current file, if any.
+func (r *rotatingFile) close() error {
+	if r.file == nil {
+		return nil
+	}
+	var err error
+	if r.gz != nil {
+		err = r.gz.Close()
+	}
+	if cErr := r.file.Close(); err == nil {
+		err = cErr
+	}
+	r.file, r.gz, r.w = nil, nil, nil
+	if err != nil {
+		return fmt.Errorf("failed to close export file: %w", err)
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// formatOptionalFloat formats an optional value, with nil as an empty string.
+func formatOptionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
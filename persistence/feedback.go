@@ -0,0 +1,45 @@
+package persistence
+
+import (
+	"cex-price-diff-notifications/feedback"
+	"context"
+	"fmt"
+)
+
+// WriteFeedback stores a consumer's report on an opportunity, replacing its earlier one. What the new report
+// lacks is kept from the earlier one, e.g., the realized spread when the PnL is reported once the position is
+// closed, or the publication when another instance, which didn't publish the opportunity, receives the report.
+func (p *Postgres) WriteFeedback(ctx context.Context, r feedback.Record) error {
+	var publishedSpread any
+	var publishedAt any
+	if r.Matched {
+		publishedSpread, publishedAt = r.PublishedSpread, r.PublishedAt
+	}
+	var notional any
+	if r.NotionalUSD > 0 {
+		notional = r.NotionalUSD
+	}
+	_, err := p.pool.Exec(ctx, `
+		INSERT INTO opportunity_feedback AS t (
+			time, opportunity_id, consumer, action, matched, unified_symbol, exchange_short, exchange_long,
+			published_spread, published_at, realized_spread, realized_pnl_usd, notional_usd, reported_at, note
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (opportunity_id, consumer) DO UPDATE SET
+			time = EXCLUDED.time, action = EXCLUDED.action, reported_at = EXCLUDED.reported_at, note = EXCLUDED.note,
+			matched = t.matched OR EXCLUDED.matched,
+			unified_symbol = CASE WHEN EXCLUDED.matched THEN EXCLUDED.unified_symbol ELSE t.unified_symbol END,
+			exchange_short = CASE WHEN EXCLUDED.matched THEN EXCLUDED.exchange_short ELSE t.exchange_short END,
+			exchange_long = CASE WHEN EXCLUDED.matched THEN EXCLUDED.exchange_long ELSE t.exchange_long END,
+			published_spread = COALESCE(EXCLUDED.published_spread, t.published_spread),
+			published_at = COALESCE(EXCLUDED.published_at, t.published_at),
+			realized_spread = COALESCE(EXCLUDED.realized_spread, t.realized_spread),
+			realized_pnl_usd = COALESCE(EXCLUDED.realized_pnl_usd, t.realized_pnl_usd),
+			notional_usd = COALESCE(EXCLUDED.notional_usd, t.notional_usd)`,
+		r.ReceivedAt, r.OpportunityID, r.Consumer, r.Action, r.Matched, r.UnifiedSymbol, r.ExchangeShort, r.ExchangeLong,
+		publishedSpread, publishedAt, r.RealizedSpread, r.RealizedPnLUSD, notional, r.ReportedAt, r.Note,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write feedback: %w", err)
+	}
+	return nil
+}
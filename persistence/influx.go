@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"bytes"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxOptions configures the line protocol writer.
+type InfluxOptions struct {
+	WriteURL string        // Write endpoint, e.g., "http://influxdb:8086/api/v2/write?org=arb&bucket=spreads" (v2) or ".../write?db=arb" (v1)
+	Token    string        // Sent as "Authorization: Token <token>" (empty sends no credentials)
+	Timeout  time.Duration // Timeout of a single write
+}
+
+// Influx writes spread, funding rate and exchange latency series in InfluxDB line protocol, with nanosecond
+// timestamps, to InfluxDB or any endpoint accepting it (e.g., Telegraf's HTTP listener or VictoriaMetrics).
+//
+// Series written each cycle:
+//
+//	spread,symbol=<unified symbol>,short=<exchange>,long=<exchange> entry=,conservative_entry=,exit=,funding_8h=,expected_pnl=,volume_usd=,score=
+//	funding_rate,symbol=<unified symbol>,exchange=<exchange> rate=,interval_hours=
+//	adapter_latency,exchange=<exchange> latency_ms=
+type Influx struct {
+	client *http.Client
+	opts   InfluxOptions
+}
+
+// NewInflux creates a line protocol writer.
+func NewInflux(opts InfluxOptions) *Influx {
+	slog.Info("Initializing InfluxDB writer", "url", opts.WriteURL)
+	return &Influx{client: &http.Client{Timeout: opts.Timeout}, opts: opts}
+}
+
+// Write sends a cycle's spreads, the funding rates of their legs and the exchange latencies as one batch.
+func (i *Influx) Write(ctx context.Context, at time.Time, spreads []arbitrage.Spread, latencies map[string]time.Duration) error {
+	ts := strconv.FormatInt(at.UnixNano(), 10)
+	var b bytes.Buffer
+
+	funding := make(map[string]bool) // "<exchange> <symbol>" already written
+	writeFunding := func(exchange, symbol string, rate *shared.FundingRateInfo) {
+		key := exchange + " " + symbol
+		if rate == nil || funding[key] {
+			return
+		}
+		funding[key] = true
+		fmt.Fprintf(&b, "funding_rate,symbol=%s,exchange=%s rate=%s,interval_hours=%di %s\n",
+			escapeTag(symbol), escapeTag(exchange), formatFloat(rate.Rate), rate.Interval, ts)
+	}
+
+	for _, s := range spreads {
+		fields := []string{
+			"entry=" + formatFloat(s.EntrySpread),
+			"conservative_entry=" + formatFloat(s.ConservativeEntrySpread),
+			"exit=" + formatFloat(s.ExitSpread),
+			"volume_usd=" + formatFloat(s.VolumeUSD),
+			"score=" + formatFloat(s.Score),
+		}
+		if s.FundingSpread8h != nil {
+			fields = append(fields, "funding_8h="+formatFloat(*s.FundingSpread8h))
+		}
+		if s.ExpectedPnL24h != nil {
+			fields = append(fields, "expected_pnl="+formatFloat(*s.ExpectedPnL24h))
+		}
+		fmt.Fprintf(&b, "spread,symbol=%s,short=%s,long=%s %s %s\n",
+			escapeTag(s.UnifiedSymbol), escapeTag(s.ExchangeShort), escapeTag(s.ExchangeLong), strings.Join(fields, ","), ts)
+
+		writeFunding(s.ExchangeShort, s.UnifiedSymbol, s.FundingRateShort)
+		writeFunding(s.ExchangeLong, s.LongSymbol(), s.FundingRateLong)
+	}
+	for exchange, d := range latencies {
+		fmt.Fprintf(&b, "adapter_latency,exchange=%s latency_ms=%s %s\n", escapeTag(exchange), formatFloat(float64(d)/float64(time.Millisecond)), ts)
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.opts.WriteURL, &b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.opts.Token != "" {
+		req.Header.Set("Authorization", "Token "+i.opts.Token)
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("influxdb rejected the write: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// tagEscaper escapes the characters that are special in line protocol tag values.
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// escapeTag escapes a tag value.
+func escapeTag(v string) string {
+	return tagEscaper.Replace(v)
+}
+
+// formatFloat formats a float field value.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,20 @@
+-- Every spread computed in a cycle, before the publish limits. The full spread is kept as JSON,
+-- with the fields used for filtering and aggregation in their own columns.
+CREATE TABLE spreads (
+    time                      TIMESTAMPTZ      NOT NULL,
+    cycle                     BIGINT           NOT NULL,
+    unified_symbol            TEXT             NOT NULL,
+    exchange_short            TEXT             NOT NULL,
+    exchange_long             TEXT             NOT NULL,
+    entry_spread              DOUBLE PRECISION NOT NULL,
+    conservative_entry_spread DOUBLE PRECISION NOT NULL,
+    exit_spread               DOUBLE PRECISION NOT NULL,
+    funding_spread_8h         DOUBLE PRECISION,
+    expected_pnl              DOUBLE PRECISION,
+    volume_usd                DOUBLE PRECISION,
+    score                     DOUBLE PRECISION,
+    data                      JSONB            NOT NULL
+);
+
+CREATE INDEX spreads_symbol_time_idx ON spreads (unified_symbol, time DESC);
+CREATE INDEX spreads_time_idx ON spreads (time DESC);
@@ -0,0 +1,15 @@
+-- Raw top-of-book tickers, written when POSTGRES_STORE_TICKERS is enabled.
+CREATE TABLE tickers (
+    time           TIMESTAMPTZ      NOT NULL,
+    exchange       TEXT             NOT NULL,
+    unified_symbol TEXT             NOT NULL,
+    bid            DOUBLE PRECISION NOT NULL,
+    ask            DOUBLE PRECISION NOT NULL,
+    bid_qty        DOUBLE PRECISION,
+    ask_qty        DOUBLE PRECISION,
+    volume_usd     DOUBLE PRECISION,
+    quote_time     TIMESTAMPTZ
+);
+
+CREATE INDEX tickers_symbol_time_idx ON tickers (unified_symbol, exchange, time DESC);
+CREATE INDEX tickers_time_idx ON tickers (time DESC);
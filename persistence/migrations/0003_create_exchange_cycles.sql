@@ -0,0 +1,8 @@
+-- The tickers each exchange contributed to every cycle, from which summary reports compute its uptime.
+CREATE TABLE exchange_cycles (
+    time     TIMESTAMPTZ NOT NULL,
+    exchange TEXT        NOT NULL,
+    tickers  INTEGER     NOT NULL
+);
+
+CREATE INDEX exchange_cycles_time_idx ON exchange_cycles (time DESC);
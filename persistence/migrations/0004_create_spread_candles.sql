@@ -0,0 +1,17 @@
+-- OHLC of each pair's entry spread per timeframe, written as each candle closes, so spread persistence can be
+-- queried without storing every cycle.
+CREATE TABLE spread_candles (
+    time                TIMESTAMPTZ      NOT NULL, -- Start of the candle's period
+    timeframe           TEXT             NOT NULL, -- e.g., '1m', '5m', '1h'
+    unified_symbol      TEXT             NOT NULL,
+    unified_symbol_long TEXT             NOT NULL DEFAULT '',
+    exchange_short      TEXT             NOT NULL,
+    exchange_long       TEXT             NOT NULL,
+    open                DOUBLE PRECISION NOT NULL,
+    high                DOUBLE PRECISION NOT NULL,
+    low                 DOUBLE PRECISION NOT NULL,
+    close               DOUBLE PRECISION NOT NULL,
+    samples             INTEGER          NOT NULL
+);
+
+CREATE INDEX spread_candles_symbol_time_idx ON spread_candles (unified_symbol, timeframe, time DESC);
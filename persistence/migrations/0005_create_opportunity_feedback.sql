@@ -0,0 +1,23 @@
+-- Consumers' reports of whether they acted on published opportunities and the spread they realized, matched with
+-- the opportunities as published, from which summary reports compare realized and published spreads. A consumer's
+-- later report on an opportunity updates its earlier one.
+CREATE TABLE opportunity_feedback (
+    time             TIMESTAMPTZ      NOT NULL, -- Reception
+    opportunity_id   TEXT             NOT NULL,
+    consumer         TEXT             NOT NULL,
+    action           TEXT             NOT NULL, -- 'acted' or 'skipped'
+    matched          BOOLEAN          NOT NULL, -- Whether the opportunity was published by the receiving instance
+    unified_symbol   TEXT             NOT NULL,
+    exchange_short   TEXT             NOT NULL,
+    exchange_long    TEXT             NOT NULL,
+    published_spread DOUBLE PRECISION,
+    published_at     TIMESTAMPTZ,
+    realized_spread  DOUBLE PRECISION,
+    realized_pnl_usd DOUBLE PRECISION,
+    notional_usd     DOUBLE PRECISION,
+    reported_at      TIMESTAMPTZ      NOT NULL,
+    note             TEXT             NOT NULL,
+    PRIMARY KEY (opportunity_id, consumer)
+);
+
+CREATE INDEX opportunity_feedback_time_idx ON opportunity_feedback (time DESC);
@@ -0,0 +1,133 @@
+package persistence
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor that wasn't returned by QueryOpportunities.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// OpportunityFilter selects the stored spreads of QueryOpportunities.
+type OpportunityFilter struct {
+	Symbol    string    // Unified symbol, e.g., "BTC/USDT:PERP"; empty for every symbol
+	Exchange  string    // Exchange of either leg, case-insensitive; empty for every exchange
+	MinSpread float64   // Minimum entry spread, in percent
+	From      time.Time // Inclusive; zero for no lower bound
+	To        time.Time // Exclusive; zero for no upper bound
+	Limit     int       // Maximum spreads returned
+	Cursor    string    // NextCursor of the previous page; empty for the first page
+}
+
+// Opportunity is a spread stored by a cycle, shaped as exported to JSON lines.
+type Opportunity struct {
+	Time  time.Time `json:"time"`
+	Cycle uint64    `json:"cycle"`
+	arbitrage.Spread
+}
+
+// OpportunityPage is a page of stored spreads, newest first.
+type OpportunityPage struct {
+	Count         int           `json:"count"`
+	Opportunities []Opportunity `json:"opportunities"`
+	NextCursor    string        `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// opportunityCursor is the position of the last spread of a page, in the order of the query.
+type opportunityCursor struct {
+	Time          int64  `json:"t"` // Microseconds since epoch, the precision of the time column
+	UnifiedSymbol string `json:"s"`
+	ExchangeShort string `json:"a"`
+	ExchangeLong  string `json:"b"`
+}
+
+// QueryOpportunities returns the stored spreads f selects, newest first, a page of up to f.Limit at a time.
+// Pages are keyed by the position of their last spread rather than an offset, so they stay consistent while
+// cycles keep writing.
+func (p *Postgres) QueryOpportunities(ctx context.Context, f OpportunityFilter) (OpportunityPage, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	where("entry_spread >= $%d", f.MinSpread)
+	if f.Symbol != "" {
+		where("unified_symbol = $%d", f.Symbol)
+	}
+	if f.Exchange != "" {
+		where("(lower(exchange_short) = lower($%[1]d) OR lower(exchange_long) = lower($%[1]d))", f.Exchange)
+	}
+	if !f.From.IsZero() {
+		where("time >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		where("time < $%d", f.To)
+	}
+	if f.Cursor != "" {
+		c, err := decodeOpportunityCursor(f.Cursor)
+		if err != nil {
+			return OpportunityPage{}, err
+		}
+		args = append(args, time.UnixMicro(c.Time), c.UnifiedSymbol, c.ExchangeShort, c.ExchangeLong)
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf("(time, unified_symbol, exchange_short, exchange_long) < ($%d, $%d, $%d, $%d)", n-3, n-2, n-1, n))
+	}
+	args = append(args, f.Limit+1) // One more tells whether there's a next page
+
+	rows, err := p.pool.Query(ctx, `
+		SELECT time, cycle, unified_symbol, exchange_short, exchange_long, data
+		FROM spreads
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY time DESC, unified_symbol DESC, exchange_short DESC, exchange_long DESC
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return OpportunityPage{}, fmt.Errorf("failed to query opportunities: %w", err)
+	}
+	defer rows.Close()
+
+	page := OpportunityPage{Opportunities: []Opportunity{}}
+	var last opportunityCursor
+	for rows.Next() {
+		if len(page.Opportunities) == f.Limit {
+			page.NextCursor = encodeOpportunityCursor(last)
+			break
+		}
+		var o Opportunity
+		var cycle int64
+		var data []byte
+		if err := rows.Scan(&o.Time, &cycle, &last.UnifiedSymbol, &last.ExchangeShort, &last.ExchangeLong, &data); err != nil {
+			return OpportunityPage{}, fmt.Errorf("failed to scan opportunity: %w", err)
+		}
+		if err := json.Unmarshal(data, &o.Spread); err != nil {
+			return OpportunityPage{}, fmt.Errorf("failed to unmarshal opportunity: %w", err)
+		}
+		o.Cycle = uint64(cycle)
+		last.Time = o.Time.UnixMicro()
+		page.Opportunities = append(page.Opportunities, o)
+	}
+	page.Count = len(page.Opportunities)
+	return page, rows.Err()
+}
+
+// encodeOpportunityCursor encodes the position of a spread as an opaque, URL-safe cursor.
+func encodeOpportunityCursor(c opportunityCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeOpportunityCursor decodes a cursor of encodeOpportunityCursor.
+func decodeOpportunityCursor(cursor string) (opportunityCursor, error) {
+	var c opportunityCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
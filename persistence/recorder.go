@@ -0,0 +1,135 @@
+package persistence
+
+import (
+	"cex-price-diff-notifications/shared"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// recordingPrefix names the files of the market data recordings, e.g., "market-2025-01-02T15-04-05.000Z.jsonl.gz".
+const recordingPrefix = "market"
+
+// Recording is the market data of one cycle as the scanner received it, before any filtering.
+type Recording struct {
+	Cycle   uint64                                       `json:"cycle"`
+	Time    time.Time                                    `json:"time"`
+	Tickers map[string][]RecordedTicker                  `json:"tickers"` // By exchange
+	Funding map[string]map[string]shared.FundingRateInfo `json:"funding"` // By exchange and unified symbol
+}
+
+// RecordedTicker is a ticker as recorded.
+type RecordedTicker struct {
+	Symbol        string    `json:"symbol"`
+	UnifiedSymbol string    `json:"unified_symbol"`
+	Bid           float64   `json:"bid"`
+	Ask           float64   `json:"ask"`
+	BidQty        float64   `json:"bid_qty,omitempty"`
+	AskQty        float64   `json:"ask_qty,omitempty"`
+	VolumeUSD     float64   `json:"volume_usd,omitempty"`
+	QuoteTime     time.Time `json:"quote_time"`
+}
+
+// NewRecording creates the recording of a cycle from its tickers, keyed by exchange, and funding rates.
+func NewRecording(cycle uint64, at time.Time, tickers map[string][]shared.TickerBidAsk, funding map[string]map[string]shared.FundingRateInfo) Recording {
+	rec := Recording{Cycle: cycle, Time: at, Tickers: make(map[string][]RecordedTicker, len(tickers)), Funding: funding}
+	for exchange, list := range tickers {
+		recorded := make([]RecordedTicker, len(list))
+		for i, t := range list {
+			recorded[i] = RecordedTicker{
+				Symbol: t.Symbol, UnifiedSymbol: t.UnifiedSymbol, Bid: t.Bid, Ask: t.Ask, BidQty: t.BidQty, AskQty: t.AskQty,
+				VolumeUSD: t.VolumeUSD, QuoteTime: t.Timestamp,
+			}
+		}
+		rec.Tickers[exchange] = recorded
+	}
+	return rec
+}
+
+// TickersBySymbol returns the recorded tickers keyed by unified symbol and exchange, as the spread calculation takes them.
+func (r Recording) TickersBySymbol() map[string]map[string]shared.TickerBidAsk {
+	tickers := make(map[string]map[string]shared.TickerBidAsk)
+	for exchange, list := range r.Tickers {
+		for _, t := range list {
+			if tickers[t.UnifiedSymbol] == nil {
+				tickers[t.UnifiedSymbol] = make(map[string]shared.TickerBidAsk)
+			}
+			tickers[t.UnifiedSymbol][exchange] = shared.TickerBidAsk{
+				Symbol: t.Symbol, UnifiedSymbol: t.UnifiedSymbol, Bid: t.Bid, Ask: t.Ask, BidQty: t.BidQty, AskQty: t.AskQty,
+				VolumeUSD: t.VolumeUSD, Timestamp: t.QuoteTime,
+			}
+		}
+	}
+	return tickers
+}
+
+// Recorder writes one self-contained line of market data per cycle to gzipped JSON lines files, rotated by
+// age, for backtests and for explaining past alerts.
+type Recorder struct {
+	mu   sync.Mutex
+	file *rotatingFile
+}
+
+// NewRecorder creates the recording directory and a recorder writing to it, starting a new file every
+// rotateInterval (0 disables rotation).
+func NewRecorder(dir string, rotateInterval time.Duration) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory %s: %w", dir, err)
+	}
+	opts := ExportOptions{Dir: dir, Format: ExportFormatJSONL, Gzip: true, RotateInterval: rotateInterval}
+	return &Recorder{file: newRotatingFile(recordingPrefix, nil, opts)}, nil
+}
+
+// Record appends the recording of a cycle.
+func (r *Recorder) Record(rec Recording) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.write(rec.Time, []any{rec})
+}
+
+// Prune deletes recording files last written before cutoff.
+func (r *Recorder) Prune(_ context.Context, cutoff time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.prune(cutoff)
+}
+
+// Close finishes the current file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.close()
+}
+
+// HasRecordings reports whether dir holds market data recordings.
+func HasRecordings(dir string) bool {
+	paths, err := exportPaths(dir, recordingPrefix)
+	return err == nil && len(paths) > 0
+}
+
+// ReadRecordings replays the recordings in dir, oldest first, calling fn once per recorded cycle.
+func ReadRecordings(dir string, fn func(rec Recording) error) error {
+	paths, err := exportPaths(dir, recordingPrefix)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no market data recordings in %s", dir)
+	}
+	for _, path := range paths {
+		err := readJSONLines(path, func(line []byte) error {
+			var rec Recording
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return err
+			}
+			return fn(rec)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read recording file %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package persistence
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/feedback"
+	"cex-price-diff-notifications/report"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TopSpreads returns the best spread of each pair between from and to, highest entry spread first.
+func (p *Postgres) TopSpreads(ctx context.Context, from, to time.Time, limit int) ([]arbitrage.Spread, error) {
+	rows, err := p.pool.Query(ctx, `
+		SELECT data FROM (
+			SELECT DISTINCT ON (unified_symbol, exchange_short, exchange_long) data, entry_spread
+			FROM spreads
+			WHERE time >= $1 AND time < $2
+			ORDER BY unified_symbol, exchange_short, exchange_long, entry_spread DESC
+		) best
+		ORDER BY entry_spread DESC
+		LIMIT $3`, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top spreads: %w", err)
+	}
+	defer rows.Close()
+
+	var spreads []arbitrage.Spread
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan spread: %w", err)
+		}
+		var s arbitrage.Spread
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal spread: %w", err)
+		}
+		spreads = append(spreads, s)
+	}
+	return spreads, rows.Err()
+}
+
+// PairStats returns the statistics of every pair between from and to. The theoretical PnL assumes one trade
+// per pair and hour, at the hour's best entry spread net of slippage and fees when positive.
+func (p *Postgres) PairStats(ctx context.Context, from, to time.Time) ([]report.PairStats, error) {
+	rows, err := p.pool.Query(ctx, `
+		WITH hourly AS (
+			SELECT unified_symbol, exchange_short, exchange_long,
+				COUNT(*) AS samples,
+				SUM(entry_spread) AS total,
+				MAX(entry_spread) AS best,
+				MAX(conservative_entry_spread - COALESCE((data->>'round_trip_fees_pct')::DOUBLE PRECISION, 0)) AS best_net
+			FROM spreads
+			WHERE time >= $1 AND time < $2
+			GROUP BY unified_symbol, exchange_short, exchange_long, date_trunc('hour', time)
+		)
+		SELECT unified_symbol, exchange_short, exchange_long,
+			SUM(samples)::BIGINT, SUM(total) / SUM(samples)::DOUBLE PRECISION, MAX(best), SUM(GREATEST(best_net, 0))
+		FROM hourly
+		GROUP BY unified_symbol, exchange_short, exchange_long`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pair statistics: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []report.PairStats
+	for rows.Next() {
+		var s report.PairStats
+		if err := rows.Scan(&s.UnifiedSymbol, &s.ExchangeShort, &s.ExchangeLong, &s.Samples, &s.AvgEntrySpread, &s.MaxEntrySpread, &s.TheoreticalPnLPct); err != nil {
+			return nil, fmt.Errorf("failed to scan pair statistics: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// ExchangeUptime returns the share of cycles, in percent, each exchange had tickers between from and to.
+func (p *Postgres) ExchangeUptime(ctx context.Context, from, to time.Time) (map[string]float64, error) {
+	rows, err := p.pool.Query(ctx, `
+		SELECT exchange, COUNT(*) FILTER (WHERE tickers > 0)::DOUBLE PRECISION / COUNT(*) * 100
+		FROM exchange_cycles
+		WHERE time >= $1 AND time < $2
+		GROUP BY exchange`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange uptime: %w", err)
+	}
+	defer rows.Close()
+
+	uptime := make(map[string]float64)
+	for rows.Next() {
+		var exchange string
+		var pct float64
+		if err := rows.Scan(&exchange, &pct); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange uptime: %w", err)
+		}
+		uptime[exchange] = pct
+	}
+	return uptime, rows.Err()
+}
+
+// FeedbackStats returns the statistics of the consumers' reports received between from and to.
+func (p *Postgres) FeedbackStats(ctx context.Context, from, to time.Time) (report.FeedbackStats, error) {
+	var stats report.FeedbackStats
+	err := p.pool.QueryRow(ctx, `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE action = $3),
+			COUNT(*) FILTER (WHERE action = $4),
+			COUNT(*) FILTER (WHERE NOT matched),
+			COALESCE(AVG(published_spread) FILTER (WHERE action = $3 AND matched AND realized_spread IS NOT NULL), 0),
+			COALESCE(AVG(realized_spread) FILTER (WHERE action = $3 AND matched AND realized_spread IS NOT NULL), 0),
+			COALESCE(SUM(realized_pnl_usd) FILTER (WHERE action = $3), 0)
+		FROM opportunity_feedback
+		WHERE time >= $1 AND time < $2`, from, to, feedback.ActionActed, feedback.ActionSkipped,
+	).Scan(&stats.Reports, &stats.Acted, &stats.Skipped, &stats.Unmatched, &stats.AvgPublishedSpread, &stats.AvgRealizedSpread, &stats.RealizedPnLUSD)
+	if err != nil {
+		return report.FeedbackStats{}, fmt.Errorf("failed to query feedback statistics: %w", err)
+	}
+	stats.AvgSlippage = stats.AvgPublishedSpread - stats.AvgRealizedSpread
+	if stats.Reports == 0 {
+		return stats, nil
+	}
+
+	rows, err := p.pool.Query(ctx, `
+		SELECT unified_symbol, exchange_short, exchange_long, COUNT(*), AVG(published_spread), AVG(realized_spread)
+		FROM opportunity_feedback
+		WHERE time >= $1 AND time < $2 AND action = $3 AND matched AND realized_spread IS NOT NULL
+		GROUP BY unified_symbol, exchange_short, exchange_long
+		ORDER BY COUNT(*) DESC, unified_symbol, exchange_short, exchange_long`, from, to, feedback.ActionActed)
+	if err != nil {
+		return report.FeedbackStats{}, fmt.Errorf("failed to query feedback pairs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pair report.FeedbackPair
+		if err := rows.Scan(&pair.UnifiedSymbol, &pair.ExchangeShort, &pair.ExchangeLong, &pair.Samples, &pair.AvgPublishedSpread, &pair.AvgRealizedSpread); err != nil {
+			return report.FeedbackStats{}, fmt.Errorf("failed to scan feedback pair: %w", err)
+		}
+		stats.Pairs = append(stats.Pairs, pair)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,122 @@
+// Package polling adapts the interval of the main loop's cycles: faster while the top spreads move a lot, so
+// short-lived opportunities aren't missed, and slower while the exchanges signal rate limit pressure, so the
+// scanner isn't banned, within configured bounds.
+package polling
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// topSpreads is the number of best spreads whose moves measure the volatility.
+const topSpreads = 20
+
+// Options configures an adaptive interval.
+type Options struct {
+	Base          time.Duration // Interval while neither signal fires, returned to gradually
+	Min           time.Duration // Shortest interval, however volatile the spreads
+	Max           time.Duration // Longest interval, however heavy the rate limit pressure
+	VolatilityBps float64       // Mean move of the top spreads between cycles, in basis points, above which polling speeds up
+	Pressure      float64       // Fraction of an exchange's rate limit used above which polling slows down, from 0 to 1
+}
+
+// Controller adapts the poll interval from the spreads of each cycle and the exchanges' rate limit pressure:
+// pressure doubles the interval, volatility halves it, and otherwise it returns halfway to the base each
+// cycle. Pressure wins over volatility. It is safe for concurrent use.
+type Controller struct {
+	mu         sync.Mutex
+	opts       Options
+	interval   time.Duration
+	usage      map[string]float64 // Highest rate limit usage per exchange since the last cycle
+	spreads    map[string]float64 // Entry spread per pair key in the last cycle
+	volatility float64            // Mean move of the top spreads in the last cycle, in basis points
+}
+
+// New creates a controller starting at the base interval.
+func New(opts Options) *Controller {
+	return &Controller{opts: opts, interval: opts.Base, usage: make(map[string]float64)}
+}
+
+// Interval returns the current interval.
+func (c *Controller) Interval() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.interval
+}
+
+// ObserveUsage records the fraction of an exchange's rate limit a request used, 1 when it was rate limited.
+func (c *Controller) ObserveUsage(exchange string, usage float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usage[exchange] = max(c.usage[exchange], usage)
+}
+
+// ObserveSpreads measures the volatility from the spreads of a cycle: the mean move, in basis points, of the
+// entry spreads of the best pairs since the previous cycle. Pairs new to the cycle don't count.
+func (c *Controller) ObserveSpreads(spreads []arbitrage.Spread) {
+	current := make(map[string]float64, len(spreads))
+	for _, s := range spreads {
+		current[s.PairKey()] = s.EntrySpread
+	}
+	top := slices.SortedFunc(maps.Keys(current), func(a, b string) int { return cmp.Compare(current[b], current[a]) })
+	top = top[:min(len(top), topSpreads)]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var moves float64
+	var n int
+	for _, key := range top {
+		if last, ok := c.spreads[key]; ok {
+			moves += math.Abs(current[key]-last) * 100 // Percent to basis points
+			n++
+		}
+	}
+	c.volatility = 0
+	if n > 0 {
+		c.volatility = moves / float64(n)
+	}
+	c.spreads = current
+}
+
+// Next adapts the interval to the signals observed since the last call, clears the rate limit usage, and
+// returns the interval with the reason it changed ("pressure", "volatility" or "relax"), "" if it didn't.
+func (c *Controller) Next() (time.Duration, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var usage float64
+	for _, u := range c.usage {
+		usage = max(usage, u)
+	}
+	clear(c.usage)
+
+	next, reason := c.interval, ""
+	switch {
+	case c.opts.Pressure > 0 && usage >= c.opts.Pressure:
+		next, reason = c.interval*2, "pressure"
+	case c.opts.VolatilityBps > 0 && c.volatility >= c.opts.VolatilityBps:
+		next, reason = c.interval/2, "volatility"
+	case c.interval != c.opts.Base:
+		next, reason = c.interval+(c.opts.Base-c.interval)/2, "relax"
+		if d := next - c.opts.Base; d.Abs() < c.opts.Base/10 {
+			next = c.opts.Base
+		}
+	}
+	next = min(max(next, c.opts.Min), c.opts.Max)
+	if next == c.interval {
+		return next, ""
+	}
+	c.interval = next
+	return next, reason
+}
+
+// Volatility returns the volatility measured in the last cycle, in basis points.
+func (c *Controller) Volatility() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.volatility
+}
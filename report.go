@@ -0,0 +1,88 @@
+package main
+
+import (
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/persistence"
+	"cex-price-diff-notifications/report"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// runReport prints the summary report of the last complete day or week, built from the spreads stored in
+// Postgres, as text or JSON. The report is neither published nor written to REPORT_DIR.
+func runReport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	period := fs.String("period", report.PeriodDaily, "period to summarize: daily or weekly")
+	top := fs.Int("top", cfg.ReportTop, "number of opportunities and pairs to list")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *period != report.PeriodDaily && *period != report.PeriodWeekly {
+		return fmt.Errorf("unknown period %q, must be daily or weekly", *period)
+	}
+	if cfg.PostgresURL == "" {
+		return errors.New("no Postgres database, set POSTGRES_URL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	postgres, err := persistence.NewPostgres(ctx, cfg.PostgresURL)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	from, to := report.Last(*period, time.Now())
+	r, err := report.Generate(ctx, postgres, *period, from, to, *top, cfg.DefaultNotionalUSD)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	fmt.Println(r.Title())
+	fmt.Println(r.Text())
+	return nil
+}
+
+// publishReport builds the summary report of a period, writes it to REPORT_DIR and publishes it as a
+// summary_report event, for the notifier channels. Failures are logged.
+func publishReport(ctx context.Context, cfg *config.Config, postgres *persistence.Postgres, encoder messaging.Encoder, publisher *messaging.Fanout, period string, from, to time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	r, err := report.Generate(ctx, postgres, period, from, to, cfg.ReportTop, cfg.DefaultNotionalUSD)
+	if err != nil {
+		slog.Error("Failed to generate the summary report", "period", period, "error", err)
+		return
+	}
+	path, err := report.WriteJSON(cfg.ReportDir, r)
+	if err != nil {
+		slog.Error("Failed to write the summary report", "period", period, "error", err)
+	}
+	slog.Info("Summary report generated", "period", period, "from", from, "to", to, "pairs", len(r.Pairs), "path", path)
+
+	body, err := encoder.Encode(messaging.EventSummaryReport, r)
+	if err != nil {
+		slog.Error("Failed to marshal summary report to JSON", "error", err)
+		return
+	}
+	err = publisher.Publish(context.WithoutCancel(ctx), messaging.Event{
+		Type:       messaging.EventSummaryReport,
+		Key:        "report",
+		RoutingKey: "report." + period,
+		Body:       body,
+	})
+	if err != nil {
+		slog.Error("Failed to publish the summary report", "error", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Package report summarizes the stored spread history over a day or a week: the best opportunities, the
+// average spread of each pair, the theoretical PnL of trading them, the uptime of each exchange and, when
+// consumers report back, the spreads they realized against those published. Reports are generated on a
+// schedule, published to the notifier channels and kept as JSON files.
+package report
+
+import (
+	"cex-price-diff-notifications/arbitrage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Report periods. Days and weeks end at midnight UTC, weeks on Monday.
+const (
+	PeriodDaily  = "daily"
+	PeriodWeekly = "weekly"
+)
+
+// delay lets the last cycle of a period be stored before its report is generated.
+const delay = time.Minute
+
+// PairStats aggregates the stored spreads of a symbol-pair and direction.
+type PairStats struct {
+	UnifiedSymbol     string  `json:"unified_symbol"`
+	ExchangeShort     string  `json:"exchange_short"`
+	ExchangeLong      string  `json:"exchange_long"`
+	Samples           int64   `json:"samples"`             // Stored spreads, one per cycle the pair was computed
+	AvgEntrySpread    float64 `json:"avg_entry_spread"`    // In percent
+	MaxEntrySpread    float64 `json:"max_entry_spread"`    // In percent
+	TheoreticalPnLPct float64 `json:"theoretical_pnl_pct"` // See Store.PairStats
+}
+
+// FeedbackStats summarizes the consumers' reports received over a period, comparing the spreads they realized
+// with those published. The spread averages are over the matched acted reports with a realized spread.
+type FeedbackStats struct {
+	Reports            int64          `json:"reports"`
+	Acted              int64          `json:"acted"`
+	Skipped            int64          `json:"skipped"`
+	Unmatched          int64          `json:"unmatched"`            // Reports on opportunities not known as published
+	AvgPublishedSpread float64        `json:"avg_published_spread"` // In percent
+	AvgRealizedSpread  float64        `json:"avg_realized_spread"`  // In percent
+	AvgSlippage        float64        `json:"avg_slippage"`         // Published minus realized, in percentage points
+	RealizedPnLUSD     float64        `json:"realized_pnl_usd"`     // Sum of the acted reports that have one
+	Pairs              []FeedbackPair `json:"pairs,omitempty"`      // By descending samples
+}
+
+// FeedbackPair compares the realized and published spreads of a symbol-pair and direction.
+type FeedbackPair struct {
+	UnifiedSymbol      string  `json:"unified_symbol"`
+	ExchangeShort      string  `json:"exchange_short"`
+	ExchangeLong       string  `json:"exchange_long"`
+	Samples            int64   `json:"samples"` // Matched acted reports with a realized spread
+	AvgPublishedSpread float64 `json:"avg_published_spread"`
+	AvgRealizedSpread  float64 `json:"avg_realized_spread"`
+}
+
+// Store is the stored history a report is built from.
+type Store interface {
+	// TopSpreads returns the best spread of each pair between from and to, highest entry spread first.
+	TopSpreads(ctx context.Context, from, to time.Time, limit int) ([]arbitrage.Spread, error)
+	// PairStats returns the statistics of every pair between from and to. The theoretical PnL assumes one
+	// trade per pair and hour, at the hour's best entry spread net of slippage and fees when positive.
+	PairStats(ctx context.Context, from, to time.Time) ([]PairStats, error)
+	// ExchangeUptime returns the share of cycles, in percent, each exchange had tickers between from and to.
+	ExchangeUptime(ctx context.Context, from, to time.Time) (map[string]float64, error)
+	// FeedbackStats returns the statistics of the consumers' reports received between from and to.
+	FeedbackStats(ctx context.Context, from, to time.Time) (FeedbackStats, error)
+}
+
+// Report is the summary of a period.
+type Report struct {
+	Period            string             `json:"period"`
+	From              time.Time          `json:"from"`
+	To                time.Time          `json:"to"`
+	GeneratedAt       time.Time          `json:"generated_at"`
+	TopOpportunities  []arbitrage.Spread `json:"top_opportunities"`
+	Pairs             []PairStats        `json:"pairs"` // By descending average entry spread
+	TheoreticalPnLPct float64            `json:"theoretical_pnl_pct"`
+	NotionalUSD       float64            `json:"notional_usd"` // Per leg, for TheoreticalPnLUSD
+	TheoreticalPnLUSD float64            `json:"theoretical_pnl_usd"`
+	Uptime            map[string]float64 `json:"uptime"`             // Percent of the cycles, keyed by exchange
+	Feedback          *FeedbackStats     `json:"feedback,omitempty"` // Only when consumers reported back
+}
+
+// Generate builds the report of a period from store. top bounds the opportunities listed, and notionalUSD
+// converts the theoretical PnL to dollars.
+func Generate(ctx context.Context, store Store, period string, from, to time.Time, top int, notionalUSD float64) (*Report, error) {
+	spreads, err := store.TopSpreads(ctx, from, to, top)
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := store.PairStats(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	uptime, err := store.ExchangeUptime(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	feedback, err := store.FeedbackStats(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(pairs, func(a, b PairStats) int {
+		if a.AvgEntrySpread != b.AvgEntrySpread {
+			if a.AvgEntrySpread > b.AvgEntrySpread {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.UnifiedSymbol, b.UnifiedSymbol)
+	})
+	r := &Report{
+		Period:           period,
+		From:             from,
+		To:               to,
+		GeneratedAt:      time.Now(),
+		TopOpportunities: spreads,
+		Pairs:            pairs,
+		NotionalUSD:      notionalUSD,
+		Uptime:           uptime,
+	}
+	if feedback.Reports > 0 {
+		r.Feedback = &feedback
+	}
+	for _, p := range pairs {
+		r.TheoreticalPnLPct += p.TheoreticalPnLPct
+	}
+	r.TheoreticalPnLUSD = r.TheoreticalPnLPct / 100 * notionalUSD
+	return r, nil
+}
+
+// Title names the report, e.g., "Daily report 2026-10-15".
+func (r *Report) Title() string {
+	title := "Daily report " + r.From.UTC().Format(time.DateOnly)
+	if r.Period == PeriodWeekly {
+		title = "Weekly report " + r.From.UTC().Format(time.DateOnly) + " to " + r.To.UTC().Add(-time.Nanosecond).Format(time.DateOnly)
+	}
+	return title
+}
+
+// Summary is a one-line digest of the report, for notifications.
+func (r *Report) Summary() string {
+	summary := fmt.Sprintf("%d pairs, theoretical PnL %.3f%% ($%.2f)", len(r.Pairs), r.TheoreticalPnLPct, r.TheoreticalPnLUSD)
+	if len(r.TopOpportunities) > 0 {
+		s := r.TopOpportunities[0]
+		summary += fmt.Sprintf(", best %s %.3f%% (%s -> %s)", s.UnifiedSymbol, s.EntrySpread, s.ExchangeLong, s.ExchangeShort)
+	}
+	if f := r.Feedback; f != nil {
+		summary += fmt.Sprintf(", %d acted, realized %.3f%% vs %.3f%% published", f.Acted, f.AvgRealizedSpread, f.AvgPublishedSpread)
+	}
+	return summary
+}
+
+// Text renders the report as plain text with aligned columns, listing as many pairs as top opportunities.
+func (r *Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s to %s\n", r.From.UTC().Format("2006-01-02 15:04"), r.To.UTC().Format("2006-01-02 15:04 MST"))
+	fmt.Fprintf(&b, "Theoretical PnL: %.3f%% ($%.2f on $%.0f per leg)\n", r.TheoreticalPnLPct, r.TheoreticalPnLUSD, r.NotionalUSD)
+
+	b.WriteString("\nTop opportunities:\n")
+	if len(r.TopOpportunities) == 0 {
+		b.WriteString("  none\n")
+	}
+	for i, s := range r.TopOpportunities {
+		fmt.Fprintf(&b, "%2d. %-18s %-8s -> %-8s %7.3f%%\n", i+1, s.UnifiedSymbol, s.ExchangeLong, s.ExchangeShort, s.EntrySpread)
+	}
+
+	b.WriteString("\nBest average spreads:\n")
+	if len(r.Pairs) == 0 {
+		b.WriteString("  none\n")
+	}
+	for i, p := range r.Pairs[:min(len(r.TopOpportunities), len(r.Pairs))] {
+		fmt.Fprintf(&b, "%2d. %-18s %-8s -> %-8s %7.3f%% avg, %7.3f%% max\n", i+1, p.UnifiedSymbol, p.ExchangeLong, p.ExchangeShort, p.AvgEntrySpread, p.MaxEntrySpread)
+	}
+
+	b.WriteString("\nUptime:\n")
+	if len(r.Uptime) == 0 {
+		b.WriteString("  unknown\n")
+	}
+	for _, exchange := range slices.Sorted(maps.Keys(r.Uptime)) {
+		fmt.Fprintf(&b, "  %-8s %6.2f%%\n", exchange, r.Uptime[exchange])
+	}
+
+	if f := r.Feedback; f != nil {
+		b.WriteString("\nConsumer feedback:\n")
+		fmt.Fprintf(&b, "  %d reports: %d acted, %d skipped, %d unmatched\n", f.Reports, f.Acted, f.Skipped, f.Unmatched)
+		fmt.Fprintf(&b, "  Realized %.3f%% vs %.3f%% published (%.3f points slippage), PnL $%.2f\n", f.AvgRealizedSpread, f.AvgPublishedSpread, f.AvgSlippage, f.RealizedPnLUSD)
+		for i, p := range f.Pairs[:min(len(r.TopOpportunities), len(f.Pairs))] {
+			fmt.Fprintf(&b, "%2d. %-18s %-8s -> %-8s %7.3f%% realized, %7.3f%% published (%d)\n", i+1, p.UnifiedSymbol, p.ExchangeLong, p.ExchangeShort, p.AvgRealizedSpread, p.AvgPublishedSpread, p.Samples)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// WriteJSON writes the report to dir as <period>-<start date>.json, replacing an earlier one, and returns
+// the file's path.
+func WriteJSON(dir string, r *Report) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create the report directory: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+	path := filepath.Join(dir, r.Period+"-"+r.From.UTC().Format(time.DateOnly)+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return path, nil
+}
+
+// End returns the end of the period after t: the next midnight UTC for daily reports, the next Monday
+// midnight UTC for weekly ones.
+func End(period string, t time.Time) time.Time {
+	t = t.UTC()
+	end := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+	if period == PeriodWeekly {
+		end = end.AddDate(0, 0, (8-int(end.Weekday()))%7)
+	}
+	return end
+}
+
+// Start returns the start of the period ending at end.
+func Start(period string, end time.Time) time.Time {
+	if period == PeriodWeekly {
+		return end.AddDate(0, 0, -7)
+	}
+	return end.AddDate(0, 0, -1)
+}
+
+// Last returns the bounds of the last complete period before t.
+func Last(period string, t time.Time) (from, to time.Time) {
+	to = Start(period, End(period, t))
+	return Start(period, to), to
+}
+
+// Schedule calls generate with the bounds of each period of periods as it ends, shortly after so its last
+// cycle is stored. It blocks until ctx is cancelled and should be run in a goroutine.
+func Schedule(ctx context.Context, periods []string, generate func(period string, from, to time.Time)) {
+	for {
+		var next time.Time
+		for _, p := range periods {
+			if end := End(p, time.Now()); next.IsZero() || end.Before(next) {
+				next = end
+			}
+		}
+		slog.Info("Next summary report scheduled", "at", next.Add(delay))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next.Add(delay))):
+		}
+		for _, p := range periods {
+			if End(p, next.Add(-time.Nanosecond)).Equal(next) {
+				generate(p, Start(p, next), next)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PruneFunc deletes the data of a store older than cutoff and returns how many items (rows, keys, files or
+// entries) it removed.
+type PruneFunc func(ctx context.Context, cutoff time.Time) (int64, error)
+
+// job prunes one kind of data with its own retention window.
+type job struct {
+	name      string
+	retention time.Duration
+	prune     PruneFunc
+}
+
+// Runner periodically prunes stored history, so long-running deployments don't grow unbounded.
+type Runner struct {
+	jobs []job
+}
+
+// NewRunner creates a runner without jobs.
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Add registers a job keeping retention worth of data (e.g., "postgres_spreads"). Jobs with a zero
+// retention are ignored, keeping their data forever.
+func (r *Runner) Add(name string, retention time.Duration, prune PruneFunc) {
+	if retention <= 0 {
+		return
+	}
+	r.jobs = append(r.jobs, job{name: name, retention: retention, prune: prune})
+	slog.Info("Retention job added", "job", name, "retention", retention)
+}
+
+// Len returns the number of jobs.
+func (r *Runner) Len() int {
+	return len(r.jobs)
+}
+
+// RunOnce runs every job once. A failing job is logged and doesn't stop the others.
+func (r *Runner) RunOnce(ctx context.Context) {
+	for _, j := range r.jobs {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		start := time.Now()
+		removed, err := j.prune(ctx, start.Add(-j.retention))
+		cancel()
+		if err != nil {
+			slog.Error("Failed to run retention job", "job", j.name, "error", err)
+			continue
+		}
+		slog.Info("Retention job complete", "job", j.name, "removed", removed, "duration", time.Since(start))
+	}
+}
+
+// Run runs the jobs immediately and then every interval. It blocks until ctx is cancelled and should be run in a goroutine.
+func (r *Runner) Run(ctx context.Context, interval time.Duration) {
+	r.RunOnce(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
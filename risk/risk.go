@@ -0,0 +1,198 @@
+// Package risk enforces position limits before a position is opened, whether simulated (backtests) or live
+// (the execute command): the notional per symbol, the number of open positions, the exposure per exchange
+// and a global kill switch.
+package risk
+
+import (
+	"cex-price-diff-notifications/shared"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrKillSwitch       = errors.New("kill switch engaged")
+	ErrMaxOpenPositions = errors.New("too many open positions")
+	ErrSymbolNotional   = errors.New("symbol notional limit exceeded")
+	ErrExchangeExposure = errors.New("exchange exposure limit exceeded")
+)
+
+// Limits are the risk limits. Zero values don't limit.
+type Limits struct {
+	MaxSymbolNotional   float64            // Largest notional per leg of all the positions in one symbol
+	MaxOpenPositions    int                // Most positions open at once
+	MaxExchangeExposure map[string]float64 // Largest notional of all the legs on an exchange, keyed by exchange
+	KillSwitch          bool               // Refuse every new position
+	KillSwitchFile      string             // Refuse every new position while this file exists, e.g., to halt from a shell
+}
+
+// Position is an open position across exchanges, usually both legs of a spread.
+type Position struct {
+	ID            string             // Unique among the open positions, e.g., the spread's pair key
+	UnifiedSymbol string             // e.g., "BTC/USDT:PERP"
+	Notional      map[string]float64 // Notional of each leg, keyed by exchange
+}
+
+// legNotional returns the notional of the largest leg.
+func (p Position) legNotional() float64 {
+	var largest float64
+	for _, n := range p.Notional {
+		largest = max(largest, math.Abs(n))
+	}
+	return largest
+}
+
+// Manager tracks the open positions and checks new ones against the limits. It is safe for concurrent use.
+type Manager struct {
+	mu        sync.Mutex
+	limits    Limits
+	positions map[string]Position
+}
+
+// NewManager creates a manager without open positions.
+func NewManager(limits Limits) *Manager {
+	return &Manager{limits: limits, positions: make(map[string]Position)}
+}
+
+// Killed reports whether the kill switch is engaged, by configuration or by its file.
+func (m *Manager) Killed() bool {
+	if m.limits.KillSwitch {
+		return true
+	}
+	if m.limits.KillSwitchFile == "" {
+		return false
+	}
+	_, err := os.Stat(m.limits.KillSwitchFile)
+	return err == nil
+}
+
+// Check returns why opening p would break a limit, or nil if it wouldn't. Adding to an open position with
+// the same ID counts its notional once more.
+func (m *Manager) Check(p Position) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.check(p)
+}
+
+// Open records p as open if it breaks no limit. Opening an ID that is already open adds to its notional.
+func (m *Manager) Open(p Position) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.check(p); err != nil {
+		return err
+	}
+	m.add(p)
+	return nil
+}
+
+// Record records p as open whatever the limits, e.g., a position opened after a successful Check.
+func (m *Manager) Record(p Position) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.add(p)
+}
+
+// add records p as open, adding to the open position with the same ID if any.
+func (m *Manager) add(p Position) {
+	if open, ok := m.positions[p.ID]; ok {
+		notional := maps.Clone(open.Notional)
+		for exchange, n := range p.Notional {
+			notional[exchange] += n
+		}
+		p.Notional = notional
+	}
+	m.positions[p.ID] = p
+}
+
+// Close forgets an open position.
+func (m *Manager) Close(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.positions, id)
+}
+
+// Load replaces the open positions, e.g., with those of the exchange accounts.
+func (m *Manager) Load(positions []Position) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.positions = make(map[string]Position, len(positions))
+	for _, p := range positions {
+		m.positions[p.ID] = p
+	}
+}
+
+// OpenCount returns the number of open positions.
+func (m *Manager) OpenCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.positions)
+}
+
+// Exposure returns the notional of all the open legs, keyed by exchange.
+func (m *Manager) Exposure() map[string]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.exposure()
+}
+
+func (m *Manager) exposure() map[string]float64 {
+	exposure := make(map[string]float64)
+	for _, p := range m.positions {
+		for exchange, n := range p.Notional {
+			exposure[exchange] += math.Abs(n)
+		}
+	}
+	return exposure
+}
+
+func (m *Manager) check(p Position) error {
+	if m.Killed() {
+		return ErrKillSwitch
+	}
+	_, adding := m.positions[p.ID]
+	if limit := m.limits.MaxOpenPositions; limit > 0 && !adding && len(m.positions) >= limit {
+		return fmt.Errorf("%w: %d open, at most %d", ErrMaxOpenPositions, len(m.positions), limit)
+	}
+	if limit := m.limits.MaxSymbolNotional; limit > 0 {
+		notional := p.legNotional()
+		for _, open := range m.positions {
+			if open.UnifiedSymbol == p.UnifiedSymbol {
+				notional += open.legNotional()
+			}
+		}
+		if notional > limit {
+			return fmt.Errorf("%w: %s would reach %.2f, at most %.2f", ErrSymbolNotional, p.UnifiedSymbol, notional, limit)
+		}
+	}
+	if len(m.limits.MaxExchangeExposure) > 0 {
+		exposure := m.exposure()
+		for exchange, n := range p.Notional {
+			limit, ok := m.limits.MaxExchangeExposure[exchange]
+			if ok && exposure[exchange]+math.Abs(n) > limit {
+				return fmt.Errorf("%w: %s would reach %.2f, at most %.2f", ErrExchangeExposure, exchange, exposure[exchange]+math.Abs(n), limit)
+			}
+		}
+	}
+	return nil
+}
+
+// PositionsFromAccounts groups the open positions of the exchange accounts by symbol, valuing each leg at its
+// entry price, so the limits account for what is already open.
+func PositionsFromAccounts(byExchange map[string][]shared.Position) []Position {
+	bySymbol := make(map[string]Position)
+	for exchange, positions := range byExchange {
+		for _, pos := range positions {
+			p, ok := bySymbol[pos.UnifiedSymbol]
+			if !ok {
+				p = Position{ID: pos.UnifiedSymbol, UnifiedSymbol: pos.UnifiedSymbol, Notional: make(map[string]float64)}
+				bySymbol[pos.UnifiedSymbol] = p
+			}
+			p.Notional[exchange] += math.Abs(pos.Quantity * pos.EntryPrice)
+		}
+	}
+	return slices.Collect(maps.Values(bySymbol))
+}
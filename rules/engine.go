@@ -0,0 +1,133 @@
+package rules
+
+import (
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/notifier"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EngineOptions configures the destinations of the rules engine.
+type EngineOptions struct {
+	RabbitMQ          *messaging.RabbitMQ // Required by routing key destinations
+	Exchange          string              // Topic exchange of routing key destinations (empty publishes to queues by name)
+	TelegramBotToken  string              // Required by Telegram destinations
+	WebhookTimeout    time.Duration
+	WebhookMaxRetries int
+}
+
+// Engine evaluates the rules for every event and delivers it to the destinations of the matching rules.
+// It is a messaging.Publisher, so it runs as a fanout sink.
+type Engine struct {
+	opts EngineOptions
+
+	mu       sync.RWMutex // Held for reading while delivering, so SetRules waits for in-flight events
+	rules    []Rule
+	telegram map[string]*notifier.Telegram // Chat -> channel
+	webhooks map[string]*messaging.Webhook // URL -> publisher
+}
+
+// NewEngine creates an engine for the rules. It fails if a destination lacks the connection or token it needs.
+func NewEngine(rules []Rule, opts EngineOptions) (*Engine, error) {
+	e := &Engine{
+		rules:    rules,
+		opts:     opts,
+		telegram: make(map[string]*notifier.Telegram),
+		webhooks: make(map[string]*messaging.Webhook),
+	}
+	for _, rule := range rules {
+		for _, d := range rule.Destinations {
+			switch {
+			case d.RoutingKey != "" && opts.RabbitMQ == nil:
+				return nil, fmt.Errorf("rule %s routes to RabbitMQ, which isn't connected", rule.Name)
+			case d.TelegramChat != "" && opts.TelegramBotToken == "":
+				return nil, fmt.Errorf("rule %s sends to Telegram without a bot token", rule.Name)
+			case d.TelegramChat != "" && e.telegram[d.TelegramChat] == nil:
+				e.telegram[d.TelegramChat] = notifier.NewTelegram(opts.TelegramBotToken, d.TelegramChat)
+			case d.Webhook != "" && e.webhooks[d.Webhook] == nil:
+				e.webhooks[d.Webhook] = messaging.NewWebhook(messaging.WebhookOptions{
+					Targets:    []messaging.WebhookTarget{{URL: d.Webhook, Secret: d.WebhookSecret}},
+					Timeout:    opts.WebhookTimeout,
+					MaxRetries: opts.WebhookMaxRetries,
+				})
+			}
+		}
+	}
+	slog.Info("Initializing rules engine", "rules", len(rules))
+	return e, nil
+}
+
+// Publish delivers the event to the destinations of every matching rule, once per destination, until a
+// final rule matches. Events that aren't spread alerts (e.g., snapshots) are not routed.
+func (e *Engine) Publish(ctx context.Context, event messaging.Event) error {
+	alert, ok, err := notifier.Decode(event.Body)
+	if err != nil || !ok {
+		return err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	delivered := make(map[Destination]bool)
+	var errs []error
+	for _, rule := range e.rules {
+		if !rule.Match.Matches(alert) {
+			continue
+		}
+		for _, d := range rule.Destinations {
+			if delivered[d] {
+				continue
+			}
+			delivered[d] = true
+			if err := e.deliver(ctx, d, alert, event); err != nil {
+				errs = append(errs, fmt.Errorf("rule %s: %w", rule.Name, err))
+			}
+		}
+		if rule.Final {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver sends an event to a single destination.
+func (e *Engine) deliver(ctx context.Context, d Destination, alert notifier.Alert, event messaging.Event) error {
+	switch {
+	case d.RoutingKey != "":
+		return e.opts.RabbitMQ.PublishWithPriority(ctx, e.opts.Exchange, d.RoutingKey, event.Body, event.Priority)
+	case d.TelegramChat != "":
+		return e.telegram[d.TelegramChat].Send(ctx, alert)
+	default:
+		return e.webhooks[d.Webhook].Publish(ctx, event)
+	}
+}
+
+// SetRules replaces the rules, e.g., after the rules file changed. The current rules are kept if a
+// destination of the new ones lacks the connection or token it needs.
+func (e *Engine) SetRules(rules []Rule) error {
+	next, err := NewEngine(rules, e.opts)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	previous := e.webhooks
+	e.rules, e.telegram, e.webhooks = next.rules, next.telegram, next.webhooks
+	e.mu.Unlock()
+	for _, w := range previous {
+		w.Close()
+	}
+	return nil
+}
+
+// Close releases the webhook connections. The RabbitMQ connection is owned by the caller.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, w := range e.webhooks {
+		w.Close()
+	}
+	return nil
+}
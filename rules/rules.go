@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"cex-price-diff-notifications/notifier"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Funding sign conditions.
+const (
+	FundingPositive = "positive" // The short leg receives more funding than the long leg pays
+	FundingNegative = "negative" // The funding spread works against the position
+)
+
+// Condition selects alerts. Empty fields match everything.
+type Condition struct {
+	EventTypes     []string `json:"event_types"`      // Event types (e.g., "spread", "opportunity_opened")
+	Symbols        []string `json:"symbols"`          // Globs matched against the unified symbol, pair and base asset (e.g., "BTC", "*/USDT", "*:PERP")
+	Exchanges      []string `json:"exchanges"`        // Globs of "<short>-<long>" exchange pairs (e.g., "binance-*" sells on Binance)
+	MinEntrySpread float64  `json:"min_entry_spread"` // Minimum entry spread, in percent
+	MaxEntrySpread float64  `json:"max_entry_spread"` // Maximum entry spread, in percent (0 disables)
+	Funding        string   `json:"funding"`          // FundingPositive or FundingNegative; alerts without funding data don't match
+}
+
+// Destination is where a matching alert goes. Exactly one field besides WebhookSecret is set.
+type Destination struct {
+	RoutingKey    string `json:"routing_key"`    // Published to the RabbitMQ topic exchange, or to the queue of that name without one
+	TelegramChat  string `json:"telegram_chat"`  // Telegram chat ID or "@channelname", sent through the notifier bot
+	Webhook       string `json:"webhook"`        // URL receiving the event body as JSON
+	WebhookSecret string `json:"webhook_secret"` // HMAC-SHA256 signing key of the webhook (empty sends unsigned requests)
+}
+
+// Rule sends alerts matching its condition to its destinations.
+type Rule struct {
+	Name         string        `json:"name"`
+	Match        Condition     `json:"match"`
+	Destinations []Destination `json:"destinations"`
+	Final        bool          `json:"final"` // Stop evaluating later rules when this one matches
+}
+
+// Load reads and validates a JSON array of rules.
+func Load(filename string) ([]Rule, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d (%s): %w", i+1, rule.Name, err)
+		}
+	}
+	return rules, nil
+}
+
+// validate checks the rule's patterns and destinations.
+func (r Rule) validate() error {
+	for _, pattern := range slices.Concat(r.Match.Symbols, r.Match.Exchanges) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", pattern, err)
+		}
+	}
+	if r.Match.Funding != "" && r.Match.Funding != FundingPositive && r.Match.Funding != FundingNegative {
+		return fmt.Errorf("funding must be %q or %q, got %q", FundingPositive, FundingNegative, r.Match.Funding)
+	}
+	if len(r.Destinations) == 0 {
+		return errors.New("no destinations")
+	}
+	for _, d := range r.Destinations {
+		set := 0
+		for _, v := range []string{d.RoutingKey, d.TelegramChat, d.Webhook} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return errors.New("each destination needs exactly one of routing_key, telegram_chat and webhook")
+		}
+	}
+	return nil
+}
+
+// Matches reports whether an alert satisfies every part of the condition.
+func (c Condition) Matches(alert notifier.Alert) bool {
+	s := alert.Spread
+	if len(c.EventTypes) > 0 && !slices.Contains(c.EventTypes, alert.EventType) {
+		return false
+	}
+	if len(c.Symbols) > 0 {
+		pair, _, _ := strings.Cut(s.UnifiedSymbol, ":")
+		base, _, _ := strings.Cut(pair, "/")
+		if !matchesAny(c.Symbols, strings.ToUpper, s.UnifiedSymbol, pair, base) {
+			return false
+		}
+	}
+	if len(c.Exchanges) > 0 && !matchesAny(c.Exchanges, strings.ToLower, s.ExchangeShort+"-"+s.ExchangeLong) {
+		return false
+	}
+	if s.EntrySpread < c.MinEntrySpread || (c.MaxEntrySpread > 0 && s.EntrySpread > c.MaxEntrySpread) {
+		return false
+	}
+	switch c.Funding {
+	case FundingPositive:
+		return s.FundingSpread8h != nil && *s.FundingSpread8h > 0
+	case FundingNegative:
+		return s.FundingSpread8h != nil && *s.FundingSpread8h < 0
+	}
+	return true
+}
+
+// matchesAny reports whether any of the glob patterns matches any of the values, after normalizing both.
+func matchesAny(patterns []string, normalize func(string) string, values ...string) bool {
+	for _, pattern := range patterns {
+		for _, v := range values {
+			if ok, _ := path.Match(normalize(pattern), normalize(v)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UsesRabbitMQ reports whether any rule publishes to a RabbitMQ routing key.
+func UsesRabbitMQ(rules []Rule) bool {
+	return slices.ContainsFunc(rules, func(r Rule) bool {
+		return slices.ContainsFunc(r.Destinations, func(d Destination) bool { return d.RoutingKey != "" })
+	})
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"cex-price-diff-notifications/account"
+	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/api"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/chaos"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/enrichment"
+	"cex-price-diff-notifications/feedback"
+	"cex-price-diff-notifications/funding"
+	"cex-price-diff-notifications/history"
+	"cex-price-diff-notifications/latency"
+	"cex-price-diff-notifications/leader"
+	"cex-price-diff-notifications/lifecycle"
+	"cex-price-diff-notifications/maintenance"
+	"cex-price-diff-notifications/marketdata"
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/metadata"
+	"cex-price-diff-notifications/metrics"
+	"cex-price-diff-notifications/outage"
+	"cex-price-diff-notifications/polling"
+	"cex-price-diff-notifications/report"
+	"cex-price-diff-notifications/retention"
+	"cex-price-diff-notifications/rules"
+	"cex-price-diff-notifications/shard"
+	"cex-price-diff-notifications/shared"
+	"cex-price-diff-notifications/wallet"
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// scanner runs the main loop's cycles. It holds the adapters and connections set up by run, the stores kept
+// up to date by the background jobs, and the state carried from one cycle to the next.
+type scanner struct {
+	cfg *config.Config
+	*stores
+	*sinks
+
+	binanceAdapter   *adapters.BinanceAdapter
+	mexcAdapter      *adapters.MexcAdapter
+	gmxAdapter       *adapters.GmxAdapter // nil when disabled
+	health           *api.Health
+	fundingUpdatedAt map[string]func() time.Time // Exchange -> time of its last funding rate update
+	injector         *chaos.Injector             // nil without chaos mode
+	symbolShard      *shard.Shard                // nil without sharding
+	elector          *leader.Elector             // nil without leader election
+	claims           *messaging.Claims           // nil without publish deduplication
+	runtimeSettings  *api.RuntimeSettings
+
+	// Goroutines that must stop before the connections they use are closed
+	background sync.WaitGroup
+	// Configurations reloaded on SIGHUP, applied at the start of the next cycle
+	reloads chan *config.Config
+
+	// Kept up to date by the background jobs; nil when disabled
+	accounts           *account.Tracker
+	feeTiers           *account.Fees
+	fundingHistory     *funding.History
+	contractSpecs      *metadata.Store
+	enricher           *enrichment.Enricher
+	feedbackCollector  *feedback.Collector
+	walletStatus       *wallet.Store
+	transferTimes      *wallet.TransferTimes
+	maintenanceMonitor *maintenance.Monitor
+	exchangeLatency    *latency.Tracker
+
+	// Fed by the cycles; nil when disabled
+	exitSignals     *lifecycle.ExitSignals
+	spreadCandles   *history.CandleAggregator
+	orderBooks      *marketdata.OrderBooks
+	convergence     *lifecycle.Convergence
+	spreadHistory   *history.SpreadHistory
+	spotHistory     *history.SpreadHistory
+	anomalyDetector *history.AnomalyDetector
+	outages         *outage.Detector
+	apiState        *api.State
+	apiServer       *api.Server
+	wsHub           *api.Hub
+
+	symbolParams       arbitrage.ParamSet
+	scorer             arbitrage.Scorer
+	modelScorer        arbitrage.BatchScorer
+	entryGate          *lifecycle.Gate
+	alertDedup         *lifecycle.Deduplicator
+	opportunityTracker *lifecycle.Tracker
+	fundingHealth      *fundingCoverage
+	snapshotDiff       *arbitrage.SnapshotDiff
+	divergenceAlerts   *arbitrage.DivergenceAlerts
+	spreadIDs          *arbitrage.OpportunityIDs
+	spotIDs            *arbitrage.OpportunityIDs
+	fundingIDs         *arbitrage.OpportunityIDs
+	divergenceIDs      *arbitrage.OpportunityIDs
+	triangularIDs      *arbitrage.OpportunityIDs
+	spreadOpts         arbitrage.Options
+	liveSpreads        *arbitrage.LiveSpreads
+
+	tickerSchedules         map[string]*schedule
+	binanceFundingSchedule  *schedule
+	fundingCalendarSchedule *schedule
+	paused                  map[string]bool // Exchanges under maintenance, whose fetches are paused
+	tickerStore             *marketdata.Store
+	poller                  *polling.Controller // nil without adaptive polling
+	tickerFetchers          map[string]func(context.Context) ([]shared.TickerBidAsk, time.Duration, error)
+	binanceTickerBuf        []adapters.BinanceBookTickerDto
+	mexcTickerBuf           []adapters.MexcTickerDto
+
+	cycle uint64
+}
+
+// startBackgroundJobs starts the jobs that keep the stores of the cycles up to date, the HTTP API and the
+// config reloads, all stopped by cancelling ctx; wait on s.background for them to stop.
+func (s *scanner) startBackgroundJobs(ctx context.Context) {
+	cfg := s.cfg
+	binanceAdapter, mexcAdapter := s.binanceAdapter, s.mexcAdapter
+	if s.elector != nil {
+		s.background.Go(func() { s.elector.Run(ctx) })
+	}
+	if s.memoryQueue != nil {
+		s.background.Go(func() { s.memoryQueue.Drain(ctx, printMessage) })
+	}
+	if s.rabbit != nil && s.injector != nil {
+		s.background.Go(func() { s.injector.RunDisconnects(ctx, "rabbitmq", s.rabbit.Drop) })
+	}
+
+	// Futures balances decide whether an opportunity can be taken with the margin at hand
+	if cfg.AccountTracking {
+		s.accounts = account.NewTracker()
+		s.accounts.Register("Binance", binanceAdapter.GetBalances, binanceAdapter.GetPositions)
+		s.accounts.Register("Mexc", mexcAdapter.GetBalances, mexcAdapter.GetPositions)
+		s.background.Go(func() { s.accounts.Run(ctx, cfg.AccountRefresh) })
+	}
+
+	// With API keys, the fees of the accounts' tiers replace the configured taker fees
+	binanceKeys, mexcKeys := cfg.BinanceAPIKey != "" && cfg.BinanceAPISecret != "", cfg.MexcAPIKey != "" && cfg.MexcAPISecret != ""
+	if cfg.FeeTierRefresh > 0 && (binanceKeys || mexcKeys) {
+		s.feeTiers = account.NewFees()
+		if binanceKeys {
+			s.feeTiers.Register("Binance", binanceAdapter.GetFeeRates, binanceAdapter.GetSpotFeeRates)
+		}
+		if mexcKeys {
+			s.feeTiers.Register("Mexc", mexcAdapter.GetFeeRates, mexcAdapter.GetSpotFeeRates)
+		}
+		s.background.Go(func() { s.feeTiers.Run(ctx, cfg.FeeTierRefresh) })
+	}
+
+	// Entered opportunities, simulated or reported through the admin API, are watched for exit signals
+	if cfg.ExitSignalsEnabled {
+		s.exitSignals = lifecycle.NewExitSignals(cfg.ExitSignalsMinProfitPct, cfg.ExitSignalsFundingWindow, cfg.ExitSignalsMaxHold)
+	}
+
+	// Each pair's entry spread is aggregated into candles, to tell spreads that persist from one-cycle blips
+	if cfg.SpreadCandlesEnabled {
+		s.spreadCandles = history.NewCandleAggregator(cfg.SpreadCandlesTimeframes, cfg.SpreadCandlesLimit)
+	}
+
+	// Order books back the API's impact estimates and the depth cap of order sizes
+	if cfg.ImpactEnabled || cfg.OrderSizeDepth {
+		s.orderBooks = marketdata.NewOrderBooks(cfg.OrderBookDepth, cfg.OrderBookMaxAge)
+		s.orderBooks.RegisterFetcher("Binance", binanceAdapter.GetOrderBook)
+		s.orderBooks.RegisterFetcher("Mexc", mexcAdapter.GetOrderBook)
+	}
+
+	if cfg.ConvergenceEnabled {
+		s.convergence = lifecycle.NewConvergence(cfg.ConvergenceSamples, cfg.ConvergenceMinSamples)
+	}
+
+	// The HTTP API serves the latest cycle's spreads, tickers and funding rates
+	s.apiState = api.NewState()
+	if cfg.APIAddr != "" {
+		s.apiServer = api.NewServer(cfg.APIAddr, s.apiState)
+		if s.spreadCandles != nil {
+			s.apiServer.SetCandles(s.spreadCandles)
+		}
+		s.apiServer.SetScheduleHorizon(cfg.FundingScheduleHorizon)
+		if s.postgres != nil {
+			s.apiServer.SetHistory(s.postgres)
+		}
+		if s.convergence != nil {
+			s.apiServer.SetConvergence(s.convergence)
+		}
+		if cfg.ImpactEnabled {
+			s.apiServer.SetOrderBooks(s.orderBooks)
+		}
+		if cfg.WSEnabled {
+			// WebSocket clients get opened/updated/closed events whatever the publish mode
+			s.wsHub = api.NewHub(s.encoder)
+			s.apiServer.Handle("GET /ws", s.wsHub)
+		}
+		s.apiServer.Handle("GET /healthz", http.HandlerFunc(s.health.ServeLiveness))
+		s.apiServer.Handle("GET /readyz", http.HandlerFunc(s.health.ServeReadiness))
+		if cfg.MetricsEnabled {
+			s.apiServer.Handle("GET /metrics", metrics.Handler(cfg.Namespace))
+		}
+		if cfg.AdminToken != "" {
+			admin := api.NewAdmin(s.runtimeSettings, cfg.AdminToken)
+			if s.accounts != nil {
+				admin.SetAccount(func() any { return s.accounts.Snapshot() })
+			}
+			if s.exitSignals != nil {
+				admin.SetExitSignals(s.exitSignals)
+			}
+			s.apiServer.Handle("/admin/", admin)
+		}
+		s.apiServer.Start()
+	}
+
+	// SIGHUP reloads the thresholds, symbol filters and routing rules from the config and rules files, so
+	// they change without a restart losing the funding rate warmup. Other settings need a restart.
+	s.reloads = make(chan *config.Config, 1)
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	s.background.Go(func() {
+		defer signal.Stop(hupChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hupChan:
+			}
+			reloaded, err := config.Load()
+			if err != nil {
+				slog.Error("Failed to reload the configuration, keeping the current one", "error", err)
+				continue
+			}
+			if s.rulesEngine != nil {
+				routingRules, err := rules.Load(reloaded.RulesFile)
+				if err == nil {
+					err = s.rulesEngine.SetRules(routingRules)
+				}
+				if err != nil {
+					slog.Error("Failed to reload routing rules, keeping the current ones", "file", reloaded.RulesFile, "error", err)
+					continue
+				}
+			}
+			err = s.runtimeSettings.Set(api.Settings{
+				MinEntrySpreadPct:   reloaded.MinEntrySpreadPct,
+				MaxPublishedSpreads: reloaded.MaxPublishedSpreads,
+				BlockedSymbols:      reloaded.BlockedSymbols,
+			})
+			if err != nil {
+				slog.Error("Failed to reload publish settings, keeping the current ones", "error", err)
+				continue
+			}
+			// The main loop applies the rest at the start of its next cycle; an unapplied reload is replaced
+			select {
+			case <-s.reloads:
+			default:
+			}
+			s.reloads <- reloaded
+			slog.Info("Configuration reloaded",
+				"min_entry_spread_%", reloaded.MinEntrySpreadPct,
+				"max_published", reloaded.MaxPublishedSpreads,
+				"blocked_symbols", reloaded.BlockedSymbols,
+				"symbol_overrides", len(reloaded.SymbolOverrides),
+			)
+		}
+	})
+
+	// The mark price stream pushes Binance funding rates; the cycles only fetch them while it is down
+	if cfg.BinanceFundingStream {
+		s.background.Go(func() { binanceAdapter.StreamFundingRates(ctx) })
+	}
+	// The Mexc websocket pushes funding rates between the fetches, which still provide the funding intervals
+	if cfg.MexcFundingStream {
+		s.background.Go(func() { mexcAdapter.StreamFundingRates(ctx, cfg.MexcStreamSilenceTimeout) })
+	}
+
+	// Goroutine to update Mexc funding rates periodically
+	s.background.Go(func() {
+		// Run once at the start
+		duration, err := mexcAdapter.UpdateFundingRates(ctx)
+		metrics.ObserveFetch("Mexc", metrics.FetchFunding, duration, err)
+		if err != nil {
+			slog.Error("Failed to perform initial Mexc funding rate update", "error", err)
+		}
+		// Then run on its schedule
+		ticker := time.NewTicker(cfg.MexcFundingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			duration, err := mexcAdapter.UpdateFundingRates(ctx)
+			metrics.ObserveFetch("Mexc", metrics.FetchFunding, duration, err)
+			if err != nil {
+				slog.Error("Failed to update Mexc funding rates", "error", err)
+			}
+		}
+	})
+
+	// Goroutine to update Binance 24h volumes periodically
+	s.background.Go(func() {
+		// Run once at the start
+		duration, err := binanceAdapter.UpdateVolumes(ctx)
+		metrics.ObserveFetch("Binance", metrics.FetchVolumes, duration, err)
+		if err != nil {
+			slog.Error("Failed to perform initial Binance volume update", "error", err)
+		}
+		// Then run on its schedule
+		ticker := time.NewTicker(cfg.BinanceVolumeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			duration, err := binanceAdapter.UpdateVolumes(ctx)
+			metrics.ObserveFetch("Binance", metrics.FetchVolumes, duration, err)
+			if err != nil {
+				slog.Error("Failed to update Binance volumes", "error", err)
+			}
+		}
+	})
+
+	// Funding history is fetched lazily for symbols that show up in spreads
+	s.fundingHistory = funding.NewHistory(cfg.FundingHistoryLimit, cfg.FundingHistoryTTL)
+	s.fundingHistory.RegisterFetcher("Binance", binanceAdapter.GetFundingHistory)
+	s.fundingHistory.RegisterFetcher("Mexc", mexcAdapter.GetFundingHistory)
+	s.background.Go(func() { s.fundingHistory.Run(ctx, 10*time.Second, 5) })
+
+	// Contract specs are refreshed often enough to catch new listings, which is when the biggest spreads happen
+	s.contractSpecs = metadata.NewStore()
+	s.contractSpecs.RegisterFetcher("Binance", binanceAdapter.GetContractSpecs)
+	s.contractSpecs.RegisterFetcher("Mexc", mexcAdapter.GetContractSpecs)
+	s.contractSpecs.OnListing(func(listings []metadata.Listing) {
+		publishListings(listings, s.encoder, s.publisher, cfg.ListingEvents && s.leading())
+		// The Binance mark price stream covers every symbol; Mexc subscribes per symbol
+		if listings[0].Exchange == "Mexc" {
+			var added, removed []string
+			for _, l := range listings {
+				if l.Listed {
+					added = append(added, l.UnifiedSymbol)
+				} else {
+					removed = append(removed, l.UnifiedSymbol)
+				}
+			}
+			mexcAdapter.UpdateListings(ctx, added, removed)
+		}
+	})
+	s.background.Go(func() { s.contractSpecs.Run(ctx, cfg.ContractRefreshInterval) })
+
+	// Published events carry display names, trading pages and asset metadata, refreshed daily by default
+	if cfg.EnrichmentEnabled {
+		var coinGecko *enrichment.CoinGecko
+		if cfg.EnrichmentAssetsRefresh > 0 {
+			coinGecko = enrichment.NewCoinGecko(cfg.CoinGeckoURL, cfg.CoinGeckoAPIKey)
+		}
+		s.enricher = enrichment.NewEnricher(coinGecko, cfg.CoinGeckoIDs)
+		if coinGecko != nil {
+			s.background.Go(func() { s.enricher.Run(ctx, cfg.EnrichmentAssetsRefresh) })
+		}
+	}
+
+	// Spread history backs the rolling statistics attached to each spread
+	s.spreadHistory = history.NewSpreadHistory(cfg.SpreadHistoryWindow, cfg.SpreadHistoryMinSamples, cfg.DynamicThresholdPercentile, s.historyRedis)
+	s.spreadHistory.SetMomentum(cfg.SpreadMomentumWindow, cfg.SpreadMomentumStableBps)
+	s.spreadHistory.SetRedisKeyPrefix(cfg.RedisKey(history.RedisKeyPrefix))
+	s.spreadHistory.LoadFromRedis()
+	if cfg.SpreadAnomalyEnabled {
+		s.anomalyDetector = history.NewAnomalyDetector(cfg.SpreadAnomalyAlpha, cfg.SpreadAnomalyThreshold, cfg.SpreadAnomalyMinSamples, cfg.SpreadHistoryWindow)
+	}
+
+	// Retention jobs keep stored history from growing unbounded
+	retentionJobs := retention.NewRunner()
+	if s.postgres != nil {
+		retentionJobs.Add("postgres_spreads", cfg.RetentionSpreads, s.postgres.PruneSpreads)
+		retentionJobs.Add("postgres_tickers", cfg.RetentionTickers, s.postgres.PruneTickers)
+		retentionJobs.Add("postgres_exchange_cycles", cfg.RetentionSpreads, s.postgres.PruneExchangeCycles)
+		retentionJobs.Add("postgres_spread_candles", cfg.RetentionCandles, s.postgres.PruneCandles)
+	}
+	if s.exporter != nil {
+		retentionJobs.Add("export_spreads", cfg.RetentionExportFiles, s.exporter.PruneSpreads)
+		retentionJobs.Add("export_tickers", cfg.RetentionExportFiles, s.exporter.PruneTickers)
+	}
+	if s.recorder != nil {
+		retentionJobs.Add("recordings", cfg.RecordRetention, s.recorder.Prune)
+	}
+	if s.redisPublisher != nil && cfg.RedisStreamEnabled {
+		retentionJobs.Add("redis_stream", cfg.RetentionRedisStream, s.redisPublisher.Prune)
+	}
+	if s.historyRedis != nil && cfg.RetentionSpreadHistory {
+		retentionJobs.Add("redis_spread_history", cfg.SpreadHistoryWindow, s.spreadHistory.PruneRedis)
+	}
+	if retentionJobs.Len() > 0 {
+		s.background.Go(func() { retentionJobs.Run(ctx, cfg.RetentionInterval) })
+	}
+
+	// Consumers report back on the opportunities they acted on, matched with the published ones for the reports
+	if cfg.FeedbackQueue != "" && !s.dryRun {
+		if s.rabbit == nil {
+			slog.Warn("RabbitMQ is not configured, consumer feedback is not collected", "queue", cfg.FeedbackQueue)
+		} else {
+			s.feedbackCollector = feedback.NewCollector(s.postgres, cfg.FeedbackRetention)
+			s.background.Go(func() { consumeFeedback(ctx, s.rabbit, cfg.FeedbackQueue, s.feedbackCollector) })
+		}
+	}
+
+	// Summary reports are built from Postgres as each period ends, by the leader only
+	if len(cfg.ReportPeriods) > 0 {
+		s.background.Go(func() {
+			report.Schedule(ctx, cfg.ReportPeriods, func(period string, from, to time.Time) {
+				if !s.leading() {
+					return
+				}
+				publishReport(ctx, cfg, s.postgres, s.encoder, s.publisher, period, from, to)
+			})
+		})
+	}
+
+	// Deposit/withdrawal status decides whether spot-spot spreads can actually be executed
+	s.walletStatus = wallet.NewStore()
+	if cfg.NetworkStatusEvents {
+		s.walletStatus.OnChange(func(changes []wallet.Change) {
+			publishNetworkChanges(changes, s.encoder, s.publisher, s.leading())
+		})
+	}
+	if cfg.SpotArbEnabled || cfg.NetworkStatusEvents {
+		s.walletStatus.RegisterFetcher("Binance", binanceAdapter.GetWalletStatus)
+		s.walletStatus.RegisterFetcher("Mexc", mexcAdapter.GetWalletStatus)
+		s.background.Go(func() { s.walletStatus.Run(ctx, cfg.WalletStatusRefresh) })
+	}
+	// Transfer times decide whether a spot-spot spread outlasts moving the asset, learned from the accounts'
+	// transfer history where available
+	s.transferTimes = wallet.NewTransferTimes(cfg.TransferTimes, cfg.TransferTimeDefault)
+	if cfg.SpotArbEnabled && cfg.TransferHistoryRefresh > 0 {
+		s.transferTimes.RegisterFetcher("Binance", binanceAdapter.GetTransferHistory)
+		s.transferTimes.RegisterFetcher("Mexc", mexcAdapter.GetTransferHistory)
+		s.background.Go(func() { s.transferTimes.Run(ctx, cfg.TransferHistoryRefresh) })
+	}
+	// Spot spreads keep their own history, for the spread expected once a transfer arrives
+	s.spotHistory = history.NewSpreadHistory(cfg.SpreadHistoryWindow, cfg.SpreadHistoryMinSamples, 0, nil)
+	s.spotHistory.SetMomentum(cfg.SpreadMomentumWindow, cfg.SpreadMomentumStableBps)
+
+	// Maintenance pauses an exchange's fetches: scheduled in the config, or reported by its system status
+	s.maintenanceMonitor = maintenance.NewMonitor(cfg.MaintenanceWindows)
+	if cfg.MaintenanceStatusInterval > 0 {
+		s.maintenanceMonitor.RegisterFetcher("Binance", binanceAdapter.GetSystemStatus)
+		s.background.Go(func() { s.maintenanceMonitor.Run(ctx, cfg.MaintenanceStatusInterval) })
+	}
+
+	// Exchanges whose data went stale or whose fetches keep failing are excluded until they recover
+	s.outages = outage.NewDetector(outageOptions(cfg), time.Now())
+	s.outages.Track("Binance")
+	s.outages.Track("Mexc")
+	if s.gmxAdapter != nil {
+		s.outages.Track("GMX")
+	}
+
+	// Round-trip latencies are measured from pings only: a ticker fetch also downloads and decodes the whole payload
+	s.exchangeLatency = latency.NewTracker()
+	s.exchangeLatency.RegisterPinger("Binance", binanceAdapter.Ping)
+	s.exchangeLatency.RegisterPinger("Mexc", mexcAdapter.Ping)
+	if s.gmxAdapter != nil {
+		s.exchangeLatency.RegisterPinger("GMX", s.gmxAdapter.Ping)
+	}
+	s.background.Go(func() { s.exchangeLatency.Run(ctx, cfg.LatencyPingInterval) })
+}
+
+// initCycles sets up the state the cycles carry over: the selection of the spreads, the opportunity IDs and
+// the fetch schedules.
+func (s *scanner) initCycles() {
+	cfg := s.cfg
+	// Per-symbol parameters fall back to the global defaults
+	s.symbolParams = paramSet(cfg)
+
+	// Spreads are ranked by a composite score rather than the raw entry spread
+	s.scorer = arbitrage.WeightedScorer{Weights: arbitrage.ScoreWeights(cfg.ScoreWeights)}
+	// An external model may rescore and drop spreads after the weighted score, which it receives as a feature
+	if cfg.ScorerURL != "" {
+		s.modelScorer = arbitrage.NewHTTPScorer(cfg.ScorerURL, cfg.ScorerTimeout)
+	}
+
+	// Hysteresis keeps borderline spreads from flapping in and out of the published set
+	s.entryGate = lifecycle.NewGate(cfg.HysteresisCycles, cfg.HysteresisExitSpreadPct)
+	// Deduplication keeps still-open opportunities from being republished every cycle
+	s.alertDedup = lifecycle.NewDeduplicator(cfg.AlertCooldown, cfg.AlertMinChangeBps)
+
+	// Lifecycle tracking replaces per-cycle raw spreads with opened/updated/closed events
+	s.opportunityTracker = lifecycle.NewTracker(cfg.LifecycleMaterialChangeBps)
+	// Spreads are held back until every exchange has fresh funding rates, then marked while any is stale
+	s.fundingHealth = newFundingCoverage(s.fundingUpdatedAt, cfg.FundingMaxAge, cfg.FundingWarmupTimeout, time.Now())
+	s.snapshotDiff = arbitrage.NewSnapshotDiff(cfg.DiffMaterialChangeBps)
+	// Funding divergences alert once when they start, rather than every cycle they last
+	s.divergenceAlerts = arbitrage.NewDivergenceAlerts()
+	// Every published opportunity carries an ID derived from its pair and open time, the same on every event
+	// and every instance, so consumers can deduplicate
+	s.spreadIDs = arbitrage.NewOpportunityIDs(messaging.EventSpread, cfg.OpportunityIDBucket)
+	s.spotIDs = arbitrage.NewOpportunityIDs(messaging.EventSpotSpread, cfg.OpportunityIDBucket)
+	s.fundingIDs = arbitrage.NewOpportunityIDs(messaging.EventFundingOpportunity, cfg.OpportunityIDBucket)
+	s.divergenceIDs = arbitrage.NewOpportunityIDs(messaging.EventFundingDivergence, cfg.OpportunityIDBucket)
+	s.triangularIDs = arbitrage.NewOpportunityIDs(messaging.EventTriangularOpportunity, cfg.OpportunityIDBucket)
+
+	s.spreadOpts = spreadOptions(cfg)
+
+	// In incremental mode, only base assets with changed tickers are recomputed each cycle
+	s.liveSpreads = arbitrage.NewLiveSpreads(s.spreadOpts)
+
+	// Each exchange's tickers and Binance funding rates are fetched on their own schedules, which can be
+	// slower than the main loop; the last tickers of an exchange are reused until its next fetch
+	s.tickerSchedules = map[string]*schedule{
+		"Binance": {interval: cfg.BinanceTickerInterval},
+		"Mexc":    {interval: cfg.MexcTickerInterval},
+	}
+	if s.gmxAdapter != nil {
+		s.tickerSchedules["GMX"] = &schedule{interval: cfg.GmxTickerInterval}
+	}
+	s.binanceFundingSchedule = &schedule{interval: cfg.BinanceFundingInterval}
+	s.fundingCalendarSchedule = &schedule{interval: cfg.FundingScheduleInterval}
+	s.paused = make(map[string]bool)
+	// Fetchers write each exchange's tickers into the store; a cycle calculates from a consistent snapshot
+	s.tickerStore = marketdata.NewStore()
+
+	// With adaptive polling, the interval follows the spreads' volatility and the exchanges' rate limit pressure
+	if cfg.PollAdaptive {
+		s.poller = polling.New(polling.Options{
+			Base:          cfg.PollInterval,
+			Min:           cfg.PollIntervalMin,
+			Max:           cfg.PollIntervalMax,
+			VolatilityBps: cfg.PollVolatilityBps,
+			Pressure:      cfg.PollRateLimitPressure,
+		})
+		adapters.SetRateLimitObserver(s.poller.ObserveUsage)
+	}
+
+	// Each exchange's ticker fetcher returns its unified tickers. The ticker payloads of a cycle are decoded into
+	// those of the previous one, rather than reallocated
+	s.tickerFetchers = map[string]func(context.Context) ([]shared.TickerBidAsk, time.Duration, error){
+		"Binance": func(ctx context.Context) ([]shared.TickerBidAsk, time.Duration, error) {
+			dtos, duration, err := s.binanceAdapter.GetTickers(ctx, s.binanceTickerBuf)
+			if err != nil {
+				return nil, duration, err
+			}
+			s.binanceTickerBuf = dtos
+			return s.binanceAdapter.Tickers(dtos), duration, nil
+		},
+		// Mexc tickers carry its funding rates
+		"Mexc": func(ctx context.Context) ([]shared.TickerBidAsk, time.Duration, error) {
+			dtos, duration, err := s.mexcAdapter.GetTickers(ctx, s.mexcTickerBuf)
+			if err != nil {
+				return nil, duration, err
+			}
+			s.mexcTickerBuf = dtos
+			s.mexcAdapter.ApplyTickerFundingRates(dtos)
+			return s.mexcAdapter.Tickers(dtos), duration, nil
+		},
+	}
+	if s.gmxAdapter != nil {
+		// GMX prices come along with its funding rates
+		s.tickerFetchers["GMX"] = s.gmxAdapter.GetTickers
+	}
+}
+
+// leading reports whether this instance publishes: without leader election, every instance does.
+func (s *scanner) leading() bool {
+	return s.elector == nil || s.elector.IsLeader()
+}
+
+// shutdown stops the HTTP API and the background jobs, before the connections they use are closed.
+func (s *scanner) shutdown() {
+	if s.apiServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := s.apiServer.Shutdown(shutdownCtx); err != nil { // Let in-flight requests complete
+			slog.Error("Failed to shut down the HTTP API", "error", err)
+		}
+		cancel()
+	}
+	if s.wsHub != nil {
+		s.wsHub.Close()
+	}
+	s.background.Wait()
+}
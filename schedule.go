@@ -0,0 +1,45 @@
+package main
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"errors"
+	"time"
+)
+
+// scheduleTolerance keeps an interval that is a multiple of the poll interval from slipping a cycle when
+// the main loop's ticker fires a little early.
+const scheduleTolerance = 250 * time.Millisecond
+
+// schedule tracks when a fetch that may run less often than the main loop is next due.
+type schedule struct {
+	interval     time.Duration // 0 means every cycle
+	last         time.Time     // Start of the cycle of the last successful fetch
+	backoffUntil time.Time     // Set when the exchange rate limited the fetch
+}
+
+// due reports whether the interval has elapsed since the last successful fetch, so failed fetches are
+// retried on the next cycle, unless the exchange rate limited them.
+func (s *schedule) due(now time.Time) bool {
+	if now.Before(s.backoffUntil) {
+		return false
+	}
+	return s.interval <= 0 || now.Sub(s.last) >= s.interval-scheduleTolerance
+}
+
+// done records a successful fetch in the cycle started at cycleStart.
+func (s *schedule) done(cycleStart time.Time) {
+	s.last = cycleStart
+}
+
+// failed records a failed fetch at now. A rate limited fetch backs off for the delay the exchange asked for,
+// or backoff if none; other failures are retried on the next cycle. It returns the backoff, 0 if none.
+func (s *schedule) failed(err error, now time.Time, backoff time.Duration) time.Duration {
+	if !errors.Is(err, adapters.ErrRateLimited) {
+		return 0
+	}
+	if after := adapters.RetryAfter(err); after > 0 {
+		backoff = after
+	}
+	s.backoffUntil = now.Add(backoff)
+	return backoff
+}
@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AWSCredentials sign the requests to AWS, e.g., from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Only for temporary credentials
+}
+
+// AWSSecretsManager reads a secret of AWS Secrets Manager holding a JSON object of key-value pairs.
+type AWSSecretsManager struct {
+	region   string
+	secretID string
+	creds    AWSCredentials
+	endpoint string
+	client   *http.Client
+}
+
+// NewAWSSecretsManager creates a provider reading the secret secretID (a name or ARN) in region.
+func NewAWSSecretsManager(region, secretID string, creds AWSCredentials) *AWSSecretsManager {
+	return &AWSSecretsManager{
+		region:   region,
+		secretID: secretID,
+		creds:    creds,
+		endpoint: "https://secretsmanager." + region + ".amazonaws.com/",
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch reads the current version of the secret.
+func (a *AWSSecretsManager) Fetch(ctx context.Context) (map[string]string, error) {
+	payload, err := json.Marshal(map[string]string{"SecretId": a.secretID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode AWS request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	a.sign(req, payload, time.Now().UTC())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AWS secret: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AWS response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AWS Secrets Manager returned %s for %s: %s", resp.Status, a.secretID, body)
+	}
+	var result struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode AWS response: %w", err)
+	}
+	if result.SecretString == "" {
+		return nil, fmt.Errorf("AWS secret %s has no string value", a.secretID)
+	}
+	return decodeObject([]byte(result.SecretString))
+}
+
+// sign adds the Signature Version 4 headers of the request to the Secrets Manager API, whose path is always /
+// without a query.
+func (a *AWSSecretsManager) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	signedHeaders := "content-type;host;x-amz-date;x-amz-target"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	if a.creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.creds.SessionToken)
+		signedHeaders = "content-type;host;x-amz-date;x-amz-security-token;x-amz-target"
+		canonicalHeaders += "x-amz-security-token:" + a.creds.SessionToken + "\n"
+	}
+	canonicalHeaders += "x-amz-target:" + req.Header.Get("X-Amz-Target") + "\n"
+
+	canonicalRequest := "POST\n/\n\n" + canonicalHeaders + "\n" + signedHeaders + "\n" + hashHex(payload)
+	scope := date + "/" + a.region + "/secretsmanager/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+a.creds.SecretAccessKey), date)
+	key = hmacSHA256(key, a.region)
+	key = hmacSHA256(key, "secretsmanager")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+a.creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,113 @@
+// Package secrets loads settings such as exchange API keys and notifier tokens from a secret store (HashiCorp
+// Vault, AWS Secrets Manager or a SOPS-encrypted file) instead of plain environment variables, and re-fetches
+// them so rotated keys are picked up without a restart.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Provider retrieves secrets keyed like the environment variables they stand for, e.g., BINANCE_API_KEY.
+type Provider interface {
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// Store holds the last secrets fetched from a provider.
+type Store struct {
+	provider Provider
+	mu       sync.RWMutex
+	values   map[string]string
+}
+
+// NewStore creates a store and fetches the secrets of provider, failing if they can't be.
+func NewStore(ctx context.Context, provider Provider) (*Store, error) {
+	values, err := provider.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{provider: provider, values: values}, nil
+}
+
+// Lookup returns the secret stored under key, if any.
+func (s *Store) Lookup(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Get returns the secret stored under key, or def if there is none.
+func (s *Store) Get(key, def string) string {
+	if v, ok := s.Lookup(key); ok {
+		return v
+	}
+	return def
+}
+
+// Refresh fetches the secrets again and reports whether any changed. The current secrets are kept on error.
+func (s *Store) Refresh(ctx context.Context) (bool, error) {
+	values, err := s.provider.Fetch(ctx)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maps.Equal(values, s.values) {
+		return false, nil
+	}
+	s.values = values
+	return true, nil
+}
+
+// Run refreshes the secrets every interval and calls onChange after they changed, e.g., after a key rotation.
+// It blocks until ctx is cancelled and should be run in a goroutine.
+func (s *Store) Run(ctx context.Context, interval time.Duration, onChange func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changed, err := s.Refresh(ctx)
+		if err != nil {
+			slog.Error("Failed to refresh the secrets, keeping the current ones", "error", err)
+			continue
+		}
+		if changed {
+			slog.Info("Secrets changed, applying them")
+			onChange()
+		}
+	}
+}
+
+// decodeObject decodes a flat JSON object of secrets. Numbers and booleans are kept as written, as the
+// environment variables they stand for would be.
+func decodeObject(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to decode secrets: %w", err)
+	}
+	values := make(map[string]string, len(m))
+	for k, v := range m {
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case json.Number, bool:
+			values[k] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("secret %s is not a string", k)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,33 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// SOPSFile reads the secrets of a SOPS-encrypted file, decrypted by the sops binary with whatever keys it is
+// configured with, e.g., an age key from SOPS_AGE_KEY_FILE. The file must hold a flat map of secrets (YAML,
+// JSON or dotenv).
+type SOPSFile struct {
+	path string
+}
+
+// NewSOPSFile creates a provider reading the file at path.
+func NewSOPSFile(path string) *SOPSFile {
+	return &SOPSFile{path: path}
+}
+
+// Fetch decrypts the file, re-reading it so a re-encrypted file with rotated keys is picked up.
+func (f *SOPSFile) Fetch(ctx context.Context) (map[string]string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sops", "--decrypt", "--output-type", "json", f.path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w: %s", f.path, err, strings.TrimSpace(stderr.String()))
+	}
+	return decodeObject(out)
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Vault reads the secrets of a HashiCorp Vault KV secret, version 1 or 2.
+type Vault struct {
+	addr   string
+	token  string
+	path   string
+	client *http.Client
+}
+
+// NewVault creates a provider reading the secret at path, e.g., "secret/data/arbitrage" for a KV version 2
+// engine mounted at secret, from the Vault server at addr with token.
+func NewVault(addr, token, path string) *Vault {
+	return &Vault{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		path:   strings.Trim(path, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch reads the key-value pairs of the secret.
+func (v *Vault) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+v.path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Vault returned %s for %s: %s", resp.Status, v.path, body)
+	}
+
+	// KV version 2 nests the pairs under data.data, next to data.metadata
+	var result struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	var versioned struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(result.Data, &versioned); err == nil && versioned.Data != nil && versioned.Metadata != nil {
+		return decodeObject(versioned.Data)
+	}
+	return decodeObject(result.Data)
+}
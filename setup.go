@@ -0,0 +1,444 @@
+package main
+
+import (
+	"cex-price-diff-notifications/api"
+	"cex-price-diff-notifications/chaos"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/messaging"
+	"cex-price-diff-notifications/persistence"
+	"cex-price-diff-notifications/rules"
+	"cex-price-diff-notifications/simulator"
+	"cex-price-diff-notifications/storage"
+	"cex-price-diff-notifications/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// cleanup closes what a setup opened, in the reverse order.
+type cleanup []func()
+
+// add registers a close, run before the closes added earlier.
+func (c *cleanup) add(close func()) {
+	*c = append(*c, close)
+}
+
+// run closes everything added, the last added first.
+func (c cleanup) run() {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i]()
+	}
+}
+
+// setupTransport returns the HTTP transport of the adapters: the testnet's, rerouted to the simulator's fake
+// exchanges and wrapped by chaos mode when configured; nil for the default one. The injector is nil without
+// chaos mode.
+func setupTransport(cfg *config.Config) (http.RoundTripper, *chaos.Injector, error) {
+	transport, err := testnetTransport(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The simulator stands in for the exchanges with a URL set, so spreads can be forced in test deployments
+	if urls := simulatorURLs(cfg); len(urls) > 0 {
+		slog.Warn("Exchange simulator: requests go to fake exchanges, do not run this in production", "urls", urls)
+		transport, err = simulator.Transport(urls, transport)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set up the exchange simulator: %w", err)
+		}
+	}
+
+	// Chaos mode injects failures, to exercise the retries, reconnects and health checks end to end
+	if !cfg.ChaosEnabled {
+		return transport, nil, nil
+	}
+	slog.Warn("Chaos mode: failures are injected, do not run this in production",
+		"error_rate", cfg.ChaosErrorRate,
+		"slow_rate", cfg.ChaosSlowRate,
+		"malformed_rate", cfg.ChaosMalformedRate,
+		"publish_error_rate", cfg.ChaosPublishErrorRate,
+		"disconnect_interval", cfg.ChaosDisconnectInterval,
+	)
+	injector := chaos.New(chaos.Options{
+		ErrorRate:          cfg.ChaosErrorRate,
+		SlowRate:           cfg.ChaosSlowRate,
+		SlowDelay:          cfg.ChaosSlowDelay,
+		MalformedRate:      cfg.ChaosMalformedRate,
+		PublishErrorRate:   cfg.ChaosPublishErrorRate,
+		DisconnectInterval: cfg.ChaosDisconnectInterval,
+	})
+	return injector.Transport(transport), injector, nil
+}
+
+// stores holds the connections of the caches and the stored history; each is nil when not configured.
+type stores struct {
+	fundingRedis *redis.Client // Funding rate cache, leader lease and publish claims
+	historyRedis *redis.Client // Spread history
+	latestRedis  *redis.Client // Latest ranked opportunities
+	postgres     *persistence.Postgres
+	influx       *persistence.Influx
+	clickhouse   *persistence.ClickHouse
+	exporter     *persistence.Exporter
+	recorder     *persistence.Recorder
+}
+
+// setupStorage connects the configured caches and history stores. The returned func closes them; on an error,
+// those already connected are closed.
+func setupStorage(cfg *config.Config) (_ *stores, _ func(), err error) {
+	s := &stores{}
+	var closes cleanup
+	defer func() {
+		if err != nil {
+			closes.run()
+		}
+	}()
+
+	// Funding rates of every exchange are cached in Redis, so they survive restarts; without Redis they are
+	// kept in memory only
+	if cfg.RedisConfigured {
+		s.fundingRedis, err = storage.NewRedisClient()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect the funding rate cache to Redis: %w", err)
+		}
+		closes.add(func() { s.fundingRedis.Close() })
+	} else {
+		slog.Warn("Redis is not configured, caches are kept in memory and lost on restart")
+	}
+
+	// Spread history is optionally kept in Redis, so the rolling statistics survive restarts
+	if cfg.SpreadHistoryRedis && !cfg.RedisConfigured {
+		slog.Warn("Redis is not configured, spread history is kept in memory")
+	} else if cfg.SpreadHistoryRedis {
+		s.historyRedis, err = storage.NewRedisClient()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect spread history to Redis: %w", err)
+		}
+		closes.add(func() { s.historyRedis.Close() })
+	}
+	// The latest ranked opportunities are kept under one Redis key for dashboards and bots
+	if cfg.RedisLatestEnabled && !cfg.RedisConfigured {
+		slog.Warn("Redis is not configured, the latest snapshot is not kept")
+	} else if cfg.RedisLatestEnabled {
+		s.latestRedis, err = storage.NewRedisClient()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect the latest snapshot to Redis: %w", err)
+		}
+		closes.add(func() { s.latestRedis.Close() })
+	}
+
+	// Spreads are optionally kept in Postgres for historical analysis
+	if cfg.PostgresURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		s.postgres, err = persistence.NewPostgres(ctx, cfg.PostgresURL)
+		if err == nil {
+			closes.add(s.postgres.Close)
+			err = s.postgres.Migrate(ctx, cfg.PostgresTimescale)
+		}
+		cancel()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set up Postgres: %w", err)
+		}
+	}
+
+	// Spread, funding and latency series optionally go to InfluxDB for Grafana
+	if cfg.InfluxWriteURL != "" {
+		s.influx = persistence.NewInflux(persistence.InfluxOptions{
+			WriteURL: cfg.InfluxWriteURL,
+			Token:    cfg.InfluxToken,
+			Timeout:  cfg.InfluxTimeout,
+		})
+	}
+
+	// Every spread of every cycle optionally goes to ClickHouse for research
+	if cfg.ClickHouseURL != "" {
+		s.clickhouse, err = persistence.NewClickHouse(persistence.ClickHouseOptions{
+			URL:           cfg.ClickHouseURL,
+			Database:      cfg.ClickHouseDatabase,
+			Username:      cfg.ClickHouseUsername,
+			Password:      cfg.ClickHousePassword,
+			Table:         cfg.ClickHouseTable,
+			BatchSize:     cfg.ClickHouseBatchSize,
+			FlushInterval: cfg.ClickHouseFlushInterval,
+			MaxBuffered:   cfg.ClickHouseMaxBuffered,
+			AsyncInsert:   cfg.ClickHouseAsyncInsert,
+			CreateTable:   cfg.ClickHouseCreateTable,
+			Timeout:       cfg.ClickHouseTimeout,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set up ClickHouse: %w", err)
+		}
+		closes.add(s.clickhouse.Close)
+	}
+
+	// Spreads are optionally exported to rotating files for offline analysis
+	if cfg.ExportDir != "" {
+		s.exporter, err = persistence.NewExporter(persistence.ExportOptions{
+			Dir:            cfg.ExportDir,
+			Format:         cfg.ExportFormat,
+			Gzip:           cfg.ExportGzip,
+			MaxBytes:       cfg.ExportMaxBytes,
+			RotateInterval: cfg.ExportRotateInterval,
+			Tickers:        cfg.ExportTickers,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set up the file exporter: %w", err)
+		}
+		closes.add(func() { s.exporter.Close() })
+	}
+
+	// Raw market data is optionally recorded every cycle, for replays and for explaining past alerts
+	if cfg.RecordDir != "" {
+		s.recorder, err = persistence.NewRecorder(cfg.RecordDir, cfg.RecordRotateInterval)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set up the market data recorder: %w", err)
+		}
+		closes.add(func() { s.recorder.Close() })
+	}
+
+	// ClickHouse expires old rows itself, through a table TTL
+	if s.clickhouse != nil && cfg.RetentionSpreads > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ClickHouseTimeout)
+		if err := s.clickhouse.SetRetention(ctx, cfg.RetentionSpreads); err != nil {
+			slog.Error("Failed to set the ClickHouse retention", "error", err)
+		}
+		cancel()
+	}
+	return s, closes.run, nil
+}
+
+// sinks holds where events and opportunities are published.
+type sinks struct {
+	dryRun         bool
+	rabbit         *messaging.RabbitMQ    // nil without RabbitMQ
+	memoryQueue    *messaging.MemoryQueue // Stands in for the RabbitMQ queues without RabbitMQ configured, nil otherwise
+	maxPriority    uint8
+	publisher      *messaging.Fanout // Spread events, to every configured sink
+	redisPublisher *messaging.Redis  // nil without the Redis sink
+	rulesEngine    *rules.Engine     // nil without the rules sink
+	encoder        messaging.Encoder
+}
+
+// setupSinks connects RabbitMQ and the configured publish sinks. In a dry run, events are only logged. The
+// returned func drains the sinks and closes their connections; on an error, those already set up are closed.
+func setupSinks(cfg *config.Config, dryRun bool, health *api.Health, injector *chaos.Injector) (_ *sinks, _ func(), err error) {
+	s := &sinks{dryRun: dryRun}
+	var closes cleanup
+	defer func() {
+		if err != nil {
+			closes.run()
+		}
+	}()
+
+	// Routing rules are loaded up front, as they may need RabbitMQ
+	var routingRules []rules.Rule
+	if cfg.PublishesTo(config.PublishBackendRules) {
+		routingRules, err = rules.Load(cfg.RulesFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load routing rules from %s: %w", cfg.RulesFile, err)
+		}
+	}
+
+	// RabbitMQ is only needed when spreads go to it, or for the auxiliary opportunity queues and routing rules
+	var queues []string
+	if cfg.PublishesTo(config.PublishBackendRabbitMQ) {
+		queues = append(queues, rabbitMQQueueName)
+	}
+	if cfg.FundingArbEnabled && !dryRun {
+		queues = append(queues, rabbitMQFundingQueueName)
+	}
+	if cfg.TriangularEnabled && !dryRun {
+		queues = append(queues, rabbitMQTriangularQueueName)
+	}
+	if cfg.SpotArbEnabled && !dryRun {
+		queues = append(queues, rabbitMQSpotQueueName)
+	}
+	if cfg.PublishHeartbeat && !dryRun {
+		queues = append(queues, rabbitMQStatsQueueName)
+	}
+	if cfg.FeedbackQueue != "" && cfg.RabbitMQConfigured && !dryRun {
+		queues = append(queues, cfg.FeedbackQueue)
+	}
+	// Priorities only apply to priority queues, which RabbitMQ caps at 255
+	s.maxPriority = uint8(min(max(cfg.RabbitMQMaxPriority, 0), 255))
+	// Without RabbitMQ configured, e.g., in local runs, its queues are kept in memory and printed on stdout
+	if len(queues) > 0 && !cfg.RabbitMQConfigured {
+		slog.Warn("RabbitMQ is not configured, messages are printed on stdout instead", "queues", queues)
+		s.memoryQueue = messaging.NewMemoryQueue(cfg.PublishSinkQueueSize)
+	} else if len(queues) > 0 || rules.UsesRabbitMQ(routingRules) {
+		rabbitMQURL := messaging.RabbitMQURLFromEnv()
+		slog.Info("Connecting to RabbitMQ", "url", rabbitMQURL)
+
+		s.rabbit, err = messaging.NewRabbitMQ(messaging.RabbitMQOptions{
+			URL:                 rabbitMQURL,
+			Durable:             cfg.RabbitMQDurable,
+			Persistent:          cfg.RabbitMQPersistent,
+			Confirms:            cfg.RabbitMQConfirms,
+			ConfirmTimeout:      cfg.RabbitMQConfirmTimeout,
+			MaxResends:          cfg.RabbitMQMaxResends,
+			ReconnectMaxBackoff: cfg.RabbitMQReconnectMaxBackoff,
+			BufferSize:          cfg.RabbitMQBufferSize,
+			MessageTTL:          cfg.RabbitMQMessageTTL,
+			MaxPriority:         s.maxPriority,
+			DeadLetterExchange:  cfg.RabbitMQDeadLetterExchange,
+			Namespace:           cfg.Namespace,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set up RabbitMQ: %w", err)
+		}
+		closes.add(func() { s.rabbit.Close() })
+		health.AddCheck("rabbitmq", s.rabbit.Check)
+
+		// The dead-letter exchange has to exist before the queues referencing it
+		if cfg.RabbitMQDeadLetterExchange != "" {
+			if err := s.rabbit.DeclareDeadLetterQueue(cfg.RabbitMQDeadLetterQueue); err != nil {
+				return nil, nil, fmt.Errorf("failed to declare the RabbitMQ dead-letter queue: %w", err)
+			}
+		}
+		for _, name := range queues {
+			if err := s.rabbit.DeclareQueue(name); err != nil {
+				return nil, nil, fmt.Errorf("failed to declare a RabbitMQ queue: %w", err)
+			}
+		}
+	}
+
+	// With a topic exchange, consumers can bind to the symbols and exchanges they care about.
+	// The legacy queue stays bound to every spread, funding divergence and summary report.
+	if s.rabbit != nil && cfg.RabbitMQExchange != "" && (cfg.PublishesTo(config.PublishBackendRabbitMQ) || rules.UsesRabbitMQ(routingRules)) {
+		if err := s.rabbit.DeclareTopicExchange(cfg.RabbitMQExchange); err != nil {
+			return nil, nil, fmt.Errorf("failed to declare the RabbitMQ exchange: %w", err)
+		}
+	}
+	if s.rabbit != nil && cfg.PublishesTo(config.PublishBackendRabbitMQ) && cfg.RabbitMQExchange != "" {
+		if err := s.rabbit.BindQueue(rabbitMQQueueName, cfg.RabbitMQExchange, "spread.#"); err != nil {
+			return nil, nil, fmt.Errorf("failed to bind the legacy RabbitMQ queue: %w", err)
+		}
+		if cfg.FundingDivergenceAlerts {
+			if err := s.rabbit.BindQueue(rabbitMQQueueName, cfg.RabbitMQExchange, "funding.#"); err != nil {
+				return nil, nil, fmt.Errorf("failed to bind the legacy RabbitMQ queue: %w", err)
+			}
+		}
+		if len(cfg.ReportPeriods) > 0 {
+			if err := s.rabbit.BindQueue(rabbitMQQueueName, cfg.RabbitMQExchange, "report.#"); err != nil {
+				return nil, nil, fmt.Errorf("failed to bind the legacy RabbitMQ queue: %w", err)
+			}
+		}
+	}
+
+	// Spread events fan out to every configured sink; a failing sink doesn't hold up the others
+	s.publisher = messaging.NewFanout(cfg.PublishSinkQueueSize)
+	closes.add(func() { s.publisher.Close() })
+	if dryRun {
+		s.publisher.Add("log", messaging.Log{})
+	}
+	addSink := func(name string, p messaging.Publisher) {
+		if injector != nil {
+			p = injector.Publisher(p) // Some publishes fail in chaos mode
+		}
+		s.publisher.Add(name, p)
+	}
+	for _, sink := range cfg.PublishSinks {
+		switch sink {
+		case config.PublishBackendRabbitMQ:
+			if s.memoryQueue != nil {
+				addSink(sink, messaging.MemoryQueueSink{MemoryQueue: s.memoryQueue, Queue: rabbitMQQueueName, Exchange: cfg.RabbitMQExchange})
+				continue
+			}
+			addSink(sink, messaging.RabbitMQSink{RabbitMQ: s.rabbit, Queue: rabbitMQQueueName, Exchange: cfg.RabbitMQExchange})
+		case config.PublishBackendKafka:
+			addSink(sink, messaging.NewKafka(cfg.KafkaBrokers, cfg.Namespaced(cfg.KafkaTopic, ".")))
+		case config.PublishBackendNATS:
+			natsPublisher, err := messaging.NewNATS(messaging.NATSOptions{
+				URL:           cfg.NATSURL,
+				SubjectPrefix: cfg.Namespaced(cfg.NATSSubjectPrefix, "."),
+				Stream:        cfg.Namespaced(cfg.NATSStream, "_"), // Stream names can't have dots
+				CreateStream:  cfg.NATSCreateStream,
+				RetryAttempts: cfg.NATSRetryAttempts,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to set up NATS: %w", err)
+			}
+			addSink(sink, natsPublisher)
+			health.AddCheck("nats", natsPublisher.Check)
+		case config.PublishBackendRedis:
+			redisClient, err := storage.NewRedisClient()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to connect the Redis publisher: %w", err)
+			}
+			redisOpts := messaging.RedisOptions{StreamMaxLen: cfg.RedisStreamMaxLen}
+			if cfg.RedisStreamEnabled {
+				redisOpts.Stream = cfg.RedisKey(cfg.RedisStream)
+			}
+			if cfg.RedisPubSubEnabled {
+				redisOpts.Channel = cfg.RedisKey(cfg.RedisChannel)
+			}
+			s.redisPublisher = messaging.NewRedis(redisClient, redisOpts)
+			addSink(sink, s.redisPublisher)
+			health.AddCheck("redis_publisher", s.redisPublisher.Check)
+		case config.PublishBackendFile:
+			filePublisher, err := messaging.NewFile(cfg.FileSinkPath)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to set up the file sink: %w", err)
+			}
+			addSink(sink, filePublisher)
+		case config.PublishBackendMQTT:
+			mqttPublisher, err := messaging.NewMQTT(messaging.MQTTOptions{
+				BrokerURL:   cfg.MQTTBrokerURL,
+				ClientID:    cfg.MQTTClientID,
+				Username:    cfg.MQTTUsername,
+				Password:    cfg.MQTTPassword,
+				TopicPrefix: cfg.Namespaced(cfg.MQTTTopicPrefix, "/"),
+				QoS:         byte(min(max(cfg.MQTTQoS, 0), 2)),
+				Retain:      cfg.MQTTRetain,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to set up MQTT: %w", err)
+			}
+			addSink(sink, mqttPublisher)
+			health.AddCheck("mqtt", mqttPublisher.Check)
+		case config.PublishBackendWebhook:
+			addSink(sink, messaging.NewWebhook(messaging.WebhookOptions{
+				Targets:    cfg.WebhookTargets,
+				Timeout:    cfg.WebhookTimeout,
+				MaxRetries: cfg.WebhookMaxRetries,
+			}))
+		case config.PublishBackendRules:
+			s.rulesEngine, err = rules.NewEngine(routingRules, rules.EngineOptions{
+				RabbitMQ:          s.rabbit,
+				Exchange:          cfg.RabbitMQExchange,
+				TelegramBotToken:  cfg.TelegramBotToken,
+				WebhookTimeout:    cfg.WebhookTimeout,
+				WebhookMaxRetries: cfg.WebhookMaxRetries,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to set up the rules engine: %w", err)
+			}
+			addSink(sink, s.rulesEngine)
+		default:
+			return nil, nil, fmt.Errorf("unknown publish sink %q", sink)
+		}
+	}
+
+	// Payloads are optionally wrapped in a versioned envelope
+	s.encoder = messaging.Encoder{ProducerID: cfg.InstanceID, Envelope: cfg.EventEnvelope}
+	return s, closes.run, nil
+}
+
+// publishToQueue publishes a funding, spot or triangular opportunity or a heartbeat to its own RabbitMQ queue
+// (in memory without RabbitMQ), or logs it in a dry run.
+func (s *sinks) publishToQueue(ctx context.Context, queue string, body []byte) (err error) {
+	ctx, span := tracing.Start(ctx, "publish", attribute.String("queue", queue))
+	defer func() { tracing.End(span, err) }()
+	if s.dryRun {
+		slog.Info("Dry run, message not published", "queue", queue)
+		slog.Debug("Dry run message body", "queue", queue, "body", string(body))
+		return nil
+	}
+	if s.memoryQueue != nil {
+		return s.memoryQueue.Publish(ctx, queue, body)
+	}
+	return s.rabbit.Publish(ctx, queue, body)
+}
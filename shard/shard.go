@@ -0,0 +1,104 @@
+// Package shard splits the symbol universe across instances by consistent hashing of unified symbols, so
+// changing the number of instances only moves a fraction of the symbols.
+package shard
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// replicas is the number of points of each shard on the ring; more points spread symbols more evenly.
+const replicas = 128
+
+// point is a position on the hash ring owned by a shard.
+type point struct {
+	hash  uint64
+	shard int
+}
+
+// Shard is the share of the symbols owned by one instance. A nil Shard owns every symbol.
+type Shard struct {
+	index int
+	count int
+	ring  []point // Sorted by hash
+}
+
+// New creates shard index (from 0) of count shards. With a single shard, it returns nil, which owns every symbol.
+func New(index, count int) *Shard {
+	if count <= 1 {
+		return nil
+	}
+	ring := make([]point, 0, count*replicas)
+	for shard := range count {
+		for replica := range replicas {
+			ring = append(ring, point{hash: hash(strconv.Itoa(shard) + "#" + strconv.Itoa(replica)), shard: shard})
+		}
+	}
+	slices.SortFunc(ring, func(a, b point) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return a.shard - b.shard
+	})
+	return &Shard{index: index, count: count, ring: ring}
+}
+
+// Index returns the index of the shard, 0 for a nil Shard.
+func (s *Shard) Index() int {
+	if s == nil {
+		return 0
+	}
+	return s.index
+}
+
+// Count returns the number of shards, 1 for a nil Shard.
+func (s *Shard) Count() int {
+	if s == nil {
+		return 1
+	}
+	return s.count
+}
+
+// Owns reports whether a unified symbol (e.g., "BTC/USDT:PERP") belongs to this shard.
+func (s *Shard) Owns(unifiedSymbol string) bool {
+	if s == nil {
+		return true
+	}
+	return s.of(unifiedSymbol) == s.index
+}
+
+// of returns the shard of a symbol: the one owning the first point at or after its hash on the ring.
+func (s *Shard) of(unifiedSymbol string) int {
+	h := hash(unifiedSymbol)
+	i, _ := slices.BinarySearchFunc(s.ring, h, func(p point, h uint64) int {
+		switch {
+		case p.hash < h:
+			return -1
+		case p.hash > h:
+			return 1
+		}
+		return 0
+	})
+	if i == len(s.ring) {
+		i = 0 // Wrap around
+	}
+	return s.ring[i].shard
+}
+
+// hash is the 64-bit FNV-1a hash of a key, mixed with the SplitMix64 finalizer so that similar keys such as
+// ring points land far apart.
+func hash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,94 @@
+// Package retry retries operations that fail transiently, with exponential backoff and jitter.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how an operation is retried. The zero value tries once.
+type Policy struct {
+	MaxAttempts  int           // Attempts including the first; 0 tries once, a negative value retries until ctx is done
+	InitialDelay time.Duration // Delay before the first retry
+	MaxDelay     time.Duration // Upper bound of the delay (0 for none)
+	Multiplier   float64       // Growth of the delay per retry (0 for 2)
+	Jitter       float64       // Fraction of each delay that is randomized, from 0 to 1, so clients don't retry in lockstep
+}
+
+// Delay returns the delay before the given retry, starting at 1 for the first one.
+func (p Policy) Delay(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	d := float64(p.InitialDelay)
+	for i := 1; i < retry; i++ {
+		d *= multiplier
+		if p.MaxDelay > 0 && d >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 {
+		d = min(d, float64(p.MaxDelay))
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error that must not be retried, e.g., a 4xx response. It reads and unwraps like err.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// Do calls op until it succeeds, returns a Permanent error, the attempts run out or ctx is done, and
+// returns the last error.
+func Do(ctx context.Context, p Policy, op func(ctx context.Context) error) error {
+	return DoNotify(ctx, p, op, nil)
+}
+
+// DoNotify is Do, calling notify (if not nil) with each failure that is retried, e.g., to log it.
+func DoNotify(ctx context.Context, p Policy, op func(ctx context.Context) error, notify func(err error, retry int, delay time.Duration)) error {
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+		if errors.As(err, new(permanentError)) {
+			return err
+		}
+		if p.MaxAttempts >= 0 && attempt >= max(p.MaxAttempts, 1) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+
+		delay := p.Delay(attempt)
+		if notify != nil {
+			notify(err, attempt, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
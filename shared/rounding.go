@@ -0,0 +1,67 @@
+package shared
+
+import (
+	"math"
+	"strconv"
+)
+
+// RoundDown rounds v down to a multiple of step (v itself if step is not positive).
+func RoundDown(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	// The epsilon keeps exact multiples from dropping a step to floating-point error
+	return math.Floor(v/step+1e-9) * step
+}
+
+// RoundUp rounds v up to a multiple of step (v itself if step is not positive).
+func RoundUp(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	return math.Ceil(v/step-1e-9) * step
+}
+
+// FormatDecimal formats v with as many decimals as step has, e.g., 0.30000000000000004 with step 0.1 as "0.3".
+func FormatDecimal(v, step float64) string {
+	decimals := -1
+	if step > 0 {
+		decimals = max(0, int(math.Ceil(-math.Log10(step)-1e-9)))
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// RoundQty rounds a quantity, in base units, down to the contract's step size.
+func (c ContractSpec) RoundQty(qty float64) float64 {
+	return RoundDown(qty, c.StepSize)
+}
+
+// RoundPriceDown rounds a price down to the contract's tick size, e.g., for a sell limit.
+func (c ContractSpec) RoundPriceDown(price float64) float64 {
+	return RoundDown(price, c.TickSize)
+}
+
+// RoundPriceUp rounds a price up to the contract's tick size, e.g., for a buy limit.
+func (c ContractSpec) RoundPriceUp(price float64) float64 {
+	return RoundUp(price, c.TickSize)
+}
+
+// FormatQty formats a quantity with the decimals of the contract's step size.
+func (c ContractSpec) FormatQty(qty float64) string {
+	return FormatDecimal(qty, c.StepSize)
+}
+
+// FormatPrice formats a price with the decimals of the contract's tick size.
+func (c ContractSpec) FormatPrice(price float64) string {
+	return FormatDecimal(price, c.TickSize)
+}
+
+// Accepts reports whether an order of qty at price meets the contract's minimum quantity and notional.
+func (c ContractSpec) Accepts(qty, price float64) bool {
+	return qty > 0 && qty >= c.MinQty && qty*price >= c.MinNotional
+}
+
+// HedgeQty rounds a quantity down to the step sizes of both legs of a hedge, so each leg can trade all of it.
+func HedgeQty(qty float64, a, b ContractSpec) float64 {
+	return RoundDown(RoundDown(qty, max(a.StepSize, b.StepSize)), min(a.StepSize, b.StepSize))
+}
@@ -0,0 +1,99 @@
+package shared
+
+import "strings"
+
+// MarketType is the kind of market a unified symbol trades on.
+type MarketType string
+
+// Market types of unified symbols.
+const (
+	MarketPerp    MarketType = "PERP"    // Linear perpetual futures
+	MarketInverse MarketType = "INVERSE" // Inverse perpetual futures, margined in the base asset; never paired with linear ones
+	MarketSpot    MarketType = "SPOT"
+)
+
+// Symbol is a unified symbol, e.g., "BTC/USDT:PERP": a base asset quoted in another on a market type. The zero
+// value is not a valid symbol.
+type Symbol struct {
+	base   string
+	quote  string
+	market MarketType
+}
+
+// NewSymbol creates the symbol of base quoted in quote on a market type.
+func NewSymbol(base, quote string, market MarketType) Symbol {
+	return Symbol{base: base, quote: quote, market: market}
+}
+
+// PerpSymbol creates a perpetual symbol, e.g., "BTC/USDT:PERP".
+func PerpSymbol(base, quote string) Symbol {
+	return NewSymbol(base, quote, MarketPerp)
+}
+
+// SpotSymbol creates a spot symbol, e.g., "ETH/BTC:SPOT".
+func SpotSymbol(base, quote string) Symbol {
+	return NewSymbol(base, quote, MarketSpot)
+}
+
+// ParseSymbol parses a unified symbol (e.g., "BTC/USDT:PERP"), returning ErrInvalidUnifiedSymbol if it has no
+// base, quote or market type.
+func ParseSymbol(unifiedSymbol string) (Symbol, error) {
+	pair, market, found := strings.Cut(unifiedSymbol, ":")
+	if !found || market == "" {
+		return Symbol{}, ErrInvalidUnifiedSymbol
+	}
+	base, quote, found := strings.Cut(pair, "/")
+	if !found || base == "" || quote == "" {
+		return Symbol{}, ErrInvalidUnifiedSymbol
+	}
+	return NewSymbol(base, quote, MarketType(market)), nil
+}
+
+// String returns the unified symbol, e.g., "BTC/USDT:PERP".
+func (s Symbol) String() string {
+	return s.Pair() + ":" + string(s.market)
+}
+
+// Base returns the base asset, e.g., "BTC".
+func (s Symbol) Base() string { return s.base }
+
+// Quote returns the quote asset, e.g., "USDT".
+func (s Symbol) Quote() string { return s.quote }
+
+// Market returns the market type, e.g., MarketPerp.
+func (s Symbol) Market() MarketType { return s.market }
+
+// Pair returns the base and quote without the market type, e.g., "BTC/USDT".
+func (s Symbol) Pair() string { return s.base + "/" + s.quote }
+
+// IsPerp reports whether the symbol is a linear perpetual.
+func (s Symbol) IsPerp() bool { return s.market == MarketPerp }
+
+// IsSpot reports whether the symbol is a spot market.
+func (s Symbol) IsSpot() bool { return s.market == MarketSpot }
+
+// SymbolFormat is how an exchange writes symbols: the base and quote joined by a separator.
+type SymbolFormat struct {
+	Separator string
+}
+
+// Symbol formats of the supported exchanges.
+var (
+	BinanceSymbols = SymbolFormat{}               // e.g., "BTCUSDT"
+	MexcSymbols    = SymbolFormat{Separator: "_"} // e.g., "BTC_USDT", for futures
+)
+
+// Format returns the exchange symbol of s, e.g., "BTC_USDT".
+func (f SymbolFormat) Format(s Symbol) string {
+	return s.base + f.Separator + s.quote
+}
+
+// Parse reads an exchange symbol on a market type, splitting base and quote with the supported quote
+// currencies. It returns ErrUnsupportedQuoteCurrency if none matches.
+func (f SymbolFormat) Parse(exchangeSymbol string, market MarketType) (Symbol, error) {
+	base, quote, err := MatchQuoteCurrency(exchangeSymbol, f.Separator)
+	if err != nil {
+		return Symbol{}, err
+	}
+	return NewSymbol(base, quote, market), nil
+}
@@ -0,0 +1,148 @@
+package shared
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		unifiedSymbol string
+		want          Symbol
+		wantErr       error
+	}{
+		{unifiedSymbol: "BTC/USDT:PERP", want: PerpSymbol("BTC", "USDT")},
+		{unifiedSymbol: "BTC/USD:INVERSE", want: NewSymbol("BTC", "USD", MarketInverse)},
+		{unifiedSymbol: "ETH/BTC:SPOT", want: SpotSymbol("ETH", "BTC")},
+		{unifiedSymbol: "1000PEPE/USDC:PERP", want: PerpSymbol("1000PEPE", "USDC")},
+		{unifiedSymbol: "", wantErr: ErrInvalidUnifiedSymbol},
+		{unifiedSymbol: "BTC/USDT", wantErr: ErrInvalidUnifiedSymbol},
+		{unifiedSymbol: "BTC/USDT:", wantErr: ErrInvalidUnifiedSymbol},
+		{unifiedSymbol: "BTCUSDT:PERP", wantErr: ErrInvalidUnifiedSymbol},
+		{unifiedSymbol: "/USDT:PERP", wantErr: ErrInvalidUnifiedSymbol},
+		{unifiedSymbol: "BTC/:PERP", wantErr: ErrInvalidUnifiedSymbol},
+	}
+	for _, tt := range tests {
+		t.Run(tt.unifiedSymbol, func(t *testing.T) {
+			got, err := ParseSymbol(tt.unifiedSymbol)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+			if tt.wantErr == nil && got.String() != tt.unifiedSymbol {
+				t.Errorf("got %q formatted back, want %q", got.String(), tt.unifiedSymbol)
+			}
+		})
+	}
+}
+
+func TestSymbolFormat(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         SymbolFormat
+		quotes         []string // Supported quote currencies, in order
+		exchangeSymbol string
+		market         MarketType
+		want           Symbol
+		wantErr        error
+	}{
+		{
+			name:           "Binance perp",
+			format:         BinanceSymbols,
+			quotes:         []string{"USDT", "USDC"},
+			exchangeSymbol: "BTCUSDT",
+			market:         MarketPerp,
+			want:           PerpSymbol("BTC", "USDT"),
+		},
+		{
+			name:           "Binance inverse",
+			format:         BinanceSymbols,
+			quotes:         []string{"USDT", "USD"},
+			exchangeSymbol: "BTCUSD",
+			market:         MarketInverse,
+			want:           NewSymbol("BTC", "USD", MarketInverse),
+		},
+		{
+			name:           "Binance spot",
+			format:         BinanceSymbols,
+			quotes:         []string{"USDT", "BTC"},
+			exchangeSymbol: "ETHBTC",
+			market:         MarketSpot,
+			want:           SpotSymbol("ETH", "BTC"),
+		},
+		{
+			name:           "Mexc perp",
+			format:         MexcSymbols,
+			quotes:         []string{"USDT", "USDC"},
+			exchangeSymbol: "SOL_USDC",
+			market:         MarketPerp,
+			want:           PerpSymbol("SOL", "USDC"),
+		},
+		{
+			name:           "longer quote listed first",
+			format:         BinanceSymbols,
+			quotes:         []string{"BUSD", "USD"},
+			exchangeSymbol: "BTCBUSD",
+			market:         MarketPerp,
+			want:           PerpSymbol("BTC", "BUSD"),
+		},
+		{
+			// The order config validation rejects, as the shorter quote shadows the longer one
+			name:           "shorter quote listed first shadows the longer one",
+			format:         BinanceSymbols,
+			quotes:         []string{"USD", "BUSD"},
+			exchangeSymbol: "BTCBUSD",
+			market:         MarketPerp,
+			want:           PerpSymbol("BTCB", "USD"),
+		},
+		{
+			name:           "unsupported quote",
+			format:         BinanceSymbols,
+			quotes:         []string{"USDT", "USDC"},
+			exchangeSymbol: "ETHBTC",
+			market:         MarketPerp,
+			wantErr:        ErrUnsupportedQuoteCurrency,
+		},
+		{
+			name:           "quote without a base",
+			format:         MexcSymbols,
+			quotes:         []string{"USDT"},
+			exchangeSymbol: "_USDT",
+			market:         MarketPerp,
+			wantErr:        ErrUnsupportedQuoteCurrency,
+		},
+		{
+			name:           "Binance symbol on Mexc",
+			format:         MexcSymbols,
+			quotes:         []string{"USDT"},
+			exchangeSymbol: "BTCUSDT",
+			market:         MarketPerp,
+			wantErr:        ErrUnsupportedQuoteCurrency,
+		},
+	}
+	saved := quoteCurrencies
+	t.Cleanup(func() { quoteCurrencies = saved })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetQuoteCurrencies(tt.quotes)
+			got, err := tt.format.Parse(tt.exchangeSymbol, tt.market)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if formatted := tt.format.Format(got); formatted != tt.exchangeSymbol {
+				t.Errorf("got %q formatted back, want %q", formatted, tt.exchangeSymbol)
+			}
+			if parsed, err := ParseSymbol(got.String()); err != nil || parsed != got {
+				t.Errorf("got %#v, %v parsing %q back, want %#v", parsed, err, got.String(), got)
+			}
+		})
+	}
+}
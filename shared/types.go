@@ -1,6 +1,10 @@
 package shared
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 // TickerBidAsk represents a unified ticker information with bid and ask prices.
 type TickerBidAsk struct {
@@ -9,16 +13,145 @@ type TickerBidAsk struct {
 	Bid           float64
 	Ask           float64
 	VolumeUSD     float64
+	BidQty        float64   // Top-of-book bid size in base units, 0 if the exchange doesn't provide it
+	AskQty        float64   // Top-of-book ask size in base units, 0 if the exchange doesn't provide it
+	Timestamp     time.Time // Exchange update time, or receive time if the exchange doesn't provide one
 }
 
 // FundingRateInfo holds standardized funding rate information.
 type FundingRateInfo struct {
-	Rate           float64 `json:"rate"`
-	Interval       int     `json:"interval"` // Interval in hours
-	NextSettleTime int64   `json:"next_settle_time"`
+	Rate           float64  `json:"rate"`                 // Paid by longs to shorts each interval (negative when shorts pay)
+	RateShort      *float64 `json:"rate_short,omitempty"` // Received by shorts each interval, on venues where it isn't Rate (e.g., GMX, which charges borrowing fees on both sides)
+	Interval       int      `json:"interval"`             // Interval in hours
+	NextSettleTime int64    `json:"next_settle_time"`
+}
+
+// ShortRate returns the rate received by shorts each interval (negative when they pay).
+func (f FundingRateInfo) ShortRate() float64 {
+	if f.RateShort != nil {
+		return *f.RateShort
+	}
+	return f.Rate
+}
+
+// FundingRatePoint is a single settled funding rate from an exchange's history.
+type FundingRatePoint struct {
+	Rate       float64 `json:"rate"`
+	SettleTime int64   `json:"settle_time"` // Settlement time in milliseconds
+}
+
+// AssetInfo is the metadata of a base asset, e.g., from CoinGecko.
+type AssetInfo struct {
+	CoinGeckoID string `json:"coingecko_id"`
+	Name        string `json:"name"`               // e.g., "Bitcoin"
+	IconURL     string `json:"icon_url,omitempty"` // Square logo image
+}
+
+// ContractSpec holds the trading constraints of a perpetual contract on a single exchange.
+// Quantities are expressed in base asset units; Mexc contract counts are converted using ContractSize.
+type ContractSpec struct {
+	TickSize     float64 `json:"tick_size"`              // Minimum price increment
+	StepSize     float64 `json:"step_size"`              // Minimum quantity increment
+	MinQty       float64 `json:"min_qty"`                // Minimum order quantity
+	MinNotional  float64 `json:"min_notional,omitempty"` // Minimum order value in quote currency, if the exchange enforces one
+	MaxLeverage  int     `json:"max_leverage,omitempty"` // Maximum leverage, if exposed publicly
+	ContractSize float64 `json:"contract_size"`          // Base units per contract (1 for Binance USDⓈ-M); USD for Binance COIN-M
 }
 
 var (
 	ErrInvalidUnifiedSymbol     = errors.New("invalid unified symbol format")
 	ErrUnsupportedQuoteCurrency = errors.New("unsupported quote currency")
 )
+
+// quoteCurrencies is the ordered list of quote currencies the adapters accept.
+var quoteCurrencies = []string{"USDT", "USDC"}
+
+// SetQuoteCurrencies replaces the list of supported quote currencies. It should be called once at startup.
+func SetQuoteCurrencies(quotes []string) {
+	quoteCurrencies = append([]string(nil), quotes...)
+}
+
+// DefaultQuoteCurrency returns the first supported quote currency, assumed for symbols given by base only.
+func DefaultQuoteCurrency() string {
+	return quoteCurrencies[0]
+}
+
+// MatchQuoteCurrency splits an exchange symbol into base and quote using the supported quote currencies.
+// The separator (e.g., "_" for Mexc) is stripped from the base. Quotes are tried in configured order.
+func MatchQuoteCurrency(exchangeSymbol, separator string) (base, quote string, err error) {
+	for _, q := range quoteCurrencies {
+		if !strings.HasSuffix(exchangeSymbol, separator+q) {
+			continue
+		}
+		base = strings.TrimSuffix(exchangeSymbol, separator+q)
+		if base == "" {
+			continue
+		}
+		return base, q, nil
+	}
+	return "", "", ErrUnsupportedQuoteCurrency
+}
+
+// AssetNetwork describes whether an asset can be moved in and out of an exchange on one network.
+type AssetNetwork struct {
+	Network         string  `json:"network"` // Normalized network code (e.g., "ETH", "TRX", "BSC")
+	DepositEnabled  bool    `json:"deposit_enabled"`
+	WithdrawEnabled bool    `json:"withdraw_enabled"`
+	WithdrawFee     float64 `json:"withdraw_fee"` // Withdrawal fee in asset units
+}
+
+// Transfer is a withdrawal or deposit from an exchange account's transfer history.
+type Transfer struct {
+	Asset   string
+	Network string    // Normalized network code
+	TxID    string    // On-chain transaction ID, shared by a withdrawal and the deposit it funded
+	Time    time.Time // When the withdrawal was requested, or the deposit credited
+	Fee     float64   // Withdrawal fee in asset units; 0 for deposits
+}
+
+// TransferEstimate is the typical time and fee of moving an asset between two exchanges over a network.
+type TransferEstimate struct {
+	Duration   time.Duration
+	Source     string  // "learned" from past transfers, "config" or "default"
+	Samples    int     // Past transfers the learned duration is based on
+	TypicalFee float64 // Median fee of past withdrawals, in asset units (0 if unknown)
+}
+
+// FeeRates are an account's trading fees on one market type of an exchange.
+type FeeRates struct {
+	MakerBps float64 `json:"maker_bps"`
+	TakerBps float64 `json:"taker_bps"`
+}
+
+// PriceLevel is a price of an order book and the quantity resting at it, in base units.
+type PriceLevel struct {
+	Price float64 `json:"price"`
+	Qty   float64 `json:"qty"`
+}
+
+// OrderBook is the top of an exchange's order book for a symbol, bids best (highest) first and asks best
+// (lowest) first.
+type OrderBook struct {
+	Exchange      string       `json:"exchange"`
+	UnifiedSymbol string       `json:"unified_symbol"`
+	Bids          []PriceLevel `json:"bids"`
+	Asks          []PriceLevel `json:"asks"`
+	Timestamp     time.Time    `json:"timestamp"`
+}
+
+// Balance is the margin of one asset in an exchange's futures account.
+type Balance struct {
+	Asset     string  `json:"asset"`
+	Total     float64 `json:"total"`     // Wallet balance, including margin in use
+	Available float64 `json:"available"` // Margin available to open new positions
+}
+
+// Position is an open position in an exchange's futures account.
+type Position struct {
+	UnifiedSymbol string  `json:"unified_symbol"`
+	Symbol        string  `json:"symbol"`   // Exchange symbol
+	Quantity      float64 `json:"quantity"` // In base units, negative for shorts
+	EntryPrice    float64 `json:"entry_price"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	Leverage      int     `json:"leverage,omitempty"`
+}
@@ -0,0 +1,481 @@
+package simulator
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// streamUpgrader upgrades the market stream requests to websockets.
+var streamUpgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// fundingIntervalHours is the funding interval of every synthetic market.
+const fundingIntervalHours = 8
+
+// mexcContractSize is the base units per contract of every synthetic Mexc market.
+const mexcContractSize = 0.001
+
+// nextSettleTime returns the next funding settlement after now, in milliseconds since epoch.
+func nextSettleTime(now time.Time) int64 {
+	return now.Truncate(fundingIntervalHours * time.Hour).Add(fundingIntervalHours * time.Hour).UnixMilli()
+}
+
+// formatPrice formats a price as Binance does, as a decimal string.
+func formatPrice(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// Synthetic order books are levels depthStepPct apart from the top of the book, each resting depthLevelUSD.
+const (
+	depthStepPct  = 0.01
+	depthLevelUSD = 50_000
+	depthLevels   = 100
+)
+
+// depth returns the levels of a side of the synthetic order book of a quote, best first: bids below the bid,
+// asks above the ask.
+func depth(best float64, ask bool, levels int) []shared.PriceLevel {
+	side := -1.0
+	if ask {
+		side = 1
+	}
+	book := make([]shared.PriceLevel, min(levels, depthLevels))
+	for i := range book {
+		price := best * (1 + side*float64(i)*depthStepPct/100)
+		book[i].Price, book[i].Qty = price, depthLevelUSD/price
+	}
+	return book
+}
+
+// findQuote returns the quote of the symbol whose exchange format is symbol, as formatted by format.
+func findQuote(quotes []quote, symbol string, format func(quote) string) (quote, bool) {
+	for _, q := range quotes {
+		if format(q) == symbol {
+			return q, true
+		}
+	}
+	return quote{}, false
+}
+
+// depthLimit reads the limit query parameter of a depth request, depthLevels by default.
+func depthLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		return depthLevels
+	}
+	return limit
+}
+
+// BinanceHandler serves the Binance futures endpoints the adapters use: book tickers, order books, funding
+// (polled and streamed), 24h volumes, exchange info and ping.
+func (s *Simulator) BinanceHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /fapi/v1/ticker/bookTicker", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		quotes := s.quotes("Binance", now)
+		tickers := make([]adapters.BinanceBookTickerDto, 0, len(quotes))
+		for _, q := range quotes {
+			tickers = append(tickers, adapters.BinanceBookTickerDto{
+				Symbol:   q.Base + q.Quote,
+				BidPrice: formatPrice(q.Bid),
+				BidQty:   "1000",
+				AskPrice: formatPrice(q.Ask),
+				AskQty:   "1000",
+				Time:     now.UnixMilli(),
+			})
+		}
+		writeJSON(w, tickers)
+	})
+	mux.HandleFunc("GET /fapi/v1/depth", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		q, ok := findQuote(s.quotes("Binance", now), r.URL.Query().Get("symbol"), func(q quote) string { return q.Base + q.Quote })
+		if !ok {
+			http.Error(w, `{"code":-1121,"msg":"Invalid symbol."}`, http.StatusBadRequest)
+			return
+		}
+		dto := adapters.BinanceDepthDto{TransactionTime: now.UnixMilli()}
+		for _, l := range depth(q.Bid, false, depthLimit(r)) {
+			dto.Bids = append(dto.Bids, [2]string{formatPrice(l.Price), formatPrice(l.Qty)})
+		}
+		for _, l := range depth(q.Ask, true, depthLimit(r)) {
+			dto.Asks = append(dto.Asks, [2]string{formatPrice(l.Price), formatPrice(l.Qty)})
+		}
+		writeJSON(w, dto)
+	})
+	mux.HandleFunc("GET /fapi/v1/premiumIndex", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		quotes := s.quotes("Binance", now)
+		indexes := make([]adapters.BinancePremiumIndexDto, 0, len(quotes))
+		for _, q := range quotes {
+			indexes = append(indexes, adapters.BinancePremiumIndexDto{
+				Symbol:          q.Base + q.Quote,
+				LastFundingRate: formatPrice(q.FundingRate),
+				NextFundingTime: nextSettleTime(now),
+			})
+		}
+		writeJSON(w, indexes)
+	})
+	// The mark price stream pushes the funding of every symbol each second, like !markPrice@arr@1s
+	mux.HandleFunc("GET /ws/{stream}", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := streamUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return // The upgrader replied with the error
+		}
+		defer conn.Close()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			quotes := s.quotes("Binance", now)
+			updates := make([]adapters.BinanceMarkPriceDto, 0, len(quotes))
+			for _, q := range quotes {
+				updates = append(updates, adapters.BinanceMarkPriceDto{
+					EventType:       "markPriceUpdate",
+					EventTime:       now.UnixMilli(),
+					Symbol:          q.Base + q.Quote,
+					FundingRate:     formatPrice(q.FundingRate),
+					NextFundingTime: nextSettleTime(now),
+				})
+			}
+			if err := conn.WriteJSON(updates); err != nil {
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+	mux.HandleFunc("GET /fapi/v1/fundingInfo", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []adapters.BinanceFundingInfoDto{}) // Every market settles at the default 8h interval
+	})
+	mux.HandleFunc("GET /fapi/v1/ticker/24hr", func(w http.ResponseWriter, r *http.Request) {
+		quotes := s.quotes("Binance", time.Now())
+		stats := make([]adapters.Binance24hrTickerDto, 0, len(quotes))
+		for _, q := range quotes {
+			stats = append(stats, adapters.Binance24hrTickerDto{Symbol: q.Base + q.Quote, QuoteVolume: "100000000"})
+		}
+		writeJSON(w, stats)
+	})
+	mux.HandleFunc("GET /fapi/v1/exchangeInfo", func(w http.ResponseWriter, r *http.Request) {
+		quotes := s.quotes("Binance", time.Now())
+		info := adapters.BinanceExchangeInfoResponse{Symbols: make([]adapters.BinanceSymbolInfoDto, 0, len(quotes))}
+		for _, q := range quotes {
+			info.Symbols = append(info.Symbols, adapters.BinanceSymbolInfoDto{
+				Symbol:       q.Base + q.Quote,
+				ContractType: "PERPETUAL",
+				Status:       "TRADING",
+				Filters: []adapters.BinanceSymbolFilterDto{
+					{FilterType: "PRICE_FILTER", TickSize: "0.0001"},
+					{FilterType: "LOT_SIZE", StepSize: "0.001", MinQty: "0.001"},
+					{FilterType: "MIN_NOTIONAL", Notional: "5"},
+				},
+			})
+		}
+		writeJSON(w, info)
+	})
+	mux.HandleFunc("GET /fapi/v1/ping", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, struct{}{})
+	})
+	return mux
+}
+
+// MexcHandler serves the Mexc futures endpoints the adapters use: tickers, order books, funding, contract
+// details and ping.
+func (s *Simulator) MexcHandler() http.Handler {
+	type response struct {
+		Success bool `json:"success"`
+		Code    int  `json:"code"`
+		Data    any  `json:"data"`
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/contract/ticker", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		quotes := s.quotes("Mexc", now)
+		tickers := make([]adapters.MexcTickerDto, 0, len(quotes))
+		for _, q := range quotes {
+			tickers = append(tickers, adapters.MexcTickerDto{
+				Symbol:      q.Base + "_" + q.Quote,
+				Bid1:        q.Bid,
+				Ask1:        q.Ask,
+				Amount24:    100_000_000,
+				Timestamp:   now.UnixMilli(),
+				FundingRate: q.FundingRate,
+			})
+		}
+		writeJSON(w, response{Success: true, Data: tickers})
+	})
+	// The futures websocket pushes the funding rate of each subscribed symbol every second, and answers pings
+	mux.HandleFunc("GET /edge", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := streamUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return // The upgrader replied with the error
+		}
+		defer conn.Close()
+		// The requests are read in their own goroutine, so that every write happens below
+		requests := make(chan adapters.MexcStreamRequest)
+		go func() {
+			defer close(requests)
+			for {
+				var req adapters.MexcStreamRequest
+				if err := conn.ReadJSON(&req); err != nil {
+					return
+				}
+				requests <- req
+			}
+		}()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		subscribed := make(map[string]bool)
+		for {
+			now := time.Now()
+			var msgs []any
+			select {
+			case <-r.Context().Done():
+				return
+			case req, ok := <-requests:
+				if !ok {
+					return
+				}
+				switch req.Method {
+				case "ping":
+					msgs = append(msgs, map[string]any{"channel": "pong", "data": now.UnixMilli()})
+				case "sub.funding.rate":
+					subscribed[req.Param["symbol"]] = true
+					msgs = append(msgs, map[string]any{"channel": "rs.sub.funding.rate", "data": "success", "ts": now.UnixMilli()})
+				case "unsub.funding.rate":
+					delete(subscribed, req.Param["symbol"])
+					msgs = append(msgs, map[string]any{"channel": "rs.unsub.funding.rate", "data": "success", "ts": now.UnixMilli()})
+				}
+			case <-ticker.C:
+				for _, q := range s.quotes("Mexc", now) {
+					symbol := q.Base + "_" + q.Quote
+					if subscribed[symbol] {
+						msgs = append(msgs, map[string]any{
+							"channel": "push.funding.rate",
+							"symbol":  symbol,
+							"data":    adapters.MexcStreamFundingDto{Symbol: symbol, Rate: q.FundingRate, NextSettleTime: nextSettleTime(now)},
+							"ts":      now.UnixMilli(),
+						})
+					}
+				}
+			}
+			for _, msg := range msgs {
+				if err := conn.WriteJSON(msg); err != nil {
+					return
+				}
+			}
+		}
+	})
+	mux.HandleFunc("GET /api/v1/contract/depth/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		q, ok := findQuote(s.quotes("Mexc", now), r.PathValue("symbol"), func(q quote) string { return q.Base + "_" + q.Quote })
+		if !ok {
+			writeJSON(w, response{Code: 1001, Data: nil})
+			return
+		}
+		var book struct {
+			Bids      [][]float64 `json:"bids"`
+			Asks      [][]float64 `json:"asks"`
+			Timestamp int64       `json:"timestamp"`
+		}
+		book.Timestamp = now.UnixMilli()
+		// Quantities are in contracts of mexcContractSize base units
+		for _, l := range depth(q.Bid, false, depthLimit(r)) {
+			book.Bids = append(book.Bids, []float64{l.Price, math.Round(l.Qty / mexcContractSize), 1})
+		}
+		for _, l := range depth(q.Ask, true, depthLimit(r)) {
+			book.Asks = append(book.Asks, []float64{l.Price, math.Round(l.Qty / mexcContractSize), 1})
+		}
+		writeJSON(w, response{Success: true, Data: book})
+	})
+	mux.HandleFunc("GET /api/v1/contract/funding_rate/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		for _, q := range s.quotes("Mexc", now) {
+			if q.Base+"_"+q.Quote == r.PathValue("symbol") {
+				writeJSON(w, response{Success: true, Data: adapters.MexcFundingRateDto{
+					Symbol:         r.PathValue("symbol"),
+					FundingRate:    q.FundingRate,
+					NextSettleTime: nextSettleTime(now),
+					CollectCycle:   fundingIntervalHours,
+				}})
+				return
+			}
+		}
+		writeJSON(w, response{Code: 1001, Data: nil}) // Mexc's "contract not found"
+	})
+	mux.HandleFunc("GET /api/v1/contract/detail", func(w http.ResponseWriter, r *http.Request) {
+		quotes := s.quotes("Mexc", time.Now())
+		details := make([]adapters.MexcContractDetailDto, 0, len(quotes))
+		for _, q := range quotes {
+			details = append(details, adapters.MexcContractDetailDto{
+				Symbol:       q.Base + "_" + q.Quote,
+				ContractSize: mexcContractSize,
+				PriceUnit:    0.0001,
+				VolUnit:      1,
+				MinVol:       1,
+				MaxLeverage:  50,
+			})
+		}
+		writeJSON(w, response{Success: true, Data: details})
+	})
+	mux.HandleFunc("GET /api/v1/contract/ping", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, response{Success: true, Data: time.Now().UnixMilli()})
+	})
+	return mux
+}
+
+// ControlHandler serves the control API forcing divergences on demand:
+//
+//	GET    /divergences  the divergences in effect
+//	POST   /divergences  puts the divergence in the body in effect
+//	DELETE /divergences  ends every divergence
+//	POST   /listings     lists a new symbol on every exchange, e.g., {"base":"NEW","price":1.5}
+func (s *Simulator) ControlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /listings", func(w http.ResponseWriter, r *http.Request) {
+		var listing struct {
+			Base  string  `json:"base"`
+			Price float64 `json:"price"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&listing); err != nil {
+			http.Error(w, "invalid listing: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.List(listing.Base, listing.Price); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Info("Symbol listed", "base", listing.Base, "price", listing.Price)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /divergences", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Divergences(time.Now()))
+	})
+	mux.HandleFunc("POST /divergences", func(w http.ResponseWriter, r *http.Request) {
+		var d Divergence
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			http.Error(w, "invalid divergence: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.Diverge(d, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Info("Divergence forced", "symbol", d.Symbol, "exchange", d.Exchange, "offset_pct", d.OffsetPct, "duration_seconds", d.DurationSeconds)
+		writeJSON(w, s.Divergences(time.Now()))
+	})
+	mux.HandleFunc("DELETE /divergences", func(w http.ResponseWriter, r *http.Request) {
+		s.Clear()
+		slog.Info("Divergences cleared")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// Run moves the market a step every interval until ctx is cancelled.
+func (s *Simulator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Step()
+		}
+	}
+}
+
+// Play puts the divergences of a script in effect at their delays from now, until ctx is cancelled.
+func (s *Simulator) Play(ctx context.Context, script []Divergence) {
+	start := time.Now()
+	for _, d := range script {
+		at := start.Add(time.Duration(d.AfterSeconds * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(at)):
+		}
+		if err := s.Diverge(d, time.Now()); err != nil {
+			slog.Error("Failed to play scripted divergence", "symbol", d.Symbol, "exchange", d.Exchange, "error", err)
+			continue
+		}
+		slog.Info("Scripted divergence started", "symbol", d.Symbol, "exchange", d.Exchange, "offset_pct", d.OffsetPct, "duration_seconds", d.DurationSeconds)
+	}
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("Failed to write simulator response", "error", err)
+	}
+}
+
+// exchangeHosts are the API hosts of each exchange.
+var exchangeHosts = map[string][]string{
+	"Binance": {"fapi.binance.com", "api.binance.com"},
+	"Mexc":    {"contract.mexc.com", "api.mexc.com"},
+}
+
+// StreamURL returns the base URL of the market streams of the simulator at rawURL, e.g., "ws://simulator:8081"
+// for "http://simulator:8081".
+func StreamURL(rawURL string) string {
+	if rest, ok := strings.CutPrefix(rawURL, "https://"); ok {
+		return "wss://" + rest
+	}
+	return "ws://" + strings.TrimPrefix(rawURL, "http://")
+}
+
+// Transport routes the requests to an exchange's API hosts to the simulator serving it, keyed by exchange
+// (e.g., "Binance" -> "http://simulator:8081"), and the other requests to next (nil for the default transport).
+func Transport(urls map[string]string, next http.RoundTripper) (http.RoundTripper, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &transport{targets: make(map[string]*url.URL), next: next}
+	for exchange, rawURL := range urls {
+		hosts, ok := exchangeHosts[exchange]
+		if !ok {
+			return nil, fmt.Errorf("unknown exchange %q", exchange)
+		}
+		target, err := url.Parse(rawURL)
+		if err != nil || target.Host == "" {
+			return nil, fmt.Errorf("invalid %s simulator URL %q", exchange, rawURL)
+		}
+		for _, host := range hosts {
+			t.targets[host] = target
+		}
+	}
+	return t, nil
+}
+
+type transport struct {
+	targets map[string]*url.URL // Host -> simulator
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, ok := t.targets[strings.ToLower(req.URL.Hostname())]
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme = target.Scheme
+	req.URL.Host = target.Host
+	req.Host = target.Host
+	return t.next.RoundTrip(req)
+}
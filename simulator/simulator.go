@@ -0,0 +1,216 @@
+// Package simulator serves synthetic Binance and Mexc futures APIs whose prices follow a random walk, with
+// divergences between the exchanges that can be scripted or forced on demand, so the whole pipeline (adapters,
+// calculator, sinks) can be integration tested without the live APIs. It is for test deployments only.
+package simulator
+
+import (
+	"cex-price-diff-notifications/shared"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Exchanges are the exchanges the simulator stands in for.
+var Exchanges = []string{"Binance", "Mexc"}
+
+// Options sets up the synthetic market.
+type Options struct {
+	Symbols     int     // Number of synthetic USDT perpetuals, besides BTC and ETH
+	Volatility  float64 // Standard deviation of a mid price step, as a fraction of the price
+	Noise       float64 // Standard deviation of each exchange's deviation from the mid price, as a fraction
+	SpreadBps   float64 // Bid-ask spread of every book, in basis points
+	FundingRate float64 // Maximum funding rate magnitude, drawn per symbol and exchange
+	Seed        uint64  // Seed of the random walk, so runs can be reproduced
+}
+
+// Divergence shifts the prices of a symbol on one exchange away from the other, forcing a spread.
+type Divergence struct {
+	Symbol          string   `json:"symbol"`                 // Unified symbol, e.g., "BTC/USDT:PERP"
+	Exchange        string   `json:"exchange"`               // Binance or Mexc
+	OffsetPct       float64  `json:"offset_pct"`             // Shift of the exchange's bid and ask, in percent
+	FundingRate     *float64 `json:"funding_rate,omitempty"` // Replaces the exchange's funding rate while in effect
+	AfterSeconds    float64  `json:"after_seconds"`          // Delay from the start of a script; ignored when forced
+	DurationSeconds float64  `json:"duration_seconds"`       // How long it lasts; 0 until cleared
+}
+
+// Active is a divergence in effect.
+type Active struct {
+	Divergence
+	Until time.Time `json:"until,omitzero"` // Zero until cleared
+}
+
+// symbol is the state of one synthetic market.
+type symbol struct {
+	base, quote string
+	mid         float64
+	deviation   map[string]float64 // Exchange -> fractional deviation from mid
+	funding     map[string]float64 // Exchange -> funding rate
+}
+
+// Simulator is a synthetic market. It is safe for concurrent use.
+type Simulator struct {
+	mu          sync.Mutex
+	opts        Options
+	rng         *rand.Rand
+	symbols     []*symbol
+	divergences []Active
+}
+
+// New creates a synthetic market.
+func New(opts Options) *Simulator {
+	s := &Simulator{opts: opts, rng: rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x5eed))}
+	s.addSymbol("BTC", 65_000)
+	s.addSymbol("ETH", 3_200)
+	for i := range opts.Symbols {
+		s.addSymbol(fmt.Sprintf("SIM%d", i), 0.01+s.rng.Float64()*100)
+	}
+	s.Step()
+	return s
+}
+
+func (s *Simulator) addSymbol(base string, mid float64) {
+	sym := &symbol{base: base, quote: "USDT", mid: mid, deviation: make(map[string]float64), funding: make(map[string]float64)}
+	for _, exchange := range Exchanges {
+		sym.funding[exchange] = (s.rng.Float64()*2 - 1) * s.opts.FundingRate
+	}
+	s.symbols = append(s.symbols, sym)
+}
+
+// Step moves every mid price one step of the random walk and redraws the exchanges' deviations from it.
+func (s *Simulator) Step() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sym := range s.symbols {
+		sym.mid *= math.Exp(s.rng.NormFloat64() * s.opts.Volatility)
+		for _, exchange := range Exchanges {
+			sym.deviation[exchange] = s.rng.NormFloat64() * s.opts.Noise
+		}
+	}
+}
+
+// List adds a USDT perpetual of base trading around price to every exchange.
+func (s *Simulator) List(base string, price float64) error {
+	if base == "" || price <= 0 {
+		return fmt.Errorf("listing needs a base and a positive price, got %q at %v", base, price)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.find(base+"/USDT:PERP") != nil {
+		return fmt.Errorf("symbol %q is already listed", base+"/USDT:PERP")
+	}
+	s.addSymbol(base, price)
+	return nil
+}
+
+// Diverge puts a divergence in effect from now, replacing any of the same symbol and exchange.
+func (s *Simulator) Diverge(d Divergence, now time.Time) error {
+	if !slices.Contains(Exchanges, d.Exchange) {
+		return fmt.Errorf("unknown exchange %q", d.Exchange)
+	}
+	if s.find(d.Symbol) == nil {
+		return fmt.Errorf("unknown symbol %q", d.Symbol)
+	}
+	if d.DurationSeconds < 0 {
+		return fmt.Errorf("negative duration %v", d.DurationSeconds)
+	}
+	a := Active{Divergence: d}
+	if d.DurationSeconds > 0 {
+		a.Until = now.Add(time.Duration(d.DurationSeconds * float64(time.Second)))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.divergences = slices.DeleteFunc(s.divergences, func(x Active) bool {
+		return x.Symbol == d.Symbol && x.Exchange == d.Exchange
+	})
+	s.divergences = append(s.divergences, a)
+	return nil
+}
+
+// Clear ends every divergence.
+func (s *Simulator) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.divergences = nil
+}
+
+// Divergences returns the divergences in effect at now.
+func (s *Simulator) Divergences(now time.Time) []Active {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(now)
+	return slices.Clone(s.divergences)
+}
+
+// expire drops the divergences that have ended. s.mu must be held.
+func (s *Simulator) expire(now time.Time) {
+	s.divergences = slices.DeleteFunc(s.divergences, func(a Active) bool {
+		return !a.Until.IsZero() && !now.Before(a.Until)
+	})
+}
+
+// find returns the symbol with a unified symbol, or nil.
+func (s *Simulator) find(unifiedSymbol string) *symbol {
+	for _, sym := range s.symbols {
+		if sym.unified() == unifiedSymbol {
+			return sym
+		}
+	}
+	return nil
+}
+
+// unified returns the unified perpetual symbol of the symbol, e.g., "BTC/USDT:PERP".
+func (sym *symbol) unified() string {
+	return shared.PerpSymbol(sym.base, sym.quote).String()
+}
+
+// quote is the book and funding of a symbol on an exchange.
+type quote struct {
+	Base, Quote string
+	Bid, Ask    float64
+	FundingRate float64
+}
+
+// quotes returns the book and funding of every symbol on an exchange at now, divergences applied.
+func (s *Simulator) quotes(exchange string, now time.Time) []quote {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(now)
+
+	quotes := make([]quote, 0, len(s.symbols))
+	for _, sym := range s.symbols {
+		shift := sym.deviation[exchange]
+		funding := sym.funding[exchange]
+		for _, d := range s.divergences {
+			if d.Exchange == exchange && d.Symbol == sym.unified() {
+				shift += d.OffsetPct / 100
+				if d.FundingRate != nil {
+					funding = *d.FundingRate
+				}
+			}
+		}
+		mid := sym.mid * (1 + shift)
+		half := mid * s.opts.SpreadBps / 20_000
+		quotes = append(quotes, quote{Base: sym.base, Quote: sym.quote, Bid: mid - half, Ask: mid + half, FundingRate: funding})
+	}
+	return quotes
+}
+
+// LoadScript reads a script of divergences from a JSON file: an array of divergences, each put in effect
+// AfterSeconds from the start of the script.
+func LoadScript(path string) ([]Divergence, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read simulator script: %w", err)
+	}
+	var script []Divergence
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse simulator script: %w", err)
+	}
+	return script, nil
+}
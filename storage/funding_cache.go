@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// FundingCache persists an exchange's funding rates in Redis, one JSON value per unified symbol under
+// "<prefix><unified symbol>", so they survive restarts. Entries expire after the TTL.
+type FundingCache[T any] struct {
+	client *redis.Client
+	prefix string // e.g., "binance:funding_rate:"
+	ttl    time.Duration
+}
+
+// NewFundingCache creates a funding rate cache for one exchange on a shared client.
+func NewFundingCache[T any](client *redis.Client, prefix string, ttl time.Duration) *FundingCache[T] {
+	return &FundingCache[T]{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Load returns the cached funding rates keyed by unified symbol. Unreadable entries are skipped.
+func (c *FundingCache[T]) Load(ctx context.Context) (map[string]T, error) {
+	rates := make(map[string]T)
+	iter := c.client.Scan(ctx, 0, c.prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		val, err := c.client.Get(ctx, key).Bytes()
+		if err != nil {
+			slog.Warn("Failed to get funding rate from Redis", "key", key, "error", err)
+			continue
+		}
+		var rate T
+		if err := json.Unmarshal(val, &rate); err != nil {
+			slog.Warn("Failed to unmarshal funding rate from Redis", "key", key, "error", err)
+			continue
+		}
+		rates[strings.TrimPrefix(key, c.prefix)] = rate
+	}
+	if err := iter.Err(); err != nil {
+		return rates, fmt.Errorf("failed to scan Redis keys %s*: %w", c.prefix, err)
+	}
+	return rates, nil
+}
+
+// Save writes the funding rates keyed by unified symbol in one pipeline, refreshing their TTL.
+func (c *FundingCache[T]) Save(ctx context.Context, rates map[string]T) error {
+	pipe := c.client.Pipeline()
+	for unifiedSymbol, rate := range rates {
+		val, err := json.Marshal(rate)
+		if err != nil {
+			slog.Error("Failed to marshal funding rate for Redis", "symbol", unifiedSymbol, "error", err)
+			continue
+		}
+		pipe.Set(ctx, c.prefix+unifiedSymbol, val, c.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save funding rates to Redis: %w", err)
+	}
+	return nil
+}
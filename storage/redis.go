@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// NewRedisClient creates a client for the shared Redis instance and verifies the connection.
+// The address is read from the REDIS_ADDR environment variable (redis:6379 by default) and the password from
+// REDIS_PASSWORD.
+func NewRedisClient() (*redis.Client, error) {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "redis:6379" // Default to the docker-compose service if not set
+	}
+	redisPassword := os.Getenv("REDIS_PASSWORD")
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     redisAddr, // Redis host and port
+		Password: redisPassword,
+		DB:       0, // default DB
+	})
+
+	// Ping Redis to check connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := redisClient.Ping(ctx).Result()
+	if err != nil {
+		redisClient.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	slog.Info("Connected to Redis successfully.")
+
+	return redisClient, nil
+}
@@ -0,0 +1,169 @@
+package testsupport
+
+import (
+	"cex-price-diff-notifications/adapters"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Contract describes an exchange adapter to CheckAdapter.
+type Contract struct {
+	Server      *Server                                                  // Fake exchange the adapter talks to
+	TickersPath string                                                   // Path of the tickers endpoint on Server
+	Tickers     func(ctx context.Context) ([]shared.TickerBidAsk, error) // Fetches the tickers and unifies them
+	Want        map[string]string                                        // Exchange symbol -> unified symbol of the tickers the canned response must yield
+	Malformed   []string                                                 // Exchange-specific malformed bodies, on top of the common ones
+}
+
+// malformed are bodies every adapter must reject: truncated, not JSON, and JSON of the wrong shape.
+var malformed = []string{
+	`[{"symbol":"BTCUSDT","bidPrice":"65000`,
+	`<html>Service Unavailable</html>`,
+	`{"chaos":`,
+	`"tickers"`,
+}
+
+// CheckAdapter checks that an adapter meets the contract of every exchange adapter, against its fake server with
+// the canned tickers response:
+//   - The canned tickers yield exactly the wanted unified symbols, with positive prices; unsupported markets
+//     and tickers that don't parse are skipped without failing the others.
+//   - A client error status is returned as an error, and not retried, except 429 which is retried.
+//   - A server error status is retried, then returned as an error.
+//   - Truncated, non-JSON and wrongly shaped payloads are returned as errors.
+//   - Every error is classified with the adapter error code of its cause; exchange-specific malformed payloads
+//     only need a code.
+//
+// It returns the violations joined, or nil. The canned response is restored before returning.
+func CheckAdapter(ctx context.Context, c Contract) error {
+	canned, ok := c.Server.Response(c.TickersPath)
+	if !ok {
+		return fmt.Errorf("%s has no canned response at %s", c.Server.Name, c.TickersPath)
+	}
+	defer c.Server.Handle(c.TickersPath, canned)
+
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{c.Server.Name}, args...)...))
+	}
+	fetch := func() ([]shared.TickerBidAsk, error) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		return c.Tickers(ctx)
+	}
+
+	// Canned tickers
+	tickers, err := fetch()
+	if err != nil {
+		fail("canned tickers failed: %v", err)
+	}
+	got := make(map[string]bool, len(tickers))
+	for _, t := range tickers {
+		got[t.Symbol] = true
+		want, ok := c.Want[t.Symbol]
+		switch {
+		case !ok:
+			fail("unexpected ticker %s (%s), it should have been skipped", t.Symbol, t.UnifiedSymbol)
+		case t.UnifiedSymbol != want:
+			fail("ticker %s unwrapped to %s, want %s", t.Symbol, t.UnifiedSymbol, want)
+		}
+		if t.Bid <= 0 || t.Ask <= 0 {
+			fail("ticker %s has bid %v and ask %v, want positive prices", t.Symbol, t.Bid, t.Ask)
+		}
+	}
+	for symbol := range c.Want {
+		if !got[symbol] {
+			fail("missing ticker %s", symbol)
+		}
+	}
+
+	// Error statuses
+	statuses := []struct {
+		status int
+		code   adapters.ErrorCode
+	}{
+		{http.StatusBadRequest, adapters.CodeRejected},
+		{http.StatusUnauthorized, adapters.CodeAuthFailed},
+		{http.StatusTooManyRequests, adapters.CodeRateLimited},
+		{http.StatusInternalServerError, adapters.CodeExchangeDown},
+	}
+	for _, s := range statuses {
+		c.Server.Handle(c.TickersPath, Response{Status: s.status, Body: `{"code":-1,"msg":"error"}`})
+		before := c.Server.Requests(c.TickersPath)
+		_, err := fetch()
+		if err == nil {
+			fail("status %d was not returned as an error", s.status)
+		} else if code := adapters.CodeOf(err); code != s.code {
+			fail("status %d was classified %q, want %q", s.status, code, s.code)
+		}
+		attempts := c.Server.Requests(c.TickersPath) - before
+		retried := s.status == http.StatusTooManyRequests || s.status >= 500
+		if !retried && attempts != 1 {
+			fail("status %d was requested %d times, want once", s.status, attempts)
+		}
+		if retried && attempts < 2 {
+			fail("status %d was not retried", s.status)
+		}
+	}
+
+	// Malformed payloads
+	for i, body := range append(malformed, c.Malformed...) {
+		c.Server.Handle(c.TickersPath, Response{Body: body})
+		tickers, err := fetch()
+		switch code := adapters.CodeOf(err); {
+		case err == nil:
+			fail("malformed payload %q was accepted with %d tickers", body, len(tickers))
+		case i < len(malformed) && code != adapters.CodeParseError:
+			fail("malformed payload %q was classified %q, want %q", body, code, adapters.CodeParseError)
+		case code == "":
+			fail("malformed payload %q was not classified", body)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// BinanceContract returns the contract of a Binance adapter talking to a fake Binance server.
+func BinanceContract(a *adapters.BinanceAdapter, s *Server) Contract {
+	return Contract{
+		Server:      s,
+		TickersPath: BinanceBookTickerPath,
+		Tickers: func(ctx context.Context) ([]shared.TickerBidAsk, error) {
+			dtos, _, err := a.GetTickers(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			return a.Tickers(dtos), nil
+		},
+		Want: map[string]string{
+			"BTCUSDT": "BTC/USDT:PERP",
+			"ETHUSDC": "ETH/USDC:PERP",
+		},
+	}
+}
+
+// MexcContract returns the contract of a Mexc adapter talking to a fake Mexc server.
+func MexcContract(a *adapters.MexcAdapter, s *Server) Contract {
+	return Contract{
+		Server:      s,
+		TickersPath: MexcTickersPath,
+		Tickers: func(ctx context.Context) ([]shared.TickerBidAsk, error) {
+			dtos, _, err := a.GetTickers(ctx, nil)
+			if err != nil {
+				return nil, err
+			}
+			return a.Tickers(dtos), nil
+		},
+		Want: map[string]string{
+			"BTC_USDT": "BTC/USDT:PERP",
+			"ETH_USDC": "ETH/USDC:PERP",
+		},
+		Malformed: []string{
+			`{"success":false,"code":510,"data":[]}`,
+			`{"success":true,"code":0,"data":{"symbol":"BTC_USDT"}}`,
+		},
+	}
+}
@@ -0,0 +1,140 @@
+// Package testsupport provides fake exchange servers with canned, configurable responses, and the contract every
+// exchange adapter must meet, so adapters can be exercised without the live APIs.
+package testsupport
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Response is a canned response of a fake exchange.
+type Response struct {
+	Status int           // 200 if 0
+	Body   string        // Sent as JSON
+	Delay  time.Duration // Wait before responding, e.g., to trigger timeouts
+	Gzip   bool          // Compress the body when the client accepts gzip
+}
+
+// Server is a fake exchange API answering each path with its canned response, and 404 for the others.
+// It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	Name  string
+	Hosts []string // API hosts of the exchange the server stands in for
+
+	mu        sync.Mutex
+	responses map[string]Response // Path -> response
+	requests  map[string]int      // Path -> number of requests
+}
+
+// NewServer starts a fake exchange standing in for hosts, with canned responses keyed by path. It must be
+// closed when done.
+func NewServer(name string, hosts []string, responses map[string]Response) *Server {
+	s := &Server{
+		Name:      name,
+		Hosts:     hosts,
+		responses: make(map[string]Response, len(responses)),
+		requests:  make(map[string]int),
+	}
+	for path, r := range responses {
+		s.responses[path] = r
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Handle sets the response of a path, replacing the canned one.
+func (s *Server) Handle(path string, r Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[path] = r
+}
+
+// Response returns the response of a path, and whether there is one.
+func (s *Server) Response(path string) (Response, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.responses[path]
+	return r, ok
+}
+
+// Requests returns the number of requests made to a path.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	s.requests[req.URL.Path]++
+	r, ok := s.responses[req.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		r = Response{Status: http.StatusNotFound, Body: `{"code":-1,"msg":"not found"}`}
+	}
+
+	if r.Delay > 0 {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-time.After(r.Delay):
+		}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !r.Gzip || !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Length", strconv.Itoa(len(r.Body)))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(r.Body))
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.WriteHeader(status)
+	zw := gzip.NewWriter(w)
+	_, _ = zw.Write([]byte(r.Body))
+	_ = zw.Close()
+}
+
+// Transport routes the requests to the hosts of the servers to them, and fails the others. Pass it to
+// adapters.SetTransport so the adapters talk to the fake exchanges.
+func Transport(servers ...*Server) http.RoundTripper {
+	t := &transport{servers: make(map[string]*url.URL)}
+	for _, s := range servers {
+		target, err := url.Parse(s.URL)
+		if err != nil {
+			panic(fmt.Sprintf("testsupport: invalid URL of the %s server: %v", s.Name, err))
+		}
+		for _, host := range s.Hosts {
+			t.servers[host] = target
+		}
+	}
+	return t
+}
+
+type transport struct {
+	servers map[string]*url.URL // Host -> fake server
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, ok := t.servers[req.URL.Hostname()]
+	if !ok {
+		return nil, fmt.Errorf("testsupport: no fake server for host %s", req.URL.Hostname())
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme = target.Scheme
+	req.URL.Host = target.Host
+	req.Host = target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,71 @@
+package testsupport
+
+// Paths of the fake Binance futures API.
+const (
+	BinanceBookTickerPath   = "/fapi/v1/ticker/bookTicker"
+	BinancePremiumIndexPath = "/fapi/v1/premiumIndex"
+	BinanceFundingInfoPath  = "/fapi/v1/fundingInfo"
+	Binance24hrTickerPath   = "/fapi/v1/ticker/24hr"
+	BinancePingPath         = "/fapi/v1/ping"
+)
+
+// Paths of the fake Mexc futures API.
+const (
+	MexcTickersPath     = "/api/v1/contract/ticker"
+	MexcFundingRatePath = "/api/v1/contract/funding_rate/" // Followed by the Mexc symbol
+	MexcPingPath        = "/api/v1/contract/ping"
+)
+
+// Canned Binance responses. The book tickers hold a market with an unsupported quote and one with an
+// unparsable price, which adapters must skip.
+const (
+	BinanceBookTickers = `[
+{"symbol":"BTCUSDT","bidPrice":"65000.10","bidQty":"1.500","askPrice":"65000.20","askQty":"2.000","time":1760000000000},
+{"symbol":"ETHUSDC","bidPrice":"3200.50","bidQty":"10.000","askPrice":"3200.60","askQty":"12.000","time":1760000000000},
+{"symbol":"BTCEUR","bidPrice":"60000.00","bidQty":"1.000","askPrice":"60001.00","askQty":"1.000","time":1760000000000},
+{"symbol":"XRPUSDT","bidPrice":"n/a","bidQty":"0","askPrice":"0.5200","askQty":"100","time":1760000000000}
+]`
+	BinancePremiumIndex = `[
+{"symbol":"BTCUSDT","lastFundingRate":"0.00010000","nextFundingTime":1760025600000},
+{"symbol":"ETHUSDC","lastFundingRate":"-0.00005000","nextFundingTime":1760025600000}
+]`
+	BinanceFundingInfo = `[
+{"symbol":"ETHUSDC","fundingIntervalHours":4}
+]`
+	Binance24hrTickers = `[
+{"symbol":"BTCUSDT","quoteVolume":"12000000000.00"},
+{"symbol":"ETHUSDC","quoteVolume":"350000000.00"}
+]`
+)
+
+// Canned Mexc responses. The tickers hold a market with an unsupported quote, which adapters must skip.
+const (
+	MexcTickers = `{"success":true,"code":0,"data":[
+{"symbol":"BTC_USDT","bid1":65000.3,"ask1":65000.4,"amount24":9500000000,"timestamp":1760000000000,"fundingRate":0.0001},
+{"symbol":"ETH_USDC","bid1":3200.4,"ask1":3200.7,"amount24":120000000,"timestamp":1760000000000,"fundingRate":-0.00002},
+{"symbol":"BTC_EUR","bid1":60000,"ask1":60002,"amount24":1000000,"timestamp":1760000000000,"fundingRate":0}
+]}`
+	MexcFundingRateBTC = `{"success":true,"code":0,"data":{"symbol":"BTC_USDT","fundingRate":0.0001,"nextSettleTime":1760025600000,"collectCycle":8}}`
+	MexcFundingRateETH = `{"success":true,"code":0,"data":{"symbol":"ETH_USDC","fundingRate":-0.00002,"nextSettleTime":1760025600000,"collectCycle":8}}`
+)
+
+// NewBinance starts a fake Binance futures and spot API with the canned responses.
+func NewBinance() *Server {
+	return NewServer("Binance", []string{"fapi.binance.com", "api.binance.com"}, map[string]Response{
+		BinanceBookTickerPath:   {Body: BinanceBookTickers, Gzip: true},
+		BinancePremiumIndexPath: {Body: BinancePremiumIndex},
+		BinanceFundingInfoPath:  {Body: BinanceFundingInfo},
+		Binance24hrTickerPath:   {Body: Binance24hrTickers, Gzip: true},
+		BinancePingPath:         {Body: `{}`},
+	})
+}
+
+// NewMexc starts a fake Mexc futures and spot API with the canned responses.
+func NewMexc() *Server {
+	return NewServer("Mexc", []string{"contract.mexc.com", "api.mexc.com"}, map[string]Response{
+		MexcTickersPath:                  {Body: MexcTickers, Gzip: true},
+		MexcFundingRatePath + "BTC_USDT": {Body: MexcFundingRateBTC},
+		MexcFundingRatePath + "ETH_USDC": {Body: MexcFundingRateETH},
+		MexcPingPath:                     {Body: `{"success":true,"code":0,"data":1760000000000}`},
+	})
+}
@@ -0,0 +1,65 @@
+// Package tracing exports OpenTelemetry traces of the scan cycles over OTLP/HTTP. Until Setup is called with an
+// endpoint, spans are no-ops.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans of this repository among those of its dependencies.
+const instrumentationName = "cex-price-diff-notifications"
+
+// Options configures the exporter.
+type Options struct {
+	Endpoint    string  // OTLP/HTTP collector URL, e.g., "http://localhost:4318"; empty disables tracing
+	ServiceName string  // service.name of the traces
+	InstanceID  string  // service.instance.id of the traces, if not empty
+	SampleRatio float64 // Fraction of the traces kept, from 0 to 1
+}
+
+// Setup installs the global tracer provider exporting to opts.Endpoint. The returned function flushes the
+// pending spans and stops the exporter; it is a no-op when tracing is disabled.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("service.name", opts.ServiceName)}
+	if opts.InstanceID != "" {
+		attrs = append(attrs, attribute.String("service.instance.id", opts.InstanceID))
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attrs...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span, a child of the span in ctx if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends a span, marking it as failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
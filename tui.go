@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/shared"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// ANSI escape sequences of the terminal UI.
+const (
+	ansiReset       = "\x1b[0m"
+	ansiBold        = "\x1b[1m"
+	ansiDim         = "\x1b[2m"
+	ansiRed         = "\x1b[31m"
+	ansiGreen       = "\x1b[32m"
+	ansiYellow      = "\x1b[33m"
+	ansiBoldGreen   = "\x1b[1;32m"
+	ansiHome        = "\x1b[H"
+	ansiClearScreen = "\x1b[2J"
+	ansiClearToEnd  = "\x1b[J"
+	ansiClearLine   = "\x1b[K"
+	ansiAltScreen   = "\x1b[?1049h"
+	ansiMainScreen  = "\x1b[?1049l"
+	ansiHideCursor  = "\x1b[?25l"
+	ansiShowCursor  = "\x1b[?25h"
+)
+
+// tuiFundingRefresh is how often the terminal UI refreshes funding rates, like the scanner does.
+const tuiFundingRefresh = 10 * time.Minute
+
+// runTUI refreshes the market every interval and redraws a table of the best spreads, with the status of
+// each exchange and the funding of both legs, until interrupted.
+func runTUI(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	count := fs.Int("n", 25, "number of spreads to show")
+	minEntry := fs.Float64("min", cfg.MinEntrySpreadPct, "minimum entry spread, in percent")
+	hot := fs.Float64("hot", 1, "entry spread, in percent, from which rows are highlighted")
+	interval := fs.Duration("interval", 5*time.Second, "refresh interval")
+	withFunding := fs.Bool("funding", true, "fetch funding rates (refreshed every 10 minutes)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	// Logs would scroll the screen away; fetch errors are shown in the exchange status instead
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	out := bufio.NewWriter(os.Stdout)
+	fmt.Fprint(out, ansiAltScreen, ansiHideCursor, ansiClearScreen)
+	out.Flush()
+	defer func() {
+		fmt.Fprint(out, ansiShowCursor, ansiMainScreen)
+		out.Flush()
+	}()
+
+	m := newMarket(cfg)
+	params := paramSet(cfg)
+	params.Defaults.MinEntrySpreadPct = *minEntry
+	var lastFunding time.Time
+	for cycle := 1; ; cycle++ {
+		start := time.Now()
+		refreshFunding := *withFunding && time.Since(lastFunding) >= tuiFundingRefresh
+		if refreshFunding {
+			lastFunding = start
+		}
+		err := m.fetch(ctx, refreshFunding)
+		if ctx.Err() != nil {
+			return nil // Interrupted mid-fetch
+		}
+		var spreads []arbitrage.Spread
+		var candidates int
+		if err == nil {
+			all := m.spreads(cfg, spreadOptions(cfg))
+			candidates = len(all)
+			spreads, _, _ = arbitrage.SelectForPublishing(all, params, *count)
+		}
+		renderTUI(out, tuiFrame{
+			at:         time.Now(),
+			cycle:      cycle,
+			cycleTime:  time.Since(start),
+			interval:   *interval,
+			exchanges:  exchanges(cfg),
+			minEntry:   *minEntry,
+			hot:        *hot,
+			symbols:    len(m.tickers),
+			candidates: candidates,
+			market:     m,
+			spreads:    spreads,
+			err:        err,
+		})
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// tuiFrame is everything shown on one screen of the terminal UI.
+type tuiFrame struct {
+	at         time.Time
+	cycle      int
+	cycleTime  time.Duration
+	interval   time.Duration
+	minEntry   float64
+	hot        float64
+	symbols    int
+	candidates int
+	market     *market
+	exchanges  []string
+	spreads    []arbitrage.Spread
+	err        error
+}
+
+// renderTUI redraws the screen from the top left, clearing what the previous frame left below.
+func renderTUI(w io.Writer, f tuiFrame) {
+	fmt.Fprint(w, ansiHome)
+	printLine(w, "%sCEX arbitrage%s  %s  cycle %d in %s, every %s  %s(Ctrl+C to quit)%s",
+		ansiBold, ansiReset, f.at.Format(time.TimeOnly), f.cycle, f.cycleTime.Round(time.Millisecond), f.interval, ansiDim, ansiReset)
+
+	for _, exchange := range f.exchanges {
+		st, ok := f.market.status[exchange]
+		switch {
+		case !ok:
+			printLine(w, "  %-8s %s● waiting%s", exchange, ansiDim, ansiReset)
+		case st.err != nil:
+			printLine(w, "  %-8s %s● down%s  %s", exchange, ansiRed, ansiReset, truncate(st.err.Error(), 100))
+		default:
+			printLine(w, "  %-8s %s● up%s    %d tickers in %s", exchange, ansiGreen, ansiReset, st.tickers, st.duration.Round(time.Millisecond))
+		}
+	}
+	printLine(w, "  %d symbols, %d candidate spreads, showing %d at or above %.3f%%", f.symbols, f.candidates, len(f.spreads), f.minEntry)
+	printLine(w, "")
+
+	if f.err != nil {
+		printLine(w, "%sNo market data, retrying in %s%s", ansiRed, f.interval, ansiReset)
+		fmt.Fprint(w, ansiClearToEnd)
+		return
+	}
+
+	// Colors are added after alignment, since tabwriter would count escape sequences as text
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "SYMBOL\tSELL\tBUY\tENTRY %\tEXIT %\tFUNDING SELL\tFUNDING BUY\tFUNDING 8H %\tPNL 24H %\tVOLUME USD\tAGE\t")
+	for _, s := range f.spreads {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.3f\t%.3f\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			s.UnifiedSymbol, s.ExchangeShort, s.ExchangeLong, s.EntrySpread, s.ExitSpread,
+			fundingCell(s.FundingRateShort, s.SecondsToFundingShort), fundingCell(s.FundingRateLong, s.SecondsToFundingLong),
+			optionalPct(s.FundingSpread8h), optionalPct(s.ExpectedPnL24h), compactUSD(s.VolumeUSD), quoteAge(f.at, s.QuoteTime))
+	}
+	tw.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	printLine(w, "%s%s%s", ansiBold, lines[0], ansiReset)
+	for i, line := range lines[1:] {
+		printLine(w, "%s%s%s", spreadColor(f.spreads[i], f.minEntry, f.hot), line, ansiReset)
+	}
+	if len(f.spreads) == 0 {
+		printLine(w, "%sNo spread at or above %.3f%%%s", ansiDim, f.minEntry, ansiReset)
+	}
+	fmt.Fprint(w, ansiClearToEnd)
+}
+
+// printLine writes a line of the screen, clearing what the previous frame left after it.
+func printLine(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, format+ansiClearLine+"\n", args...)
+}
+
+// spreadColor colors a row by its entry spread: bold green from the hot threshold, green from twice the
+// minimum, yellow below. Rows whose expected PnL is negative (funding or fees outweigh the edge) are red.
+func spreadColor(s arbitrage.Spread, minEntry, hot float64) string {
+	switch {
+	case s.ExpectedPnL24h != nil && *s.ExpectedPnL24h < 0:
+		return ansiRed
+	case s.EntrySpread >= hot:
+		return ansiBoldGreen
+	case s.EntrySpread >= 2*minEntry:
+		return ansiGreen
+	default:
+		return ansiYellow
+	}
+}
+
+// fundingCell formats a leg's funding rate, in percent, with the time to its next settlement.
+func fundingCell(info *shared.FundingRateInfo, secondsToFunding *int64) string {
+	if info == nil {
+		return "-"
+	}
+	cell := fmt.Sprintf("%+.4f%%", info.Rate*100)
+	if secondsToFunding != nil {
+		cell += " " + (time.Duration(*secondsToFunding) * time.Second).Truncate(time.Minute).String()
+	}
+	return cell
+}
+
+// compactUSD formats a USD amount with a K, M or B suffix.
+func compactUSD(v float64) string {
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("%.1fB", v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("%.1fM", v/1e6)
+	case v >= 1e3:
+		return fmt.Sprintf("%.1fK", v/1e3)
+	case v > 0:
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return "-"
+	}
+}
+
+// quoteAge formats how old the older leg's ticker was when the frame was drawn.
+func quoteAge(now, quoteTime time.Time) string {
+	if quoteTime.IsZero() {
+		return "-"
+	}
+	return max(now.Sub(quoteTime), 0).Round(100 * time.Millisecond).String()
+}
+
+// truncate shortens s to at most n runes, with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"cex-price-diff-notifications/api"
+	"cex-price-diff-notifications/arbitrage"
+	"cex-price-diff-notifications/config"
+	"cex-price-diff-notifications/lifecycle"
+	"cmp"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Tune objectives.
+const (
+	tuneObjectivePnL       = "pnl"       // Highest simulated PnL in USD
+	tuneObjectivePrecision = "precision" // Highest share of taken trades closed with a profit
+)
+
+// Tune snippet formats.
+const (
+	tuneFormatEnv  = "env"
+	tuneFormatYAML = "yaml"
+)
+
+// tuneTrade is a simulated position, entered when an opportunity opened with a positive expected PnL.
+type tuneTrade struct {
+	entry arbitrage.Spread
+	at    time.Time
+}
+
+// tuneRun replays the recorded spreads with one combination of the swept parameters.
+type tuneRun struct {
+	minEntry float64
+	feeScale float64
+	horizon  time.Duration
+	params   arbitrage.ParamSet // With the minimum entry spread and the scaled fees
+	gate     *lifecycle.Gate
+	tracker  *lifecycle.Tracker
+	open     map[string]tuneTrade // Pair key -> trade
+
+	alerts  int     // Opportunities opened
+	taken   int     // Trades entered
+	wins    int     // Trades closed with a positive PnL
+	pnlPct  float64 // Summed over trades, in percent of the leg notional
+	pnlUSD  float64
+	heldSec float64
+}
+
+// precision returns the share of taken trades closed with a profit, in percent.
+func (r *tuneRun) precision() float64 {
+	if r.taken == 0 {
+		return 0
+	}
+	return float64(r.wins) / float64(r.taken) * 100
+}
+
+// runTune sweeps entry thresholds, fee assumptions and holding horizons over recorded data and reports the
+// combinations that maximize the simulated PnL or the alert precision, with a config snippet of the best one.
+// Each combination replays the data through the publish rules: an opened opportunity is an alert, entered as a
+// trade when its expected PnL over the horizon, with the assumed fees, is positive. Trades are closed when the
+// opportunity closes or the horizon elapses, at the exit spread then, minus the configured (unscaled) fees plus
+// the funding accrued at the entry's rates.
+func runTune(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("tune", flag.ContinueOnError)
+	dir := fs.String("dir", cmp.Or(cfg.RecordDir, cfg.ExportDir), "directory of recordings or JSON lines exports (see RECORD_DIR, EXPORT_DIR and EXPORT_TICKERS)")
+	source := fs.String("source", backtestSourceAuto, "data to replay: recording or tickers (recalculating spreads), spreads, or auto")
+	slippage := fs.Float64("slippage", cfg.SlippageBps, "assumed slippage per leg in basis points, when replaying tickers")
+	minList := fs.String("min", "0.05,0.1,0.2,0.3,0.5", "comma-separated minimum entry spreads to try, in percent")
+	feeList := fs.String("fee-scale", "1,1.5,2", "comma-separated multipliers of the taker fees assumed when entering")
+	horizonList := fs.String("horizon", "1h,8h,24h", "comma-separated holding horizons to try")
+	objective := fs.String("objective", tuneObjectivePnL, "what to maximize: pnl or precision")
+	minTrades := fs.Int("min-trades", 10, "trades a combination needs to be ranked above those with fewer")
+	maxPublished := fs.Int("max", cfg.MaxPublishedSpreads, "maximum spreads published per cycle (0 means unlimited)")
+	hysteresis := fs.Int("hysteresis", cfg.HysteresisCycles, "cycles a spread must stay above the minimum before it is published (0 disables)")
+	exitSpread := fs.Float64("exit", cfg.HysteresisExitSpreadPct, "entry spread, in percent, below which a gated spread is dropped")
+	top := fs.Int("top", 10, "number of combinations to print")
+	format := fs.String("format", tuneFormatEnv, "config snippet format: env or yaml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	src, err := backtestSource(*dir, *source)
+	if err != nil {
+		return err
+	}
+	if *objective != tuneObjectivePnL && *objective != tuneObjectivePrecision {
+		return fmt.Errorf("unknown objective %q, must be pnl or precision", *objective)
+	}
+	if *format != tuneFormatEnv && *format != tuneFormatYAML {
+		return fmt.Errorf("unknown format %q, must be env or yaml", *format)
+	}
+	minEntries, err := parseFloatList("min", *minList)
+	if err != nil {
+		return err
+	}
+	feeScales, err := parseFloatList("fee-scale", *feeList)
+	if err != nil {
+		return err
+	}
+	horizons, err := parseDurationList("horizon", *horizonList)
+	if err != nil {
+		return err
+	}
+
+	settings, err := api.NewRuntimeSettings(api.Settings{BlockedSymbols: cfg.BlockedSymbols})
+	if err != nil {
+		return err
+	}
+	actual := paramSet(cfg)
+	runs := make([]*tuneRun, 0, len(minEntries)*len(feeScales)*len(horizons))
+	for _, feeScale := range feeScales {
+		for _, horizon := range horizons {
+			for _, minEntry := range minEntries {
+				params := scaleFees(actual, feeScale)
+				params.Defaults.MinEntrySpreadPct = minEntry
+				runs = append(runs, &tuneRun{
+					minEntry: minEntry,
+					feeScale: feeScale,
+					horizon:  horizon,
+					params:   params,
+					gate:     lifecycle.NewGate(*hysteresis, *exitSpread),
+					tracker:  lifecycle.NewTracker(cfg.LifecycleMaterialChangeBps),
+					open:     make(map[string]tuneTrade),
+				})
+			}
+		}
+	}
+
+	// closeTrade settles a trade at the pair's latest exit spread
+	lastSeen := make(map[string]arbitrage.Spread) // Pair key -> latest candidate spread
+	closeTrade := func(r *tuneRun, key string, t tuneTrade, at time.Time) {
+		delete(r.open, key)
+		exit, ok := lastSeen[key]
+		if !ok {
+			exit = t.entry
+		}
+		held := at.Sub(t.at)
+		pnl := t.entry.EntrySpread + exit.ExitSpread - actual.For(t.entry.UnifiedSymbol).TakerFeesBps.RoundTripPct(t.entry.ExchangeShort, t.entry.ExchangeLong)
+		if t.entry.FundingSpread8h != nil {
+			pnl += *t.entry.FundingSpread8h * held.Hours() / 8
+		}
+		if pnl > 0 {
+			r.wins++
+		}
+		r.pnlPct += pnl
+		r.pnlUSD += pnl / 100 * t.entry.TargetNotionalUSD
+		r.heldSec += held.Seconds()
+	}
+
+	var (
+		cycles, candidates int
+		first, last        time.Time
+	)
+	replay := func(at time.Time, spreads []arbitrage.Spread) {
+		if first.IsZero() {
+			first = at
+		}
+		last = at
+		cycles++
+		candidates += len(spreads)
+		for _, s := range spreads {
+			lastSeen[s.PairKey()] = s
+		}
+		spreads = slices.DeleteFunc(spreads, func(s arbitrage.Spread) bool { return settings.Blocked(s.UnifiedSymbol) })
+
+		// The expected PnL only depends on the fees and the horizon, shared by the runs of every threshold
+		priced := make(map[[2]float64][]arbitrage.Spread)
+		for _, r := range runs {
+			assumptions := [2]float64{r.feeScale, float64(r.horizon)}
+			pricedSpreads, ok := priced[assumptions]
+			if !ok {
+				pricedSpreads = slices.Clone(spreads)
+				arbitrage.ApplyExpectedPnL(pricedSpreads, r.params, r.horizon)
+				priced[assumptions] = pricedSpreads
+			}
+
+			var eligible []arbitrage.Spread
+			if *hysteresis > 0 {
+				eligible, _ = r.gate.Apply(pricedSpreads, r.params)
+			} else {
+				eligible, _ = arbitrage.SelectAboveMinimum(pricedSpreads, r.params)
+			}
+			events := r.tracker.Update(eligible, at)
+			published, _ := arbitrage.SelectTop(eligible, *maxPublished)
+			for _, e := range r.tracker.Publish(events, published, at) {
+				key := e.Spread.PairKey()
+				switch e.EventType {
+				case lifecycle.EventOpened:
+					r.alerts++
+					if expectedProfit(e.Spread) {
+						r.taken++
+						r.open[key] = tuneTrade{entry: e.Spread, at: at}
+					}
+				case lifecycle.EventClosed:
+					if t, ok := r.open[key]; ok {
+						closeTrade(r, key, t, at)
+					}
+				}
+			}
+			for key, t := range r.open {
+				if at.Sub(t.at) >= r.horizon {
+					closeTrade(r, key, t, at)
+				}
+			}
+		}
+	}
+	if err := replayMarketData(cfg, *dir, src, *slippage, actual, replay); err != nil {
+		return err
+	}
+	if cycles == 0 {
+		return fmt.Errorf("no recorded %s in %s", src, *dir)
+	}
+	// Trades still open at the end are marked at the last exit spreads
+	for _, r := range runs {
+		for key, t := range r.open {
+			closeTrade(r, key, t, last)
+		}
+	}
+
+	// Combinations with too few trades to judge are ranked last
+	enough := func(r *tuneRun) int {
+		if r.taken >= *minTrades {
+			return 1
+		}
+		return 0
+	}
+	slices.SortStableFunc(runs, func(a, b *tuneRun) int {
+		if c := cmp.Compare(enough(b), enough(a)); c != 0 {
+			return c
+		}
+		if *objective == tuneObjectivePrecision {
+			return cmp.Or(cmp.Compare(b.precision(), a.precision()), cmp.Compare(b.pnlUSD, a.pnlUSD))
+		}
+		return cmp.Or(cmp.Compare(b.pnlUSD, a.pnlUSD), cmp.Compare(b.precision(), a.precision()))
+	})
+
+	fmt.Printf("Replayed %d cycles of recorded %s from %s to %s (%s)\n", cycles, src, first.Format(time.RFC3339), last.Format(time.RFC3339), last.Sub(first).Round(time.Second))
+	fmt.Printf("Candidate spreads: %d, combinations tried: %d\n", candidates, len(runs))
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "MIN %\tFEE SCALE\tHORIZON\tALERTS\tTAKEN\tPRECISION %\tPNL %\tPNL USD\tAVG HOLD\t")
+	for i, r := range runs {
+		if *top > 0 && i >= *top {
+			break
+		}
+		avg := "-"
+		if r.taken > 0 {
+			avg = averageDuration(r.heldSec, r.taken).String()
+		}
+		fmt.Fprintf(w, "%g\t%g\t%s\t%d\t%d\t%.1f\t%.3f\t%.2f\t%s\t\n",
+			r.minEntry, r.feeScale, formatHorizon(r.horizon), r.alerts, r.taken, r.precision(), r.pnlPct, r.pnlUSD, avg)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	best := runs[0]
+	fmt.Println()
+	if best.taken < *minTrades {
+		fmt.Printf("No combination reached %d trades, so the best is ranked on fewer (see -min-trades)\n\n", *minTrades)
+	}
+	fmt.Printf("# Best by %s over %s: %d trades, %.1f%% precision, %.2f USD\n", *objective, last.Sub(first).Round(time.Second), best.taken, best.precision(), best.pnlUSD)
+	fees := best.params.Defaults.TakerFeesBps
+	exchanges := slices.Sorted(maps.Keys(fees))
+	if *format == tuneFormatYAML {
+		pairs := make([]string, len(exchanges))
+		for i, exchange := range exchanges {
+			pairs[i] = exchange + ": " + formatNumber(fees[exchange])
+		}
+		fmt.Printf("min_entry_spread_pct: %s\n", formatNumber(best.minEntry))
+		fmt.Printf("taker_fees_bps: {%s}\n", strings.Join(pairs, ", "))
+		fmt.Printf("holding_horizon: %s\n", formatHorizon(best.horizon))
+		return nil
+	}
+	pairs := make([]string, len(exchanges))
+	for i, exchange := range exchanges {
+		pairs[i] = exchange + ":" + formatNumber(fees[exchange])
+	}
+	fmt.Printf("MIN_ENTRY_SPREAD_PCT=%s\n", formatNumber(best.minEntry))
+	fmt.Printf("TAKER_FEES_BPS=%s\n", strings.Join(pairs, ","))
+	fmt.Printf("HOLDING_HORIZON=%s\n", formatHorizon(best.horizon))
+	return nil
+}
+
+// expectedProfit reports whether a spread is expected to be profitable over the horizon after the assumed fees,
+// or without funding when its rates are unknown.
+func expectedProfit(s arbitrage.Spread) bool {
+	if s.ExpectedPnL24h != nil {
+		return *s.ExpectedPnL24h > 0
+	}
+	return s.EntrySpread-s.RoundTripFeesPct > 0
+}
+
+// scaleFees returns params with the default and override taker fees multiplied by scale.
+func scaleFees(params arbitrage.ParamSet, scale float64) arbitrage.ParamSet {
+	scaled := func(fees map[string]float64) map[string]float64 {
+		if fees == nil {
+			return nil
+		}
+		out := make(map[string]float64, len(fees))
+		for exchange, bps := range fees {
+			out[exchange] = bps * scale
+		}
+		return out
+	}
+	params.Defaults.TakerFeesBps = scaled(params.Defaults.TakerFeesBps)
+	overrides := make(map[string]arbitrage.SymbolOverride, len(params.Overrides))
+	for symbol, o := range params.Overrides {
+		o.TakerFeesBps = scaled(o.TakerFeesBps)
+		overrides[symbol] = o
+	}
+	params.Overrides = overrides
+	return params
+}
+
+// parseFloatList parses the comma-separated numbers of a flag.
+func parseFloatList(name, raw string) ([]float64, error) {
+	var values []float64
+	for _, part := range strings.Split(raw, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in -%s", part, name)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// parseDurationList parses the comma-separated positive durations of a flag.
+func parseDurationList(name, raw string) ([]time.Duration, error) {
+	var values []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration %q in -%s", part, name)
+		}
+		values = append(values, d)
+	}
+	return values, nil
+}
+
+// formatHorizon formats a duration without its zero minutes and seconds (e.g., 8h rather than 8h0m0s).
+func formatHorizon(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "h0m0s") {
+		return strings.TrimSuffix(s, "0m0s")
+	}
+	if strings.HasSuffix(s, "m0s") {
+		return strings.TrimSuffix(s, "0s")
+	}
+	return s
+}
+
+// formatNumber formats a number with as few digits as represent it exactly.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,164 @@
+package wallet
+
+import (
+	"cex-price-diff-notifications/shared"
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// StatusFetcher retrieves the deposit/withdrawal status of all assets on a single exchange, keyed by asset.
+type StatusFetcher func(ctx context.Context) (map[string][]shared.AssetNetwork, error)
+
+// Change is a change of the deposit or withdrawal availability of an asset on one network of an exchange. A
+// network an exchange stops listing is reported disabled.
+type Change struct {
+	Exchange           string `json:"exchange"`
+	Asset              string `json:"asset"`
+	Network            string `json:"network"`
+	DepositEnabled     bool   `json:"deposit_enabled"`
+	WithdrawEnabled    bool   `json:"withdraw_enabled"`
+	WasDepositEnabled  bool   `json:"was_deposit_enabled"`
+	WasWithdrawEnabled bool   `json:"was_withdraw_enabled"`
+	DetectedAt         int64  `json:"detected_at"` // Milliseconds since epoch
+}
+
+// ChangeHandler is told the network status changes found by a refresh of an exchange.
+type ChangeHandler func(changes []Change)
+
+// Store caches asset network status per exchange and asset.
+type Store struct {
+	mu       sync.RWMutex
+	status   map[string]map[string][]shared.AssetNetwork // exchange -> asset -> networks
+	fetchers map[string]StatusFetcher
+	onChange ChangeHandler // Nil ignores changes
+}
+
+// NewStore creates an empty wallet status store.
+func NewStore() *Store {
+	return &Store{
+		status:   make(map[string]map[string][]shared.AssetNetwork),
+		fetchers: make(map[string]StatusFetcher),
+	}
+}
+
+// RegisterFetcher sets the wallet status source for an exchange (e.g., "Binance").
+func (s *Store) RegisterFetcher(exchange string, f StatusFetcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetchers[exchange] = f
+}
+
+// OnChange sets the handler of network status changes. The first refresh of an exchange only sets its
+// baseline, so it reports none.
+func (s *Store) OnChange(handle ChangeHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = handle
+}
+
+// Get returns the networks of an asset on an exchange.
+func (s *Store) Get(exchange, asset string) ([]shared.AssetNetwork, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	networks, ok := s.status[exchange][asset]
+	return networks, ok
+}
+
+// Refresh re-fetches the status of all registered exchanges. Exchanges that fail keep their previous status.
+func (s *Store) Refresh(ctx context.Context) {
+	s.mu.RLock()
+	fetchers := make(map[string]StatusFetcher, len(s.fetchers))
+	for exchange, f := range s.fetchers {
+		fetchers[exchange] = f
+	}
+	s.mu.RUnlock()
+
+	for exchange, fetch := range fetchers {
+		status, err := fetch(ctx)
+		if err != nil {
+			slog.Error("Failed to refresh wallet status", "exchange", exchange, "error", err)
+			continue
+		}
+		s.mu.Lock()
+		previous, known := s.status[exchange]
+		s.status[exchange] = status
+		handle := s.onChange
+		s.mu.Unlock()
+		slog.Info("Wallet status refreshed", "exchange", exchange, "assets", len(status))
+
+		if known && handle != nil {
+			if changes := diff(exchange, previous, status, time.Now()); len(changes) > 0 {
+				handle(changes)
+			}
+		}
+	}
+}
+
+// Run refreshes the status immediately and then every interval. It blocks until ctx is cancelled and should be run in a goroutine.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	s.Refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
+
+// diff returns the network status changes of an exchange between two refreshes, sorted by asset and network.
+func diff(exchange string, previous, current map[string][]shared.AssetNetwork, at time.Time) []Change {
+	var changes []Change
+	for asset := range unionKeys(previous, current) {
+		before := byNetwork(previous[asset])
+		after := byNetwork(current[asset])
+		for network := range unionKeys(before, after) {
+			was, now := before[network], after[network]
+			if was.DepositEnabled == now.DepositEnabled && was.WithdrawEnabled == now.WithdrawEnabled {
+				continue
+			}
+			changes = append(changes, Change{
+				Exchange:           exchange,
+				Asset:              asset,
+				Network:            network,
+				DepositEnabled:     now.DepositEnabled,
+				WithdrawEnabled:    now.WithdrawEnabled,
+				WasDepositEnabled:  was.DepositEnabled,
+				WasWithdrawEnabled: was.WithdrawEnabled,
+				DetectedAt:         at.UnixMilli(),
+			})
+		}
+	}
+	slices.SortFunc(changes, func(a, b Change) int {
+		return cmp.Or(cmp.Compare(a.Asset, b.Asset), cmp.Compare(a.Network, b.Network))
+	})
+	return changes
+}
+
+// byNetwork keys an asset's networks by network code.
+func byNetwork(networks []shared.AssetNetwork) map[string]shared.AssetNetwork {
+	m := make(map[string]shared.AssetNetwork, len(networks))
+	for _, n := range networks {
+		m[n.Network] = n
+	}
+	return m
+}
+
+// unionKeys returns the keys of two maps.
+func unionKeys[V any](a, b map[string]V) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
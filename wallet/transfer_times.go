@@ -0,0 +1,185 @@
+package wallet
+
+import (
+	"cex-price-diff-notifications/shared"
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxTransferSamples bounds the past transfers kept per route; the estimate is their median.
+const maxTransferSamples = 20
+
+// Sources of a transfer estimate.
+const (
+	SourceLearned = "learned"
+	SourceConfig  = "config"
+	SourceDefault = "default"
+)
+
+// DefaultTransferTimes are typical times, per network, from a withdrawal request to the deposit being credited.
+var DefaultTransferTimes = map[string]time.Duration{
+	"BTC":   40 * time.Minute,
+	"ETH":   5 * time.Minute,
+	"BSC":   time.Minute,
+	"TRX":   2 * time.Minute,
+	"SOL":   time.Minute,
+	"ARB":   2 * time.Minute,
+	"OP":    2 * time.Minute,
+	"MATIC": 5 * time.Minute,
+	"AVAXC": time.Minute,
+	"TON":   time.Minute,
+}
+
+// HistoryFetcher retrieves the recent withdrawals and deposits of an exchange account.
+type HistoryFetcher func(ctx context.Context) (withdrawals, deposits []shared.Transfer, err error)
+
+// transferSamples are the durations and fees of the past transfers of a route, oldest first.
+type transferSamples struct {
+	durations []time.Duration
+	fees      []float64
+}
+
+// TransferTimes is a knowledge base of how long moving an asset between exchanges takes, and what it costs.
+// Durations are learned from the accounts' transfer history, matching withdrawals on one exchange with
+// deposits on another by transaction ID, and fall back to configured times per network or asset and network.
+type TransferTimes struct {
+	mu         sync.RWMutex
+	configured map[string]time.Duration    // Keyed by network (e.g., "ETH") or asset and network (e.g., "USDT/TRX")
+	fallback   time.Duration               // Duration of networks neither learned nor configured
+	learned    map[string]*transferSamples // Keyed by route
+	seen       map[string]bool             // Transaction IDs already learned
+	fetchers   map[string]HistoryFetcher   // Keyed by exchange
+}
+
+// NewTransferTimes creates a knowledge base with the configured durations, keyed by network or "ASSET/NETWORK",
+// and fallback for anything else.
+func NewTransferTimes(configured map[string]time.Duration, fallback time.Duration) *TransferTimes {
+	return &TransferTimes{
+		configured: configured,
+		fallback:   fallback,
+		learned:    make(map[string]*transferSamples),
+		seen:       make(map[string]bool),
+		fetchers:   make(map[string]HistoryFetcher),
+	}
+}
+
+// RegisterFetcher sets the transfer history source of an exchange (e.g., "Binance").
+func (t *TransferTimes) RegisterFetcher(exchange string, f HistoryFetcher) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fetchers[exchange] = f
+}
+
+// Estimate returns the typical duration and fee of moving asset from one exchange to another over network:
+// the median of past transfers of the route if any, else the configured duration of the asset on the network,
+// of the network, or the fallback.
+func (t *TransferTimes) Estimate(asset, network, from, to string) shared.TransferEstimate {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if samples, ok := t.learned[routeKey(asset, network, from, to)]; ok {
+		return shared.TransferEstimate{
+			Duration:   median(samples.durations),
+			Source:     SourceLearned,
+			Samples:    len(samples.durations),
+			TypicalFee: median(samples.fees),
+		}
+	}
+	if d, ok := t.configured[asset+"/"+network]; ok {
+		return shared.TransferEstimate{Duration: d, Source: SourceConfig}
+	}
+	if d, ok := t.configured[network]; ok {
+		return shared.TransferEstimate{Duration: d, Source: SourceConfig}
+	}
+	return shared.TransferEstimate{Duration: t.fallback, Source: SourceDefault}
+}
+
+// Refresh fetches the transfer history of all registered exchanges and learns the transfers not seen before.
+// Exchanges that fail are skipped until the next refresh.
+func (t *TransferTimes) Refresh(ctx context.Context) {
+	t.mu.RLock()
+	fetchers := make(map[string]HistoryFetcher, len(t.fetchers))
+	for exchange, f := range t.fetchers {
+		fetchers[exchange] = f
+	}
+	t.mu.RUnlock()
+
+	withdrawals := make(map[string][]shared.Transfer)
+	deposits := make(map[string]string) // Transaction ID -> exchange
+	depositTimes := make(map[string]time.Time)
+	for exchange, fetch := range fetchers {
+		w, d, err := fetch(ctx)
+		if err != nil {
+			slog.Error("Failed to fetch transfer history", "exchange", exchange, "error", err)
+			continue
+		}
+		withdrawals[exchange] = w
+		for _, deposit := range d {
+			deposits[deposit.TxID] = exchange
+			depositTimes[deposit.TxID] = deposit.Time
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var learned int
+	for from, transfers := range withdrawals {
+		for _, w := range transfers {
+			to, ok := deposits[w.TxID]
+			if !ok || to == from || t.seen[w.TxID] {
+				continue
+			}
+			duration := depositTimes[w.TxID].Sub(w.Time)
+			if duration <= 0 {
+				continue
+			}
+			t.seen[w.TxID] = true
+			key := routeKey(w.Asset, w.Network, from, to)
+			samples, ok := t.learned[key]
+			if !ok {
+				samples = &transferSamples{}
+				t.learned[key] = samples
+			}
+			samples.durations = append(samples.durations, duration)
+			samples.fees = append(samples.fees, w.Fee)
+			if len(samples.durations) > maxTransferSamples {
+				samples.durations = samples.durations[1:]
+				samples.fees = samples.fees[1:]
+			}
+			learned++
+		}
+	}
+	if learned > 0 {
+		slog.Info("Learned transfer times from history", "transfers", learned, "routes", len(t.learned))
+	}
+}
+
+// Run refreshes the history immediately and then every interval. It blocks until ctx is cancelled and should
+// be run in a goroutine.
+func (t *TransferTimes) Run(ctx context.Context, interval time.Duration) {
+	t.Refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Refresh(ctx)
+		}
+	}
+}
+
+// routeKey identifies the transfers of an asset over a network between two exchanges.
+func routeKey(asset, network, from, to string) string {
+	return asset + "|" + network + "|" + from + "|" + to
+}
+
+// median returns the middle value of values, or the lower of the two middle ones.
+func median[T time.Duration | float64](values []T) T {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted[(len(sorted)-1)/2]
+}
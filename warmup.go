@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// fundingCoverage tells which exchanges have missing or stale funding rates, and holds publishing back at
+// startup until all of them are fresh: before the first poll the Binance rates are empty, and the Mexc rates
+// loaded from Redis may be hours old.
+type fundingCoverage struct {
+	updatedAt map[string]func() time.Time // Exchange -> time of its last funding rate update, zero before the first
+	maxAge    time.Duration
+	timeout   time.Duration // 0 disables the warmup
+	startedAt time.Time
+	warm      bool // Every exchange was fresh once, or the warmup timed out
+}
+
+// newFundingCoverage creates the coverage of the exchanges' funding rates, warming up from now for at most
+// timeout.
+func newFundingCoverage(updatedAt map[string]func() time.Time, maxAge, timeout time.Duration, now time.Time) *fundingCoverage {
+	return &fundingCoverage{
+		updatedAt: updatedAt,
+		maxAge:    maxAge,
+		timeout:   timeout,
+		startedAt: now,
+		warm:      timeout <= 0,
+	}
+}
+
+// stale returns the exchanges whose funding rates were never updated or not for the maximum age, sorted.
+func (c *fundingCoverage) stale(now time.Time) []string {
+	var stale []string
+	for _, exchange := range slices.Sorted(maps.Keys(c.updatedAt)) {
+		if at := c.updatedAt[exchange](); at.IsZero() || now.Sub(at) > c.maxAge {
+			stale = append(stale, exchange)
+		}
+	}
+	return stale
+}
+
+// warmingUp reports whether publishing is still held back, with the exchanges stale this cycle. The warmup ends
+// for good once every exchange is fresh or the timeout elapses; staleness after it only marks the spreads.
+func (c *fundingCoverage) warmingUp(stale []string, now time.Time) bool {
+	if c.warm {
+		return false
+	}
+	switch {
+	case len(stale) == 0:
+		slog.Info("Funding rates fresh on every exchange, publishing", "warmup", now.Sub(c.startedAt).Round(time.Second))
+	case now.Sub(c.startedAt) >= c.timeout:
+		slog.Warn("Funding rates still stale after the warmup, publishing with degraded funding", "stale", stale, "timeout", c.timeout)
+	default:
+		slog.Info("Waiting for fresh funding rates before publishing", "stale", stale)
+		return true
+	}
+	c.warm = true
+	return false
+}